@@ -166,6 +166,22 @@ const (
 	AddonRabbitmqPortName              string = "RABBIT_PORT"
 	AddonRabbitmqPasswordKey           string = "rabbitmq-password"
 	AddonRabbitmqDefaultPort           string = "5672"
+	AddonPostgresqlPasswordKey         string = "postgresql-password"
+	AddonPostgresqlUser                string = "dice"
+	AddonPostgresqlDefaultPort         string = "5432"
+	AddonPostgresqlHostName            string = "POSTGRESQL_HOST"
+	AddonPostgresqlPortName            string = "POSTGRESQL_PORT"
+	AddonPostgresqlUserName            string = "POSTGRESQL_USERNAME"
+	AddonPostgresqlPasswordName        string = "POSTGRESQL_PASSWORD"
+	AddonPostgresqlReplicaHostName     string = "POSTGRESQL_REPLICA_HOST"
+	AddonMongodbPasswordKey            string = "mongodb-password"
+	AddonMongodbUser                   string = "root"
+	AddonMongodbDefaultPort            string = "27017"
+	AddonMongodbHostName               string = "MONGODB_HOST"
+	AddonMongodbPortName               string = "MONGODB_PORT"
+	AddonMongodbUserName               string = "MONGODB_USERNAME"
+	AddonMongodbPasswordName           string = "MONGODB_PASSWORD"
+	AddonMongodbReplicaSetName         string = "MONGODB_REPLICA_SET"
 	AddonKmsKey                        string = "kms_key"
 )
 
@@ -272,6 +288,10 @@ const (
 	AddonMySQL = "mysql"
 	// AddonRedis redis
 	AddonRedis = "redis"
+	// AddonPostgresql postgresql
+	AddonPostgresql = "postgresql"
+	// AddonMongodb mongodb
+	AddonMongodb = "mongodb"
 	//AddonES elasticsearch
 	AddonES = "terminus-elasticsearch"
 	// AddonRocketMQ rocketmq
@@ -550,6 +570,8 @@ type AddonFetchResponseData struct {
 	CustomAddonType string `json:"customAddonType"`
 	// TenantOwner addon 租户owner的 instancerouting id
 	TenantOwner string `json:"tenantOwner"`
+	// Scaling 进行中或最近一次失败的规格变更
+	Scaling *AddonScaleRecord `json:"scaling,omitempty"`
 }
 
 // ReferenceInfo 引用信息
@@ -0,0 +1,131 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"time"
+)
+
+// AddonBackupType addon 备份触发方式
+type AddonBackupType string
+
+const (
+	// AddonBackupScheduled 按备份策略定时触发
+	AddonBackupScheduled AddonBackupType = "SCHEDULED"
+	// AddonBackupManual 手动触发
+	AddonBackupManual AddonBackupType = "MANUAL"
+)
+
+// AddonBackupStatus addon 备份/恢复状态
+type AddonBackupStatus string
+
+const (
+	// AddonBackupPending 等待执行, 恢复时表示新实例创建中
+	AddonBackupPending AddonBackupStatus = "PENDING"
+	// AddonBackupRunning 备份/恢复 job 执行中
+	AddonBackupRunning AddonBackupStatus = "RUNNING"
+	// AddonBackupSuccess 执行成功
+	AddonBackupSuccess AddonBackupStatus = "SUCCESS"
+	// AddonBackupFailed 执行失败
+	AddonBackupFailed AddonBackupStatus = "FAILED"
+)
+
+// AddonBackupStorage 备份文件存放的对象存储
+type AddonBackupStorage struct {
+	// 对象存储地址, 支持 oss 及 minio
+	Endpoint  string `json:"endpoint"`
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"accessKey"`
+	// 仅在创建或更新时传入, 查询时不返回
+	SecretKey string `json:"secretKey,omitempty"`
+	// 备份文件的对象名前缀
+	PathPrefix string `json:"pathPrefix"`
+}
+
+// AddonBackupPolicy addon 实例备份策略
+type AddonBackupPolicy struct {
+	ID uint64 `json:"id"`
+	// addon 实例ID(routing instance id)
+	AddonID   string `json:"addonId"`
+	AddonName string `json:"addonName"`
+	// 标准 cron 表达式, 如 "0 2 * * *"
+	Schedule string `json:"schedule"`
+	// 保留的定时备份份数, 超出的最旧备份会被删除; 手动备份不计入
+	Retention int                `json:"retention"`
+	Storage   AddonBackupStorage `json:"storage"`
+	Enabled   bool               `json:"enabled"`
+	// 最近一次定时备份触发时间
+	LastScheduledAt *time.Time `json:"lastScheduledAt,omitempty"`
+	Creator         string     `json:"creator"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// AddonBackupPolicyRequest 创建或更新 addon 备份策略
+type AddonBackupPolicyRequest struct {
+	Schedule string `json:"schedule"`
+	// 默认 7
+	Retention int                `json:"retention"`
+	Storage   AddonBackupStorage `json:"storage"`
+	Enabled   bool               `json:"enabled"`
+}
+
+// AddonBackup addon 备份记录
+type AddonBackup struct {
+	ID         uint64            `json:"id"`
+	AddonID    string            `json:"addonId"`
+	InstanceID string            `json:"instanceId"`
+	AddonName  string            `json:"addonName"`
+	Type       AddonBackupType   `json:"type"`
+	Status     AddonBackupStatus `json:"status"`
+	Bucket     string            `json:"bucket"`
+	ObjectName string            `json:"objectName"`
+	// 备份文件地址, 仅备份成功时返回
+	URL        string     `json:"url,omitempty"`
+	Message    string     `json:"message,omitempty"`
+	Operator   string     `json:"operator"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// AddonRestoreRequest 将备份恢复到新的 addon 实例
+type AddonRestoreRequest struct {
+	// 新实例名称, 为空时使用 <原名称>-restore-<备份ID>
+	Name string `json:"name"`
+	// 新实例规格, 为空时与原实例一致
+	Plan string `json:"plan"`
+}
+
+// AddonRestore addon 恢复记录
+type AddonRestore struct {
+	ID       uint64 `json:"id"`
+	BackupID uint64 `json:"backupId"`
+	// 备份来源 addon 实例ID
+	SourceAddonID string `json:"sourceAddonId"`
+	// 恢复生成的新 addon 实例ID
+	TargetAddonID string            `json:"targetAddonId"`
+	Status        AddonBackupStatus `json:"status"`
+	Message       string            `json:"message,omitempty"`
+	Operator      string            `json:"operator"`
+	StartedAt     *time.Time        `json:"startedAt,omitempty"`
+	FinishedAt    *time.Time        `json:"finishedAt,omitempty"`
+	CreatedAt     time.Time         `json:"createdAt"`
+}
+
+// AddonBackupHistory addon 实例的备份及恢复历史
+type AddonBackupHistory struct {
+	Backups  []AddonBackup  `json:"backups"`
+	Restores []AddonRestore `json:"restores"`
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"time"
+)
+
+// AddonCredentialRotationStatus addon 凭证轮换状态
+type AddonCredentialRotationStatus string
+
+const (
+	// AddonCredentialRotationRunning 轮换进行中
+	AddonCredentialRotationRunning AddonCredentialRotationStatus = "RUNNING"
+	// AddonCredentialRotationSuccess 轮换完成
+	AddonCredentialRotationSuccess AddonCredentialRotationStatus = "SUCCESS"
+	// AddonCredentialRotationFailed 轮换失败, 详见 message
+	AddonCredentialRotationFailed AddonCredentialRotationStatus = "FAILED"
+)
+
+// AddonCredentialRotationStage addon 凭证轮换阶段
+type AddonCredentialRotationStage string
+
+const (
+	// AddonCredentialRotationApplying 在 addon 上启用新密码
+	AddonCredentialRotationApplying AddonCredentialRotationStage = "APPLYING"
+	// AddonCredentialRotationRestarting 新密码已保存, 重新部署引用 addon 的 runtime, 全部部署成功后进入宽限期
+	AddonCredentialRotationRestarting AddonCredentialRotationStage = "RESTARTING"
+	// AddonCredentialRotationGrace 宽限期内旧凭证仍然有效, 宽限期结束后失效
+	AddonCredentialRotationGrace AddonCredentialRotationStage = "GRACE"
+	// AddonCredentialRotationDone 轮换结束
+	AddonCredentialRotationDone AddonCredentialRotationStage = "DONE"
+)
+
+// AddonCredentialRotateRequest addon 凭证轮换请求
+type AddonCredentialRotateRequest struct {
+	// GracePeriod 旧凭证的宽限期, 单位分钟, 默认 60
+	GracePeriod int `json:"gracePeriod"`
+}
+
+// AddonCredentialRotationRuntime 轮换时重新部署的 runtime
+type AddonCredentialRotationRuntime struct {
+	RuntimeID     uint64 `json:"runtimeId"`
+	RuntimeName   string `json:"runtimeName"`
+	ApplicationID uint64 `json:"applicationId"`
+	AppName       string `json:"applicationName"`
+	DeploymentID  uint64 `json:"deploymentId,omitempty"`
+	// DeploymentStatus 重新部署的 deployment 最近一次检查时的状态
+	DeploymentStatus DeploymentStatus `json:"deploymentStatus,omitempty"`
+	// Error 重新部署失败原因
+	Error string `json:"error,omitempty"`
+}
+
+// AddonCredentialRotation addon 凭证轮换记录
+type AddonCredentialRotation struct {
+	ID uint64 `json:"id"`
+	// addon 实例ID(routing instance id)
+	AddonID    string                        `json:"addonId"`
+	InstanceID string                        `json:"instanceId"`
+	AddonName  string                        `json:"addonName"`
+	Status     AddonCredentialRotationStatus `json:"status"`
+	Stage      AddonCredentialRotationStage  `json:"stage"`
+	// DualPassword 是否使用 mysql 双密码, 否则切换到另一个用户
+	DualPassword bool `json:"dualPassword"`
+	// User 轮换后 runtime 使用的用户
+	User string `json:"user"`
+	// OldUser 切换掉的旧用户, 宽限期结束后删除
+	OldUser string `json:"oldUser,omitempty"`
+	// GracePeriod 旧凭证的宽限期, 单位分钟
+	GracePeriod int `json:"gracePeriod"`
+	// GraceUntil 旧凭证失效时间
+	GraceUntil *time.Time                       `json:"graceUntil,omitempty"`
+	Runtimes   []AddonCredentialRotationRuntime `json:"runtimes"`
+	Message    string                           `json:"message"`
+	Operator   string                           `json:"operator"`
+	FinishedAt *time.Time                       `json:"finishedAt,omitempty"`
+	CreatedAt  time.Time                        `json:"createdAt"`
+	UpdatedAt  time.Time                        `json:"updatedAt"`
+}
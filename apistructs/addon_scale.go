@@ -0,0 +1,68 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"time"
+)
+
+// AddonScaleStatus addon 规格变更状态
+type AddonScaleStatus string
+
+const (
+	// AddonScaleRunning 变更已下发, 等待 addon 恢复健康
+	AddonScaleRunning AddonScaleStatus = "RUNNING"
+	// AddonScaleSuccess 变更成功
+	AddonScaleSuccess AddonScaleStatus = "SUCCESS"
+	// AddonScaleFailed 变更失败, addon 仍为变更前的规格
+	AddonScaleFailed AddonScaleStatus = "FAILED"
+)
+
+// AddonScaleSpec addon 规格及资源
+type AddonScaleSpec struct {
+	// Plan 规格, basic/professional/ultimate
+	Plan string `json:"plan"`
+	// CPU 单节点 cpu 大小
+	CPU float64 `json:"cpu"`
+	// Mem 单节点内存大小, 单位 MB
+	Mem int `json:"mem"`
+	// Replicas 节点数量
+	Replicas int `json:"replicas"`
+}
+
+// AddonPlanScaleRequest 变更 addon 规格或扩缩容请求
+// 指定 Plan 时以该规格的资源为基准, CPU/Mem/Replicas 非零时覆盖对应的值
+type AddonPlanScaleRequest struct {
+	AddonScaleSpec
+}
+
+// AddonScaleRecord addon 规格变更记录
+type AddonScaleRecord struct {
+	ID uint64 `json:"id"`
+	// addon 实例ID(routing instance id)
+	AddonID    string `json:"addonId"`
+	InstanceID string `json:"instanceId"`
+	AddonName  string `json:"addonName"`
+	// From 变更前规格
+	From AddonScaleSpec `json:"from"`
+	// To 变更后规格
+	To       AddonScaleSpec   `json:"to"`
+	Status   AddonScaleStatus `json:"status"`
+	Message  string           `json:"message"`
+	Operator string           `json:"operator"`
+	// FinishedAt 变更结束时间
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
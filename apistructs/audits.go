@@ -216,6 +216,8 @@ const (
 	RepoLockedTemplate   TemplateName = "repoLocked"
 	DeleteTagTemplate    TemplateName = "deleteTag"
 	DeleteBranchTemplate TemplateName = "deleteBranch"
+	// ========================kms=======================================
+	KmsOperationTemplate TemplateName = "kmsOperation"
 )
 
 // AuditTemplateMap 解析前端审计模版全家桶
@@ -21,6 +21,14 @@ const (
 	AndroidCertificateType CertificateType = "Android"
 	IOSCertificateType     CertificateType = "IOS"
 	MessageCertificateType CertificateType = "Message"
+	TLSCertificateType     CertificateType = "TLS"
+)
+
+const (
+	// TLSCertificateSourceManual 手动上传的 TLS 证书
+	TLSCertificateSourceManual = "MANUAL"
+	// TLSCertificateSourceACME 通过 ACME 自动签发的 TLS 证书
+	TLSCertificateSourceACME = "ACME"
 )
 
 // IOSCertificateDTO IOS 证书信息
@@ -80,6 +88,21 @@ type AndroidCertificateAutoDTO struct {
 	ReleaseKeyStore AndroidCertificateKeyStoreDTO `json:"releaseKeyStore"`
 }
 
+// TLSCertificateDTO TLS 证书信息
+type TLSCertificateDTO struct {
+	// Certificate PEM 格式证书链, 第一个为域名证书
+	Certificate string `json:"certificate"`
+	// PrivateKey PEM 格式私钥
+	PrivateKey string `json:"privateKey"`
+	// Source 证书来源, MANUAL 或 ACME
+	Source string `json:"source"`
+	// 以下字段由证书内容解析得出, 无需填写
+	Domains   []string  `json:"domains"`
+	Issuer    string    `json:"issuer"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+}
+
 // CertificateCreateRequest POST /api/certificates 创建证书s请求结构
 type CertificateCreateRequest struct {
 	OrgID       uint64                `json:"orgId"`
@@ -89,6 +112,7 @@ type CertificateCreateRequest struct {
 	AndroidInfo AndroidCertificateDTO `json:"androidInfo"`
 	IOSInfo     IOSCertificateDTO     `json:"iosInfo"`
 	MessageInfo CertificateFileDTO    `json:"messageInfo"`
+	TLSInfo     TLSCertificateDTO     `json:"tlsInfo"`
 }
 
 // CertificateCreateResponse POST /api/certificates 创建证书响应结构
@@ -102,6 +126,8 @@ type CertificateUpdateRequest struct {
 	UUID     string `json:"uuid"`
 	Desc     string `json:"desc"`
 	Filename string `json:"filename"`
+	// TLSInfo 非空时替换 TLS 证书内容, 用于证书续期
+	TLSInfo *TLSCertificateDTO `json:"tlsInfo,omitempty"`
 }
 
 // CertificateUpdateResponse PUT /api/certificates/{certificateId} 更新证书响应结构
@@ -169,6 +195,7 @@ type CertificateDTO struct {
 	AndroidInfo AndroidCertificateDTO `json:"androidInfo"`
 	IOSInfo     IOSCertificateDTO     `json:"iosInfo"`
 	MessageInfo CertificateFileDTO    `json:"messageInfo"`
+	TLSInfo     TLSCertificateDTO     `json:"tlsInfo"`
 	CreatedAt   time.Time             `json:"createdAt"` // Certificate创建时间
 	UpdatedAt   time.Time             `json:"updatedAt"` // Certificate更新时间
 }
@@ -199,6 +226,12 @@ type ApplicationCertificateDTO struct {
 	CreatedAt     time.Time              `json:"createdAt"` // 应用引用Certificate时间
 }
 
+// AppCertificateListResponse GET /api/certificates/actions/list-application-quotes 查询应用引用证书响应
+type AppCertificateListResponse struct {
+	Header
+	Data PagingAppCertificateDTO `json:"data"`
+}
+
 // PagingAppCertificateDTO 查询应用证书响应Body
 type PagingAppCertificateDTO struct {
 	Total int                         `json:"total"`
@@ -0,0 +1,109 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"time"
+)
+
+// DeployFreezeWindowType 部署冻结窗口类型
+type DeployFreezeWindowType string
+
+const (
+	// DeployFreezeOnce 一次性窗口, 如节假日
+	DeployFreezeOnce DeployFreezeWindowType = "ONCE"
+	// DeployFreezeWeekly 每周重复的窗口, 如周五 18:00 至周一 09:00
+	DeployFreezeWeekly DeployFreezeWindowType = "WEEKLY"
+)
+
+// DeployFreezeOverrideStatus 紧急解冻申请状态
+type DeployFreezeOverrideStatus string
+
+const (
+	DeployFreezeOverrideWaitApprove DeployFreezeOverrideStatus = "WaitApprove"
+	DeployFreezeOverrideAccept      DeployFreezeOverrideStatus = "Accept"
+	DeployFreezeOverrideReject      DeployFreezeOverrideStatus = "Reject"
+)
+
+// DeployFreezeWindow 部署冻结窗口
+type DeployFreezeWindow struct {
+	ID uint64 `json:"id"`
+	// 企业ID
+	OrgID uint64 `json:"orgId"`
+	// 项目ID, 0 表示对企业下所有项目生效
+	ProjectID uint64 `json:"projectId"`
+	// 环境, 为空表示对所有环境生效
+	Workspace   string                 `json:"workspace"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Type        DeployFreezeWindowType `json:"type"`
+	// ONCE 类型的起止时间
+	StartAt *time.Time `json:"startAt,omitempty"`
+	EndAt   *time.Time `json:"endAt,omitempty"`
+	// WEEKLY 类型的起止时间, weekday 0 为周日, time 格式为 HH:MM
+	StartWeekday int    `json:"startWeekday"`
+	StartTime    string `json:"startTime"`
+	EndWeekday   int    `json:"endWeekday"`
+	EndTime      string `json:"endTime"`
+	// WEEKLY 类型使用的时区, 默认 Asia/Shanghai
+	Timezone  string    `json:"timezone"`
+	Enabled   bool      `json:"enabled"`
+	Creator   string    `json:"creator"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// DeployFreezeOverride 冻结期间的紧急部署申请, 审批通过后在有效期内允许部署
+type DeployFreezeOverride struct {
+	ID             uint64                     `json:"id"`
+	OrgID          uint64                     `json:"orgId"`
+	ProjectID      uint64                     `json:"projectId"`
+	Workspace      string                     `json:"workspace"`
+	Reason         string                     `json:"reason"`
+	Status         DeployFreezeOverrideStatus `json:"status"`
+	Applicant      string                     `json:"applicant"`
+	Approver       string                     `json:"approver"`
+	ApprovalReason string                     `json:"approvalReason"`
+	StartAt        *time.Time                 `json:"startAt,omitempty"`
+	EndAt          *time.Time                 `json:"endAt,omitempty"`
+	CreatedAt      time.Time                  `json:"createdAt"`
+}
+
+// DeployFreezeOverrideCreateRequest 发起紧急部署申请
+type DeployFreezeOverrideCreateRequest struct {
+	ProjectID uint64 `json:"projectId"`
+	Workspace string `json:"workspace"`
+	// 申请理由, 必填
+	Reason string `json:"reason"`
+	// 审批通过后的有效时长, 单位分钟, 默认 60
+	Duration int64 `json:"duration"`
+}
+
+// DeployFreezeOverrideApproveRequest 审批紧急部署申请
+type DeployFreezeOverrideApproveRequest struct {
+	Reject bool   `json:"reject"`
+	Reason string `json:"reason"`
+}
+
+// DeployFreezeCheckResult 部署冻结检查结果
+type DeployFreezeCheckResult struct {
+	// 是否允许部署
+	Allowed bool `json:"allowed"`
+	// 不允许部署时的原因
+	Reason string `json:"reason,omitempty"`
+	// 命中的冻结窗口
+	Window *DeployFreezeWindow `json:"window,omitempty"`
+	// 生效中的紧急部署申请
+	Override *DeployFreezeOverride `json:"override,omitempty"`
+}
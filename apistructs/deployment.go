@@ -70,6 +70,38 @@ type Deployment struct {
 	RollbackFrom   uint64     `json:"rollbackFrom"`
 }
 
+// DeploymentVerifyMetaKey dice.yml meta 中声明部署后验证配置的 key, 值为 DeploymentVerifyConfig 的 json
+const DeploymentVerifyMetaKey = "POST_DEPLOY_VERIFY"
+
+// DeploymentVerifyConfig 部署后验证配置, 在观察窗口内持续检查服务健康状态和指标,
+// 不达标时部署失败并自动回滚到上一次成功的部署
+type DeploymentVerifyConfig struct {
+	// 观察窗口, 单位秒, 默认 300
+	Window int64 `json:"window"`
+	// 不检查服务健康状态
+	DisableHealthCheck bool `json:"disableHealthCheck"`
+	// 验证不通过时不自动回滚
+	DisableRollback bool `json:"disableRollback"`
+	// 指标检查项
+	Metrics []DeploymentVerifyMetric `json:"metrics"`
+}
+
+// DeploymentVerifyMetric 部署后验证的指标检查项, 通过 monitor 指标查询接口获取观察窗口内的聚合值
+type DeploymentVerifyMetric struct {
+	// 检查项名称, e.g. error_rate
+	Name string `json:"name"`
+	// 指标名, e.g. application_http
+	Metric string `json:"metric"`
+	// 聚合方式, e.g. avg, max, sum, 默认 avg
+	Aggregate string `json:"aggregate"`
+	// 聚合字段, e.g. elapsed_mean
+	Field string `json:"field"`
+	// 过滤条件, 值支持 ${runtime_id} ${application_id} ${workspace} 占位符
+	Filters map[string]string `json:"filters"`
+	// 阈值, 聚合值大于阈值即视为不达标
+	Threshold float64 `json:"threshold"`
+}
+
 type DeploymentDetailListResponse struct {
 	Header
 	UserInfoHeader
@@ -102,6 +134,20 @@ type DeploymentCancelResponse struct {
 	// no data
 }
 
+// DeploymentRolloutRequest 推进/暂停/终止服务的灰度或蓝绿发布
+// POST: /api/deployments/{deploymentID}/actions/rollout
+type DeploymentRolloutRequest struct {
+	// 服务名
+	Service string `json:"service"`
+	// promote, pause 或 abort
+	Action RolloutAction `json:"action"`
+}
+
+type DeploymentRolloutResponse struct {
+	Header
+	Data RolloutStatus `json:"data"`
+}
+
 type DeploymentApproveRequest struct {
 	ID     uint64 `json:"id"`
 	Reject bool   `json:"reject"`
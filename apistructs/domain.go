@@ -46,6 +46,8 @@ type Domain struct {
 	CustomDomain string `json:"customDomain"`
 	RootDomain   string `json:"rootDomain"` // Deprecated
 	UseHttps     bool   `json:"useHttps"`   // Deprecated
+	// Certificate 域名绑定的 TLS 证书, 未绑定时为空
+	Certificate *DomainCertificate `json:"certificate,omitempty"`
 }
 
 type DomainGroup = map[string][]*Domain
@@ -0,0 +1,65 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"time"
+)
+
+// DomainCertificateStatus 域名证书状态
+type DomainCertificateStatus string
+
+const (
+	// DomainCertificateIssuing 证书签发(或续期)中
+	DomainCertificateIssuing DomainCertificateStatus = "ISSUING"
+	// DomainCertificateIssued 证书已签发
+	DomainCertificateIssued DomainCertificateStatus = "ISSUED"
+	// DomainCertificateFailed 签发失败, 详见 message
+	DomainCertificateFailed DomainCertificateStatus = "FAILED"
+)
+
+// DomainCertificateIssueRequest 通过 ACME 为自定义域名签发证书请求
+type DomainCertificateIssueRequest struct {
+	// Domain runtime 的自定义域名
+	Domain string `json:"domain"`
+}
+
+// DomainCertificate 域名绑定的 TLS 证书
+type DomainCertificate struct {
+	ID        uint64 `json:"id"`
+	RuntimeID uint64 `json:"runtimeId"`
+	Domain    string `json:"domain"`
+	// CertificateID 证书服务中的证书 ID, 签发成功后才有值
+	CertificateID uint64                  `json:"certificateId"`
+	Status        DomainCertificateStatus `json:"status"`
+	Issuer        string                  `json:"issuer"`
+	NotBefore     *time.Time              `json:"notBefore,omitempty"`
+	NotAfter      *time.Time              `json:"notAfter,omitempty"`
+	Message       string                  `json:"message"`
+	Operator      string                  `json:"operator"`
+	CreatedAt     time.Time               `json:"createdAt"`
+	UpdatedAt     time.Time               `json:"updatedAt"`
+}
+
+// DomainCertificateIssueResponse 签发域名证书响应
+type DomainCertificateIssueResponse struct {
+	Header
+	Data DomainCertificate `json:"data"`
+}
+
+// DomainCertificateListResponse 查询 runtime 域名证书响应
+type DomainCertificateListResponse struct {
+	Header
+	Data []DomainCertificate `json:"data"`
+}
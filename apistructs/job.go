@@ -79,6 +79,7 @@ type JobFromUser struct {
 	Labels       map[string]string      `json:"labels,omitempty"`
 	Extra        map[string]string      `json:"extra,omitempty"`
 	Env          map[string]string      `json:"env,omitempty"`
+	SecretEnv    map[string]string      `json:"secretEnv,omitempty"` // 敏感的环境变量, k8s job 以 Secret 注入, 不以明文出现在 pod spec 中
 	Binds        []Bind                 `json:"binds,omitempty"`
 	Volumes      []diceyml.Volume       `json:"volumes,omitempty"`
 	Executor     string                 `json:"executor,omitempty"`
@@ -88,6 +89,45 @@ type JobFromUser struct {
 	PreFetcher   *PreFetcher            `json:"preFetcher,omitempty"`
 	BackoffLimit int                    `json:"backoffLimit,omitempty"`
 	Params       map[string]interface{} `json:"params,omitempty"`
+	// Schedule 不为空时 job 按 cron 表达式周期执行
+	Schedule *diceyml.JobSchedule `json:"schedule,omitempty"`
+}
+
+// NewJobFromDiceYml 由 dice.yml 中定义的 job 生成 scheduler job, schedule 一并带上, 使 job 按 cron 周期执行
+func NewJobFromDiceYml(namespace, name string, job *diceyml.Job) (JobFromUser, error) {
+	binds, err := diceyml.ParseBinds(job.Binds)
+	if err != nil {
+		return JobFromUser{}, err
+	}
+	jobBinds := make([]Bind, 0, len(binds))
+	for _, bind := range binds {
+		jobBinds = append(jobBinds, Bind{
+			ContainerPath: bind.ContainerPath,
+			HostPath:      bind.HostPath,
+			ReadOnly:      bind.Type == "ro",
+		})
+	}
+	env := make(map[string]string, len(job.Envs))
+	for k, v := range job.Envs {
+		env[k] = v
+	}
+	labels := make(map[string]string, len(job.Labels))
+	for k, v := range job.Labels {
+		labels[k] = v
+	}
+	return JobFromUser{
+		Name:      name,
+		Namespace: namespace,
+		Image:     job.Image,
+		Cmd:       job.Cmd,
+		CPU:       job.Resources.CPU,
+		Memory:    float64(job.Resources.Mem),
+		Labels:    labels,
+		Env:       env,
+		Binds:     jobBinds,
+		Volumes:   job.Volumes,
+		Schedule:  job.Schedule,
+	}, nil
 }
 
 // PreFetcher 用于 job 下载功能
@@ -135,3 +175,47 @@ type JobBatchResponse struct {
 }
 
 var ErrJobIsRunning = errors.New("job is running")
+
+// CronJobAction 定时 job 的操作类型
+type CronJobAction string
+
+const (
+	// CronJobHistory 查询执行历史
+	CronJobHistory CronJobAction = "history"
+	// CronJobTrigger 立即触发一次执行
+	CronJobTrigger CronJobAction = "trigger"
+	// CronJobSuspend 暂停调度
+	CronJobSuspend CronJobAction = "suspend"
+	// CronJobResume 恢复调度
+	CronJobResume CronJobAction = "resume"
+)
+
+// JobExecution 定时 job 的一次执行记录
+type JobExecution struct {
+	Name        string     `json:"name"`
+	Status      StatusCode `json:"status"`
+	Manual      bool       `json:"manual"` // 是否为手动触发
+	StartTime   int64      `json:"startTime,omitempty"`
+	FinishTime  int64      `json:"finishTime,omitempty"`
+	LastMessage string     `json:"lastMessage,omitempty"`
+}
+
+// CronJobStatus 定时 job 的调度状态及执行历史
+type CronJobStatus struct {
+	Cron             string         `json:"cron"`
+	Suspend          bool           `json:"suspend"`
+	LastScheduleTime int64          `json:"lastScheduleTime,omitempty"`
+	Executions       []JobExecution `json:"executions"` // 按开始时间倒序
+}
+
+// JobSuspendRequest 暂停或恢复定时 job 的请求
+type JobSuspendRequest struct {
+	Suspend bool `json:"suspend"`
+}
+
+// JobCronResponse 定时 job 相关接口的响应
+type JobCronResponse struct {
+	Name    string        `json:"name"`
+	Error   string        `json:"error"`
+	CronJob CronJobStatus `json:"cronJob"`
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func TestNewJobFromDiceYml(t *testing.T) {
+	diceJob := &diceyml.Job{
+		Image:     "busybox",
+		Cmd:       "echo hello",
+		Envs:      diceyml.EnvMap{"A": "a"},
+		Resources: diceyml.Resources{CPU: 0.5, Mem: 256},
+		Binds:     diceyml.Binds{"/host:/data:ro", "/tmp:/tmp"},
+		Schedule:  &diceyml.JobSchedule{Cron: "*/5 * * * *", ConcurrencyPolicy: "Forbid"},
+	}
+	job, err := NewJobFromDiceYml("ns", "cleanup", diceJob)
+	assert.NoError(t, err)
+	assert.Equal(t, "ns", job.Namespace)
+	assert.Equal(t, "cleanup", job.Name)
+	assert.Equal(t, float64(256), job.Memory)
+	assert.Equal(t, map[string]string{"A": "a"}, job.Env)
+	assert.Equal(t, []Bind{
+		{HostPath: "/host", ContainerPath: "/data", ReadOnly: true},
+		{HostPath: "/tmp", ContainerPath: "/tmp"},
+	}, job.Binds)
+	assert.Equal(t, diceJob.Schedule, job.Schedule)
+
+	diceJob.Binds = diceyml.Binds{"invalid"}
+	_, err = NewJobFromDiceYml("ns", "cleanup", diceJob)
+	assert.Error(t, err)
+}
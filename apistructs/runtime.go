@@ -15,6 +15,7 @@ package apistructs
 
 import (
 	"encoding/json"
+	"time"
 )
 
 // Request for API `GET /api/runtimes/{idOrName}`
@@ -97,6 +98,120 @@ type RuntimeReleaseCreateRequest struct {
 	ApplicationID uint64 `json:"applicationId"`
 }
 
+// PreviewRuntimeCreateRequest 基于 merge request 创建预览环境
+type PreviewRuntimeCreateRequest struct {
+	// 制品ID
+	ReleaseID string `json:"releaseId"`
+	// 环境, 默认 DEV
+	Workspace string `json:"workspace"`
+	// 项目ID
+	ProjectID uint64 `json:"projectId"`
+	// 应用ID
+	ApplicationID uint64 `json:"applicationId"`
+	// 仓库内的 merge request ID
+	MergeID uint64 `json:"mergeId"`
+	// 存活时长, 单位小时, 不填使用默认值
+	TTL int64 `json:"ttl"`
+}
+
+// PreviewRuntimeDTO 预览环境信息
+type PreviewRuntimeDTO struct {
+	ID            uint64    `json:"id"`
+	ProjectID     uint64    `json:"projectId"`
+	ApplicationID uint64    `json:"applicationId"`
+	MergeID       uint64    `json:"mergeId"`
+	RuntimeID     uint64    `json:"runtimeId"`
+	ReleaseID     string    `json:"releaseId"`
+	DeploymentID  uint64    `json:"deploymentId,omitempty"`
+	Domains       []string  `json:"domains"`
+	ExpiredAt     time.Time `json:"expiredAt"`
+}
+
+// RuntimeDriftReport runtime 声明的规格与集群中实际运行状态的差异
+type RuntimeDriftReport struct {
+	RuntimeID uint64 `json:"runtimeId"`
+	// Drifted 是否存在差异
+	Drifted   bool                  `json:"drifted"`
+	CheckedAt time.Time             `json:"checkedAt"`
+	Services  []RuntimeServiceDrift `json:"services"`
+}
+
+// RuntimeServiceDrift 单个服务的差异
+type RuntimeServiceDrift struct {
+	Service string `json:"service"`
+	// Missing 集群中找不到该服务
+	Missing bool               `json:"missing"`
+	Diffs   []RuntimeDriftItem `json:"diffs"`
+}
+
+// RuntimeDriftItem 差异项, Field 为 image, replicas, env.<KEY>, cpu, mem, ports.
+// 环境变量可能包含密码等敏感信息, env 差异不返回 Declared 与 Live 的值, 只以 Change 标明差异类型
+type RuntimeDriftItem struct {
+	Field    string             `json:"field"`
+	Declared string             `json:"declared,omitempty"`
+	Live     string             `json:"live,omitempty"`
+	Change   RuntimeDriftChange `json:"change"`
+}
+
+// RuntimeDriftChange 差异类型
+type RuntimeDriftChange string
+
+const (
+	// RuntimeDriftChanged 集群中的值与声明不同
+	RuntimeDriftChanged RuntimeDriftChange = "changed"
+	// RuntimeDriftRemoved 声明的内容在集群中不存在
+	RuntimeDriftRemoved RuntimeDriftChange = "removed"
+)
+
+// RuntimeExportFormat runtime 导出格式
+type RuntimeExportFormat string
+
+const (
+	// RuntimeExportHelm 导出为 helm chart
+	RuntimeExportHelm RuntimeExportFormat = "helm"
+	// RuntimeExportKustomize 导出为可直接 kubectl apply -k 的 manifests
+	RuntimeExportKustomize RuntimeExportFormat = "kustomize"
+)
+
+// RuntimeExportValues runtime 导出后的 helm values, 也作为导入时的输入
+type RuntimeExportValues struct {
+	Name     string                                `json:"name"`
+	Services map[string]RuntimeExportServiceValues `json:"services"`
+	// AddonEnvs addon 连接信息, 导出时以占位符替代, 由使用方在目标集群填写
+	AddonEnvs map[string]string `json:"addonEnvs,omitempty"`
+}
+
+// RuntimeExportServiceValues 单个服务的 helm values
+type RuntimeExportServiceValues struct {
+	Image    string  `json:"image"`
+	Cmd      string  `json:"cmd,omitempty"`
+	Replicas int     `json:"replicas"`
+	CPU      float64 `json:"cpu"`
+	// Mem 单位 MiB
+	Mem   int   `json:"mem"`
+	Ports []int `json:"ports,omitempty"`
+	// Envs dice.yml 中声明的环境变量, 以 ConfigMap 注入
+	Envs map[string]string `json:"envs,omitempty"`
+	// SecretEnvs 来自配置中心的环境变量, 导出时以占位符替代, 以 Secret 注入, 导入时不写入 dice.yml
+	SecretEnvs map[string]string `json:"secretEnvs,omitempty"`
+	// Domains 对外暴露的域名, 指向第一个端口
+	Domains []string `json:"domains,omitempty"`
+}
+
+// RuntimeHelmImportRequest 将 helm values 导入为应用的制品
+type RuntimeHelmImportRequest struct {
+	ApplicationID uint64 `json:"applicationId"`
+	// Values values.yaml 的内容
+	Values string `json:"values"`
+	// Version 制品版本, 可选
+	Version string `json:"version"`
+}
+
+// RuntimeHelmImportResponseData 导入生成的制品
+type RuntimeHelmImportResponseData struct {
+	ReleaseID string `json:"releaseId"`
+}
+
 type RuntimeCreateRequestExtra struct {
 	OrgID           uint64      `json:"orgId,omitempty"`
 	ProjectID       uint64      `json:"projectId,omitempty"`
@@ -64,5 +64,6 @@ const (
 	DeploymentPhaseScript    DeploymentPhase = "SCRIPT_APPLYING"
 	DeploymentPhaseService   DeploymentPhase = "SERVICE_DEPLOYING"
 	DeploymentPhaseRegister  DeploymentPhase = "DISCOVERY_REGISTER"
+	DeploymentPhaseVerify    DeploymentPhase = "POST_DEPLOY_VERIFYING"
 	DeploymentPhaseCompleted DeploymentPhase = "COMPLETED"
 )
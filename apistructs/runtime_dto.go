@@ -124,6 +124,8 @@ type DeploymentStatusDTO struct {
 	// 模块错误信息
 	ModuleErrMsg map[string]string           `json:"lastMessage"`
 	Runtime      *DeploymentStatusRuntimeDTO `json:"runtime"`
+	// 进行中的灰度/蓝绿发布状态, key 为服务名
+	Rollouts map[string]*RolloutStatus `json:"rollouts,omitempty"`
 }
 
 // Deprecated: use RuntimeInspect api to get ServiceGroup Info
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 
 	"github.com/erda-project/erda/pkg/parser/diceyml"
 )
@@ -132,6 +133,33 @@ type ScheduleInfo2 struct {
 	// =DEPRECATED= k8s 中忽略该字段
 	HasProject bool
 	Project    string
+
+	// Spread 服务副本在拓扑域（zone、rack、host）间打散
+	// map[servicename]SpreadPolicy
+	Spread map[string]SpreadPolicy
+	// AntiAffinity 服务不与指定的服务部署在同一拓扑域
+	// map[servicename]AntiAffinityPolicy
+	AntiAffinity map[string]AntiAffinityPolicy
+}
+
+// SpreadPolicy 服务副本打散策略
+type SpreadPolicy struct {
+	// Topology 拓扑域: zone, rack, host 或其他节点拓扑 label
+	Topology string
+	// MaxSkew 任意两个拓扑域间副本数的最大差值
+	MaxSkew int
+	// Required true: 无法满足时不调度; false: 尽量满足
+	Required bool
+}
+
+// AntiAffinityPolicy 服务间反亲和策略
+type AntiAffinityPolicy struct {
+	// Services 不与之部署在同一拓扑域的服务列表
+	Services []string
+	// Topology 拓扑域, 未指定 spread 时为 host
+	Topology string
+	// Required true: 无法满足时不调度; false: 尽量满足
+	Required bool
 }
 
 // ScheduleInfo 之后将完全替换为 ScheduleInfo2
@@ -288,6 +316,22 @@ type Service struct {
 	// WorkLoad indicates the type of service，
 	//support Kubernetes workload DaemonSet(Per-Node), Statefulset and Deployment
 	WorkLoad string `json:"workLoad,omitempty"`
+	// Autoscaling 水平自动伸缩配置, 为空则副本数固定为 Scale
+	Autoscaling *diceyml.Autoscaling `json:"autoscaling,omitempty"`
+	// CurrentReplicas 当前实际副本数, 开启 autoscaling 后可能与 Scale 不同, 仅用于展示
+	CurrentReplicas int `json:"currentReplicas,omitempty"`
+	// CurrentResources 当前实际生效的资源, 目前仅 operator 部署的 addon 返回, 用于确认规格变更已生效
+	CurrentResources *Resources `json:"currentResources,omitempty"`
+	// Strategy 发布策略, 为空则为滚动更新
+	Strategy *diceyml.Strategy `json:"strategy,omitempty"`
+	// Rollout 进行中的灰度/蓝绿发布状态, 仅用于展示
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+	// ConfigFiles 挂载到容器中的配置文件, 内容已由 orchestrator 从配置中心或证书中获取
+	ConfigFiles []diceyml.ConfigFile `json:"configFiles,omitempty"`
+	// DisruptionBudget 驱逐节点等主动中断时允许同时不可用的实例数限制
+	DisruptionBudget *diceyml.DisruptionBudget `json:"disruptionBudget,omitempty"`
+	// Lifecycle 优雅停止配置
+	Lifecycle *diceyml.Lifecycle `json:"lifecycle,omitempty"`
 
 	StatusDesc
 }
@@ -340,10 +384,31 @@ type ExecHealthCheck struct {
 	Duration int `json:"duration,omitempty"`
 }
 
-// 暂定支持"HTTP" 和 "COMMAND"两种方式
+type TCPHealthCheck struct {
+	Port int `json:"port,omitempty"`
+	//单位是秒
+	Duration int `json:"duration,omitempty"`
+}
+
+type GRPCHealthCheck struct {
+	Port int `json:"port,omitempty"`
+	// grpc health checking protocol 中的 service 名, 为空检查整个 server
+	Service string `json:"service,omitempty"`
+	//单位是秒
+	Duration int `json:"duration,omitempty"`
+}
+
+// 支持 "HTTP", "COMMAND", "TCP" 和 "GRPC" 方式
+// LivenessProbe, ReadinessProbe, StartupProbe 为单独配置的探针, 未配置时使用上面的检查方式
 type NewHealthCheck struct {
 	HttpHealthCheck *HttpHealthCheck `json:"http,omitempty"`
 	ExecHealthCheck *ExecHealthCheck `json:"exec,omitempty"`
+	TCPHealthCheck  *TCPHealthCheck  `json:"tcp,omitempty"`
+	GRPCHealthCheck *GRPCHealthCheck `json:"grpc,omitempty"`
+
+	LivenessProbe  *diceyml.Probe `json:"liveness,omitempty"`
+	ReadinessProbe *diceyml.Probe `json:"readiness,omitempty"`
+	StartupProbe   *diceyml.Probe `json:"startup,omitempty"`
 }
 
 type Volume struct {
@@ -493,6 +558,164 @@ type ServiceGroupKillPodResponse struct {
 	Header
 }
 
+/*
+promote, pause or abort the canary / blue-green rollout of service
+POST: /api/servicegroup/actions/rollout
+*/
+type ServiceGroupRolloutRequest struct {
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	Service   string        `json:"service"`
+	Action    RolloutAction `json:"action"`
+}
+type ServiceGroupRolloutResponse struct {
+	Header
+	Data RolloutStatus `json:"data"`
+}
+
+type RolloutAction string
+
+const (
+	// RolloutActionPromote 灰度发布进入下一步(最后一步则全量), 蓝绿发布切换流量
+	RolloutActionPromote RolloutAction = "promote"
+	// RolloutActionPause 暂停灰度发布的自动推进
+	RolloutActionPause RolloutAction = "pause"
+	// RolloutActionAbort 终止发布, 删除新版本, 流量回到旧版本
+	RolloutActionAbort RolloutAction = "abort"
+)
+
+func (a RolloutAction) Valid() bool {
+	switch a {
+	case RolloutActionPromote, RolloutActionPause, RolloutActionAbort:
+		return true
+	}
+	return false
+}
+
+type RolloutPhase string
+
+const (
+	// RolloutPhaseProgressing 灰度发布按步骤自动推进中
+	RolloutPhaseProgressing RolloutPhase = "Progressing"
+	// RolloutPhasePaused 等待手动 promote
+	RolloutPhasePaused RolloutPhase = "Paused"
+	// RolloutPhasePromoting 新版本已接管流量, 正在更新稳定版本
+	RolloutPhasePromoting RolloutPhase = "Promoting"
+	// RolloutPhaseCompleted 发布完成
+	RolloutPhaseCompleted RolloutPhase = "Completed"
+	// RolloutPhaseAborted 发布已终止
+	RolloutPhaseAborted RolloutPhase = "Aborted"
+)
+
+// RolloutStatus 服务的灰度/蓝绿发布状态
+type RolloutStatus struct {
+	// Strategy canary or blue_green
+	Strategy string       `json:"strategy"`
+	Phase    RolloutPhase `json:"phase"`
+	// Step 当前灰度步骤, 从 0 开始
+	Step       int `json:"step"`
+	TotalSteps int `json:"totalSteps"`
+	// Weight 新版本承接流量的百分比
+	Weight int `json:"weight"`
+	// StableReplicas 旧版本副本数
+	StableReplicas int `json:"stableReplicas"`
+	// RolloutReplicas 新版本副本数
+	RolloutReplicas int `json:"rolloutReplicas"`
+	// NextStepAt 自动进入下一步的时间, 为空则需要手动 promote
+	NextStepAt *time.Time `json:"nextStepAt,omitempty"`
+	Message    string     `json:"message,omitempty"`
+}
+
+/*
+bind tls certificate to the ingresses of servicegroup
+PUT: /api/servicegroup/actions/tls
+*/
+// ServiceGroupTLSRequest 将域名证书保存到 servicegroup 所在 namespace 的 secret, 并绑定到使用该域名的服务 ingress
+type ServiceGroupTLSRequest struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Domain    string `json:"domain"`
+	// Certificate PEM 格式证书链
+	Certificate string `json:"certificate"`
+	// PrivateKey PEM 格式私钥
+	PrivateKey string `json:"privateKey"`
+}
+
+type ServiceGroupTLSResponse struct {
+	Header
+}
+
+/*
+live spec of servicegroup in cluster
+GET: /api/servicegroup/actions/live?namespace=<namespace>&name=<name>
+*/
+type ServiceGroupLiveResponse struct {
+	Header
+	Data ServiceGroupLive `json:"data"`
+}
+
+// ServiceGroupLive servicegroup 在集群中实际运行的规格
+type ServiceGroupLive struct {
+	// Supported 为 false 表示 executor 不支持读取实际规格, 如有状态的 servicegroup 或非 k8s 集群
+	Supported bool              `json:"supported"`
+	Services  []ServiceLiveSpec `json:"services"`
+}
+
+// ServiceLiveSpec 集群中服务实际运行的规格, 用于和 servicegroup 中声明的规格比对
+type ServiceLiveSpec struct {
+	Name string `json:"name"`
+	// Missing 集群中找不到该服务对应的工作负载
+	Missing  bool              `json:"missing"`
+	Image    string            `json:"image"`
+	Replicas int               `json:"replicas"`
+	Envs     map[string]string `json:"envs"`
+	// CPU 核数, 取 limits, 为 0 代表未设置
+	CPU float64 `json:"cpu"`
+	// Mem 单位 MiB, 取 limits, 为 0 代表未设置
+	Mem   float64 `json:"mem"`
+	Ports []int   `json:"ports"`
+}
+
+/*
+dry-run networkpolicy of servicegroup
+*/
+type ServiceGroupNetworkPolicyRequest ServiceGroupCreateV2Request
+type ServiceGroupNetworkPolicyResponse struct {
+	Header
+	Data NetworkPolicyGraph `json:"data"`
+}
+
+// NetworkPolicyGraph runtime 内服务间的访问关系, 以及据此生成的 NetworkPolicy
+type NetworkPolicyGraph struct {
+	// Enabled 是否开启了网络隔离, 由 dice.yml meta 中的 NETWORK_POLICY: on 开启
+	Enabled   bool                `json:"enabled"`
+	Namespace string              `json:"namespace"`
+	Edges     []NetworkPolicyEdge `json:"edges"`
+	// Policies 每个服务一个 NetworkPolicy, 未声明的访问都会被拒绝
+	Policies []networkingv1.NetworkPolicy `json:"policies"`
+}
+
+// NetworkPolicyEdge 允许的访问: From 访问 To 的 Ports
+type NetworkPolicyEdge struct {
+	// From 服务名, addon 所在的 namespace, 或者 * 代表任意来源
+	From string                `json:"from"`
+	To   string                `json:"to"`
+	Kind NetworkPolicyEdgeKind `json:"kind"`
+	// Ports 为空代表所有端口
+	Ports []int `json:"ports,omitempty"`
+}
+
+type NetworkPolicyEdgeKind string
+
+const (
+	// NetworkPolicyEdgeDependsOn 来自 depends_on
+	NetworkPolicyEdgeDependsOn NetworkPolicyEdgeKind = "depends_on"
+	// NetworkPolicyEdgeAddon 来自 runtime 绑定的 addon
+	NetworkPolicyEdgeAddon NetworkPolicyEdgeKind = "addon"
+	// NetworkPolicyEdgeExpose 来自 expose 的端口或 endpoint
+	NetworkPolicyEdgeExpose NetworkPolicyEdgeKind = "expose"
+)
+
 /*
 restart servicegroup
 
@@ -719,6 +942,8 @@ type CapacityInfoData struct {
 	RedisOperator         bool `json:"redisOperator"`
 	MysqlOperator         bool `json:"mysqlOperator"`
 	DaemonsetOperator     bool `json:"daemonsetOperator"`
+	PostgresqlOperator    bool `json:"postgresqlOperator"`
+	MongodbOperator       bool `json:"mongodbOperator"`
 }
 
 type ComponentInfoResponse struct {
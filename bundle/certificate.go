@@ -0,0 +1,119 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package bundle
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/bundle/apierrors"
+	"github.com/erda-project/erda/pkg/httputil"
+)
+
+// ListAppCertificates 获取应用引用的证书列表
+func (b *Bundle) ListAppCertificates(req *apistructs.AppCertificateListRequest) (*apistructs.PagingAppCertificateDTO, error) {
+	host, err := b.urls.CMDB()
+	if err != nil {
+		return nil, err
+	}
+	hc := b.hc
+
+	var listResp apistructs.AppCertificateListResponse
+	resp, err := hc.Get(host).Path("/api/certificates/actions/list-application-quotes").
+		Header(httputil.InternalHeader, "bundle").
+		Param("appId", strconv.FormatUint(req.AppID, 10)).
+		Param("status", req.Status).
+		Param("pageNo", strconv.Itoa(req.PageNo)).
+		Param("pageSize", strconv.Itoa(req.PageSize)).
+		Do().JSON(&listResp)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !resp.IsOK() || !listResp.Success {
+		return nil, toAPIError(resp.StatusCode(), listResp.Error)
+	}
+
+	return &listResp.Data, nil
+}
+
+// CreateCertificate 创建证书
+func (b *Bundle) CreateCertificate(userID string, req *apistructs.CertificateCreateRequest) (*apistructs.CertificateDTO, error) {
+	host, err := b.urls.CMDB()
+	if err != nil {
+		return nil, err
+	}
+	hc := b.hc
+
+	var createResp apistructs.CertificateCreateResponse
+	resp, err := hc.Post(host).Path("/api/certificates").
+		Header(httputil.InternalHeader, "bundle").
+		Header(httputil.UserHeader, userID).
+		JSONBody(req).
+		Do().JSON(&createResp)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !resp.IsOK() || !createResp.Success {
+		return nil, toAPIError(resp.StatusCode(), createResp.Error)
+	}
+
+	return &createResp.Data, nil
+}
+
+// UpdateCertificate 更新证书
+func (b *Bundle) UpdateCertificate(userID string, certificateID uint64, req *apistructs.CertificateUpdateRequest) error {
+	host, err := b.urls.CMDB()
+	if err != nil {
+		return err
+	}
+	hc := b.hc
+
+	var updateResp apistructs.CertificateUpdateResponse
+	resp, err := hc.Put(host).Path(fmt.Sprintf("/api/certificates/%d", certificateID)).
+		Header(httputil.InternalHeader, "bundle").
+		Header(httputil.UserHeader, userID).
+		JSONBody(req).
+		Do().JSON(&updateResp)
+	if err != nil {
+		return apierrors.ErrInvoke.InternalError(err)
+	}
+	if !resp.IsOK() || !updateResp.Success {
+		return toAPIError(resp.StatusCode(), updateResp.Error)
+	}
+
+	return nil
+}
+
+// GetCertificate 获取证书详情
+func (b *Bundle) GetCertificate(certificateID uint64) (*apistructs.CertificateDTO, error) {
+	host, err := b.urls.CMDB()
+	if err != nil {
+		return nil, err
+	}
+	hc := b.hc
+
+	var getResp apistructs.CertificateDetailResponse
+	resp, err := hc.Get(host).Path(fmt.Sprintf("/api/certificates/%d", certificateID)).
+		Header(httputil.InternalHeader, "bundle").
+		Do().JSON(&getResp)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !resp.IsOK() || !getResp.Success {
+		return nil, toAPIError(resp.StatusCode(), getResp.Error)
+	}
+
+	return &getResp.CertificateDTO, nil
+}
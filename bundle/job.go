@@ -0,0 +1,79 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package bundle
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/bundle/apierrors"
+)
+
+// CreateJob create scheduler job
+func (b *Bundle) CreateJob(job apistructs.JobCreateRequest) (*apistructs.Job, error) {
+	var resp apistructs.JobCreateResponse
+	if err := callScheduler(b, job, &resp, "/v1/job/create", b.hc.Put); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, apierrors.ErrInvoke.InternalError(fmt.Errorf(resp.Error))
+	}
+	return &resp.Job, nil
+}
+
+// StartJob start scheduler job which is created
+func (b *Bundle) StartJob(namespace, name string) (*apistructs.Job, error) {
+	var resp apistructs.JobStartResponse
+	path := fmt.Sprintf("/v1/job/%s/%s/start", url.PathEscape(namespace), url.PathEscape(name))
+	if err := callScheduler(b, nil, &resp, path, b.hc.Post); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, apierrors.ErrInvoke.InternalError(fmt.Errorf(resp.Error))
+	}
+	return &resp.Job, nil
+}
+
+// InspectJob get scheduler job and its status
+func (b *Bundle) InspectJob(namespace, name string) (*apistructs.Job, error) {
+	host, err := b.urls.Scheduler()
+	if err != nil {
+		return nil, err
+	}
+	var job apistructs.Job
+	r, err := b.hc.Get(host).
+		Path(fmt.Sprintf("/v1/job/%s/%s", url.PathEscape(namespace), url.PathEscape(name))).
+		Do().JSON(&job)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !r.IsOK() {
+		return nil, apierrors.ErrInvoke.InternalError(fmt.Errorf("statuscode: %d, %v", r.StatusCode(), string(r.Body())))
+	}
+	return &job, nil
+}
+
+// DeleteJob delete scheduler job
+func (b *Bundle) DeleteJob(job apistructs.Job) error {
+	var resp apistructs.JobDeleteResponse
+	path := fmt.Sprintf("/v1/job/%s/%s/delete", url.PathEscape(job.Namespace), url.PathEscape(job.Name))
+	if err := callScheduler(b, job, &resp, path, b.hc.Delete); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return apierrors.ErrInvoke.InternalError(fmt.Errorf(resp.Error))
+	}
+	return nil
+}
@@ -153,3 +153,108 @@ func (b *Bundle) KMSDescribeKey(req apistructs.KMSDescribeKeyRequest) (*kmstypes
 	}
 	return descResp.Data, nil
 }
+
+func (b *Bundle) KMSGetPublicKey(req apistructs.KMSGetPublicKeyRequest) (*kmstypes.PublicKey, error) {
+	host, err := b.urls.KMS()
+	if err != nil {
+		return nil, err
+	}
+	hc := b.hc
+
+	var publicKeyResp apistructs.KMSGetPublicKeyResponse
+	httpResp, err := hc.Get(host).Path("/api/kms/get-public-key").
+		Header(httputil.InternalHeader, "bundle").
+		JSONBody(&req).
+		Do().JSON(&publicKeyResp)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !httpResp.IsOK() || !publicKeyResp.Success {
+		return nil, toAPIError(httpResp.StatusCode(), publicKeyResp.Error)
+	}
+	return publicKeyResp.Data, nil
+}
+
+func (b *Bundle) KMSAsymmetricDecrypt(req apistructs.KMSAsymmetricDecryptRequest) (*kmstypes.AsymmetricDecryptResponse, error) {
+	host, err := b.urls.KMS()
+	if err != nil {
+		return nil, err
+	}
+	hc := b.hc
+
+	var decryptResp apistructs.KMSAsymmetricDecryptResponse
+	httpResp, err := hc.Post(host).Path("/api/kms/asymmetric-decrypt").
+		Header(httputil.InternalHeader, "bundle").
+		JSONBody(&req).
+		Do().JSON(&decryptResp)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !httpResp.IsOK() || !decryptResp.Success {
+		return nil, toAPIError(httpResp.StatusCode(), decryptResp.Error)
+	}
+	return decryptResp.Data, nil
+}
+
+func (b *Bundle) KMSSign(req apistructs.KMSSignRequest) (*kmstypes.SignResponse, error) {
+	host, err := b.urls.KMS()
+	if err != nil {
+		return nil, err
+	}
+	hc := b.hc
+
+	var signResp apistructs.KMSSignResponse
+	httpResp, err := hc.Post(host).Path("/api/kms/sign").
+		Header(httputil.InternalHeader, "bundle").
+		JSONBody(&req).
+		Do().JSON(&signResp)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !httpResp.IsOK() || !signResp.Success {
+		return nil, toAPIError(httpResp.StatusCode(), signResp.Error)
+	}
+	return signResp.Data, nil
+}
+
+func (b *Bundle) KMSVerify(req apistructs.KMSVerifyRequest) (*kmstypes.VerifyResponse, error) {
+	host, err := b.urls.KMS()
+	if err != nil {
+		return nil, err
+	}
+	hc := b.hc
+
+	var verifyResp apistructs.KMSVerifyResponse
+	httpResp, err := hc.Post(host).Path("/api/kms/verify").
+		Header(httputil.InternalHeader, "bundle").
+		JSONBody(&req).
+		Do().JSON(&verifyResp)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !httpResp.IsOK() || !verifyResp.Success {
+		return nil, toAPIError(httpResp.StatusCode(), verifyResp.Error)
+	}
+	return verifyResp.Data, nil
+}
+
+func (b *Bundle) KMSReEncrypt(req apistructs.KMSReEncryptRequest) (*kmstypes.ReEncryptResponse, error) {
+	host, err := b.urls.KMS()
+	if err != nil {
+		return nil, err
+	}
+	hc := b.hc
+
+	var reEncryptResp apistructs.KMSReEncryptResponse
+	httpResp, err := hc.Post(host).Path("/api/kms/re-encrypt").
+		Header(httputil.InternalHeader, "bundle").
+		JSONBody(&req).
+		Do().JSON(&reEncryptResp)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !httpResp.IsOK() || !reEncryptResp.Success {
+		return nil, toAPIError(httpResp.StatusCode(), reEncryptResp.Error)
+	}
+	return reEncryptResp.Data, nil
+}
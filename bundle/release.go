@@ -84,6 +84,28 @@ func (b *Bundle) GetRelease(releaseID string) (*apistructs.ReleaseGetResponseDat
 	return &(releaseResp.Data), nil
 }
 
+// CreateRelease 创建 release
+func (b *Bundle) CreateRelease(req apistructs.ReleaseCreateRequest) (string, error) {
+	host, err := b.urls.DiceHub()
+	if err != nil {
+		return "", err
+	}
+	hc := b.hc
+
+	var releaseResp apistructs.ReleaseCreateResponse
+	resp, err := hc.Post(host).Path("/api/releases").
+		Header("Internal-Client", "true").
+		JSONBody(req).
+		Do().JSON(&releaseResp)
+	if err != nil {
+		return "", apierrors.ErrInvoke.InternalError(err)
+	}
+	if !resp.IsOK() || !releaseResp.Success {
+		return "", toAPIError(resp.StatusCode(), releaseResp.Error)
+	}
+	return releaseResp.Data.ReleaseID, nil
+}
+
 func (b *Bundle) ListReleases(req apistructs.ReleaseListRequest) (*apistructs.ReleaseListResponseData, error) {
 	host, err := b.urls.DiceHub()
 	if err != nil {
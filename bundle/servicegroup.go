@@ -129,6 +129,35 @@ func (b *Bundle) InspectServiceGroup(namespace, name string) (
 	return &resp.Data, nil
 }
 
+// LiveServiceGroup get the spec of services actually running in cluster
+func (b *Bundle) LiveServiceGroup(namespace, name string) (*apistructs.ServiceGroupLive, error) {
+	host, err := b.urls.Scheduler()
+	if err != nil {
+		return nil, err
+	}
+	var resp apistructs.ServiceGroupLiveResponse
+	r, err := b.hc.Get(host).Path("/api/servicegroup/actions/live").Param("namespace", namespace).Param("name", name).Do().JSON(&resp)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !r.IsOK() || !resp.Success {
+		return nil, toAPIError(r.StatusCode(), resp.Error)
+	}
+	return &resp.Data, nil
+}
+
+// BindServiceGroupTLS 将域名证书绑定到 servicegroup 中使用该域名的服务 ingress
+func (b *Bundle) BindServiceGroupTLS(req apistructs.ServiceGroupTLSRequest) error {
+	var resp apistructs.ServiceGroupTLSResponse
+	if err := callScheduler(b, req, &resp, "/api/servicegroup/actions/tls", b.hc.Put); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return toAPIError(200, resp.Error)
+	}
+	return nil
+}
+
 // CreateJobVolume create job volume
 func (b *Bundle) CreateJobVolume(v apistructs.JobVolume) (string, error) {
 	var resp apistructs.JobVolumeCreateResponse
@@ -152,6 +181,18 @@ func (b *Bundle) KillPod(r apistructs.ServiceGroupKillPodRequest) error {
 	return nil
 }
 
+// RolloutServiceGroup promote, pause or abort the rollout of service
+func (b *Bundle) RolloutServiceGroup(r apistructs.ServiceGroupRolloutRequest) (*apistructs.RolloutStatus, error) {
+	var resp apistructs.ServiceGroupRolloutResponse
+	if err := callScheduler(b, r, &resp, "/api/servicegroup/actions/rollout", b.hc.Post); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, toAPIError(200, resp.Error)
+	}
+	return &resp.Data, nil
+}
+
 // InspectServiceGroup get servicegroup info
 func (b *Bundle) ServiceGroupConfigUpdate(sg apistructs.ServiceGroup) error {
 	var resp apistructs.ServiceGroupConfigUpdateResponse
@@ -0,0 +1,142 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// addon-backup runs inside the backup and restore jobs created by orchestrator. It dumps an addon
+// instance and uploads the dump to object storage, or downloads a dump and loads it into an instance.
+//
+// The addon connection info (e.g. MYSQL_HOST, REDIS_PASSWORD) and the storage location are passed by
+// environment variables, the passwords and the storage secret key are injected from a k8s secret. The image is expected to ship mysqldump/mysql, redis-cli/rdb and multielasticdump.
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/cloudstorage"
+)
+
+// backupScripts dump the addon to $BACKUP_FILE
+var backupScripts = map[string]string{
+	// only user schemas are dumped, restoring system schemas (e.g. mysql.user) would break the target instance
+	apistructs.AddonMySQL: `dbs=$(mysql -h"$MYSQL_HOST" -P"$MYSQL_PORT" -u"$MYSQL_USERNAME" -N -e 'SHOW DATABASES' | ` +
+		`{ grep -Ev '^(information_schema|performance_schema|mysql|sys)$' || true; }) && ` +
+		`if [ -z "$dbs" ]; then gzip < /dev/null > "$BACKUP_FILE"; else ` +
+		`mysqldump -h"$MYSQL_HOST" -P"$MYSQL_PORT" -u"$MYSQL_USERNAME" --databases $dbs ` +
+		`--single-transaction --routines --triggers --events | gzip > "$BACKUP_FILE"; fi`,
+	apistructs.AddonRedis: `redis-cli -h "$REDIS_HOST" -p "$REDIS_PORT" --rdb "$BACKUP_FILE"`,
+	apistructs.AddonES: `multielasticdump --direction=dump --match='^[^.].*$' --input="$ES_URL" --output="$WORK_DIR/es" && ` +
+		`tar -czf "$BACKUP_FILE" -C "$WORK_DIR/es" .`,
+}
+
+// restoreScripts load $BACKUP_FILE into the addon
+var restoreScripts = map[string]string{
+	apistructs.AddonMySQL: `gunzip -c "$BACKUP_FILE" | mysql -h"$MYSQL_HOST" -P"$MYSQL_PORT" -u"$MYSQL_USERNAME"`,
+	apistructs.AddonRedis: `rdb --command protocol "$BACKUP_FILE" | redis-cli -h "$REDIS_HOST" -p "$REDIS_PORT" --pipe`,
+	apistructs.AddonES: `mkdir -p "$WORK_DIR/es" && tar -xzf "$BACKUP_FILE" -C "$WORK_DIR/es" && ` +
+		`multielasticdump --direction=load --input="$WORK_DIR/es" --output="$ES_URL"`,
+}
+
+func main() {
+	if err := run(); err != nil {
+		logrus.Fatal(err)
+	}
+	logrus.Infof("%s %s finished", os.Getenv("ADDON_BACKUP_ACTION"), os.Getenv("ADDON_NAME"))
+}
+
+func run() error {
+	action, addonName := os.Getenv("ADDON_BACKUP_ACTION"), os.Getenv("ADDON_NAME")
+	var scripts map[string]string
+	switch action {
+	case "backup":
+		scripts = backupScripts
+	case "restore":
+		scripts = restoreScripts
+	default:
+		return errors.Errorf("invalid ADDON_BACKUP_ACTION: %q", action)
+	}
+	script, ok := scripts[addonName]
+	if !ok {
+		return errors.Errorf("%s is not supported for addon %s", action, addonName)
+	}
+
+	bucket, object := os.Getenv("STORAGE_BUCKET"), os.Getenv("STORAGE_OBJECT")
+	client, err := cloudstorage.New(os.Getenv("STORAGE_ENDPOINT"), os.Getenv("STORAGE_ACCESS_KEY"),
+		os.Getenv("STORAGE_SECRET_KEY"))
+	if err != nil {
+		return err
+	}
+
+	workDir, err := ioutil.TempDir("", "addon-backup")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+	file := filepath.Join(workDir, filepath.Base(object))
+
+	if action == "restore" {
+		logrus.Infof("downloading %s/%s", bucket, object)
+		if err := client.DownloadToFile(bucket, object, file); err != nil {
+			return errors.Wrap(err, "failed to download backup file")
+		}
+	}
+
+	cmd := exec.Command("bash", "-o", "pipefail", "-c", script)
+	cmd.Env = append(os.Environ(),
+		"BACKUP_FILE="+file,
+		"WORK_DIR="+workDir,
+		"MYSQL_PWD="+os.Getenv(apistructs.AddonMysqlPasswordName),
+		"REDISCLI_AUTH="+os.Getenv(apistructs.AddonRedisPasswordName),
+		"ES_URL="+esURL(),
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	logrus.Infof("running %s of addon %s", action, addonName)
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "failed to %s addon %s", action, addonName)
+	}
+
+	if action == "backup" {
+		logrus.Infof("uploading %s/%s", bucket, object)
+		if _, err := client.UploadFile(bucket, object, file); err != nil {
+			return errors.Wrap(err, "failed to upload backup file")
+		}
+	}
+	return nil
+}
+
+// esURL builds elasticsearch address with basic auth from addon config
+func esURL() string {
+	host := os.Getenv(apistructs.AddonEsHostName)
+	if host == "" {
+		return ""
+	}
+	port := os.Getenv(apistructs.AddonEsPortName)
+	if port == "" {
+		port = apistructs.AddonEsDefaultPort
+	}
+	u := url.URL{Scheme: "http", Host: net.JoinHostPort(host, port)}
+	if user := os.Getenv(apistructs.AddonEsUserName); user != "" {
+		u.User = url.UserPassword(user, os.Getenv(apistructs.AddonEsPasswordName))
+	} else if password := os.Getenv(apistructs.AddonEsPasswordName); password != "" {
+		u.User = url.UserPassword("elastic", password)
+	}
+	return u.String()
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// kms-migrate copy all kms keys with their key versions from etcd store into mysql store.
+// Etcd and mysql are configured by the same envs as kms, e.g. ETCD_ENDPOINTS, MYSQL_HOST.
+package main
+
+import (
+	"flag"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/modules/kms/conf"
+	"github.com/erda-project/erda/pkg/kms"
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+	"github.com/erda-project/erda/pkg/kms/stores/etcd"
+	"github.com/erda-project/erda/pkg/kms/stores/mysql"
+)
+
+var dryRun = flag.Bool("dry-run", false, "only check and print keys to migrate, not write into mysql")
+
+func main() {
+	flag.Parse()
+	conf.Load()
+
+	storeConfigs := conf.MySQLConfigs()
+	storeConfigs[etcd.EnvKeyEtcdEndpoints] = conf.EtcdEndpoints()
+	mgr, err := kms.GetManager(kms.WithStoreConfigs(storeConfigs))
+	if err != nil {
+		logrus.Fatalf("failed to init kms manager, err: %v", err)
+	}
+	src, err := mgr.GetStore(kmstypes.StoreKind_ETCD)
+	if err != nil {
+		logrus.Fatalf("failed to get etcd store, err: %v", err)
+	}
+	dst, err := mgr.GetStore(kmstypes.StoreKind_MYSQL)
+	if err != nil {
+		logrus.Fatalf("failed to get mysql store, err: %v", err)
+	}
+
+	pluginKinds := []kmstypes.PluginKind{kmstypes.PluginKind_DICE_KMS, kmstypes.PluginKind_AWS_KMS, kmstypes.PluginKind_ALIYUN_KMS}
+	result, err := dst.(*mysql.Store).CopyFrom(src, pluginKinds, *dryRun)
+	if err != nil {
+		logrus.Fatalf("failed to migrate keys, copied: %d, skipped: %d, err: %v", len(result.Copied), len(result.Skipped), err)
+	}
+	logrus.Infof("migrate keys done, dryRun: %v, copied: %d, skipped: %d", *dryRun, len(result.Copied), len(result.Skipped))
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// runtime-export downloads a runtime from orchestrator as a helm chart or kustomize manifests tarball,
+// or imports helm values back into a release of the application.
+//
+//	runtime-export -addr http://orchestrator:8081 -org 1 -user 2 -runtime 3 -format helm -o demo.tgz
+//	runtime-export -addr http://orchestrator:8081 -org 1 -user 2 -app 4 -import values.yaml
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/httputil"
+)
+
+var (
+	addr      = flag.String("addr", "http://localhost:8081", "address of orchestrator")
+	orgID     = flag.String("org", "", "org id")
+	userID    = flag.String("user", "", "user id")
+	runtimeID = flag.Uint64("runtime", 0, "id of the runtime to export")
+	format    = flag.String("format", string(apistructs.RuntimeExportHelm), "export format, helm or kustomize")
+	output    = flag.String("o", "", "output file, default to the file name given by orchestrator")
+	appID     = flag.Uint64("app", 0, "id of the application to import into")
+	values    = flag.String("import", "", "helm values file to import as a release")
+	version   = flag.String("version", "", "version of the imported release")
+)
+
+func main() {
+	flag.Parse()
+	if *orgID == "" || *userID == "" {
+		logrus.Fatalf("-org and -user are required")
+	}
+	var err error
+	switch {
+	case *values != "":
+		err = importValues()
+	case *runtimeID != 0:
+		err = export()
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+func export() error {
+	url := fmt.Sprintf("%s/api/runtimes/%d/actions/export?format=%s", strings.TrimSuffix(*addr, "/"), *runtimeID, *format)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	filename := *output
+	if filename == "" {
+		// never trust path in the response header, only write into current directory
+		filename = filepath.Base(strings.TrimPrefix(resp.Header.Get("Content-Disposition"), "attachment;fileName="))
+		if filename == "." || filename == ".." || filename == string(filepath.Separator) {
+			return fmt.Errorf("invalid filename in response, specify one by -o")
+		}
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return err
+	}
+	logrus.Infof("runtime %d exported to %s", *runtimeID, filename)
+	return nil
+}
+
+func importValues() error {
+	if *appID == 0 {
+		return fmt.Errorf("-app is required when importing")
+	}
+	content, err := ioutil.ReadFile(*values)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(apistructs.RuntimeHelmImportRequest{
+		ApplicationID: *appID,
+		Values:        string(content),
+		Version:       *version,
+	})
+	if err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(*addr, "/") + "/api/runtimes/actions/import-helm-values"
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		apistructs.Header
+		Data apistructs.RuntimeHelmImportResponseData `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if !result.Success {
+		return fmt.Errorf("failed to import values: %s", result.Error.Msg)
+	}
+	logrus.Infof("values imported as release %s", result.Data.ReleaseID)
+	return nil
+}
+
+func do(req *http.Request) (*http.Response, error) {
+	req.Header.Set(httputil.OrgHeader, *orgID)
+	req.Header.Set(httputil.UserHeader, *userID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("request %s failed, status: %d, body: %s", req.URL, resp.StatusCode, msg)
+	}
+	return resp, nil
+}
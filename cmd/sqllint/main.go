@@ -0,0 +1,172 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// sqllint lints mysql migration scripts with the rules in pkg/sqllint, for pipelines and pre-commit hooks.
+// Directories are walked for *.sql files. It exits with 1 if any finding is at or above -fail-on, 2 on usage errors.
+//
+//	sqllint -config .sqllint.yml modules/orchestrator/sqls
+//	sqllint -format sarif -o sqllint.sarif $(git diff --cached --name-only -- '*.sql')
+//	sqllint -print-config > .sqllint.yml
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/erda-project/erda/pkg/sqllint"
+)
+
+const defaultConfigFile = ".sqllint.yml"
+
+var (
+	configFile  = flag.String("config", "", "yaml config of rules, default to "+defaultConfigFile+" in working directory if exists")
+	format      = flag.String("format", "text", "output format, text, json or sarif")
+	output      = flag.String("o", "", "output file, default to stdout")
+	failOn      = flag.String("fail-on", string(sqllint.SeverityError), "exit with 1 if any finding is at or above the severity, error, warning, info or none")
+	printConfig = flag.Bool("print-config", false, "print the default config with all rules and their default params")
+)
+
+func main() {
+	flag.Parse()
+	if *printConfig {
+		data, err := yaml.Marshal(sqllint.DefaultConfig())
+		if err != nil {
+			exit(err)
+		}
+		fmt.Print(string(data))
+		return
+	}
+	if flag.NArg() == 0 {
+		exit(fmt.Errorf("no sql file or directory given"))
+	}
+	write, ok := map[string]func(io.Writer, []sqllint.Finding) error{
+		"text":  sqllint.WriteText,
+		"json":  sqllint.WriteJSON,
+		"sarif": sqllint.WriteSARIF,
+	}[*format]
+	if !ok {
+		exit(fmt.Errorf("invalid format %s", *format))
+	}
+	threshold := sqllint.Severity(*failOn)
+	if *failOn != "none" && !threshold.Valid() {
+		exit(fmt.Errorf("invalid -fail-on %s", *failOn))
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		exit(err)
+	}
+	linter, err := sqllint.NewFromConfig(cfg)
+	if err != nil {
+		exit(err)
+	}
+	files, err := collectFiles(flag.Args())
+	if err != nil {
+		exit(err)
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			exit(err)
+		}
+		// syntax errors are recorded as findings
+		_ = linter.Input(data, file)
+	}
+
+	findings := linter.Findings()
+	if err := writeFindings(write, findings); err != nil {
+		exit(err)
+	}
+
+	if *failOn == "none" {
+		return
+	}
+	for _, f := range findings {
+		if f.Severity.AtLeast(threshold) {
+			if *output != "" {
+				fmt.Fprintf(os.Stderr, "sqllint: found %d issues, see %s\n", len(findings), *output)
+			}
+			os.Exit(1)
+		}
+	}
+}
+
+// writeFindings writes findings to -o or stdout. The output file is closed here rather than deferred in main,
+// since os.Exit skips deferred calls and the report could be left unflushed.
+func writeFindings(write func(io.Writer, []sqllint.Finding) error, findings []sqllint.Finding) error {
+	if *output == "" {
+		return write(os.Stdout, findings)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(f, findings); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func loadConfig() (*sqllint.Config, error) {
+	file := *configFile
+	if file == "" {
+		if _, err := os.Stat(defaultConfigFile); err != nil {
+			return nil, nil
+		}
+		file = defaultConfigFile
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return sqllint.LoadConfig(data)
+}
+
+func collectFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		if err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && strings.EqualFold(filepath.Ext(p), ".sql") {
+				files = append(files, p)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func exit(err error) {
+	fmt.Fprintf(os.Stderr, "sqllint: %v\n", err)
+	os.Exit(2)
+}
@@ -21,7 +21,8 @@ type Certificate struct {
 	Android  string
 	Ios      string
 	Message  string
-	Type     string // IOS发布证书/Android证书/消息推送证书
+	Tls      string
+	Type     string // IOS发布证书/Android证书/消息推送证书/TLS证书
 	Desc     string
 	Creator  string
 	Operator string
@@ -69,6 +69,7 @@ func (c *Certificate) Create(userID string, createReq *apistructs.CertificateCre
 	var (
 		androidMarshal []byte
 		iosMarshal     []byte
+		tlsMarshal     []byte
 		err            error
 	)
 	// 参数合法性检查
@@ -197,6 +198,15 @@ func (c *Certificate) Create(userID string, createReq *apistructs.CertificateCre
 		if createReq.MessageInfo.UUID == "" {
 			return nil, errors.Errorf("need messageInfo uuid")
 		}
+	case apistructs.TLSCertificateType:
+		tlsInfo := createReq.TLSInfo
+		if err := parseTLSCertificate(&tlsInfo); err != nil {
+			return nil, errors.Errorf("failed to create certificate, (%v)", err)
+		}
+		tlsMarshal, err = json.Marshal(&tlsInfo)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.Errorf("failed to create certificate(type error)")
 	}
@@ -218,6 +228,7 @@ func (c *Certificate) Create(userID string, createReq *apistructs.CertificateCre
 		OrgID:    int64(createReq.OrgID),
 		Android:  string(androidMarshal),
 		Ios:      string(iosMarshal),
+		Tls:      string(tlsMarshal),
 		Creator:  userID,
 		Operator: userID,
 		Type:     createReq.Type,
@@ -238,6 +249,20 @@ func (c *Certificate) Update(certificateID int64, updateReq *apistructs.Certific
 	}
 
 	certificate.Desc = updateReq.Desc
+	if updateReq.TLSInfo != nil {
+		if certificate.Type != string(apistructs.TLSCertificateType) {
+			return errors.Errorf("failed to update certificate(not a tls certificate)")
+		}
+		tlsInfo := *updateReq.TLSInfo
+		if err := parseTLSCertificate(&tlsInfo); err != nil {
+			return errors.Errorf("failed to update certificate, (%v)", err)
+		}
+		tlsMarshal, err := json.Marshal(&tlsInfo)
+		if err != nil {
+			return err
+		}
+		certificate.Tls = string(tlsMarshal)
+	}
 	if err = c.db.UpdateCertificate(&certificate); err != nil {
 		logrus.Errorf("failed to update certificate, (%v)", err)
 		return errors.Errorf("failed to update certificate")
@@ -297,10 +322,12 @@ func (p *Certificate) convertToCertificateDTO(certificate *model.Certificate) *a
 		androidInfo apistructs.AndroidCertificateDTO
 		iosInfo     apistructs.IOSCertificateDTO
 		messageInfo apistructs.CertificateFileDTO
+		tlsInfo     apistructs.TLSCertificateDTO
 	)
 	_ = json.Unmarshal([]byte(certificate.Android), &androidInfo)
 	_ = json.Unmarshal([]byte(certificate.Ios), &iosInfo)
 	_ = json.Unmarshal([]byte(certificate.Message), &messageInfo)
+	_ = json.Unmarshal([]byte(certificate.Tls), &tlsInfo)
 	return &apistructs.CertificateDTO{
 		ID:          uint64(certificate.ID),
 		Type:        certificate.Type,
@@ -308,6 +335,7 @@ func (p *Certificate) convertToCertificateDTO(certificate *model.Certificate) *a
 		AndroidInfo: androidInfo,
 		IOSInfo:     iosInfo,
 		MessageInfo: messageInfo,
+		TLSInfo:     tlsInfo,
 		Desc:        certificate.Desc,
 		OrgID:       uint64(certificate.OrgID),
 		Creator:     certificate.Creator,
@@ -0,0 +1,49 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+// parseTLSCertificate 校验 TLS 证书链与私钥是否匹配, 并从域名证书中解析域名、签发者及有效期
+func parseTLSCertificate(info *apistructs.TLSCertificateDTO) error {
+	if info.Certificate == "" || info.PrivateKey == "" {
+		return errors.New("tls certificate or private key is empty")
+	}
+	pair, err := tls.X509KeyPair([]byte(info.Certificate), []byte(info.PrivateKey))
+	if err != nil {
+		return errors.Errorf("invalid tls key pair, (%v)", err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return errors.Errorf("invalid tls certificate, (%v)", err)
+	}
+	info.Domains = leaf.DNSNames
+	if len(info.Domains) == 0 && leaf.Subject.CommonName != "" {
+		info.Domains = []string{leaf.Subject.CommonName}
+	}
+	info.Issuer = leaf.Issuer.CommonName
+	info.NotBefore = leaf.NotBefore
+	info.NotAfter = leaf.NotAfter
+	if info.Source == "" {
+		info.Source = apistructs.TLSCertificateSourceManual
+	}
+	return nil
+}
@@ -0,0 +1,2 @@
+ALTER TABLE `dice_certificates`
+    ADD COLUMN `tls` TEXT NULL COMMENT 'tls certificate info, json' AFTER `message`;
@@ -262,3 +262,26 @@ func Proxy() string {
 func BundleUserID() string {
 	return "1101"
 }
+
+func KafkaBrokers() string {
+	return os.Getenv("BOOTSTRAP_SERVERS")
+}
+
+func KafkaGroup() string {
+	e := os.Getenv("EVENTBOX_KAFKA_GROUP")
+	if e == "" {
+		e = "eventbox"
+	}
+	return e
+}
+
+// KafkaInputTopics 逗号分隔的 kafka input 订阅的 topic 列表, 为空则不启用 kafka input
+func KafkaInputTopics() []string {
+	topics := []string{}
+	for _, t := range strings.Split(os.Getenv("EVENTBOX_KAFKA_INPUT_TOPICS"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			topics = append(topics, t)
+		}
+	}
+	return topics
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/erda-project/erda/modules/eventbox/input"
 	etcdinput "github.com/erda-project/erda/modules/eventbox/input/etcd"
 	httpinput "github.com/erda-project/erda/modules/eventbox/input/http"
+	kafkainput "github.com/erda-project/erda/modules/eventbox/input/kafka"
 	"github.com/erda-project/erda/modules/eventbox/monitor"
 	"github.com/erda-project/erda/modules/eventbox/register"
 	"github.com/erda-project/erda/modules/eventbox/server"
@@ -35,6 +36,7 @@ import (
 	fakesubscriber "github.com/erda-project/erda/modules/eventbox/subscriber/fake"
 	groupsubscriber "github.com/erda-project/erda/modules/eventbox/subscriber/group"
 	httpsubscriber "github.com/erda-project/erda/modules/eventbox/subscriber/http"
+	kafkasubscriber "github.com/erda-project/erda/modules/eventbox/subscriber/kafka"
 	mbox "github.com/erda-project/erda/modules/eventbox/subscriber/mbox"
 	smssubscriber "github.com/erda-project/erda/modules/eventbox/subscriber/sms"
 	vmssubscriber "github.com/erda-project/erda/modules/eventbox/subscriber/vms"
@@ -135,6 +137,22 @@ func New() (Dispatcher, error) {
 	dispatcher.RegisterSubscriber(mboxS)
 	dispatcher.RegisterSubscriber(groupS)
 
+	// kafka input & subscriber are enabled only when kafka brokers configured
+	if brokers := conf.KafkaBrokers(); brokers != "" {
+		kafkaS, err := kafkasubscriber.New(brokers)
+		if err != nil {
+			return nil, err
+		}
+		dispatcher.RegisterSubscriber(kafkaS)
+		if topics := conf.KafkaInputTopics(); len(topics) > 0 {
+			kafkai, err := kafkainput.New(brokers, conf.KafkaGroup(), topics)
+			if err != nil {
+				return nil, err
+			}
+			dispatcher.RegisterInput(kafkai)
+		}
+	}
+
 	for name := range dispatcher.subscribers {
 		dispatcher.subscriberspool[name] = goroutinepool.New(conf.PoolSize())
 	}
@@ -0,0 +1,180 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package kafka
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/modules/eventbox/input"
+	"github.com/erda-project/erda/modules/eventbox/monitor"
+	"github.com/erda-project/erda/modules/eventbox/types"
+)
+
+const (
+	pollTimeout = 1 * time.Second
+	// 分发失败后按指数退避重试, 直到分发成功或 stop 才继续消费, 不会丢弃消息
+	retryInterval    = 1 * time.Second
+	maxRetryInterval = 1 * time.Minute
+)
+
+// Consumer 是 kafka input 依赖的 consumer 行为, *kafka.Consumer 实现了该接口
+type Consumer interface {
+	SubscribeTopics(topics []string, rebalanceCb kafka.RebalanceCb) error
+	ReadMessage(timeout time.Duration) (*kafka.Message, error)
+	CommitMessage(m *kafka.Message) ([]kafka.TopicPartition, error)
+	Close() error
+}
+
+// KafkaInput 从 kafka topic 中消费 types.Message 并分发.
+// 关闭自动提交, 只有在消息分发完成后才提交 offset, 保证 at-least-once
+type KafkaInput struct {
+	consumer Consumer
+	topics   []string
+	handler  input.Handler
+	// 用来通知 stop
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+	runningWg sync.WaitGroup
+
+	retryInterval    time.Duration
+	maxRetryInterval time.Duration
+}
+
+func New(brokers, group string, topics []string) (input.Input, error) {
+	c, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers":  brokers,
+		"group.id":           group,
+		"auto.offset.reset":  "earliest",
+		"enable.auto.commit": false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewWithConsumer(c, topics), nil
+}
+
+// NewWithConsumer 使用指定的 consumer 创建 KafkaInput, 创建后需调用 Start 才能 Stop
+func NewWithConsumer(c Consumer, topics []string) *KafkaInput {
+	k := &KafkaInput{
+		consumer:         c,
+		topics:           topics,
+		stopCh:           make(chan struct{}),
+		retryInterval:    retryInterval,
+		maxRetryInterval: maxRetryInterval,
+	}
+	// Start 通常在单独的 goroutine 中执行, 在此之前计数, 避免 Stop 先于 Start 执行时不等待消费结束
+	k.runningWg.Add(1)
+	return k
+}
+
+func (k *KafkaInput) Name() string {
+	return "KAFKA"
+}
+
+func (k *KafkaInput) Start(handler input.Handler) error {
+	defer k.runningWg.Done()
+	defer func() {
+		if err := k.consumer.Close(); err != nil {
+			logrus.Errorf("Kafkainput: close consumer: %v", err)
+		}
+	}()
+	k.handler = handler
+	if err := k.consumer.SubscribeTopics(k.topics, nil); err != nil {
+		return err
+	}
+	logrus.Infof("Kafkainput: subscribe topics: %v", k.topics)
+
+	for {
+		select {
+		case <-k.stopCh:
+			logrus.Info("Kafkainput start() done")
+			return nil
+		default:
+		}
+		msg, err := k.consumer.ReadMessage(pollTimeout)
+		if err != nil {
+			if kerr, ok := err.(kafka.Error); ok && kerr.Code() == kafka.ErrTimedOut {
+				continue
+			}
+			logrus.Errorf("Kafkainput: read message: %v", err)
+			continue
+		}
+		if !k.dispatch(msg) {
+			// stopped before dispatched, not commit, message will be redelivered
+			continue
+		}
+		if _, err := k.consumer.CommitMessage(msg); err != nil {
+			logrus.Errorf("Kafkainput: commit message: %v", err)
+		}
+	}
+}
+
+func (k *KafkaInput) Stop() error {
+	k.stopOnce.Do(func() { close(k.stopCh) })
+	logrus.Info("Kafkainput: stopping")
+	k.runningWg.Wait()
+	logrus.Info("Kafkainput: stopped")
+	return nil
+}
+
+// dispatch 分发一条 kafka 消息, 返回是否可以提交 offset
+func (k *KafkaInput) dispatch(msg *kafka.Message) bool {
+	monitor.Notify(monitor.MonitorInfo{Tp: monitor.KafkaInput})
+	var m types.Message
+	if err := json.Unmarshal(msg.Value, &m); err != nil {
+		logrus.Errorf("Kafkainput: illegal message, drop it, topic: %s, offset: %v, err: %v",
+			topicName(msg), msg.TopicPartition.Offset, err)
+		return true
+	}
+	if m.Time == 0 {
+		m.Time = msg.Timestamp.UnixNano()
+	}
+	interval := k.retryInterval
+	for {
+		derr := k.handler(&m)
+		if derr == nil || derr.IsOK() || derr.IsFiltered() {
+			return true
+		}
+		// 不提交 offset, 后续消息等待该消息分发成功, 保证不丢失
+		logrus.Errorf("Kafkainput: dispatch failed, retry in %s, topic: %s, offset: %v, err: %v",
+			interval, topicName(msg), msg.TopicPartition.Offset, derr)
+		select {
+		case <-k.stopCh:
+			return false
+		case <-time.After(interval):
+		}
+		interval = nextRetryInterval(interval, k.maxRetryInterval)
+	}
+}
+
+// nextRetryInterval 重试间隔翻倍, 不超过 max
+func nextRetryInterval(interval, max time.Duration) time.Duration {
+	interval *= 2
+	if interval > max {
+		return max
+	}
+	return interval
+}
+
+func topicName(msg *kafka.Message) string {
+	if msg.TopicPartition.Topic == nil {
+		return ""
+	}
+	return *msg.TopicPartition.Topic
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/modules/eventbox/dispatcher/errors"
+	"github.com/erda-project/erda/modules/eventbox/testutil"
+	"github.com/erda-project/erda/modules/eventbox/types"
+)
+
+func sendMessage(t *testing.T, b *testutil.KafkaBroker, topic, content string) {
+	v, err := json.Marshal(types.Message{
+		Sender:  "test",
+		Content: content,
+		Labels:  map[types.LabelKey]interface{}{"FAKE": ""},
+	})
+	assert.Nil(t, err)
+	b.Send(topic, v)
+}
+
+func TestKafkaInputDispatchAndCommit(t *testing.T) {
+	b := testutil.NewKafkaBroker()
+	sendMessage(t, b, "events", "m1")
+	b.Send("events", []byte("not json"))
+	sendMessage(t, b, "events", "m2")
+
+	var mu sync.Mutex
+	received := []string{}
+	in := NewWithConsumer(b.Consumer("eventbox"), []string{"events"})
+	go in.Start(func(m *types.Message) *errors.DispatchError {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, m.Content.(string))
+		assert.NotZero(t, m.Time)
+		return errors.New()
+	})
+	assert.Eventually(t, func() bool {
+		return b.Committed("eventbox", "events") == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Nil(t, in.Stop())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"m1", "m2"}, received)
+}
+
+func TestKafkaInputRedeliverUnfinished(t *testing.T) {
+	b := testutil.NewKafkaBroker()
+	sendMessage(t, b, "events", "m1")
+
+	attempts := make(chan struct{}, 100)
+	in := NewWithConsumer(b.Consumer("eventbox"), []string{"events"})
+	in.retryInterval = time.Hour
+	go in.Start(func(m *types.Message) *errors.DispatchError {
+		attempts <- struct{}{}
+		derr := errors.New()
+		derr.BackendErrs["FAKE"] = []error{fmt.Errorf("backend unavailable")}
+		return derr
+	})
+	<-attempts
+	// stop while waiting for retry, offset must not be committed
+	assert.Nil(t, in.Stop())
+	assert.Equal(t, 0, int(b.Committed("eventbox", "events")))
+
+	// new consumer in the same group receives the message again
+	redelivered := make(chan string, 1)
+	in2 := NewWithConsumer(b.Consumer("eventbox"), []string{"events"})
+	go in2.Start(func(m *types.Message) *errors.DispatchError {
+		redelivered <- m.Content.(string)
+		return errors.New()
+	})
+	select {
+	case c := <-redelivered:
+		assert.Equal(t, "m1", c)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not redelivered")
+	}
+	assert.Nil(t, in2.Stop())
+	assert.Equal(t, 1, int(b.Committed("eventbox", "events")))
+}
+
+func TestKafkaInputRetryUntilDispatched(t *testing.T) {
+	b := testutil.NewKafkaBroker()
+	sendMessage(t, b, "events", "m1")
+	sendMessage(t, b, "events", "m2")
+
+	const failures = 10
+	var mu sync.Mutex
+	received := []string{}
+	in := NewWithConsumer(b.Consumer("eventbox"), []string{"events"})
+	in.retryInterval = time.Millisecond
+	in.maxRetryInterval = 4 * time.Millisecond
+	go in.Start(func(m *types.Message) *errors.DispatchError {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, m.Content.(string))
+		if len(received) <= failures {
+			// failed message is neither committed nor skipped
+			assert.Equal(t, 0, int(b.Committed("eventbox", "events")))
+			derr := errors.New()
+			derr.BackendErrs["FAKE"] = []error{fmt.Errorf("backend unavailable")}
+			return derr
+		}
+		return errors.New()
+	})
+	assert.Eventually(t, func() bool {
+		return b.Committed("eventbox", "events") == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Nil(t, in.Stop())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, failures+2, len(received))
+	for _, c := range received[:failures+1] {
+		assert.Equal(t, "m1", c)
+	}
+	assert.Equal(t, "m2", received[failures+1])
+}
+
+func TestNextRetryInterval(t *testing.T) {
+	assert.Equal(t, 2*time.Second, nextRetryInterval(time.Second, time.Minute))
+	assert.Equal(t, time.Minute, nextRetryInterval(40*time.Second, time.Minute))
+	assert.Equal(t, time.Minute, nextRetryInterval(time.Minute, time.Minute))
+}
+
+func TestKafkaInputStopRightAfterStart(t *testing.T) {
+	b := testutil.NewKafkaBroker()
+	c := b.Consumer("eventbox")
+	in := NewWithConsumer(c, []string{"events"})
+	go in.Start(func(m *types.Message) *errors.DispatchError { return errors.New() })
+	// Stop waits for Start to close the consumer even if Start has not run yet
+	assert.Nil(t, in.Stop())
+	_, err := c.ReadMessage(time.Millisecond)
+	assert.EqualError(t, err, "consumer closed")
+}
@@ -17,9 +17,9 @@ package monitor
 
 import "strconv"
 
-const _InfoType_name = "EtcdInputEtcdInputDropHTTPInputDINGDINGOutputDINGDINGWorkNoticeOutputMYSQLOutputHTTPOutputLastType"
+const _InfoType_name = "EtcdInputEtcdInputDropHTTPInputDINGDINGOutputDINGDINGWorkNoticeOutputMYSQLOutputHTTPOutputKafkaInputKafkaOutputLastType"
 
-var _InfoType_index = [...]uint8{0, 9, 22, 31, 45, 69, 80, 90, 98}
+var _InfoType_index = [...]uint8{0, 9, 22, 31, 45, 69, 80, 90, 100, 111, 119}
 
 func (i InfoType) String() string {
 	if i < 0 || i >= InfoType(len(_InfoType_index)-1) {
@@ -41,6 +41,8 @@ const (
 	DINGDINGWorkNoticeOutput
 	MYSQLOutput
 	HTTPOutput
+	KafkaInput
+	KafkaOutput
 	LastType
 )
 
@@ -53,6 +55,8 @@ func infoTypeList() []InfoType {
 		DINGDINGWorkNoticeOutput,
 		MYSQLOutput,
 		HTTPOutput,
+		KafkaInput,
+		KafkaOutput,
 	}
 }
 
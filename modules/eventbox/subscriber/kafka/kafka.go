@@ -0,0 +1,147 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package kafka
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/modules/eventbox/monitor"
+	"github.com/erda-project/erda/modules/eventbox/subscriber"
+	"github.com/erda-project/erda/modules/eventbox/types"
+)
+
+const (
+	defaultPublishTimeout = 10 * time.Second
+
+	HeaderSender = "sender"
+	HeaderTime   = "time"
+)
+
+// Dest 消息要发送到的 topic 列表, 如 label: {"KAFKA": ["pipeline-event", "deployment-event"]}
+// 也支持单个 topic, 如 label: {"KAFKA": "pipeline-event"}
+type Dest []string
+
+func (d *Dest) UnmarshalJSON(b []byte) error {
+	var topic string
+	if err := json.Unmarshal(b, &topic); err == nil {
+		*d = Dest{topic}
+		return nil
+	}
+	var topics []string
+	if err := json.Unmarshal(b, &topics); err != nil {
+		return err
+	}
+	*d = topics
+	return nil
+}
+
+// Producer 是 kafka subscriber 依赖的 producer 行为, *kafka.Producer 实现了该接口
+type Producer interface {
+	Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error
+	Close()
+}
+
+type KafkaSubscriber struct {
+	producer       Producer
+	publishTimeout time.Duration
+}
+
+func New(brokers string) (subscriber.Subscriber, error) {
+	p, err := kafka.NewProducer(&kafka.ConfigMap{
+		"bootstrap.servers":        brokers,
+		"acks":                     "all",
+		"message.send.max.retries": 10,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewWithProducer(p), nil
+}
+
+// NewWithProducer 使用指定的 producer 创建 KafkaSubscriber
+func NewWithProducer(p Producer) *KafkaSubscriber {
+	return &KafkaSubscriber{
+		producer:       p,
+		publishTimeout: defaultPublishTimeout,
+	}
+}
+
+// Publish 将 content 发送到 dest 中的所有 topic, 并等待 broker 确认, 未确认的 topic 作为错误返回
+func (s *KafkaSubscriber) Publish(dest string, content string, timestamp int64, msg *types.Message) []error {
+	monitor.Notify(monitor.MonitorInfo{Tp: monitor.KafkaOutput})
+
+	var d Dest
+	if err := json.Unmarshal([]byte(dest), &d); err != nil {
+		return []error{err}
+	}
+	deliveryCh := make(chan kafka.Event, len(d))
+	errs := []error{}
+	pending := 0
+	for i := range d {
+		topic := d[i]
+		if topic == "" {
+			errs = append(errs, errors.New("empty kafka topic"))
+			continue
+		}
+		m := &kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+			Value:          []byte(content),
+			Timestamp:      time.Unix(0, timestamp),
+			Headers: []kafka.Header{
+				{Key: HeaderSender, Value: []byte(msg.Sender)},
+				{Key: HeaderTime, Value: []byte(strconv.FormatInt(timestamp, 10))},
+			},
+		}
+		if err := s.producer.Produce(m, deliveryCh); err != nil {
+			errs = append(errs, errors.Wrapf(err, "topic: %s", topic))
+			continue
+		}
+		pending++
+	}
+
+	timeout := time.After(s.publishTimeout)
+	for ; pending > 0; pending-- {
+		select {
+		case e := <-deliveryCh:
+			m, ok := e.(*kafka.Message)
+			if !ok {
+				errs = append(errs, errors.Errorf("unexpected kafka event: %v", e))
+				continue
+			}
+			if m.TopicPartition.Error != nil {
+				errs = append(errs, errors.Wrapf(m.TopicPartition.Error, "topic: %s", *m.TopicPartition.Topic))
+				continue
+			}
+			logrus.Infof("succ kafka produce: %v", m.TopicPartition)
+		case <-timeout:
+			errs = append(errs, errors.Errorf("wait kafka delivery report timeout, %d message(s) unconfirmed", pending))
+			return errs
+		}
+	}
+	return errs
+}
+
+func (s *KafkaSubscriber) Status() interface{} {
+	return nil
+}
+
+func (s *KafkaSubscriber) Name() string {
+	return "KAFKA"
+}
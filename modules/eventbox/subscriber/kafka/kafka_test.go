@@ -0,0 +1,69 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/modules/eventbox/testutil"
+	"github.com/erda-project/erda/modules/eventbox/types"
+)
+
+func TestDestUnmarshal(t *testing.T) {
+	var d Dest
+	assert.Nil(t, json.Unmarshal([]byte(`"a"`), &d))
+	assert.Equal(t, Dest{"a"}, d)
+	assert.Nil(t, json.Unmarshal([]byte(`["a","b"]`), &d))
+	assert.Equal(t, Dest{"a", "b"}, d)
+	assert.NotNil(t, json.Unmarshal([]byte(`{"a":1}`), &d))
+}
+
+func TestKafkaSubscriberPublish(t *testing.T) {
+	b := testutil.NewKafkaBroker()
+	s := NewWithProducer(b.Producer())
+	now := time.Now().UnixNano()
+	msg := &types.Message{Sender: "pipeline", Time: now}
+
+	errs := s.Publish(`["pipeline-event","all-event"]`, `{"status":"Success"}`, now, msg)
+	assert.Equal(t, 0, len(errs))
+	for _, topic := range []string{"pipeline-event", "all-event"} {
+		ms := b.Messages(topic)
+		assert.Equal(t, 1, len(ms))
+		assert.Equal(t, `{"status":"Success"}`, string(ms[0].Value))
+		assert.Equal(t, HeaderSender, ms[0].Headers[0].Key)
+		assert.Equal(t, "pipeline", string(ms[0].Headers[0].Value))
+	}
+
+	errs = s.Publish(`"single"`, `{}`, now, msg)
+	assert.Equal(t, 0, len(errs))
+	assert.Equal(t, 1, len(b.Messages("single")))
+}
+
+func TestKafkaSubscriberPublishFailed(t *testing.T) {
+	b := testutil.NewKafkaBroker()
+	b.ProduceErr = fmt.Errorf("not enough replicas")
+	s := NewWithProducer(b.Producer())
+	msg := &types.Message{Sender: "pipeline"}
+
+	errs := s.Publish(`["t1","t2"]`, `{}`, 0, msg)
+	assert.Equal(t, 2, len(errs))
+
+	errs = s.Publish(`not json`, `{}`, 0, msg)
+	assert.Equal(t, 1, len(errs))
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package testutil
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+// KafkaBroker 是进程内的 kafka broker 替身, 每个 topic 只有一个 partition,
+// 按 consumer group 记录已提交的 offset, 用于测试 kafka input 和 subscriber
+type KafkaBroker struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	topics    map[string][]*kafka.Message
+	committed map[string]map[string]kafka.Offset // group -> topic -> next offset
+	// ProduceErr 不为空时, Produce 的投递报告中返回该错误
+	ProduceErr error
+}
+
+func NewKafkaBroker() *KafkaBroker {
+	b := &KafkaBroker{
+		topics:    make(map[string][]*kafka.Message),
+		committed: make(map[string]map[string]kafka.Offset),
+	}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Messages 返回 topic 中的所有消息
+func (b *KafkaBroker) Messages(topic string) []*kafka.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*kafka.Message{}, b.topics[topic]...)
+}
+
+// Committed 返回 group 在 topic 上已提交的 offset
+func (b *KafkaBroker) Committed(group, topic string) kafka.Offset {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.committed[group][topic]
+}
+
+func (b *KafkaBroker) append(topic string, m *kafka.Message) *kafka.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stored := *m
+	stored.TopicPartition = kafka.TopicPartition{
+		Topic:  &topic,
+		Offset: kafka.Offset(len(b.topics[topic])),
+	}
+	if stored.Timestamp.IsZero() {
+		stored.Timestamp = time.Now()
+	}
+	b.topics[topic] = append(b.topics[topic], &stored)
+	b.cond.Broadcast()
+	return &stored
+}
+
+// Producer 返回一个写入该 broker 的 producer
+func (b *KafkaBroker) Producer() *KafkaProducer {
+	return &KafkaProducer{broker: b}
+}
+
+// Consumer 返回一个属于 group 的 consumer, 从该 group 已提交的 offset 开始消费
+func (b *KafkaBroker) Consumer(group string) *KafkaConsumer {
+	return &KafkaConsumer{broker: b, group: group, position: make(map[string]kafka.Offset)}
+}
+
+// Send 直接向 topic 写入一条消息
+func (b *KafkaBroker) Send(topic string, value []byte) {
+	b.append(topic, &kafka.Message{Value: value})
+}
+
+type KafkaProducer struct {
+	broker *KafkaBroker
+	closed bool
+}
+
+func (p *KafkaProducer) Produce(m *kafka.Message, deliveryChan chan kafka.Event) error {
+	if p.closed {
+		return errors.New("producer closed")
+	}
+	if m.TopicPartition.Topic == nil {
+		return errors.New("topic not specified")
+	}
+	var report *kafka.Message
+	if p.broker.ProduceErr != nil {
+		failed := *m
+		failed.TopicPartition.Error = p.broker.ProduceErr
+		report = &failed
+	} else {
+		report = p.broker.append(*m.TopicPartition.Topic, m)
+	}
+	if deliveryChan != nil {
+		go func() { deliveryChan <- report }()
+	}
+	return nil
+}
+
+func (p *KafkaProducer) Close() {
+	p.closed = true
+}
+
+type KafkaConsumer struct {
+	broker   *KafkaBroker
+	group    string
+	topics   []string
+	position map[string]kafka.Offset
+	closed   bool
+}
+
+func (c *KafkaConsumer) SubscribeTopics(topics []string, _ kafka.RebalanceCb) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	c.topics = topics
+	for _, t := range topics {
+		c.position[t] = c.broker.committed[c.group][t]
+	}
+	return nil
+}
+
+func (c *KafkaConsumer) ReadMessage(timeout time.Duration) (*kafka.Message, error) {
+	deadline := time.Now().Add(timeout)
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	for {
+		if c.closed {
+			return nil, errors.New("consumer closed")
+		}
+		for _, t := range c.topics {
+			pos := c.position[t]
+			if int(pos) < len(c.broker.topics[t]) {
+				c.position[t] = pos + 1
+				m := *c.broker.topics[t][pos]
+				return &m, nil
+			}
+		}
+		if !time.Now().Before(deadline) {
+			return nil, kafka.NewError(kafka.ErrTimedOut, "timed out", false)
+		}
+		// wake up periodically to check deadline
+		timer := time.AfterFunc(10*time.Millisecond, c.broker.cond.Broadcast)
+		c.broker.cond.Wait()
+		timer.Stop()
+	}
+}
+
+func (c *KafkaConsumer) CommitMessage(m *kafka.Message) ([]kafka.TopicPartition, error) {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	if c.broker.committed[c.group] == nil {
+		c.broker.committed[c.group] = make(map[string]kafka.Offset)
+	}
+	tp := m.TopicPartition
+	tp.Offset = m.TopicPartition.Offset + 1
+	c.broker.committed[c.group][*tp.Topic] = tp.Offset
+	return []kafka.TopicPartition{tp}, nil
+}
+
+func (c *KafkaConsumer) Close() error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	c.closed = true
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package kms
+
+import (
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/bundle"
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+)
+
+// cmdbAuditForwarder forward kms audit events to cmdb audit asynchronously, not block kms operations
+type cmdbAuditForwarder struct {
+	bdl *bundle.Bundle
+}
+
+func newCMDBAuditForwarder(bdl *bundle.Bundle) *cmdbAuditForwarder {
+	return &cmdbAuditForwarder{bdl: bdl}
+}
+
+func (f *cmdbAuditForwarder) Forward(event kmstypes.AuditEvent) error {
+	audit := convertAuditEvent(event)
+	go func() {
+		if err := f.bdl.CreateAuditEvent(&apistructs.AuditCreateRequest{Audit: audit}); err != nil {
+			logrus.Errorf("failed to forward kms audit event to cmdb, requestID: %s, err: %v", event.RequestID, err)
+		}
+	}()
+	return nil
+}
+
+func convertAuditEvent(event kmstypes.AuditEvent) apistructs.Audit {
+	// cmdb audit requires user id, use internal client if called by other services
+	userID := event.Caller.UserID
+	if userID == "" {
+		userID = event.Caller.InternalClient
+	}
+	result := apistructs.SuccessfulResult
+	if !event.Success {
+		result = apistructs.FailureResult
+	}
+	operatedAt := strconv.FormatInt(event.OperatedAt.Unix(), 10)
+	return apistructs.Audit{
+		UserID: userID,
+		// kms keys do not belong to any org, cmdb audit requires non-zero scope id
+		ScopeType: apistructs.SysScope,
+		ScopeID:   1,
+		Context: map[string]interface{}{
+			"operation":      event.Operation,
+			"keyID":          event.KeyID,
+			"keyVersionID":   event.KeyVersionID,
+			"internalClient": event.Caller.InternalClient,
+			"requestID":      event.RequestID,
+		},
+		TemplateName: apistructs.KmsOperationTemplate,
+		Result:       result,
+		ErrorMsg:     event.ErrorMsg,
+		StartTime:    operatedAt,
+		EndTime:      operatedAt,
+		ClientIP:     event.Caller.ClientIP,
+	}
+}
@@ -15,8 +15,11 @@
 package conf
 
 import (
+	"time"
+
 	"github.com/erda-project/erda/pkg/envconf"
 	"github.com/erda-project/erda/pkg/kms/kmstypes"
+	"github.com/erda-project/erda/pkg/kms/stores/mysql"
 )
 
 // Conf define config from envs.
@@ -25,6 +28,21 @@ type Conf struct {
 	Debug         bool               `env:"DEBUG" default:"false"`
 	KmsStoreKind  kmstypes.StoreKind `env:"KMS_STORE_KIND" default:"ETCD"`
 	EtcdEndpoints string             `env:"ETCD_ENDPOINTS" required:"false"`
+
+	// interval of key lifecycle scheduled tasks, e.g. automatic rotation and scheduled deletion
+	ScheduledTasksInterval time.Duration `env:"KMS_SCHEDULED_TASKS_INTERVAL" default:"1m"`
+
+	// audit, events exceeded retention are deleted, etcd store expires them by lease
+	AuditRetention   time.Duration `env:"KMS_AUDIT_RETENTION" default:"720h"`
+	AuditForwardCMDB bool          `env:"KMS_AUDIT_FORWARD_CMDB" default:"false"`
+
+	// mysql store
+	MySQLURL      string `env:"MYSQL_URL" required:"false"`
+	MySQLHost     string `env:"MYSQL_HOST" required:"false"`
+	MySQLPort     string `env:"MYSQL_PORT" default:"3306"`
+	MySQLUsername string `env:"MYSQL_USERNAME" required:"false"`
+	MySQLPassword string `env:"MYSQL_PASSWORD" required:"false"`
+	MySQLDatabase string `env:"MYSQL_DATABASE" required:"false"`
 }
 
 var cfg Conf
@@ -33,10 +51,15 @@ var cfg Conf
 func Load() {
 	envconf.MustLoad(&cfg)
 
-	if cfg.KmsStoreKind == kmstypes.StoreKind_ETCD {
+	switch cfg.KmsStoreKind {
+	case kmstypes.StoreKind_ETCD:
 		if len(cfg.EtcdEndpoints) == 0 {
 			panic("missing env ETCD_ENDPOINTS while KMS_STORE_KIND is ETCD")
 		}
+	case kmstypes.StoreKind_MYSQL:
+		if len(cfg.MySQLURL) == 0 && len(cfg.MySQLHost) == 0 {
+			panic("missing env MYSQL_HOST or MYSQL_URL while KMS_STORE_KIND is MYSQL")
+		}
 	}
 }
 
@@ -57,3 +80,29 @@ func KmsStoreKind() kmstypes.StoreKind {
 func EtcdEndpoints() string {
 	return cfg.EtcdEndpoints
 }
+
+func ScheduledTasksInterval() time.Duration {
+	return cfg.ScheduledTasksInterval
+}
+
+// AuditRetention return how long audit events are kept.
+func AuditRetention() time.Duration {
+	return cfg.AuditRetention
+}
+
+// AuditForwardCMDB return whether forward audit events to cmdb audit.
+func AuditForwardCMDB() bool {
+	return cfg.AuditForwardCMDB
+}
+
+// MySQLConfigs return configs of mysql store.
+func MySQLConfigs() map[string]string {
+	return map[string]string{
+		mysql.EnvKeyMySQLURL:      cfg.MySQLURL,
+		mysql.EnvKeyMySQLHost:     cfg.MySQLHost,
+		mysql.EnvKeyMySQLPort:     cfg.MySQLPort,
+		mysql.EnvKeyMySQLUsername: cfg.MySQLUsername,
+		mysql.EnvKeyMySQLPassword: cfg.MySQLPassword,
+		mysql.EnvKeyMySQLDatabase: cfg.MySQLDatabase,
+	}
+}
@@ -55,3 +55,22 @@ func normalLoad(t *testing.T) {
 	assert.Equal(t, ListenAddr(), ":3082")
 	assert.False(t, Debug())
 }
+
+func TestLoadMySQL(t *testing.T) {
+	_ = os.Setenv(envKeyKmsStoreKind, kmstypes.StoreKind_MYSQL.String())
+	defer func() { _ = os.Unsetenv(envKeyKmsStoreKind) }()
+
+	// missing mysql host
+	func() {
+		defer func() { recover() }()
+		Load()
+		t.Errorf("shold have panicked")
+	}()
+
+	_ = os.Setenv("MYSQL_HOST", "localhost")
+	defer func() { _ = os.Unsetenv("MYSQL_HOST") }()
+	Load()
+	assert.Equal(t, kmstypes.StoreKind_MYSQL, KmsStoreKind())
+	assert.Equal(t, "localhost", MySQLConfigs()["MYSQL_HOST"])
+	assert.Equal(t, "3306", MySQLConfigs()["MYSQL_PORT"])
+}
@@ -19,14 +19,27 @@ import (
 )
 
 var (
-	ErrCheckIdentity    = err("ErrCheckIdentity", "身份校验失败")
-	ErrParseRequest     = err("ErrParseRequest", "解析请求失败")
-	ErrCreateKey        = err("ErrCreateKey", "创建 KMS 用户主密钥失败")
-	ErrEncrypt          = err("ErrEncrypt", "对称加密失败")
-	ErrDecrypt          = err("ErrDecrypt", "对称解密失败")
-	ErrGenerateDataKey  = err("ErrGenerateDataKey", "生成数据加密密钥失败")
-	ErrRotateKeyVersion = err("ErrRotateKeyVersion", "轮转密钥版本失败")
-	ErrDescribeKey      = err("ErrDescribeKey", "查询用户主密钥失败")
+	ErrCheckIdentity     = err("ErrCheckIdentity", "身份校验失败")
+	ErrParseRequest      = err("ErrParseRequest", "解析请求失败")
+	ErrCreateKey         = err("ErrCreateKey", "创建 KMS 用户主密钥失败")
+	ErrEncrypt           = err("ErrEncrypt", "对称加密失败")
+	ErrDecrypt           = err("ErrDecrypt", "对称解密失败")
+	ErrGenerateDataKey   = err("ErrGenerateDataKey", "生成数据加密密钥失败")
+	ErrRotateKeyVersion  = err("ErrRotateKeyVersion", "轮转密钥版本失败")
+	ErrDescribeKey       = err("ErrDescribeKey", "查询用户主密钥失败")
+	ErrGetPublicKey      = err("ErrGetPublicKey", "获取公钥失败")
+	ErrAsymmetricDecrypt = err("ErrAsymmetricDecrypt", "非对称解密失败")
+	ErrSign              = err("ErrSign", "签名失败")
+	ErrVerify            = err("ErrVerify", "验签失败")
+	ErrReEncrypt         = err("ErrReEncrypt", "重新加密失败")
+
+	ErrEnableKey               = err("ErrEnableKey", "启用用户主密钥失败")
+	ErrDisableKey              = err("ErrDisableKey", "禁用用户主密钥失败")
+	ErrScheduleKeyDeletion     = err("ErrScheduleKeyDeletion", "计划删除用户主密钥失败")
+	ErrCancelKeyDeletion       = err("ErrCancelKeyDeletion", "取消删除用户主密钥失败")
+	ErrUpdateKeyRotationPolicy = err("ErrUpdateKeyRotationPolicy", "更新密钥自动轮转策略失败")
+
+	ErrListAuditEvents = err("ErrListAuditEvents", "查询密钥审计事件失败")
 )
 
 func err(template, defaultValue string) *errorresp.APIError {
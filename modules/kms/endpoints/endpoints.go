@@ -16,6 +16,7 @@ package endpoints
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/erda-project/erda/pkg/httpserver"
 	"github.com/erda-project/erda/pkg/kms"
@@ -47,7 +48,7 @@ func WithKmsManager(mgr *kms.Manager) Option {
 
 // Routes 返回 endpoints 的所有 endpoint 方法，也就是 route.
 func (e *Endpoints) Routes() []httpserver.Endpoint {
-	return []httpserver.Endpoint{
+	routes := []httpserver.Endpoint{
 		{Path: "/health", Method: http.MethodGet, Handler: e.Health},
 
 		// kms
@@ -57,5 +58,27 @@ func (e *Endpoints) Routes() []httpserver.Endpoint {
 		{Path: "/api/kms/generate-data-key", Method: http.MethodPost, Handler: e.KmsGenerateDataKey},
 		{Path: "/api/kms/rotate-key-version", Method: http.MethodPost, Handler: e.KmsRotateKeyVersion},
 		{Path: "/api/kms/describe-key", Method: http.MethodGet, Handler: e.KmsRotateKeyVersion},
+		{Path: "/api/kms/get-public-key", Method: http.MethodGet, Handler: e.KmsGetPublicKey},
+		{Path: "/api/kms/asymmetric-decrypt", Method: http.MethodPost, Handler: e.KmsAsymmetricDecrypt},
+		{Path: "/api/kms/sign", Method: http.MethodPost, Handler: e.KmsSign},
+		{Path: "/api/kms/verify", Method: http.MethodPost, Handler: e.KmsVerify},
+		{Path: "/api/kms/re-encrypt", Method: http.MethodPost, Handler: e.KmsReEncrypt},
+
+		// key lifecycle
+		{Path: "/api/kms/enable-key", Method: http.MethodPost, Handler: e.KmsEnableKey},
+		{Path: "/api/kms/disable-key", Method: http.MethodPost, Handler: e.KmsDisableKey},
+		{Path: "/api/kms/schedule-key-deletion", Method: http.MethodPost, Handler: e.KmsScheduleKeyDeletion},
+		{Path: "/api/kms/cancel-key-deletion", Method: http.MethodPost, Handler: e.KmsCancelKeyDeletion},
+		{Path: "/api/kms/update-key-rotation-policy", Method: http.MethodPost, Handler: e.KmsUpdateKeyRotationPolicy},
+
+		// audit
+		{Path: "/api/kms/audit-events", Method: http.MethodGet, Handler: e.KmsListAuditEvents},
+	}
+	// inject caller and request id for audit
+	for i := range routes {
+		if routes[i].Handler != nil && strings.HasPrefix(routes[i].Path, "/api/kms") {
+			routes[i].Handler = withAuditContext(routes[i].Handler)
+		}
 	}
+	return routes
 }
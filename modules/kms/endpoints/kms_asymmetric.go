@@ -0,0 +1,95 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package endpoints
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/erda-project/erda/modules/kms/endpoints/apierrors"
+	"github.com/erda-project/erda/pkg/httpserver"
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+)
+
+func (e *Endpoints) KmsGetPublicKey(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	var req kmstypes.GetPublicKeyRequest
+	if err := e.parseRequestBody(r, &req); err != nil {
+		return err.ToResp(), nil
+	}
+
+	plugin, err := e.getPluginByKeyID(req.KeyID)
+	if err != nil {
+		return apierrors.ErrGetPublicKey.InvalidParameter(err).ToResp(), nil
+	}
+	publicKey, err := plugin.GetPublicKey(ctx, &req)
+	if err != nil {
+		return apierrors.ErrGetPublicKey.InternalError(err).ToResp(), nil
+	}
+
+	return httpserver.OkResp(publicKey)
+}
+
+func (e *Endpoints) KmsAsymmetricDecrypt(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	var req kmstypes.AsymmetricDecryptRequest
+	if err := e.parseRequestBody(r, &req); err != nil {
+		return err.ToResp(), nil
+	}
+
+	plugin, err := e.getPluginByKeyID(req.KeyID)
+	if err != nil {
+		return apierrors.ErrAsymmetricDecrypt.InvalidParameter(err).ToResp(), nil
+	}
+	decryptResp, err := plugin.AsymmetricDecrypt(ctx, &req)
+	if err != nil {
+		return apierrors.ErrAsymmetricDecrypt.InternalError(err).ToResp(), nil
+	}
+
+	return httpserver.OkResp(decryptResp)
+}
+
+func (e *Endpoints) KmsSign(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	var req kmstypes.SignRequest
+	if err := e.parseRequestBody(r, &req); err != nil {
+		return err.ToResp(), nil
+	}
+
+	plugin, err := e.getPluginByKeyID(req.KeyID)
+	if err != nil {
+		return apierrors.ErrSign.InvalidParameter(err).ToResp(), nil
+	}
+	signResp, err := plugin.Sign(ctx, &req)
+	if err != nil {
+		return apierrors.ErrSign.InternalError(err).ToResp(), nil
+	}
+
+	return httpserver.OkResp(signResp)
+}
+
+func (e *Endpoints) KmsVerify(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	var req kmstypes.VerifyRequest
+	if err := e.parseRequestBody(r, &req); err != nil {
+		return err.ToResp(), nil
+	}
+
+	plugin, err := e.getPluginByKeyID(req.KeyID)
+	if err != nil {
+		return apierrors.ErrVerify.InvalidParameter(err).ToResp(), nil
+	}
+	verifyResp, err := plugin.Verify(ctx, &req)
+	if err != nil {
+		return apierrors.ErrVerify.InternalError(err).ToResp(), nil
+	}
+
+	return httpserver.OkResp(verifyResp)
+}
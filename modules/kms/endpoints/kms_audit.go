@@ -0,0 +1,78 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package endpoints
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/erda-project/erda/modules/kms/conf"
+	"github.com/erda-project/erda/modules/kms/endpoints/apierrors"
+	"github.com/erda-project/erda/pkg/httpserver"
+	"github.com/erda-project/erda/pkg/httputil"
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+	"github.com/erda-project/erda/pkg/uuid"
+)
+
+const headerRequestID = "X-Request-Id"
+
+func (e *Endpoints) KmsListAuditEvents(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	var req kmstypes.ListAuditEventsRequest
+	if err := e.parseRequestQuery(r, &req); err != nil {
+		return err.ToResp(), nil
+	}
+
+	store, err := e.KmsMgr.GetStore(conf.KmsStoreKind())
+	if err != nil {
+		return apierrors.ErrListAuditEvents.InternalError(err).ToResp(), nil
+	}
+	total, events, err := store.ListAuditEvents(&req)
+	if err != nil {
+		return apierrors.ErrListAuditEvents.InternalError(err).ToResp(), nil
+	}
+
+	return httpserver.OkResp(kmstypes.ListAuditEventsResponse{Total: total, List: events})
+}
+
+// withAuditContext inject caller identity and request id into context, which are recorded in audit events
+func withAuditContext(handler func(context.Context, *http.Request, map[string]string) (httpserver.Responser, error)) func(context.Context, *http.Request, map[string]string) (httpserver.Responser, error) {
+	return func(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+		requestID := r.Header.Get(headerRequestID)
+		if requestID == "" {
+			requestID = uuid.UUID()
+		}
+		caller := kmstypes.Caller{
+			UserID:         r.Header.Get(httputil.UserHeader),
+			InternalClient: r.Header.Get(httputil.InternalHeader),
+			ClientIP:       getClientIP(r),
+		}
+		ctx = context.WithValue(ctx, kmstypes.CtxKeyKmsRequestID, requestID)
+		ctx = context.WithValue(ctx, kmstypes.CtxKeyKmsCaller, &caller)
+		return handler(ctx, r, vars)
+	}
+}
+
+// getClientIP return the first ip of X-Forwarded-For, or remote ip if not forwarded
+func getClientIP(r *http.Request) string {
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		return strings.TrimSpace(strings.Split(forwardedFor, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
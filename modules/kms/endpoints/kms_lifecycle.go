@@ -0,0 +1,113 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package endpoints
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/erda-project/erda/modules/kms/endpoints/apierrors"
+	"github.com/erda-project/erda/pkg/httpserver"
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+)
+
+func (e *Endpoints) KmsEnableKey(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	var req kmstypes.EnableKeyRequest
+	if err := e.parseRequestBody(r, &req); err != nil {
+		return err.ToResp(), nil
+	}
+
+	plugin, err := e.getPluginByKeyID(req.KeyID)
+	if err != nil {
+		return apierrors.ErrEnableKey.InvalidParameter(err).ToResp(), nil
+	}
+	enableResp, err := plugin.EnableKey(ctx, &req)
+	if err != nil {
+		return apierrors.ErrEnableKey.InternalError(err).ToResp(), nil
+	}
+
+	return httpserver.OkResp(enableResp)
+}
+
+func (e *Endpoints) KmsDisableKey(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	var req kmstypes.DisableKeyRequest
+	if err := e.parseRequestBody(r, &req); err != nil {
+		return err.ToResp(), nil
+	}
+
+	plugin, err := e.getPluginByKeyID(req.KeyID)
+	if err != nil {
+		return apierrors.ErrDisableKey.InvalidParameter(err).ToResp(), nil
+	}
+	disableResp, err := plugin.DisableKey(ctx, &req)
+	if err != nil {
+		return apierrors.ErrDisableKey.InternalError(err).ToResp(), nil
+	}
+
+	return httpserver.OkResp(disableResp)
+}
+
+func (e *Endpoints) KmsScheduleKeyDeletion(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	var req kmstypes.ScheduleKeyDeletionRequest
+	if err := e.parseRequestBody(r, &req); err != nil {
+		return err.ToResp(), nil
+	}
+
+	plugin, err := e.getPluginByKeyID(req.KeyID)
+	if err != nil {
+		return apierrors.ErrScheduleKeyDeletion.InvalidParameter(err).ToResp(), nil
+	}
+	scheduleResp, err := plugin.ScheduleKeyDeletion(ctx, &req)
+	if err != nil {
+		return apierrors.ErrScheduleKeyDeletion.InternalError(err).ToResp(), nil
+	}
+
+	return httpserver.OkResp(scheduleResp)
+}
+
+func (e *Endpoints) KmsCancelKeyDeletion(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	var req kmstypes.CancelKeyDeletionRequest
+	if err := e.parseRequestBody(r, &req); err != nil {
+		return err.ToResp(), nil
+	}
+
+	plugin, err := e.getPluginByKeyID(req.KeyID)
+	if err != nil {
+		return apierrors.ErrCancelKeyDeletion.InvalidParameter(err).ToResp(), nil
+	}
+	cancelResp, err := plugin.CancelKeyDeletion(ctx, &req)
+	if err != nil {
+		return apierrors.ErrCancelKeyDeletion.InternalError(err).ToResp(), nil
+	}
+
+	return httpserver.OkResp(cancelResp)
+}
+
+func (e *Endpoints) KmsUpdateKeyRotationPolicy(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	var req kmstypes.UpdateKeyRotationPolicyRequest
+	if err := e.parseRequestBody(r, &req); err != nil {
+		return err.ToResp(), nil
+	}
+
+	plugin, err := e.getPluginByKeyID(req.KeyID)
+	if err != nil {
+		return apierrors.ErrUpdateKeyRotationPolicy.InvalidParameter(err).ToResp(), nil
+	}
+	policyResp, err := plugin.UpdateKeyRotationPolicy(ctx, &req)
+	if err != nil {
+		return apierrors.ErrUpdateKeyRotationPolicy.InternalError(err).ToResp(), nil
+	}
+
+	return httpserver.OkResp(policyResp)
+}
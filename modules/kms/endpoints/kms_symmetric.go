@@ -93,3 +93,21 @@ func (e *Endpoints) KmsRotateKeyVersion(ctx context.Context, r *http.Request, va
 
 	return httpserver.OkResp(rotateResp)
 }
+
+func (e *Endpoints) KmsReEncrypt(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	var req kmstypes.ReEncryptRequest
+	if err := e.parseRequestBody(r, &req); err != nil {
+		return err.ToResp(), nil
+	}
+
+	plugin, err := e.getPluginByKeyID(req.KeyID)
+	if err != nil {
+		return apierrors.ErrReEncrypt.InternalError(err).ToResp(), nil
+	}
+	reEncryptResp, err := plugin.ReEncrypt(ctx, &req)
+	if err != nil {
+		return apierrors.ErrReEncrypt.InternalError(err).ToResp(), nil
+	}
+
+	return httpserver.OkResp(reEncryptResp)
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/gorilla/schema"
 	"github.com/sirupsen/logrus"
 
 	"github.com/erda-project/erda/modules/kms/conf"
@@ -27,6 +28,12 @@ import (
 	"github.com/erda-project/erda/pkg/kms/kmstypes"
 )
 
+var queryStringDecoder = func() *schema.Decoder {
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	return decoder
+}()
+
 // getPluginByKeyID 根据 keyID 获取对应的 plugin
 func (e *Endpoints) getPluginByKeyID(keyID string) (kmstypes.Plugin, error) {
 	store, err := e.KmsMgr.GetStore(conf.KmsStoreKind())
@@ -57,6 +64,20 @@ func (e *Endpoints) parseRequestBody(r *http.Request, req kmstypes.RequestValida
 	return nil
 }
 
+// parseRequestQuery decode url query params into req, return *errorresp.APIError
+func (e *Endpoints) parseRequestQuery(r *http.Request, req kmstypes.RequestValidator) *errorresp.APIError {
+	if err := e.checkIdentity(r); err != nil {
+		return apierrors.ErrCheckIdentity.InvalidParameter(err)
+	}
+	if err := queryStringDecoder.Decode(req, r.URL.Query()); err != nil {
+		return apierrors.ErrParseRequest.InvalidParameter(err)
+	}
+	if err := req.ValidateRequest(); err != nil {
+		return apierrors.ErrParseRequest.InvalidParameter(err)
+	}
+	return nil
+}
+
 func (e *Endpoints) checkIdentity(r *http.Request) (err error) {
 	defer func() {
 		if err != nil {
@@ -15,12 +15,18 @@
 package kms
 
 import (
+	"context"
+	"time"
+
 	"github.com/sirupsen/logrus"
 
+	"github.com/erda-project/erda/bundle"
 	"github.com/erda-project/erda/modules/kms/conf"
 	"github.com/erda-project/erda/modules/kms/endpoints"
+	"github.com/erda-project/erda/pkg/dlock"
 	"github.com/erda-project/erda/pkg/httpserver"
 	"github.com/erda-project/erda/pkg/kms"
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
 	"github.com/erda-project/erda/pkg/kms/stores/etcd"
 )
 
@@ -33,9 +39,14 @@ func Initialize() error {
 		logrus.SetLevel(logrus.DebugLevel)
 	}
 
-	kmsMgr, err := kms.GetManager(kms.WithStoreConfigs(map[string]string{
-		etcd.EnvKeyEtcdEndpoints: conf.EtcdEndpoints(),
-	}))
+	storeConfigs := conf.MySQLConfigs()
+	storeConfigs[etcd.EnvKeyEtcdEndpoints] = conf.EtcdEndpoints()
+	storeConfigs[etcd.EnvKeyAuditRetention] = conf.AuditRetention().String()
+	mgrOptions := []kms.Option{kms.WithStoreConfigs(storeConfigs)}
+	if conf.AuditForwardCMDB() {
+		mgrOptions = append(mgrOptions, kms.WithAuditForwarder(newCMDBAuditForwarder(bundle.New(bundle.WithCMDB()))))
+	}
+	kmsMgr, err := kms.GetManager(mgrOptions...)
 	if err != nil {
 		return err
 	}
@@ -49,9 +60,77 @@ func Initialize() error {
 		return err
 	}
 
+	go runScheduledTasksWithLock(kmsMgr)
+
 	return server.ListenAndServe()
 }
 
+const scheduledTasksLockKey = "/dice/kms/scheduled-tasks/lock"
+
 func do() error {
 	return nil
 }
+
+// runScheduledTasksWithLock run scheduled tasks only on the instance holding the dlock,
+// other instances wait until the lock is released or lost.
+// Without etcd (mysql store), every instance runs scheduled tasks, rotation is still protected by compare-and-swap of store.
+func runScheduledTasksWithLock(kmsMgr *kms.Manager) {
+	if conf.EtcdEndpoints() == "" {
+		runScheduledTasks(context.Background(), kmsMgr)
+		return
+	}
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+		lock, err := dlock.New(scheduledTasksLockKey, func() { cancel() })
+		if err != nil {
+			logrus.Errorf("failed to get dlock of kms scheduled tasks, err: %v", err)
+			cancel()
+			time.Sleep(conf.ScheduledTasksInterval())
+			continue
+		}
+		if err := lock.Lock(ctx); err != nil {
+			logrus.Errorf("failed to lock kms scheduled tasks, err: %v", err)
+			_ = lock.Close()
+			cancel()
+			time.Sleep(conf.ScheduledTasksInterval())
+			continue
+		}
+		logrus.Infof("kms scheduled tasks lock acquired, start to run scheduled tasks")
+		runScheduledTasks(ctx, kmsMgr)
+		if err := lock.UnlockAndClose(); err != nil {
+			logrus.Errorf("failed to unlock kms scheduled tasks, err: %v", err)
+		}
+		cancel()
+	}
+}
+
+// runScheduledTasks execute key lifecycle scheduled tasks of all plugins periodically until ctx done,
+// include automatic key rotation and deletion of keys reached deletion date,
+// and clean audit events exceeded retention
+func runScheduledTasks(ctx context.Context, kmsMgr *kms.Manager) {
+	ticker := time.NewTicker(conf.ScheduledTasksInterval())
+	defer ticker.Stop()
+	for {
+		var now time.Time
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+		if store, err := kmsMgr.GetStore(conf.KmsStoreKind()); err != nil {
+			logrus.Errorf("failed to get kms store, err: %v", err)
+		} else if err := store.DeleteAuditEventsBefore(now.Add(-conf.AuditRetention())); err != nil {
+			logrus.Errorf("failed to clean kms audit events, err: %v", err)
+		}
+		for pluginKind := range kmstypes.PluginFactory {
+			plugin, err := kmsMgr.GetPlugin(pluginKind, conf.KmsStoreKind())
+			if err != nil {
+				logrus.Errorf("failed to get kms plugin %s, err: %v", pluginKind, err)
+				continue
+			}
+			if err := plugin.ExecuteScheduledTasks(ctx, now); err != nil {
+				logrus.Errorf("failed to execute scheduled tasks of kms plugin %s, err: %v", pluginKind, err)
+			}
+		}
+	}
+}
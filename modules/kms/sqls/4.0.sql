@@ -0,0 +1,72 @@
+CREATE TABLE IF NOT EXISTS `kms_keys`
+(
+    `id`                     BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`             DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`             DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `key_id`                 VARCHAR(64)         NOT NULL COMMENT 'CMK id',
+    `plugin_kind`            VARCHAR(32)         NOT NULL COMMENT 'kms plugin kind, e.g. DICE_KMS',
+    `key_spec`               VARCHAR(32)         NOT NULL COMMENT 'customer master key spec, e.g. SYMMETRIC_DEFAULT',
+    `key_usage`              VARCHAR(32)         NOT NULL COMMENT 'key usage, ENCRYPT_DECRYPT or SIGN_VERIFY',
+    `key_state`              VARCHAR(32)         NOT NULL COMMENT 'key state',
+    `remark`                 VARCHAR(1024)       NOT NULL DEFAULT '' COMMENT 'key description',
+    `primary_key_version_id` VARCHAR(64)         NOT NULL COMMENT 'primary key version id',
+    `rotation_period_days`   INT(11)             NOT NULL DEFAULT 0 COMMENT 'period of automatic key rotation in days, 0 means disabled',
+    `has_next_rotation`      TINYINT(1)          NOT NULL DEFAULT 0 COMMENT 'whether next_rotation_at is set',
+    `next_rotation_at`       DATETIME            NOT NULL DEFAULT '1970-01-01 00:00:00' COMMENT 'next automatic key rotation time, valid only if has_next_rotation',
+    `has_deletion_date`      TINYINT(1)          NOT NULL DEFAULT 0 COMMENT 'whether deletion_date is set',
+    `deletion_date`          DATETIME            NOT NULL DEFAULT '1970-01-01 00:00:00' COMMENT 'scheduled deletion time, valid only if has_deletion_date',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_key_id` (`key_id`),
+    KEY `idx_plugin_kind` (`plugin_kind`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='kms customer master keys';
+
+CREATE TABLE IF NOT EXISTS `kms_key_versions`
+(
+    `id`                   BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`           DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`           DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `key_id`               VARCHAR(64)         NOT NULL COMMENT 'CMK id',
+    `version_id`           VARCHAR(64)         NOT NULL COMMENT 'key version id',
+    `symmetric_key_base64` TEXT                NOT NULL COMMENT 'base64 encoded symmetric key material',
+    `private_key_base64`   TEXT                NOT NULL COMMENT 'base64 encoded PKCS#8 private key material',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_key_id_version_id` (`key_id`, `version_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='kms key versions';
+
+CREATE TABLE IF NOT EXISTS `kms_key_rotations`
+(
+    `id`              BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`      DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`      DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `key_id`          VARCHAR(64)         NOT NULL COMMENT 'CMK id',
+    `from_version_id` VARCHAR(64)         NOT NULL COMMENT 'primary key version id before rotation',
+    `to_version_id`   VARCHAR(64)         NOT NULL COMMENT 'primary key version id after rotation',
+    `rotated_at`      DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'rotated time',
+    PRIMARY KEY (`id`),
+    KEY `idx_key_id` (`key_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='kms key version rotation history';
+
+CREATE TABLE IF NOT EXISTS `kms_audit_events`
+(
+    `id`              BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`      DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`      DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `request_id`      VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'kms request id',
+    `user_id`         VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'caller user id',
+    `internal_client` VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'caller internal client, e.g. the service name',
+    `client_ip`       VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'caller ip',
+    `operation`       VARCHAR(64)         NOT NULL COMMENT 'kms operation, e.g. Decrypt',
+    `plugin_kind`     VARCHAR(32)         NOT NULL DEFAULT '' COMMENT 'kms plugin kind',
+    `key_id`          VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'CMK id',
+    `key_version_id`  VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'key version id',
+    `is_success`      TINYINT(1)          NOT NULL DEFAULT 0 COMMENT 'whether the operation succeeded',
+    `error_msg`       VARCHAR(1024)       NOT NULL DEFAULT '' COMMENT 'error message if failed',
+    `operated_at`     DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'operated time',
+    PRIMARY KEY (`id`),
+    KEY `idx_key_id_operated_at` (`key_id`, `operated_at`),
+    KEY `idx_operated_at` (`operated_at`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='kms audit events';
@@ -40,6 +40,17 @@ type Conf struct {
 	InitContainerImage   string `env:"INIT_CONTAINER_IMAGE" default:"registry.cn-hangzhou.aliyuncs.com/dice-third-party/curl:stable"`
 	TokenClientID        string `env:"TOKEN_CLIENT_ID" default:"orchestrator"`
 	TokenClientSecret    string `env:"TOKEN_CLIENT_SECRET" default:"devops/orchestrator"`
+	PreviewRuntimeQuota  int    `env:"PREVIEW_RUNTIME_QUOTA" default:"5"`
+	PreviewRuntimeTTL    int64  `env:"PREVIEW_RUNTIME_TTL_HOURS" default:"72"`
+	// merge request 事件回调的校验 token, 为空时不注册回调, 预览环境只按 TTL 回收
+	PreviewRuntimeHookToken string `env:"PREVIEW_RUNTIME_HOOK_TOKEN" default:""`
+	AddonBackupImage        string `env:"ADDON_BACKUP_IMAGE" default:"registry.cn-hangzhou.aliyuncs.com/dice/addon-backup:latest"`
+	// ACME 证书签发, 测试时可指向 pebble 等本地 ACME 服务
+	AcmeDirectoryURL       string `env:"ACME_DIRECTORY_URL" default:"https://acme-v02.api.letsencrypt.org/directory"`
+	AcmeEmail              string `env:"ACME_EMAIL" default:""`
+	AcmeInsecureSkipVerify bool   `env:"ACME_INSECURE_SKIP_VERIFY" default:"false"`
+	AcmeRenewBeforeDays    int    `env:"ACME_RENEW_BEFORE_DAYS" default:"30"`
+	CertExpiryAlertDays    int    `env:"CERT_EXPIRY_ALERT_DAYS" default:"14"`
 }
 
 var cfg Conf
@@ -148,3 +159,48 @@ func TokenClientID() string {
 func TokenClientSecret() string {
 	return cfg.TokenClientSecret
 }
+
+// PreviewRuntimeQuota 返回每个项目可同时存在的预览环境数量上限.
+func PreviewRuntimeQuota() int {
+	return cfg.PreviewRuntimeQuota
+}
+
+// PreviewRuntimeTTL 返回预览环境默认存活时长, 单位小时.
+func PreviewRuntimeTTL() int64 {
+	return cfg.PreviewRuntimeTTL
+}
+
+// PreviewRuntimeHookToken 返回 merge request 事件回调的校验 token.
+func PreviewRuntimeHookToken() string {
+	return cfg.PreviewRuntimeHookToken
+}
+
+// AddonBackupImage 返回执行 addon 备份/恢复 job 的镜像.
+func AddonBackupImage() string {
+	return cfg.AddonBackupImage
+}
+
+// AcmeDirectoryURL 返回 ACME 服务的 directory 地址.
+func AcmeDirectoryURL() string {
+	return cfg.AcmeDirectoryURL
+}
+
+// AcmeEmail 返回注册 ACME 账号使用的邮箱.
+func AcmeEmail() string {
+	return cfg.AcmeEmail
+}
+
+// AcmeInsecureSkipVerify 返回访问 ACME 服务时是否跳过 TLS 校验, 仅用于测试环境.
+func AcmeInsecureSkipVerify() bool {
+	return cfg.AcmeInsecureSkipVerify
+}
+
+// AcmeRenewBeforeDays 返回 ACME 证书在过期前多少天自动续期.
+func AcmeRenewBeforeDays() int {
+	return cfg.AcmeRenewBeforeDays
+}
+
+// CertExpiryAlertDays 返回域名证书在过期前多少天开始告警.
+func CertExpiryAlertDays() int {
+	return cfg.CertExpiryAlertDays
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/dbengine"
+)
+
+// AddonBackupPolicy addon 备份策略表, 每个 addon 实例至多一条
+type AddonBackupPolicy struct {
+	dbengine.BaseModel
+	AddonID         string `gorm:"type:varchar(64);not null;unique_index:idx_addon_id"` // routing instance id
+	AddonName       string
+	Schedule        string `gorm:"not null"`
+	Retention       int
+	Endpoint        string
+	Bucket          string
+	AccessKey       string
+	SecretKey       string // 加密存储
+	PathPrefix      string
+	Enabled         bool
+	LastScheduledAt *time.Time
+	Creator         string
+}
+
+// TableName 数据库表名
+func (AddonBackupPolicy) TableName() string {
+	return "tb_addon_backup_policy"
+}
+
+// AddonBackup addon 备份记录表
+type AddonBackup struct {
+	dbengine.BaseModel
+	AddonID    string `gorm:"type:varchar(64);not null;index:idx_addon_id"`
+	InstanceID string `gorm:"type:varchar(64)"` // 备份时的 real instance id
+	AddonName  string
+	Type       apistructs.AddonBackupType
+	Status     apistructs.AddonBackupStatus
+	Endpoint   string
+	Bucket     string
+	AccessKey  string
+	SecretKey  string // 加密存储, 备份时的存储凭证, 备份策略修改或删除后仍可用于恢复
+	ObjectName string
+	JobName    string
+	Message    string `gorm:"type:text"`
+	Operator   string
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// TableName 数据库表名
+func (AddonBackup) TableName() string {
+	return "tb_addon_backup"
+}
+
+// AddonRestore addon 恢复记录表
+type AddonRestore struct {
+	dbengine.BaseModel
+	BackupID      uint64 `gorm:"not null"`
+	SourceAddonID string `gorm:"type:varchar(64);not null;index:idx_source_addon_id"`
+	TargetAddonID string `gorm:"type:varchar(64)"`
+	Status        apistructs.AddonBackupStatus
+	JobName       string
+	Message       string `gorm:"type:text"`
+	Operator      string
+	StartedAt     *time.Time
+	FinishedAt    *time.Time
+}
+
+// TableName 数据库表名
+func (AddonRestore) TableName() string {
+	return "tb_addon_restore"
+}
+
+// GetAddonBackupPolicy 查询 addon 实例的备份策略, 不存在时返回 nil
+func (db *DBClient) GetAddonBackupPolicy(addonID string) (*AddonBackupPolicy, error) {
+	var policy AddonBackupPolicy
+	if err := db.Where("addon_id = ?", addonID).Take(&policy).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get addon backup policy, addonID: %s", addonID)
+	}
+	return &policy, nil
+}
+
+// SaveAddonBackupPolicy 创建或更新备份策略
+func (db *DBClient) SaveAddonBackupPolicy(policy *AddonBackupPolicy) error {
+	if err := db.Save(policy).Error; err != nil {
+		return errors.Wrapf(err, "failed to save addon backup policy, addonID: %s", policy.AddonID)
+	}
+	return nil
+}
+
+// DeleteAddonBackupPolicy 删除备份策略
+func (db *DBClient) DeleteAddonBackupPolicy(addonID string) error {
+	if err := db.
+		Where("addon_id = ?", addonID).
+		Delete(&AddonBackupPolicy{}).Error; err != nil {
+		return errors.Wrapf(err, "failed to delete addon backup policy, addonID: %s", addonID)
+	}
+	return nil
+}
+
+// FindEnabledAddonBackupPolicies 查询所有启用的备份策略
+func (db *DBClient) FindEnabledAddonBackupPolicies() ([]AddonBackupPolicy, error) {
+	var policies []AddonBackupPolicy
+	if err := db.Where("enabled = ?", true).Find(&policies).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to find enabled addon backup policies")
+	}
+	return policies, nil
+}
+
+// ClaimAddonBackupPolicy 以 last_scheduled_at 做乐观锁抢占本次定时备份, 返回是否抢占成功,
+// 避免多个 orchestrator 实例重复触发
+func (db *DBClient) ClaimAddonBackupPolicy(policy *AddonBackupPolicy, scheduledAt time.Time) (bool, error) {
+	q := db.Model(&AddonBackupPolicy{}).Where("id = ?", policy.ID)
+	if policy.LastScheduledAt == nil {
+		q = q.Where("last_scheduled_at IS NULL")
+	} else {
+		q = q.Where("last_scheduled_at = ?", *policy.LastScheduledAt)
+	}
+	r := q.Update("last_scheduled_at", scheduledAt)
+	if r.Error != nil {
+		return false, errors.Wrapf(r.Error, "failed to claim addon backup policy, id: %d", policy.ID)
+	}
+	if r.RowsAffected == 0 {
+		return false, nil
+	}
+	policy.LastScheduledAt = &scheduledAt
+	return true, nil
+}
+
+// CreateAddonBackup insert addonBackup
+func (db *DBClient) CreateAddonBackup(backup *AddonBackup) error {
+	return db.Create(backup).Error
+}
+
+// UpdateAddonBackup update addonBackup
+func (db *DBClient) UpdateAddonBackup(backup *AddonBackup) error {
+	if err := db.Save(backup).Error; err != nil {
+		return errors.Wrapf(err, "failed to update addon backup, id: %d", backup.ID)
+	}
+	return nil
+}
+
+// TransitAddonBackup 仅当备份记录仍处于 from 状态时更新其状态, 返回是否更新成功,
+// 避免多个 orchestrator 实例同步同一备份时相互覆盖
+func (db *DBClient) TransitAddonBackup(backup *AddonBackup, from apistructs.AddonBackupStatus) (bool, error) {
+	r := db.Model(&AddonBackup{}).
+		Where("id = ? AND status = ?", backup.ID, from).
+		Updates(map[string]interface{}{
+			"status":      backup.Status,
+			"message":     backup.Message,
+			"job_name":    backup.JobName,
+			"started_at":  backup.StartedAt,
+			"finished_at": backup.FinishedAt,
+		})
+	if r.Error != nil {
+		return false, errors.Wrapf(r.Error, "failed to update addon backup, id: %d", backup.ID)
+	}
+	return r.RowsAffected > 0, nil
+}
+
+// GetAddonBackup 根据 ID 查询备份记录
+func (db *DBClient) GetAddonBackup(id uint64) (*AddonBackup, error) {
+	var backup AddonBackup
+	if err := db.Where("id = ?", id).Take(&backup).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to get addon backup, id: %d", id)
+	}
+	return &backup, nil
+}
+
+// DeleteAddonBackup 删除备份记录
+func (db *DBClient) DeleteAddonBackup(id uint64) error {
+	if err := db.Where("id = ?", id).Delete(&AddonBackup{}).Error; err != nil {
+		return errors.Wrapf(err, "failed to delete addon backup, id: %d", id)
+	}
+	return nil
+}
+
+// FindAddonBackups 查询 addon 实例的备份记录, 按创建时间倒序
+func (db *DBClient) FindAddonBackups(addonID string) ([]AddonBackup, error) {
+	var backups []AddonBackup
+	if err := db.Where("addon_id = ?", addonID).Order("id desc").Find(&backups).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to find addon backups, addonID: %s", addonID)
+	}
+	return backups, nil
+}
+
+// FindSuccessfulScheduledAddonBackups 查询 addon 实例成功的定时备份, 按创建时间倒序
+func (db *DBClient) FindSuccessfulScheduledAddonBackups(addonID string) ([]AddonBackup, error) {
+	var backups []AddonBackup
+	if err := db.
+		Where("addon_id = ? AND type = ? AND status = ?",
+			addonID, apistructs.AddonBackupScheduled, apistructs.AddonBackupSuccess).
+		Order("id desc").
+		Find(&backups).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to find scheduled addon backups, addonID: %s", addonID)
+	}
+	return backups, nil
+}
+
+// FindUnfinishedAddonBackups 查询所有未结束的备份
+func (db *DBClient) FindUnfinishedAddonBackups() ([]AddonBackup, error) {
+	var backups []AddonBackup
+	if err := db.
+		Where("status IN (?)", []apistructs.AddonBackupStatus{apistructs.AddonBackupPending, apistructs.AddonBackupRunning}).
+		Find(&backups).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to find unfinished addon backups")
+	}
+	return backups, nil
+}
+
+// CreateAddonRestore insert addonRestore
+func (db *DBClient) CreateAddonRestore(restore *AddonRestore) error {
+	return db.Create(restore).Error
+}
+
+// TransitAddonRestore 仅当恢复记录仍处于 from 状态时更新其状态, 返回是否更新成功,
+// 避免多个 orchestrator 实例重复启动恢复 job 或相互覆盖结果
+func (db *DBClient) TransitAddonRestore(restore *AddonRestore, from apistructs.AddonBackupStatus) (bool, error) {
+	r := db.Model(&AddonRestore{}).
+		Where("id = ? AND status = ?", restore.ID, from).
+		Updates(map[string]interface{}{
+			"status":      restore.Status,
+			"message":     restore.Message,
+			"job_name":    restore.JobName,
+			"started_at":  restore.StartedAt,
+			"finished_at": restore.FinishedAt,
+		})
+	if r.Error != nil {
+		return false, errors.Wrapf(r.Error, "failed to update addon restore, id: %d", restore.ID)
+	}
+	return r.RowsAffected > 0, nil
+}
+
+// FindAddonRestores 查询以 addon 实例为来源的恢复记录, 按创建时间倒序
+func (db *DBClient) FindAddonRestores(sourceAddonID string) ([]AddonRestore, error) {
+	var restores []AddonRestore
+	if err := db.Where("source_addon_id = ?", sourceAddonID).Order("id desc").Find(&restores).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to find addon restores, addonID: %s", sourceAddonID)
+	}
+	return restores, nil
+}
+
+// FindUnfinishedAddonRestores 查询所有未结束的恢复
+func (db *DBClient) FindUnfinishedAddonRestores() ([]AddonRestore, error) {
+	var restores []AddonRestore
+	if err := db.
+		Where("status IN (?)", []apistructs.AddonBackupStatus{apistructs.AddonBackupPending, apistructs.AddonBackupRunning}).
+		Find(&restores).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to find unfinished addon restores")
+	}
+	return restores, nil
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/dbengine"
+)
+
+// AddonCredentialRotation addon 凭证轮换记录表
+type AddonCredentialRotation struct {
+	dbengine.BaseModel
+	AddonID      string `gorm:"type:varchar(64);not null;index:idx_addon_id"` // routing instance id
+	InstanceID   string `gorm:"type:varchar(64);not null;index:idx_instance_id"`
+	AddonName    string
+	OrgID        string
+	Status       apistructs.AddonCredentialRotationStatus
+	Stage        apistructs.AddonCredentialRotationStage
+	DualPassword bool
+	User         string // 轮换后 runtime 使用的 mysql 用户
+	OldUser      string // 不支持双密码时切换掉的旧用户, 宽限期结束后删除
+	GracePeriod  int    // 旧凭证宽限期, 单位分钟
+	GraceUntil   *time.Time
+	Runtimes     string `gorm:"type:text"` // 重新部署的 runtime 及 deployment, json 格式, 为空表示尚未重新部署
+	Message      string `gorm:"type:text"`
+	Operator     string
+	FinishedAt   *time.Time
+}
+
+// TableName 数据库表名
+func (AddonCredentialRotation) TableName() string {
+	return "tb_addon_credential_rotation"
+}
+
+// CreateAddonCredentialRotation 创建凭证轮换记录
+func (db *DBClient) CreateAddonCredentialRotation(rotation *AddonCredentialRotation) error {
+	if err := db.Create(rotation).Error; err != nil {
+		return errors.Wrapf(err, "failed to create addon credential rotation, addonID: %s", rotation.AddonID)
+	}
+	return nil
+}
+
+// UpdateAddonCredentialRotation 更新凭证轮换记录
+func (db *DBClient) UpdateAddonCredentialRotation(rotation *AddonCredentialRotation) error {
+	if err := db.Save(rotation).Error; err != nil {
+		return errors.Wrapf(err, "failed to update addon credential rotation, id: %d", rotation.ID)
+	}
+	return nil
+}
+
+// GetRunningAddonCredentialRotationByInstance 查询真实实例上进行中的凭证轮换, 不存在时返回 nil
+func (db *DBClient) GetRunningAddonCredentialRotationByInstance(instanceID string) (*AddonCredentialRotation, error) {
+	var rotation AddonCredentialRotation
+	if err := db.Where("instance_id = ?", instanceID).
+		Where("status = ?", apistructs.AddonCredentialRotationRunning).
+		Take(&rotation).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get running addon credential rotation, instanceID: %s", instanceID)
+	}
+	return &rotation, nil
+}
+
+// FindAddonCredentialRotations 查询 addon 的凭证轮换记录, 按时间倒序
+func (db *DBClient) FindAddonCredentialRotations(addonID string) ([]AddonCredentialRotation, error) {
+	var rotations []AddonCredentialRotation
+	if err := db.Where("addon_id = ?", addonID).Order("id desc").Find(&rotations).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to find addon credential rotations, addonID: %s", addonID)
+	}
+	return rotations, nil
+}
+
+// FailStaleAddonCredentialRotations 将 before 之后未再更新的启用新密码阶段及尚未记录 deployment 的重新部署阶段的轮换置为失败
+func (db *DBClient) FailStaleAddonCredentialRotations(before time.Time, message string) error {
+	if err := db.Model(&AddonCredentialRotation{}).
+		Where("status = ?", apistructs.AddonCredentialRotationRunning).
+		Where("stage = ? OR (stage = ? AND (runtimes IS NULL OR runtimes = ''))",
+			apistructs.AddonCredentialRotationApplying, apistructs.AddonCredentialRotationRestarting).
+		Where("updated_at < ?", before).
+		Updates(map[string]interface{}{
+			"status":      apistructs.AddonCredentialRotationFailed,
+			"message":     message,
+			"finished_at": time.Now(),
+		}).Error; err != nil {
+		return errors.Wrap(err, "failed to fail stale addon credential rotations")
+	}
+	return nil
+}
+
+// FindRestartingAddonCredentialRotations 查询已记录重新部署的 deployment、等待部署结束的凭证轮换
+func (db *DBClient) FindRestartingAddonCredentialRotations() ([]AddonCredentialRotation, error) {
+	var rotations []AddonCredentialRotation
+	if err := db.Where("status = ?", apistructs.AddonCredentialRotationRunning).
+		Where("stage = ?", apistructs.AddonCredentialRotationRestarting).
+		Where("runtimes <> ''").
+		Find(&rotations).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to find restarting addon credential rotations")
+	}
+	return rotations, nil
+}
+
+// TransitAddonCredentialRotation 仅当轮换仍在进行且处于 stage 阶段时更新, 避免多个实例重复处理, 返回是否更新成功
+func (db *DBClient) TransitAddonCredentialRotation(id uint64, stage apistructs.AddonCredentialRotationStage,
+	updates map[string]interface{}) (bool, error) {
+	result := db.Model(&AddonCredentialRotation{}).
+		Where("id = ?", id).
+		Where("status = ?", apistructs.AddonCredentialRotationRunning).
+		Where("stage = ?", stage).
+		Updates(updates)
+	if result.Error != nil {
+		return false, errors.Wrapf(result.Error, "failed to update addon credential rotation, id: %d", id)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindExpiredAddonCredentialRotations 查询宽限期已结束、旧密码待失效的凭证轮换
+func (db *DBClient) FindExpiredAddonCredentialRotations(now time.Time) ([]AddonCredentialRotation, error) {
+	var rotations []AddonCredentialRotation
+	if err := db.Where("status = ?", apistructs.AddonCredentialRotationRunning).
+		Where("stage = ?", apistructs.AddonCredentialRotationGrace).
+		Where("grace_until <= ?", now).
+		Find(&rotations).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to find expired addon credential rotations")
+	}
+	return rotations, nil
+}
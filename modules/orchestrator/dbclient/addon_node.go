@@ -16,6 +16,8 @@ package dbclient
 import (
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/erda-project/erda/apistructs"
 )
 
@@ -67,3 +69,14 @@ func (db *DBClient) GetAddonNodesByInstanceIDs(instanceIDs []string) (*[]AddonNo
 	}
 	return &addonNodes, nil
 }
+
+// DeleteAddonNodesByInstanceID 将 instanceID 下的 addonNode 标记为已删除
+func (db *DBClient) DeleteAddonNodesByInstanceID(instanceID string) error {
+	if err := db.Model(&AddonNode{}).
+		Where("instance_id = ?", instanceID).
+		Where("is_deleted = ?", apistructs.AddonNotDeleted).
+		Updates(map[string]interface{}{"is_deleted": apistructs.AddonDeleted, "update_time": time.Now()}).Error; err != nil {
+		return errors.Wrapf(err, "failed to delete addon nodes, instanceID: %s", instanceID)
+	}
+	return nil
+}
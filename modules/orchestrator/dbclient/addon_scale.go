@@ -0,0 +1,125 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/dbengine"
+)
+
+// AddonScaleRecord addon 规格变更记录表
+type AddonScaleRecord struct {
+	dbengine.BaseModel
+	AddonID      string `gorm:"type:varchar(64);not null;index:idx_addon_id"` // routing instance id
+	InstanceID   string `gorm:"type:varchar(64);not null;index:idx_instance_id"`
+	AddonName    string
+	FromPlan     string
+	FromCPU      float64 `gorm:"column:from_cpu"`
+	FromMem      int
+	FromReplicas int
+	ToPlan       string
+	ToCPU        float64 `gorm:"column:to_cpu"`
+	ToMem        int
+	ToReplicas   int
+	Status       apistructs.AddonScaleStatus
+	Message      string `gorm:"type:text"`
+	Operator     string
+	FinishedAt   *time.Time
+}
+
+// TableName 数据库表名
+func (AddonScaleRecord) TableName() string {
+	return "tb_addon_scale_record"
+}
+
+// CreateAddonScaleRecord 创建规格变更记录
+func (db *DBClient) CreateAddonScaleRecord(record *AddonScaleRecord) error {
+	if err := db.Create(record).Error; err != nil {
+		return errors.Wrapf(err, "failed to create addon scale record, addonID: %s", record.AddonID)
+	}
+	return nil
+}
+
+// UpdateAddonScaleRecord 更新规格变更记录
+func (db *DBClient) UpdateAddonScaleRecord(record *AddonScaleRecord) error {
+	if err := db.Save(record).Error; err != nil {
+		return errors.Wrapf(err, "failed to update addon scale record, id: %d", record.ID)
+	}
+	return nil
+}
+
+// GetLatestAddonScaleRecord 查询 addon 最近一次规格变更, 不存在时返回 nil
+func (db *DBClient) GetLatestAddonScaleRecord(addonID string) (*AddonScaleRecord, error) {
+	var record AddonScaleRecord
+	if err := db.Where("addon_id = ?", addonID).Order("id desc").Take(&record).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get latest addon scale record, addonID: %s", addonID)
+	}
+	return &record, nil
+}
+
+// GetRunningAddonScaleRecordByInstance 查询真实实例上进行中的规格变更, 不存在时返回 nil
+func (db *DBClient) GetRunningAddonScaleRecordByInstance(instanceID string) (*AddonScaleRecord, error) {
+	var record AddonScaleRecord
+	if err := db.Where("instance_id = ?", instanceID).
+		Where("status = ?", apistructs.AddonScaleRunning).
+		Take(&record).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get running addon scale record, instanceID: %s", instanceID)
+	}
+	return &record, nil
+}
+
+// FindAddonScaleRecords 查询 addon 的规格变更记录, 按时间倒序
+func (db *DBClient) FindAddonScaleRecords(addonID string) ([]AddonScaleRecord, error) {
+	var records []AddonScaleRecord
+	if err := db.Where("addon_id = ?", addonID).Order("id desc").Find(&records).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to find addon scale records, addonID: %s", addonID)
+	}
+	return records, nil
+}
+
+// FindRunningAddonScaleRecords 查询所有进行中的规格变更
+func (db *DBClient) FindRunningAddonScaleRecords() ([]AddonScaleRecord, error) {
+	var records []AddonScaleRecord
+	if err := db.Where("status = ?", apistructs.AddonScaleRunning).Find(&records).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to find running addon scale records")
+	}
+	return records, nil
+}
+
+// UpdateAddonInstancePlan 规格变更成功后更新真实实例及其 routing 的规格
+func (db *DBClient) UpdateAddonInstancePlan(instanceID, plan string) error {
+	if err := db.Model(&AddonInstance{}).
+		Where("id = ?", instanceID).
+		Updates(map[string]interface{}{"plan": plan, "update_time": time.Now()}).Error; err != nil {
+		return errors.Wrapf(err, "failed to update addon instance plan, instanceID: %s", instanceID)
+	}
+	if err := db.Model(&AddonInstanceRouting{}).
+		Where("real_instance = ?", instanceID).
+		Where("is_deleted = ?", apistructs.AddonNotDeleted).
+		Updates(map[string]interface{}{"plan": plan, "update_time": time.Now()}).Error; err != nil {
+		return errors.Wrapf(err, "failed to update addon routing plan, instanceID: %s", instanceID)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/dbengine"
+)
+
+// DeployFreezeWindow 部署冻结窗口表
+type DeployFreezeWindow struct {
+	dbengine.BaseModel
+	OrgID        uint64 `gorm:"not null;index:idx_org_id"`
+	ProjectID    uint64 `gorm:"not null"`
+	Workspace    string
+	Name         string `gorm:"not null"`
+	Description  string
+	Type         apistructs.DeployFreezeWindowType `gorm:"not null"`
+	StartAt      *time.Time
+	EndAt        *time.Time
+	StartWeekday int
+	StartTime    string
+	EndWeekday   int
+	EndTime      string
+	Timezone     string
+	Enabled      bool
+	Creator      string
+}
+
+// TableName 数据库表名
+func (DeployFreezeWindow) TableName() string {
+	return "ps_v2_deploy_freeze_windows"
+}
+
+// DeployFreezeOverride 冻结期间紧急部署申请表
+type DeployFreezeOverride struct {
+	dbengine.BaseModel
+	OrgID          uint64 `gorm:"not null"`
+	ProjectID      uint64 `gorm:"not null;index:idx_project_id"`
+	Workspace      string `gorm:"not null"`
+	Reason         string `gorm:"type:text"`
+	Status         apistructs.DeployFreezeOverrideStatus
+	Applicant      string
+	Approver       string
+	ApprovalReason string
+	Duration       int64
+	StartAt        *time.Time
+	EndAt          *time.Time
+}
+
+// TableName 数据库表名
+func (DeployFreezeOverride) TableName() string {
+	return "ps_v2_deploy_freeze_overrides"
+}
+
+// CreateDeployFreezeWindow insert deployFreezeWindow
+func (db *DBClient) CreateDeployFreezeWindow(window *DeployFreezeWindow) error {
+	return db.Create(window).Error
+}
+
+// UpdateDeployFreezeWindow update deployFreezeWindow
+func (db *DBClient) UpdateDeployFreezeWindow(window *DeployFreezeWindow) error {
+	if err := db.Save(window).Error; err != nil {
+		return errors.Wrapf(err, "failed to update deploy freeze window, id: %v", window.ID)
+	}
+	return nil
+}
+
+// GetDeployFreezeWindow 根据 ID 查询冻结窗口, 不存在时返回 nil
+func (db *DBClient) GetDeployFreezeWindow(id uint64) (*DeployFreezeWindow, error) {
+	var window DeployFreezeWindow
+	if err := db.Where("id = ?", id).Take(&window).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get deploy freeze window, id: %d", id)
+	}
+	return &window, nil
+}
+
+// DeleteDeployFreezeWindow 删除冻结窗口
+func (db *DBClient) DeleteDeployFreezeWindow(id uint64) error {
+	if err := db.
+		Where("id = ?", id).
+		Delete(&DeployFreezeWindow{}).Error; err != nil {
+		return errors.Wrapf(err, "failed to delete deploy freeze window: %v", id)
+	}
+	return nil
+}
+
+// FindDeployFreezeWindows 查询企业级及指定项目的冻结窗口, projectID 为 0 时只查询企业级窗口
+func (db *DBClient) FindDeployFreezeWindows(orgID, projectID uint64) ([]DeployFreezeWindow, error) {
+	var windows []DeployFreezeWindow
+	if err := db.
+		Where("org_id = ? AND project_id IN (?)", orgID, []uint64{0, projectID}).
+		Order("id desc").
+		Find(&windows).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to find deploy freeze windows, orgID: %d, projectID: %d",
+			orgID, projectID)
+	}
+	return windows, nil
+}
+
+// FindEnabledDeployFreezeWindows 查询对项目环境生效的冻结窗口
+func (db *DBClient) FindEnabledDeployFreezeWindows(orgID, projectID uint64, workspace string) ([]DeployFreezeWindow, error) {
+	var windows []DeployFreezeWindow
+	if err := db.
+		Where("org_id = ? AND project_id IN (?) AND workspace IN (?) AND enabled = ?",
+			orgID, []uint64{0, projectID}, []string{"", workspace}, true).
+		Find(&windows).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to find enabled deploy freeze windows, orgID: %d, projectID: %d",
+			orgID, projectID)
+	}
+	return windows, nil
+}
+
+// CreateDeployFreezeOverride insert deployFreezeOverride
+func (db *DBClient) CreateDeployFreezeOverride(override *DeployFreezeOverride) error {
+	return db.Create(override).Error
+}
+
+// ApproveDeployFreezeOverride 保存紧急部署申请的审批结果, 仅当申请仍在等待审批时更新, 返回是否更新成功
+func (db *DBClient) ApproveDeployFreezeOverride(override *DeployFreezeOverride) (bool, error) {
+	result := db.Model(&DeployFreezeOverride{}).
+		Where("id = ? AND status = ?", override.ID, apistructs.DeployFreezeOverrideWaitApprove).
+		Updates(map[string]interface{}{
+			"status":          override.Status,
+			"approver":        override.Approver,
+			"approval_reason": override.ApprovalReason,
+			"start_at":        override.StartAt,
+			"end_at":          override.EndAt,
+		})
+	if result.Error != nil {
+		return false, errors.Wrapf(result.Error, "failed to approve deploy freeze override, id: %v", override.ID)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetDeployFreezeOverride 根据 ID 查询紧急部署申请, 不存在时返回 nil
+func (db *DBClient) GetDeployFreezeOverride(id uint64) (*DeployFreezeOverride, error) {
+	var override DeployFreezeOverride
+	if err := db.Where("id = ?", id).Take(&override).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get deploy freeze override, id: %d", id)
+	}
+	return &override, nil
+}
+
+// FindDeployFreezeOverrides 查询项目下的紧急部署申请, status 为空时不过滤
+func (db *DBClient) FindDeployFreezeOverrides(projectID uint64, status string) ([]DeployFreezeOverride, error) {
+	var overrides []DeployFreezeOverride
+	q := db.Where("project_id = ?", projectID)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if err := q.Order("id desc").Find(&overrides).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to find deploy freeze overrides, projectID: %d", projectID)
+	}
+	return overrides, nil
+}
+
+// GetActiveDeployFreezeOverride 查询当前生效的紧急部署申请, 不存在时返回 nil
+func (db *DBClient) GetActiveDeployFreezeOverride(projectID uint64, workspace string, now time.Time) (*DeployFreezeOverride, error) {
+	var override DeployFreezeOverride
+	if err := db.
+		Where("project_id = ? AND workspace = ? AND status = ? AND start_at <= ? AND end_at > ?",
+			projectID, workspace, apistructs.DeployFreezeOverrideAccept, now, now).
+		Order("end_at desc").
+		Take(&override).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get active deploy freeze override, projectID: %d", projectID)
+	}
+	return &override, nil
+}
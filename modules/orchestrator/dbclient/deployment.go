@@ -74,6 +74,11 @@ type DeploymentExtra struct {
 	CancelEndAt         *time.Time `json:"cancelEndAt,omitempty"`
 	ForceCanceled       bool       `json:"forceCanceled,omitempty"`
 	AutoTimeout         bool       `json:"autoTimeout,omitempty"`
+	VerifyStartAt       *time.Time `json:"verifyStartAt,omitempty"`
+	VerifyEndAt         *time.Time `json:"verifyEndAt,omitempty"`
+	// 自动回滚时, 回滚部署单记录被回滚的部署单, 被回滚的部署单记录回滚部署单
+	RollbackFrom uint64 `json:"rollbackFrom,omitempty"`
+	RollbackTo   uint64 `json:"rollbackTo,omitempty"`
 }
 
 func (ex DeploymentExtra) Value() (driver.Value, error) {
@@ -88,8 +93,13 @@ func (ex *DeploymentExtra) Scan(value interface{}) error {
 	if value == nil {
 		return nil
 	}
-	v, ok := value.([]byte)
-	if !ok {
+	var v []byte
+	switch src := value.(type) {
+	case []byte:
+		v = src
+	case string:
+		v = []byte(src)
+	default:
 		return errors.New("invalid scan source for DeploymentExtra")
 	}
 	if len(v) == 0 {
@@ -353,7 +363,7 @@ func (d *Deployment) Convert() *apistructs.Deployment {
 		FailCause:      d.FailCause,
 		Outdated:       d.Outdated,
 		Operator:       d.Operator,
-		RollbackFrom:   0,
+		RollbackFrom:   d.Extra.RollbackFrom,
 		CreatedAt:      d.CreatedAt,
 		FinishedAt:     d.FinishedAt,
 		NeedApproval:   d.NeedApproval,
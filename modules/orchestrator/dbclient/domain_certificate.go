@@ -0,0 +1,209 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/dbengine"
+)
+
+// DomainCertificate runtime 域名绑定的 TLS 证书, 证书内容保存在 cmdb 证书服务中
+type DomainCertificate struct {
+	dbengine.BaseModel
+	RuntimeID     uint64 `gorm:"not null;index:idx_runtime_id"`
+	OrgID         uint64
+	Domain        string `gorm:"type:varchar(255);unique_index:uk_domain"`
+	CertificateID uint64
+	Status        apistructs.DomainCertificateStatus
+	Issuer        string
+	NotBefore     *time.Time
+	NotAfter      *time.Time
+	// HTTP-01 校验中的 token 及对应的 key authorization
+	ChallengeToken   string `gorm:"type:varchar(255);index:idx_challenge_token"`
+	ChallengeKeyAuth string `gorm:"type:varchar(512)"`
+	Message          string `gorm:"type:text"`
+	Operator         string
+	AlertedAt        *time.Time
+}
+
+// TableName 数据库表名
+func (DomainCertificate) TableName() string {
+	return "tb_runtime_domain_certificate"
+}
+
+// AcmeAccount ACME 账号, 每个 ACME 服务一个
+type AcmeAccount struct {
+	dbengine.BaseModel
+	DirectoryURL string `gorm:"type:varchar(255);unique_index:uk_directory_url"`
+	Email        string
+	AccountURI   string
+	PrivateKey   string `gorm:"type:text"` // PEM 格式账号私钥
+}
+
+// TableName 数据库表名
+func (AcmeAccount) TableName() string {
+	return "tb_acme_account"
+}
+
+// CreateDomainCertificate 创建域名证书记录
+func (db *DBClient) CreateDomainCertificate(cert *DomainCertificate) error {
+	if err := db.Create(cert).Error; err != nil {
+		return errors.Wrapf(err, "failed to create domain certificate, domain: %s", cert.Domain)
+	}
+	return nil
+}
+
+// UpdateDomainCertificate 更新域名证书记录
+func (db *DBClient) UpdateDomainCertificate(cert *DomainCertificate) error {
+	if err := db.Save(cert).Error; err != nil {
+		return errors.Wrapf(err, "failed to update domain certificate, domain: %s", cert.Domain)
+	}
+	return nil
+}
+
+// ClaimDomainCertificate 以记录当前的 status 及 updated_at 为条件将其置为签发中, 记录已被其他实例修改时返回 false.
+// 多实例同时签发同一域名会产生多个 ACME 订单, 互相覆盖校验 token
+func (db *DBClient) ClaimDomainCertificate(cert *DomainCertificate) (bool, error) {
+	result := db.Model(&DomainCertificate{}).
+		Where("id = ? AND status = ? AND updated_at = ?", cert.ID, cert.Status, cert.UpdatedAt).
+		Updates(map[string]interface{}{
+			"status":     apistructs.DomainCertificateIssuing,
+			"runtime_id": cert.RuntimeID,
+			"org_id":     cert.OrgID,
+			"operator":   cert.Operator,
+			"message":    "",
+		})
+	if result.Error != nil {
+		return false, errors.Wrapf(result.Error, "failed to claim domain certificate, domain: %s", cert.Domain)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	cert.Status = apistructs.DomainCertificateIssuing
+	cert.Message = ""
+	return true, nil
+}
+
+// ClaimDomainCertificateAlert 以上次告警时间为条件记录本次告警时间, 记录已被其他实例修改时返回 false
+func (db *DBClient) ClaimDomainCertificateAlert(cert *DomainCertificate, alertedAt time.Time) (bool, error) {
+	query := db.Model(&DomainCertificate{}).Where("id = ?", cert.ID)
+	if cert.AlertedAt == nil {
+		query = query.Where("alerted_at IS NULL")
+	} else {
+		query = query.Where("alerted_at = ?", *cert.AlertedAt)
+	}
+	result := query.UpdateColumn("alerted_at", alertedAt)
+	if result.Error != nil {
+		return false, errors.Wrapf(result.Error, "failed to claim domain certificate alert, domain: %s", cert.Domain)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	cert.AlertedAt = &alertedAt
+	return true, nil
+}
+
+// DeleteDomainCertificate 删除域名证书记录, 证书服务中的证书保留
+func (db *DBClient) DeleteDomainCertificate(id uint64) error {
+	if err := db.Where("id = ?", id).Delete(&DomainCertificate{}).Error; err != nil {
+		return errors.Wrapf(err, "failed to delete domain certificate, id: %d", id)
+	}
+	return nil
+}
+
+// GetDomainCertificateByDomain 根据域名查询证书记录, 不存在时返回 nil
+func (db *DBClient) GetDomainCertificateByDomain(domain string) (*DomainCertificate, error) {
+	var cert DomainCertificate
+	if err := db.Where("domain = ?", domain).Take(&cert).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get domain certificate, domain: %s", domain)
+	}
+	return &cert, nil
+}
+
+// GetDomainCertificateByChallengeToken 根据 HTTP-01 token 查询校验中的证书记录, 不存在时返回 nil
+func (db *DBClient) GetDomainCertificateByChallengeToken(token string) (*DomainCertificate, error) {
+	var cert DomainCertificate
+	if err := db.Where("challenge_token = ?", token).Take(&cert).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get domain certificate, challenge token: %s", token)
+	}
+	return &cert, nil
+}
+
+// FindDomainCertificatesByRuntimeID 查询 runtime 的域名证书记录
+func (db *DBClient) FindDomainCertificatesByRuntimeID(runtimeID uint64) ([]DomainCertificate, error) {
+	var certs []DomainCertificate
+	if err := db.Where("runtime_id = ?", runtimeID).Order("domain").Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to find domain certificates, runtimeID: %d", runtimeID)
+	}
+	return certs, nil
+}
+
+// FindDomainCertificatesExpireBefore 查询在指定时间前过期的证书, 包括续期失败但之前签发的证书仍在使用的记录
+func (db *DBClient) FindDomainCertificatesExpireBefore(t time.Time) ([]DomainCertificate, error) {
+	var certs []DomainCertificate
+	if err := db.Where("status = ? OR (status = ? AND not_after IS NOT NULL)",
+		apistructs.DomainCertificateIssued, apistructs.DomainCertificateFailed).
+		Where("not_after <= ?", t).
+		Find(&certs).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to find expiring domain certificates")
+	}
+	return certs, nil
+}
+
+// FailStaleIssuingDomainCertificates 将 before 之后未再更新的签发中记录置为失败
+func (db *DBClient) FailStaleIssuingDomainCertificates(before time.Time, message string) error {
+	if err := db.Model(&DomainCertificate{}).
+		Where("status = ?", apistructs.DomainCertificateIssuing).
+		Where("updated_at <= ?", before).
+		Updates(map[string]interface{}{
+			"status":             apistructs.DomainCertificateFailed,
+			"message":            message,
+			"challenge_token":    "",
+			"challenge_key_auth": "",
+		}).Error; err != nil {
+		return errors.Wrap(err, "failed to fail stale issuing domain certificates")
+	}
+	return nil
+}
+
+// GetAcmeAccount 查询 ACME 服务对应的账号, 不存在时返回 nil
+func (db *DBClient) GetAcmeAccount(directoryURL string) (*AcmeAccount, error) {
+	var account AcmeAccount
+	if err := db.Where("directory_url = ?", directoryURL).Take(&account).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get acme account, directory: %s", directoryURL)
+	}
+	return &account, nil
+}
+
+// CreateAcmeAccount 保存 ACME 账号
+func (db *DBClient) CreateAcmeAccount(account *AcmeAccount) error {
+	if err := db.Create(account).Error; err != nil {
+		return errors.Wrapf(err, "failed to create acme account, directory: %s", account.DirectoryURL)
+	}
+	return nil
+}
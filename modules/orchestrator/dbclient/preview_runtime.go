@@ -0,0 +1,126 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/pkg/dbengine"
+)
+
+// PreviewRuntime merge request 预览环境记录表
+type PreviewRuntime struct {
+	dbengine.BaseModel
+	ProjectID     uint64 `gorm:"not null"`
+	ApplicationID uint64 `gorm:"not null;unique_index:idx_unique_app_id_merge_id"`
+	MergeID       uint64 `gorm:"not null;unique_index:idx_unique_app_id_merge_id"`
+	RuntimeID     uint64 `gorm:"not null"`
+	ReleaseID     string
+	Operator      string
+	ExpiredAt     time.Time
+}
+
+// TableName 数据库表名
+func (PreviewRuntime) TableName() string {
+	return "ps_v2_preview_runtimes"
+}
+
+// CreatePreviewRuntime insert previewRuntime
+func (db *DBClient) CreatePreviewRuntime(preview *PreviewRuntime) error {
+	return db.Create(preview).Error
+}
+
+// UpdatePreviewRuntime update previewRuntime
+func (db *DBClient) UpdatePreviewRuntime(preview *PreviewRuntime) error {
+	if err := db.Save(preview).Error; err != nil {
+		return errors.Wrapf(err, "failed to update preview runtime, id: %v", preview.ID)
+	}
+	return nil
+}
+
+// GetPreviewRuntimeByMergeID 根据 applicationID 和 mergeID 查询预览环境, 不存在时返回 nil
+func (db *DBClient) GetPreviewRuntimeByMergeID(applicationID, mergeID uint64) (*PreviewRuntime, error) {
+	var preview PreviewRuntime
+	if err := db.
+		Where("application_id = ? AND merge_id = ?", applicationID, mergeID).
+		Take(&preview).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get preview runtime, applicationID: %d, mergeID: %d",
+			applicationID, mergeID)
+	}
+	return &preview, nil
+}
+
+// GetPreviewRuntimeByRuntimeID 根据 runtimeID 查询预览环境, 不是预览环境时返回 nil
+func (db *DBClient) GetPreviewRuntimeByRuntimeID(runtimeID uint64) (*PreviewRuntime, error) {
+	var preview PreviewRuntime
+	if err := db.Where("runtime_id = ?", runtimeID).Take(&preview).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get preview runtime, runtimeID: %d", runtimeID)
+	}
+	return &preview, nil
+}
+
+// CountPreviewRuntimesUpTo 统计项目下 id 不大于 maxID 的预览环境数量, 即 maxID 及之前创建的
+func (db *DBClient) CountPreviewRuntimesUpTo(projectID, maxID uint64) (int, error) {
+	var count int
+	if err := db.Model(&PreviewRuntime{}).
+		Where("project_id = ? AND id <= ?", projectID, maxID).
+		Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed to count preview runtimes, projectID: %d", projectID)
+	}
+	return count, nil
+}
+
+// FindPreviewRuntimesByProjectID 查询项目下的预览环境
+func (db *DBClient) FindPreviewRuntimesByProjectID(projectID uint64) ([]PreviewRuntime, error) {
+	var previews []PreviewRuntime
+	if err := db.
+		Where("project_id = ?", projectID).
+		Order("id desc").
+		Find(&previews).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to find preview runtimes, projectID: %d", projectID)
+	}
+	return previews, nil
+}
+
+// FindPreviewRuntimesNewerThan find preview runtimes newer than minId (id > minId)
+func (db *DBClient) FindPreviewRuntimesNewerThan(minId uint64, limit int) ([]PreviewRuntime, error) {
+	var previews []PreviewRuntime
+	if err := db.
+		Where("id > ?", minId).
+		Order("id").
+		Limit(limit).
+		Find(&previews).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to find preview runtimes after: %d", minId)
+	}
+	return previews, nil
+}
+
+// DeletePreviewRuntime 删除预览环境记录
+func (db *DBClient) DeletePreviewRuntime(id uint64) error {
+	if err := db.
+		Where("id = ?", id).
+		Delete(&PreviewRuntime{}).Error; err != nil {
+		return errors.Wrapf(err, "failed to delete preview runtime: %v", id)
+	}
+	return nil
+}
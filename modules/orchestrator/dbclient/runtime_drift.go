@@ -0,0 +1,66 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/pkg/dbengine"
+)
+
+// RuntimeDrift runtime 最近一次发出漂移事件的报告, 用于多实例扫描时去重
+type RuntimeDrift struct {
+	dbengine.BaseModel
+	RuntimeID  uint64 `gorm:"not null;unique_index:uk_runtime_id"`
+	ReportHash string `gorm:"type:varchar(64);not null"`
+}
+
+// TableName 数据库表名
+func (RuntimeDrift) TableName() string {
+	return "ps_v2_runtime_drifts"
+}
+
+// CreateRuntimeDrift 创建 runtime 漂移记录, runtime 已有记录时由唯一索引拒绝
+func (db *DBClient) CreateRuntimeDrift(drift *RuntimeDrift) error {
+	if err := db.Create(drift).Error; err != nil {
+		return errors.Wrapf(err, "failed to create runtime drift, runtimeID: %d", drift.RuntimeID)
+	}
+	return nil
+}
+
+// UpdateRuntimeDriftHash 仅当报告哈希发生变化时更新, 返回是否由本次调用更新, 记录不存在时 exists 为 false
+func (db *DBClient) UpdateRuntimeDriftHash(runtimeID uint64, hash string) (updated bool, exists bool, err error) {
+	result := db.Model(&RuntimeDrift{}).
+		Where("runtime_id = ? AND report_hash <> ?", runtimeID, hash).
+		Update("report_hash", hash)
+	if result.Error != nil {
+		return false, false, errors.Wrapf(result.Error, "failed to update runtime drift, runtimeID: %d", runtimeID)
+	}
+	if result.RowsAffected > 0 {
+		return true, true, nil
+	}
+	var count int
+	if err := db.Model(&RuntimeDrift{}).Where("runtime_id = ?", runtimeID).Count(&count).Error; err != nil {
+		return false, false, errors.Wrapf(err, "failed to count runtime drift, runtimeID: %d", runtimeID)
+	}
+	return false, count > 0, nil
+}
+
+// DeleteRuntimeDrift 删除 runtime 的漂移记录
+func (db *DBClient) DeleteRuntimeDrift(runtimeID uint64) error {
+	if err := db.Where("runtime_id = ?", runtimeID).Delete(&RuntimeDrift{}).Error; err != nil {
+		return errors.Wrapf(err, "failed to delete runtime drift, runtimeID: %d", runtimeID)
+	}
+	return nil
+}
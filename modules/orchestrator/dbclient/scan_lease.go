@@ -0,0 +1,61 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/pkg/dbengine"
+)
+
+// ScanLease 定时任务的租约, 多个实例同时运行时在租约有效期内只有持有者执行
+type ScanLease struct {
+	dbengine.BaseModel
+	Name      string    `gorm:"type:varchar(64);not null;unique_index:uk_name"`
+	Holder    string    `gorm:"type:varchar(255);not null"`
+	ExpiredAt time.Time `gorm:"not null"`
+}
+
+// TableName 数据库表名
+func (ScanLease) TableName() string {
+	return "ps_v2_scan_leases"
+}
+
+// ClaimScanLease 租约不存在或已过期时由 holder 获取, 有效期为 ttl, 返回是否由本次调用获取
+func (db *DBClient) ClaimScanLease(name, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	result := db.Model(&ScanLease{}).
+		Where("name = ? AND expired_at < ?", name, now).
+		Updates(map[string]interface{}{"holder": holder, "expired_at": now.Add(ttl)})
+	if result.Error != nil {
+		return false, errors.Wrapf(result.Error, "failed to claim scan lease, name: %s", name)
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+	var count int
+	if err := db.Model(&ScanLease{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		return false, errors.Wrapf(err, "failed to count scan lease, name: %s", name)
+	}
+	if count > 0 {
+		return false, nil
+	}
+	// 并发插入时唯一索引只允许一个实例成功
+	if err := db.Create(&ScanLease{Name: name, Holder: holder, ExpiredAt: now.Add(ttl)}).Error; err != nil {
+		return false, nil
+	}
+	return true, nil
+}
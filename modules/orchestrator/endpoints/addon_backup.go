@@ -0,0 +1,123 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/services/apierrors"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/httpserver"
+)
+
+// GetAddonBackupPolicy 获取 addon 备份策略
+func (e *Endpoints) GetAddonBackupPolicy(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrGetAddonBackupPolicy.NotLogin().ToResp(), nil
+	}
+	policy, err := e.addon.GetBackupPolicy(userID.String(), vars["addonID"])
+	if err != nil {
+		return apierrors.ErrGetAddonBackupPolicy.InternalError(err).ToResp(), nil
+	}
+	return httpserver.OkResp(policy)
+}
+
+// SaveAddonBackupPolicy 创建或更新 addon 备份策略
+func (e *Endpoints) SaveAddonBackupPolicy(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrSaveAddonBackupPolicy.NotLogin().ToResp(), nil
+	}
+	var req apistructs.AddonBackupPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrSaveAddonBackupPolicy.InvalidParameter(err).ToResp(), nil
+	}
+	policy, err := e.addon.SaveBackupPolicy(userID.String(), vars["addonID"], &req)
+	if err != nil {
+		return apierrors.ErrSaveAddonBackupPolicy.InternalError(err).ToResp(), nil
+	}
+	return httpserver.OkResp(policy)
+}
+
+// DeleteAddonBackupPolicy 删除 addon 备份策略
+func (e *Endpoints) DeleteAddonBackupPolicy(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrSaveAddonBackupPolicy.NotLogin().ToResp(), nil
+	}
+	if err := e.addon.DeleteBackupPolicy(userID.String(), vars["addonID"]); err != nil {
+		return apierrors.ErrSaveAddonBackupPolicy.InternalError(err).ToResp(), nil
+	}
+	return httpserver.OkResp(nil)
+}
+
+// BackupAddon 立即备份 addon
+func (e *Endpoints) BackupAddon(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrBackupAddon.NotLogin().ToResp(), nil
+	}
+	backup, err := e.addon.Backup(userID.String(), vars["addonID"])
+	if err != nil {
+		return apierrors.ErrBackupAddon.InternalError(err).ToResp(), nil
+	}
+	return httpserver.OkResp(backup)
+}
+
+// ListAddonBackups 获取 addon 备份及恢复历史
+func (e *Endpoints) ListAddonBackups(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrListAddonBackup.NotLogin().ToResp(), nil
+	}
+	history, err := e.addon.ListBackups(userID.String(), vars["addonID"])
+	if err != nil {
+		return apierrors.ErrListAddonBackup.InternalError(err).ToResp(), nil
+	}
+	return httpserver.OkResp(history)
+}
+
+// RestoreAddonBackup 将备份恢复到新的 addon 实例
+func (e *Endpoints) RestoreAddonBackup(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrRestoreAddon.NotLogin().ToResp(), nil
+	}
+	backupID, err := strconv.ParseUint(vars["backupID"], 10, 64)
+	if err != nil {
+		return apierrors.ErrRestoreAddon.InvalidParameter("backupID").ToResp(), nil
+	}
+	var req apistructs.AddonRestoreRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return apierrors.ErrRestoreAddon.InvalidParameter(err).ToResp(), nil
+		}
+	}
+	restore, err := e.addon.Restore(userID.String(), vars["addonID"], backupID, &req)
+	if err != nil {
+		return apierrors.ErrRestoreAddon.InternalError(err).ToResp(), nil
+	}
+	return httpserver.OkResp(restore)
+}
+
+// AddonBackupSchedule 触发到期的 addon 定时备份并同步备份/恢复状态
+func (e *Endpoints) AddonBackupSchedule() (bool, error) {
+	e.addon.BackupSchedule()
+	return false, nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/services/apierrors"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/httpserver"
+)
+
+// RotateAddonCredential 轮换 addon 凭证并重新部署引用该 addon 的 runtime
+func (e *Endpoints) RotateAddonCredential(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrRotateAddonCredential.NotLogin().ToResp(), nil
+	}
+	var req apistructs.AddonCredentialRotateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return apierrors.ErrRotateAddonCredential.InvalidParameter(err).ToResp(), nil
+		}
+	}
+	rotation, err := e.addon.RotateCredential(userID.String(), vars["addonID"], &req, e.redeployRuntime)
+	if err != nil {
+		return apierrors.ErrRotateAddonCredential.InternalError(err).ToResp(), nil
+	}
+	return httpserver.OkResp(rotation)
+}
+
+// ListAddonCredentialRotations 获取 addon 凭证轮换记录
+func (e *Endpoints) ListAddonCredentialRotations(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrListAddonCredentialRotation.NotLogin().ToResp(), nil
+	}
+	rotations, err := e.addon.ListCredentialRotations(userID.String(), vars["addonID"])
+	if err != nil {
+		return apierrors.ErrListAddonCredentialRotation.InternalError(err).ToResp(), nil
+	}
+	return httpserver.OkResp(rotations)
+}
+
+// AddonCredentialRotationSync 使宽限期已结束的 addon 旧密码失效
+func (e *Endpoints) AddonCredentialRotationSync() (bool, error) {
+	e.addon.CredentialRotationSync()
+	return false, nil
+}
+
+// redeployRuntime 以轮换操作人的身份重新部署 runtime
+func (e *Endpoints) redeployRuntime(operator string, orgID, runtimeID uint64) (uint64, error) {
+	deployment, err := e.runtime.Redeploy(user.ID(operator), orgID, runtimeID)
+	if err != nil {
+		return 0, err
+	}
+	return deployment.DeploymentID, nil
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/services/apierrors"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/httpserver"
+)
+
+// ScaleAddon 变更 addon 规格或扩缩容
+func (e *Endpoints) ScaleAddon(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrScaleAddon.NotLogin().ToResp(), nil
+	}
+	var req apistructs.AddonPlanScaleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrScaleAddon.InvalidParameter(err).ToResp(), nil
+	}
+	record, err := e.addon.Scale(userID.String(), vars["addonID"], &req)
+	if err != nil {
+		return apierrors.ErrScaleAddon.InternalError(err).ToResp(), nil
+	}
+	return httpserver.OkResp(record)
+}
+
+// ListAddonScaleRecords 获取 addon 规格变更记录
+func (e *Endpoints) ListAddonScaleRecords(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrListAddonScaleRecord.NotLogin().ToResp(), nil
+	}
+	records, err := e.addon.GetScaleRecords(userID.String(), vars["addonID"])
+	if err != nil {
+		return apierrors.ErrListAddonScaleRecord.InternalError(err).ToResp(), nil
+	}
+	return httpserver.OkResp(records)
+}
+
+// AddonScaleSync 同步进行中的 addon 规格变更状态
+func (e *Endpoints) AddonScaleSync() (bool, error) {
+	e.addon.ScaleSync()
+	return false, nil
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/services/apierrors"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/httpserver"
+	"github.com/erda-project/erda/pkg/httpserver/errorresp"
+)
+
+// CreateDeployFreezeWindow 创建部署冻结窗口
+func (e *Endpoints) CreateDeployFreezeWindow(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrCreateDeployFreeze.NotLogin().ToResp(), nil
+	}
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrCreateDeployFreeze.InvalidParameter(err).ToResp(), nil
+	}
+	var req apistructs.DeployFreezeWindow
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrCreateDeployFreeze.InvalidParameter("req body").ToResp(), nil
+	}
+	data, err := e.freeze.CreateWindow(userID, orgID, &req)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(data)
+}
+
+// UpdateDeployFreezeWindow 更新部署冻结窗口
+func (e *Endpoints) UpdateDeployFreezeWindow(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrUpdateDeployFreeze.NotLogin().ToResp(), nil
+	}
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrUpdateDeployFreeze.InvalidParameter(err).ToResp(), nil
+	}
+	windowID, err := strconv.ParseUint(vars["windowID"], 10, 64)
+	if err != nil {
+		return apierrors.ErrUpdateDeployFreeze.InvalidParameter("windowID").ToResp(), nil
+	}
+	var req apistructs.DeployFreezeWindow
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrUpdateDeployFreeze.InvalidParameter("req body").ToResp(), nil
+	}
+	data, err := e.freeze.UpdateWindow(userID, orgID, windowID, &req)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(data)
+}
+
+// DeleteDeployFreezeWindow 删除部署冻结窗口
+func (e *Endpoints) DeleteDeployFreezeWindow(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrDeleteDeployFreeze.NotLogin().ToResp(), nil
+	}
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrDeleteDeployFreeze.InvalidParameter(err).ToResp(), nil
+	}
+	windowID, err := strconv.ParseUint(vars["windowID"], 10, 64)
+	if err != nil {
+		return apierrors.ErrDeleteDeployFreeze.InvalidParameter("windowID").ToResp(), nil
+	}
+	if err := e.freeze.DeleteWindow(userID, orgID, windowID); err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(nil)
+}
+
+// ListDeployFreezeWindows 查询部署冻结窗口(变更日历), 不指定 projectId 时返回企业级窗口
+func (e *Endpoints) ListDeployFreezeWindows(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrListDeployFreeze.NotLogin().ToResp(), nil
+	}
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrListDeployFreeze.InvalidParameter(err).ToResp(), nil
+	}
+	var projectID uint64
+	if v := r.URL.Query().Get("projectId"); v != "" {
+		if projectID, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return apierrors.ErrListDeployFreeze.InvalidParameter("projectId").ToResp(), nil
+		}
+	}
+	data, err := e.freeze.ListWindows(userID, orgID, projectID)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(data)
+}
+
+// ApplyDeployFreezeOverride 申请冻结期间紧急部署
+func (e *Endpoints) ApplyDeployFreezeOverride(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrApplyDeployFreeze.NotLogin().ToResp(), nil
+	}
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrApplyDeployFreeze.InvalidParameter(err).ToResp(), nil
+	}
+	var req apistructs.DeployFreezeOverrideCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrApplyDeployFreeze.InvalidParameter("req body").ToResp(), nil
+	}
+	data, err := e.freeze.ApplyOverride(userID, orgID, &req)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(data)
+}
+
+// ApproveDeployFreezeOverride 审批紧急部署申请
+func (e *Endpoints) ApproveDeployFreezeOverride(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrApproveDeployFreeze.NotLogin().ToResp(), nil
+	}
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrApproveDeployFreeze.InvalidParameter(err).ToResp(), nil
+	}
+	overrideID, err := strconv.ParseUint(vars["overrideID"], 10, 64)
+	if err != nil {
+		return apierrors.ErrApproveDeployFreeze.InvalidParameter("overrideID").ToResp(), nil
+	}
+	var req apistructs.DeployFreezeOverrideApproveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrApproveDeployFreeze.InvalidParameter("req body").ToResp(), nil
+	}
+	if err := e.freeze.ApproveOverride(userID, orgID, overrideID, &req); err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(nil)
+}
+
+// ListDeployFreezeOverrides 查询项目下的紧急部署申请
+func (e *Endpoints) ListDeployFreezeOverrides(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrListDeployFreeze.NotLogin().ToResp(), nil
+	}
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrListDeployFreeze.InvalidParameter(err).ToResp(), nil
+	}
+	projectID, err := strconv.ParseUint(r.URL.Query().Get("projectId"), 10, 64)
+	if err != nil {
+		return apierrors.ErrListDeployFreeze.InvalidParameter("projectId").ToResp(), nil
+	}
+	data, err := e.freeze.ListOverrides(userID, orgID, projectID, r.URL.Query().Get("status"))
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(data)
+}
+
+// CheckDeployFreeze 查询项目环境当前是否允许部署
+func (e *Endpoints) CheckDeployFreeze(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrCheckDeployFreeze.NotLogin().ToResp(), nil
+	}
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrCheckDeployFreeze.InvalidParameter(err).ToResp(), nil
+	}
+	projectID, err := strconv.ParseUint(r.URL.Query().Get("projectId"), 10, 64)
+	if err != nil {
+		return apierrors.ErrCheckDeployFreeze.InvalidParameter("projectId").ToResp(), nil
+	}
+	workspace := r.URL.Query().Get("workspace")
+	if workspace == "" {
+		return apierrors.ErrCheckDeployFreeze.MissingParameter("workspace").ToResp(), nil
+	}
+	data, err := e.freeze.CheckWithPermission(userID, orgID, projectID, workspace, time.Now())
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(data)
+}
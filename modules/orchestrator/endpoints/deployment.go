@@ -54,6 +54,28 @@ func (e *Endpoints) CancelDeployment(ctx context.Context, r *http.Request, vars
 	return httpserver.OkResp(nil)
 }
 
+// RolloutDeployment 推进, 暂停或终止服务的灰度/蓝绿发布
+func (e *Endpoints) RolloutDeployment(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrRolloutDeployment.NotLogin().ToResp(), nil
+	}
+	v := vars["deploymentID"]
+	deploymentID, err := strutil.Atoi64(v)
+	if err != nil {
+		return apierrors.ErrRolloutDeployment.InvalidParameter(strutil.Concat("deploymentID: ", v)).ToResp(), nil
+	}
+	var req apistructs.DeploymentRolloutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrRolloutDeployment.InvalidParameter(err).ToResp(), nil
+	}
+	status, err := e.deployment.Rollout(userID, uint64(deploymentID), req)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(status)
+}
+
 // ListLaunchedApprovedDeployments 列出'user-id'用户发起审批的 deployments
 func (e *Endpoints) ListLaunchedApprovalDeployments(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
 	userID, err := user.GetUserID(r)
@@ -306,3 +328,9 @@ func (e *Endpoints) DeployStagesDomains(ctx context.Context, r *http.Request, va
 	}
 	return httpserver.OkResp(data)
 }
+
+// ConfigFilesSync 定时同步 runtime 挂载的配置中心文件及证书
+func (e *Endpoints) ConfigFilesSync() (bool, error) {
+	e.deployment.ConfigFilesSync()
+	return false, nil
+}
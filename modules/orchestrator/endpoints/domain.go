@@ -72,3 +72,72 @@ func (e *Endpoints) UpdateDomains(ctx context.Context, r *http.Request, vars map
 	}
 	return httpserver.OkResp(nil)
 }
+
+// IssueDomainCertificate 通过 ACME 为自定义域名签发证书
+func (e *Endpoints) IssueDomainCertificate(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	v := vars["runtimeID"]
+	runtimeID, err := strutil.Atoi64(v)
+	if err != nil {
+		return apierrors.ErrIssueDomainCertificate.InvalidParameter(strutil.Concat("runtimeID: ", v)).ToResp(), nil
+	}
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrIssueDomainCertificate.InvalidParameter(err).ToResp(), nil
+	}
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrIssueDomainCertificate.NotLogin().ToResp(), nil
+	}
+	var req apistructs.DomainCertificateIssueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrIssueDomainCertificate.InvalidParameter(err).ToResp(), nil
+	}
+	data, err := e.domain.IssueCertificate(userID, orgID, uint64(runtimeID), &req)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(data)
+}
+
+// ListDomainCertificates 查询 runtime 域名证书
+func (e *Endpoints) ListDomainCertificates(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	v := vars["runtimeID"]
+	runtimeID, err := strutil.Atoi64(v)
+	if err != nil {
+		return apierrors.ErrListDomainCertificate.InvalidParameter(strutil.Concat("runtimeID: ", v)).ToResp(), nil
+	}
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrListDomainCertificate.InvalidParameter(err).ToResp(), nil
+	}
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrListDomainCertificate.NotLogin().ToResp(), nil
+	}
+	data, err := e.domain.ListCertificates(userID, orgID, uint64(runtimeID))
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(data)
+}
+
+// ServeAcmeChallenge 响应 ACME HTTP-01 校验, 请求经平台 ingress 从自定义域名转发而来
+func (e *Endpoints) ServeAcmeChallenge(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	keyAuth, err := e.domain.ChallengeKeyAuth(vars["token"])
+	if err != nil {
+		return err
+	}
+	if keyAuth == "" {
+		http.NotFound(w, r)
+		return nil
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, err = w.Write([]byte(keyAuth))
+	return err
+}
+
+// DomainCertificateSync 续期即将过期的 ACME 证书并发送过期告警
+func (e *Endpoints) DomainCertificateSync() (bool, error) {
+	e.domain.CertificateSync()
+	return false, nil
+}
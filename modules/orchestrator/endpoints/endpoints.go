@@ -29,6 +29,7 @@ import (
 	"github.com/erda-project/erda/modules/orchestrator/services/addon"
 	"github.com/erda-project/erda/modules/orchestrator/services/deployment"
 	"github.com/erda-project/erda/modules/orchestrator/services/domain"
+	"github.com/erda-project/erda/modules/orchestrator/services/freeze"
 	"github.com/erda-project/erda/modules/orchestrator/services/instance"
 	"github.com/erda-project/erda/modules/orchestrator/services/migration"
 	"github.com/erda-project/erda/modules/orchestrator/services/resource"
@@ -53,6 +54,7 @@ type Endpoints struct {
 	encrypt    *encryption.EnvEncrypt
 	instance   *instance.Instance
 	migration  *migration.Migration
+	freeze     *freeze.Freeze
 }
 
 // Option Endpoints 配置选项
@@ -160,6 +162,13 @@ func WithMigration(migration *migration.Migration) Option {
 	}
 }
 
+// WithFreeze 设置部署冻结窗口 service
+func WithFreeze(freeze *freeze.Freeze) Option {
+	return func(e *Endpoints) {
+		e.freeze = freeze
+	}
+}
+
 // Routes 返回 endpoints 的所有 endpoint 方法，也就是 route.
 func (e *Endpoints) Routes() []httpserver.Endpoint {
 	return []httpserver.Endpoint{
@@ -175,6 +184,10 @@ func (e *Endpoints) Routes() []httpserver.Endpoint {
 		{Path: "/api/runtimes/{runtimeID}", Method: http.MethodDelete, Handler: e.DeleteRuntime},
 		// TODO: change configuration -> spec
 		{Path: "/api/runtimes/{runtimeID}/configuration", Method: http.MethodGet, Handler: e.GetRuntimeSpec},
+		{Path: "/api/runtimes/{runtimeID}/drift", Method: http.MethodGet, Handler: e.GetRuntimeDrift},
+		{Path: "/api/runtimes/{runtimeID}/actions/reconcile", Method: http.MethodPost, Handler: e.ReconcileRuntime},
+		{Path: "/api/runtimes/{runtimeID}/actions/export", Method: http.MethodGet, WriterHandler: e.ExportRuntime},
+		{Path: "/api/runtimes/actions/import-helm-values", Method: http.MethodPost, Handler: e.ImportRuntimeHelmValues},
 		{Path: "/api/runtimes/{runtimeID}/actions/stop", Method: http.MethodPost, Handler: e.StopRuntime},
 		{Path: "/api/runtimes/{runtimeID}/actions/start", Method: http.MethodPost, Handler: e.StartRuntime},
 		{Path: "/api/runtimes/{runtimeID}/actions/restart", Method: http.MethodPost, Handler: e.RestartRuntime},
@@ -191,6 +204,20 @@ func (e *Endpoints) Routes() []httpserver.Endpoint {
 		// kill pod (only k8s)
 		{Path: "/api/runtimes/actions/killpod", Method: http.MethodPost, Handler: e.KillPod},
 
+		// preview runtimes
+		{Path: "/api/preview-runtimes", Method: http.MethodPost, Handler: e.CreatePreviewRuntime},
+		{Path: "/api/preview-runtimes/actions/hook", Method: http.MethodPost, Handler: e.PreviewRuntimeMergeRequestHook},
+
+		// deploy freeze endpoints
+		{Path: "/api/deploy-freeze/windows", Method: http.MethodPost, Handler: e.CreateDeployFreezeWindow},
+		{Path: "/api/deploy-freeze/windows", Method: http.MethodGet, Handler: e.ListDeployFreezeWindows},
+		{Path: "/api/deploy-freeze/windows/{windowID}", Method: http.MethodPut, Handler: e.UpdateDeployFreezeWindow},
+		{Path: "/api/deploy-freeze/windows/{windowID}", Method: http.MethodDelete, Handler: e.DeleteDeployFreezeWindow},
+		{Path: "/api/deploy-freeze/overrides", Method: http.MethodPost, Handler: e.ApplyDeployFreezeOverride},
+		{Path: "/api/deploy-freeze/overrides", Method: http.MethodGet, Handler: e.ListDeployFreezeOverrides},
+		{Path: "/api/deploy-freeze/overrides/{overrideID}/actions/approve", Method: http.MethodPost, Handler: e.ApproveDeployFreezeOverride},
+		{Path: "/api/deploy-freeze/actions/check", Method: http.MethodGet, Handler: e.CheckDeployFreeze},
+
 		// deployment endpoints
 		{Path: "/api/deployments", Method: http.MethodGet, Handler: e.ListDeployments},
 		{Path: "/api/deployments/actions/list-launched-approval", Method: http.MethodGet, Handler: e.ListLaunchedApprovalDeployments},
@@ -201,6 +228,7 @@ func (e *Endpoints) Routes() []httpserver.Endpoint {
 		// TODO: do not returns runtime info, use /api/runtimes/{runtimeId} instead
 		{Path: "/api/deployments/{deploymentID}/status", Method: http.MethodGet, Handler: e.GetDeploymentStatus},
 		{Path: "/api/deployments/{deploymentID}/actions/cancel", Method: http.MethodPost, Handler: e.CancelDeployment},
+		{Path: "/api/deployments/{deploymentID}/actions/rollout", Method: http.MethodPost, Handler: e.RolloutDeployment},
 
 		{Path: "/api/deployments/{deploymentID}/actions/deploy-addons", Method: http.MethodPost, Handler: e.DeployStagesAddons},
 		{Path: "/api/deployments/{deploymentID}/actions/deploy-services", Method: http.MethodPost, Handler: e.DeployStagesServices},
@@ -210,6 +238,9 @@ func (e *Endpoints) Routes() []httpserver.Endpoint {
 		// TODO: api should be `/api/domains`
 		{Path: "/api/runtimes/{runtimeID}/domains", Method: http.MethodGet, Handler: e.ListDomains},
 		{Path: "/api/runtimes/{runtimeID}/domains", Method: http.MethodPut, Handler: e.UpdateDomains},
+		{Path: "/api/runtimes/{runtimeID}/domains/actions/issue-certificate", Method: http.MethodPost, Handler: e.IssueDomainCertificate},
+		{Path: "/api/runtimes/{runtimeID}/domain-certificates", Method: http.MethodGet, Handler: e.ListDomainCertificates},
+		{Path: domain.AcmeChallengePath + "{token}", Method: http.MethodGet, WriterHandler: e.ServeAcmeChallenge},
 
 		// instance endpoints
 		{Path: "/api/instances/actions/get-service", Method: http.MethodGet, Handler: e.ListServiceInstance},
@@ -236,6 +267,22 @@ func (e *Endpoints) Routes() []httpserver.Endpoint {
 		{Path: "/api/addon-platform/addons/{addonID}/config", Method: http.MethodPost, Handler: e.AddonConfigCallback},
 		{Path: "/api/addons/actions/list-customs", Method: http.MethodGet, Handler: e.ListCustomAddon},
 
+		// addon backup endpoints
+		{Path: "/api/addons/{addonID}/backup-policy", Method: http.MethodGet, Handler: e.GetAddonBackupPolicy},
+		{Path: "/api/addons/{addonID}/backup-policy", Method: http.MethodPut, Handler: e.SaveAddonBackupPolicy},
+		{Path: "/api/addons/{addonID}/backup-policy", Method: http.MethodDelete, Handler: e.DeleteAddonBackupPolicy},
+		{Path: "/api/addons/{addonID}/actions/backup", Method: http.MethodPost, Handler: e.BackupAddon},
+		{Path: "/api/addons/{addonID}/backups", Method: http.MethodGet, Handler: e.ListAddonBackups},
+		{Path: "/api/addons/{addonID}/backups/{backupID}/actions/restore", Method: http.MethodPost, Handler: e.RestoreAddonBackup},
+
+		// addon scale endpoints
+		{Path: "/api/addons/{addonID}/actions/scale", Method: http.MethodPost, Handler: e.ScaleAddon},
+		{Path: "/api/addons/{addonID}/scale-records", Method: http.MethodGet, Handler: e.ListAddonScaleRecords},
+
+		// addon credential rotation endpoints
+		{Path: "/api/addons/{addonID}/actions/rotate-credential", Method: http.MethodPost, Handler: e.RotateAddonCredential},
+		{Path: "/api/addons/{addonID}/credential-rotations", Method: http.MethodGet, Handler: e.ListAddonCredentialRotations},
+
 		// middleware endpoints(real addon instance)
 		{Path: "/api/middlewares", Method: http.MethodGet, Handler: e.ListMiddleware},
 		{Path: "/api/middlewares/{middlewareID}", Method: http.MethodGet, Handler: e.GetMiddleware},
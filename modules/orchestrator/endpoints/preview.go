@@ -0,0 +1,86 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package endpoints
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/conf"
+	"github.com/erda-project/erda/modules/orchestrator/services/apierrors"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/httpserver"
+	"github.com/erda-project/erda/pkg/httpserver/errorresp"
+)
+
+// CreatePreviewRuntime 基于制品为 merge request 创建预览环境
+func (e *Endpoints) CreatePreviewRuntime(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	operator, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrCreateRuntime.NotLogin().ToResp(), nil
+	}
+	var req apistructs.PreviewRuntimeCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		// param problem
+		return apierrors.ErrCreateRuntime.InvalidParameter("req body").ToResp(), nil
+	}
+	data, err := e.runtime.CreatePreview(operator, &req)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(data)
+}
+
+// PreviewRuntimeMergeRequestHook 消费 merge request 合并/关闭事件, 回收对应的预览环境
+func (e *Endpoints) PreviewRuntimeMergeRequestHook(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	if !validPreviewHookToken(r.URL.Query().Get("token")) {
+		return apierrors.ErrDeleteRuntime.AccessDenied().ToResp(), nil
+	}
+	var req apistructs.RepoCreateMrEvent
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrDeleteRuntime.InvalidParameter("req body").ToResp(), nil
+	}
+	var operator string
+	switch req.Event {
+	case apistructs.GitMergeMREvent:
+		operator = req.Content.MergeUserId
+	case apistructs.GitCloseMREvent:
+		operator = req.Content.CloseUserId
+	default:
+		return httpserver.OkResp(nil)
+	}
+	logrus.Infof("merge request %d of app %d is %s, teardown preview runtime",
+		req.Content.RepoMergeId, req.Content.AppID, req.Content.State)
+	if err := e.runtime.TeardownPreview(uint64(req.Content.AppID), uint64(req.Content.RepoMergeId), operator); err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(nil)
+}
+
+// validPreviewHookToken 校验回调地址中的 token, 未配置 token 时拒绝所有回调
+func validPreviewHookToken(token string) bool {
+	expected := conf.PreviewRuntimeHookToken()
+	return expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// PreviewRuntimeGC 定时回收过期的预览环境
+func (e *Endpoints) PreviewRuntimeGC() (bool, error) {
+	e.runtime.PreviewGC()
+	return false, nil
+}
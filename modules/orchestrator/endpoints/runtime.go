@@ -335,6 +335,56 @@ func (e *Endpoints) GetRuntimeSpec(ctx context.Context, r *http.Request, vars ma
 	return httpserver.OkResp(data)
 }
 
+// GetRuntimeDrift 查询应用实例声明的规格与集群实际状态的差异
+func (e *Endpoints) GetRuntimeDrift(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrGetRuntime.InvalidParameter(err).ToResp(), nil
+	}
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrGetRuntime.NotLogin().ToResp(), nil
+	}
+	v := vars["runtimeID"]
+	runtimeID, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return apierrors.ErrGetRuntime.InvalidParameter("runtimeID: " + v).ToResp(), nil
+	}
+	data, err := e.runtime.Drift(userID, orgID, runtimeID)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(data)
+}
+
+// ReconcileRuntime 以声明的规格重新部署, 消除集群中的手动修改
+func (e *Endpoints) ReconcileRuntime(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrDeployRuntime.InvalidParameter(err).ToResp(), nil
+	}
+	operator, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrDeployRuntime.NotLogin().ToResp(), nil
+	}
+	v := vars["runtimeID"]
+	runtimeID, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return apierrors.ErrDeployRuntime.InvalidParameter("runtimeID: " + v).ToResp(), nil
+	}
+	data, err := e.runtime.Reconcile(operator, orgID, runtimeID)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(data)
+}
+
+// RuntimeDriftScan 定时扫描 runtime 的规格漂移
+func (e *Endpoints) RuntimeDriftScan() (bool, error) {
+	e.runtime.DriftScan()
+	return false, nil
+}
+
 // FullGC 触发全量 GC
 func (e *Endpoints) FullGC(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
 	go e.runtime.FullGC()
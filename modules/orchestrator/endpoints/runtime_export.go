@@ -0,0 +1,78 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package endpoints
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/services/apierrors"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/httpserver"
+	"github.com/erda-project/erda/pkg/httpserver/errorresp"
+)
+
+// ExportRuntime 将应用实例导出为 helm chart 或 kustomize manifests 的 tar.gz
+func (e *Endpoints) ExportRuntime(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrExportRuntime.InvalidParameter(err)
+	}
+	userID, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrExportRuntime.NotLogin()
+	}
+	v := vars["runtimeID"]
+	runtimeID, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return apierrors.ErrExportRuntime.InvalidParameter("runtimeID: " + v)
+	}
+	format := apistructs.RuntimeExportFormat(r.URL.Query().Get("format"))
+	filename, data, err := e.runtime.Export(userID, orgID, runtimeID, format)
+	if err != nil {
+		return err
+	}
+	w.Header().Add("Content-Disposition", "attachment;fileName="+filename)
+	w.Header().Add("Content-Type", "application/gzip")
+	if _, err := io.Copy(w, bytes.NewReader(data)); err != nil {
+		return apierrors.ErrExportRuntime.InternalError(err)
+	}
+	return nil
+}
+
+// ImportRuntimeHelmValues 将 helm values 导入为应用的制品
+func (e *Endpoints) ImportRuntimeHelmValues(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	orgID, err := getOrgID(r)
+	if err != nil {
+		return apierrors.ErrImportRuntime.InvalidParameter(err).ToResp(), nil
+	}
+	operator, err := user.GetUserID(r)
+	if err != nil {
+		return apierrors.ErrImportRuntime.NotLogin().ToResp(), nil
+	}
+	var req apistructs.RuntimeHelmImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrImportRuntime.InvalidParameter("req body").ToResp(), nil
+	}
+	data, err := e.runtime.ImportHelmValues(operator, orgID, &req)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(data)
+}
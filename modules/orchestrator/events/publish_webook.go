@@ -53,6 +53,27 @@ func (m *EventManager) publishWebhook(event *RuntimeEvent) error {
 		w.ProjectID = strconv.FormatUint(event.Runtime.ProjectID, 10)
 		w.ApplicationID = strconv.FormatUint(event.Runtime.ApplicationID, 10)
 		w.Env = event.Runtime.Workspace
+	case RuntimeDeployRollback:
+		w.Event = "runtime"
+		w.Action = "rollback"
+		w.OrgID = strconv.FormatUint(event.Runtime.OrgID, 10)
+		w.ProjectID = strconv.FormatUint(event.Runtime.ProjectID, 10)
+		w.ApplicationID = strconv.FormatUint(event.Runtime.ApplicationID, 10)
+		w.Env = event.Runtime.Workspace
+	case RuntimeDrifted:
+		w.Event = "runtime"
+		w.Action = "drift"
+		w.OrgID = strconv.FormatUint(event.Runtime.OrgID, 10)
+		w.ProjectID = strconv.FormatUint(event.Runtime.ProjectID, 10)
+		w.ApplicationID = strconv.FormatUint(event.Runtime.ApplicationID, 10)
+		w.Env = event.Runtime.Workspace
+	case RuntimeDomainCertificateExpiring:
+		w.Event = "runtime"
+		w.Action = "certificate-expiring"
+		w.OrgID = strconv.FormatUint(event.Runtime.OrgID, 10)
+		w.ProjectID = strconv.FormatUint(event.Runtime.ProjectID, 10)
+		w.ApplicationID = strconv.FormatUint(event.Runtime.ApplicationID, 10)
+		w.Env = event.Runtime.Workspace
 	default:
 		// TODO: support more webhooks
 		return nil
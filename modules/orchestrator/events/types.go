@@ -39,6 +39,11 @@ const (
 	RuntimeDeployCanceled      EventName = "RuntimeDeployCanceled"
 	RuntimeDeployCancelFailed  EventName = "RuntimeDeployCancelFailed"
 	RuntimeDeployOk            EventName = "RuntimeDeployOk"
+	RuntimeDeployRollback      EventName = "RuntimeDeployRollback"
+	// drift
+	RuntimeDrifted EventName = "RuntimeDrifted"
+	// domain certificate
+	RuntimeDomainCertificateExpiring EventName = "RuntimeDomainCertificateExpiring"
 )
 
 type ActionName string
@@ -62,4 +67,8 @@ type RuntimeEvent struct {
 	Instances []*apistructs.RuntimeInstanceDTO `json:"instance,omitempty"`
 	// only used for RuntimeDeploy* events
 	Deployment *apistructs.Deployment `json:"deployment,omitempty"`
+	// only used for RuntimeDrifted
+	Drift *apistructs.RuntimeDriftReport `json:"drift,omitempty"`
+	// only used for RuntimeDomainCertificateExpiring
+	DomainCertificate *apistructs.DomainCertificate `json:"domainCertificate,omitempty"`
 }
@@ -15,10 +15,12 @@
 package orchestrator
 
 import (
+	"net/url"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/bundle"
 	"github.com/erda-project/erda/modules/orchestrator/conf"
 	"github.com/erda-project/erda/modules/orchestrator/dbclient"
@@ -28,6 +30,7 @@ import (
 	"github.com/erda-project/erda/modules/orchestrator/services/addon"
 	"github.com/erda-project/erda/modules/orchestrator/services/deployment"
 	"github.com/erda-project/erda/modules/orchestrator/services/domain"
+	"github.com/erda-project/erda/modules/orchestrator/services/freeze"
 	"github.com/erda-project/erda/modules/orchestrator/services/instance"
 	"github.com/erda-project/erda/modules/orchestrator/services/migration"
 	"github.com/erda-project/erda/modules/orchestrator/services/resource"
@@ -37,6 +40,7 @@ import (
 	"github.com/erda-project/erda/pkg/httpclient"
 	"github.com/erda-project/erda/pkg/httpserver"
 	"github.com/erda-project/erda/pkg/loop"
+	"github.com/erda-project/erda/pkg/strutil"
 	// "terminus.io/dice/telemetry/promxp"
 )
 
@@ -137,12 +141,25 @@ func initEndpoints(db *dbclient.DBClient) (*endpoints.Endpoints, error) {
 		)),
 	)
 
+	// init domain service
+	dom := domain.New(
+		domain.WithDBClient(db),
+		domain.WithEventManager(evMgr),
+		domain.WithBundle(bdl))
+
+	// init deploy freeze service
+	fz := freeze.New(
+		freeze.WithDBClient(db),
+		freeze.WithBundle(bdl))
+
 	// init runtime service
 	rt := runtime.New(
 		runtime.WithDBClient(db),
 		runtime.WithEventManager(evMgr),
 		runtime.WithBundle(bdl),
-		runtime.WithAddon(a))
+		runtime.WithAddon(a),
+		runtime.WithDomain(dom),
+		runtime.WithFreeze(fz))
 
 	// init deployment service
 	d := deployment.New(
@@ -153,14 +170,9 @@ func initEndpoints(db *dbclient.DBClient) (*endpoints.Endpoints, error) {
 		deployment.WithMigration(migration),
 		deployment.WithEncrypt(encrypt),
 		deployment.WithResource(resource),
+		deployment.WithRollbacker(rt),
 	)
 
-	// init domain service
-	dom := domain.New(
-		domain.WithDBClient(db),
-		domain.WithEventManager(evMgr),
-		domain.WithBundle(bdl))
-
 	ins := instance.New(
 		instance.WithBundle(bdl),
 	)
@@ -180,11 +192,38 @@ func initEndpoints(db *dbclient.DBClient) (*endpoints.Endpoints, error) {
 		endpoints.WithEnvEncrypt(encrypt),
 		endpoints.WithResource(resource),
 		endpoints.WithMigration(migration),
+		endpoints.WithFreeze(fz),
 	)
 
+	registerWebHook(bdl)
+
 	return ep, nil
 }
 
+func registerWebHook(bdl *bundle.Bundle) {
+	// 监听 merge request 合并/关闭事件, 回收预览环境. 回调地址携带 token 供校验, 未配置 token 时不注册
+	token := conf.PreviewRuntimeHookToken()
+	if token == "" {
+		logrus.Warnf("preview runtime hook token is not configured, preview runtimes are only recycled by ttl")
+		return
+	}
+	ev := apistructs.CreateHookRequest{
+		Name:   "orchestrator_preview_runtimes",
+		Events: []string{apistructs.GitMergeMREvent, apistructs.GitCloseMREvent},
+		URL: strutil.Concat("http://", conf.SelfAddr(), "/api/preview-runtimes/actions/hook?token=",
+			url.QueryEscape(token)),
+		Active: true,
+		HookLocation: apistructs.HookLocation{
+			Org:         "-1",
+			Project:     "-1",
+			Application: "-1",
+		},
+	}
+	if err := bdl.CreateWebhook(ev); err != nil {
+		logrus.Warnf("failed to register merge request event for preview runtimes, (%v)", err)
+	}
+}
+
 // 初始化定时任务
 func initCron(ep *endpoints.Endpoints) error {
 	// cron for pushOn deployment
@@ -211,6 +250,22 @@ func initCron(ep *endpoints.Endpoints) error {
 
 	go loop.New(loop.WithInterval(5 * time.Minute)).Do(ep.SyncAddonResources)
 
+	go loop.New(loop.WithInterval(10 * time.Minute)).Do(ep.PreviewRuntimeGC)
+
+	// 配置中心文件及证书内容变化时滚动重启服务
+	go loop.New(loop.WithInterval(10 * time.Minute)).Do(ep.ConfigFilesSync)
+
+	// 扫描 runtime 规格漂移
+	go loop.New(loop.WithInterval(30 * time.Minute)).Do(ep.RuntimeDriftScan)
+
+	// addon 定时备份及备份/恢复状态同步
+	go loop.New(loop.WithInterval(time.Minute)).Do(ep.AddonBackupSchedule)
+	go loop.New(loop.WithInterval(30 * time.Second)).Do(ep.AddonScaleSync)
+	go loop.New(loop.WithInterval(time.Minute)).Do(ep.AddonCredentialRotationSync)
+
+	// runtime 域名 ACME 证书续期及过期告警
+	go loop.New(loop.WithInterval(time.Hour)).Do(ep.DomainCertificateSync)
+
 	ep.FullGCLoop()
 
 	return nil
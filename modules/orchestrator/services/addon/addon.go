@@ -1241,6 +1241,15 @@ func (a *Addon) Get(userID, orgID, routingInstanceID string, internalcall bool)
 
 	addonResp := a.convert(routingInstance)
 
+	// 填充进行中或最近一次失败的规格变更
+	scaleRecord, err := a.db.GetLatestAddonScaleRecord(routingInstance.ID)
+	if err != nil {
+		return nil, err
+	}
+	if scaleRecord != nil && scaleRecord.Status != apistructs.AddonScaleSuccess {
+		addonResp.Scaling = convertScaleRecord(scaleRecord)
+	}
+
 	// 填充 config 信息
 	instance, err := a.db.GetAddonInstance(routingInstance.RealInstance)
 	if err != nil {
@@ -0,0 +1,759 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package addon
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/conf"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/pkg/cloudstorage"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+const (
+	// addonBackupJobNamespace 备份/恢复 job 所在的 scheduler namespace
+	addonBackupJobNamespace = "addon-backup"
+	// addonBackupDefaultRetention 默认保留的定时备份份数
+	addonBackupDefaultRetention = 7
+	// addonBackupJobTimeout 备份/恢复 job 最长执行时间, 超时视为失败
+	addonBackupJobTimeout = 6 * time.Hour
+	// addonRestoreProvisionTimeout 恢复时等待新实例创建的最长时间
+	addonRestoreProvisionTimeout = time.Hour
+)
+
+// addonBackupFileExt 支持备份的 addon 及其备份文件后缀
+var addonBackupFileExt = map[string]string{
+	apistructs.AddonMySQL: ".sql.gz",
+	apistructs.AddonRedis: ".rdb",
+	apistructs.AddonES:    ".tar.gz",
+}
+
+// addonBackupSecretEnvKeys addon 连接信息中需要以 Secret 注入备份/恢复 job 的配置项
+var addonBackupSecretEnvKeys = map[string]struct{}{
+	apistructs.AddonMysqlPasswordName: {},
+	apistructs.AddonRedisPasswordName: {},
+	apistructs.AddonEsPasswordName:    {},
+}
+
+// GetBackupPolicy 查询 addon 实例的备份策略, 未配置时返回 nil
+func (a *Addon) GetBackupPolicy(userID, addonID string) (*apistructs.AddonBackupPolicy, error) {
+	routing, err := a.getBackupRouting(addonID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkAddonPermission(userID, routing, apistructs.GetAction); err != nil {
+		return nil, err
+	}
+	policy, err := a.db.GetAddonBackupPolicy(addonID)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+	return convertBackupPolicy(policy), nil
+}
+
+// SaveBackupPolicy 创建或更新 addon 实例的备份策略
+func (a *Addon) SaveBackupPolicy(userID, addonID string, req *apistructs.AddonBackupPolicyRequest) (*apistructs.AddonBackupPolicy, error) {
+	routing, err := a.getBackupRouting(addonID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkAddonPermission(userID, routing, apistructs.UpdateAction); err != nil {
+		return nil, err
+	}
+	if _, err := cron.ParseStandard(req.Schedule); err != nil {
+		return nil, errors.Errorf("invalid schedule %q: %v", req.Schedule, err)
+	}
+	if req.Retention < 0 {
+		return nil, errors.Errorf("invalid retention: %d", req.Retention)
+	}
+	if req.Retention == 0 {
+		req.Retention = addonBackupDefaultRetention
+	}
+	if req.Storage.Endpoint == "" || req.Storage.Bucket == "" || req.Storage.AccessKey == "" {
+		return nil, errors.New("storage endpoint, bucket and accessKey are required")
+	}
+
+	policy, err := a.db.GetAddonBackupPolicy(addonID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &dbclient.AddonBackupPolicy{AddonID: addonID, AddonName: routing.AddonName, Creator: userID}
+	}
+	secretKey := req.Storage.SecretKey
+	if secretKey == "" {
+		// 未传入 secretKey 时沿用已保存的
+		if policy.SecretKey == "" {
+			return nil, errors.New("storage secretKey is required")
+		}
+		if secretKey, err = a.encrypt.DecryptPassword(policy.SecretKey); err != nil {
+			return nil, err
+		}
+	}
+	client, err := cloudstorage.New(req.Storage.Endpoint, req.Storage.AccessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.HealthCheck(); err != nil {
+		return nil, errors.Errorf("failed to connect to storage %s: %v", req.Storage.Endpoint, err)
+	}
+	exists, err := client.BucketExists(req.Storage.Bucket)
+	if err != nil {
+		return nil, errors.Errorf("failed to check bucket %s: %v", req.Storage.Bucket, err)
+	}
+	if !exists {
+		return nil, errors.Errorf("bucket %s not found in storage %s", req.Storage.Bucket, req.Storage.Endpoint)
+	}
+	encSecretKey, err := a.encrypt.EncryptPassword(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	if policy.Schedule != req.Schedule {
+		// 修改 schedule 后从当前时间重新计算下次触发时间
+		now := time.Now()
+		policy.LastScheduledAt = &now
+	}
+	policy.Schedule = req.Schedule
+	policy.Retention = req.Retention
+	policy.Endpoint = req.Storage.Endpoint
+	policy.Bucket = req.Storage.Bucket
+	policy.AccessKey = req.Storage.AccessKey
+	policy.SecretKey = encSecretKey
+	policy.PathPrefix = strings.Trim(req.Storage.PathPrefix, "/")
+	policy.Enabled = req.Enabled
+	if err := a.db.SaveAddonBackupPolicy(policy); err != nil {
+		return nil, err
+	}
+	return convertBackupPolicy(policy), nil
+}
+
+// DeleteBackupPolicy 删除 addon 实例的备份策略, 已有的备份文件不受影响, 仍可使用备份时记录的存储凭证恢复
+func (a *Addon) DeleteBackupPolicy(userID, addonID string) error {
+	routing, err := a.getBackupRouting(addonID)
+	if err != nil {
+		return err
+	}
+	if err := a.checkAddonPermission(userID, routing, apistructs.UpdateAction); err != nil {
+		return err
+	}
+	return a.db.DeleteAddonBackupPolicy(addonID)
+}
+
+// Backup 立即备份 addon 实例, 备份文件存放到备份策略配置的对象存储
+func (a *Addon) Backup(userID, addonID string) (*apistructs.AddonBackup, error) {
+	routing, err := a.getBackupRouting(addonID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkAddonPermission(userID, routing, apistructs.UpdateAction); err != nil {
+		return nil, err
+	}
+	policy, err := a.db.GetAddonBackupPolicy(addonID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, errors.Errorf("backup policy of addon %s not found, storage must be configured first", addonID)
+	}
+	backup, err := a.startBackup(policy, routing, apistructs.AddonBackupManual, userID)
+	if err != nil {
+		return nil, err
+	}
+	return convertBackup(backup), nil
+}
+
+// ListBackups 查询 addon 实例的备份及恢复历史
+func (a *Addon) ListBackups(userID, addonID string) (*apistructs.AddonBackupHistory, error) {
+	routing, err := a.db.GetInstanceRouting(addonID)
+	if err != nil {
+		return nil, err
+	}
+	if routing == nil {
+		return nil, errors.Errorf("addon %s not found", addonID)
+	}
+	if err := a.checkAddonPermission(userID, routing, apistructs.GetAction); err != nil {
+		return nil, err
+	}
+	backups, err := a.db.FindAddonBackups(addonID)
+	if err != nil {
+		return nil, err
+	}
+	restores, err := a.db.FindAddonRestores(addonID)
+	if err != nil {
+		return nil, err
+	}
+	history := apistructs.AddonBackupHistory{
+		Backups:  make([]apistructs.AddonBackup, 0, len(backups)),
+		Restores: make([]apistructs.AddonRestore, 0, len(restores)),
+	}
+	for i := range backups {
+		history.Backups = append(history.Backups, *convertBackup(&backups[i]))
+	}
+	for i := range restores {
+		history.Restores = append(history.Restores, *convertRestore(&restores[i]))
+	}
+	return &history, nil
+}
+
+// Restore 使用备份创建一个新的 addon 实例, 实例创建完成后由定时任务执行恢复 job
+func (a *Addon) Restore(userID, addonID string, backupID uint64, req *apistructs.AddonRestoreRequest) (*apistructs.AddonRestore, error) {
+	routing, err := a.getBackupRouting(addonID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkAddonPermission(userID, routing, apistructs.CreateAction); err != nil {
+		return nil, err
+	}
+	backup, err := a.db.GetAddonBackup(backupID)
+	if err != nil {
+		return nil, err
+	}
+	if backup.AddonID != addonID {
+		return nil, errors.Errorf("backup %d does not belong to addon %s", backupID, addonID)
+	}
+	if backup.Status != apistructs.AddonBackupSuccess {
+		return nil, errors.Errorf("backup %d is %s, only successful backups can be restored", backupID, backup.Status)
+	}
+	// 创建新实例前校验存储凭证, 避免创建出无法恢复的实例
+	if _, _, err := a.backupStorageCredential(backup); err != nil {
+		return nil, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = fmt.Sprintf("%s-restore-%d", routing.Name, backupID)
+	}
+	plan := req.Plan
+	if plan == "" {
+		plan = routing.Plan
+	}
+	var options map[string]string
+	if routing.Options != "" {
+		if err := json.Unmarshal([]byte(routing.Options), &options); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse options of addon %s", addonID)
+		}
+	}
+	orgID, _ := strconv.ParseUint(routing.OrgID, 10, 64)
+	projectID, _ := strconv.ParseUint(routing.ProjectID, 10, 64)
+	targetAddonID, err := a.AddonCreate(apistructs.AddonDirectCreateRequest{
+		ClusterName: routing.Cluster,
+		OrgID:       orgID,
+		ProjectID:   projectID,
+		Workspace:   routing.Workspace,
+		Operator:    userID,
+		ShareScope:  routing.ShareScope,
+		Addons: diceyml.AddOns{
+			name: &diceyml.AddOn{Plan: routing.AddonName + ":" + plan, Options: options},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create addon to restore to")
+	}
+	restore := dbclient.AddonRestore{
+		BackupID:      backupID,
+		SourceAddonID: addonID,
+		TargetAddonID: targetAddonID,
+		Status:        apistructs.AddonBackupPending,
+		Operator:      userID,
+	}
+	if err := a.db.CreateAddonRestore(&restore); err != nil {
+		return nil, err
+	}
+	return convertRestore(&restore), nil
+}
+
+// BackupSchedule 触发到期的定时备份, 并同步执行中的备份/恢复 job 状态
+func (a *Addon) BackupSchedule() {
+	now := time.Now()
+	policies, err := a.db.FindEnabledAddonBackupPolicies()
+	if err != nil {
+		logrus.Errorf("[alert] failed to find addon backup policies: %v", err)
+	}
+	for i := range policies {
+		a.scheduleBackup(&policies[i], now)
+	}
+
+	backups, err := a.db.FindUnfinishedAddonBackups()
+	if err != nil {
+		logrus.Errorf("[alert] failed to find unfinished addon backups: %v", err)
+	}
+	for i := range backups {
+		a.syncBackup(&backups[i], now)
+	}
+
+	restores, err := a.db.FindUnfinishedAddonRestores()
+	if err != nil {
+		logrus.Errorf("[alert] failed to find unfinished addon restores: %v", err)
+	}
+	for i := range restores {
+		a.syncRestore(&restores[i], now)
+	}
+}
+
+func (a *Addon) scheduleBackup(policy *dbclient.AddonBackupPolicy, now time.Time) {
+	due, err := backupDue(policy.Schedule, policy.LastScheduledAt, policy.CreatedAt, now)
+	if err != nil {
+		logrus.Errorf("invalid schedule of addon backup policy %d: %v", policy.ID, err)
+		return
+	}
+	if !due {
+		return
+	}
+	claimed, err := a.db.ClaimAddonBackupPolicy(policy, now)
+	if err != nil {
+		logrus.Errorf("failed to claim addon backup policy %d: %v", policy.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	routing, err := a.getBackupRouting(policy.AddonID)
+	if err != nil {
+		logrus.Warnf("skip scheduled backup of addon %s: %v", policy.AddonID, err)
+		return
+	}
+	if _, err := a.startBackup(policy, routing, apistructs.AddonBackupScheduled, ""); err != nil {
+		logrus.Errorf("failed to start scheduled backup of addon %s: %v", policy.AddonID, err)
+	}
+}
+
+// startBackup 创建备份记录并启动备份 job, 启动失败时记录为失败
+func (a *Addon) startBackup(policy *dbclient.AddonBackupPolicy, routing *dbclient.AddonInstanceRouting,
+	backupType apistructs.AddonBackupType, operator string) (*dbclient.AddonBackup, error) {
+	backup := dbclient.AddonBackup{
+		AddonID:    routing.ID,
+		InstanceID: routing.RealInstance,
+		AddonName:  routing.AddonName,
+		Type:       backupType,
+		Status:     apistructs.AddonBackupPending,
+		Endpoint:   policy.Endpoint,
+		Bucket:     policy.Bucket,
+		AccessKey:  policy.AccessKey,
+		SecretKey:  policy.SecretKey,
+		Operator:   operator,
+	}
+	if err := a.db.CreateAddonBackup(&backup); err != nil {
+		return nil, err
+	}
+	backup.ObjectName = backupObjectName(policy.PathPrefix, routing, backup.ID, time.Now())
+	backup.JobName = fmt.Sprintf("backup-%d", backup.ID)
+
+	if err := a.runBackupJob("backup", backup.JobName, routing, &backup); err != nil {
+		backup.Status = apistructs.AddonBackupFailed
+		backup.Message = err.Error()
+	} else {
+		now := time.Now()
+		backup.Status = apistructs.AddonBackupRunning
+		backup.StartedAt = &now
+	}
+	if err := a.db.UpdateAddonBackup(&backup); err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+// runBackupJob 创建并启动执行备份或恢复的 scheduler job, routing 为被操作的 addon 实例, 使用备份记录中的存储及凭证
+func (a *Addon) runBackupJob(action, jobName string, routing *dbclient.AddonInstanceRouting, backup *dbclient.AddonBackup) error {
+	ins, err := a.db.GetAddonInstance(routing.RealInstance)
+	if err != nil {
+		return err
+	}
+	if ins == nil {
+		return errors.Errorf("addon instance %s not found", routing.RealInstance)
+	}
+	config, err := a.GetAddonConfig(ins)
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return errors.Errorf("config of addon instance %s not found", ins.ID)
+	}
+	accessKey, secretKey, err := a.backupStorageCredential(backup)
+	if err != nil {
+		return err
+	}
+
+	env, secretEnv := backupJobEnv(action, ins.AddonName, config.Config)
+	env["STORAGE_ENDPOINT"] = backup.Endpoint
+	env["STORAGE_BUCKET"] = backup.Bucket
+	env["STORAGE_OBJECT"] = backup.ObjectName
+	env["STORAGE_ACCESS_KEY"] = accessKey
+	secretEnv["STORAGE_SECRET_KEY"] = secretKey
+
+	job := apistructs.JobCreateRequest{
+		Name:        jobName,
+		Namespace:   addonBackupJobNamespace,
+		ClusterName: ins.Cluster,
+		Image:       conf.AddonBackupImage(),
+		Cmd:         "/app/addon-backup",
+		CPU:         0.5,
+		Memory:      1024,
+		Env:         env,
+		SecretEnv:   secretEnv,
+		Labels: map[string]string{
+			"DICE_ORG_ID":     routing.OrgID,
+			"DICE_PROJECT_ID": routing.ProjectID,
+			"DICE_WORKSPACE":  routing.Workspace,
+			"DICE_ADDON_ID":   routing.ID,
+		},
+	}
+	if _, err := a.bdl.CreateJob(job); err != nil {
+		return errors.Wrapf(err, "failed to create %s job", action)
+	}
+	if _, err := a.bdl.StartJob(addonBackupJobNamespace, jobName); err != nil {
+		return errors.Wrapf(err, "failed to start %s job", action)
+	}
+	return nil
+}
+
+// syncBackup 同步备份 job 状态. 状态以乐观锁更新, 只有更新成功的实例才删除 job 及清理过期备份,
+// 其他实例此后查询不到 job 时, 记录已不是 Running, 不会被误标记为失败
+func (a *Addon) syncBackup(backup *dbclient.AddonBackup, now time.Time) {
+	if backup.Status != apistructs.AddonBackupRunning {
+		return
+	}
+	status, message, job := a.backupJobStatus(backup.JobName, backup.StartedAt, now)
+	if status == apistructs.AddonBackupRunning {
+		return
+	}
+	backup.Status = status
+	backup.Message = message
+	backup.FinishedAt = &now
+	transited, err := a.db.TransitAddonBackup(backup, apistructs.AddonBackupRunning)
+	if err != nil {
+		logrus.Errorf("failed to update addon backup %d: %v", backup.ID, err)
+		return
+	}
+	if !transited {
+		return
+	}
+	a.deleteBackupJob(job)
+	if status == apistructs.AddonBackupSuccess && backup.Type == apistructs.AddonBackupScheduled {
+		a.cleanExpiredBackups(backup.AddonID)
+	}
+}
+
+// syncRestore 等待新实例创建完成后启动恢复 job, 并同步 job 状态, 状态流转方式同 syncBackup
+func (a *Addon) syncRestore(restore *dbclient.AddonRestore, now time.Time) {
+	switch restore.Status {
+	case apistructs.AddonBackupPending:
+		target, err := a.db.GetInstanceRouting(restore.TargetAddonID)
+		if err != nil {
+			logrus.Errorf("failed to get addon %s to restore to: %v", restore.TargetAddonID, err)
+			return
+		}
+		switch {
+		case target == nil || target.Status == string(apistructs.AddonAttachFail):
+			restore.Status = apistructs.AddonBackupFailed
+			restore.Message = fmt.Sprintf("failed to create addon %s to restore to", restore.TargetAddonID)
+		case now.Sub(restore.CreatedAt) > addonRestoreProvisionTimeout:
+			restore.Status = apistructs.AddonBackupFailed
+			restore.Message = fmt.Sprintf("addon %s to restore to is not ready in %s",
+				restore.TargetAddonID, addonRestoreProvisionTimeout)
+		case target.Status == string(apistructs.AddonAttached):
+			// 先抢占记录再启动 job, 避免多个实例重复恢复
+			restore.JobName = fmt.Sprintf("restore-%d", restore.ID)
+			restore.Status = apistructs.AddonBackupRunning
+			restore.StartedAt = &now
+			if !a.transitRestore(restore, apistructs.AddonBackupPending) {
+				return
+			}
+			if err := a.startRestoreJob(restore, target); err != nil {
+				restore.Status = apistructs.AddonBackupFailed
+				restore.Message = err.Error()
+				restore.FinishedAt = &now
+				a.transitRestore(restore, apistructs.AddonBackupRunning)
+			}
+			return
+		default:
+			return
+		}
+		restore.FinishedAt = &now
+		a.transitRestore(restore, apistructs.AddonBackupPending)
+	case apistructs.AddonBackupRunning:
+		status, message, job := a.backupJobStatus(restore.JobName, restore.StartedAt, now)
+		if status == apistructs.AddonBackupRunning {
+			return
+		}
+		restore.Status = status
+		restore.Message = message
+		restore.FinishedAt = &now
+		if a.transitRestore(restore, apistructs.AddonBackupRunning) {
+			a.deleteBackupJob(job)
+		}
+	}
+}
+
+// transitRestore 以乐观锁更新恢复记录状态, 返回是否更新成功
+func (a *Addon) transitRestore(restore *dbclient.AddonRestore, from apistructs.AddonBackupStatus) bool {
+	transited, err := a.db.TransitAddonRestore(restore, from)
+	if err != nil {
+		logrus.Errorf("failed to update addon restore %d: %v", restore.ID, err)
+		return false
+	}
+	return transited
+}
+
+func (a *Addon) startRestoreJob(restore *dbclient.AddonRestore, target *dbclient.AddonInstanceRouting) error {
+	backup, err := a.db.GetAddonBackup(restore.BackupID)
+	if err != nil {
+		return err
+	}
+	return a.runBackupJob("restore", restore.JobName, target, backup)
+}
+
+// backupStorageCredential 返回备份记录中的存储凭证, secret key 已解密
+func (a *Addon) backupStorageCredential(backup *dbclient.AddonBackup) (string, string, error) {
+	if backup.AccessKey == "" || backup.SecretKey == "" {
+		return "", "", errors.Errorf("storage credential of backup %d is not recorded", backup.ID)
+	}
+	secretKey, err := a.encrypt.DecryptPassword(backup.SecretKey)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to decrypt storage credential of backup %d", backup.ID)
+	}
+	return backup.AccessKey, secretKey, nil
+}
+
+// backupJobStatus 查询备份/恢复 job 状态, job 不存在时返回的 job 为 nil
+func (a *Addon) backupJobStatus(jobName string, startedAt *time.Time, now time.Time) (apistructs.AddonBackupStatus, string, *apistructs.Job) {
+	job, err := a.bdl.InspectJob(addonBackupJobNamespace, jobName)
+	if err != nil {
+		if strutil.Contains(err.Error(), "not found") {
+			return apistructs.AddonBackupFailed, fmt.Sprintf("job %s not found", jobName), nil
+		}
+		logrus.Warnf("failed to inspect addon backup job %s: %v", jobName, err)
+		return apistructs.AddonBackupRunning, "", nil
+	}
+	status, message := transferBackupJobStatus(job.Status, job.LastMessage)
+	if status == apistructs.AddonBackupRunning {
+		if startedAt == nil || now.Sub(*startedAt) <= addonBackupJobTimeout {
+			return status, "", job
+		}
+		status, message = apistructs.AddonBackupFailed, fmt.Sprintf("job %s timeout after %s", jobName, addonBackupJobTimeout)
+	}
+	return status, message, job
+}
+
+// deleteBackupJob 删除已结束的备份/恢复 job
+func (a *Addon) deleteBackupJob(job *apistructs.Job) {
+	if job == nil {
+		return
+	}
+	if err := a.bdl.DeleteJob(*job); err != nil {
+		logrus.Warnf("failed to delete addon backup job %s: %v", job.Name, err)
+	}
+}
+
+// cleanExpiredBackups 删除超出保留份数的定时备份及其备份文件
+func (a *Addon) cleanExpiredBackups(addonID string) {
+	policy, err := a.db.GetAddonBackupPolicy(addonID)
+	if err != nil || policy == nil || policy.Retention <= 0 {
+		return
+	}
+	backups, err := a.db.FindSuccessfulScheduledAddonBackups(addonID)
+	if err != nil {
+		logrus.Errorf("failed to find scheduled backups of addon %s: %v", addonID, err)
+		return
+	}
+	if len(backups) <= policy.Retention {
+		return
+	}
+	secretKey, err := a.encrypt.DecryptPassword(policy.SecretKey)
+	if err != nil {
+		logrus.Errorf("failed to decrypt storage secret of addon %s: %v", addonID, err)
+		return
+	}
+	client, err := cloudstorage.New(policy.Endpoint, policy.AccessKey, secretKey)
+	if err != nil {
+		logrus.Errorf("failed to connect to storage of addon %s: %v", addonID, err)
+		return
+	}
+	for _, backup := range backups[policy.Retention:] {
+		if backup.Endpoint == policy.Endpoint {
+			if err := client.DeleteFile(backup.Bucket, backup.ObjectName); err != nil {
+				logrus.Errorf("failed to delete backup file %s/%s: %v", backup.Bucket, backup.ObjectName, err)
+				continue
+			}
+		}
+		if err := a.db.DeleteAddonBackup(backup.ID); err != nil {
+			logrus.Errorf("failed to delete addon backup %d: %v", backup.ID, err)
+		}
+	}
+}
+
+// getBackupRouting 查询 addon 实例并校验其是否支持备份
+func (a *Addon) getBackupRouting(addonID string) (*dbclient.AddonInstanceRouting, error) {
+	routing, err := a.db.GetInstanceRouting(addonID)
+	if err != nil {
+		return nil, err
+	}
+	if routing == nil {
+		return nil, errors.Errorf("addon %s not found", addonID)
+	}
+	if _, ok := addonBackupFileExt[routing.AddonName]; !ok {
+		return nil, errors.Errorf("backup is not supported for addon %s", routing.AddonName)
+	}
+	return routing, nil
+}
+
+// checkAddonPermission 校验用户对 addon 实例的操作权限, 有项目时按项目鉴权, 否则按企业鉴权
+func (a *Addon) checkAddonPermission(userID string, routing *dbclient.AddonInstanceRouting, action string) error {
+	req := apistructs.PermissionCheckRequest{
+		UserID:   userID,
+		Resource: "addon",
+		Action:   action,
+	}
+	if routing.ProjectID != "" {
+		req.Scope = apistructs.ProjectScope
+		req.ScopeID, _ = strconv.ParseUint(routing.ProjectID, 10, 64)
+	} else {
+		req.Scope = apistructs.OrgScope
+		req.ScopeID, _ = strconv.ParseUint(routing.OrgID, 10, 64)
+	}
+	permissionResult, err := a.bdl.CheckPermission(&req)
+	if err != nil {
+		return err
+	}
+	if !permissionResult.Access {
+		return errors.New("权限不足")
+	}
+	return nil
+}
+
+// backupDue 判断定时备份是否到期, 未触发过时以策略创建时间为起点
+func backupDue(schedule string, lastScheduledAt *time.Time, createdAt, now time.Time) (bool, error) {
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return false, err
+	}
+	base := createdAt
+	if lastScheduledAt != nil {
+		base = *lastScheduledAt
+	}
+	return !sched.Next(base).After(now), nil
+}
+
+// backupObjectName 生成备份文件对象名: <prefix>/<addonName>/<addonID>/<time>-<backupID><ext>
+func backupObjectName(prefix string, routing *dbclient.AddonInstanceRouting, backupID uint64, now time.Time) string {
+	return path.Join(prefix, routing.AddonName, routing.ID,
+		fmt.Sprintf("%s-%d%s", now.Format("20060102150405"), backupID, addonBackupFileExt[routing.AddonName]))
+}
+
+// backupJobEnv 将 addon 连接信息转为备份/恢复 job 的环境变量, 密码放到 secretEnv 中以 Secret 注入
+func backupJobEnv(action, addonName string, config map[string]interface{}) (env, secretEnv map[string]string) {
+	env = map[string]string{
+		"ADDON_BACKUP_ACTION": action,
+		"ADDON_NAME":          addonName,
+	}
+	secretEnv = make(map[string]string)
+	for k, v := range config {
+		value, ok := v.(string)
+		if !ok {
+			value = fmt.Sprintf("%v", v)
+		}
+		if _, ok := addonBackupSecretEnvKeys[k]; ok {
+			secretEnv[k] = value
+		} else {
+			env[k] = value
+		}
+	}
+	delete(env, apistructs.AddonPasswordHasEncripy)
+	return env, secretEnv
+}
+
+// transferBackupJobStatus 将 scheduler job 状态转为备份状态
+func transferBackupJobStatus(status apistructs.StatusCode, lastMessage string) (apistructs.AddonBackupStatus, string) {
+	switch status {
+	case apistructs.StatusStoppedOnOK, apistructs.StatusFinished:
+		return apistructs.AddonBackupSuccess, ""
+	case apistructs.StatusStoppedOnFailed, apistructs.StatusFailed, apistructs.StatusStoppedByKilled,
+		apistructs.StatusError, apistructs.StatusNotFoundInCluster:
+		if lastMessage == "" {
+			lastMessage = string(status)
+		}
+		return apistructs.AddonBackupFailed, lastMessage
+	default:
+		return apistructs.AddonBackupRunning, ""
+	}
+}
+
+func convertBackupPolicy(policy *dbclient.AddonBackupPolicy) *apistructs.AddonBackupPolicy {
+	return &apistructs.AddonBackupPolicy{
+		ID:        policy.ID,
+		AddonID:   policy.AddonID,
+		AddonName: policy.AddonName,
+		Schedule:  policy.Schedule,
+		Retention: policy.Retention,
+		Storage: apistructs.AddonBackupStorage{
+			Endpoint:   policy.Endpoint,
+			Bucket:     policy.Bucket,
+			AccessKey:  policy.AccessKey,
+			PathPrefix: policy.PathPrefix,
+		},
+		Enabled:         policy.Enabled,
+		LastScheduledAt: policy.LastScheduledAt,
+		Creator:         policy.Creator,
+		CreatedAt:       policy.CreatedAt,
+		UpdatedAt:       policy.UpdatedAt,
+	}
+}
+
+func convertBackup(backup *dbclient.AddonBackup) *apistructs.AddonBackup {
+	result := apistructs.AddonBackup{
+		ID:         backup.ID,
+		AddonID:    backup.AddonID,
+		InstanceID: backup.InstanceID,
+		AddonName:  backup.AddonName,
+		Type:       backup.Type,
+		Status:     backup.Status,
+		Bucket:     backup.Bucket,
+		ObjectName: backup.ObjectName,
+		Message:    backup.Message,
+		Operator:   backup.Operator,
+		StartedAt:  backup.StartedAt,
+		FinishedAt: backup.FinishedAt,
+		CreatedAt:  backup.CreatedAt,
+	}
+	if backup.Status == apistructs.AddonBackupSuccess {
+		result.URL = strings.Join([]string{backup.Endpoint, backup.Bucket, backup.ObjectName}, "/")
+	}
+	return &result
+}
+
+func convertRestore(restore *dbclient.AddonRestore) *apistructs.AddonRestore {
+	return &apistructs.AddonRestore{
+		ID:            restore.ID,
+		BackupID:      restore.BackupID,
+		SourceAddonID: restore.SourceAddonID,
+		TargetAddonID: restore.TargetAddonID,
+		Status:        restore.Status,
+		Message:       restore.Message,
+		Operator:      restore.Operator,
+		StartedAt:     restore.StartedAt,
+		FinishedAt:    restore.FinishedAt,
+		CreatedAt:     restore.CreatedAt,
+	}
+}
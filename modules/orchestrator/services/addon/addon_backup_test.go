@@ -0,0 +1,150 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package addon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/pkg/dbengine"
+)
+
+func TestBackupDue(t *testing.T) {
+	created := time.Date(2021, 3, 1, 10, 30, 0, 0, time.Local)
+
+	due, err := backupDue("0 2 * * *", nil, created, created.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, due)
+
+	due, err = backupDue("0 2 * * *", nil, created, time.Date(2021, 3, 2, 2, 0, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.True(t, due)
+
+	last := time.Date(2021, 3, 2, 2, 0, 5, 0, time.Local)
+	due, err = backupDue("0 2 * * *", &last, created, time.Date(2021, 3, 2, 23, 0, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.False(t, due)
+
+	_, err = backupDue("every day", nil, created, created)
+	assert.Error(t, err)
+}
+
+func TestBackupObjectName(t *testing.T) {
+	routing := &dbclient.AddonInstanceRouting{ID: "r1", AddonName: apistructs.AddonMySQL}
+	now := time.Date(2021, 3, 2, 2, 0, 0, 0, time.Local)
+	assert.Equal(t, "backups/mysql/r1/20210302020000-12.sql.gz", backupObjectName("backups", routing, 12, now))
+	assert.Equal(t, "mysql/r1/20210302020000-12.sql.gz", backupObjectName("", routing, 12, now))
+}
+
+func TestBackupJobEnv(t *testing.T) {
+	env, secretEnv := backupJobEnv("backup", apistructs.AddonRedis, map[string]interface{}{
+		"REDIS_HOST":                       "redis.default.svc",
+		"REDIS_PORT":                       6379,
+		apistructs.AddonRedisPasswordName:  "pass",
+		apistructs.AddonPasswordHasEncripy: "YES",
+	})
+	assert.Equal(t, map[string]string{
+		"ADDON_BACKUP_ACTION": "backup",
+		"ADDON_NAME":          apistructs.AddonRedis,
+		"REDIS_HOST":          "redis.default.svc",
+		"REDIS_PORT":          "6379",
+	}, env)
+	assert.Equal(t, map[string]string{apistructs.AddonRedisPasswordName: "pass"}, secretEnv)
+}
+
+func TestTransitAddonRestore(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.AutoMigrate(&dbclient.AddonRestore{}).Error)
+	client := &dbclient.DBClient{DBEngine: &dbengine.DBEngine{DB: db}}
+
+	restore := dbclient.AddonRestore{BackupID: 1, SourceAddonID: "a1", Status: apistructs.AddonBackupPending}
+	assert.NoError(t, client.CreateAddonRestore(&restore))
+
+	// 两个实例同时读到 Pending 的记录, 只有一个能启动恢复 job
+	now := time.Now()
+	first, second := restore, restore
+	first.Status, first.JobName, first.StartedAt = apistructs.AddonBackupRunning, "restore-1", &now
+	second.Status, second.JobName, second.StartedAt = apistructs.AddonBackupRunning, "restore-1", &now
+	transited, err := client.TransitAddonRestore(&first, apistructs.AddonBackupPending)
+	assert.NoError(t, err)
+	assert.True(t, transited)
+	transited, err = client.TransitAddonRestore(&second, apistructs.AddonBackupPending)
+	assert.NoError(t, err)
+	assert.False(t, transited)
+
+	// 已成功的记录不会被其他实例因 job 不存在而改为失败
+	first.Status, first.FinishedAt = apistructs.AddonBackupSuccess, &now
+	transited, err = client.TransitAddonRestore(&first, apistructs.AddonBackupRunning)
+	assert.NoError(t, err)
+	assert.True(t, transited)
+	second.Status, second.Message = apistructs.AddonBackupFailed, "job restore-1 not found"
+	transited, err = client.TransitAddonRestore(&second, apistructs.AddonBackupRunning)
+	assert.NoError(t, err)
+	assert.False(t, transited)
+
+	restores, err := client.FindAddonRestores("a1")
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.AddonBackupSuccess, restores[0].Status)
+	assert.Equal(t, "restore-1", restores[0].JobName)
+}
+
+func TestTransferBackupJobStatus(t *testing.T) {
+	status, _ := transferBackupJobStatus(apistructs.StatusStoppedOnOK, "")
+	assert.Equal(t, apistructs.AddonBackupSuccess, status)
+
+	status, message := transferBackupJobStatus(apistructs.StatusStoppedOnFailed, "mysqldump: access denied")
+	assert.Equal(t, apistructs.AddonBackupFailed, status)
+	assert.Equal(t, "mysqldump: access denied", message)
+
+	status, message = transferBackupJobStatus(apistructs.StatusStoppedByKilled, "")
+	assert.Equal(t, apistructs.AddonBackupFailed, status)
+	assert.Equal(t, string(apistructs.StatusStoppedByKilled), message)
+
+	status, _ = transferBackupJobStatus(apistructs.StatusRunning, "")
+	assert.Equal(t, apistructs.AddonBackupRunning, status)
+	status, _ = transferBackupJobStatus(apistructs.StatusUnschedulable, "")
+	assert.Equal(t, apistructs.AddonBackupRunning, status)
+}
+
+func TestBackupStorageCredential(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.AutoMigrate(&dbclient.AddonBackup{}, &dbclient.AddonInstance{}).Error)
+	a := &Addon{db: &dbclient.DBClient{DBEngine: &dbengine.DBEngine{DB: db}}}
+
+	// 备份记录保存备份时的存储凭证, 备份策略修改或删除后仍可恢复
+	policy := dbclient.AddonBackupPolicy{AddonID: "a1", Endpoint: "oss.example.com", Bucket: "backup",
+		AccessKey: "ak", SecretKey: "encrypted-sk"}
+	routing := dbclient.AddonInstanceRouting{ID: "a1", RealInstance: "not-exist", AddonName: apistructs.AddonMySQL}
+	backup, err := a.startBackup(&policy, &routing, apistructs.AddonBackupManual, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.AddonBackupFailed, backup.Status)
+	saved, err := a.db.GetAddonBackup(backup.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "ak", saved.AccessKey)
+	assert.Equal(t, "encrypted-sk", saved.SecretKey)
+
+	// 未记录凭证的备份在创建恢复实例前即被拒绝
+	_, _, err = a.backupStorageCredential(&dbclient.AddonBackup{Endpoint: "oss.example.com", Bucket: "backup"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,616 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package addon
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/random"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+)
+
+const (
+	// addonCredentialDefaultGracePeriod 默认旧密码宽限期
+	addonCredentialDefaultGracePeriod = time.Hour
+	// addonCredentialMaxGracePeriod 旧密码最长宽限期
+	addonCredentialMaxGracePeriod = 7 * 24 * time.Hour
+	// addonCredentialRedeployTimeout 启用新密码或发起重新部署超过该时间未结束, 视为 orchestrator 重启导致中断
+	addonCredentialRedeployTimeout = 30 * time.Minute
+	// addonCredentialDeployTimeout 重新部署的 deployment 超过该时间未全部成功(如等待审批), 视为失败
+	addonCredentialDeployTimeout = time.Hour
+	// addonMysqlRotateUser 不支持双密码的 mysql 轮换时与 mysql 用户交替使用的用户
+	addonMysqlRotateUser = "mysql_rotate"
+)
+
+// addonCredentialPasswordName 支持凭证轮换的 addon 及 config 中对应的密码字段
+var addonCredentialPasswordName = map[string]string{
+	apistructs.AddonMySQL: apistructs.AddonMysqlPasswordName,
+	apistructs.AddonRedis: apistructs.AddonRedisPasswordName,
+}
+
+// RuntimeRedeployFunc 重新部署 runtime 并返回 deployment id, 由调用方注入, 避免 addon 依赖 runtime service
+type RuntimeRedeployFunc func(operator string, orgID, runtimeID uint64) (uint64, error)
+
+// RotateCredential 轮换 addon 凭证: 生成新密码并在 addon 上启用, 保存到 addon config 后异步重新部署所有引用该 addon 的 runtime,
+// 所有 deployment 部署成功后才进入宽限期, 任一 deployment 失败或超时则轮换失败并保留旧凭证.
+// mysql 只轮换提供给 runtime 使用的用户, root 及主从复制用户不变. 8.0.14 及以上版本使用双密码, 宽限期内新旧密码同时有效;
+// 低版本创建另一个用户并切换 config 到新用户, 宽限期结束后删除旧用户.
+// redis 不支持双密码, 通过 operator 更新密码并滚动重启 redis, runtime 重新部署完成前会短暂无法连接, 没有宽限期.
+func (a *Addon) RotateCredential(userID, addonID string, req *apistructs.AddonCredentialRotateRequest,
+	redeploy RuntimeRedeployFunc) (*apistructs.AddonCredentialRotation, error) {
+	routing, err := a.db.GetInstanceRouting(addonID)
+	if err != nil {
+		return nil, err
+	}
+	if routing == nil {
+		return nil, errors.Errorf("addon %s not found", addonID)
+	}
+	if err := a.checkAddonPermission(userID, routing, apistructs.UpdateAction); err != nil {
+		return nil, err
+	}
+	passwordName, ok := addonCredentialPasswordName[routing.AddonName]
+	if !ok {
+		return nil, errors.Errorf("credential rotation is not supported for addon %s", routing.AddonName)
+	}
+	if routing.Status != string(apistructs.AddonAttached) {
+		return nil, errors.Errorf("addon %s is %s, only attached addon can rotate credential", addonID, routing.Status)
+	}
+	gracePeriod, err := credentialGracePeriod(req.GracePeriod)
+	if err != nil {
+		return nil, err
+	}
+	ins, err := a.db.GetAddonInstance(routing.RealInstance)
+	if err != nil {
+		return nil, err
+	}
+	if ins == nil {
+		return nil, errors.Errorf("addon real instance: %s not found", routing.RealInstance)
+	}
+	running, err := a.db.GetRunningAddonCredentialRotationByInstance(ins.ID)
+	if err != nil {
+		return nil, err
+	}
+	if running != nil {
+		return nil, errors.Errorf("addon %s is rotating credential, please wait for it to finish", addonID)
+	}
+	sg, err := a.bdl.InspectServiceGroup(ins.Namespace, ins.ScheduleName)
+	if err != nil {
+		return nil, err
+	}
+	if sg.Status != apistructs.StatusReady && sg.Status != apistructs.StatusHealthy {
+		return nil, errors.Errorf("addon %s is unhealthy(%s), check it first", addonID, sg.Status)
+	}
+	// 非 operator 部署的 redis 为 statefulset, scheduler 更新 service group 时会按 deployment 处理, 无法更新密码
+	if ins.AddonName == apistructs.AddonRedis && sg.Labels["USE_OPERATOR"] != apistructs.AddonRedis {
+		return nil, errors.Errorf("addon %s is not deployed by operator, credential rotation is not supported", addonID)
+	}
+
+	rotation := dbclient.AddonCredentialRotation{
+		AddonID:     routing.ID,
+		InstanceID:  ins.ID,
+		AddonName:   ins.AddonName,
+		OrgID:       routing.OrgID,
+		Status:      apistructs.AddonCredentialRotationRunning,
+		Stage:       apistructs.AddonCredentialRotationApplying,
+		GracePeriod: int(gracePeriod / time.Minute),
+		Operator:    userID,
+	}
+	if err := a.db.CreateAddonCredentialRotation(&rotation); err != nil {
+		return nil, err
+	}
+
+	password := random.String(16)
+	if err := a.applyRotatedCredential(&rotation, ins, sg, password); err != nil {
+		a.finishCredentialRotation(&rotation, apistructs.AddonCredentialRotationFailed,
+			fmt.Sprintf("failed to apply new password to addon: %v", err))
+		return nil, err
+	}
+	if err := a.saveRotatedCredential(ins, rotation.User, passwordName, password); err != nil {
+		a.finishCredentialRotation(&rotation, apistructs.AddonCredentialRotationFailed,
+			fmt.Sprintf("new password has been applied to addon but failed to save it: %v", err))
+		return nil, err
+	}
+	a.ExportLogInfo(apistructs.InfoLevel, apistructs.AddonError, ins.ID, ins.ID,
+		"addon(%s)(%s) 已启用新密码, 重新部署引用的 runtime", ins.AddonName, ins.ID)
+
+	rotation.Stage = apistructs.AddonCredentialRotationRestarting
+	if err := a.db.UpdateAddonCredentialRotation(&rotation); err != nil {
+		return nil, err
+	}
+	result := convertCredentialRotation(&rotation)
+	// 重新部署 runtime 数量不定, 异步执行, 通过轮换记录查看进度
+	go a.redeployRotationRuntimes(rotation, ins, userID, redeploy)
+	return result, nil
+}
+
+// applyRotatedCredential 在 addon 上启用新密码: mysql 修改或切换用户, redis 更新 service group 中的 requirepass
+func (a *Addon) applyRotatedCredential(rotation *dbclient.AddonCredentialRotation, ins *dbclient.AddonInstance,
+	sg *apistructs.ServiceGroup, password string) error {
+	if ins.AddonName == apistructs.AddonRedis {
+		if replaceServiceEnv(sg, "requirepass", password) == 0 {
+			return errors.Errorf("no service of addon %s has env requirepass", ins.ID)
+		}
+		return a.bdl.ServiceGroupConfigUpdate(*sg)
+	}
+	currentUser, err := mysqlCurrentUser(ins)
+	if err != nil {
+		return err
+	}
+	rotation.DualPassword = mysqlSupportsDualPassword(ins.Version)
+	rotation.User = currentUser
+	if !rotation.DualPassword {
+		rotation.User, rotation.OldUser = mysqlAlternateUser(currentUser), currentUser
+	}
+	return a.execMysqlCredentialSqls(ins, sg, mysqlRotateCredentialSqls(rotation.User, password, rotation.DualPassword))
+}
+
+// redeployRotationRuntimes 重新部署引用 addon 的 runtime 并记录 deployment, 由 CredentialRotationSync 检查部署结果
+func (a *Addon) redeployRotationRuntimes(rotation dbclient.AddonCredentialRotation, ins *dbclient.AddonInstance,
+	userID string, redeploy RuntimeRedeployFunc) {
+	runtimes, err := a.redeployAddonRuntimes(ins, userID, redeploy)
+	if err != nil {
+		a.finishCredentialRotation(&rotation, apistructs.AddonCredentialRotationFailed,
+			fmt.Sprintf("failed to list runtimes referencing addon: %v, old credential is kept valid", err))
+		return
+	}
+	runtimesJSON, _ := json.Marshal(runtimes)
+	rotation.Runtimes = string(runtimesJSON)
+	if failed := failedRedeployRuntimes(runtimes); len(failed) > 0 {
+		a.finishCredentialRotation(&rotation, apistructs.AddonCredentialRotationFailed,
+			fmt.Sprintf("failed to redeploy runtimes: %s, old credential is kept valid, redeploy them and rotate again to revoke it",
+				strings.Join(failed, ", ")))
+		return
+	}
+	if err := a.db.UpdateAddonCredentialRotation(&rotation); err != nil {
+		logrus.Errorf("failed to update addon credential rotation %d: %v", rotation.ID, err)
+	}
+}
+
+// ListCredentialRotations 查询 addon 凭证轮换记录
+func (a *Addon) ListCredentialRotations(userID, addonID string) ([]apistructs.AddonCredentialRotation, error) {
+	routing, err := a.db.GetInstanceRouting(addonID)
+	if err != nil {
+		return nil, err
+	}
+	if routing == nil {
+		return nil, errors.Errorf("addon %s not found", addonID)
+	}
+	if err := a.checkAddonPermission(userID, routing, apistructs.GetAction); err != nil {
+		return nil, err
+	}
+	rotations, err := a.db.FindAddonCredentialRotations(addonID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]apistructs.AddonCredentialRotation, 0, len(rotations))
+	for i := range rotations {
+		result = append(result, *convertCredentialRotation(&rotations[i]))
+	}
+	return result, nil
+}
+
+// CredentialRotationSync 检查重新部署结果并进入宽限期, 宽限期结束后使旧凭证失效, 并结束因 orchestrator 重启而中断的轮换, 由定时任务调用
+func (a *Addon) CredentialRotationSync() {
+	if err := a.db.FailStaleAddonCredentialRotations(time.Now().Add(-addonCredentialRedeployTimeout),
+		"rotation is interrupted, old credential is kept valid, redeploy runtimes and rotate again to revoke it"); err != nil {
+		logrus.Errorf("failed to fail stale addon credential rotations: %v", err)
+	}
+	restarting, err := a.db.FindRestartingAddonCredentialRotations()
+	if err != nil {
+		logrus.Errorf("failed to find restarting addon credential rotations: %v", err)
+	}
+	for i := range restarting {
+		a.checkRotationDeployments(&restarting[i])
+	}
+	rotations, err := a.db.FindExpiredAddonCredentialRotations(time.Now())
+	if err != nil {
+		logrus.Errorf("[alert] failed to find expired addon credential rotations: %v", err)
+		return
+	}
+	for i := range rotations {
+		a.revokeOldCredential(&rotations[i])
+	}
+}
+
+// checkRotationDeployments 检查重新部署的 deployment: 全部成功后进入宽限期(redis 直接结束),
+// 任一失败或超时未完成(如被冻结、等待审批)则轮换失败, 旧凭证保持有效
+func (a *Addon) checkRotationDeployments(rotation *dbclient.AddonCredentialRotation) {
+	var runtimes []apistructs.AddonCredentialRotationRuntime
+	if err := json.Unmarshal([]byte(rotation.Runtimes), &runtimes); err != nil {
+		logrus.Errorf("failed to unmarshal runtimes of addon credential rotation %d: %v", rotation.ID, err)
+		return
+	}
+	for i := range runtimes {
+		deployment, err := a.db.GetDeployment(runtimes[i].DeploymentID)
+		if err != nil {
+			logrus.Errorf("failed to get deployment of addon credential rotation %d: %v", rotation.ID, err)
+			return
+		}
+		runtimes[i].DeploymentStatus = deployment.Status
+	}
+	pending, failed := rotationDeploymentsProgress(runtimes)
+	now := time.Now()
+	if len(failed) == 0 && len(pending) > 0 && now.Sub(rotation.UpdatedAt) < addonCredentialDeployTimeout {
+		return
+	}
+
+	runtimesJSON, _ := json.Marshal(runtimes)
+	updates := map[string]interface{}{"runtimes": string(runtimesJSON)}
+	switch {
+	case len(failed) > 0:
+		updates["status"] = apistructs.AddonCredentialRotationFailed
+		updates["finished_at"] = now
+		updates["message"] = fmt.Sprintf("failed to redeploy runtimes: %s, old credential is kept valid, redeploy them and rotate again to revoke it",
+			strings.Join(failed, ", "))
+	case len(pending) > 0:
+		updates["status"] = apistructs.AddonCredentialRotationFailed
+		updates["finished_at"] = now
+		updates["message"] = fmt.Sprintf("redeploying runtimes timed out after %s: %s, old credential is kept valid, redeploy them and rotate again to revoke it",
+			addonCredentialDeployTimeout, strings.Join(pending, ", "))
+	case rotation.AddonName == apistructs.AddonRedis:
+		updates["status"] = apistructs.AddonCredentialRotationSuccess
+		updates["stage"] = apistructs.AddonCredentialRotationDone
+		updates["finished_at"] = now
+	default:
+		updates["stage"] = apistructs.AddonCredentialRotationGrace
+		updates["grace_until"] = now.Add(time.Duration(rotation.GracePeriod) * time.Minute)
+	}
+	ok, err := a.db.TransitAddonCredentialRotation(rotation.ID, apistructs.AddonCredentialRotationRestarting, updates)
+	if err != nil {
+		logrus.Errorf("failed to update addon credential rotation %d: %v", rotation.ID, err)
+		return
+	}
+	if ok && len(failed) == 0 && len(pending) == 0 {
+		a.ExportLogInfo(apistructs.InfoLevel, apistructs.AddonError, rotation.InstanceID, rotation.InstanceID,
+			"addon(%s)(%s) 引用的 runtime 已全部重新部署", rotation.AddonName, rotation.InstanceID)
+	}
+}
+
+func (a *Addon) revokeOldCredential(rotation *dbclient.AddonCredentialRotation) {
+	ins, err := a.db.GetAddonInstance(rotation.InstanceID)
+	if err != nil {
+		logrus.Errorf("failed to get addon instance %s: %v", rotation.InstanceID, err)
+		return
+	}
+	if ins == nil || ins.Deleted == apistructs.AddonDeleted {
+		a.finishCredentialRotation(rotation, apistructs.AddonCredentialRotationFailed, "addon instance has been deleted")
+		return
+	}
+	sg, err := a.bdl.InspectServiceGroup(ins.Namespace, ins.ScheduleName)
+	if err != nil {
+		logrus.Errorf("failed to inspect addon %s service group: %v", ins.ID, err)
+		return
+	}
+	if err := a.execMysqlCredentialSqls(ins, sg, mysqlRevokeCredentialSqls(rotation)); err != nil {
+		logrus.Errorf("failed to revoke old password of addon %s: %v", ins.ID, err)
+		return
+	}
+	a.finishCredentialRotation(rotation, apistructs.AddonCredentialRotationSuccess, "")
+	a.ExportLogInfo(apistructs.SuccessLevel, apistructs.AddonError, ins.ID, ins.ID,
+		"addon(%s)(%s) 旧凭证已失效, 凭证轮换完成", ins.AddonName, ins.ID)
+}
+
+func (a *Addon) finishCredentialRotation(rotation *dbclient.AddonCredentialRotation,
+	status apistructs.AddonCredentialRotationStatus, message string) {
+	now := time.Now()
+	rotation.Status = status
+	rotation.Message = message
+	rotation.FinishedAt = &now
+	if status == apistructs.AddonCredentialRotationSuccess {
+		rotation.Stage = apistructs.AddonCredentialRotationDone
+	}
+	if err := a.db.UpdateAddonCredentialRotation(rotation); err != nil {
+		logrus.Errorf("failed to update addon credential rotation %d: %v", rotation.ID, err)
+	}
+}
+
+// execMysqlCredentialSqls 以 root 用户在 mysql 主节点上执行 sql, 用户变更通过主从复制同步到从节点
+func (a *Addon) execMysqlCredentialSqls(ins *dbclient.AddonInstance, sg *apistructs.ServiceGroup, sqls []string) error {
+	rootPassword, err := a.db.GetByInstanceIDAndField(ins.ID, apistructs.AddonMysqlPasswordKey)
+	if err != nil {
+		return err
+	}
+	var kmsKey *string
+	if ins.KmsKey != "" {
+		kmsKey = &ins.KmsKey
+	}
+	decPwd, err := a.DecryptPassword(kmsKey, rootPassword.Value)
+	if err != nil {
+		return err
+	}
+	masterName := ""
+	if master, err := a.db.GetByInstanceIDAndField(ins.ID, apistructs.AddonMysqlMasterKey); err == nil {
+		masterName = master.Value
+	}
+	host, err := mysqlMasterHost(sg, masterName)
+	if err != nil {
+		return err
+	}
+	clusterInfo, err := a.bdl.QueryClusterInfo(ins.Cluster)
+	if err != nil {
+		return err
+	}
+	return a.bdl.MySQLExec(&apistructs.MysqlExec{
+		User:     apistructs.MySQLDefaultUser,
+		Password: decPwd,
+		URL:      apistructs.AddonMysqlJdbcPrefix + host + ":" + apistructs.AddonMysqlDefaultPort,
+		Sqls:     sqls,
+	}, formatSoldierUrl(&clusterInfo))
+}
+
+// saveRotatedCredential 加密新密码并通过 AddonConfigCallback 更新 addon config 中的用户及密码, 加密方式与 addon 创建时一致
+func (a *Addon) saveRotatedCredential(ins *dbclient.AddonInstance, user, passwordName, password string) error {
+	config := map[string]interface{}{}
+	if ins.Config != "" {
+		if err := json.Unmarshal([]byte(ins.Config), &config); err != nil {
+			return err
+		}
+	}
+	value := password
+	switch {
+	case ins.KmsKey != "":
+		encryptData, err := a.bdl.KMSEncrypt(apistructs.KMSEncryptRequest{
+			EncryptRequest: kmstypes.EncryptRequest{
+				KeyID:           ins.KmsKey,
+				PlaintextBase64: base64.StdEncoding.EncodeToString([]byte(password)),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		value = encryptData.CiphertextBase64
+	case config[apistructs.AddonPasswordHasEncripy] != nil:
+		encPwd, err := a.encrypt.EncryptPassword(password)
+		if err != nil {
+			return err
+		}
+		value = encPwd
+	}
+	config[passwordName] = value
+	if user != "" {
+		config[apistructs.AddonMysqlUserName] = user
+	}
+
+	// redis 部署状态及备份均从 extra 中读取密码, 需同步更新
+	if ins.AddonName == apistructs.AddonRedis {
+		extra, err := a.db.GetByInstanceIDAndField(ins.ID, apistructs.AddonRedisPasswordKey)
+		if err != nil {
+			return err
+		}
+		extra.Value = value
+		if err := a.db.UpdateAddonInstanceExtra(extra); err != nil {
+			return err
+		}
+	}
+
+	response := apistructs.AddonConfigCallBackResponse{}
+	for k, v := range config {
+		response.Config = append(response.Config, apistructs.AddonConfigCallBackItemResponse{Name: k, Value: v})
+	}
+	return a.AddonConfigCallback(ins.ID, &response)
+}
+
+// redeployAddonRuntimes 重新部署引用 addon 真实实例的所有 runtime, 使其加载新密码
+func (a *Addon) redeployAddonRuntimes(ins *dbclient.AddonInstance, userID string,
+	redeploy RuntimeRedeployFunc) ([]apistructs.AddonCredentialRotationRuntime, error) {
+	routings, err := a.db.GetInstanceRoutingByRealInstance(ins.ID)
+	if err != nil {
+		return nil, err
+	}
+	runtimes := []apistructs.AddonCredentialRotationRuntime{}
+	redeployed := map[uint64]struct{}{}
+	for _, routing := range *routings {
+		orgID, _ := strconv.ParseUint(routing.OrgID, 10, 64)
+		references, err := a.ListReferencesByRoutingInstanceID(orgID, userID, routing.ID, true)
+		if err != nil {
+			return nil, err
+		}
+		if references == nil {
+			continue
+		}
+		for _, ref := range *references {
+			if _, ok := redeployed[ref.RuntimeID]; ok || ref.RuntimeID == 0 {
+				continue
+			}
+			redeployed[ref.RuntimeID] = struct{}{}
+			item := apistructs.AddonCredentialRotationRuntime{
+				RuntimeID:     ref.RuntimeID,
+				RuntimeName:   ref.RuntimeName,
+				ApplicationID: ref.AppID,
+				AppName:       ref.AppName,
+			}
+			deploymentID, err := redeploy(userID, ref.OrgID, ref.RuntimeID)
+			if err != nil {
+				item.Error = err.Error()
+			} else {
+				item.DeploymentID = deploymentID
+			}
+			runtimes = append(runtimes, item)
+		}
+	}
+	return runtimes, nil
+}
+
+// credentialGracePeriod 校验并返回旧密码宽限期, 未指定时使用默认值
+func credentialGracePeriod(minutes int) (time.Duration, error) {
+	if minutes < 0 {
+		return 0, errors.New("gracePeriod can not be negative")
+	}
+	if minutes == 0 {
+		return addonCredentialDefaultGracePeriod, nil
+	}
+	gracePeriod := time.Duration(minutes) * time.Minute
+	if gracePeriod > addonCredentialMaxGracePeriod {
+		return 0, errors.Errorf("gracePeriod can not be longer than %s", addonCredentialMaxGracePeriod)
+	}
+	return gracePeriod, nil
+}
+
+// mysqlSupportsDualPassword mysql 8.0.14 起支持 RETAIN CURRENT PASSWORD
+func mysqlSupportsDualPassword(version string) bool {
+	current := [3]int{}
+	for i, part := range strings.SplitN(version, ".", 3) {
+		n, err := strconv.Atoi(strings.TrimFunc(part, func(r rune) bool { return r < '0' || r > '9' }))
+		if err != nil {
+			return false
+		}
+		current[i] = n
+	}
+	required := [3]int{8, 0, 14}
+	for i := range current {
+		if current[i] != required[i] {
+			return current[i] > required[i]
+		}
+	}
+	return true
+}
+
+// mysqlCurrentUser 返回 addon config 中提供给 runtime 使用的 mysql 用户, 未记录时为创建时的 mysql 用户
+func mysqlCurrentUser(ins *dbclient.AddonInstance) (string, error) {
+	config := map[string]interface{}{}
+	if ins.Config != "" {
+		if err := json.Unmarshal([]byte(ins.Config), &config); err != nil {
+			return "", err
+		}
+	}
+	if user, ok := config[apistructs.AddonMysqlUserName].(string); ok && user != "" {
+		return user, nil
+	}
+	return apistructs.AddonMysqlUser, nil
+}
+
+// mysqlAlternateUser 不支持双密码时, 在 mysql 与 mysql_rotate 两个用户间交替
+func mysqlAlternateUser(current string) string {
+	if current == addonMysqlRotateUser {
+		return apistructs.AddonMysqlUser
+	}
+	return addonMysqlRotateUser
+}
+
+// mysqlRotateCredentialSqls 支持双密码时修改用户密码并保留旧密码; 否则重建另一个用户并授予与 mysql 用户相同的权限,
+// 该用户可能是上次轮换删除失败残留的, 不会被 runtime 使用
+func mysqlRotateCredentialSqls(user, password string, dualPassword bool) []string {
+	password = strings.Replace(password, "'", "''", -1)
+	if dualPassword {
+		return []string{
+			fmt.Sprintf("ALTER USER '%s'@'%%' IDENTIFIED BY '%s' RETAIN CURRENT PASSWORD;", user, password),
+			apistructs.AddonMysqlFlushSqls,
+		}
+	}
+	return []string{
+		fmt.Sprintf("DROP USER IF EXISTS '%s'@'%%';", user),
+		fmt.Sprintf("CREATE USER '%s'@'%%' IDENTIFIED BY '%s';", user, password),
+		fmt.Sprintf("GRANT ALL PRIVILEGES ON *.* TO '%s'@'%%' WITH GRANT OPTION;", user),
+		apistructs.AddonMysqlFlushSqls,
+	}
+}
+
+// mysqlRevokeCredentialSqls 宽限期结束后使旧密码失效, 或删除已切换掉的旧用户
+func mysqlRevokeCredentialSqls(rotation *dbclient.AddonCredentialRotation) []string {
+	if rotation.OldUser != "" {
+		return []string{fmt.Sprintf("DROP USER IF EXISTS '%s'@'%%';", rotation.OldUser), apistructs.AddonMysqlFlushSqls}
+	}
+	return []string{fmt.Sprintf("ALTER USER '%s'@'%%' DISCARD OLD PASSWORD;", rotation.User), apistructs.AddonMysqlFlushSqls}
+}
+
+// mysqlMasterHost 返回 mysql 主节点地址, 未记录主节点时使用第一个服务
+func mysqlMasterHost(sg *apistructs.ServiceGroup, masterName string) (string, error) {
+	for _, svc := range sg.Services {
+		if masterName != "" && svc.Name != masterName {
+			continue
+		}
+		if len(svc.InstanceInfos) > 0 && svc.InstanceInfos[0].Ip != "" {
+			return svc.InstanceInfos[0].Ip, nil
+		}
+		if svc.Vip != "" {
+			return svc.Vip, nil
+		}
+	}
+	return "", errors.Errorf("mysql master %q not found in service group", masterName)
+}
+
+// replaceServiceEnv 替换 service group 中所有已设置 key 的服务环境变量, 返回替换的服务数
+func replaceServiceEnv(sg *apistructs.ServiceGroup, key, value string) int {
+	replaced := 0
+	for i := range sg.Services {
+		if _, ok := sg.Services[i].Env[key]; ok {
+			sg.Services[i].Env[key] = value
+			replaced++
+		}
+	}
+	return replaced
+}
+
+// rotationDeploymentsProgress 返回仍在部署中(包括等待审批)及部署失败、被取消的 runtime
+func rotationDeploymentsProgress(runtimes []apistructs.AddonCredentialRotationRuntime) (pending, failed []string) {
+	for _, r := range runtimes {
+		name := fmt.Sprintf("%s(%d)", r.RuntimeName, r.RuntimeID)
+		switch r.DeploymentStatus {
+		case apistructs.DeploymentStatusOK:
+		case apistructs.DeploymentStatusFailed, apistructs.DeploymentStatusCanceling, apistructs.DeploymentStatusCanceled:
+			failed = append(failed, fmt.Sprintf("%s deployment %d is %s", name, r.DeploymentID, r.DeploymentStatus))
+		default:
+			pending = append(pending, fmt.Sprintf("%s deployment %d is %s", name, r.DeploymentID, r.DeploymentStatus))
+		}
+	}
+	return pending, failed
+}
+
+func failedRedeployRuntimes(runtimes []apistructs.AddonCredentialRotationRuntime) []string {
+	var failed []string
+	for _, r := range runtimes {
+		if r.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s(%d)", r.RuntimeName, r.RuntimeID))
+		}
+	}
+	return failed
+}
+
+func convertCredentialRotation(rotation *dbclient.AddonCredentialRotation) *apistructs.AddonCredentialRotation {
+	result := apistructs.AddonCredentialRotation{
+		ID:           rotation.ID,
+		AddonID:      rotation.AddonID,
+		InstanceID:   rotation.InstanceID,
+		AddonName:    rotation.AddonName,
+		Status:       rotation.Status,
+		Stage:        rotation.Stage,
+		DualPassword: rotation.DualPassword,
+		User:         rotation.User,
+		OldUser:      rotation.OldUser,
+		GracePeriod:  rotation.GracePeriod,
+		GraceUntil:   rotation.GraceUntil,
+		Runtimes:     []apistructs.AddonCredentialRotationRuntime{},
+		Message:      rotation.Message,
+		Operator:     rotation.Operator,
+		FinishedAt:   rotation.FinishedAt,
+		CreatedAt:    rotation.CreatedAt,
+		UpdatedAt:    rotation.UpdatedAt,
+	}
+	if rotation.Runtimes != "" {
+		if err := json.Unmarshal([]byte(rotation.Runtimes), &result.Runtimes); err != nil {
+			logrus.Errorf("failed to unmarshal runtimes of addon credential rotation %d: %v", rotation.ID, err)
+		}
+	}
+	return &result
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package addon
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/bundle"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/pkg/dbengine"
+)
+
+func TestMysqlSupportsDualPassword(t *testing.T) {
+	assert.False(t, mysqlSupportsDualPassword("5.7.29"))
+	assert.False(t, mysqlSupportsDualPassword("8.0.13"))
+	assert.True(t, mysqlSupportsDualPassword("8.0.14"))
+	assert.True(t, mysqlSupportsDualPassword("8.0.22-debian"))
+	assert.False(t, mysqlSupportsDualPassword(""))
+}
+
+func TestMysqlCredentialSqls(t *testing.T) {
+	assert.Equal(t, []string{"ALTER USER 'mysql'@'%' IDENTIFIED BY 'abc' RETAIN CURRENT PASSWORD;", apistructs.AddonMysqlFlushSqls},
+		mysqlRotateCredentialSqls("mysql", "abc", true))
+	assert.Equal(t, []string{
+		"DROP USER IF EXISTS 'mysql_rotate'@'%';",
+		"CREATE USER 'mysql_rotate'@'%' IDENTIFIED BY 'a''b';",
+		"GRANT ALL PRIVILEGES ON *.* TO 'mysql_rotate'@'%' WITH GRANT OPTION;",
+		apistructs.AddonMysqlFlushSqls,
+	}, mysqlRotateCredentialSqls("mysql_rotate", "a'b", false))
+	assert.Equal(t, "ALTER USER 'mysql'@'%' DISCARD OLD PASSWORD;",
+		mysqlRevokeCredentialSqls(&dbclient.AddonCredentialRotation{User: "mysql", DualPassword: true})[0])
+	assert.Equal(t, "DROP USER IF EXISTS 'mysql'@'%';",
+		mysqlRevokeCredentialSqls(&dbclient.AddonCredentialRotation{User: "mysql_rotate", OldUser: "mysql"})[0])
+}
+
+func TestMysqlRotateUser(t *testing.T) {
+	user, err := mysqlCurrentUser(&dbclient.AddonInstance{Config: `{"MYSQL_PASSWORD":"x"}`})
+	assert.Nil(t, err)
+	assert.Equal(t, "mysql", user)
+	user, err = mysqlCurrentUser(&dbclient.AddonInstance{Config: `{"MYSQL_USERNAME":"mysql_rotate"}`})
+	assert.Nil(t, err)
+	assert.Equal(t, "mysql_rotate", user)
+	assert.Equal(t, "mysql_rotate", mysqlAlternateUser("mysql"))
+	assert.Equal(t, "mysql", mysqlAlternateUser("mysql_rotate"))
+}
+
+func TestFailStaleAddonCredentialRotations(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Nil(t, db.AutoMigrate(&dbclient.AddonCredentialRotation{}).Error)
+	client := &dbclient.DBClient{DBEngine: &dbengine.DBEngine{DB: db}}
+
+	old := time.Now().Add(-time.Hour)
+	for _, rotation := range []dbclient.AddonCredentialRotation{
+		{InstanceID: "stale", Stage: apistructs.AddonCredentialRotationRestarting},
+		{InstanceID: "stale", Stage: apistructs.AddonCredentialRotationGrace},
+		{InstanceID: "deploying", Stage: apistructs.AddonCredentialRotationRestarting, Runtimes: "[]"},
+	} {
+		rotation.Status = apistructs.AddonCredentialRotationRunning
+		assert.Nil(t, client.CreateAddonCredentialRotation(&rotation))
+		assert.Nil(t, db.Model(&rotation).UpdateColumn("updated_at", old).Error)
+	}
+	fresh := dbclient.AddonCredentialRotation{InstanceID: "fresh", Status: apistructs.AddonCredentialRotationRunning,
+		Stage: apistructs.AddonCredentialRotationRestarting}
+	assert.Nil(t, client.CreateAddonCredentialRotation(&fresh))
+
+	assert.Nil(t, client.FailStaleAddonCredentialRotations(time.Now().Add(-addonCredentialRedeployTimeout), "interrupted"))
+	var rotations []dbclient.AddonCredentialRotation
+	assert.Nil(t, db.Order("id").Find(&rotations).Error)
+	assert.Equal(t, apistructs.AddonCredentialRotationFailed, rotations[0].Status)
+	assert.Equal(t, "interrupted", rotations[0].Message)
+	// 宽限期内的轮换由宽限期结束后处理, 已记录 deployment 的轮换等待部署结果
+	assert.Equal(t, apistructs.AddonCredentialRotationRunning, rotations[1].Status)
+	assert.Equal(t, apistructs.AddonCredentialRotationRunning, rotations[2].Status)
+	assert.Equal(t, apistructs.AddonCredentialRotationRunning, rotations[3].Status)
+}
+
+func TestCheckRotationDeployments(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Nil(t, db.AutoMigrate(&dbclient.AddonCredentialRotation{}, &dbclient.Deployment{}).Error)
+	client := &dbclient.DBClient{DBEngine: &dbengine.DBEngine{DB: db}}
+	a := &Addon{db: client, bdl: bundle.New()}
+
+	deployments := map[apistructs.DeploymentStatus]uint64{}
+	for _, status := range []apistructs.DeploymentStatus{apistructs.DeploymentStatusOK,
+		apistructs.DeploymentStatusFailed, apistructs.DeploymentStatusWaitApprove} {
+		deployment := dbclient.Deployment{RuntimeId: 1, Status: status}
+		assert.Nil(t, db.Create(&deployment).Error)
+		deployments[status] = deployment.ID
+	}
+	newRotation := func(addonName string, statuses ...apistructs.DeploymentStatus) *dbclient.AddonCredentialRotation {
+		var runtimes []apistructs.AddonCredentialRotationRuntime
+		for i, status := range statuses {
+			runtimes = append(runtimes, apistructs.AddonCredentialRotationRuntime{RuntimeID: uint64(i + 1),
+				RuntimeName: "feature", DeploymentID: deployments[status]})
+		}
+		runtimesJSON, _ := json.Marshal(runtimes)
+		rotation := dbclient.AddonCredentialRotation{AddonName: addonName, Status: apistructs.AddonCredentialRotationRunning,
+			Stage: apistructs.AddonCredentialRotationRestarting, GracePeriod: 30, Runtimes: string(runtimesJSON)}
+		assert.Nil(t, client.CreateAddonCredentialRotation(&rotation))
+		return &rotation
+	}
+	reload := func(rotation *dbclient.AddonCredentialRotation) *dbclient.AddonCredentialRotation {
+		var result dbclient.AddonCredentialRotation
+		assert.Nil(t, db.Where("id = ?", rotation.ID).Take(&result).Error)
+		return &result
+	}
+
+	// 全部部署成功后进入宽限期
+	ok := newRotation(apistructs.AddonMySQL, apistructs.DeploymentStatusOK)
+	a.checkRotationDeployments(ok)
+	result := reload(ok)
+	assert.Equal(t, apistructs.AddonCredentialRotationRunning, result.Status)
+	assert.Equal(t, apistructs.AddonCredentialRotationGrace, result.Stage)
+	assert.NotNil(t, result.GraceUntil)
+	assert.True(t, result.GraceUntil.After(time.Now().Add(29*time.Minute)))
+
+	// redis 没有宽限期, 部署成功后直接结束
+	redis := newRotation(apistructs.AddonRedis, apistructs.DeploymentStatusOK)
+	a.checkRotationDeployments(redis)
+	result = reload(redis)
+	assert.Equal(t, apistructs.AddonCredentialRotationSuccess, result.Status)
+	assert.Equal(t, apistructs.AddonCredentialRotationDone, result.Stage)
+
+	// 任一部署失败则轮换失败, 保留旧凭证
+	failed := newRotation(apistructs.AddonMySQL, apistructs.DeploymentStatusOK, apistructs.DeploymentStatusFailed)
+	a.checkRotationDeployments(failed)
+	result = reload(failed)
+	assert.Equal(t, apistructs.AddonCredentialRotationFailed, result.Status)
+	assert.Equal(t, apistructs.AddonCredentialRotationRestarting, result.Stage)
+	assert.Nil(t, result.GraceUntil)
+	assert.Contains(t, result.Message, "old credential is kept valid")
+
+	// 等待审批的部署在超时前继续等待, 超时后轮换失败
+	pending := newRotation(apistructs.AddonMySQL, apistructs.DeploymentStatusOK, apistructs.DeploymentStatusWaitApprove)
+	a.checkRotationDeployments(pending)
+	result = reload(pending)
+	assert.Equal(t, apistructs.AddonCredentialRotationRunning, result.Status)
+	assert.Equal(t, apistructs.AddonCredentialRotationRestarting, result.Stage)
+	result.UpdatedAt = time.Now().Add(-addonCredentialDeployTimeout)
+	a.checkRotationDeployments(result)
+	result = reload(pending)
+	assert.Equal(t, apistructs.AddonCredentialRotationFailed, result.Status)
+	assert.Contains(t, result.Message, "timed out")
+
+	// 已被其他实例处理的轮换不再更新
+	a.checkRotationDeployments(ok)
+	assert.Equal(t, apistructs.AddonCredentialRotationGrace, reload(ok).Stage)
+}
+
+func TestCredentialGracePeriod(t *testing.T) {
+	d, err := credentialGracePeriod(0)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Hour, d)
+	d, err = credentialGracePeriod(30)
+	assert.Nil(t, err)
+	assert.Equal(t, 30*time.Minute, d)
+	_, err = credentialGracePeriod(-1)
+	assert.NotNil(t, err)
+	_, err = credentialGracePeriod(8 * 24 * 60)
+	assert.NotNil(t, err)
+}
+
+func TestMysqlMasterHost(t *testing.T) {
+	sg := &apistructs.ServiceGroup{Dice: apistructs.Dice{Services: []apistructs.Service{
+		{Name: "mysql-slave", Vip: "slave.svc"},
+		{Name: "mysql", Vip: "master.svc", InstanceInfos: []apistructs.InstanceInfo{{Ip: "10.0.0.1"}}},
+	}}}
+	host, err := mysqlMasterHost(sg, "mysql")
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.1", host)
+	host, err = mysqlMasterHost(sg, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "slave.svc", host)
+	_, err = mysqlMasterHost(sg, "unknown")
+	assert.NotNil(t, err)
+}
@@ -82,6 +82,18 @@ func (a *Addon) GetAddonResourceStatus(addonIns *dbclient.AddonInstance,
 				configMap, err = a.ZookeeperDeployStatus(addonIns, serviceGroup)
 			case apistructs.AddonConsul:
 				configMap, err = a.ConsulDeployStatus(addonIns, serviceGroup)
+			case apistructs.AddonPostgresql:
+				if serviceGroup.Labels["USE_OPERATOR"] != "" {
+					configMap, err = a.PostgresqlDeployStatus(addonIns, serviceGroup)
+				} else {
+					configMap, err = a.CommonDeployStatus(addonIns, serviceGroup, addonDice, addonSpec)
+				}
+			case apistructs.AddonMongodb:
+				if serviceGroup.Labels["USE_OPERATOR"] != "" {
+					configMap, err = a.MongodbDeployStatus(addonIns, serviceGroup)
+				} else {
+					configMap, err = a.CommonDeployStatus(addonIns, serviceGroup, addonDice, addonSpec)
+				}
 			default:
 				// 非基础addon，走通用的处理逻辑
 				configMap, err = a.CommonDeployStatus(addonIns, serviceGroup, addonDice, addonSpec)
@@ -485,6 +497,18 @@ func (a *Addon) buildAddonRequestGroup(params *apistructs.AddonHandlerCreateItem
 			addonDeployGroup.GroupLabels["ADDON_GROUPS"] = "2"
 		}
 		buildErr = a.BuildRabbitmqServiceItem(params, addonIns, addonSpec, addonDice)
+	case apistructs.AddonPostgresql:
+		if capacity.Data.PostgresqlOperator {
+			buildErr = a.BuildPostgresqlOperatorServiceItem(params, addonIns, addonSpec, addonDice)
+		} else {
+			buildErr = a.BuildCommonServiceItem(params, addonIns, addonSpec, addonDice, &clusterInfo)
+		}
+	case apistructs.AddonMongodb:
+		if capacity.Data.MongodbOperator {
+			buildErr = a.BuildMongodbOperatorServiceItem(params, addonIns, addonSpec, addonDice)
+		} else {
+			buildErr = a.BuildCommonServiceItem(params, addonIns, addonSpec, addonDice, &clusterInfo)
+		}
 	default: //default case
 		buildErr = a.BuildCommonServiceItem(params, addonIns, addonSpec, addonDice, &clusterInfo)
 	}
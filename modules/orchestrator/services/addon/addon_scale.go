@@ -0,0 +1,424 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package addon
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+)
+
+const (
+	// addonScaleGracePeriod 变更下发后等待调度器开始处理的时间, 期间不判断 addon 是否健康
+	addonScaleGracePeriod = time.Minute
+	// addonScaleTimeout 等待变更后 addon 恢复健康的最长时间, 超时视为失败
+	addonScaleTimeout = 30 * time.Minute
+)
+
+// addonScaleUnsupportedOperators scheduler 中尚未实现 Update 的 operator, 以及不以 statefulset 部署、无法确认变更生效的 operator
+var addonScaleUnsupportedOperators = map[string]struct{}{
+	"redis":     {},
+	"daemonset": {},
+}
+
+// Scale 变更 addon 规格或扩缩容, 变更异步执行, 通过 GetScaleRecords 或 addon 详情中的 scaling 查看进度
+func (a *Addon) Scale(userID, addonID string, req *apistructs.AddonPlanScaleRequest) (*apistructs.AddonScaleRecord, error) {
+	routing, err := a.db.GetInstanceRouting(addonID)
+	if err != nil {
+		return nil, err
+	}
+	if routing == nil {
+		return nil, errors.Errorf("addon %s not found", addonID)
+	}
+	if err := a.checkAddonPermission(userID, routing, apistructs.UpdateAction); err != nil {
+		return nil, err
+	}
+	if routing.Category == apistructs.AddonCustomCategory || routing.PlatformServiceType != 0 {
+		return nil, errors.Errorf("addon %s does not support scale", routing.AddonName)
+	}
+	if routing.Status != string(apistructs.AddonAttached) {
+		return nil, errors.Errorf("addon %s is %s, only attached addon can be scaled", addonID, routing.Status)
+	}
+	ins, err := a.db.GetAddonInstance(routing.RealInstance)
+	if err != nil {
+		return nil, err
+	}
+	if ins == nil {
+		return nil, errors.Errorf("addon real instance: %s not found", routing.RealInstance)
+	}
+	running, err := a.db.GetRunningAddonScaleRecordByInstance(ins.ID)
+	if err != nil {
+		return nil, err
+	}
+	if running != nil {
+		return nil, errors.Errorf("addon %s is scaling, please wait for it to finish", addonID)
+	}
+
+	sg, err := a.bdl.InspectServiceGroup(ins.Namespace, ins.ScheduleName)
+	if err != nil {
+		return nil, err
+	}
+	if sg.Status != apistructs.StatusReady && sg.Status != apistructs.StatusHealthy {
+		return nil, errors.Errorf("addon %s is unhealthy(%s), check it first", addonID, sg.Status)
+	}
+	// 非 operator 部署的 addon 为 statefulset, scheduler 更新 service group 时会按 deployment 处理, 暂不支持变更
+	operator := sg.Labels["USE_OPERATOR"]
+	if operator == "" {
+		return nil, errors.Errorf("addon %s is not deployed by operator, scale is not supported", addonID)
+	}
+	if _, ok := addonScaleUnsupportedOperators[operator]; ok {
+		return nil, errors.Errorf("addon %s deployed by %s operator does not support scale", addonID, operator)
+	}
+
+	from := currentScaleSpec(ins.Plan, sg)
+	var planItem *apistructs.AddonPlanItem
+	if req.Plan != "" && normalizePlan(req.Plan) != from.Plan {
+		planItem, err = a.getScalePlanItem(ins, normalizePlan(req.Plan))
+		if err != nil {
+			return nil, err
+		}
+	}
+	to, err := computeScaleTarget(from, req.AddonScaleSpec, planItem)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyScaleSpec(sg, from, to); err != nil {
+		return nil, err
+	}
+
+	record := dbclient.AddonScaleRecord{
+		AddonID:      routing.ID,
+		InstanceID:   ins.ID,
+		AddonName:    ins.AddonName,
+		FromPlan:     from.Plan,
+		FromCPU:      from.CPU,
+		FromMem:      from.Mem,
+		FromReplicas: from.Replicas,
+		ToPlan:       to.Plan,
+		ToCPU:        to.CPU,
+		ToMem:        to.Mem,
+		ToReplicas:   to.Replicas,
+		Status:       apistructs.AddonScaleRunning,
+		Operator:     userID,
+	}
+	if err := a.db.CreateAddonScaleRecord(&record); err != nil {
+		return nil, err
+	}
+	if err := a.bdl.ServiceGroupConfigUpdate(*sg); err != nil {
+		a.finishScale(&record, apistructs.AddonScaleFailed, fmt.Sprintf("failed to update service group: %v", err))
+		return nil, err
+	}
+	a.ExportLogInfo(apistructs.InfoLevel, apistructs.AddonError, ins.ID, ins.ID,
+		"addon(%s)(%s) 规格变更中: %s", ins.AddonName, ins.ID, scaleSpecDesc(from, to))
+	return convertScaleRecord(&record), nil
+}
+
+// GetScaleRecords 查询 addon 规格变更记录
+func (a *Addon) GetScaleRecords(userID, addonID string) ([]apistructs.AddonScaleRecord, error) {
+	routing, err := a.db.GetInstanceRouting(addonID)
+	if err != nil {
+		return nil, err
+	}
+	if routing == nil {
+		return nil, errors.Errorf("addon %s not found", addonID)
+	}
+	if err := a.checkAddonPermission(userID, routing, apistructs.GetAction); err != nil {
+		return nil, err
+	}
+	records, err := a.db.FindAddonScaleRecords(addonID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]apistructs.AddonScaleRecord, 0, len(records))
+	for i := range records {
+		result = append(result, *convertScaleRecord(&records[i]))
+	}
+	return result, nil
+}
+
+// ScaleSync 同步进行中的规格变更状态, 由定时任务调用
+func (a *Addon) ScaleSync() {
+	records, err := a.db.FindRunningAddonScaleRecords()
+	if err != nil {
+		logrus.Errorf("[alert] failed to find running addon scale records: %v", err)
+		return
+	}
+	now := time.Now()
+	for i := range records {
+		a.syncScale(&records[i], now)
+	}
+}
+
+func (a *Addon) syncScale(record *dbclient.AddonScaleRecord, now time.Time) {
+	if now.Sub(record.CreatedAt) < addonScaleGracePeriod {
+		return
+	}
+	ins, err := a.db.GetAddonInstance(record.InstanceID)
+	if err != nil {
+		logrus.Errorf("failed to get addon instance %s: %v", record.InstanceID, err)
+		return
+	}
+	if ins == nil || ins.Deleted == apistructs.AddonDeleted {
+		a.finishScale(record, apistructs.AddonScaleFailed, "addon instance has been deleted")
+		return
+	}
+	sg, err := a.bdl.InspectServiceGroup(ins.Namespace, ins.ScheduleName)
+	if err != nil {
+		logrus.Errorf("failed to inspect addon %s service group: %v", ins.ID, err)
+		return
+	}
+	healthy := sg.Status == apistructs.StatusReady || sg.Status == apistructs.StatusHealthy
+	switch {
+	case healthy && scaleObserved(sg, record):
+		if err := a.db.UpdateAddonInstancePlan(ins.ID, record.ToPlan); err != nil {
+			logrus.Errorf("failed to update addon %s plan: %v", ins.ID, err)
+			return
+		}
+		if err := a.resetAddonNodes(ins, sg); err != nil {
+			logrus.Errorf("failed to reset addon %s nodes: %v", ins.ID, err)
+		}
+		a.finishScale(record, apistructs.AddonScaleSuccess, "")
+		a.ExportLogInfo(apistructs.SuccessLevel, apistructs.AddonError, ins.ID, ins.ID,
+			"addon(%s)(%s) 规格变更成功", ins.AddonName, ins.ID)
+		return
+	case sg.Status == apistructs.StatusFailed:
+		a.finishScale(record, apistructs.AddonScaleFailed, fmt.Sprintf("addon is %s after scale", sg.Status))
+	default:
+		if now.Sub(record.CreatedAt) <= addonScaleTimeout {
+			return
+		}
+		if healthy {
+			a.finishScale(record, apistructs.AddonScaleFailed,
+				fmt.Sprintf("wait addon scaled timeout(%s), current: %s", addonScaleTimeout, observedScaleDesc(sg)))
+		} else {
+			a.finishScale(record, apistructs.AddonScaleFailed,
+				fmt.Sprintf("wait addon healthy timeout(%s), last status: %s", addonScaleTimeout, sg.Status))
+		}
+	}
+	a.ExportLogInfo(apistructs.ErrorLevel, apistructs.AddonError, ins.ID, ins.ID+"-scalefailed",
+		"addon(%s)(%s) 规格变更失败: %s", ins.AddonName, ins.ID, record.Message)
+}
+
+func (a *Addon) finishScale(record *dbclient.AddonScaleRecord, status apistructs.AddonScaleStatus, message string) {
+	now := time.Now()
+	record.Status = status
+	record.Message = message
+	record.FinishedAt = &now
+	if err := a.db.UpdateAddonScaleRecord(record); err != nil {
+		logrus.Errorf("failed to update addon scale record %d: %v", record.ID, err)
+	}
+}
+
+// resetAddonNodes 按变更后的 service group 重建 addon 节点信息
+func (a *Addon) resetAddonNodes(ins *dbclient.AddonInstance, sg *apistructs.ServiceGroup) error {
+	if err := a.db.DeleteAddonNodesByInstanceID(ins.ID); err != nil {
+		return err
+	}
+	for _, svc := range sg.Services {
+		for i := 0; i < svc.Scale; i++ {
+			if err := a.db.CreateAddonNode(&dbclient.AddonNode{
+				ID:         a.getRandomId(),
+				InstanceID: ins.ID,
+				Namespace:  ins.Namespace,
+				NodeName:   fmt.Sprintf("%s-%d", svc.Name, i),
+				CreatedAt:  time.Now(),
+				UpdatedAt:  time.Now(),
+				CPU:        svc.Resources.Cpu,
+				Mem:        uint64(svc.Resources.Mem),
+				Deleted:    apistructs.AddonNotDeleted,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getScalePlanItem 查询 addon 当前版本中目标规格的资源, 扩展未定义规格时使用 operator 默认规格
+func (a *Addon) getScalePlanItem(ins *dbclient.AddonInstance, plan string) (*apistructs.AddonPlanItem, error) {
+	addonSpec, _, err := a.GetAddonExtention(&apistructs.AddonHandlerCreateItem{
+		AddonName: ins.AddonName,
+		Plan:      plan,
+		Options:   map[string]string{"version": ins.Version},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if item, ok := addonSpec.Plan[plan]; ok {
+		return &item, nil
+	}
+	if item, ok := operatorAddonPlans[plan]; ok {
+		return &item, nil
+	}
+	return nil, errors.Errorf("addon %s does not support plan %s", ins.AddonName, plan)
+}
+
+// normalizePlan 与 transPlan 一致, 将 large/medium/small 转换为对应规格
+func normalizePlan(plan string) string {
+	switch plan {
+	case "large", apistructs.AddonUltimate:
+		return apistructs.AddonUltimate
+	case "medium", apistructs.AddonProfessional:
+		return apistructs.AddonProfessional
+	default:
+		return apistructs.AddonBasic
+	}
+}
+
+// currentScaleSpec 根据 service group 计算 addon 当前规格, 资源取第一个服务, 节点数为所有服务副本数之和
+func currentScaleSpec(plan string, sg *apistructs.ServiceGroup) apistructs.AddonScaleSpec {
+	spec := apistructs.AddonScaleSpec{Plan: plan}
+	for i, svc := range sg.Services {
+		if i == 0 {
+			spec.CPU = svc.Resources.Cpu
+			spec.Mem = int(svc.Resources.Mem)
+		}
+		spec.Replicas += svc.Scale
+	}
+	return spec
+}
+
+// computeScaleTarget 计算目标规格: 变更 plan 时以 planItem 为基准, 请求中非零的资源覆盖基准值
+func computeScaleTarget(from, req apistructs.AddonScaleSpec, planItem *apistructs.AddonPlanItem) (apistructs.AddonScaleSpec, error) {
+	to := from
+	if req.Plan != "" {
+		to.Plan = normalizePlan(req.Plan)
+	}
+	if planItem != nil {
+		if planItem.CPU > 0 {
+			to.CPU = planItem.CPU
+		}
+		if planItem.Mem > 0 {
+			to.Mem = planItem.Mem
+		}
+		if planItem.Nodes > 0 {
+			to.Replicas = planItem.Nodes
+		}
+	}
+	if req.CPU < 0 || req.Mem < 0 || req.Replicas < 0 {
+		return to, errors.New("cpu, mem and replicas can not be negative")
+	}
+	if req.CPU > 0 {
+		to.CPU = req.CPU
+	}
+	if req.Mem > 0 {
+		to.Mem = req.Mem
+	}
+	if req.Replicas > 0 {
+		to.Replicas = req.Replicas
+	}
+	if to == from {
+		return to, errors.New("addon spec is not changed")
+	}
+	return to, nil
+}
+
+// applyScaleSpec 将目标规格写入 service group, 节点数只能在单服务的 addon 上调整, 由 operator 维护各节点角色
+func applyScaleSpec(sg *apistructs.ServiceGroup, from, to apistructs.AddonScaleSpec) error {
+	if len(sg.Services) == 0 {
+		return errors.New("addon has no service")
+	}
+	if to.Replicas != from.Replicas {
+		if len(sg.Services) != 1 {
+			return errors.Errorf("addon replicas can not be changed from %d to %d, addon has %d services",
+				from.Replicas, to.Replicas, len(sg.Services))
+		}
+		sg.Services[0].Scale = to.Replicas
+	}
+	for i := range sg.Services {
+		sg.Services[i].Resources.Cpu = to.CPU
+		sg.Services[i].Resources.Mem = float64(to.Mem)
+	}
+	return nil
+}
+
+// scaleObserved 判断 scheduler 观察到的实际副本数及资源是否已与目标规格一致, service group 健康不代表变更已生效.
+// operator 部署的 addon 只在第一个服务上汇总实际规格, 因此只比对 scheduler 上报了实际规格的服务
+func scaleObserved(sg *apistructs.ServiceGroup, record *dbclient.AddonScaleRecord) bool {
+	replicas := 0
+	reported := false
+	for _, svc := range sg.Services {
+		if svc.CurrentResources == nil {
+			continue
+		}
+		reported = true
+		if math.Abs(svc.CurrentResources.Cpu-record.ToCPU) > 0.001 || int(svc.CurrentResources.Mem) != record.ToMem {
+			return false
+		}
+		replicas += svc.CurrentReplicas
+	}
+	return reported && replicas == record.ToReplicas
+}
+
+// observedScaleDesc 描述实际生效的规格, 用于超时时提示
+func observedScaleDesc(sg *apistructs.ServiceGroup) string {
+	descs := make([]string, 0, len(sg.Services))
+	for _, svc := range sg.Services {
+		if svc.CurrentResources == nil {
+			continue
+		}
+		descs = append(descs, fmt.Sprintf("%s cpu %s, mem %dMB, replicas %d", svc.Name,
+			formatCPU(svc.CurrentResources.Cpu), int(svc.CurrentResources.Mem), svc.CurrentReplicas))
+	}
+	if len(descs) == 0 {
+		return "unknown"
+	}
+	return strings.Join(descs, "; ")
+}
+
+func scaleSpecDesc(from, to apistructs.AddonScaleSpec) string {
+	return fmt.Sprintf("plan %s -> %s, cpu %s -> %s, mem %dMB -> %dMB, replicas %d -> %d",
+		from.Plan, to.Plan, formatCPU(from.CPU), formatCPU(to.CPU), from.Mem, to.Mem, from.Replicas, to.Replicas)
+}
+
+func formatCPU(cpu float64) string {
+	if cpu == math.Trunc(cpu) {
+		return fmt.Sprintf("%d", int(cpu))
+	}
+	return fmt.Sprintf("%.2f", cpu)
+}
+
+func convertScaleRecord(record *dbclient.AddonScaleRecord) *apistructs.AddonScaleRecord {
+	return &apistructs.AddonScaleRecord{
+		ID:         record.ID,
+		AddonID:    record.AddonID,
+		InstanceID: record.InstanceID,
+		AddonName:  record.AddonName,
+		From: apistructs.AddonScaleSpec{
+			Plan:     record.FromPlan,
+			CPU:      record.FromCPU,
+			Mem:      record.FromMem,
+			Replicas: record.FromReplicas,
+		},
+		To: apistructs.AddonScaleSpec{
+			Plan:     record.ToPlan,
+			CPU:      record.ToCPU,
+			Mem:      record.ToMem,
+			Replicas: record.ToReplicas,
+		},
+		Status:     record.Status,
+		Message:    record.Message,
+		Operator:   record.Operator,
+		FinishedAt: record.FinishedAt,
+		CreatedAt:  record.CreatedAt,
+		UpdatedAt:  record.UpdatedAt,
+	}
+}
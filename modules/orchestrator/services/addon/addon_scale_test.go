@@ -0,0 +1,101 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package addon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+)
+
+func TestComputeScaleTarget(t *testing.T) {
+	from := apistructs.AddonScaleSpec{Plan: apistructs.AddonBasic, CPU: 0.5, Mem: 1024, Replicas: 1}
+
+	// 变更 plan, 以目标规格资源为准
+	plan := operatorAddonPlans[apistructs.AddonProfessional]
+	to, err := computeScaleTarget(from, apistructs.AddonScaleSpec{Plan: "medium"}, &plan)
+	assert.Nil(t, err)
+	assert.Equal(t, apistructs.AddonScaleSpec{Plan: apistructs.AddonProfessional, CPU: 1, Mem: 2048, Replicas: 2}, to)
+
+	// 请求中的资源覆盖规格资源
+	to, err = computeScaleTarget(from, apistructs.AddonScaleSpec{Plan: apistructs.AddonProfessional, Mem: 4096}, &plan)
+	assert.Nil(t, err)
+	assert.Equal(t, 4096, to.Mem)
+	assert.Equal(t, 2, to.Replicas)
+
+	// 只调整资源, plan 不变
+	to, err = computeScaleTarget(from, apistructs.AddonScaleSpec{CPU: 2}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, apistructs.AddonScaleSpec{Plan: apistructs.AddonBasic, CPU: 2, Mem: 1024, Replicas: 1}, to)
+
+	_, err = computeScaleTarget(from, apistructs.AddonScaleSpec{Plan: "small"}, nil)
+	assert.NotNil(t, err)
+	_, err = computeScaleTarget(from, apistructs.AddonScaleSpec{Replicas: -1}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestApplyScaleSpec(t *testing.T) {
+	sg := &apistructs.ServiceGroup{Dice: apistructs.Dice{Services: []apistructs.Service{
+		{Name: "mysql", Scale: 1, Resources: apistructs.Resources{Cpu: 0.5, Mem: 1024}},
+	}}}
+	from := currentScaleSpec(apistructs.AddonBasic, sg)
+	assert.Equal(t, apistructs.AddonScaleSpec{Plan: apistructs.AddonBasic, CPU: 0.5, Mem: 1024, Replicas: 1}, from)
+
+	to := apistructs.AddonScaleSpec{Plan: apistructs.AddonProfessional, CPU: 1, Mem: 2048, Replicas: 2}
+	assert.Nil(t, applyScaleSpec(sg, from, to))
+	assert.Equal(t, 2, sg.Services[0].Scale)
+	assert.Equal(t, float64(2048), sg.Services[0].Resources.Mem)
+
+	// 多服务的 addon 各服务角色固定, 不允许调整节点数
+	sg = &apistructs.ServiceGroup{Dice: apistructs.Dice{Services: []apistructs.Service{
+		{Name: "mysql-master", Scale: 1, Resources: apistructs.Resources{Cpu: 1, Mem: 2048}},
+		{Name: "mysql-slave", Scale: 1, Resources: apistructs.Resources{Cpu: 1, Mem: 2048}},
+	}}}
+	from = currentScaleSpec(apistructs.AddonProfessional, sg)
+	assert.Equal(t, 2, from.Replicas)
+	assert.NotNil(t, applyScaleSpec(sg, from, apistructs.AddonScaleSpec{CPU: 1, Mem: 2048, Replicas: 3}))
+	assert.Nil(t, applyScaleSpec(sg, from, apistructs.AddonScaleSpec{CPU: 2, Mem: 4096, Replicas: 2}))
+	assert.Equal(t, float64(2), sg.Services[1].Resources.Cpu)
+}
+
+func TestScaleObserved(t *testing.T) {
+	record := &dbclient.AddonScaleRecord{ToCPU: 1, ToMem: 2048, ToReplicas: 2}
+	sg := &apistructs.ServiceGroup{Dice: apistructs.Dice{Services: []apistructs.Service{
+		{Name: "mysql", Scale: 2, Resources: apistructs.Resources{Cpu: 1, Mem: 2048}},
+	}}}
+	// 调度器尚未观察到变更后的资源
+	assert.False(t, scaleObserved(sg, record))
+
+	sg.Services[0].CurrentReplicas = 1
+	sg.Services[0].CurrentResources = &apistructs.Resources{Cpu: 1, Mem: 2048}
+	assert.False(t, scaleObserved(sg, record))
+
+	sg.Services[0].CurrentReplicas = 2
+	sg.Services[0].CurrentResources = &apistructs.Resources{Cpu: 0.5, Mem: 2048}
+	assert.False(t, scaleObserved(sg, record))
+
+	sg.Services[0].CurrentResources = &apistructs.Resources{Cpu: 1, Mem: 2048}
+	assert.True(t, scaleObserved(sg, record))
+
+	// operator 部署的 addon 只在第一个服务上汇总实际规格
+	sg.Services = append(sg.Services, apistructs.Service{Name: "sentinel", Scale: 1})
+	assert.True(t, scaleObserved(sg, record))
+	assert.Equal(t, "mysql cpu 1, mem 2048MB, replicas 2", observedScaleDesc(sg))
+	sg.Services[0].CurrentResources = nil
+	assert.False(t, scaleObserved(sg, record))
+	assert.Equal(t, "unknown", observedScaleDesc(sg))
+}
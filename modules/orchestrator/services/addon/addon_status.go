@@ -304,6 +304,48 @@ func (a *Addon) RedisDeployStatus(addonIns *dbclient.AddonInstance, serviceGroup
 	return configMap, nil
 }
 
+// PostgresqlDeployStatus postgresql operator状态拉取
+func (a *Addon) PostgresqlDeployStatus(addonIns *dbclient.AddonInstance, serviceGroup *apistructs.ServiceGroup) (map[string]string, error) {
+	configMap := map[string]string{}
+	password, err := a.db.GetByInstanceIDAndField(addonIns.ID, apistructs.AddonPostgresqlPasswordKey)
+	if err != nil {
+		logrus.Errorf("获取postgresql password报错, %v", err)
+		return nil, err
+	}
+	if len(serviceGroup.Services) >= 1 {
+		configMap[apistructs.AddonPostgresqlHostName] = serviceGroup.Services[0].Vip
+		configMap[apistructs.AddonPostgresqlPortName] = apistructs.AddonPostgresqlDefaultPort
+	}
+	if replicaHost := serviceGroup.Labels["REPLICA_HOST"]; replicaHost != "" {
+		configMap[apistructs.AddonPostgresqlReplicaHostName] = replicaHost
+	}
+	configMap[apistructs.AddonPostgresqlUserName] = apistructs.AddonPostgresqlUser
+	configMap[apistructs.AddonPostgresqlPasswordName] = password.Value
+	configMap[apistructs.AddonPasswordHasEncripy] = "YES"
+	return configMap, nil
+}
+
+// MongodbDeployStatus mongodb operator状态拉取
+func (a *Addon) MongodbDeployStatus(addonIns *dbclient.AddonInstance, serviceGroup *apistructs.ServiceGroup) (map[string]string, error) {
+	configMap := map[string]string{}
+	password, err := a.db.GetByInstanceIDAndField(addonIns.ID, apistructs.AddonMongodbPasswordKey)
+	if err != nil {
+		logrus.Errorf("获取mongodb password报错, %v", err)
+		return nil, err
+	}
+	if len(serviceGroup.Services) >= 1 {
+		configMap[apistructs.AddonMongodbHostName] = serviceGroup.Services[0].Vip
+		configMap[apistructs.AddonMongodbPortName] = apistructs.AddonMongodbDefaultPort
+	}
+	if replicaSet := serviceGroup.Labels["REPLICA_SET"]; replicaSet != "" {
+		configMap[apistructs.AddonMongodbReplicaSetName] = replicaSet
+	}
+	configMap[apistructs.AddonMongodbUserName] = apistructs.AddonMongodbUser
+	configMap[apistructs.AddonMongodbPasswordName] = password.Value
+	configMap[apistructs.AddonPasswordHasEncripy] = "YES"
+	return configMap, nil
+}
+
 // ZookeeperDeployStatus zk状态拉取
 func (a *Addon) ZookeeperDeployStatus(addonIns *dbclient.AddonInstance, serviceGroup *apistructs.ServiceGroup) (map[string]string, error) {
 	configMap := map[string]string{}
@@ -1104,6 +1146,96 @@ func (a *Addon) BuildRedisOperatorServiceItem(addonIns *dbclient.AddonInstance,
 	return nil
 }
 
+// operatorAddonPlans 扩展中未定义规格时，operator 类 addon 使用的默认规格
+var operatorAddonPlans = map[string]apistructs.AddonPlanItem{
+	apistructs.AddonBasic:        {CPU: 0.5, Mem: 1024, Nodes: 1},
+	apistructs.AddonProfessional: {CPU: 1, Mem: 2048, Nodes: 2},
+	apistructs.AddonUltimate:     {CPU: 2, Mem: 4096, Nodes: 3},
+}
+
+// buildOperatorServiceItem 将 addon 的 dice.yml 规整为单个 service，并按规格设置资源和副本数
+func (a *Addon) buildOperatorServiceItem(serviceName string, params *apistructs.AddonHandlerCreateItem,
+	addonSpec *apistructs.AddonExtension, addonDice *diceyml.Object) (*diceyml.Service, error) {
+	if len(addonDice.Services) == 0 {
+		return nil, errors.Errorf("addon %s has no service in dice.yml", params.AddonName)
+	}
+	plan, ok := addonSpec.Plan[params.Plan]
+	if !ok {
+		if plan, ok = operatorAddonPlans[params.Plan]; !ok {
+			return nil, errors.Errorf("addon %s does not support plan %s", params.AddonName, params.Plan)
+		}
+	}
+	var service *diceyml.Service
+	for _, v := range addonDice.Services {
+		service = v
+		break
+	}
+	if plan.CPU > 0 {
+		service.Resources.CPU = plan.CPU
+	}
+	if plan.Mem > 0 {
+		service.Resources.Mem = plan.Mem
+	}
+	service.Deployments.Replicas = 1
+	if plan.Nodes > 0 {
+		service.Deployments.Replicas = plan.Nodes
+	}
+	addonDice.Services = diceyml.Services{serviceName: service}
+	return service, nil
+}
+
+// BuildPostgresqlOperatorServiceItem postgresql operator build service item
+func (a *Addon) BuildPostgresqlOperatorServiceItem(params *apistructs.AddonHandlerCreateItem, addonIns *dbclient.AddonInstance,
+	addonSpec *apistructs.AddonExtension, addonDice *diceyml.Object) error {
+	service, err := a.buildOperatorServiceItem(apistructs.AddonPostgresql, params, addonSpec, addonDice)
+	if err != nil {
+		return err
+	}
+	// 设置密码
+	password, err := a.savePassword(addonIns, apistructs.AddonPostgresqlPasswordKey)
+	if err != nil {
+		return err
+	}
+	// 设置meta
+	addonDice.Meta = map[string]string{
+		"USE_OPERATOR": apistructs.AddonPostgresql,
+		"VERSION":      addonSpec.Version,
+	}
+	//设置环境变量
+	service.Envs = map[string]string{
+		"ADDON_ID":          addonIns.ID,
+		"ADDON_NODE_ID":     a.getRandomId(),
+		"POSTGRES_PASSWORD": password,
+	}
+	return nil
+}
+
+// BuildMongodbOperatorServiceItem mongodb operator build service item
+func (a *Addon) BuildMongodbOperatorServiceItem(params *apistructs.AddonHandlerCreateItem, addonIns *dbclient.AddonInstance,
+	addonSpec *apistructs.AddonExtension, addonDice *diceyml.Object) error {
+	service, err := a.buildOperatorServiceItem(apistructs.AddonMongodb, params, addonSpec, addonDice)
+	if err != nil {
+		return err
+	}
+	// 设置密码
+	password, err := a.savePassword(addonIns, apistructs.AddonMongodbPasswordKey)
+	if err != nil {
+		return err
+	}
+	// 设置meta
+	addonDice.Meta = map[string]string{
+		"USE_OPERATOR": apistructs.AddonMongodb,
+		"VERSION":      addonSpec.Version,
+	}
+	//设置环境变量
+	service.Envs = map[string]string{
+		"ADDON_ID":                   addonIns.ID,
+		"ADDON_NODE_ID":              a.getRandomId(),
+		"MONGO_INITDB_ROOT_PASSWORD": password,
+	}
+	return nil
+}
+
 func (a *Addon) guessCanalAddr(instanceroutings []dbclient.AddonInstanceRouting, instances []dbclient.AddonInstance) map[string]string {
 	for _, routing := range instanceroutings {
 		var (
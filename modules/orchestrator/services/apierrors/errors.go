@@ -29,6 +29,8 @@ var (
 	ErrUpdateRuntime   = err("ErrUpdateRuntime", "更新应用实例失败")
 	ErrReferRuntime    = err("ErrReferRuntime", "查询应用实例引用集群失败")
 	ErrKillPod         = err("ErrKillPod", "kill pod 失败")
+	ErrExportRuntime   = err("ErrExportRuntime", "导出应用实例失败")
+	ErrImportRuntime   = err("ErrImportRuntime", "导入应用实例失败")
 )
 
 var (
@@ -44,6 +46,18 @@ var (
 	ErrDeployStagesAddons   = err("ErrDeployStagesAddons", "部署addon失败")
 	ErrDeployStagesServices = err("ErrDeployStagesServices", "部署service失败")
 	ErrDeployStagesDomains  = err("ErrDeployStagesDomains", "部署domain失败")
+	ErrRolloutDeployment    = err("ErrRolloutDeployment", "操作灰度发布失败")
+)
+
+// deploy freeze errors
+var (
+	ErrCreateDeployFreeze  = err("ErrCreateDeployFreeze", "创建部署冻结窗口失败")
+	ErrUpdateDeployFreeze  = err("ErrUpdateDeployFreeze", "更新部署冻结窗口失败")
+	ErrDeleteDeployFreeze  = err("ErrDeleteDeployFreeze", "删除部署冻结窗口失败")
+	ErrListDeployFreeze    = err("ErrListDeployFreeze", "查询部署冻结窗口失败")
+	ErrCheckDeployFreeze   = err("ErrCheckDeployFreeze", "查询部署冻结状态失败")
+	ErrApplyDeployFreeze   = err("ErrApplyDeployFreeze", "申请紧急部署失败")
+	ErrApproveDeployFreeze = err("ErrApproveDeployFreeze", "审批紧急部署失败")
 )
 
 // domain errors
@@ -85,6 +99,29 @@ var (
 	ErrAddonYmlImport = err("ErrAddonYmlImport", "addonyml 导入")
 )
 
+var (
+	ErrSaveAddonBackupPolicy = err("ErrSaveAddonBackupPolicy", "保存 addon 备份策略失败")
+	ErrGetAddonBackupPolicy  = err("ErrGetAddonBackupPolicy", "获取 addon 备份策略失败")
+	ErrBackupAddon           = err("ErrBackupAddon", "备份 addon 失败")
+	ErrListAddonBackup       = err("ErrListAddonBackup", "获取 addon 备份历史失败")
+	ErrRestoreAddon          = err("ErrRestoreAddon", "恢复 addon 备份失败")
+)
+
+var (
+	ErrScaleAddon           = err("ErrScaleAddon", "变更 addon 规格失败")
+	ErrListAddonScaleRecord = err("ErrListAddonScaleRecord", "获取 addon 规格变更记录失败")
+)
+
+var (
+	ErrRotateAddonCredential       = err("ErrRotateAddonCredential", "轮换 addon 凭证失败")
+	ErrListAddonCredentialRotation = err("ErrListAddonCredentialRotation", "获取 addon 凭证轮换记录失败")
+)
+
+var (
+	ErrIssueDomainCertificate = err("ErrIssueDomainCertificate", "签发域名证书失败")
+	ErrListDomainCertificate  = err("ErrListDomainCertificate", "查询域名证书失败")
+)
+
 func err(template, defaultValue string) *errorresp.APIError {
 	return errorresp.New(errorresp.WithTemplateMessage(template, defaultValue))
 }
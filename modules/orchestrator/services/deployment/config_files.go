@@ -0,0 +1,238 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package deployment
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"runtime/debug"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/bundle"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+const (
+	// configFilesSyncLease 配置文件同步的租约名称
+	configFilesSyncLease = "config-files-sync"
+	// configFilesSyncLeaseTTL 略短于同步间隔, 持有者宕机后下一轮可由其他实例获取
+	configFilesSyncLeaseTTL = 8 * time.Minute
+)
+
+// ConfigFilesSync 定时检查已部署 runtime 挂载的配置中心文件及证书, 内容变化时更新 servicegroup,
+// scheduler 随之更新 configmap/secret 及 pod 上的文件校验和, 触发服务滚动重启
+func (d *Deployment) ConfigFilesSync() {
+	defer func() {
+		if err := recover(); err != nil {
+			debug.PrintStack()
+			logrus.Errorf("[alert] failed to sync config files, panic: %v", err)
+		}
+	}()
+
+	holder, _ := os.Hostname()
+	claimed, err := d.db.ClaimScanLease(configFilesSyncLease, holder, configFilesSyncLeaseTTL)
+	if err != nil {
+		logrus.Errorf("failed to claim lease of config files sync, (%v)", err)
+		return
+	}
+	if !claimed {
+		logrus.Debugf("config files sync is held by other instance, skip")
+		return
+	}
+
+	bulk := 100
+	lastRuntimeID := uint64(0)
+	for {
+		runtimes, err := d.db.FindRuntimesNewerThan(lastRuntimeID, bulk)
+		if err != nil {
+			logrus.Errorf("[alert] failed to find runtimes after: %v, (%v)", lastRuntimeID, err)
+			break
+		}
+		for i := range runtimes {
+			if err := d.syncConfigFiles(&runtimes[i]); err != nil {
+				logrus.Warnf("failed to sync config files of runtime %d, (%v)", runtimes[i].ID, err)
+			}
+		}
+		if len(runtimes) < bulk {
+			// ended
+			break
+		}
+		lastRuntimeID = runtimes[len(runtimes)-1].ID
+	}
+}
+
+func (d *Deployment) syncConfigFiles(runtime *dbclient.Runtime) error {
+	if !runtime.Deployed || runtime.LegacyStatus == dbclient.LegacyStatusDeleting {
+		return nil
+	}
+	// 部署中的 runtime 由本次部署获取最新内容
+	deployment, err := d.db.FindLastDeployment(runtime.ID)
+	if err != nil || deployment == nil || deployment.Status != apistructs.DeploymentStatusOK {
+		return err
+	}
+	var dice diceyml.Object
+	if err := json.Unmarshal([]byte(deployment.Dice), &dice); err != nil {
+		return err
+	}
+	if !referencesConfigFiles(&dice) {
+		return nil
+	}
+	app, err := d.bdl.GetApp(runtime.ApplicationID)
+	if err != nil {
+		return err
+	}
+	fileconfigs := map[string]string{}
+	if configNamespace := workspaceConfigNamespace(app, runtime.Workspace); configNamespace != "" {
+		if _, fileconfigs, err = d.bdl.FetchDeploymentConfig(configNamespace); err != nil {
+			return err
+		}
+	}
+	sg, err := d.bdl.InspectServiceGroupWithTimeout(runtime.ScheduleName.Args())
+	if err != nil {
+		return err
+	}
+	var changed []string
+	for i := range sg.Services {
+		svc := &sg.Services[i]
+		declared, ok := dice.Services[svc.Name]
+		if !ok || len(declared.Files) == 0 {
+			continue
+		}
+		files := make([]diceyml.ConfigFile, len(declared.Files))
+		copy(files, declared.Files)
+		if err := resolveConfigFiles(d.bdl, app.ID, svc.Name, files, fileconfigs); err != nil {
+			return err
+		}
+		if configFilesChanged(svc.ConfigFiles, files) {
+			svc.ConfigFiles = files
+			changed = append(changed, svc.Name)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	logrus.Infof("config files of runtime %d changed, restart services: %v", runtime.ID, changed)
+	return d.bdl.ServiceGroupConfigUpdate(*sg)
+}
+
+// referencesConfigFiles dice.yml 中是否有引用配置中心文件或证书的服务
+func referencesConfigFiles(dice *diceyml.Object) bool {
+	for _, svc := range dice.Services {
+		for _, file := range svc.Files {
+			if file.Config != "" || file.Certificate != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// configFilesChanged 比较已部署的文件与最新内容, 已部署的 secret 文件内容以校验和代替
+func configFilesChanged(deployed, latest []diceyml.ConfigFile) bool {
+	if len(deployed) != len(latest) {
+		return true
+	}
+	checksums := make(map[string]string, len(deployed))
+	for _, file := range deployed {
+		checksums[file.Path] = file.ContentChecksum()
+	}
+	for _, file := range latest {
+		checksum, ok := checksums[file.Path]
+		if !ok || checksum != file.ContentChecksum() {
+			return true
+		}
+	}
+	return false
+}
+
+// workspaceConfigNamespace 应用在环境下的配置中心 namespace
+func workspaceConfigNamespace(app *apistructs.ApplicationDTO, workspace string) string {
+	for _, w := range app.Workspaces {
+		if w.Workspace == workspace {
+			return w.ConfigNamespace
+		}
+	}
+	return ""
+}
+
+// resolveConfigFiles fetch the contents of files which reference config-center or certificates,
+// so that scheduler can materialize them as configmaps or secrets
+func resolveConfigFiles(bdl *bundle.Bundle, appID uint64, serviceName string, files []diceyml.ConfigFile, groupFileconfigs map[string]string) error {
+	var certificates map[string]apistructs.ApplicationCertificateDTO
+	for i := range files {
+		file := &files[i]
+		var uuid string
+		switch {
+		case file.Config != "":
+			v, ok := groupFileconfigs[file.Config]
+			if !ok {
+				return errors.Errorf("failed to mount %s of service %s, file config %s not found", file.Path, serviceName, file.Config)
+			}
+			uuid = v
+		case file.Certificate != "":
+			if certificates == nil {
+				quotes, err := bdl.ListAppCertificates(&apistructs.AppCertificateListRequest{
+					AppID:    appID,
+					Status:   string(apistructs.ApprovalStatusApproved),
+					PageNo:   1,
+					PageSize: 1000,
+				})
+				if err != nil {
+					return err
+				}
+				certificates = make(map[string]apistructs.ApplicationCertificateDTO, len(quotes.List))
+				for _, c := range quotes.List {
+					if c.Type == string(apistructs.MessageCertificateType) {
+						certificates[c.Name] = c
+					}
+				}
+			}
+			c, ok := certificates[file.Certificate]
+			if !ok || c.MessageInfo.UUID == "" {
+				return errors.Errorf("failed to mount %s of service %s, certificate %s not quoted by application", file.Path, serviceName, file.Certificate)
+			}
+			uuid = c.MessageInfo.UUID
+			file.Secret = true
+		default:
+			continue
+		}
+		content, err := downloadConfigFile(bdl, uuid)
+		if err != nil {
+			return errors.Wrapf(err, "failed to mount %s of service %s", file.Path, serviceName)
+		}
+		if !utf8.Valid(content) {
+			return errors.Errorf("failed to mount %s of service %s, binary file is not supported", file.Path, serviceName)
+		}
+		file.Content = string(content)
+		file.Config = ""
+		file.Certificate = ""
+	}
+	return nil
+}
+
+func downloadConfigFile(bdl *bundle.Bundle, uuid string) ([]byte, error) {
+	r, err := bdl.DownloadDiceFile(uuid)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
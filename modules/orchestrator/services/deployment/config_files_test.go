@@ -0,0 +1,55 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func TestReferencesConfigFiles(t *testing.T) {
+	dice := &diceyml.Object{Services: diceyml.Services{
+		"web": {Files: []diceyml.ConfigFile{{Path: "/app/a.yml", Content: "a: 1"}}},
+	}}
+	assert.False(t, referencesConfigFiles(dice))
+	dice.Services["api"] = &diceyml.Service{Files: []diceyml.ConfigFile{{Path: "/app/tls.crt", Certificate: "tls"}}}
+	assert.True(t, referencesConfigFiles(dice))
+}
+
+func TestConfigFilesChanged(t *testing.T) {
+	latest := []diceyml.ConfigFile{
+		{Path: "/app/a.yml", Content: "a: 1"},
+		{Path: "/app/tls.crt", Content: "cert", Secret: true},
+	}
+	secret := diceyml.ConfigFile{Path: "/app/tls.crt", Secret: true, Checksum: latest[1].ContentChecksum()}
+
+	// content of secret file is redacted in the deployed servicegroup
+	assert.False(t, configFilesChanged([]diceyml.ConfigFile{secret, latest[0]}, latest))
+	assert.True(t, configFilesChanged([]diceyml.ConfigFile{secret, {Path: "/app/a.yml", Content: "a: 2"}}, latest))
+	assert.True(t, configFilesChanged([]diceyml.ConfigFile{latest[0]}, latest))
+	assert.True(t, configFilesChanged([]diceyml.ConfigFile{secret, {Path: "/app/b.yml", Content: "a: 1"}}, latest))
+}
+
+func TestWorkspaceConfigNamespace(t *testing.T) {
+	app := &apistructs.ApplicationDTO{Workspaces: []apistructs.ApplicationWorkspace{
+		{Workspace: "DEV", ConfigNamespace: "app-1-DEV"},
+		{Workspace: "PROD", ConfigNamespace: "app-1-PROD"},
+	}}
+	assert.Equal(t, "app-1-PROD", workspaceConfigNamespace(app, "PROD"))
+	assert.Equal(t, "", workspaceConfigNamespace(app, "TEST"))
+}
@@ -48,6 +48,14 @@ type Deployment struct {
 	resource  *resource.Resource
 	migration *migration.Migration
 	encrypt   *encryption.EnvEncrypt
+	// 部署后验证不通过时自动回滚
+	rollbacker Rollbacker
+}
+
+// Rollbacker 回滚 runtime 到指定的部署单
+type Rollbacker interface {
+	Rollback(operator user.ID, orgID uint64, runtimeID uint64, deploymentID uint64) (
+		*apistructs.DeploymentCreateResponseDTO, error)
 }
 
 // Option 部署对象配置选项
@@ -111,13 +119,21 @@ func WithResource(resource *resource.Resource) Option {
 	}
 }
 
+// WithRollbacker 配置部署后验证不通过时的回滚入口
+func WithRollbacker(rollbacker Rollbacker) Option {
+	return func(d *Deployment) {
+		d.rollbacker = rollbacker
+	}
+}
+
 func (d *Deployment) ContinueDeploy(deploymentID uint64) error {
 	// prepare the context
-	fsm := NewFSMContext(deploymentID, d.db, d.evMgr, d.bdl, d.addon, d.migration, d.encrypt, d.resource)
+	fsm := NewFSMContext(deploymentID, d.db, d.evMgr, d.bdl, d.addon, d.migration, d.encrypt, d.resource, d.rollbacker)
 	if err := fsm.Load(); err != nil {
 		return errors.Wrapf(err, "failed to load fsm, deployment: %d, (%v)", deploymentID, err)
 	}
-	if fsm.Deployment.SkipPushByOrch {
+	// 流水线部署由流水线推进, 但部署后验证需等待观察窗口结束, 仍由 orchestrator 推进
+	if fsm.Deployment.SkipPushByOrch && fsm.Deployment.Phase != apistructs.DeploymentPhaseVerify {
 		return nil
 	}
 	if end, err := fsm.timeout(); end || err != nil {
@@ -143,7 +159,7 @@ func (d *Deployment) CancelLastDeploy(runtimeID uint64, operator string, force b
 	if deployment == nil {
 		return apierrors.ErrCancelDeployment.NotFound()
 	}
-	fsm := NewFSMContext(deployment.ID, d.db, d.evMgr, d.bdl, d.addon, d.migration, d.encrypt, d.resource)
+	fsm := NewFSMContext(deployment.ID, d.db, d.evMgr, d.bdl, d.addon, d.migration, d.encrypt, d.resource, d.rollbacker)
 	if err := fsm.Load(); err != nil {
 		return err
 	}
@@ -163,6 +179,49 @@ func (d *Deployment) CancelLastDeploy(runtimeID uint64, operator string, force b
 	return fsm.doCancelDeploy(operator, force)
 }
 
+// Rollout promote, pause or abort the canary / blue-green rollout of service
+func (d *Deployment) Rollout(userID user.ID, deploymentID uint64, req apistructs.DeploymentRolloutRequest) (
+	*apistructs.RolloutStatus, error) {
+	if req.Service == "" {
+		return nil, apierrors.ErrRolloutDeployment.MissingParameter("service")
+	}
+	if !req.Action.Valid() {
+		return nil, apierrors.ErrRolloutDeployment.InvalidParameter(strutil.Concat("action: ", string(req.Action)))
+	}
+	deployment, err := d.db.GetDeployment(deploymentID)
+	if err != nil {
+		return nil, apierrors.ErrRolloutDeployment.InternalError(err)
+	}
+	runtime, err := d.db.GetRuntime(deployment.RuntimeId)
+	if err != nil {
+		return nil, apierrors.ErrRolloutDeployment.InternalError(err)
+	}
+	perm, err := d.bdl.CheckPermission(&apistructs.PermissionCheckRequest{
+		UserID:   userID.String(),
+		Scope:    apistructs.AppScope,
+		ScopeID:  runtime.ApplicationID,
+		Resource: "runtime-" + strutil.ToLower(runtime.Workspace),
+		Action:   apistructs.OperateAction,
+	})
+	if err != nil {
+		return nil, apierrors.ErrRolloutDeployment.InternalError(err)
+	}
+	if !perm.Access {
+		return nil, apierrors.ErrRolloutDeployment.AccessDenied()
+	}
+	namespace, name := runtime.ScheduleName.Args()
+	status, err := d.bdl.RolloutServiceGroup(apistructs.ServiceGroupRolloutRequest{
+		Namespace: namespace,
+		Name:      name,
+		Service:   req.Service,
+		Action:    req.Action,
+	})
+	if err != nil {
+		return nil, apierrors.ErrRolloutDeployment.InternalError(err)
+	}
+	return status, nil
+}
+
 // ListOrg 查询部署记录(列出orgid下所有有权限的deployments)
 func (d *Deployment) ListOrg(userID user.ID, orgID uint64, needFilterProjectRole bool,
 	needApproval *bool, approvedBy *user.ID, operateUsers []string, approved *bool,
@@ -382,9 +441,27 @@ func (d *Deployment) GetStatus(deploymentID uint64) (*apistructs.DeploymentStatu
 		FailCause:    deployment.FailCause,
 		ModuleErrMsg: statusMap,
 		Runtime:      rt,
+		Rollouts:     convertRollouts(sg),
 	}, nil
 }
 
+func convertRollouts(sg *apistructs.ServiceGroup) map[string]*apistructs.RolloutStatus {
+	if sg == nil {
+		return nil
+	}
+	var rollouts map[string]*apistructs.RolloutStatus
+	for _, service := range sg.Services {
+		if service.Rollout == nil {
+			continue
+		}
+		if rollouts == nil {
+			rollouts = make(map[string]*apistructs.RolloutStatus)
+		}
+		rollouts[service.Name] = service.Rollout
+	}
+	return rollouts
+}
+
 func (d *Deployment) Approve(userID user.ID, orgID uint64, deploymentID uint64, reject bool, reason string, referer string) error {
 	deployment, err := d.db.GetDeployment(deploymentID)
 	if err != nil {
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -69,10 +70,12 @@ type DeployFSMContext struct {
 	migration *migration.Migration
 	resource  *resource.Resource
 	encrypt   *encryption.EnvEncrypt
+	// rollback entry of auto rollback
+	rollbacker Rollbacker
 }
 
 // TODO: context should base on deployment service
-func NewFSMContext(deploymentID uint64, db *dbclient.DBClient, evMgr *events.EventManager, bdl *bundle.Bundle, a *addon.Addon, m *migration.Migration, encrypt *encryption.EnvEncrypt, resource *resource.Resource) *DeployFSMContext {
+func NewFSMContext(deploymentID uint64, db *dbclient.DBClient, evMgr *events.EventManager, bdl *bundle.Bundle, a *addon.Addon, m *migration.Migration, encrypt *encryption.EnvEncrypt, resource *resource.Resource, rollbacker Rollbacker) *DeployFSMContext {
 	logger := log.DeployLogHelper{DeploymentID: deploymentID, Bdl: bdl}
 	a.Logger = &logger
 	// prepare the context
@@ -86,6 +89,7 @@ func NewFSMContext(deploymentID uint64, db *dbclient.DBClient, evMgr *events.Eve
 		migration:    m,
 		encrypt:      encrypt,
 		resource:     resource,
+		rollbacker:   rollbacker,
 	}
 }
 
@@ -191,6 +195,8 @@ func (fsm *DeployFSMContext) continueDeploying() error {
 		return fsm.continuePhaseService()
 	case apistructs.DeploymentPhaseRegister:
 		return fsm.continuePhaseRegister()
+	case apistructs.DeploymentPhaseVerify:
+		return fsm.continuePhaseVerify()
 	case apistructs.DeploymentPhaseCompleted:
 		return fsm.continuePhaseCompleted()
 	default:
@@ -573,8 +579,16 @@ func (fsm *DeployFSMContext) continuePhaseRegister() error {
 		fsm.Deployment.Phase != apistructs.DeploymentPhaseRegister {
 		return nil
 	}
+	next := apistructs.DeploymentPhaseCompleted
+	verify, err := fsm.needVerify()
+	if err != nil {
+		return fsm.failDeploy(err)
+	}
+	if verify {
+		next = apistructs.DeploymentPhaseVerify
+	}
 	// pushOn Phase
-	if err := fsm.pushOnPhase(apistructs.DeploymentPhaseCompleted); err != nil {
+	if err := fsm.pushOnPhase(next); err != nil {
 		return err
 	}
 	return nil
@@ -663,12 +677,21 @@ func (fsm *DeployFSMContext) requestAddons() error {
 	runtime := fsm.Runtime
 	app := fsm.App
 
+	// 预览环境只申请基础规格的 addon
+	preview, err := fsm.db.GetPreviewRuntimeByRuntimeID(runtime.ID)
+	if err != nil {
+		return err
+	}
+
 	var baseAddons []apistructs.AddonCreateItem
 	for name, a := range fsm.Spec.AddOns {
 		plan := strings.SplitN(a.Plan, ":", 2)
 		if len(plan) != 2 {
 			return errors.Errorf("addon plan information is not compliant")
 		}
+		if preview != nil {
+			plan[1] = apistructs.AddonBasic
+		}
 		baseAddons = append(baseAddons, apistructs.AddonCreateItem{
 			Name:    name,
 			Type:    plan[0],
@@ -913,13 +936,7 @@ func (fsm *DeployFSMContext) generateDeployServiceRequest(group *apistructs.Serv
 	for k, v := range obj.Envs {
 		groupEnv[k] = v
 	}
-	var configNamespace string
-	for _, w := range fsm.App.Workspaces {
-		if w.Workspace == runtime.Workspace {
-			configNamespace = w.ConfigNamespace
-			break
-		}
-	}
+	configNamespace := workspaceConfigNamespace(fsm.App, runtime.Workspace)
 	if len(configNamespace) > 0 {
 		// get configs from config-center
 		envconfigs, fileconfigs, err := fsm.bdl.FetchDeploymentConfig(configNamespace)
@@ -963,10 +980,52 @@ func (fsm *DeployFSMContext) generateDeployServiceRequest(group *apistructs.Serv
 		}
 
 	}
+	// 开启网络隔离时, 允许 runtime 绑定的 addon 访问服务
+	if v := strings.ToLower(obj.Meta["NETWORK_POLICY"]); v == "on" || v == "true" {
+		namespaces, err := fsm.attachedAddonNamespaces(usedAddonInsMap)
+		if err != nil {
+			return nil, nil, err
+		}
+		obj.Meta["NETWORK_POLICY_ADDON_NAMESPACES"] = strings.Join(namespaces, ",")
+	}
 	group.DiceYml = *obj
 	return usedAddonInsMap, usedAddonTenantMap, nil
 }
 
+// attachedAddonNamespaces 获取 runtime 绑定的 addon 的 namespace, 即 addon 的 servicegroup
+func (fsm *DeployFSMContext) attachedAddonNamespaces(usedAddonInsMap map[string]dbclient.AddonInstanceRouting) ([]string, error) {
+	attachments, err := fsm.db.GetAttachMentsByRuntimeID(fsm.Runtime.ID)
+	if err != nil {
+		return nil, err
+	}
+	instanceIDs := []string{}
+	for _, attachment := range *attachments {
+		instanceIDs = append(instanceIDs, attachment.InstanceID)
+	}
+	for _, routing := range usedAddonInsMap {
+		instanceIDs = append(instanceIDs, routing.RealInstance)
+	}
+	visited := map[string]struct{}{}
+	namespaces := []string{}
+	for _, id := range instanceIDs {
+		if _, ok := visited[id]; ok || id == "" {
+			continue
+		}
+		visited[id] = struct{}{}
+		ins, err := fsm.db.GetAddonInstance(id)
+		if err != nil {
+			return nil, err
+		}
+		// addon 不是由调度器部署的, 比如云服务
+		if ins == nil || ins.Namespace == "" || ins.ScheduleName == "" {
+			continue
+		}
+		namespaces = append(namespaces, ins.Namespace+"--"+ins.ScheduleName)
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
 func (fsm *DeployFSMContext) checkCancelOk() (bool, error) {
 	if fsm.Deployment.Extra.CancelStartAt != nil {
 		startCheckPoint := fsm.Deployment.Extra.CancelStartAt.Add(30 * time.Second)
@@ -1322,6 +1381,9 @@ func (fsm *DeployFSMContext) convertService(serviceName string, service *diceyml
 		service.ImagePassword = nexususer.Password
 		service.ImageUsername = nexususer.Name
 	}
+	if err := resolveConfigFiles(fsm.bdl, fsm.App.ID, serviceName, service.Files, groupFileconfigs); err != nil {
+		return nil, nil, err
+	}
 	if len(groupFileconfigs) > 0 {
 		tokeninfo, err := fsm.bdl.GetOpenapiOAuth2Token(apistructs.OpenapiOAuth2TokenGetRequest{
 			ClientID:     conf.TokenClientID(),
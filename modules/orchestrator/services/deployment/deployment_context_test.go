@@ -84,6 +84,7 @@ func TestConvertGroupLabels(t *testing.T) {
 func TestFSMTimeout(t *testing.T) {
 	f := genFakeFSM()
 
+	defer monkey.UnpatchAll()
 	_ = recordUpdateDeployment()
 	_ = recordEvent()
 	_ = recordDLog()
@@ -316,6 +317,7 @@ func TestFSMContinueCanceling(t *testing.T) {
 	f.Deployment.Status = apistructs.DeploymentStatusCanceling
 	f.Runtime.Status = apistructs.RuntimeStatusHealthy
 
+	defer monkey.UnpatchAll()
 	updateC := recordUpdateDeployment()
 	emitC := recordEvent()
 	loggingC := recordDLog()
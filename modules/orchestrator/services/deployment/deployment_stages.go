@@ -20,7 +20,7 @@ import (
 )
 
 func (d *Deployment) DeployStageAddons(deploymentID uint64) (*apistructs.DeploymentCreateResponseDTO, error) {
-	fsm := NewFSMContext(deploymentID, d.db, d.evMgr, d.bdl, d.addon, d.migration, d.encrypt, d.resource)
+	fsm := NewFSMContext(deploymentID, d.db, d.evMgr, d.bdl, d.addon, d.migration, d.encrypt, d.resource, d.rollbacker)
 	if err := fsm.Load(); err != nil {
 		return nil, errors.Wrapf(err, "failed to load fsm, deployment: %d, (%v)", deploymentID, err)
 	}
@@ -59,7 +59,7 @@ func (d *Deployment) DeployStageAddons(deploymentID uint64) (*apistructs.Deploym
 }
 
 func (d *Deployment) DeployStageServices(deploymentID uint64) (*apistructs.DeploymentCreateResponseDTO, error) {
-	fsm := NewFSMContext(deploymentID, d.db, d.evMgr, d.bdl, d.addon, d.migration, d.encrypt, d.resource)
+	fsm := NewFSMContext(deploymentID, d.db, d.evMgr, d.bdl, d.addon, d.migration, d.encrypt, d.resource, d.rollbacker)
 	if err := fsm.Load(); err != nil {
 		return nil, errors.Wrapf(err, "failed to load fsm, deployment: %d, (%v)", deploymentID, err)
 	}
@@ -90,7 +90,7 @@ func (d *Deployment) DeployStageServices(deploymentID uint64) (*apistructs.Deplo
 }
 
 func (d *Deployment) DeployStageDomains(deploymentID uint64) (*apistructs.DeploymentCreateResponseDTO, error) {
-	fsm := NewFSMContext(deploymentID, d.db, d.evMgr, d.bdl, d.addon, d.migration, d.encrypt, d.resource)
+	fsm := NewFSMContext(deploymentID, d.db, d.evMgr, d.bdl, d.addon, d.migration, d.encrypt, d.resource, d.rollbacker)
 	if err := fsm.Load(); err != nil {
 		return nil, errors.Wrapf(err, "failed to load fsm, deployment: %d, (%v)", deploymentID, err)
 	}
@@ -104,6 +104,12 @@ func (d *Deployment) DeployStageDomains(deploymentID uint64) (*apistructs.Deploy
 				break
 			}
 			fallthrough
+		case apistructs.DeploymentPhaseVerify:
+			err = fsm.continuePhaseVerify()
+			if err != nil {
+				break
+			}
+			fallthrough
 		case apistructs.DeploymentPhaseCompleted:
 			err = fsm.continuePhaseCompleted()
 			if err != nil {
@@ -0,0 +1,267 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package deployment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/modules/orchestrator/events"
+	"github.com/erda-project/erda/modules/pkg/user"
+)
+
+const (
+	defaultVerifyWindow    = 300 * time.Second
+	defaultVerifyAggregate = "avg"
+)
+
+// verifyConfig 解析 dice.yml meta 中的部署后验证配置, 未声明时返回 nil
+func verifyConfig(meta map[string]string) (*apistructs.DeploymentVerifyConfig, error) {
+	raw, ok := meta[apistructs.DeploymentVerifyMetaKey]
+	if !ok || strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var cfg apistructs.DeploymentVerifyConfig
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return nil, errors.Wrapf(err, "invalid meta %s", apistructs.DeploymentVerifyMetaKey)
+	}
+	for i, m := range cfg.Metrics {
+		if m.Metric == "" || m.Field == "" {
+			return nil, errors.Errorf("invalid meta %s, metric and field of metrics[%d] are required",
+				apistructs.DeploymentVerifyMetaKey, i)
+		}
+	}
+	return &cfg, nil
+}
+
+func verifyWindow(cfg *apistructs.DeploymentVerifyConfig) time.Duration {
+	if cfg.Window <= 0 {
+		return defaultVerifyWindow
+	}
+	return time.Duration(cfg.Window) * time.Second
+}
+
+// needVerify 自动回滚产生的部署单不再验证, 避免反复回滚
+func (fsm *DeployFSMContext) needVerify() (bool, error) {
+	if fsm.Deployment.Extra.RollbackFrom != 0 {
+		return false, nil
+	}
+	cfg, err := verifyConfig(fsm.Spec.Meta)
+	if err != nil {
+		return false, err
+	}
+	return cfg != nil, nil
+}
+
+func (fsm *DeployFSMContext) continuePhaseVerify() error {
+	if fsm.Deployment.Status != apistructs.DeploymentStatusDeploying ||
+		fsm.Deployment.Phase != apistructs.DeploymentPhaseVerify {
+		return nil
+	}
+	cfg, err := verifyConfig(fsm.Spec.Meta)
+	if err != nil {
+		return fsm.failDeploy(err)
+	}
+	if cfg == nil {
+		return fsm.completeVerify()
+	}
+	now := time.Now()
+	if fsm.Deployment.Extra.VerifyStartAt == nil {
+		fsm.d.Log(fmt.Sprintf("start post-deploy verification, window: %s", verifyWindow(cfg)))
+		fsm.Deployment.Extra.VerifyStartAt = &now
+		return fsm.db.UpdateDeployment(fsm.Deployment)
+	}
+	start := *fsm.Deployment.Extra.VerifyStartAt
+
+	if !cfg.DisableHealthCheck {
+		sg, err := fsm.getServiceGroup()
+		if err != nil {
+			return errors.Wrap(err, "failed to get service status")
+		}
+		if sg.Status != apistructs.StatusReady && sg.Status != apistructs.StatusHealthy {
+			return fsm.failVerify(cfg, fmt.Sprintf("service status is %s", sg.Status))
+		}
+	}
+	// 查询失败或窗口内暂无数据的指标无法证明部署健康, 窗口结束时仍如此则验证不通过
+	var inconclusive []string
+	for _, m := range cfg.Metrics {
+		value, ok, err := fsm.queryVerifyMetric(m, start, now)
+		if err != nil {
+			fsm.d.Log(fmt.Sprintf("failed to query metric %s, (%v)", m.Name, err))
+			inconclusive = append(inconclusive, m.Name)
+			continue
+		}
+		if !ok {
+			inconclusive = append(inconclusive, m.Name)
+			continue
+		}
+		fsm.d.Log(fmt.Sprintf(" * metric %s: %v, threshold: %v", m.Name, value, m.Threshold))
+		if value > m.Threshold {
+			return fsm.failVerify(cfg, fmt.Sprintf("metric %s is %v, exceeds threshold %v", m.Name, value, m.Threshold))
+		}
+	}
+
+	if now.Sub(start) < verifyWindow(cfg) {
+		return nil
+	}
+	if len(inconclusive) > 0 {
+		return fsm.failVerify(cfg, fmt.Sprintf("no data of metric %s in window", strings.Join(inconclusive, ", ")))
+	}
+	fsm.d.Log("post-deploy verification passed")
+	fsm.Deployment.Extra.VerifyEndAt = &now
+	return fsm.completeVerify()
+}
+
+// completeVerify 验证结束后直接完成部署, 流水线部署不会再由 orchestrator 推进 Completed 阶段
+func (fsm *DeployFSMContext) completeVerify() error {
+	if err := fsm.pushOnPhase(apistructs.DeploymentPhaseCompleted); err != nil {
+		return err
+	}
+	return fsm.continuePhaseCompleted()
+}
+
+// queryVerifyMetric 查询观察窗口内指标的聚合值, ok 为 false 表示窗口内暂无数据
+func (fsm *DeployFSMContext) queryVerifyMetric(m apistructs.DeploymentVerifyMetric, start, end time.Time) (float64, bool, error) {
+	agg := m.Aggregate
+	if agg == "" {
+		agg = defaultVerifyAggregate
+	}
+	params := url.Values{}
+	params.Set("start", strconv.FormatInt(start.UnixNano()/int64(time.Millisecond), 10))
+	params.Set("end", strconv.FormatInt(end.UnixNano()/int64(time.Millisecond), 10))
+	params.Set(agg, m.Field)
+	vars := map[string]string{
+		"${runtime_id}":     strconv.FormatUint(fsm.Runtime.ID, 10),
+		"${application_id}": strconv.FormatUint(fsm.Runtime.ApplicationID, 10),
+		"${workspace}":      fsm.Runtime.Workspace,
+	}
+	for k, v := range m.Filters {
+		for placeholder, value := range vars {
+			v = strings.Replace(v, placeholder, value, -1)
+		}
+		params.Set("filter_"+k, v)
+	}
+	data, err := fsm.bdl.MetricsRouting("", m.Metric, params)
+	if err != nil {
+		return 0, false, err
+	}
+	return parseMetricValue(data, agg+"."+m.Field)
+}
+
+// parseMetricValue 从指标查询结果 {"results":[{"data":[{"avg.field":{"data":1}}]}]} 中取出聚合值
+func parseMetricValue(data interface{}, key string) (float64, bool, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return 0, false, err
+	}
+	var result struct {
+		Results []struct {
+			Data []map[string]struct {
+				Data *float64 `json:"data"`
+			} `json:"data"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return 0, false, errors.Wrap(err, "unexpected metric result")
+	}
+	for _, r := range result.Results {
+		for _, d := range r.Data {
+			if v, ok := d[key]; ok && v.Data != nil {
+				return *v.Data, true, nil
+			}
+		}
+	}
+	return 0, false, nil
+}
+
+// failVerify 验证不通过, 部署失败并按配置自动回滚
+func (fsm *DeployFSMContext) failVerify(cfg *apistructs.DeploymentVerifyConfig, reason string) error {
+	now := time.Now()
+	fsm.Deployment.Extra.VerifyEndAt = &now
+	if err := fsm.failDeploy(errors.Errorf("post-deploy verification failed, %s", reason)); err != nil {
+		return err
+	}
+	if cfg.DisableRollback {
+		return nil
+	}
+	return fsm.autoRollback(reason)
+}
+
+// autoRollback 回滚到上一次成功的部署, 与用户发起的回滚一样经过封网, 冻结窗口与审批检查,
+// 回滚部署单没有流水线推进, 因此交由 orchestrator 推进
+func (fsm *DeployFSMContext) autoRollback(reason string) error {
+	if fsm.rollbacker == nil {
+		fsm.d.Log("auto rollback is not supported")
+		return nil
+	}
+	successes, err := fsm.db.FindSuccessfulDeployments(fsm.Runtime.ID, 1)
+	if err != nil {
+		return err
+	}
+	if len(successes) == 0 {
+		fsm.d.Log("no successful deployment to rollback to")
+		return nil
+	}
+	rollbackTo := successes[0]
+	resp, err := fsm.rollbacker.Rollback(user.ID(fsm.Deployment.Operator), fsm.Runtime.OrgID, fsm.Runtime.ID, rollbackTo.ID)
+	if err != nil {
+		fsm.d.Log(fmt.Sprintf("failed to auto rollback to deployment %d, (%v)", rollbackTo.ID, err))
+		return err
+	}
+	deployment, err := fsm.db.GetDeployment(resp.DeploymentID)
+	if err != nil {
+		return err
+	}
+	deployment.SkipPushByOrch = false
+	deployment.Extra.RollbackFrom = fsm.Deployment.ID
+	if err := fsm.db.UpdateDeployment(deployment); err != nil {
+		return err
+	}
+	fsm.d.Log(fmt.Sprintf("auto rollback to deployment %d, new deployment: %d, status: %s",
+		rollbackTo.ID, deployment.ID, deployment.Status))
+	fsm.Deployment.Extra.RollbackTo = deployment.ID
+	if err := fsm.db.UpdateDeployment(fsm.Deployment); err != nil {
+		return err
+	}
+
+	fsm.evMgr.EmitEvent(&events.RuntimeEvent{
+		EventName:  events.RuntimeDeployRollback,
+		Operator:   fsm.Deployment.Operator,
+		Runtime:    dbclient.ConvertRuntimeDTO(fsm.Runtime, fsm.App),
+		Deployment: fsm.Deployment.Convert(),
+	})
+
+	if err := fsm.bdl.CreateMboxNotify("notify.deployrollback.auto.markdown_template",
+		map[string]string{
+			"projectName":  fsm.App.ProjectName,
+			"appName":      fsm.App.Name,
+			"runtimeName":  fsm.Runtime.Name,
+			"deploymentId": strconv.FormatUint(fsm.Deployment.ID, 10),
+			"rollbackTo":   strconv.FormatUint(rollbackTo.ID, 10),
+			"reason":       reason,
+		},
+		"zh-CN", fsm.Runtime.OrgID, []string{fsm.Deployment.Operator}); err != nil {
+		logrus.Errorf("failed to notify auto rollback of deployment %d: %v", fsm.Deployment.ID, err)
+	}
+	return nil
+}
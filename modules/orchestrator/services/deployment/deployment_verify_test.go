@@ -0,0 +1,239 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package deployment
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/bundle"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/modules/orchestrator/events"
+	"github.com/erda-project/erda/modules/orchestrator/services/addon"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/dbengine"
+	"github.com/erda-project/erda/pkg/discover"
+	"github.com/erda-project/erda/pkg/httpclient"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func TestVerifyConfig(t *testing.T) {
+	cfg, err := verifyConfig(map[string]string{})
+	assert.Nil(t, err)
+	assert.Nil(t, cfg)
+
+	cfg, err = verifyConfig(map[string]string{
+		apistructs.DeploymentVerifyMetaKey: `{"metrics":[{"name":"latency","metric":"application_http","field":"elapsed_mean","threshold":500}]}`,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(cfg.Metrics))
+	assert.Equal(t, 300*time.Second, verifyWindow(cfg))
+
+	_, err = verifyConfig(map[string]string{
+		apistructs.DeploymentVerifyMetaKey: `{"metrics":[{"name":"latency"}]}`,
+	})
+	assert.NotNil(t, err)
+}
+
+func TestParseMetricValue(t *testing.T) {
+	var data interface{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"results":[{"name":"application_http","data":[{"avg.elapsed_mean":{"agg":"avg","data":612.5}}]}]}`), &data))
+
+	v, ok, err := parseMetricValue(data, "avg.elapsed_mean")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 612.5, v)
+
+	_, ok, err = parseMetricValue(data, "max.elapsed_mean")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+// fakeRollbacker 记录回滚请求并创建回滚部署单, 与 runtime 回滚一样由流水线推进
+type fakeRollbacker struct {
+	db         *dbclient.DBClient
+	rollbackTo uint64
+}
+
+func (r *fakeRollbacker) Rollback(operator user.ID, orgID uint64, runtimeID uint64, deploymentID uint64) (
+	*apistructs.DeploymentCreateResponseDTO, error) {
+	r.rollbackTo = deploymentID
+	deployment := dbclient.Deployment{
+		RuntimeId:      runtimeID,
+		Status:         apistructs.DeploymentStatusWaiting,
+		Phase:          apistructs.DeploymentPhaseInit,
+		Operator:       operator.String(),
+		Type:           "REDEPLOY",
+		SkipPushByOrch: true,
+	}
+	if err := r.db.CreateDeployment(&deployment); err != nil {
+		return nil, err
+	}
+	return &apistructs.DeploymentCreateResponseDTO{DeploymentID: deployment.ID, RuntimeID: runtimeID}, nil
+}
+
+// newTestVerifyDeployment 返回基于内存 sqlite 和 fake 平台服务的部署服务, 以及一个处于 Register 阶段的流水线部署单,
+// metric 为 fake 监控返回的指标值, 为 nil 时无数据
+func newTestVerifyDeployment(t *testing.T, metric *float64) (*Deployment, *dbclient.DBClient, *dbclient.Deployment, *fakeRollbacker) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	assert.NoError(t, db.AutoMigrate(&dbclient.Deployment{}, &dbclient.Runtime{}, &dbclient.RuntimeDomain{}).Error)
+	client := &dbclient.DBClient{DBEngine: &dbengine.DBEngine{DB: db}}
+
+	platform := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/clusters/"):
+			w.Write([]byte(`{"success":true,"data":{"name":"test"}}`))
+		case strings.HasPrefix(r.URL.Path, "/api/applications/"):
+			w.Write([]byte(`{"success":true,"data":{"id":1,"name":"app","projectName":"project"}}`))
+		case strings.HasSuffix(r.URL.Path, "/actions/get-ns-info"):
+			w.Write([]byte(`{"success":true,"data":{"enabled":false}}`))
+		case r.URL.Path == "/api/servicegroup":
+			w.Write([]byte(`{"success":true,"data":{"status":"Healthy"}}`))
+		case strings.HasPrefix(r.URL.Path, "/api/metrics/"):
+			if metric == nil {
+				w.Write([]byte(`{"data":{"results":[{"data":[]}]}}`))
+				return
+			}
+			b, _ := json.Marshal(map[string]interface{}{"data": map[string]interface{}{"results": []interface{}{
+				map[string]interface{}{"data": []interface{}{
+					map[string]interface{}{"avg.elapsed_mean": map[string]interface{}{"data": *metric}},
+				}},
+			}}})
+			w.Write(b)
+		default:
+			w.Write([]byte(`{"success":true}`))
+		}
+	}))
+	t.Cleanup(platform.Close)
+	addr := strings.TrimPrefix(platform.URL, "http://")
+	for _, env := range []string{discover.EnvCMDB, discover.EnvScheduler, discover.EnvMonitor, discover.EnvHepa, discover.EnvCollector, discover.EnvEventBox} {
+		t.Setenv(env, addr)
+	}
+	bdl := bundle.New(bundle.WithCMDB(), bundle.WithScheduler(), bundle.WithMonitor(), bundle.WithHepa(),
+		bundle.WithCollector(), bundle.WithEventBox(), bundle.WithHTTPClient(httpclient.New()))
+
+	runtime := &dbclient.Runtime{Name: "master", ApplicationID: 1, Workspace: "PROD", ProjectID: 1, Creator: "1",
+		OrgID: 1, ClusterName: "test", ScheduleName: dbclient.ScheduleName{Namespace: "services", Name: "test"}}
+	assert.NoError(t, client.CreateRuntime(runtime))
+	assert.NoError(t, client.CreateDeployment(&dbclient.Deployment{RuntimeId: runtime.ID, Operator: "1",
+		Status: apistructs.DeploymentStatusOK, Dice: "{}"}))
+
+	dice, err := json.Marshal(diceyml.Object{Meta: map[string]string{
+		apistructs.DeploymentVerifyMetaKey: `{"window":60,"metrics":[{"name":"latency","metric":"application_http","field":"elapsed_mean","threshold":500}]}`,
+	}})
+	assert.NoError(t, err)
+	deployment := &dbclient.Deployment{RuntimeId: runtime.ID, Operator: "1", Dice: string(dice),
+		Status: apistructs.DeploymentStatusDeploying, Phase: apistructs.DeploymentPhaseRegister, SkipPushByOrch: true}
+	assert.NoError(t, client.CreateDeployment(deployment))
+
+	rollbacker := &fakeRollbacker{db: client}
+	return New(
+		WithDBClient(client),
+		WithEventManager(events.NewEventManager(100, nil, client, bdl)),
+		WithBundle(bdl),
+		WithAddon(addon.New()),
+		WithRollbacker(rollbacker),
+	), client, deployment, rollbacker
+}
+
+// expireVerifyWindow 将验证开始时间前移, 使观察窗口结束
+func expireVerifyWindow(t *testing.T, db *dbclient.DBClient, deploymentID uint64) {
+	deployment, err := db.GetDeployment(deploymentID)
+	assert.NoError(t, err)
+	start := time.Now().Add(-time.Hour)
+	deployment.Extra.VerifyStartAt = &start
+	assert.NoError(t, db.UpdateDeployment(deployment))
+}
+
+func TestPipelineDeployVerify(t *testing.T) {
+	latency := 100.0
+	d, db, deployment, rollbacker := newTestVerifyDeployment(t, &latency)
+
+	// pipeline deploys domains, verification starts and the deployment keeps deploying
+	_, err := d.DeployStageDomains(deployment.ID)
+	assert.NoError(t, err)
+	current, err := db.GetDeployment(deployment.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.DeploymentStatusDeploying, current.Status)
+	assert.Equal(t, apistructs.DeploymentPhaseVerify, current.Phase)
+	assert.NotNil(t, current.Extra.VerifyStartAt)
+
+	// pushed by orchestrator although the deployment is pushed by pipeline, window not end yet
+	assert.NoError(t, d.ContinueDeploy(deployment.ID))
+	current, err = db.GetDeployment(deployment.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.DeploymentStatusDeploying, current.Status)
+
+	expireVerifyWindow(t, db, deployment.ID)
+	assert.NoError(t, d.ContinueDeploy(deployment.ID))
+	current, err = db.GetDeployment(deployment.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.DeploymentStatusOK, current.Status)
+	assert.Equal(t, apistructs.DeploymentPhaseCompleted, current.Phase)
+	assert.NotNil(t, current.Extra.VerifyEndAt)
+	assert.Zero(t, rollbacker.rollbackTo)
+}
+
+func TestPipelineDeployVerifyRollback(t *testing.T) {
+	// no metric data in window is inconclusive, verification fails when window ends
+	d, db, deployment, rollbacker := newTestVerifyDeployment(t, nil)
+
+	_, err := d.DeployStageDomains(deployment.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, d.ContinueDeploy(deployment.ID))
+	current, err := db.GetDeployment(deployment.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.DeploymentStatusDeploying, current.Status)
+
+	expireVerifyWindow(t, db, deployment.ID)
+	assert.NoError(t, d.ContinueDeploy(deployment.ID))
+	current, err = db.GetDeployment(deployment.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.DeploymentStatusFailed, current.Status)
+	assert.Contains(t, current.FailCause, "no data of metric latency")
+
+	// rollback through the rollback entry, the rollback deployment is pushed by orchestrator and not verified
+	assert.Equal(t, uint64(1), rollbacker.rollbackTo)
+	assert.NotZero(t, current.Extra.RollbackTo)
+	rollback, err := db.GetDeployment(current.Extra.RollbackTo)
+	assert.NoError(t, err)
+	assert.False(t, rollback.SkipPushByOrch)
+	assert.Equal(t, deployment.ID, rollback.Extra.RollbackFrom)
+}
+
+func TestPipelineDeployVerifyExceedsThreshold(t *testing.T) {
+	latency := 612.5
+	d, db, deployment, rollbacker := newTestVerifyDeployment(t, &latency)
+
+	_, err := d.DeployStageDomains(deployment.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, d.ContinueDeploy(deployment.ID))
+	current, err := db.GetDeployment(deployment.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.DeploymentStatusFailed, current.Status)
+	assert.Contains(t, current.FailCause, "exceeds threshold")
+	assert.Equal(t, uint64(1), rollbacker.rollbackTo)
+}
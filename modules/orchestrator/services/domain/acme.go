@@ -0,0 +1,190 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package domain
+
+import (
+	gocontext "context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme"
+)
+
+// challengeSolver 负责对外提供 ACME HTTP-01 校验内容
+type challengeSolver interface {
+	// Present 使 http://<domain>/.well-known/acme-challenge/<token> 返回 keyAuth
+	Present(domain, token, keyAuth string) error
+	// CleanUp 撤销校验内容
+	CleanUp(domain, token string) error
+}
+
+// issuedCertificate ACME 签发的证书
+type issuedCertificate struct {
+	CertPEM string
+	KeyPEM  string
+	Leaf    *x509.Certificate
+}
+
+// acmeIssuer 通过 ACME(RFC 8555) 协议签发证书, 兼容 Let's Encrypt 及 pebble
+type acmeIssuer struct {
+	client *acme.Client
+}
+
+func newAcmeIssuer(directoryURL string, key crypto.Signer, insecureSkipVerify bool) *acmeIssuer {
+	hc := &http.Client{Timeout: 30 * time.Second}
+	if insecureSkipVerify {
+		hc.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+	return &acmeIssuer{
+		client: &acme.Client{
+			Key:          key,
+			DirectoryURL: directoryURL,
+			HTTPClient:   hc,
+			UserAgent:    "erda-orchestrator",
+		},
+	}
+}
+
+// register 注册 ACME 账号并返回账号 URI, 账号已存在时直接返回已有账号
+func (i *acmeIssuer) register(ctx gocontext.Context, email string) (string, error) {
+	account := &acme.Account{}
+	if email != "" {
+		account.Contact = []string{"mailto:" + email}
+	}
+	registered, err := i.client.Register(ctx, account, acme.AcceptTOS)
+	if err == acme.ErrAccountAlreadyExists {
+		registered, err = i.client.GetReg(ctx, "")
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to register acme account")
+	}
+	return registered.URI, nil
+}
+
+// issue 为域名签发证书, 通过 solver 完成 HTTP-01 校验
+func (i *acmeIssuer) issue(ctx gocontext.Context, domain string, solver challengeSolver) (*issuedCertificate, error) {
+	order, err := i.client.AuthorizeOrder(ctx, acme.DomainIDs(domain))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create acme order for %s", domain)
+	}
+	for _, u := range order.AuthzURLs {
+		if err := i.authorize(ctx, u, domain, solver); err != nil {
+			return nil, err
+		}
+	}
+	if order, err = i.client.WaitOrder(ctx, order.URI); err != nil {
+		return nil, errors.Wrapf(err, "acme order of %s not ready", domain)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{domain}}, key)
+	if err != nil {
+		return nil, err
+	}
+	der, _, err := i.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to finalize acme order of %s", domain)
+	}
+	return encodeCertificate(der, key)
+}
+
+func (i *acmeIssuer) authorize(ctx gocontext.Context, url, domain string, solver challengeSolver) error {
+	authz, err := i.client.GetAuthorization(ctx, url)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get acme authorization of %s", domain)
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+	var challenge *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == "http-01" {
+			challenge = c
+			break
+		}
+	}
+	if challenge == nil {
+		return errors.Errorf("acme server offers no http-01 challenge for %s", domain)
+	}
+	keyAuth, err := i.client.HTTP01ChallengeResponse(challenge.Token)
+	if err != nil {
+		return err
+	}
+	if err := solver.Present(domain, challenge.Token, keyAuth); err != nil {
+		return errors.Wrapf(err, "failed to present http-01 challenge of %s", domain)
+	}
+	defer func() {
+		if err := solver.CleanUp(domain, challenge.Token); err != nil {
+			logrus.Warnf("failed to clean up http-01 challenge of %s, (%v)", domain, err)
+		}
+	}()
+	if _, err := i.client.Accept(ctx, challenge); err != nil {
+		return errors.Wrapf(err, "failed to accept http-01 challenge of %s", domain)
+	}
+	if _, err := i.client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return errors.Wrapf(err, "http-01 challenge of %s failed", domain)
+	}
+	return nil
+}
+
+// encodeCertificate 将 DER 证书链及私钥编码为 PEM
+func encodeCertificate(der [][]byte, key *ecdsa.PrivateKey) (*issuedCertificate, error) {
+	if len(der) == 0 {
+		return nil, errors.New("acme server returned empty certificate chain")
+	}
+	leaf, err := x509.ParseCertificate(der[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid certificate returned by acme server")
+	}
+	var certPEM []byte
+	for _, b := range der {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: b})...)
+	}
+	keyPEM, err := encodeECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &issuedCertificate{CertPEM: string(certPEM), KeyPEM: keyPEM, Leaf: leaf}, nil
+}
+
+func encodeECPrivateKey(key *ecdsa.PrivateKey) (string, error) {
+	b, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b})), nil
+}
+
+func decodeECPrivateKey(s string) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, errors.New("invalid pem encoded ec private key")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package domain
+
+import (
+	gocontext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeCertificate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	issued, err := encodeCertificate([][]byte{der, der}, key)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"www.example.com"}, issued.Leaf.DNSNames)
+	assert.Equal(t, 2, strings.Count(issued.CertPEM, "BEGIN CERTIFICATE"))
+	_, err = tls.X509KeyPair([]byte(issued.CertPEM), []byte(issued.KeyPEM))
+	assert.NoError(t, err)
+
+	decoded, err := decodeECPrivateKey(issued.KeyPEM)
+	require.NoError(t, err)
+	assert.Equal(t, key.D, decoded.D)
+
+	_, err = encodeCertificate(nil, key)
+	assert.Error(t, err)
+	_, err = decodeECPrivateKey("invalid")
+	assert.Error(t, err)
+}
+
+type memorySolver struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func (s *memorySolver) Present(domain, token, keyAuth string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token] = keyAuth
+	return nil
+}
+
+func (s *memorySolver) CleanUp(domain, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, token)
+	return nil
+}
+
+func (s *memorySolver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keyAuth, ok := s.tokens[strings.TrimPrefix(r.URL.Path, AcmeChallengePath)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte(keyAuth))
+}
+
+// TestAcmeIssuerPebble issues a certificate from a local pebble server, e.g.
+//
+//	docker run -p 14000:14000 -e PEBBLE_VA_ALWAYS_VALID=1 letsencrypt/pebble
+//	ACME_TEST_DIRECTORY_URL=https://localhost:14000/dir go test -run TestAcmeIssuerPebble
+//
+// Without PEBBLE_VA_ALWAYS_VALID, pebble must resolve ACME_TEST_DOMAIN to this host and reach ACME_TEST_HTTP01_ADDR.
+func TestAcmeIssuerPebble(t *testing.T) {
+	directoryURL := os.Getenv("ACME_TEST_DIRECTORY_URL")
+	if directoryURL == "" {
+		t.Skip("ACME_TEST_DIRECTORY_URL not set")
+	}
+	domain := os.Getenv("ACME_TEST_DOMAIN")
+	if domain == "" {
+		domain = "erda.example.com"
+	}
+	addr := os.Getenv("ACME_TEST_HTTP01_ADDR")
+	if addr == "" {
+		addr = ":5002"
+	}
+
+	solver := &memorySolver{tokens: make(map[string]string)}
+	srv := &http.Server{Addr: addr, Handler: solver}
+	go srv.ListenAndServe()
+	defer srv.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	issuer := newAcmeIssuer(directoryURL, key, true)
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 2*time.Minute)
+	defer cancel()
+
+	uri, err := issuer.register(ctx, "admin@example.com")
+	require.NoError(t, err)
+	assert.NotEmpty(t, uri)
+	again, err := issuer.register(ctx, "admin@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, uri, again)
+
+	issued, err := issuer.issue(ctx, domain, solver)
+	require.NoError(t, err)
+	assert.Equal(t, []string{domain}, issued.Leaf.DNSNames)
+	assert.Empty(t, solver.tokens)
+	_, err = tls.X509KeyPair([]byte(issued.CertPEM), []byte(issued.KeyPEM))
+	assert.NoError(t, err)
+}
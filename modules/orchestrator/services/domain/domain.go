@@ -14,6 +14,8 @@
 package domain
 
 import (
+	"fmt"
+
 	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/bundle"
 	"github.com/erda-project/erda/modules/orchestrator/dbclient"
@@ -86,7 +88,23 @@ func (d *Domain) List(userID user.ID, orgID uint64, runtimeID uint64) (*apistruc
 	if err := dc.load(runtimeID); err != nil {
 		return nil, err
 	}
-	return dc.GroupDomains(), nil
+	group := dc.GroupDomains()
+	certs, err := d.db.FindDomainCertificatesByRuntimeID(runtimeID)
+	if err != nil {
+		return nil, apierrors.ErrListDomain.InternalError(err)
+	}
+	certMap := make(map[string]*dbclient.DomainCertificate, len(certs))
+	for i := range certs {
+		certMap[certs[i].Domain] = &certs[i]
+	}
+	for _, domains := range *group {
+		for _, item := range domains {
+			if cert, ok := certMap[item.Domain]; ok {
+				item.Certificate = convertDomainCertificate(cert)
+			}
+		}
+	}
+	return group, nil
 }
 
 // Update 更新域名
@@ -114,3 +132,21 @@ func (d *Domain) Update(userID user.ID, orgID uint64, runtimeID uint64, group *a
 	}
 	return dc.UpdateDomains(group)
 }
+
+// GenerateDefaults 为 runtime 的 endpoint 服务生成默认域名, 已存在的默认域名保持不变
+func (d *Domain) GenerateDefaults(runtimeID uint64, label string, serviceNames []string) ([]string, error) {
+	dc := newCtx(d.db, d.bdl)
+	if err := dc.load(runtimeID); err != nil {
+		return nil, err
+	}
+	var domains []string
+	for _, name := range serviceNames {
+		domain, err := d.db.GetDefaultDomainOrCreate(runtimeID, name,
+			fmt.Sprintf("%s-%s-%d-app%s", name, label, runtimeID, dc.RootDomain))
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, domain)
+	}
+	return domains, nil
+}
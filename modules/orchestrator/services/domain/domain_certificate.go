@@ -0,0 +1,478 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package domain
+
+import (
+	gocontext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/bundle"
+	"github.com/erda-project/erda/modules/orchestrator/conf"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/modules/orchestrator/events"
+	"github.com/erda-project/erda/modules/orchestrator/services/apierrors"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+const (
+	// AcmeChallengePath HTTP-01 校验路径前缀
+	AcmeChallengePath = "/.well-known/acme-challenge/"
+
+	// 单次签发的超时时间, 超过该时间仍处于签发中的记录视为中断
+	acmeIssueTimeout = 10 * time.Minute
+	// 续期失败后重试的最小间隔, 避免触发 ACME 服务的签发频率限制
+	acmeRetryInterval = 6 * time.Hour
+	// 同一证书两次过期告警的最小间隔
+	certExpiryAlertInterval = 24 * time.Hour
+	// 定时续期时同时签发的证书数量上限
+	acmeRenewConcurrency = 5
+)
+
+// IssueCertificate 通过 ACME 为 runtime 的自定义域名签发证书, 签发异步进行
+func (d *Domain) IssueCertificate(userID user.ID, orgID uint64, runtimeID uint64,
+	req *apistructs.DomainCertificateIssueRequest) (*apistructs.DomainCertificate, error) {
+	runtime, err := d.db.GetRuntime(runtimeID)
+	if err != nil {
+		return nil, apierrors.ErrIssueDomainCertificate.InternalError(err)
+	}
+	access, err := d.checkRuntimePermission(userID, runtime, apistructs.OperateAction)
+	if err != nil {
+		return nil, apierrors.ErrIssueDomainCertificate.InternalError(err)
+	}
+	if !access {
+		return nil, apierrors.ErrIssueDomainCertificate.AccessDenied()
+	}
+	if req.Domain == "" {
+		return nil, apierrors.ErrIssueDomainCertificate.MissingParameter("domain")
+	}
+	domains, err := d.db.FindDomains([]string{req.Domain})
+	if err != nil {
+		return nil, apierrors.ErrIssueDomainCertificate.InternalError(err)
+	}
+	if len(domains) == 0 || domains[0].RuntimeId != runtimeID {
+		return nil, apierrors.ErrIssueDomainCertificate.InvalidParameter(
+			fmt.Sprintf("域名 %s 不属于 Runtime %d", req.Domain, runtimeID))
+	}
+	if domains[0].DomainType != CustomDomainType {
+		return nil, apierrors.ErrIssueDomainCertificate.InvalidParameter(
+			fmt.Sprintf("域名 %s 为默认域名, 使用集群泛域名证书", req.Domain))
+	}
+
+	cert, err := d.db.GetDomainCertificateByDomain(req.Domain)
+	if err != nil {
+		return nil, apierrors.ErrIssueDomainCertificate.InternalError(err)
+	}
+	if cert != nil && cert.Status == apistructs.DomainCertificateIssuing {
+		return nil, apierrors.ErrIssueDomainCertificate.InvalidState(fmt.Sprintf("域名 %s 的证书正在签发中", req.Domain))
+	}
+	if cert == nil {
+		cert = &dbclient.DomainCertificate{Domain: req.Domain, Status: apistructs.DomainCertificateIssuing}
+	}
+	cert.RuntimeID = runtimeID
+	cert.OrgID = runtime.OrgID
+	cert.Operator = userID.String()
+	if cert.ID == 0 {
+		// 域名唯一索引保证并发创建时只有一个成功
+		if err := d.db.CreateDomainCertificate(cert); err != nil {
+			return nil, apierrors.ErrIssueDomainCertificate.InternalError(err)
+		}
+	} else {
+		claimed, err := d.db.ClaimDomainCertificate(cert)
+		if err != nil {
+			return nil, apierrors.ErrIssueDomainCertificate.InternalError(err)
+		}
+		if !claimed {
+			return nil, apierrors.ErrIssueDomainCertificate.InvalidState(fmt.Sprintf("域名 %s 的证书正在签发中", req.Domain))
+		}
+	}
+
+	go d.issueCertificate(cert)
+
+	return convertDomainCertificate(cert), nil
+}
+
+// ListCertificates 查询 runtime 域名绑定的证书
+func (d *Domain) ListCertificates(userID user.ID, orgID uint64, runtimeID uint64) ([]apistructs.DomainCertificate, error) {
+	runtime, err := d.db.GetRuntime(runtimeID)
+	if err != nil {
+		return nil, apierrors.ErrListDomainCertificate.InternalError(err)
+	}
+	access, err := d.checkRuntimePermission(userID, runtime, apistructs.GetAction)
+	if err != nil {
+		return nil, apierrors.ErrListDomainCertificate.InternalError(err)
+	}
+	if !access {
+		return nil, apierrors.ErrListDomainCertificate.AccessDenied()
+	}
+	certs, err := d.db.FindDomainCertificatesByRuntimeID(runtimeID)
+	if err != nil {
+		return nil, apierrors.ErrListDomainCertificate.InternalError(err)
+	}
+	result := make([]apistructs.DomainCertificate, 0, len(certs))
+	for i := range certs {
+		result = append(result, *convertDomainCertificate(&certs[i]))
+	}
+	return result, nil
+}
+
+// ChallengeKeyAuth 返回 HTTP-01 token 对应的 key authorization, 不存在时返回空
+func (d *Domain) ChallengeKeyAuth(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	cert, err := d.db.GetDomainCertificateByChallengeToken(token)
+	if err != nil || cert == nil {
+		return "", err
+	}
+	return cert.ChallengeKeyAuth, nil
+}
+
+// CertificateSync 清理中断的签发, 续期即将过期的证书并发送过期告警
+func (d *Domain) CertificateSync() {
+	now := time.Now()
+
+	if err := d.db.FailStaleIssuingDomainCertificates(now.Add(-2*acmeIssueTimeout), "certificate issuance interrupted"); err != nil {
+		logrus.Errorf("failed to fail stale issuing domain certificates, (%v)", err)
+	}
+
+	renewals, err := d.db.FindDomainCertificatesExpireBefore(now.AddDate(0, 0, conf.AcmeRenewBeforeDays()))
+	if err != nil {
+		logrus.Errorf("failed to find domain certificates to renew, (%v)", err)
+	}
+	// 单个签发耗时可达 acmeIssueTimeout, 并发续期, 同时限制并发数以免触发 ACME 服务的频率限制
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, acmeRenewConcurrency)
+	for i := range renewals {
+		cert := &renewals[i]
+		if cert.Status == apistructs.DomainCertificateFailed && now.Sub(cert.UpdatedAt) < acmeRetryInterval {
+			continue
+		}
+		bound, err := d.certificateDomainBound(cert)
+		if err != nil {
+			logrus.Errorf("failed to check domain %s of certificate, (%v)", cert.Domain, err)
+			continue
+		}
+		if !bound {
+			continue
+		}
+		logrus.Infof("renewing certificate of domain %s which expires at %s", cert.Domain, cert.NotAfter)
+		claimed, err := d.db.ClaimDomainCertificate(cert)
+		if err != nil {
+			logrus.Errorf("failed to claim domain certificate of %s, (%v)", cert.Domain, err)
+			continue
+		}
+		if !claimed {
+			// 其他实例已开始续期
+			continue
+		}
+		wg.Add(1)
+		limit <- struct{}{}
+		go func() {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			d.issueCertificate(cert)
+		}()
+	}
+	wg.Wait()
+
+	expirings, err := d.db.FindDomainCertificatesExpireBefore(now.AddDate(0, 0, conf.CertExpiryAlertDays()))
+	if err != nil {
+		logrus.Errorf("failed to find expiring domain certificates, (%v)", err)
+	}
+	for i := range expirings {
+		cert := &expirings[i]
+		if !needExpiryAlert(cert, now) {
+			continue
+		}
+		claimed, err := d.db.ClaimDomainCertificateAlert(cert, now)
+		if err != nil {
+			logrus.Errorf("failed to claim alert of domain certificate %s, (%v)", cert.Domain, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if err := d.alertCertificateExpiring(cert); err != nil {
+			logrus.Errorf("failed to alert expiring certificate of %s, (%v)", cert.Domain, err)
+		}
+	}
+}
+
+func (d *Domain) checkRuntimePermission(userID user.ID, runtime *dbclient.Runtime, action string) (bool, error) {
+	perm, err := d.bdl.CheckPermission(&apistructs.PermissionCheckRequest{
+		UserID:   userID.String(),
+		Scope:    apistructs.AppScope,
+		ScopeID:  runtime.ApplicationID,
+		Resource: "runtime-" + strutil.ToLower(runtime.Workspace),
+		Action:   action,
+	})
+	if err != nil {
+		return false, err
+	}
+	return perm.Access, nil
+}
+
+// certificateDomainBound 检查证书对应的域名是否仍属于该 runtime, 不再属于时删除证书记录
+func (d *Domain) certificateDomainBound(cert *dbclient.DomainCertificate) (bool, error) {
+	domains, err := d.db.FindDomains([]string{cert.Domain})
+	if err != nil {
+		return false, err
+	}
+	if len(domains) > 0 && domains[0].RuntimeId == cert.RuntimeID {
+		return true, nil
+	}
+	logrus.Infof("domain %s no longer belongs to runtime %d, drop its certificate record", cert.Domain, cert.RuntimeID)
+	return false, d.db.DeleteDomainCertificate(cert.ID)
+}
+
+// issueCertificate 签发证书并保存到证书服务, 结果记录到 cert
+func (d *Domain) issueCertificate(cert *dbclient.DomainCertificate) {
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), acmeIssueTimeout)
+	defer cancel()
+
+	if err := d.doIssueCertificate(ctx, cert); err != nil {
+		logrus.Errorf("failed to issue certificate of domain %s, (%v)", cert.Domain, err)
+		cert.Status = apistructs.DomainCertificateFailed
+		cert.Message = err.Error()
+	}
+	cert.ChallengeToken, cert.ChallengeKeyAuth = "", ""
+	if err := d.db.UpdateDomainCertificate(cert); err != nil {
+		logrus.Errorf("failed to update domain certificate of %s, (%v)", cert.Domain, err)
+	}
+}
+
+func (d *Domain) doIssueCertificate(ctx gocontext.Context, cert *dbclient.DomainCertificate) error {
+	runtime, err := d.db.GetRuntime(cert.RuntimeID)
+	if err != nil {
+		return err
+	}
+	issuer, err := d.loadAcmeIssuer(ctx)
+	if err != nil {
+		return err
+	}
+	issued, err := issuer.issue(ctx, cert.Domain, &ingressSolver{db: d.db, bdl: d.bdl, cert: cert, clusterName: runtime.ClusterName})
+	if err != nil {
+		return err
+	}
+
+	tlsInfo := apistructs.TLSCertificateDTO{
+		Certificate: issued.CertPEM,
+		PrivateKey:  issued.KeyPEM,
+		Source:      apistructs.TLSCertificateSourceACME,
+	}
+	desc := fmt.Sprintf("ACME certificate of domain %s", cert.Domain)
+	if cert.CertificateID == 0 {
+		created, err := d.bdl.CreateCertificate(cert.Operator, &apistructs.CertificateCreateRequest{
+			OrgID:   cert.OrgID,
+			Type:    string(apistructs.TLSCertificateType),
+			Name:    "acme-" + cert.Domain,
+			Desc:    desc,
+			TLSInfo: tlsInfo,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to save certificate")
+		}
+		cert.CertificateID = created.ID
+	} else if err := d.bdl.UpdateCertificate(cert.Operator, cert.CertificateID, &apistructs.CertificateUpdateRequest{
+		Desc:    desc,
+		TLSInfo: &tlsInfo,
+	}); err != nil {
+		return errors.Wrap(err, "failed to save certificate")
+	}
+
+	cert.Issuer = issued.Leaf.Issuer.CommonName
+	cert.NotBefore = &issued.Leaf.NotBefore
+	cert.NotAfter = &issued.Leaf.NotAfter
+	cert.AlertedAt = nil
+	if err := d.bindCertificate(runtime, cert, issued); err != nil {
+		return errors.Wrap(err, "failed to bind certificate to ingress")
+	}
+	cert.Status = apistructs.DomainCertificateIssued
+	cert.Message = ""
+	return nil
+}
+
+// bindCertificate 将证书保存到 runtime 所在 namespace 的 secret, 并绑定到该域名的 ingress
+func (d *Domain) bindCertificate(runtime *dbclient.Runtime, cert *dbclient.DomainCertificate, issued *issuedCertificate) error {
+	return d.bdl.BindServiceGroupTLS(apistructs.ServiceGroupTLSRequest{
+		Namespace:   runtime.ScheduleName.Namespace,
+		Name:        runtime.ScheduleName.Name,
+		Domain:      cert.Domain,
+		Certificate: issued.CertPEM,
+		PrivateKey:  issued.KeyPEM,
+	})
+}
+
+// loadAcmeIssuer 加载当前 ACME 服务的账号, 不存在时注册新账号
+func (d *Domain) loadAcmeIssuer(ctx gocontext.Context) (*acmeIssuer, error) {
+	directoryURL := conf.AcmeDirectoryURL()
+	account, err := d.db.GetAcmeAccount(directoryURL)
+	if err != nil {
+		return nil, err
+	}
+	if account != nil {
+		key, err := decodeECPrivateKey(account.PrivateKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid acme account key of %s", directoryURL)
+		}
+		return newAcmeIssuer(directoryURL, key, conf.AcmeInsecureSkipVerify()), nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := encodeECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	issuer := newAcmeIssuer(directoryURL, key, conf.AcmeInsecureSkipVerify())
+	uri, err := issuer.register(ctx, conf.AcmeEmail())
+	if err != nil {
+		return nil, err
+	}
+	if err := d.db.CreateAcmeAccount(&dbclient.AcmeAccount{
+		DirectoryURL: directoryURL,
+		Email:        conf.AcmeEmail(),
+		AccountURI:   uri,
+		PrivateKey:   keyPEM,
+	}); err != nil {
+		return nil, err
+	}
+	return issuer, nil
+}
+
+func (d *Domain) alertCertificateExpiring(cert *dbclient.DomainCertificate) error {
+	runtime, err := d.db.GetRuntime(cert.RuntimeID)
+	if err != nil {
+		return err
+	}
+	app, err := d.bdl.GetApp(runtime.ApplicationID)
+	if err != nil {
+		return err
+	}
+	logrus.Warnf("certificate of domain %s (runtime %d) expires at %s", cert.Domain, cert.RuntimeID, cert.NotAfter)
+	d.evMgr.EmitEvent(&events.RuntimeEvent{
+		EventName:         events.RuntimeDomainCertificateExpiring,
+		Runtime:           dbclient.ConvertRuntimeDTO(runtime, app),
+		DomainCertificate: convertDomainCertificate(cert),
+	})
+	return nil
+}
+
+// needExpiryAlert 证书即将过期且最近未告警过时需要告警
+func needExpiryAlert(cert *dbclient.DomainCertificate, now time.Time) bool {
+	if cert.NotAfter == nil {
+		return false
+	}
+	return cert.AlertedAt == nil || now.Sub(*cert.AlertedAt) >= certExpiryAlertInterval
+}
+
+// ingressSolver 通过 runtime 所在集群的 ingress 将域名的 HTTP-01 校验路径转发到 orchestrator,
+// 校验内容保存在 db 中以支持多实例
+type ingressSolver struct {
+	db          *dbclient.DBClient
+	bdl         *bundle.Bundle
+	cert        *dbclient.DomainCertificate
+	clusterName string
+}
+
+func (s *ingressSolver) Present(domain, token, keyAuth string) error {
+	s.cert.ChallengeToken = token
+	s.cert.ChallengeKeyAuth = keyAuth
+	if err := s.db.UpdateDomainCertificate(s.cert); err != nil {
+		return err
+	}
+	req, err := challengeIngressRequest(conf.SelfAddr(), s.clusterName, domain, true)
+	if err != nil {
+		return err
+	}
+	return s.bdl.CreateOrUpdateComponentIngress(req)
+}
+
+func (s *ingressSolver) CleanUp(domain, token string) error {
+	req, err := challengeIngressRequest(conf.SelfAddr(), s.clusterName, domain, false)
+	if err != nil {
+		return err
+	}
+	return s.bdl.CreateOrUpdateComponentIngress(req)
+}
+
+// challengeIngressRequest 构造转发 HTTP-01 校验路径到 orchestrator 的 ingress, present 为 false 时清除 ingress
+func challengeIngressRequest(selfAddr, clusterName, domain string, present bool) (apistructs.ComponentIngressUpdateRequest, error) {
+	host, portStr, err := net.SplitHostPort(selfAddr)
+	if err != nil {
+		return apistructs.ComponentIngressUpdateRequest{}, errors.Wrapf(err, "invalid self addr %s", selfAddr)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return apistructs.ComponentIngressUpdateRequest{}, errors.Wrapf(err, "invalid self addr %s", selfAddr)
+	}
+	// orchestrator.default.svc.cluster.local => component orchestrator in namespace default
+	parts := strings.Split(host, ".")
+	var namespace string
+	if len(parts) > 1 {
+		namespace = parts[1]
+	}
+	enableTLS := false
+	req := apistructs.ComponentIngressUpdateRequest{
+		K8SNamespace:  namespace,
+		ComponentName: parts[0],
+		ComponentPort: port,
+		ClusterName:   clusterName,
+		IngressName:   "acme-challenge-" + domain,
+		RouteOptions: apistructs.RouteOptions{
+			EnableTLS: &enableTLS,
+			Annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect": "false",
+			},
+		},
+	}
+	if present {
+		req.Routes = []apistructs.IngressRoute{{Domain: domain, Path: AcmeChallengePath}}
+	}
+	return req, nil
+}
+
+func convertDomainCertificate(cert *dbclient.DomainCertificate) *apistructs.DomainCertificate {
+	return &apistructs.DomainCertificate{
+		ID:            cert.ID,
+		RuntimeID:     cert.RuntimeID,
+		Domain:        cert.Domain,
+		CertificateID: cert.CertificateID,
+		Status:        cert.Status,
+		Issuer:        cert.Issuer,
+		NotBefore:     cert.NotBefore,
+		NotAfter:      cert.NotAfter,
+		Message:       cert.Message,
+		Operator:      cert.Operator,
+		CreatedAt:     cert.CreatedAt,
+		UpdatedAt:     cert.UpdatedAt,
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package domain
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/bundle"
+	"github.com/erda-project/erda/modules/orchestrator/conf"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/pkg/dbengine"
+	"github.com/erda-project/erda/pkg/discover"
+	"github.com/erda-project/erda/pkg/httpclient"
+)
+
+func TestChallengeIngressRequest(t *testing.T) {
+	req, err := challengeIngressRequest("orchestrator.default.svc.cluster.local:8081", "terminus-dev", "www.example.com", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "orchestrator", req.ComponentName)
+	assert.Equal(t, "default", req.K8SNamespace)
+	assert.Equal(t, 8081, req.ComponentPort)
+	assert.Equal(t, "terminus-dev", req.ClusterName)
+	assert.Equal(t, "acme-challenge-www.example.com", req.IngressName)
+	assert.Equal(t, []apistructs.IngressRoute{{Domain: "www.example.com", Path: "/.well-known/acme-challenge/"}}, req.Routes)
+	assert.False(t, *req.RouteOptions.EnableTLS)
+	assert.NoError(t, req.CheckValid())
+
+	req, err = challengeIngressRequest("orchestrator:8081", "", "www.example.com", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "orchestrator", req.ComponentName)
+	assert.Equal(t, "", req.K8SNamespace)
+	assert.Empty(t, req.Routes)
+
+	_, err = challengeIngressRequest("orchestrator", "", "www.example.com", true)
+	assert.Error(t, err)
+}
+
+func TestNeedExpiryAlert(t *testing.T) {
+	now := time.Now()
+	notAfter := now.Add(7 * 24 * time.Hour)
+	recent := now.Add(-time.Hour)
+	stale := now.Add(-25 * time.Hour)
+
+	assert.False(t, needExpiryAlert(&dbclient.DomainCertificate{}, now))
+	assert.True(t, needExpiryAlert(&dbclient.DomainCertificate{NotAfter: &notAfter}, now))
+	assert.False(t, needExpiryAlert(&dbclient.DomainCertificate{NotAfter: &notAfter, AlertedAt: &recent}, now))
+	assert.True(t, needExpiryAlert(&dbclient.DomainCertificate{NotAfter: &notAfter, AlertedAt: &stale}, now))
+}
+
+func TestClaimDomainCertificate(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.AutoMigrate(&dbclient.DomainCertificate{}).Error)
+	client := &dbclient.DBClient{DBEngine: &dbengine.DBEngine{DB: db}}
+
+	now := time.Now()
+	notAfter := now.Add(24 * time.Hour)
+	assert.NoError(t, client.CreateDomainCertificate(&dbclient.DomainCertificate{
+		Domain: "issued.example.com", Status: apistructs.DomainCertificateIssued, NotAfter: &notAfter}))
+	assert.NoError(t, client.CreateDomainCertificate(&dbclient.DomainCertificate{
+		Domain: "renew-failed.example.com", Status: apistructs.DomainCertificateFailed, NotAfter: &notAfter}))
+	assert.NoError(t, client.CreateDomainCertificate(&dbclient.DomainCertificate{
+		Domain: "never-issued.example.com", Status: apistructs.DomainCertificateFailed}))
+
+	// 续期失败的证书仍在使用, 需要续期及告警
+	certs, err := client.FindDomainCertificatesExpireBefore(now.Add(48 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(certs))
+
+	// 两个实例读到同一条记录, 只有一个能开始签发
+	first, second := certs[0], certs[0]
+	claimed, err := client.ClaimDomainCertificate(&first)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, apistructs.DomainCertificateIssuing, first.Status)
+	claimed, err = client.ClaimDomainCertificate(&second)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	first, second = certs[1], certs[1]
+	claimed, err = client.ClaimDomainCertificateAlert(&first, now)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = client.ClaimDomainCertificateAlert(&second, now)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+}
+
+func TestIngressSolverCluster(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.AutoMigrate(&dbclient.DomainCertificate{}).Error)
+	client := &dbclient.DBClient{DBEngine: &dbengine.DBEngine{DB: db}}
+
+	var reqs []apistructs.ComponentIngressUpdateRequest
+	hepa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req apistructs.ComponentIngressUpdateRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		reqs = append(reqs, req)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer hepa.Close()
+	t.Setenv(discover.EnvHepa, strings.TrimPrefix(hepa.URL, "http://"))
+	t.Setenv("DICE_CLUSTER_NAME", "main")
+	conf.Load()
+
+	cert := &dbclient.DomainCertificate{Domain: "www.example.com", Status: apistructs.DomainCertificateIssuing}
+	assert.NoError(t, client.CreateDomainCertificate(cert))
+	solver := &ingressSolver{
+		db:          client,
+		bdl:         bundle.New(bundle.WithHepa(), bundle.WithHTTPClient(httpclient.New())),
+		cert:        cert,
+		clusterName: "edge",
+	}
+	assert.NoError(t, solver.Present("www.example.com", "token", "key-auth"))
+	assert.NoError(t, solver.CleanUp("www.example.com", "token"))
+
+	// 域名解析到 runtime 所在集群, 校验路径由该集群的 ingress 转发
+	assert.Equal(t, 2, len(reqs))
+	for _, req := range reqs {
+		assert.Equal(t, "edge", req.ClusterName)
+	}
+}
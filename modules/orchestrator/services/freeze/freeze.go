@@ -0,0 +1,469 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package freeze 部署冻结窗口, 冻结期间禁止部署, 紧急情况下可申请审批后临时解冻
+package freeze
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/bundle"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/modules/orchestrator/services/apierrors"
+	"github.com/erda-project/erda/modules/pkg/user"
+)
+
+const (
+	defaultTimezone         = "Asia/Shanghai"
+	defaultOverrideDuration = 60
+	minutesPerDay           = 24 * 60
+)
+
+// Freeze 部署冻结窗口封装
+type Freeze struct {
+	db  *dbclient.DBClient
+	bdl *bundle.Bundle
+}
+
+// Option 部署冻结窗口对象配置选项
+type Option func(*Freeze)
+
+// New 新建部署冻结窗口对象实例
+func New(options ...Option) *Freeze {
+	f := &Freeze{}
+	for _, op := range options {
+		op(f)
+	}
+	return f
+}
+
+// WithDBClient 配置 db client
+func WithDBClient(db *dbclient.DBClient) Option {
+	return func(f *Freeze) {
+		f.db = db
+	}
+}
+
+// WithBundle 配置 bundle
+func WithBundle(bdl *bundle.Bundle) Option {
+	return func(f *Freeze) {
+		f.bdl = bdl
+	}
+}
+
+// CreateWindow 创建冻结窗口
+func (f *Freeze) CreateWindow(userID user.ID, orgID uint64, req *apistructs.DeployFreezeWindow) (*apistructs.DeployFreezeWindow, error) {
+	if err := f.checkPermission(userID, orgID, req.ProjectID, apistructs.UpdateAction); err != nil {
+		return nil, apierrors.ErrCreateDeployFreeze.AccessDenied()
+	}
+	window := dbclient.DeployFreezeWindow{
+		OrgID:     orgID,
+		ProjectID: req.ProjectID,
+		Enabled:   true,
+		Creator:   userID.String(),
+	}
+	fillWindow(&window, req)
+	if err := checkWindow(&window); err != nil {
+		return nil, apierrors.ErrCreateDeployFreeze.InvalidParameter(err)
+	}
+	if err := f.db.CreateDeployFreezeWindow(&window); err != nil {
+		return nil, apierrors.ErrCreateDeployFreeze.InternalError(err)
+	}
+	return convertWindowDTO(&window), nil
+}
+
+// UpdateWindow 更新冻结窗口
+func (f *Freeze) UpdateWindow(userID user.ID, orgID uint64, id uint64, req *apistructs.DeployFreezeWindow) (*apistructs.DeployFreezeWindow, error) {
+	window, err := f.db.GetDeployFreezeWindow(id)
+	if err != nil {
+		return nil, apierrors.ErrUpdateDeployFreeze.InternalError(err)
+	}
+	if window == nil {
+		return nil, apierrors.ErrUpdateDeployFreeze.NotFound()
+	}
+	if window.OrgID != orgID {
+		return nil, apierrors.ErrUpdateDeployFreeze.AccessDenied()
+	}
+	if err := f.checkPermission(userID, orgID, window.ProjectID, apistructs.UpdateAction); err != nil {
+		return nil, apierrors.ErrUpdateDeployFreeze.AccessDenied()
+	}
+	fillWindow(window, req)
+	window.Enabled = req.Enabled
+	if err := checkWindow(window); err != nil {
+		return nil, apierrors.ErrUpdateDeployFreeze.InvalidParameter(err)
+	}
+	if err := f.db.UpdateDeployFreezeWindow(window); err != nil {
+		return nil, apierrors.ErrUpdateDeployFreeze.InternalError(err)
+	}
+	return convertWindowDTO(window), nil
+}
+
+// DeleteWindow 删除冻结窗口
+func (f *Freeze) DeleteWindow(userID user.ID, orgID uint64, id uint64) error {
+	window, err := f.db.GetDeployFreezeWindow(id)
+	if err != nil {
+		return apierrors.ErrDeleteDeployFreeze.InternalError(err)
+	}
+	if window == nil {
+		return apierrors.ErrDeleteDeployFreeze.NotFound()
+	}
+	if window.OrgID != orgID {
+		return apierrors.ErrDeleteDeployFreeze.AccessDenied()
+	}
+	if err := f.checkPermission(userID, orgID, window.ProjectID, apistructs.UpdateAction); err != nil {
+		return apierrors.ErrDeleteDeployFreeze.AccessDenied()
+	}
+	if err := f.db.DeleteDeployFreezeWindow(id); err != nil {
+		return apierrors.ErrDeleteDeployFreeze.InternalError(err)
+	}
+	return nil
+}
+
+// ListWindows 查询企业级及项目级冻结窗口
+func (f *Freeze) ListWindows(userID user.ID, orgID uint64, projectID uint64) ([]apistructs.DeployFreezeWindow, error) {
+	if err := f.checkPermission(userID, orgID, projectID, apistructs.GetAction); err != nil {
+		return nil, apierrors.ErrListDeployFreeze.AccessDenied()
+	}
+	windows, err := f.db.FindDeployFreezeWindows(orgID, projectID)
+	if err != nil {
+		return nil, apierrors.ErrListDeployFreeze.InternalError(err)
+	}
+	result := make([]apistructs.DeployFreezeWindow, 0, len(windows))
+	for i := range windows {
+		result = append(result, *convertWindowDTO(&windows[i]))
+	}
+	return result, nil
+}
+
+// ApplyOverride 发起冻结期间的紧急部署申请
+func (f *Freeze) ApplyOverride(userID user.ID, orgID uint64, req *apistructs.DeployFreezeOverrideCreateRequest) (*apistructs.DeployFreezeOverride, error) {
+	if req.ProjectID == 0 {
+		return nil, apierrors.ErrApplyDeployFreeze.MissingParameter("projectId")
+	}
+	if req.Workspace == "" {
+		return nil, apierrors.ErrApplyDeployFreeze.MissingParameter("workspace")
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, apierrors.ErrApplyDeployFreeze.MissingParameter("reason")
+	}
+	if err := f.checkPermission(userID, orgID, req.ProjectID, apistructs.GetAction); err != nil {
+		return nil, apierrors.ErrApplyDeployFreeze.AccessDenied()
+	}
+	duration := req.Duration
+	if duration <= 0 {
+		duration = defaultOverrideDuration
+	}
+	override := dbclient.DeployFreezeOverride{
+		OrgID:     orgID,
+		ProjectID: req.ProjectID,
+		Workspace: strings.ToUpper(req.Workspace),
+		Reason:    req.Reason,
+		Status:    apistructs.DeployFreezeOverrideWaitApprove,
+		Applicant: userID.String(),
+		Duration:  duration,
+	}
+	if err := f.db.CreateDeployFreezeOverride(&override); err != nil {
+		return nil, apierrors.ErrApplyDeployFreeze.InternalError(err)
+	}
+	return convertOverrideDTO(&override), nil
+}
+
+// ApproveOverride 审批紧急部署申请, 通过后在有效期内允许部署
+func (f *Freeze) ApproveOverride(userID user.ID, orgID uint64, id uint64, req *apistructs.DeployFreezeOverrideApproveRequest) error {
+	override, err := f.db.GetDeployFreezeOverride(id)
+	if err != nil {
+		return apierrors.ErrApproveDeployFreeze.InternalError(err)
+	}
+	if override == nil {
+		return apierrors.ErrApproveDeployFreeze.NotFound()
+	}
+	if override.OrgID != orgID {
+		return apierrors.ErrApproveDeployFreeze.AccessDenied()
+	}
+	if override.Status != apistructs.DeployFreezeOverrideWaitApprove {
+		return apierrors.ErrApproveDeployFreeze.InvalidState(fmt.Sprintf("该申请(%d)已被审批过", id))
+	}
+	if override.Applicant == userID.String() {
+		return apierrors.ErrApproveDeployFreeze.InvalidState("不能审批自己发起的申请")
+	}
+	if err := f.checkPermission(userID, orgID, override.ProjectID, apistructs.UpdateAction); err != nil {
+		return apierrors.ErrApproveDeployFreeze.AccessDenied()
+	}
+	override.Approver = userID.String()
+	override.ApprovalReason = req.Reason
+	override.Status = apistructs.DeployFreezeOverrideAccept
+	if req.Reject {
+		override.Status = apistructs.DeployFreezeOverrideReject
+	} else {
+		now := time.Now()
+		end := now.Add(time.Duration(override.Duration) * time.Minute)
+		override.StartAt = &now
+		override.EndAt = &end
+	}
+	// 多人同时审批时只有第一个生效
+	approved, err := f.db.ApproveDeployFreezeOverride(override)
+	if err != nil {
+		return apierrors.ErrApproveDeployFreeze.InternalError(err)
+	}
+	if !approved {
+		return apierrors.ErrApproveDeployFreeze.InvalidState(fmt.Sprintf("该申请(%d)已被审批过", id))
+	}
+	return nil
+}
+
+// ListOverrides 查询项目下的紧急部署申请
+func (f *Freeze) ListOverrides(userID user.ID, orgID uint64, projectID uint64, status string) ([]apistructs.DeployFreezeOverride, error) {
+	if projectID == 0 {
+		return nil, apierrors.ErrListDeployFreeze.MissingParameter("projectId")
+	}
+	if err := f.checkPermission(userID, orgID, projectID, apistructs.GetAction); err != nil {
+		return nil, apierrors.ErrListDeployFreeze.AccessDenied()
+	}
+	overrides, err := f.db.FindDeployFreezeOverrides(projectID, status)
+	if err != nil {
+		return nil, apierrors.ErrListDeployFreeze.InternalError(err)
+	}
+	result := make([]apistructs.DeployFreezeOverride, 0, len(overrides))
+	for i := range overrides {
+		if overrides[i].OrgID != orgID {
+			continue
+		}
+		result = append(result, *convertOverrideDTO(&overrides[i]))
+	}
+	return result, nil
+}
+
+// CheckWithPermission 校验用户项目权限后检查项目环境当前是否允许部署
+func (f *Freeze) CheckWithPermission(userID user.ID, orgID, projectID uint64, workspace string, now time.Time) (*apistructs.DeployFreezeCheckResult, error) {
+	if err := f.checkPermission(userID, orgID, projectID, apistructs.GetAction); err != nil {
+		return nil, apierrors.ErrCheckDeployFreeze.AccessDenied()
+	}
+	result, err := f.Check(orgID, projectID, workspace, now)
+	if err != nil {
+		return nil, apierrors.ErrCheckDeployFreeze.InternalError(err)
+	}
+	return result, nil
+}
+
+// Check 检查项目环境当前是否允许部署, 回滚不受冻结窗口限制, 调用方无需检查
+func (f *Freeze) Check(orgID, projectID uint64, workspace string, now time.Time) (*apistructs.DeployFreezeCheckResult, error) {
+	workspace = strings.ToUpper(workspace)
+	windows, err := f.db.FindEnabledDeployFreezeWindows(orgID, projectID, workspace)
+	if err != nil {
+		return nil, err
+	}
+	var hit *dbclient.DeployFreezeWindow
+	for i := range windows {
+		if windowActive(&windows[i], now) {
+			hit = &windows[i]
+			break
+		}
+	}
+	if hit == nil {
+		return &apistructs.DeployFreezeCheckResult{Allowed: true}, nil
+	}
+	result := apistructs.DeployFreezeCheckResult{Window: convertWindowDTO(hit)}
+	override, err := f.db.GetActiveDeployFreezeOverride(projectID, workspace, now)
+	if err != nil {
+		return nil, err
+	}
+	if override != nil {
+		result.Allowed = true
+		result.Override = convertOverrideDTO(override)
+		return &result, nil
+	}
+	result.Reason = fmt.Sprintf("处于部署冻结窗口 %s 中, 无法部署", hit.Name)
+	return &result, nil
+}
+
+func (f *Freeze) checkPermission(userID user.ID, orgID, projectID uint64, action string) error {
+	req := apistructs.PermissionCheckRequest{
+		UserID:   userID.String(),
+		Scope:    apistructs.OrgScope,
+		ScopeID:  orgID,
+		Resource: apistructs.OrgResource,
+		Action:   action,
+	}
+	if projectID != 0 {
+		req.Scope = apistructs.ProjectScope
+		req.ScopeID = projectID
+		req.Resource = apistructs.ProjectResource
+	}
+	perm, err := f.bdl.CheckPermission(&req)
+	if err != nil {
+		return err
+	}
+	if !perm.Access {
+		return errors.Errorf("access denied")
+	}
+	return nil
+}
+
+func fillWindow(window *dbclient.DeployFreezeWindow, req *apistructs.DeployFreezeWindow) {
+	window.Workspace = strings.ToUpper(req.Workspace)
+	window.Name = req.Name
+	window.Description = req.Description
+	window.Type = req.Type
+	window.StartAt = req.StartAt
+	window.EndAt = req.EndAt
+	window.StartWeekday = req.StartWeekday
+	window.StartTime = req.StartTime
+	window.EndWeekday = req.EndWeekday
+	window.EndTime = req.EndTime
+	window.Timezone = req.Timezone
+	if window.Timezone == "" {
+		window.Timezone = defaultTimezone
+	}
+}
+
+func checkWindow(window *dbclient.DeployFreezeWindow) error {
+	if window.Name == "" {
+		return errors.New("name is required")
+	}
+	switch window.Workspace {
+	case "", string(apistructs.DevWorkspace), string(apistructs.TestWorkspace),
+		string(apistructs.StagingWorkspace), string(apistructs.ProdWorkspace):
+	default:
+		return errors.Errorf("invalid workspace: %s", window.Workspace)
+	}
+	switch window.Type {
+	case apistructs.DeployFreezeOnce:
+		if window.StartAt == nil || window.EndAt == nil {
+			return errors.New("startAt and endAt are required")
+		}
+		if !window.StartAt.Before(*window.EndAt) {
+			return errors.New("startAt must be before endAt")
+		}
+	case apistructs.DeployFreezeWeekly:
+		for _, weekday := range []int{window.StartWeekday, window.EndWeekday} {
+			if weekday < 0 || weekday > 6 {
+				return errors.Errorf("invalid weekday: %d", weekday)
+			}
+		}
+		for _, clock := range []string{window.StartTime, window.EndTime} {
+			if _, err := parseClock(clock); err != nil {
+				return err
+			}
+		}
+		if _, err := time.LoadLocation(window.Timezone); err != nil {
+			return errors.Errorf("invalid timezone: %s", window.Timezone)
+		}
+	default:
+		return errors.Errorf("invalid type: %s", window.Type)
+	}
+	return nil
+}
+
+// windowActive 判断冻结窗口在 now 时刻是否生效, WEEKLY 窗口支持跨周, 如周五 18:00 至周一 09:00
+func windowActive(window *dbclient.DeployFreezeWindow, now time.Time) bool {
+	switch window.Type {
+	case apistructs.DeployFreezeOnce:
+		if window.StartAt == nil || window.EndAt == nil {
+			return false
+		}
+		return !now.Before(*window.StartAt) && now.Before(*window.EndAt)
+	case apistructs.DeployFreezeWeekly:
+		start, err := parseClock(window.StartTime)
+		if err != nil {
+			return false
+		}
+		end, err := parseClock(window.EndTime)
+		if err != nil {
+			return false
+		}
+		t := now.In(location(window.Timezone))
+		cur := int(t.Weekday())*minutesPerDay + t.Hour()*60 + t.Minute()
+		from := window.StartWeekday*minutesPerDay + start
+		to := window.EndWeekday*minutesPerDay + end
+		if from <= to {
+			return cur >= from && cur < to
+		}
+		return cur >= from || cur < to
+	default:
+		return false
+	}
+}
+
+// parseClock 解析 HH:MM, 返回当天的分钟数
+func parseClock(clock string) (int, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) != 2 {
+		return 0, errors.Errorf("invalid time: %s, should be HH:MM", clock)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, errors.Errorf("invalid time: %s, should be HH:MM", clock)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, errors.Errorf("invalid time: %s, should be HH:MM", clock)
+	}
+	return hour*60 + minute, nil
+}
+
+func location(timezone string) *time.Location {
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+func convertWindowDTO(window *dbclient.DeployFreezeWindow) *apistructs.DeployFreezeWindow {
+	return &apistructs.DeployFreezeWindow{
+		ID:           window.ID,
+		OrgID:        window.OrgID,
+		ProjectID:    window.ProjectID,
+		Workspace:    window.Workspace,
+		Name:         window.Name,
+		Description:  window.Description,
+		Type:         window.Type,
+		StartAt:      window.StartAt,
+		EndAt:        window.EndAt,
+		StartWeekday: window.StartWeekday,
+		StartTime:    window.StartTime,
+		EndWeekday:   window.EndWeekday,
+		EndTime:      window.EndTime,
+		Timezone:     window.Timezone,
+		Enabled:      window.Enabled,
+		Creator:      window.Creator,
+		CreatedAt:    window.CreatedAt,
+		UpdatedAt:    window.UpdatedAt,
+	}
+}
+
+func convertOverrideDTO(override *dbclient.DeployFreezeOverride) *apistructs.DeployFreezeOverride {
+	return &apistructs.DeployFreezeOverride{
+		ID:             override.ID,
+		OrgID:          override.OrgID,
+		ProjectID:      override.ProjectID,
+		Workspace:      override.Workspace,
+		Reason:         override.Reason,
+		Status:         override.Status,
+		Applicant:      override.Applicant,
+		Approver:       override.Approver,
+		ApprovalReason: override.ApprovalReason,
+		StartAt:        override.StartAt,
+		EndAt:          override.EndAt,
+		CreatedAt:      override.CreatedAt,
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package freeze
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/bundle"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/dbengine"
+	"github.com/erda-project/erda/pkg/discover"
+	"github.com/erda-project/erda/pkg/httpclient"
+	"github.com/erda-project/erda/pkg/httpserver/errorresp"
+)
+
+// newTestFreeze 使用 sqlite 及只返回鉴权结果的 cmdb 构造 Freeze
+func newTestFreeze(t *testing.T, access bool) (*Freeze, *dbclient.DBClient) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	assert.NoError(t, db.AutoMigrate(&dbclient.DeployFreezeWindow{}, &dbclient.DeployFreezeOverride{}).Error)
+	client := &dbclient.DBClient{DBEngine: &dbengine.DBEngine{DB: db}}
+
+	cmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(fmt.Sprintf(`{"success":true,"data":{"access":%v}}`, access)))
+	}))
+	t.Cleanup(cmdb.Close)
+	t.Setenv(discover.EnvCMDB, strings.TrimPrefix(cmdb.URL, "http://"))
+	bdl := bundle.New(bundle.WithCMDB(), bundle.WithHTTPClient(httpclient.New()))
+	return New(WithDBClient(client), WithBundle(bdl)), client
+}
+
+func apiErrorCode(err error) string {
+	if apiErr, ok := err.(*errorresp.APIError); ok {
+		return apiErr.Code()
+	}
+	return ""
+}
+
+func TestWindowActiveOnce(t *testing.T) {
+	start := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(7 * 24 * time.Hour)
+	w := dbclient.DeployFreezeWindow{Type: apistructs.DeployFreezeOnce, StartAt: &start, EndAt: &end}
+	assert.False(t, windowActive(&w, start.Add(-time.Minute)))
+	assert.True(t, windowActive(&w, start))
+	assert.True(t, windowActive(&w, start.Add(time.Hour)))
+	assert.False(t, windowActive(&w, end))
+}
+
+func TestWindowActiveWeekly(t *testing.T) {
+	// 周五 18:00 至周一 09:00, 跨周
+	w := dbclient.DeployFreezeWindow{
+		Type:         apistructs.DeployFreezeWeekly,
+		StartWeekday: 5,
+		StartTime:    "18:00",
+		EndWeekday:   1,
+		EndTime:      "09:00",
+		Timezone:     "UTC",
+	}
+	// 2021-10-01 为周五
+	assert.False(t, windowActive(&w, time.Date(2021, 10, 1, 17, 59, 0, 0, time.UTC)))
+	assert.True(t, windowActive(&w, time.Date(2021, 10, 1, 18, 0, 0, 0, time.UTC)))
+	assert.True(t, windowActive(&w, time.Date(2021, 10, 3, 12, 0, 0, 0, time.UTC)))
+	assert.True(t, windowActive(&w, time.Date(2021, 10, 4, 8, 59, 0, 0, time.UTC)))
+	assert.False(t, windowActive(&w, time.Date(2021, 10, 4, 9, 0, 0, 0, time.UTC)))
+	assert.False(t, windowActive(&w, time.Date(2021, 10, 6, 12, 0, 0, 0, time.UTC)))
+
+	// 同一周内: 周三 00:00 至周三 06:00
+	w = dbclient.DeployFreezeWindow{
+		Type:         apistructs.DeployFreezeWeekly,
+		StartWeekday: 3,
+		StartTime:    "00:00",
+		EndWeekday:   3,
+		EndTime:      "06:00",
+		Timezone:     "UTC",
+	}
+	assert.True(t, windowActive(&w, time.Date(2021, 10, 6, 5, 0, 0, 0, time.UTC)))
+	assert.False(t, windowActive(&w, time.Date(2021, 10, 6, 6, 0, 0, 0, time.UTC)))
+}
+
+func TestParseClock(t *testing.T) {
+	m, err := parseClock("18:30")
+	assert.NoError(t, err)
+	assert.Equal(t, 18*60+30, m)
+	_, err = parseClock("24:00")
+	assert.Error(t, err)
+	_, err = parseClock("1800")
+	assert.Error(t, err)
+}
+
+func TestCheck(t *testing.T) {
+	f, db := newTestFreeze(t, true)
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	start, end := now.Add(-time.Hour), now.Add(time.Hour)
+	for _, w := range []dbclient.DeployFreezeWindow{
+		// 其他企业、其他环境及已停用的窗口不生效
+		{OrgID: 2, Name: "other-org", Type: apistructs.DeployFreezeOnce, StartAt: &start, EndAt: &end, Enabled: true},
+		{OrgID: 1, ProjectID: 1, Workspace: "STAGING", Name: "staging", Type: apistructs.DeployFreezeOnce, StartAt: &start, EndAt: &end, Enabled: true},
+		{OrgID: 1, ProjectID: 1, Workspace: "PROD", Name: "disabled", Type: apistructs.DeployFreezeOnce, StartAt: &start, EndAt: &end},
+	} {
+		assert.NoError(t, db.CreateDeployFreezeWindow(&w))
+	}
+	result, err := f.Check(1, 1, "prod", now)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Nil(t, result.Window)
+
+	// 企业级窗口对所有项目环境生效
+	orgWindow := dbclient.DeployFreezeWindow{OrgID: 1, Name: "national-day", Type: apistructs.DeployFreezeOnce,
+		StartAt: &start, EndAt: &end, Enabled: true}
+	assert.NoError(t, db.CreateDeployFreezeWindow(&orgWindow))
+	result, err = f.Check(1, 1, "prod", now)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, orgWindow.ID, result.Window.ID)
+	assert.Contains(t, result.Reason, "national-day")
+	result, err = f.Check(1, 1, "prod", end)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// 审批通过的紧急部署申请只在有效期内对申请的环境生效
+	for _, o := range []dbclient.DeployFreezeOverride{
+		{OrgID: 1, ProjectID: 1, Workspace: "TEST", Status: apistructs.DeployFreezeOverrideAccept, StartAt: &start, EndAt: &end},
+		{OrgID: 1, ProjectID: 1, Workspace: "PROD", Status: apistructs.DeployFreezeOverrideWaitApprove, StartAt: &start, EndAt: &end},
+		{OrgID: 1, ProjectID: 1, Workspace: "PROD", Status: apistructs.DeployFreezeOverrideAccept, StartAt: &start, EndAt: &start},
+	} {
+		assert.NoError(t, db.CreateDeployFreezeOverride(&o))
+	}
+	result, err = f.Check(1, 1, "PROD", now)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	override := dbclient.DeployFreezeOverride{OrgID: 1, ProjectID: 1, Workspace: "PROD",
+		Status: apistructs.DeployFreezeOverrideAccept, StartAt: &start, EndAt: &end}
+	assert.NoError(t, db.CreateDeployFreezeOverride(&override))
+	result, err = f.Check(1, 1, "PROD", now)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, override.ID, result.Override.ID)
+}
+
+func TestCheckWithPermission(t *testing.T) {
+	f, _ := newTestFreeze(t, false)
+	_, err := f.CheckWithPermission(user.ID("1"), 1, 1, "PROD", time.Now())
+	assert.Equal(t, "AccessDenied", apiErrorCode(err))
+
+	f, _ = newTestFreeze(t, true)
+	result, err := f.CheckWithPermission(user.ID("1"), 1, 1, "PROD", time.Now())
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestApproveOverride(t *testing.T) {
+	f, db := newTestFreeze(t, true)
+	override, err := f.ApplyOverride(user.ID("1"), 1, &apistructs.DeployFreezeOverrideCreateRequest{
+		ProjectID: 1, Workspace: "prod", Reason: "hotfix", Duration: 30,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "PROD", override.Workspace)
+	assert.Equal(t, apistructs.DeployFreezeOverrideWaitApprove, override.Status)
+
+	// 不能审批自己的申请, 其他企业无权审批
+	err = f.ApproveOverride(user.ID("1"), 1, override.ID, &apistructs.DeployFreezeOverrideApproveRequest{})
+	assert.Equal(t, "InvalidState", apiErrorCode(err))
+	err = f.ApproveOverride(user.ID("2"), 2, override.ID, &apistructs.DeployFreezeOverrideApproveRequest{})
+	assert.Equal(t, "AccessDenied", apiErrorCode(err))
+	err = f.ApproveOverride(user.ID("2"), 1, override.ID+100, &apistructs.DeployFreezeOverrideApproveRequest{})
+	assert.Equal(t, "NotFound", apiErrorCode(err))
+
+	assert.NoError(t, f.ApproveOverride(user.ID("2"), 1, override.ID, &apistructs.DeployFreezeOverrideApproveRequest{Reason: "ok"}))
+	approved, err := db.GetDeployFreezeOverride(override.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.DeployFreezeOverrideAccept, approved.Status)
+	assert.Equal(t, "2", approved.Approver)
+	assert.Equal(t, 30*time.Minute, approved.EndAt.Sub(*approved.StartAt))
+
+	// 已审批的申请不能再次审批, 并发审批时只有第一个生效
+	err = f.ApproveOverride(user.ID("3"), 1, override.ID, &apistructs.DeployFreezeOverrideApproveRequest{Reject: true})
+	assert.Equal(t, "InvalidState", apiErrorCode(err))
+	approved.Status = apistructs.DeployFreezeOverrideReject
+	ok, err := db.ApproveDeployFreezeOverride(approved)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	approved, err = db.GetDeployFreezeOverride(override.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.DeployFreezeOverrideAccept, approved.Status)
+}
+
+func TestWindowNotFound(t *testing.T) {
+	f, _ := newTestFreeze(t, true)
+	_, err := f.UpdateWindow(user.ID("1"), 1, 100, &apistructs.DeployFreezeWindow{})
+	assert.Equal(t, "NotFound", apiErrorCode(err))
+	assert.Equal(t, "NotFound", apiErrorCode(f.DeleteWindow(user.ID("1"), 1, 100)))
+}
+
+func TestWindowDefaultTimezone(t *testing.T) {
+	f, _ := newTestFreeze(t, true)
+	window, err := f.CreateWindow(user.ID("1"), 1, &apistructs.DeployFreezeWindow{
+		Name: "weekend", Type: apistructs.DeployFreezeWeekly,
+		StartWeekday: 5, StartTime: "18:00", EndWeekday: 1, EndTime: "09:00",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultTimezone, window.Timezone)
+	// 2021-10-01 18:30 为北京时间周五 18:30, UTC 10:30
+	w := dbclient.DeployFreezeWindow{Type: window.Type, StartWeekday: window.StartWeekday, StartTime: window.StartTime,
+		EndWeekday: window.EndWeekday, EndTime: window.EndTime, Timezone: window.Timezone}
+	assert.True(t, windowActive(&w, time.Date(2021, 10, 1, 10, 30, 0, 0, time.UTC)))
+}
@@ -0,0 +1,287 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/modules/orchestrator/events"
+	"github.com/erda-project/erda/modules/orchestrator/services/apierrors"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+// scheduler 中每节点部署的服务, 副本数由节点数决定
+const workLoadPerNode = "per_node"
+
+const (
+	// driftScanLease 漂移扫描的租约名称
+	driftScanLease = "runtime-drift-scan"
+	// driftScanLeaseTTL 略短于扫描间隔, 持有者宕机后下一轮可由其他实例获取
+	driftScanLeaseTTL = 25 * time.Minute
+)
+
+// errDriftNotSupported runtime 所在集群无法读取服务的实际规格
+var errDriftNotSupported = errors.New("drift detection is not supported by the cluster of runtime")
+
+// Drift 查询 runtime 声明的规格与集群中实际运行状态的差异
+func (r *Runtime) Drift(userID user.ID, orgID uint64, runtimeID uint64) (*apistructs.RuntimeDriftReport, error) {
+	runtime, err := r.db.GetRuntime(runtimeID)
+	if err != nil {
+		return nil, apierrors.ErrGetRuntime.InternalError(err)
+	}
+	perm, err := r.bdl.CheckPermission(&apistructs.PermissionCheckRequest{
+		UserID:   userID.String(),
+		Scope:    apistructs.AppScope,
+		ScopeID:  runtime.ApplicationID,
+		Resource: "runtime-" + strutil.ToLower(runtime.Workspace),
+		Action:   apistructs.GetAction,
+	})
+	if err != nil {
+		return nil, apierrors.ErrGetRuntime.InternalError(err)
+	}
+	if !perm.Access {
+		return nil, apierrors.ErrGetRuntime.AccessDenied()
+	}
+	report, err := r.detectDrift(runtime)
+	if err == errDriftNotSupported {
+		return nil, apierrors.ErrGetRuntime.InvalidState(err.Error())
+	}
+	if err != nil {
+		return nil, apierrors.ErrGetRuntime.InternalError(err)
+	}
+	return report, nil
+}
+
+// Reconcile 以声明的规格重新部署 runtime, 覆盖集群中被手动修改的内容
+func (r *Runtime) Reconcile(operator user.ID, orgID uint64, runtimeID uint64) (*apistructs.DeploymentCreateResponsePipelineDTO, error) {
+	return r.RedeployPipeline(operator, orgID, runtimeID)
+}
+
+// DriftScan 定时扫描已部署成功的 runtime, 发现新的差异时发出 RuntimeDrifted 事件,
+// 差异未变化时不重复发出. 每轮扫描只由获得租约的实例执行
+func (r *Runtime) DriftScan() {
+	defer func() {
+		if err := recover(); err != nil {
+			debug.PrintStack()
+			logrus.Errorf("[alert] failed to scan runtime drift, panic: %v", err)
+		}
+	}()
+
+	holder, _ := os.Hostname()
+	claimed, err := r.db.ClaimScanLease(driftScanLease, holder, driftScanLeaseTTL)
+	if err != nil {
+		logrus.Errorf("failed to claim lease of runtime drift scan, (%v)", err)
+		return
+	}
+	if !claimed {
+		logrus.Debugf("runtime drift scan is held by other instance, skip")
+		return
+	}
+
+	bulk := 100
+	lastRuntimeID := uint64(0)
+	for {
+		runtimes, err := r.db.FindRuntimesNewerThan(lastRuntimeID, bulk)
+		if err != nil {
+			logrus.Errorf("[alert] failed to find runtimes after: %v, (%v)", lastRuntimeID, err)
+			break
+		}
+		for i := range runtimes {
+			r.driftScanForSingleRuntime(&runtimes[i])
+		}
+		if len(runtimes) < bulk {
+			// ended
+			break
+		}
+		lastRuntimeID = runtimes[len(runtimes)-1].ID
+	}
+}
+
+func (r *Runtime) driftScanForSingleRuntime(runtime *dbclient.Runtime) {
+	if !runtime.Deployed || runtime.LegacyStatus == dbclient.LegacyStatusDeleting {
+		return
+	}
+	// 部署中或部署失败时, 集群状态本就与声明不一致
+	deployment, err := r.db.FindLastDeployment(runtime.ID)
+	if err != nil || deployment == nil || deployment.Status != apistructs.DeploymentStatusOK {
+		return
+	}
+	report, err := r.detectDrift(runtime)
+	if err == errDriftNotSupported {
+		return
+	}
+	if err != nil {
+		logrus.Warnf("failed to detect drift of runtime %d, (%v)", runtime.ID, err)
+		return
+	}
+	changed, err := r.claimDriftReport(report)
+	if err != nil {
+		logrus.Warnf("failed to record drift of runtime %d, (%v)", runtime.ID, err)
+		return
+	}
+	if !changed || !report.Drifted {
+		return
+	}
+	logrus.Infof("runtime %d drifted from declared spec: %+v", runtime.ID, report.Services)
+	app, err := r.bdl.GetApp(runtime.ApplicationID)
+	if err != nil {
+		logrus.Errorf("failed to get app %d of drifted runtime %d, (%v)", runtime.ApplicationID, runtime.ID, err)
+		return
+	}
+	r.evMgr.EmitEvent(&events.RuntimeEvent{
+		EventName: events.RuntimeDrifted,
+		Runtime:   dbclient.ConvertRuntimeDTO(runtime, app),
+		Drift:     report,
+	})
+}
+
+// claimDriftReport 记录报告哈希, 仅当与上次记录不同且由本实例记录成功时返回 true.
+// 无差异的报告哈希为空, 差异消失后再次出现时重新发出事件
+func (r *Runtime) claimDriftReport(report *apistructs.RuntimeDriftReport) (bool, error) {
+	hash := ""
+	if report.Drifted {
+		var err error
+		if hash, err = driftReportHash(report); err != nil {
+			return false, err
+		}
+	}
+	updated, exists, err := r.db.UpdateRuntimeDriftHash(report.RuntimeID, hash)
+	if err != nil || updated || exists || hash == "" {
+		return updated, err
+	}
+	// 并发插入时唯一索引只允许一个实例成功
+	if err := r.db.CreateRuntimeDrift(&dbclient.RuntimeDrift{RuntimeID: report.RuntimeID, ReportHash: hash}); err != nil {
+		logrus.Debugf("drift of runtime %d is recorded by others, (%v)", report.RuntimeID, err)
+		return false, nil
+	}
+	return true, nil
+}
+
+// driftReportHash 报告中差异内容的哈希, 不包含检查时间
+func driftReportHash(report *apistructs.RuntimeDriftReport) (string, error) {
+	b, err := json.Marshal(report.Services)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (r *Runtime) detectDrift(runtime *dbclient.Runtime) (*apistructs.RuntimeDriftReport, error) {
+	sg, err := r.bdl.InspectServiceGroupWithTimeout(runtime.ScheduleName.Args())
+	if err != nil {
+		return nil, err
+	}
+	live, err := r.bdl.LiveServiceGroup(runtime.ScheduleName.Args())
+	if err != nil {
+		return nil, err
+	}
+	if !live.Supported {
+		return nil, errDriftNotSupported
+	}
+	services := compareDrift(sg, live.Services)
+	return &apistructs.RuntimeDriftReport{
+		RuntimeID: runtime.ID,
+		Drifted:   len(services) > 0,
+		CheckedAt: time.Now(),
+		Services:  services,
+	}, nil
+}
+
+// compareDrift 比对声明的 servicegroup 与集群中的实际规格, 只返回存在差异的服务.
+// 集群中的环境变量包含 scheduler 注入的部分, 因此只比对声明过的 key
+func compareDrift(sg *apistructs.ServiceGroup, live []apistructs.ServiceLiveSpec) []apistructs.RuntimeServiceDrift {
+	liveMap := make(map[string]apistructs.ServiceLiveSpec, len(live))
+	for _, l := range live {
+		liveMap[l.Name] = l
+	}
+	result := []apistructs.RuntimeServiceDrift{}
+	for _, svc := range sg.Services {
+		l, ok := liveMap[svc.Name]
+		if !ok || l.Missing {
+			result = append(result, apistructs.RuntimeServiceDrift{Service: svc.Name, Missing: true})
+			continue
+		}
+		var diffs []apistructs.RuntimeDriftItem
+		addDiff := func(field, declared, live string) {
+			if declared != live {
+				diffs = append(diffs, apistructs.RuntimeDriftItem{
+					Field: field, Declared: declared, Live: live, Change: apistructs.RuntimeDriftChanged,
+				})
+			}
+		}
+		addDiff("image", svc.Image, l.Image)
+		if svc.Autoscaling == nil && svc.WorkLoad != workLoadPerNode {
+			addDiff("replicas", strconv.Itoa(svc.Scale), strconv.Itoa(l.Replicas))
+		}
+		keys := make([]string, 0, len(svc.Env))
+		for k := range svc.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		// 只报告 key, 不把环境变量的值写入报告和事件
+		for _, k := range keys {
+			liveValue, exists := l.Envs[k]
+			if !exists {
+				diffs = append(diffs, apistructs.RuntimeDriftItem{Field: "env." + k, Change: apistructs.RuntimeDriftRemoved})
+			} else if liveValue != svc.Env[k] {
+				diffs = append(diffs, apistructs.RuntimeDriftItem{Field: "env." + k, Change: apistructs.RuntimeDriftChanged})
+			}
+		}
+		if l.CPU > 0 {
+			addDiff("cpu", formatFloat(svc.Resources.Cpu), formatFloat(l.CPU))
+		}
+		if l.Mem > 0 {
+			addDiff("mem", formatFloat(svc.Resources.Mem), formatFloat(l.Mem))
+		}
+		declaredPorts := make([]int, 0, len(svc.Ports))
+		for _, p := range svc.Ports {
+			declaredPorts = append(declaredPorts, p.Port)
+		}
+		addDiff("ports", formatPorts(declaredPorts), formatPorts(l.Ports))
+		if len(diffs) > 0 {
+			result = append(result, apistructs.RuntimeServiceDrift{Service: svc.Name, Diffs: diffs})
+		}
+	}
+	return result
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatPorts(ports []int) string {
+	sorted := make([]int, len(ports))
+	copy(sorted, ports)
+	sort.Ints(sorted)
+	strs := make([]string, 0, len(sorted))
+	for _, p := range sorted {
+		strs = append(strs, strconv.Itoa(p))
+	}
+	return strings.Join(strs, ",")
+}
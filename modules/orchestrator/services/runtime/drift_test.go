@@ -0,0 +1,157 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/pkg/dbengine"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func TestCompareDrift(t *testing.T) {
+	sg := &apistructs.ServiceGroup{}
+	sg.Services = []apistructs.Service{
+		{
+			Name:      "web",
+			Image:     "nginx:1.0",
+			Scale:     2,
+			Env:       map[string]string{"A": "1", "B": "2", "C": "secret"},
+			Resources: apistructs.Resources{Cpu: 0.5, Mem: 512},
+			Ports:     []diceyml.ServicePort{{Port: 80}},
+		},
+		{
+			Name:        "api",
+			Image:       "api:1.0",
+			Scale:       1,
+			Autoscaling: &diceyml.Autoscaling{MinReplicas: 1, MaxReplicas: 5},
+			Resources:   apistructs.Resources{Cpu: 1, Mem: 1024},
+		},
+		{
+			Name:  "worker",
+			Image: "worker:1.0",
+		},
+	}
+	live := []apistructs.ServiceLiveSpec{
+		{
+			Name:     "web",
+			Image:    "nginx:1.1",
+			Replicas: 3,
+			Envs:     map[string]string{"A": "1", "B": "3", "SELF_HOST": "web"},
+			CPU:      0.5,
+			Mem:      512,
+			Ports:    []int{80},
+		},
+		{
+			Name:     "api",
+			Image:    "api:1.0",
+			Replicas: 4,
+			CPU:      1,
+			Mem:      1024,
+		},
+		{
+			Name:    "worker",
+			Missing: true,
+		},
+	}
+	drifts := compareDrift(sg, live)
+	assert.Equal(t, 2, len(drifts))
+	assert.Equal(t, "web", drifts[0].Service)
+	assert.Equal(t, []apistructs.RuntimeDriftItem{
+		{Field: "image", Declared: "nginx:1.0", Live: "nginx:1.1", Change: apistructs.RuntimeDriftChanged},
+		{Field: "replicas", Declared: "2", Live: "3", Change: apistructs.RuntimeDriftChanged},
+		// values of env are not reported
+		{Field: "env.B", Change: apistructs.RuntimeDriftChanged},
+		{Field: "env.C", Change: apistructs.RuntimeDriftRemoved},
+	}, drifts[0].Diffs)
+	assert.Equal(t, "worker", drifts[1].Service)
+	assert.True(t, drifts[1].Missing)
+}
+
+func TestClaimDriftReport(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.AutoMigrate(&dbclient.RuntimeDrift{}).Error)
+	r := New(WithDBClient(&dbclient.DBClient{DBEngine: &dbengine.DBEngine{DB: db}}))
+
+	clean := &apistructs.RuntimeDriftReport{RuntimeID: 1}
+	drifted := &apistructs.RuntimeDriftReport{RuntimeID: 1, Drifted: true, Services: []apistructs.RuntimeServiceDrift{
+		{Service: "web", Diffs: []apistructs.RuntimeDriftItem{{Field: "image", Declared: "nginx:1.0", Live: "nginx:1.1"}}},
+	}}
+	moreDrifted := &apistructs.RuntimeDriftReport{RuntimeID: 1, Drifted: true, Services: []apistructs.RuntimeServiceDrift{
+		{Service: "web", Diffs: []apistructs.RuntimeDriftItem{{Field: "image", Declared: "nginx:1.0", Live: "nginx:1.2"}}},
+	}}
+
+	for _, c := range []struct {
+		report  *apistructs.RuntimeDriftReport
+		changed bool
+	}{
+		// nothing recorded for runtime never drifted
+		{clean, false},
+		{drifted, true},
+		// same drift is reported once, no matter which instance scans
+		{drifted, false},
+		{moreDrifted, true},
+		{clean, true},
+		{clean, false},
+		// drift comes back after reconciled
+		{drifted, true},
+	} {
+		changed, err := r.claimDriftReport(c.report)
+		assert.NoError(t, err)
+		assert.Equal(t, c.changed, changed)
+	}
+
+	// report of other runtime is recorded separately
+	other := *drifted
+	other.RuntimeID = 2
+	changed, err := r.claimDriftReport(&other)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Error(t, r.db.CreateRuntimeDrift(&dbclient.RuntimeDrift{RuntimeID: 2}), "unique runtime id")
+}
+
+func TestDriftScanLease(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.AutoMigrate(&dbclient.ScanLease{}).Error)
+	client := &dbclient.DBClient{DBEngine: &dbengine.DBEngine{DB: db}}
+
+	claimed, err := client.ClaimScanLease(driftScanLease, "a", time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	// lease is held by others
+	claimed, err = client.ClaimScanLease(driftScanLease, "b", time.Hour)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+	// other scans are not affected
+	claimed, err = client.ClaimScanLease("other-scan", "b", time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	// expired lease can be claimed by others
+	assert.NoError(t, db.Model(&dbclient.ScanLease{}).Where("name = ?", driftScanLease).
+		Update("expired_at", time.Now().Add(-time.Minute)).Error)
+	claimed, err = client.ClaimScanLease(driftScanLease, "b", time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/services/apierrors"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+var invalidChartNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Export 将 runtime 导出为 helm chart 或 kustomize manifests, 返回 tar.gz 文件名及内容.
+// 导出内容包含服务配置, 因此需要 runtime 的操作权限
+func (r *Runtime) Export(userID user.ID, orgID uint64, runtimeID uint64, format apistructs.RuntimeExportFormat) (string, []byte, error) {
+	runtime, err := r.db.GetRuntime(runtimeID)
+	if err != nil {
+		return "", nil, apierrors.ErrExportRuntime.InternalError(err)
+	}
+	app, err := r.bdl.GetApp(runtime.ApplicationID)
+	if err != nil {
+		return "", nil, apierrors.ErrExportRuntime.InternalError(err)
+	}
+	perm, err := r.bdl.CheckPermission(&apistructs.PermissionCheckRequest{
+		UserID:   userID.String(),
+		Scope:    apistructs.AppScope,
+		ScopeID:  app.ID,
+		Resource: "runtime-" + strutil.ToLower(runtime.Workspace),
+		Action:   apistructs.OperateAction,
+	})
+	if err != nil {
+		return "", nil, apierrors.ErrExportRuntime.InternalError(err)
+	}
+	if !perm.Access {
+		return "", nil, apierrors.ErrExportRuntime.AccessDenied()
+	}
+
+	sg, err := r.bdl.InspectServiceGroupWithTimeout(runtime.ScheduleName.Args())
+	if err != nil {
+		return "", nil, apierrors.ErrExportRuntime.InternalError(err)
+	}
+	domains, err := r.db.FindDomainsByRuntimeId(runtimeID)
+	if err != nil {
+		return "", nil, apierrors.ErrExportRuntime.InternalError(err)
+	}
+	addonConfigs, err := r.addon.GetRuntimeAddonConfig(runtimeID)
+	if err != nil {
+		return "", nil, apierrors.ErrExportRuntime.InternalError(err)
+	}
+	addonEnvKeys := make(map[string]struct{})
+	for _, c := range *addonConfigs {
+		for k := range c.Config {
+			addonEnvKeys[k] = struct{}{}
+		}
+	}
+	version, dice := "", ""
+	if deployment, err := r.db.FindLastDeployment(runtimeID); err == nil && deployment != nil {
+		version, dice = deployment.ReleaseId, deployment.Dice
+	}
+
+	name := chartName(app.Name, runtime.Name)
+	values := exportValues(name, sg, domains, addonEnvKeys, declaredEnvs(dice))
+	switch format {
+	case apistructs.RuntimeExportHelm, "":
+		files, err := renderHelmChart(values, version)
+		if err != nil {
+			return "", nil, apierrors.ErrExportRuntime.InternalError(err)
+		}
+		data, err := tarball(name, files)
+		if err != nil {
+			return "", nil, apierrors.ErrExportRuntime.InternalError(err)
+		}
+		return name + ".tgz", data, nil
+	case apistructs.RuntimeExportKustomize:
+		files, err := renderKustomize(values)
+		if err != nil {
+			return "", nil, apierrors.ErrExportRuntime.InternalError(err)
+		}
+		data, err := tarball(name, files)
+		if err != nil {
+			return "", nil, apierrors.ErrExportRuntime.InternalError(err)
+		}
+		return name + "-manifests.tar.gz", data, nil
+	default:
+		return "", nil, apierrors.ErrExportRuntime.InvalidParameter("format: " + string(format))
+	}
+}
+
+// ImportHelmValues 将 helm values 还原为 dice.yml 并创建制品, 之后可按制品部署
+func (r *Runtime) ImportHelmValues(operator user.ID, orgID uint64, req *apistructs.RuntimeHelmImportRequest) (*apistructs.RuntimeHelmImportResponseData, error) {
+	if req.ApplicationID == 0 {
+		return nil, apierrors.ErrImportRuntime.MissingParameter("applicationId")
+	}
+	if strings.TrimSpace(req.Values) == "" {
+		return nil, apierrors.ErrImportRuntime.MissingParameter("values")
+	}
+	app, err := r.bdl.GetApp(req.ApplicationID)
+	if err != nil {
+		return nil, apierrors.ErrImportRuntime.InternalError(err)
+	}
+	perm, err := r.bdl.CheckPermission(&apistructs.PermissionCheckRequest{
+		UserID:   operator.String(),
+		Scope:    apistructs.AppScope,
+		ScopeID:  app.ID,
+		Resource: apistructs.AppResource,
+		Action:   apistructs.UpdateAction,
+	})
+	if err != nil {
+		return nil, apierrors.ErrImportRuntime.InternalError(err)
+	}
+	if !perm.Access {
+		return nil, apierrors.ErrImportRuntime.AccessDenied()
+	}
+
+	var values apistructs.RuntimeExportValues
+	if err := yaml.Unmarshal([]byte(req.Values), &values); err != nil {
+		return nil, apierrors.ErrImportRuntime.InvalidParameter(err)
+	}
+	if len(values.Services) == 0 {
+		return nil, apierrors.ErrImportRuntime.InvalidParameter("no services in values")
+	}
+	dice, err := valuesToDiceYml(&values)
+	if err != nil {
+		return nil, apierrors.ErrImportRuntime.InvalidParameter(err)
+	}
+	releaseID, err := r.bdl.CreateRelease(apistructs.ReleaseCreateRequest{
+		ReleaseName:     values.Name,
+		Desc:            "imported from helm values",
+		Dice:            dice,
+		Version:         req.Version,
+		OrgID:           int64(orgID),
+		ProjectID:       int64(app.ProjectID),
+		ApplicationID:   int64(app.ID),
+		ProjectName:     app.ProjectName,
+		ApplicationName: app.Name,
+		UserID:          operator.String(),
+	})
+	if err != nil {
+		return nil, apierrors.ErrImportRuntime.InternalError(err)
+	}
+	return &apistructs.RuntimeHelmImportResponseData{ReleaseID: releaseID}, nil
+}
+
+// chartName 生成符合 helm chart 命名规范的名称, runtime 名称可能包含 / 等字符
+func chartName(appName, runtimeName string) string {
+	name := strings.ToLower(appName + "-" + runtimeName)
+	name = invalidChartNameChars.ReplaceAllString(name, "-")
+	return strings.Trim(name, "-")
+}
@@ -0,0 +1,432 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	goyaml "gopkg.in/yaml.v2"
+	"sigs.k8s.io/yaml"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+// envPlaceholder 导出时 addon 连接信息及 dice.yml 未声明的环境变量的占位符, 需要在目标集群中替换
+const envPlaceholder = "CHANGE_ME"
+
+const chartYamlTemplate = `apiVersion: v2
+name: {{ .Values.name }}
+description: exported from runtime {{ .Values.name }}
+type: application
+version: 0.1.0
+appVersion: {{ .Version | quote }}
+`
+
+const configMapTemplate = `{{- range $name, $svc := .Values.services }}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ $name }}-config
+  labels:
+    app.kubernetes.io/name: {{ $name }}
+    app.kubernetes.io/instance: {{ $.Release.Name }}
+data:
+  {{- range $k, $v := $svc.envs }}
+  {{ $k }}: {{ $v | quote }}
+  {{- end }}
+{{- end }}
+`
+
+const secretTemplate = `{{- if .Values.addonEnvs }}
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .Release.Name }}-addons
+  labels:
+    app.kubernetes.io/instance: {{ .Release.Name }}
+type: Opaque
+stringData:
+  {{- range $k, $v := .Values.addonEnvs }}
+  {{ $k }}: {{ $v | quote }}
+  {{- end }}
+{{- end }}
+{{- range $name, $svc := .Values.services }}
+{{- if $svc.secretEnvs }}
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ $name }}-secret
+  labels:
+    app.kubernetes.io/name: {{ $name }}
+    app.kubernetes.io/instance: {{ $.Release.Name }}
+type: Opaque
+stringData:
+  {{- range $k, $v := $svc.secretEnvs }}
+  {{ $k }}: {{ $v | quote }}
+  {{- end }}
+{{- end }}
+{{- end }}
+`
+
+const deploymentTemplate = `{{- range $name, $svc := .Values.services }}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ $name }}
+  labels:
+    app.kubernetes.io/name: {{ $name }}
+    app.kubernetes.io/instance: {{ $.Release.Name }}
+spec:
+  replicas: {{ $svc.replicas }}
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{ $name }}
+      app.kubernetes.io/instance: {{ $.Release.Name }}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{ $name }}
+        app.kubernetes.io/instance: {{ $.Release.Name }}
+    spec:
+      containers:
+        - name: {{ $name }}
+          image: {{ $svc.image | quote }}
+          {{- if $svc.cmd }}
+          command: ["sh", "-c", {{ $svc.cmd | quote }}]
+          {{- end }}
+          {{- if $svc.ports }}
+          ports:
+            {{- range $svc.ports }}
+            - containerPort: {{ . }}
+            {{- end }}
+          {{- end }}
+          envFrom:
+            - configMapRef:
+                name: {{ $name }}-config
+            {{- if $svc.secretEnvs }}
+            - secretRef:
+                name: {{ $name }}-secret
+            {{- end }}
+            {{- if $.Values.addonEnvs }}
+            - secretRef:
+                name: {{ $.Release.Name }}-addons
+            {{- end }}
+          resources:
+            requests:
+              cpu: {{ $svc.cpu | quote }}
+              memory: "{{ $svc.mem }}Mi"
+            limits:
+              cpu: {{ $svc.cpu | quote }}
+              memory: "{{ $svc.mem }}Mi"
+{{- end }}
+`
+
+const serviceTemplate = `{{- range $name, $svc := .Values.services }}
+{{- if $svc.ports }}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ $name }}
+  labels:
+    app.kubernetes.io/name: {{ $name }}
+    app.kubernetes.io/instance: {{ $.Release.Name }}
+spec:
+  selector:
+    app.kubernetes.io/name: {{ $name }}
+    app.kubernetes.io/instance: {{ $.Release.Name }}
+  ports:
+    {{- range $svc.ports }}
+    - name: tcp-{{ . }}
+      port: {{ . }}
+      targetPort: {{ . }}
+    {{- end }}
+{{- end }}
+{{- end }}
+`
+
+const ingressTemplate = `{{- range $name, $svc := .Values.services }}
+{{- if and $svc.domains $svc.ports }}
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{ $name }}
+  labels:
+    app.kubernetes.io/name: {{ $name }}
+    app.kubernetes.io/instance: {{ $.Release.Name }}
+spec:
+  rules:
+    {{- range $svc.domains }}
+    - host: {{ . }}
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: {{ $name }}
+                port:
+                  number: {{ index $svc.ports 0 }}
+    {{- end }}
+{{- end }}
+{{- end }}
+`
+
+// manifestTemplates 同时作为 helm chart 的 templates, 以及渲染 kustomize manifests 的模版,
+// 因此只能使用 helm 与 text/template 共有的语法, 以及 quote 函数
+var manifestTemplates = []struct {
+	name    string
+	content string
+}{
+	{"configmap.yaml", configMapTemplate},
+	{"secret.yaml", secretTemplate},
+	{"deployment.yaml", deploymentTemplate},
+	{"service.yaml", serviceTemplate},
+	{"ingress.yaml", ingressTemplate},
+}
+
+var templateFuncs = template.FuncMap{
+	"quote": func(v interface{}) string {
+		return fmt.Sprintf("%q", fmt.Sprint(v))
+	},
+}
+
+// exportValues 将 servicegroup 转换为 helm values, 平台注入的 DICE_ 环境变量不导出.
+// 与 dice.yml 中声明一致的环境变量原样导出, 其余来自配置中心, 可能包含密钥, 与 addon 连接信息一样以占位符替代
+func exportValues(name string, sg *apistructs.ServiceGroup, domains []dbclient.RuntimeDomain,
+	addonEnvKeys map[string]struct{}, declared map[string]map[string]string) *apistructs.RuntimeExportValues {
+	values := apistructs.RuntimeExportValues{
+		Name:      name,
+		Services:  make(map[string]apistructs.RuntimeExportServiceValues, len(sg.Services)),
+		AddonEnvs: make(map[string]string, len(addonEnvKeys)),
+	}
+	for k := range addonEnvKeys {
+		values.AddonEnvs[k] = envPlaceholder
+	}
+	for _, svc := range sg.Services {
+		s := apistructs.RuntimeExportServiceValues{
+			Image:    svc.Image,
+			Cmd:      svc.Cmd,
+			Replicas: svc.Scale,
+			CPU:      svc.Resources.Cpu,
+			Mem:      int(svc.Resources.Mem),
+			Envs:     make(map[string]string),
+		}
+		for _, p := range svc.Ports {
+			s.Ports = append(s.Ports, p.Port)
+		}
+		for k, v := range svc.Env {
+			if strings.HasPrefix(k, "DICE_") {
+				continue
+			}
+			if _, ok := addonEnvKeys[k]; ok {
+				continue
+			}
+			if d, ok := declared[svc.Name][k]; ok && d == v {
+				s.Envs[k] = v
+				continue
+			}
+			if s.SecretEnvs == nil {
+				s.SecretEnvs = make(map[string]string)
+			}
+			s.SecretEnvs[k] = envPlaceholder
+		}
+		for _, d := range domains {
+			if d.EndpointName == svc.Name {
+				s.Domains = append(s.Domains, d.Domain)
+			}
+		}
+		sort.Strings(s.Domains)
+		values.Services[svc.Name] = s
+	}
+	return &values
+}
+
+// renderHelmChart 生成 helm chart 的全部文件
+func renderHelmChart(values *apistructs.RuntimeExportValues, version string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	chart, err := renderTemplate("Chart.yaml", chartYamlTemplate, values, version)
+	if err != nil {
+		return nil, err
+	}
+	files["Chart.yaml"] = chart
+	valuesYaml, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	files["values.yaml"] = valuesYaml
+	for _, t := range manifestTemplates {
+		files["templates/"+t.name] = []byte(t.content)
+	}
+	return files, nil
+}
+
+// renderKustomize 以 values 渲染出 manifests, 并生成 kustomization.yaml
+func renderKustomize(values *apistructs.RuntimeExportValues) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	var resources []string
+	for _, t := range manifestTemplates {
+		content, err := renderTemplate(t.name, t.content, values, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(content)) == 0 {
+			continue
+		}
+		files[t.name] = content
+		resources = append(resources, t.name)
+	}
+	kustomization, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  resources,
+	})
+	if err != nil {
+		return nil, err
+	}
+	files["kustomization.yaml"] = kustomization
+	return files, nil
+}
+
+func renderTemplate(name, content string, values *apistructs.RuntimeExportValues, version string) ([]byte, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	// 转为 map 以便模版中使用与 values.yaml 一致的小写字段名
+	b, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, map[string]interface{}{
+		"Values":  v,
+		"Release": map[string]interface{}{"Name": values.Name},
+		"Version": version,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to render %s", name)
+	}
+	return buf.Bytes(), nil
+}
+
+// tarball 将文件打包为 tar.gz, 所有文件位于 root 目录下
+func tarball(root string, files map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	now := time.Now()
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    root + "/" + name,
+			Mode:    0644,
+			Size:    int64(len(files[name])),
+			ModTime: now,
+		}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// declaredEnvs 解析部署单的 dice.yml, 返回各服务声明的环境变量, 包括全局环境变量
+func declaredEnvs(dice string) map[string]map[string]string {
+	result := make(map[string]map[string]string)
+	var obj diceyml.Object
+	if err := json.Unmarshal([]byte(dice), &obj); err != nil {
+		return result
+	}
+	for name, svc := range obj.Services {
+		if svc == nil {
+			continue
+		}
+		envs := make(map[string]string, len(obj.Envs)+len(svc.Envs))
+		for k, v := range obj.Envs {
+			envs[k] = v
+		}
+		for k, v := range svc.Envs {
+			envs[k] = v
+		}
+		result[name] = envs
+	}
+	return result
+}
+
+// valuesToDiceYml 将 helm values 还原为 dice.yml, 域名, addon 连接信息及以占位符导出的环境变量不在 dice.yml 中声明
+func valuesToDiceYml(values *apistructs.RuntimeExportValues) (string, error) {
+	obj := diceyml.Object{
+		Version:  "2.0",
+		Services: make(diceyml.Services, len(values.Services)),
+	}
+	for name, svc := range values.Services {
+		s := diceyml.Service{
+			Image:       svc.Image,
+			Cmd:         svc.Cmd,
+			Envs:        svc.Envs,
+			Resources:   diceyml.Resources{CPU: svc.CPU, Mem: svc.Mem},
+			Deployments: diceyml.Deployments{Replicas: svc.Replicas},
+		}
+		for _, p := range svc.Ports {
+			s.Ports = append(s.Ports, diceyml.ServicePort{Port: p})
+		}
+		if len(svc.Domains) > 0 && len(svc.Ports) > 0 {
+			s.Expose = []int{svc.Ports[0]}
+		}
+		obj.Services[name] = &s
+	}
+	// dice.yml 的字段名以 yaml tag 为准
+	b, err := goyaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	dice, err := diceyml.New(b, true)
+	if err != nil {
+		return "", err
+	}
+	return dice.YAML()
+}
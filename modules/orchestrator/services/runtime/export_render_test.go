@@ -0,0 +1,122 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func testExportValues() *apistructs.RuntimeExportValues {
+	sg := &apistructs.ServiceGroup{}
+	sg.Services = []apistructs.Service{
+		{
+			Name:      "web",
+			Image:     "nginx:1.0",
+			Cmd:       "nginx -g 'daemon off;'",
+			Scale:     2,
+			Resources: apistructs.Resources{Cpu: 0.5, Mem: 512},
+			Ports:     []diceyml.ServicePort{{Port: 80}},
+			Env: map[string]string{
+				"LOG_LEVEL":      "info",
+				"DICE_WORKSPACE": "PROD",
+				"MYSQL_HOST":     "mysql.addon",
+				"API_TOKEN":      "s3cr3t",
+			},
+		},
+		{
+			Name:      "worker",
+			Image:     "worker:1.0",
+			Scale:     1,
+			Resources: apistructs.Resources{Cpu: 1, Mem: 1024},
+		},
+	}
+	domains := []dbclient.RuntimeDomain{
+		{Domain: "web.example.com", EndpointName: "web"},
+	}
+	declared := declaredEnvs(`{"envs":{"LOG_LEVEL":"debug"},"services":{"web":{"envs":{"LOG_LEVEL":"info"}},"worker":{}}}`)
+	return exportValues("demo-master", sg, domains, map[string]struct{}{"MYSQL_HOST": {}}, declared)
+}
+
+func TestExportValues(t *testing.T) {
+	values := testExportValues()
+	assert.Equal(t, map[string]string{"MYSQL_HOST": envPlaceholder}, values.AddonEnvs)
+	web := values.Services["web"]
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "info"}, web.Envs)
+	// 配置中心的环境变量不导出真实值
+	assert.Equal(t, map[string]string{"API_TOKEN": envPlaceholder}, web.SecretEnvs)
+	assert.Nil(t, values.Services["worker"].SecretEnvs)
+	assert.Equal(t, []int{80}, web.Ports)
+	assert.Equal(t, []string{"web.example.com"}, web.Domains)
+	assert.Equal(t, 2, web.Replicas)
+}
+
+func TestRenderKustomize(t *testing.T) {
+	files, err := renderKustomize(testExportValues())
+	assert.NoError(t, err)
+	for _, name := range []string{"kustomization.yaml", "configmap.yaml", "secret.yaml",
+		"deployment.yaml", "service.yaml", "ingress.yaml"} {
+		content, ok := files[name]
+		assert.True(t, ok, name)
+		for _, doc := range strings.Split(string(content), "\n---\n") {
+			var obj map[string]interface{}
+			assert.NoError(t, yaml.Unmarshal([]byte(doc), &obj), name)
+		}
+	}
+	assert.Contains(t, string(files["deployment.yaml"]), `image: "nginx:1.0"`)
+	assert.Contains(t, string(files["deployment.yaml"]), `memory: "512Mi"`)
+	assert.Contains(t, string(files["ingress.yaml"]), "host: web.example.com")
+	assert.Contains(t, string(files["secret.yaml"]), `MYSQL_HOST: "CHANGE_ME"`)
+	assert.Contains(t, string(files["secret.yaml"]), `API_TOKEN: "CHANGE_ME"`)
+	assert.Contains(t, string(files["deployment.yaml"]), "name: web-secret")
+	for name, content := range files {
+		assert.NotContains(t, string(content), "s3cr3t", name)
+	}
+}
+
+func TestRenderHelmChart(t *testing.T) {
+	files, err := renderHelmChart(testExportValues(), "release-1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(files["Chart.yaml"]), "name: demo-master")
+	var values apistructs.RuntimeExportValues
+	assert.NoError(t, yaml.Unmarshal(files["values.yaml"], &values))
+	assert.Equal(t, testExportValues().Services["web"], values.Services["web"])
+	assert.Equal(t, testExportValues().AddonEnvs, values.AddonEnvs)
+	assert.Equal(t, deploymentTemplate, string(files["templates/deployment.yaml"]))
+	assert.NotContains(t, string(files["values.yaml"]), "s3cr3t")
+}
+
+func TestValuesToDiceYml(t *testing.T) {
+	dice, err := valuesToDiceYml(testExportValues())
+	assert.NoError(t, err)
+	d, err := diceyml.New([]byte(dice), true)
+	assert.NoError(t, err)
+	web := d.Obj().Services["web"]
+	assert.Equal(t, "nginx:1.0", web.Image)
+	assert.Equal(t, 2, web.Deployments.Replicas)
+	assert.Equal(t, 512, web.Resources.Mem)
+	assert.Equal(t, []int{80}, web.Expose)
+	assert.Equal(t, diceyml.EnvMap{"LOG_LEVEL": "info"}, web.Envs)
+}
+
+func TestChartName(t *testing.T) {
+	assert.Equal(t, "demo-feature-login", chartName("Demo", "feature/login"))
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/orchestrator/conf"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/modules/orchestrator/services/apierrors"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+// CreatePreview 基于制品为 merge request 创建预览环境, 同一 merge request 重复调用时原地更新并续期
+func (r *Runtime) CreatePreview(operator user.ID, req *apistructs.PreviewRuntimeCreateRequest) (*apistructs.PreviewRuntimeDTO, error) {
+	if req.MergeID == 0 {
+		return nil, apierrors.ErrCreateRuntime.MissingParameter("mergeId")
+	}
+	if req.Workspace == "" {
+		req.Workspace = string(apistructs.DevWorkspace)
+	}
+	preview, err := r.db.GetPreviewRuntimeByMergeID(req.ApplicationID, req.MergeID)
+	if err != nil {
+		return nil, apierrors.ErrCreateRuntime.InternalError(err)
+	}
+	reserved := preview == nil
+	if reserved {
+		preview = &dbclient.PreviewRuntime{
+			ProjectID:     req.ProjectID,
+			ApplicationID: req.ApplicationID,
+			MergeID:       req.MergeID,
+			ReleaseID:     req.ReleaseID,
+			Operator:      operator.String(),
+			ExpiredAt:     previewExpiredAt(time.Now(), req.TTL),
+		}
+		if err := r.reservePreview(preview); err != nil {
+			return nil, err
+		}
+		// the reserved record is released if runtime is not created, otherwise it is kept to tear down the runtime
+		defer func() {
+			if preview.RuntimeID != 0 {
+				return
+			}
+			if err := r.db.DeletePreviewRuntime(preview.ID); err != nil {
+				logrus.Errorf("failed to release preview runtime of merge request %d, (%v)", preview.MergeID, err)
+			}
+		}()
+	}
+
+	createReq, release, err := r.releaseCreateRequest(operator, &apistructs.RuntimeReleaseCreateRequest{
+		ReleaseID:     req.ReleaseID,
+		Workspace:     req.Workspace,
+		ProjectID:     req.ProjectID,
+		ApplicationID: req.ApplicationID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	services, err := exposedServices(release.Diceyml)
+	if err != nil {
+		return nil, apierrors.ErrCreateRuntime.InvalidParameter(err)
+	}
+	createReq.Name = previewRuntimeName(req.MergeID)
+	var domains []string
+	deployment, err := r.create(operator, createReq, func(runtime *dbclient.Runtime) error {
+		// 部署前记录 runtime, 部署流程据此识别预览环境
+		preview.RuntimeID = runtime.ID
+		preview.ReleaseID = req.ReleaseID
+		preview.Operator = operator.String()
+		preview.ExpiredAt = previewExpiredAt(time.Now(), req.TTL)
+		if err := r.db.UpdatePreviewRuntime(preview); err != nil {
+			return apierrors.ErrCreateRuntime.InternalError(err)
+		}
+		// 默认域名须在部署前生成, 否则部署流程会先按 workspace 生成默认域名
+		var err error
+		if domains, err = r.domain.GenerateDefaults(runtime.ID, previewDomainLabel(req.MergeID), services); err != nil {
+			return apierrors.ErrCreateRuntime.InternalError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		if reserved && preview.RuntimeID != 0 {
+			// the runtime is not deployed, tear it down instead of leaving it orphaned
+			if teardownErr := r.teardownPreview(preview, operator.String()); teardownErr != nil {
+				logrus.Errorf("failed to teardown preview runtime %d, (%v)", preview.RuntimeID, teardownErr)
+			}
+		}
+		return nil, err
+	}
+
+	dto := convertPreviewRuntimeDTO(preview)
+	dto.DeploymentID = deployment.DeploymentID
+	dto.Domains = domains
+	return dto, nil
+}
+
+// reservePreview 在创建 runtime 前保存预览环境记录并占用项目配额, 保存后再检查配额,
+// 并发请求中 id 靠后的记录超出配额时被释放, 因此不会超出配额
+func (r *Runtime) reservePreview(preview *dbclient.PreviewRuntime) error {
+	if err := r.db.CreatePreviewRuntime(preview); err != nil {
+		return apierrors.ErrCreateRuntime.InternalError(err)
+	}
+	count, err := r.db.CountPreviewRuntimesUpTo(preview.ProjectID, preview.ID)
+	if err == nil && count <= conf.PreviewRuntimeQuota() {
+		return nil
+	}
+	if err := r.db.DeletePreviewRuntime(preview.ID); err != nil {
+		logrus.Errorf("failed to release preview runtime of merge request %d, (%v)", preview.MergeID, err)
+	}
+	if err != nil {
+		return apierrors.ErrCreateRuntime.InternalError(err)
+	}
+	return apierrors.ErrCreateRuntime.InvalidState(
+		fmt.Sprintf("preview runtime quota exceeded, project %d already has %d", preview.ProjectID, count-1))
+}
+
+// TeardownPreview 回收 merge request 对应的预览环境, 不存在时忽略
+func (r *Runtime) TeardownPreview(applicationID, mergeID uint64, operator string) error {
+	preview, err := r.db.GetPreviewRuntimeByMergeID(applicationID, mergeID)
+	if err != nil {
+		return apierrors.ErrDeleteRuntime.InternalError(err)
+	}
+	if preview == nil {
+		return nil
+	}
+	if operator == "" {
+		operator = preview.Operator
+	}
+	if err := r.teardownPreview(preview, operator); err != nil {
+		return apierrors.ErrDeleteRuntime.InternalError(err)
+	}
+	return nil
+}
+
+// PreviewGC 定时回收过期的预览环境, 同时清理 runtime 已被删除的预览记录
+func (r *Runtime) PreviewGC() {
+	defer func() {
+		if err := recover(); err != nil {
+			debug.PrintStack()
+			logrus.Errorf("[alert] failed to gc preview runtimes, panic: %v", err)
+		}
+	}()
+
+	bulk := 100
+	lastID := uint64(0)
+	now := time.Now()
+	for {
+		previews, err := r.db.FindPreviewRuntimesNewerThan(lastID, bulk)
+		if err != nil {
+			logrus.Errorf("[alert] failed to find preview runtimes after: %v, (%v)", lastID, err)
+			break
+		}
+		for i := range previews {
+			if !previews[i].ExpiredAt.Before(now) {
+				continue
+			}
+			logrus.Infof("preview runtime expired, runtimeID: %d, mergeID: %d, expiredAt: %v",
+				previews[i].RuntimeID, previews[i].MergeID, previews[i].ExpiredAt)
+			if err := r.teardownPreview(&previews[i], previews[i].Operator); err != nil {
+				logrus.Errorf("failed to teardown preview runtime %d, (%v)", previews[i].RuntimeID, err)
+			}
+		}
+		if len(previews) < bulk {
+			// ended
+			break
+		}
+		lastID = previews[len(previews)-1].ID
+	}
+}
+
+func (r *Runtime) teardownPreview(preview *dbclient.PreviewRuntime, operator string) error {
+	runtime, err := r.db.GetRuntimeAllowNil(preview.RuntimeID)
+	if err != nil {
+		return err
+	}
+	if runtime != nil {
+		app, err := r.bdl.GetApp(runtime.ApplicationID)
+		if err != nil {
+			return err
+		}
+		if err := r.markDeleting(runtime, app, operator); err != nil {
+			return err
+		}
+	}
+	return r.db.DeletePreviewRuntime(preview.ID)
+}
+
+func previewRuntimeName(mergeID uint64) string {
+	return "mr-" + strconv.FormatUint(mergeID, 10)
+}
+
+func previewDomainLabel(mergeID uint64) string {
+	return "mr" + strconv.FormatUint(mergeID, 10)
+}
+
+// previewExpiredAt ttl 单位小时, 非正数时使用默认配置
+func previewExpiredAt(now time.Time, ttl int64) time.Time {
+	if ttl <= 0 {
+		ttl = conf.PreviewRuntimeTTL()
+	}
+	return now.Add(time.Duration(ttl) * time.Hour)
+}
+
+// exposedServices 返回 dice.yml 中需要对外暴露的服务名
+func exposedServices(yml string) ([]string, error) {
+	dice, err := diceyml.New([]byte(yml), false)
+	if err != nil {
+		return nil, err
+	}
+	var services []string
+	for name, service := range dice.Obj().Services {
+		if len(service.Expose) > 0 {
+			services = append(services, name)
+		}
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+func convertPreviewRuntimeDTO(preview *dbclient.PreviewRuntime) *apistructs.PreviewRuntimeDTO {
+	return &apistructs.PreviewRuntimeDTO{
+		ID:            preview.ID,
+		ProjectID:     preview.ProjectID,
+		ApplicationID: preview.ApplicationID,
+		MergeID:       preview.MergeID,
+		RuntimeID:     preview.RuntimeID,
+		ReleaseID:     preview.ReleaseID,
+		ExpiredAt:     preview.ExpiredAt,
+	}
+}
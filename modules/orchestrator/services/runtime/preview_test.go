@@ -0,0 +1,195 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/bundle"
+	"github.com/erda-project/erda/modules/orchestrator/conf"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/modules/orchestrator/events"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/dbengine"
+	"github.com/erda-project/erda/pkg/discover"
+	"github.com/erda-project/erda/pkg/httpclient"
+)
+
+func TestExposedServices(t *testing.T) {
+	yml := `version: 2.0
+services:
+  web:
+    image: nginx
+    ports:
+      - 80
+    expose:
+      - 80
+    resources:
+      cpu: 0.1
+      mem: 128
+  api:
+    image: api
+    ports:
+      - 8080
+    expose:
+      - 8080
+    resources:
+      cpu: 0.1
+      mem: 128
+  worker:
+    image: worker
+    resources:
+      cpu: 0.1
+      mem: 128
+`
+	services, err := exposedServices(yml)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"api", "web"}, services)
+}
+
+func TestPreviewExpiredAt(t *testing.T) {
+	now := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, now.Add(2*time.Hour), previewExpiredAt(now, 2))
+	assert.Equal(t, "mr-12", previewRuntimeName(12))
+	assert.Equal(t, "mr12", previewDomainLabel(12))
+}
+
+// newTestPreviewRuntime 返回基于内存 sqlite 和 fake cmdb 的 Runtime, 预览环境配额为 quota
+func newTestPreviewRuntime(t *testing.T, quota string) (*Runtime, *dbclient.DBClient) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	assert.NoError(t, db.AutoMigrate(&dbclient.PreviewRuntime{}, &dbclient.Runtime{}).Error)
+	client := &dbclient.DBClient{DBEngine: &dbengine.DBEngine{DB: db}}
+
+	cmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true,"data":{"id":1,"name":"app"}}`))
+	}))
+	t.Cleanup(cmdb.Close)
+	t.Setenv(discover.EnvCMDB, strings.TrimPrefix(cmdb.URL, "http://"))
+	t.Setenv("PREVIEW_RUNTIME_QUOTA", quota)
+	conf.Load()
+
+	bdl := bundle.New(bundle.WithCMDB(), bundle.WithHTTPClient(httpclient.New()))
+	return New(
+		WithDBClient(client),
+		WithBundle(bdl),
+		WithEventManager(events.NewEventManager(10, nil, client, bdl)),
+	), client
+}
+
+func countPreviews(t *testing.T, db *dbclient.DBClient) int {
+	var count int
+	assert.NoError(t, db.Model(&dbclient.PreviewRuntime{}).Count(&count).Error)
+	return count
+}
+
+func TestCreatePreviewQuota(t *testing.T) {
+	r, db := newTestPreviewRuntime(t, "1")
+	assert.NoError(t, db.CreatePreviewRuntime(&dbclient.PreviewRuntime{ProjectID: 1, ApplicationID: 1, MergeID: 1, RuntimeID: 1}))
+
+	// over quota, the reserved record is released
+	_, err := r.CreatePreview(user.ID("1"), &apistructs.PreviewRuntimeCreateRequest{ProjectID: 1, ApplicationID: 1, MergeID: 2})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "quota exceeded")
+	assert.Equal(t, 1, countPreviews(t, db))
+
+	// records of other projects are not counted
+	preview := &dbclient.PreviewRuntime{ProjectID: 2, ApplicationID: 2, MergeID: 1}
+	assert.NoError(t, r.reservePreview(preview))
+	assert.NotZero(t, preview.ID)
+	assert.Equal(t, 2, countPreviews(t, db))
+
+	// the later one of concurrent reservations exceeds quota and is released, the earlier one is kept
+	later := &dbclient.PreviewRuntime{ProjectID: 2, ApplicationID: 2, MergeID: 2}
+	assert.Error(t, r.reservePreview(later))
+	assert.Equal(t, 2, countPreviews(t, db))
+}
+
+func TestCreatePreviewReleaseOnFailure(t *testing.T) {
+	r, db := newTestPreviewRuntime(t, "5")
+
+	// runtime is not created as release can not be fetched, the reserved record is released
+	_, err := r.CreatePreview(user.ID("1"), &apistructs.PreviewRuntimeCreateRequest{
+		ProjectID: 1, ApplicationID: 1, MergeID: 2, ReleaseID: "not-exist",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 0, countPreviews(t, db))
+
+	// existed record is kept on failure
+	assert.NoError(t, db.CreatePreviewRuntime(&dbclient.PreviewRuntime{ProjectID: 1, ApplicationID: 1, MergeID: 3, RuntimeID: 1}))
+	_, err = r.CreatePreview(user.ID("1"), &apistructs.PreviewRuntimeCreateRequest{
+		ProjectID: 1, ApplicationID: 1, MergeID: 3, ReleaseID: "not-exist",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, countPreviews(t, db))
+}
+
+func TestTeardownPreview(t *testing.T) {
+	r, db := newTestPreviewRuntime(t, "5")
+	runtime := &dbclient.Runtime{Name: "mr-1", ApplicationID: 1, Workspace: "DEV", ProjectID: 1, Creator: "1", OrgID: 1}
+	assert.NoError(t, db.CreateRuntime(runtime))
+	assert.NoError(t, db.CreatePreviewRuntime(&dbclient.PreviewRuntime{ProjectID: 1, ApplicationID: 1, MergeID: 1, RuntimeID: runtime.ID, Operator: "1"}))
+	// runtime of merge request 2 is already deleted
+	assert.NoError(t, db.CreatePreviewRuntime(&dbclient.PreviewRuntime{ProjectID: 1, ApplicationID: 1, MergeID: 2, RuntimeID: 100}))
+
+	// not existed preview is ignored
+	assert.NoError(t, r.TeardownPreview(1, 3, ""))
+	assert.Equal(t, 2, countPreviews(t, db))
+
+	// deployment recognizes the runtime as a preview by its runtime id
+	preview, err := db.GetPreviewRuntimeByRuntimeID(runtime.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), preview.MergeID)
+
+	assert.NoError(t, r.TeardownPreview(1, 1, ""))
+	deleting, err := db.GetRuntime(runtime.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, dbclient.LegacyStatusDeleting, deleting.LegacyStatus)
+	preview, err = db.GetPreviewRuntimeByMergeID(1, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, preview)
+	preview, err = db.GetPreviewRuntimeByRuntimeID(runtime.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, preview)
+
+	assert.NoError(t, r.TeardownPreview(1, 2, ""))
+	assert.Equal(t, 0, countPreviews(t, db))
+}
+
+func TestPreviewGC(t *testing.T) {
+	r, db := newTestPreviewRuntime(t, "5")
+	now := time.Now()
+	assert.NoError(t, db.CreatePreviewRuntime(&dbclient.PreviewRuntime{ProjectID: 1, ApplicationID: 1, MergeID: 1, RuntimeID: 100, ExpiredAt: now.Add(-time.Hour)}))
+	assert.NoError(t, db.CreatePreviewRuntime(&dbclient.PreviewRuntime{ProjectID: 1, ApplicationID: 1, MergeID: 2, RuntimeID: 101, ExpiredAt: now.Add(time.Hour)}))
+
+	r.PreviewGC()
+
+	expired, err := db.GetPreviewRuntimeByMergeID(1, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, expired)
+	alive, err := db.GetPreviewRuntimeByMergeID(1, 2)
+	assert.NoError(t, err)
+	assert.NotNil(t, alive)
+}
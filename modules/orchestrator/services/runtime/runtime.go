@@ -34,6 +34,8 @@ import (
 	"github.com/erda-project/erda/modules/orchestrator/events"
 	"github.com/erda-project/erda/modules/orchestrator/services/addon"
 	"github.com/erda-project/erda/modules/orchestrator/services/apierrors"
+	"github.com/erda-project/erda/modules/orchestrator/services/domain"
+	"github.com/erda-project/erda/modules/orchestrator/services/freeze"
 	"github.com/erda-project/erda/modules/orchestrator/spec"
 	"github.com/erda-project/erda/modules/orchestrator/utils"
 	"github.com/erda-project/erda/modules/pkg/diceworkspace"
@@ -45,10 +47,12 @@ import (
 
 // Runtime 应用实例对象封装
 type Runtime struct {
-	db    *dbclient.DBClient
-	evMgr *events.EventManager
-	bdl   *bundle.Bundle
-	addon *addon.Addon
+	db     *dbclient.DBClient
+	evMgr  *events.EventManager
+	bdl    *bundle.Bundle
+	addon  *addon.Addon
+	domain *domain.Domain
+	freeze *freeze.Freeze
 }
 
 // Option 应用实例对象配置选项
@@ -91,6 +95,20 @@ func WithAddon(a *addon.Addon) Option {
 	}
 }
 
+// WithDomain 配置 domain service
+func WithDomain(d *domain.Domain) Option {
+	return func(r *Runtime) {
+		r.domain = d
+	}
+}
+
+// WithFreeze 配置部署冻结窗口 service
+func WithFreeze(f *freeze.Freeze) Option {
+	return func(r *Runtime) {
+		r.freeze = f
+	}
+}
+
 func (r *Runtime) CreateByReleaseIDPipeline(orgid uint64, operator user.ID, releaseReq *apistructs.RuntimeReleaseCreateRequest) (apistructs.RuntimeReleaseCreatePipelineResponse, error) {
 	releaseResp, err := r.bdl.GetRelease(releaseReq.ReleaseID)
 	if err != nil {
@@ -205,39 +223,49 @@ func (r *Runtime) CreateByReleaseIDPipeline(orgid uint64, operator user.ID, rele
 
 // Create 创建应用实例
 func (r *Runtime) CreateByReleaseID(operator user.ID, releaseReq *apistructs.RuntimeReleaseCreateRequest) (*apistructs.DeploymentCreateResponseDTO, error) {
-	releaseResp, err := r.bdl.GetRelease(releaseReq.ReleaseID)
+	req, _, err := r.releaseCreateRequest(operator, releaseReq)
 	if err != nil {
 		return nil, err
 	}
+	return r.Create(operator, req)
+}
+
+// releaseCreateRequest 校验制品并构造基于制品部署的 runtime 创建请求
+func (r *Runtime) releaseCreateRequest(operator user.ID, releaseReq *apistructs.RuntimeReleaseCreateRequest) (
+	*apistructs.RuntimeCreateRequest, *apistructs.ReleaseGetResponseData, error) {
+	releaseResp, err := r.bdl.GetRelease(releaseReq.ReleaseID)
+	if err != nil {
+		return nil, nil, err
+	}
 	if releaseReq == nil {
-		return nil, errors.Errorf("releaseId does not exist")
+		return nil, nil, errors.Errorf("releaseId does not exist")
 	}
 	if releaseReq.ProjectID != uint64(releaseResp.ProjectID) {
-		return nil, errors.Errorf("release does not correspond to the project")
+		return nil, nil, errors.Errorf("release does not correspond to the project")
 	}
 	if releaseReq.ApplicationID != uint64(releaseResp.ApplicationID) {
-		return nil, errors.Errorf("release does not correspond to the application")
+		return nil, nil, errors.Errorf("release does not correspond to the application")
 	}
 	branchWorkspaces, err := r.bdl.GetAllValidBranchWorkspace(releaseReq.ApplicationID)
 	if err != nil {
-		return nil, apierrors.ErrCreateRuntime.InternalError(err)
+		return nil, nil, apierrors.ErrCreateRuntime.InternalError(err)
 	}
 	_, validArtifactWorkspace := gitflowutil.IsValidBranchWorkspace(branchWorkspaces, apistructs.DiceWorkspace(releaseReq.Workspace))
 	if !validArtifactWorkspace {
-		return nil, errors.Errorf("release does not correspond to the workspace")
+		return nil, nil, errors.Errorf("release does not correspond to the workspace")
 	}
 
 	projectInfo, err := r.bdl.GetProject(releaseReq.ProjectID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if projectInfo == nil {
-		return nil, errors.Errorf("The project is illegal")
+		return nil, nil, errors.Errorf("The project is illegal")
 	}
 
 	wsCluster, ok := projectInfo.ClusterConfig[releaseReq.Workspace]
 	if !ok {
-		return nil, fmt.Errorf("workspace corresponding cluster is empty")
+		return nil, nil, fmt.Errorf("workspace corresponding cluster is empty")
 	}
 	var targetClusterName string
 	// 跨集群部署
@@ -248,7 +276,7 @@ func (r *Runtime) CreateByReleaseID(operator user.ID, releaseReq *apistructs.Run
 		// 在制品所属集群部署
 		// 校验制品所属集群和环境对应集群是否相同
 		if releaseResp.ClusterName != wsCluster {
-			return nil, fmt.Errorf("release does not correspond to the cluster")
+			return nil, nil, fmt.Errorf("release does not correspond to the cluster")
 		}
 		targetClusterName = releaseResp.ClusterName
 	}
@@ -270,11 +298,17 @@ func (r *Runtime) CreateByReleaseID(operator user.ID, releaseReq *apistructs.Run
 	extra.DeployType = "RELEASE"
 	req.Extra = extra
 
-	return r.Create(operator, &req)
+	return &req, releaseResp, nil
 }
 
 // Create 创建应用实例
 func (r *Runtime) Create(operator user.ID, req *apistructs.RuntimeCreateRequest) (
+	*apistructs.DeploymentCreateResponseDTO, error) {
+	return r.create(operator, req, nil)
+}
+
+// create 创建应用实例, prepare 不为空时在 runtime 记录就绪后、发起部署前调用, 返回错误时不再部署
+func (r *Runtime) create(operator user.ID, req *apistructs.RuntimeCreateRequest, prepare func(*dbclient.Runtime) error) (
 	*apistructs.DeploymentCreateResponseDTO, error) {
 	// TODO: 需要等 pipeline action 调用走内网后，再从 header 中取 User-ID (operator)
 	// TODO: should not assign like this
@@ -357,6 +391,11 @@ func (r *Runtime) Create(operator user.ID, req *apistructs.RuntimeCreateRequest)
 			return nil, apierrors.ErrCreateRuntime.InvalidState("正在部署中，请不要重复部署")
 		}
 	}
+	if prepare != nil {
+		if err := prepare(runtime); err != nil {
+			return nil, err
+		}
+	}
 	deploytype := "BUILD"
 	if req.Extra.DeployType == "RELEASE" {
 		deploytype = "RELEASE"
@@ -653,6 +692,11 @@ func (r *Runtime) doDeployRuntime(ctx *DeployContext) (*apistructs.DeploymentCre
 	if err != nil {
 		return nil, apierrors.ErrRollbackRuntime.InternalError(err)
 	}
+	// 检查是否处于部署冻结窗口
+	frozen, err := r.checkDeployFrozen(ctx.Runtime.OrgID, ctx.Runtime)
+	if err != nil {
+		return nil, apierrors.ErrDeployRuntime.InternalError(err)
+	}
 	status := apistructs.DeploymentStatusWaiting
 	reason := ""
 	needApproval := false
@@ -664,6 +708,9 @@ func (r *Runtime) doDeployRuntime(ctx *DeployContext) (*apistructs.DeploymentCre
 	if blocked {
 		status = apistructs.DeploymentStatusFailed
 		reason = "企业封网中,无法部署"
+	} else if frozen != "" {
+		status = apistructs.DeploymentStatusFailed
+		reason = frozen
 	} else {
 		// 检查 branchrule 来判断是否需要审批
 		if branch.NeedApproval {
@@ -792,6 +839,21 @@ func (r *Runtime) doDeployRuntime(ctx *DeployContext) (*apistructs.DeploymentCre
 	}, nil
 }
 
+// checkDeployFrozen 检查 runtime 所在环境是否处于部署冻结窗口, 冻结中返回原因
+func (r *Runtime) checkDeployFrozen(orgID uint64, runtime *dbclient.Runtime) (string, error) {
+	if r.freeze == nil {
+		return "", nil
+	}
+	result, err := r.freeze.Check(orgID, runtime.ProjectID, runtime.Workspace, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if result.Allowed {
+		return "", nil
+	}
+	return result.Reason, nil
+}
+
 func (r *Runtime) checkOrgDeployBlocked(orgID uint64, runtime *dbclient.Runtime) (bool, error) {
 	org, err := r.bdl.GetOrg(orgID)
 	if err != nil {
@@ -981,6 +1043,7 @@ func (r *Runtime) Rollback(operator user.ID, orgID uint64, runtimeID uint64, dep
 	if err != nil {
 		return nil, apierrors.ErrRollbackRuntime.InternalError(err)
 	}
+	// 回滚到已成功的版本用于恢复故障(包括部署后验证失败的自动回滚), 不受部署冻结窗口限制
 	status := apistructs.DeploymentStatusWaiting
 	reason := ""
 	needApproval := false
@@ -1140,23 +1203,31 @@ func (r *Runtime) Delete(operator user.ID, orgID uint64, runtimeID uint64) (*api
 	if !perm.Access {
 		return nil, apierrors.ErrDeleteRuntime.AccessDenied()
 	}
+	if err := r.markDeleting(runtime, app, operator.String()); err != nil {
+		return nil, apierrors.ErrDeleteRuntime.InternalError(err)
+	}
+	return dbclient.ConvertRuntimeDTO(runtime, app), nil
+}
+
+// markDeleting 将 runtime 标记为 DELETING, 由 PushOnDeletingRuntimes 异步摧毁
+func (r *Runtime) markDeleting(runtime *dbclient.Runtime, app *apistructs.ApplicationDTO, operator string) error {
 	if runtime.LegacyStatus == dbclient.LegacyStatusDeleting {
 		// already marked
-		return dbclient.ConvertRuntimeDTO(runtime, app), nil
+		return nil
 	}
 	// set status to DELETING
 	runtime.LegacyStatus = dbclient.LegacyStatusDeleting
 	if err := r.db.UpdateRuntime(runtime); err != nil {
-		return nil, apierrors.ErrDeleteRuntime.InternalError(err)
+		return err
 	}
 	event := events.RuntimeEvent{
 		EventName: events.RuntimeDeleting,
 		Runtime:   dbclient.ConvertRuntimeDTO(runtime, app),
-		Operator:  operator.String(),
+		Operator:  operator,
 	}
 	r.evMgr.EmitEvent(&event)
 	// TODO: should emit RuntimeDeleted after really deleted or RuntimeDeleteFailed if failed
-	return dbclient.ConvertRuntimeDTO(runtime, app), nil
+	return nil
 }
 
 // Destroy 摧毁应用实例
@@ -1223,6 +1294,9 @@ func (r *Runtime) Destroy(runtimeID uint64) error {
 	if err := r.db.DeleteRuntime(runtimeID); err != nil {
 		return err
 	}
+	if err := r.db.DeleteRuntimeDrift(runtimeID); err != nil {
+		logrus.Errorf("failed to delete drift of runtime %d, (%v)", runtimeID, err)
+	}
 	event := events.RuntimeEvent{
 		EventName: events.RuntimeDeleted,
 		Runtime:   dbclient.ConvertRuntimeDTO(runtime, app),
@@ -1481,7 +1555,7 @@ func (r *Runtime) Get(userID user.ID, orgID uint64, idOrName string, appID strin
 	if sg != nil {
 		for _, v := range sg.Services {
 			statusServiceMap[v.Name] = string(v.StatusDesc.Status)
-			replicaMap[v.Name] = v.Scale
+			replicaMap[v.Name] = serviceReplicas(v)
 		}
 	}
 	for k, v := range dice.Services {
@@ -1634,6 +1708,14 @@ func (r *Runtime) findRuntimeByIDOrName(idOrName string, appIDStr string, worksp
 	return r.db.FindRuntime(spec.RuntimeUniqueId{Name: name, Workspace: workspace, ApplicationId: appID})
 }
 
+// serviceReplicas 服务的实际副本数, 开启 autoscaling 后以 scheduler 返回的当前副本数为准
+func serviceReplicas(svc apistructs.Service) int {
+	if svc.CurrentReplicas > 0 {
+		return svc.CurrentReplicas
+	}
+	return svc.Scale
+}
+
 func convertInternalAddrs(sg *apistructs.ServiceGroup, serviceName string) []string {
 	addrs := make([]string, 0)
 	if sg == nil {
@@ -15,11 +15,24 @@
 package runtime
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jinzhu/gorm"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/bundle"
+	"github.com/erda-project/erda/modules/orchestrator/dbclient"
+	"github.com/erda-project/erda/modules/orchestrator/events"
+	"github.com/erda-project/erda/modules/orchestrator/services/freeze"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/dbengine"
+	"github.com/erda-project/erda/pkg/discover"
+	"github.com/erda-project/erda/pkg/httpclient"
 )
 
 func TestModifyStatusIfNotForDisplay(t *testing.T) {
@@ -37,3 +50,61 @@ func TestModifyStatusIfNotForDisplay(t *testing.T) {
 		assert.Equal(t, "Stopped", s.Status)
 	}
 }
+
+func TestDeployFreezeExemptsRollback(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.AutoMigrate(&dbclient.Runtime{}, &dbclient.Deployment{},
+		&dbclient.DeployFreezeWindow{}, &dbclient.DeployFreezeOverride{}).Error)
+	client := &dbclient.DBClient{DBEngine: &dbengine.DBEngine{DB: db}}
+
+	cmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/permissions/actions/check":
+			w.Write([]byte(`{"success":true,"data":{"access":true}}`))
+		case "/api/branch-rules":
+			w.Write([]byte(`{"success":true,"data":[]}`))
+		default:
+			w.Write([]byte(`{"success":true,"data":{"id":1,"name":"app"}}`))
+		}
+	}))
+	defer cmdb.Close()
+	t.Setenv(discover.EnvCMDB, strings.TrimPrefix(cmdb.URL, "http://"))
+	bdl := bundle.New(bundle.WithCMDB(), bundle.WithHTTPClient(httpclient.New()))
+	r := New(
+		WithDBClient(client),
+		WithBundle(bdl),
+		WithEventManager(events.NewEventManager(10, nil, client, bdl)),
+		WithFreeze(freeze.New(freeze.WithDBClient(client), freeze.WithBundle(bdl))),
+	)
+
+	runtime := dbclient.Runtime{Name: "master", ApplicationID: 1, Workspace: "PROD", OrgID: 1, ProjectID: 1}
+	assert.NoError(t, db.Create(&runtime).Error)
+	deployed := dbclient.Deployment{RuntimeId: runtime.ID, Status: apistructs.DeploymentStatusOK}
+	assert.NoError(t, db.Create(&deployed).Error)
+	start := time.Now().Add(-time.Hour)
+	end := start.Add(2 * time.Hour)
+	assert.NoError(t, client.CreateDeployFreezeWindow(&dbclient.DeployFreezeWindow{OrgID: 1, Name: "release",
+		Type: apistructs.DeployFreezeOnce, StartAt: &start, EndAt: &end, Enabled: true}))
+
+	// 冻结期间禁止部署
+	frozen, err := r.checkDeployFrozen(1, &runtime)
+	assert.NoError(t, err)
+	assert.Contains(t, frozen, "release")
+
+	// 回滚不受冻结窗口限制
+	result, err := r.Rollback(user.ID("1"), 1, runtime.ID, deployed.ID)
+	assert.NoError(t, err)
+	rollback, err := client.GetDeployment(result.DeploymentID)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.DeploymentStatusWaiting, rollback.Status)
+	assert.Empty(t, rollback.FailCause)
+}
+
+func TestServiceReplicas(t *testing.T) {
+	assert.Equal(t, 2, serviceReplicas(apistructs.Service{Scale: 2}))
+	// replicas scaled by hpa
+	assert.Equal(t, 5, serviceReplicas(apistructs.Service{Scale: 2, CurrentReplicas: 5}))
+}
@@ -0,0 +1,245 @@
+CREATE TABLE IF NOT EXISTS `ps_v2_preview_runtimes`
+(
+    `id`             BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`     DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`     DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `project_id`     BIGINT(20) UNSIGNED NOT NULL COMMENT 'project id',
+    `application_id` BIGINT(20) UNSIGNED NOT NULL COMMENT 'application id',
+    `merge_id`       BIGINT(20) UNSIGNED NOT NULL COMMENT 'merge request id inside the repo',
+    `runtime_id`     BIGINT(20) UNSIGNED NOT NULL COMMENT 'preview runtime id',
+    `release_id`     VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'last deployed release id',
+    `operator`       VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'user who requested the preview',
+    `expired_at`     DATETIME            NOT NULL COMMENT 'preview runtime is destroyed after this time',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_unique_app_id_merge_id` (`application_id`, `merge_id`),
+    KEY `idx_project_id` (`project_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='merge request preview runtimes';
+
+CREATE TABLE IF NOT EXISTS `ps_v2_deploy_freeze_windows`
+(
+    `id`            BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`    DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`    DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `org_id`        BIGINT(20) UNSIGNED NOT NULL COMMENT 'org id',
+    `project_id`    BIGINT(20) UNSIGNED NOT NULL DEFAULT 0 COMMENT 'project id, 0 means all projects of the org',
+    `workspace`     VARCHAR(32)         NOT NULL DEFAULT '' COMMENT 'workspace, empty means all workspaces',
+    `name`          VARCHAR(255)        NOT NULL COMMENT 'window name',
+    `description`   VARCHAR(1024)       NOT NULL DEFAULT '' COMMENT 'window description',
+    `type`          VARCHAR(32)         NOT NULL COMMENT 'ONCE or WEEKLY',
+    `start_at`      DATETIME            NULL COMMENT 'start time of ONCE window',
+    `end_at`        DATETIME            NULL COMMENT 'end time of ONCE window',
+    `start_weekday` INT(11)             NOT NULL DEFAULT 0 COMMENT 'start weekday of WEEKLY window, 0 is sunday',
+    `start_time`    VARCHAR(8)          NOT NULL DEFAULT '' COMMENT 'start time of WEEKLY window, HH:MM',
+    `end_weekday`   INT(11)             NOT NULL DEFAULT 0 COMMENT 'end weekday of WEEKLY window, 0 is sunday',
+    `end_time`      VARCHAR(8)          NOT NULL DEFAULT '' COMMENT 'end time of WEEKLY window, HH:MM',
+    `timezone`      VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'timezone of WEEKLY window',
+    `enabled`       TINYINT(1)          NOT NULL DEFAULT 1 COMMENT 'whether the window is enforced',
+    `creator`       VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'creator user id',
+    PRIMARY KEY (`id`),
+    KEY `idx_org_id` (`org_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='deployment freeze windows';
+
+CREATE TABLE IF NOT EXISTS `ps_v2_deploy_freeze_overrides`
+(
+    `id`              BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`      DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`      DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `org_id`          BIGINT(20) UNSIGNED NOT NULL COMMENT 'org id',
+    `project_id`      BIGINT(20) UNSIGNED NOT NULL COMMENT 'project id',
+    `workspace`       VARCHAR(32)         NOT NULL COMMENT 'workspace',
+    `reason`          TEXT                NOT NULL COMMENT 'why deploying during freeze is needed',
+    `status`          VARCHAR(32)         NOT NULL COMMENT 'WaitApprove, Accept or Reject',
+    `applicant`       VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'applicant user id',
+    `approver`        VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'approver user id',
+    `approval_reason` VARCHAR(1024)       NOT NULL DEFAULT '' COMMENT 'approval comment',
+    `duration`        BIGINT(20)          NOT NULL DEFAULT 0 COMMENT 'valid minutes after approved',
+    `start_at`        DATETIME            NULL COMMENT 'override valid from',
+    `end_at`          DATETIME            NULL COMMENT 'override valid until',
+    PRIMARY KEY (`id`),
+    KEY `idx_project_id` (`project_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='emergency overrides of deployment freeze windows';
+
+CREATE TABLE IF NOT EXISTS `ps_v2_runtime_drifts`
+(
+    `id`          BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`  DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`  DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `runtime_id`  BIGINT(20) UNSIGNED NOT NULL COMMENT 'runtime id',
+    `report_hash` VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'hash of the last drift report emitted, empty if not drifted',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_runtime_id` (`runtime_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='drift of runtimes from declared spec';
+
+CREATE TABLE IF NOT EXISTS `ps_v2_scan_leases`
+(
+    `id`         BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at` DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at` DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `name`       VARCHAR(64)         NOT NULL COMMENT 'name of the scheduled scan',
+    `holder`     VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'instance holding the lease',
+    `expired_at` DATETIME            NOT NULL COMMENT 'lease valid until',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_name` (`name`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='leases of scheduled scans running on one instance at a time';
+
+CREATE TABLE IF NOT EXISTS `tb_addon_backup_policy`
+(
+    `id`                BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`        DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`        DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `addon_id`          VARCHAR(64)         NOT NULL COMMENT 'addon routing instance id',
+    `addon_name`        VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'addon name, e.g. mysql',
+    `schedule`          VARCHAR(64)         NOT NULL COMMENT 'cron expression',
+    `retention`         INT(11)             NOT NULL DEFAULT 7 COMMENT 'number of scheduled backups to keep',
+    `endpoint`          VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'object storage endpoint',
+    `bucket`            VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'object storage bucket',
+    `access_key`        VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'object storage access key',
+    `secret_key`        VARCHAR(1024)       NOT NULL DEFAULT '' COMMENT 'encrypted object storage secret key',
+    `path_prefix`       VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'object name prefix of backup files',
+    `enabled`           TINYINT(1)          NOT NULL DEFAULT 1 COMMENT 'whether scheduled backup is enabled',
+    `last_scheduled_at` DATETIME            NULL COMMENT 'last time a scheduled backup was triggered',
+    `creator`           VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'creator user id',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_addon_id` (`addon_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='addon backup policies';
+
+CREATE TABLE IF NOT EXISTS `tb_addon_backup`
+(
+    `id`          BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`  DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`  DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `addon_id`    VARCHAR(64)         NOT NULL COMMENT 'addon routing instance id',
+    `instance_id` VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'addon real instance id',
+    `addon_name`  VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'addon name, e.g. mysql',
+    `type`        VARCHAR(32)         NOT NULL COMMENT 'SCHEDULED or MANUAL',
+    `status`      VARCHAR(32)         NOT NULL COMMENT 'PENDING, RUNNING, SUCCESS or FAILED',
+    `endpoint`    VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'object storage endpoint',
+    `bucket`      VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'object storage bucket',
+    `access_key`  VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'object storage access key used by the backup',
+    `secret_key`  VARCHAR(512)        NOT NULL DEFAULT '' COMMENT 'encrypted object storage secret key used by the backup',
+    `object_name` VARCHAR(512)        NOT NULL DEFAULT '' COMMENT 'object name of backup file',
+    `job_name`    VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'scheduler job name',
+    `message`     TEXT                NULL COMMENT 'failure message',
+    `operator`    VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'operator user id, empty for scheduled backups',
+    `started_at`  DATETIME            NULL COMMENT 'job started time',
+    `finished_at` DATETIME            NULL COMMENT 'job finished time',
+    PRIMARY KEY (`id`),
+    KEY `idx_addon_id` (`addon_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='addon backups';
+
+CREATE TABLE IF NOT EXISTS `tb_addon_restore`
+(
+    `id`              BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`      DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`      DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `backup_id`       BIGINT(20) UNSIGNED NOT NULL COMMENT 'restored backup id',
+    `source_addon_id` VARCHAR(64)         NOT NULL COMMENT 'addon routing instance id the backup belongs to',
+    `target_addon_id` VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'addon routing instance id restored to',
+    `status`          VARCHAR(32)         NOT NULL COMMENT 'PENDING, RUNNING, SUCCESS or FAILED',
+    `job_name`        VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'scheduler job name',
+    `message`         TEXT                NULL COMMENT 'failure message',
+    `operator`        VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'operator user id',
+    `started_at`      DATETIME            NULL COMMENT 'job started time',
+    `finished_at`     DATETIME            NULL COMMENT 'job finished time',
+    PRIMARY KEY (`id`),
+    KEY `idx_source_addon_id` (`source_addon_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='addon restores';
+
+CREATE TABLE IF NOT EXISTS `tb_addon_scale_record`
+(
+    `id`            BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`    DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`    DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `addon_id`      VARCHAR(64)         NOT NULL COMMENT 'addon routing instance id',
+    `instance_id`   VARCHAR(64)         NOT NULL COMMENT 'addon real instance id',
+    `addon_name`    VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'addon name',
+    `from_plan`     VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'plan before scale',
+    `from_cpu`      DOUBLE              NOT NULL DEFAULT 0 COMMENT 'cpu per node before scale',
+    `from_mem`      INT(11)             NOT NULL DEFAULT 0 COMMENT 'memory(MB) per node before scale',
+    `from_replicas` INT(11)             NOT NULL DEFAULT 0 COMMENT 'node count before scale',
+    `to_plan`       VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'plan after scale',
+    `to_cpu`        DOUBLE              NOT NULL DEFAULT 0 COMMENT 'cpu per node after scale',
+    `to_mem`        INT(11)             NOT NULL DEFAULT 0 COMMENT 'memory(MB) per node after scale',
+    `to_replicas`   INT(11)             NOT NULL DEFAULT 0 COMMENT 'node count after scale',
+    `status`        VARCHAR(32)         NOT NULL COMMENT 'RUNNING, SUCCESS or FAILED',
+    `message`       TEXT                NULL COMMENT 'failure message',
+    `operator`      VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'operator user id',
+    `finished_at`   DATETIME            NULL COMMENT 'finished time',
+    PRIMARY KEY (`id`),
+    KEY `idx_addon_id` (`addon_id`),
+    KEY `idx_instance_id` (`instance_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='addon plan change and scale records';
+
+CREATE TABLE IF NOT EXISTS `tb_addon_credential_rotation`
+(
+    `id`            BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`    DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`    DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `addon_id`      VARCHAR(64)         NOT NULL COMMENT 'addon routing instance id',
+    `instance_id`   VARCHAR(64)         NOT NULL COMMENT 'addon real instance id',
+    `addon_name`    VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'addon name',
+    `org_id`        VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'org id',
+    `status`        VARCHAR(32)         NOT NULL COMMENT 'RUNNING, SUCCESS or FAILED',
+    `stage`         VARCHAR(32)         NOT NULL COMMENT 'APPLYING, RESTARTING, GRACE or DONE',
+    `dual_password` TINYINT(1)          NOT NULL DEFAULT 0 COMMENT 'whether mysql dual password is used',
+    `user`          VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'mysql user used by runtimes after rotation',
+    `old_user`      VARCHAR(64)         NOT NULL DEFAULT '' COMMENT 'mysql user switched from, dropped after grace period',
+    `grace_period`  INT(11)             NOT NULL DEFAULT 0 COMMENT 'grace period of the old password in minutes',
+    `grace_until`   DATETIME            NULL COMMENT 'time the old password is revoked',
+    `runtimes`      TEXT                NULL COMMENT 'redeployed runtimes and deployments, json',
+    `message`       TEXT                NULL COMMENT 'failure message',
+    `operator`      VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'operator user id',
+    `finished_at`   DATETIME            NULL COMMENT 'finished time',
+    PRIMARY KEY (`id`),
+    KEY `idx_addon_id` (`addon_id`),
+    KEY `idx_instance_id` (`instance_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='addon credential rotations';
+
+CREATE TABLE IF NOT EXISTS `tb_runtime_domain_certificate`
+(
+    `id`                 BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`         DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`         DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `runtime_id`         BIGINT(20) UNSIGNED NOT NULL COMMENT 'runtime id',
+    `org_id`             BIGINT(20) UNSIGNED NOT NULL DEFAULT 0 COMMENT 'org id',
+    `domain`             VARCHAR(255)        NOT NULL COMMENT 'custom domain of runtime',
+    `certificate_id`     BIGINT(20) UNSIGNED NOT NULL DEFAULT 0 COMMENT 'certificate id in cmdb certificate service',
+    `status`             VARCHAR(32)         NOT NULL COMMENT 'ISSUING, ISSUED or FAILED',
+    `issuer`             VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'certificate issuer',
+    `not_before`         DATETIME            NULL COMMENT 'certificate valid from',
+    `not_after`          DATETIME            NULL COMMENT 'certificate valid until',
+    `challenge_token`    VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'pending acme http-01 challenge token',
+    `challenge_key_auth` VARCHAR(512)        NOT NULL DEFAULT '' COMMENT 'key authorization of pending http-01 challenge',
+    `message`            TEXT                NULL COMMENT 'failure message',
+    `operator`           VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'operator user id',
+    `alerted_at`         DATETIME            NULL COMMENT 'last expiry alert time',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_domain` (`domain`),
+    KEY `idx_runtime_id` (`runtime_id`),
+    KEY `idx_challenge_token` (`challenge_token`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='tls certificates of runtime domains';
+
+CREATE TABLE IF NOT EXISTS `tb_acme_account`
+(
+    `id`            BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+    `created_at`    DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+    `updated_at`    DATETIME            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+    `directory_url` VARCHAR(255)        NOT NULL COMMENT 'acme directory url',
+    `email`         VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'account contact email',
+    `account_uri`   VARCHAR(255)        NOT NULL DEFAULT '' COMMENT 'account uri returned by acme server',
+    `private_key`   TEXT                NOT NULL COMMENT 'pem encoded account private key',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_directory_url` (`directory_url`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4 COMMENT ='acme accounts';
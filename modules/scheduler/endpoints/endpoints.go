@@ -444,6 +444,143 @@ func (h *HTTPEndpoints) ServiceGroupKillPod(ctx context.Context, r *http.Request
 	})
 }
 
+func (h *HTTPEndpoints) ServiceGroupRollout(ctx context.Context, r *http.Request, vars map[string]string) (
+	httpserver.Responser, error) {
+	req := apistructs.ServiceGroupRolloutRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errstr := fmt.Sprintf("decode rollout request fail: %v", err)
+		return mkResponse(apistructs.ServiceGroupRolloutResponse{
+			Header: apistructs.Header{
+				Success: false,
+				Error:   apistructs.ErrorResponse{Msg: errstr},
+			}})
+	}
+	if req.Namespace == "" || req.Name == "" || req.Service == "" || !req.Action.Valid() {
+		errstr := fmt.Sprintf("empty namespace or name or service, or invalid action: %s", req.Action)
+		return mkResponse(apistructs.ServiceGroupRolloutResponse{
+			Header: apistructs.Header{
+				Success: false,
+				Error:   apistructs.ErrorResponse{Msg: errstr},
+			},
+		})
+	}
+
+	status, err := h.serviceGroupImpl.Rollout(ctx, req)
+	if err != nil {
+		return mkResponse(apistructs.ServiceGroupRolloutResponse{
+			Header: apistructs.Header{
+				Success: false,
+				Error:   apistructs.ErrorResponse{Msg: err.Error()},
+			},
+		})
+	}
+	return mkResponse(apistructs.ServiceGroupRolloutResponse{
+		Header: apistructs.Header{
+			Success: true,
+		},
+		Data: status,
+	})
+}
+
+// ServiceGroupNetworkPolicy computes the networkpolicy of the dice.yml without applying it
+func (h *HTTPEndpoints) ServiceGroupNetworkPolicy(ctx context.Context, r *http.Request, vars map[string]string) (
+	httpserver.Responser, error) {
+	req := apistructs.ServiceGroupNetworkPolicyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errstr := fmt.Sprintf("decode networkpolicy request fail: %v", err)
+		return mkResponse(apistructs.ServiceGroupNetworkPolicyResponse{
+			Header: apistructs.Header{
+				Success: false,
+				Error:   apistructs.ErrorResponse{Msg: errstr},
+			}})
+	}
+
+	graph, err := h.serviceGroupImpl.NetworkPolicy(ctx, req)
+	if err != nil {
+		return mkResponse(apistructs.ServiceGroupNetworkPolicyResponse{
+			Header: apistructs.Header{
+				Success: false,
+				Error:   apistructs.ErrorResponse{Msg: err.Error()},
+			},
+		})
+	}
+	return mkResponse(apistructs.ServiceGroupNetworkPolicyResponse{
+		Header: apistructs.Header{
+			Success: true,
+		},
+		Data: graph,
+	})
+}
+
+// ServiceGroupLive returns the spec of services actually running in cluster
+func (h *HTTPEndpoints) ServiceGroupLive(ctx context.Context, r *http.Request, vars map[string]string) (
+	httpserver.Responser, error) {
+	namespace := r.URL.Query().Get("namespace")
+	name := r.URL.Query().Get("name")
+	if namespace == "" || name == "" {
+		errstr := fmt.Sprintf("empty namespace or name")
+		return mkResponse(apistructs.ServiceGroupLiveResponse{
+			Header: apistructs.Header{
+				Success: false,
+				Error:   apistructs.ErrorResponse{Msg: errstr},
+			},
+		})
+	}
+
+	live, err := h.serviceGroupImpl.Live(ctx, namespace, name)
+	if err != nil {
+		return mkResponse(apistructs.ServiceGroupLiveResponse{
+			Header: apistructs.Header{
+				Success: false,
+				Error:   apistructs.ErrorResponse{Msg: err.Error()},
+			},
+		})
+	}
+	return mkResponse(apistructs.ServiceGroupLiveResponse{
+		Header: apistructs.Header{
+			Success: true,
+		},
+		Data: live,
+	})
+}
+
+// ServiceGroupTLS binds the certificate of domain to the ingresses of servicegroup
+func (h *HTTPEndpoints) ServiceGroupTLS(ctx context.Context, r *http.Request, vars map[string]string) (
+	httpserver.Responser, error) {
+	req := apistructs.ServiceGroupTLSRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errstr := fmt.Sprintf("decode tls request fail: %v", err)
+		return mkResponse(apistructs.ServiceGroupTLSResponse{
+			Header: apistructs.Header{
+				Success: false,
+				Error:   apistructs.ErrorResponse{Msg: errstr},
+			}})
+	}
+	if req.Namespace == "" || req.Name == "" || req.Domain == "" {
+		errstr := fmt.Sprintf("empty namespace, name or domain")
+		return mkResponse(apistructs.ServiceGroupTLSResponse{
+			Header: apistructs.Header{
+				Success: false,
+				Error:   apistructs.ErrorResponse{Msg: errstr},
+			},
+		})
+	}
+
+	if err := h.serviceGroupImpl.BindTLS(ctx, req); err != nil {
+		return mkResponse(apistructs.ServiceGroupTLSResponse{
+			Header: apistructs.Header{
+				Success: false,
+				Error:   apistructs.ErrorResponse{Msg: err.Error()},
+			},
+		})
+	}
+	return mkResponse(apistructs.ServiceGroupTLSResponse{
+		Header: apistructs.Header{
+			Success: true,
+		},
+	})
+}
+
 func (h *HTTPEndpoints) ServiceGroupConfigUpdate(ctx context.Context, r *http.Request, vars map[string]string) (
 	httpserver.Responser, error) {
 	req := apistructs.ServiceGroup{}
@@ -750,6 +887,70 @@ func (h *HTTPEndpoints) JobInspect(ctx context.Context, r *http.Request, vars ma
 	}
 	return mkResponse(job)
 }
+func (h *HTTPEndpoints) JobHistory(ctx context.Context, r *http.Request, vars map[string]string) (
+	httpserver.Responser, error) {
+	return h.jobCronAction(vars, apistructs.CronJobHistory)
+}
+
+func (h *HTTPEndpoints) JobTrigger(ctx context.Context, r *http.Request, vars map[string]string) (
+	httpserver.Responser, error) {
+	return h.jobCronAction(vars, apistructs.CronJobTrigger)
+}
+
+func (h *HTTPEndpoints) JobSuspend(ctx context.Context, r *http.Request, vars map[string]string) (
+	httpserver.Responser, error) {
+	var req apistructs.JobSuspendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errstr := fmt.Sprintf("failed to decode jobSuspend body, err: %v", err)
+		logrus.Error(errstr)
+		return httpserver.HTTPResponse{
+			Status: http.StatusBadRequest,
+			Content: apistructs.JobCronResponse{
+				Error: errstr,
+			},
+		}, nil
+	}
+	if req.Suspend {
+		return h.jobCronAction(vars, apistructs.CronJobSuspend)
+	}
+	return h.jobCronAction(vars, apistructs.CronJobResume)
+}
+
+func (h *HTTPEndpoints) jobCronAction(vars map[string]string, action apistructs.CronJobAction) (
+	httpserver.Responser, error) {
+	name := vars["name"]
+	namespace := vars["namespace"]
+
+	if name == "" || namespace == "" {
+		errstr := fmt.Sprintf("failed to %s job, empty name or namespace", action)
+		logrus.Error(errstr)
+		return httpserver.HTTPResponse{
+			Status: http.StatusBadRequest,
+			Content: apistructs.JobCronResponse{
+				Error: errstr,
+			},
+		}, nil
+	}
+
+	if os.Getenv(ENABLE_SPECIFIED_K8S_NAMESPACE) != "" {
+		namespace = os.Getenv(ENABLE_SPECIFIED_K8S_NAMESPACE)
+	}
+
+	status, err := h.job.CronJob(namespace, name, action)
+	if err != nil {
+		errstr := fmt.Sprintf("failed to %s job, err: %v", action, err)
+		logrus.Error(errstr)
+		return httpserver.HTTPResponse{
+			Status: http.StatusBadRequest,
+			Content: apistructs.JobCronResponse{
+				Name:  name,
+				Error: errstr,
+			},
+		}, nil
+	}
+	return mkResponse(apistructs.JobCronResponse{Name: name, CronJob: status})
+}
+
 func (h *HTTPEndpoints) JobList(ctx context.Context, r *http.Request, vars map[string]string) (
 	httpserver.Responser, error) {
 	namespace := vars["namespace"]
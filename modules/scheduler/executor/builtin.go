@@ -21,6 +21,7 @@ import (
 	_ "github.com/erda-project/erda/modules/scheduler/executor/plugins/k8sflink"
 	_ "github.com/erda-project/erda/modules/scheduler/executor/plugins/k8sjob"
 	_ "github.com/erda-project/erda/modules/scheduler/executor/plugins/k8sspark"
+	_ "github.com/erda-project/erda/modules/scheduler/executor/plugins/localprocess"
 	_ "github.com/erda-project/erda/modules/scheduler/executor/plugins/marathon"
 	_ "github.com/erda-project/erda/modules/scheduler/executor/plugins/metronome"
 	_ "github.com/erda-project/erda/modules/scheduler/executor/plugins/spark"
@@ -103,6 +103,35 @@ type TerminalExecutor interface {
 	Terminal(namespace, podname, containername string, conn *websocket.Conn)
 }
 
+// RolloutExecutor executor supports canary and blue-green rollout, only k8s executor implement it
+type RolloutExecutor interface {
+	Rollout(ctx context.Context, sg *apistructs.ServiceGroup, serviceName string, action apistructs.RolloutAction) (apistructs.RolloutStatus, error)
+}
+
+// NetworkPolicyExecutor executor computes the networkpolicy of servicegroup, only k8s executor implement it
+type NetworkPolicyExecutor interface {
+	NetworkPolicy(ctx context.Context, sg *apistructs.ServiceGroup) (apistructs.NetworkPolicyGraph, error)
+}
+
+// LiveExecutor executor reports the spec of services actually running in cluster, only k8s executor implement it
+type LiveExecutor interface {
+	// Live returns ErrLiveNotSupported if the servicegroup is not supported, e.g. stateful servicegroup
+	Live(ctx context.Context, sg *apistructs.ServiceGroup) ([]apistructs.ServiceLiveSpec, error)
+}
+
+// TLSExecutor executor binds the certificate of domain to the ingresses of servicegroup, only k8s executor implement it
+type TLSExecutor interface {
+	BindTLS(ctx context.Context, sg *apistructs.ServiceGroup, domain, certificate, privateKey string) error
+}
+
+// ErrLiveNotSupported means the live spec of servicegroup can not be read by the executor
+var ErrLiveNotSupported = errors.New("live spec not supported")
+
+// CronJobExecutor executor supports scheduled jobs, only k8sjob executor implement it
+type CronJobExecutor interface {
+	CronJob(ctx context.Context, job *apistructs.Job, action apistructs.CronJobAction) (apistructs.CronJobStatus, error)
+}
+
 type ExecutorWholeConfigs struct {
 	// Common cluster configuration
 	BasicConfig map[string]string
@@ -77,6 +77,7 @@ type SecretUtil interface {
 	Get(ns, name string) (*corev1.Secret, error)
 	Create(*corev1.Secret) error
 	CreateIfNotExist(secret *corev1.Secret) error
+	CreateOrUpdate(secret *corev1.Secret) error
 }
 
 type ImageSecretUtil interface {
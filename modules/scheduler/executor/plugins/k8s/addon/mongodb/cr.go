@@ -0,0 +1,96 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mongodb
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MongoDBCommunity the cr of mongodb community operator, apiVersion: mongodbcommunity.mongodb.com/v1
+type MongoDBCommunity struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MongoDBCommunitySpec   `json:"spec,omitempty"`
+	Status MongoDBCommunityStatus `json:"status,omitempty"`
+}
+
+type MongoDBCommunitySpec struct {
+	// Members the number of members in the replica set
+	Members int `json:"members"`
+	// Type only ReplicaSet is supported by the operator
+	Type     Type     `json:"type"`
+	Version  string   `json:"version"`
+	Security Security `json:"security"`
+	Users    []User   `json:"users"`
+	// StatefulSetConfiguration overrides the statefulset created by the operator
+	StatefulSetConfiguration StatefulSetConfiguration `json:"statefulSet,omitempty"`
+}
+
+type Type string
+
+const ReplicaSet Type = "ReplicaSet"
+
+type Security struct {
+	Authentication Authentication `json:"authentication"`
+}
+
+type Authentication struct {
+	Modes []AuthMode `json:"modes"`
+}
+
+type AuthMode string
+
+type User struct {
+	Name                       string             `json:"name"`
+	DB                         string             `json:"db"`
+	PasswordSecretRef          SecretKeyReference `json:"passwordSecretRef"`
+	Roles                      []Role             `json:"roles"`
+	ScramCredentialsSecretName string             `json:"scramCredentialsSecretName"`
+}
+
+type SecretKeyReference struct {
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"`
+}
+
+type Role struct {
+	DB   string `json:"db"`
+	Name string `json:"name"`
+}
+
+type StatefulSetConfiguration struct {
+	Spec StatefulSetSpec `json:"spec"`
+}
+
+// StatefulSetSpec the subset of appsv1.StatefulSetSpec which is merged into the statefulset by the operator
+type StatefulSetSpec struct {
+	Template             corev1.PodTemplateSpec         `json:"template,omitempty"`
+	VolumeClaimTemplates []corev1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
+}
+
+type MongoDBCommunityStatus struct {
+	MongoURI string `json:"mongoUri"`
+	Phase    Phase  `json:"phase"`
+	Message  string `json:"message,omitempty"`
+}
+
+type Phase string
+
+const (
+	PhaseRunning Phase = "Running"
+	PhasePending Phase = "Pending"
+	PhaseFailed  Phase = "Failed"
+)
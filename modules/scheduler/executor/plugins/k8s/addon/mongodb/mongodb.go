@@ -0,0 +1,344 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mongodb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/addon"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8sapi"
+	"github.com/erda-project/erda/modules/scheduler/schedulepolicy/constraintbuilders"
+	"github.com/erda-project/erda/pkg/httpclient"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+const (
+	svcNameMongodb = "mongodb"
+	defaultVersion = "4.4.6"
+	// user the root user created for the addon
+	user         = "root"
+	passwordEnv  = "MONGO_INITDB_ROOT_PASSWORD"
+	storageClass = "dice-local-volume"
+	volumeSize   = "10Gi"
+)
+
+type MongodbOperator struct {
+	k8s        addon.K8SUtil
+	ns         addon.NamespaceUtil
+	secret     addon.SecretUtil
+	overcommit addon.OvercommitUtil
+	client     *httpclient.HTTPClient
+}
+
+func New(k8s addon.K8SUtil, ns addon.NamespaceUtil, secret addon.SecretUtil, overcommit addon.OvercommitUtil, client *httpclient.HTTPClient) *MongodbOperator {
+	return &MongodbOperator{
+		k8s:        k8s,
+		ns:         ns,
+		secret:     secret,
+		overcommit: overcommit,
+		client:     client,
+	}
+}
+
+// IsSupported Determine whether to support mongodb community operator
+func (mo *MongodbOperator) IsSupported() bool {
+	resp, err := mo.client.Get(mo.k8s.GetK8SAddr()).
+		Path("/apis/mongodbcommunity.mongodb.com/v1").
+		Do().
+		DiscardBody()
+	if err != nil {
+		logrus.Errorf("failed to query /apis/mongodbcommunity.mongodb.com/v1, host: %v, err: %v",
+			mo.k8s.GetK8SAddr(), err)
+		return false
+	}
+	if !resp.IsOK() {
+		return false
+	}
+	return true
+}
+
+// Validate Verify the legality of the ServiceGroup transformed from diceyml
+func (mo *MongodbOperator) Validate(sg *apistructs.ServiceGroup) error {
+	operator, ok := sg.Labels["USE_OPERATOR"]
+	if !ok {
+		return fmt.Errorf("[BUG] sg need USE_OPERATOR label")
+	}
+	if strutil.ToLower(operator) != svcNameMongodb {
+		return fmt.Errorf("[BUG] value of label USE_OPERATOR should be 'mongodb'")
+	}
+	if len(sg.Services) != 1 {
+		return fmt.Errorf("illegal services num: %d", len(sg.Services))
+	}
+	if sg.Services[0].Name != svcNameMongodb {
+		return fmt.Errorf("illegal service: %s, should be 'mongodb'", sg.Services[0].Name)
+	}
+	if sg.Services[0].Env[passwordEnv] == "" {
+		return fmt.Errorf("illegal service: %s, need env '%s'", sg.Services[0].Name, passwordEnv)
+	}
+	return nil
+}
+
+type mongodbAndSecret struct {
+	mongo  MongoDBCommunity
+	secret corev1.Secret
+}
+
+// Convert Convert sg to cr, which is kubernetes yaml
+func (mo *MongodbOperator) Convert(sg *apistructs.ServiceGroup) interface{} {
+	svc := sg.Services[0]
+	namespace := genK8SNamespace(sg.Type, sg.ID)
+
+	scheinfo := sg.ScheduleInfo2
+	scheinfo.Stateful = true
+	affinity := constraintbuilders.K8S(&scheinfo, nil, nil, nil).Affinity
+
+	version := sg.Labels["VERSION"]
+	if version == "" {
+		version = defaultVersion
+	}
+	members := svc.Scale
+	if members < 1 {
+		members = 1
+	}
+	passwordSecret := sg.ID + "-password"
+
+	mongo := MongoDBCommunity{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "mongodbcommunity.mongodb.com/v1",
+			Kind:       "MongoDBCommunity",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sg.ID,
+			Namespace: namespace,
+		},
+		Spec: MongoDBCommunitySpec{
+			Members: members,
+			Type:    ReplicaSet,
+			Version: version,
+			Security: Security{
+				Authentication: Authentication{Modes: []AuthMode{"SCRAM"}},
+			},
+			Users: []User{{
+				Name:                       user,
+				DB:                         "admin",
+				PasswordSecretRef:          SecretKeyReference{Name: passwordSecret, Key: "password"},
+				Roles:                      []Role{{DB: "admin", Name: "root"}},
+				ScramCredentialsSecretName: sg.ID + "-scram",
+			}},
+			StatefulSetConfiguration: StatefulSetConfiguration{
+				Spec: StatefulSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "mongod",
+								Resources: corev1.ResourceRequirements{
+									Requests: corev1.ResourceList{
+										corev1.ResourceCPU: resource.MustParse(
+											fmt.Sprintf("%dm", int(1000*mo.overcommit.CPUOvercommit(svc.Resources.Cpu)))),
+										corev1.ResourceMemory: resource.MustParse(
+											fmt.Sprintf("%dMi", mo.overcommit.MemoryOvercommit(int(svc.Resources.Mem)))),
+									},
+									Limits: corev1.ResourceList{
+										corev1.ResourceCPU:    resource.MustParse(fmt.Sprintf("%dm", int(1000*svc.Resources.Cpu))),
+										corev1.ResourceMemory: resource.MustParse(fmt.Sprintf("%dMi", int(svc.Resources.Mem))),
+									},
+								},
+							}},
+							Affinity: &affinity,
+						},
+					},
+					VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+						ObjectMeta: metav1.ObjectMeta{Name: "data-volume"},
+						Spec: corev1.PersistentVolumeClaimSpec{
+							AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+							StorageClassName: &[]string{storageClass}[0],
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceStorage: resource.MustParse(volumeSize),
+								},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      passwordSecret,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			"password": []byte(svc.Env[passwordEnv]),
+		},
+	}
+	return mongodbAndSecret{mongo: mongo, secret: secret}
+}
+
+func (mo *MongodbOperator) Create(k8syml interface{}) error {
+	mongoAndSecret, ok := k8syml.(mongodbAndSecret)
+	if !ok {
+		return fmt.Errorf("[BUG] this k8syml should be mongodbAndSecret")
+	}
+	mongo := mongoAndSecret.mongo
+	secret := mongoAndSecret.secret
+	if err := mo.ns.Exists(mongo.Namespace); err != nil {
+		if err := mo.ns.Create(mongo.Namespace, nil); err != nil {
+			return err
+		}
+	}
+	if err := mo.secret.CreateIfNotExist(&secret); err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	resp, err := mo.client.Post(mo.k8s.GetK8SAddr()).
+		Path(fmt.Sprintf("/apis/mongodbcommunity.mongodb.com/v1/namespaces/%s/mongodbcommunity", mongo.Namespace)).
+		JSONBody(mongo).
+		Do().
+		Body(&b)
+	if err != nil {
+		return fmt.Errorf("failed to create mongodb, %s/%s, err: %v", mongo.Namespace, mongo.Name, err)
+	}
+	if !resp.IsOK() {
+		return fmt.Errorf("failed to create mongodb, %s/%s, statuscode: %v, body: %v",
+			mongo.Namespace, mongo.Name, resp.StatusCode(), b.String())
+	}
+	return nil
+}
+
+func (mo *MongodbOperator) Inspect(sg *apistructs.ServiceGroup) (*apistructs.ServiceGroup, error) {
+	namespace := genK8SNamespace(sg.Type, sg.ID)
+	mongo, err := mo.Get(namespace, sg.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var status apistructs.StatusCode
+	switch mongo.Status.Phase {
+	case PhaseRunning:
+		status = apistructs.StatusHealthy
+	case PhasePending:
+		status = apistructs.StatusProgressing
+	case PhaseFailed:
+		status = apistructs.StatusFailed
+	default:
+		status = apistructs.StatusUnknown
+	}
+
+	mongosvc := &(sg.Services[0])
+	mongosvc.Status = status
+	sg.Status = status
+
+	// the operator creates the headless service <name>-svc for the replica set
+	mongosvc.Vip = strutil.Join([]string{mongo.Name + "-svc", namespace, "svc.cluster.local"}, ".")
+	sg.Labels["REPLICA_SET"] = mongo.Name
+	return sg, nil
+}
+
+func (mo *MongodbOperator) Remove(sg *apistructs.ServiceGroup) error {
+	k8snamespace := genK8SNamespace(sg.Type, sg.ID)
+	var b bytes.Buffer
+	resp, err := mo.client.Delete(mo.k8s.GetK8SAddr()).
+		Path(fmt.Sprintf("/apis/mongodbcommunity.mongodb.com/v1/namespaces/%s/mongodbcommunity/%s", k8snamespace, sg.ID)).
+		JSONBody(k8sapi.DeleteOptions).
+		Do().
+		Body(&b)
+	if err != nil {
+		return fmt.Errorf("failed to delete mongodb: %s/%s, err: %v", sg.Type, sg.ID, err)
+	}
+	if !resp.IsOK() {
+		if resp.IsNotfound() {
+			return nil
+		}
+		return fmt.Errorf("failed to delete mongodb: %s/%s, statuscode: %v, body: %v",
+			sg.Type, sg.ID, resp.StatusCode(), b.String())
+	}
+
+	if err := mo.ns.Delete(k8snamespace); err != nil {
+		logrus.Errorf("failed to delete namespace: %s: %v", k8snamespace, err)
+		return nil
+	}
+	return nil
+}
+
+// Update updates the password secret and the mongodbcommunity, the operator reconciles the user
+// password from the secret referred by passwordSecretRef
+func (mo *MongodbOperator) Update(k8syml interface{}) error {
+	mongoAndSecret, ok := k8syml.(mongodbAndSecret)
+	if !ok {
+		return fmt.Errorf("[BUG] this k8syml should be mongodbAndSecret")
+	}
+	mongo := mongoAndSecret.mongo
+	secret := mongoAndSecret.secret
+	if err := mo.ns.Exists(mongo.Namespace); err != nil {
+		return err
+	}
+	old, err := mo.Get(mongo.Namespace, mongo.Name)
+	if err != nil {
+		return err
+	}
+	// fix error: "metadata.resourceVersion: Invalid value: 0x0: must be specified for an update"
+	mongo.ObjectMeta.ResourceVersion = old.ObjectMeta.ResourceVersion
+	if err := mo.secret.CreateOrUpdate(&secret); err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	resp, err := mo.client.Put(mo.k8s.GetK8SAddr()).
+		Path(fmt.Sprintf("/apis/mongodbcommunity.mongodb.com/v1/namespaces/%s/mongodbcommunity/%s", mongo.Namespace, mongo.Name)).
+		JSONBody(mongo).
+		Do().
+		Body(&b)
+	if err != nil {
+		return fmt.Errorf("failed to update mongodb, %s/%s, err: %v", mongo.Namespace, mongo.Name, err)
+	}
+	if !resp.IsOK() {
+		return fmt.Errorf("failed to update mongodb, %s/%s, statuscode: %v, body: %v",
+			mongo.Namespace, mongo.Name, resp.StatusCode(), b.String())
+	}
+	return nil
+}
+
+// Get gets the mongodbcommunity cr
+func (mo *MongodbOperator) Get(namespace, name string) (*MongoDBCommunity, error) {
+	var b bytes.Buffer
+	resp, err := mo.client.Get(mo.k8s.GetK8SAddr()).
+		Path(fmt.Sprintf("/apis/mongodbcommunity.mongodb.com/v1/namespaces/%s/mongodbcommunity/%s", namespace, name)).
+		Do().
+		Body(&b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mongodb, %s/%s, err: %v", namespace, name, err)
+	}
+	if !resp.IsOK() {
+		return nil, fmt.Errorf("failed to get mongodb, %s/%s, statuscode: %v, body: %v",
+			namespace, name, resp.StatusCode(), b.String())
+	}
+	mongo := &MongoDBCommunity{}
+	if err := json.NewDecoder(&b).Decode(mongo); err != nil {
+		return nil, err
+	}
+	return mongo, nil
+}
+
+func genK8SNamespace(namespace, name string) string {
+	return strutil.Concat(namespace, "--", name)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mongodb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/addon"
+	"github.com/erda-project/erda/pkg/httpclient"
+)
+
+type fakeOvercommit struct{}
+
+func (fakeOvercommit) CPUOvercommit(limit float64) float64 { return limit / 2 }
+func (fakeOvercommit) MemoryOvercommit(limit int) int      { return limit / 2 }
+
+type fakeK8S struct{ addr string }
+
+func (k fakeK8S) GetK8SAddr() string { return k.addr }
+
+type fakeNamespace struct{}
+
+func (fakeNamespace) Exists(ns string) error                           { return nil }
+func (fakeNamespace) Create(ns string, labels map[string]string) error { return nil }
+func (fakeNamespace) Delete(ns string) error                           { return nil }
+
+type fakeSecret struct {
+	addon.SecretUtil
+	updated []*corev1.Secret
+}
+
+func (f *fakeSecret) CreateOrUpdate(secret *corev1.Secret) error {
+	f.updated = append(f.updated, secret)
+	return nil
+}
+
+func TestConvert(t *testing.T) {
+	mo := New(nil, nil, nil, fakeOvercommit{}, nil)
+	sg := &apistructs.ServiceGroup{
+		Dice: apistructs.Dice{
+			ID:     "abc",
+			Type:   "addon-mongodb",
+			Labels: map[string]string{"USE_OPERATOR": "mongodb"},
+			Services: []apistructs.Service{{
+				Name:      "mongodb",
+				Scale:     3,
+				Resources: apistructs.Resources{Cpu: 1, Mem: 2048},
+				Env:       map[string]string{passwordEnv: "secret"},
+			}},
+		},
+	}
+	assert.Nil(t, mo.Validate(sg))
+
+	r := mo.Convert(sg).(mongodbAndSecret)
+	assert.Equal(t, "abc", r.mongo.Name)
+	assert.Equal(t, "addon-mongodb--abc", r.mongo.Namespace)
+	assert.Equal(t, defaultVersion, r.mongo.Spec.Version)
+	assert.Equal(t, 3, r.mongo.Spec.Members)
+	assert.Equal(t, "abc-password", r.mongo.Spec.Users[0].PasswordSecretRef.Name)
+	container := r.mongo.Spec.StatefulSetConfiguration.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "500m", container.Resources.Requests.Cpu().String())
+	assert.Equal(t, "secret", string(r.secret.Data["password"]))
+}
+
+func TestUpdate(t *testing.T) {
+	var put MongoDBCommunity
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"metadata":{"name":"abc","resourceVersion":"7"}}`))
+		case http.MethodPut:
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&put))
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	secret := &fakeSecret{}
+	mo := New(fakeK8S{strings.TrimPrefix(server.URL, "http://")}, fakeNamespace{}, secret, fakeOvercommit{}, httpclient.New())
+	sg := &apistructs.ServiceGroup{
+		Dice: apistructs.Dice{
+			ID:     "abc",
+			Type:   "addon-mongodb",
+			Labels: map[string]string{"USE_OPERATOR": "mongodb"},
+			Services: []apistructs.Service{{
+				Name:      "mongodb",
+				Scale:     1,
+				Resources: apistructs.Resources{Cpu: 1, Mem: 2048},
+				Env:       map[string]string{passwordEnv: "rotated"},
+			}},
+		},
+	}
+	assert.NoError(t, mo.Update(mo.Convert(sg)))
+	// the rotated password is written to the secret referred by the cr
+	assert.Equal(t, 1, len(secret.updated))
+	assert.Equal(t, "abc-password", secret.updated[0].Name)
+	assert.Equal(t, "rotated", string(secret.updated[0].Data["password"]))
+	assert.Equal(t, "7", put.ResourceVersion)
+}
@@ -157,7 +157,8 @@ func (my *MysqlOperator) Convert(sg *apistructs.ServiceGroup) interface{} {
 			},
 			PodTemplate: ofst.PodTemplateSpec{
 				Spec: ofst.PodSpec{
-					Affinity: &affinity,
+					Affinity:  &affinity,
+					Resources: convertResources(mysql.Resources),
 				},
 			},
 			BackupSchedule: &BackupScheduleSpec{
@@ -290,9 +291,103 @@ func (my *MysqlOperator) Remove(sg *apistructs.ServiceGroup) error {
 	return nil
 }
 
+// Update updates the mysql cr in place, e.g. scale single instance to group replication or change resources.
+// secret and backup pvc are kept as they are.
 func (my *MysqlOperator) Update(k8syml interface{}) error {
-	// TODO:
-	return fmt.Errorf("mysqloperator not impl Update yet")
+	mysqlSecretBackupPVC, ok := k8syml.(mysqlSecretBackupPVC)
+	if !ok {
+		return fmt.Errorf("[BUG] this k8syml should be mysqlAndSecret")
+	}
+	mysql := mysqlSecretBackupPVC.mysql
+	if err := my.ns.Exists(mysql.Namespace); err != nil {
+		return err
+	}
+	old, err := my.Get(mysql.Namespace, mysql.Name)
+	if err != nil {
+		return err
+	}
+	// fix error: "metadata.resourceVersion: Invalid value: 0x0: must be specified for an update"
+	mysql.ObjectMeta.ResourceVersion = old.ObjectMeta.ResourceVersion
+	mergeTopology(mysql, old)
+
+	var b bytes.Buffer
+	resp, err := my.client.Put(my.k8s.GetK8SAddr()).
+		Path(fmt.Sprintf("/apis/kubedb.com/v1alpha1/namespaces/%s/mysqls/%s", mysql.Namespace, mysql.Name)).
+		JSONBody(mysql).
+		Do().
+		Body(&b)
+	if err != nil {
+		return fmt.Errorf("failed to update mysql, %s/%s, err: %v", mysql.Namespace, mysql.Name, err)
+	}
+	if !resp.IsOK() {
+		return fmt.Errorf("failed to update mysql, %s/%s, statuscode: %v, body: %v",
+			mysql.Namespace, mysql.Name, resp.StatusCode(), b.String())
+	}
+	return nil
+}
+
+// Get gets the mysql cr
+func (my *MysqlOperator) Get(namespace, name string) (*MySQL, error) {
+	var b bytes.Buffer
+	resp, err := my.client.Get(my.k8s.GetK8SAddr()).
+		Path(fmt.Sprintf("/apis/kubedb.com/v1alpha1/namespaces/%s/mysqls/%s", namespace, name)).
+		Do().
+		Body(&b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mysql, %s/%s, err: %v", namespace, name, err)
+	}
+	if !resp.IsOK() {
+		return nil, fmt.Errorf("failed to get mysql, %s/%s, statuscode: %v, body: %v",
+			namespace, name, resp.StatusCode(), b.String())
+	}
+	mysql := &MySQL{}
+	if err := json.NewDecoder(&b).Decode(mysql); err != nil {
+		return nil, err
+	}
+	return mysql, nil
+}
+
+// mergeTopology turns on group replication only when a running standalone mysql is scaled out, the topology of
+// new mysql is left as it is. Once the replication group is started, its group name and base server id can not be changed.
+func mergeTopology(mysql, old *MySQL) {
+	switch {
+	case old.Spec.Topology != nil:
+		mysql.Spec.Topology = old.Spec.Topology
+	case mysql.Spec.Replicas != nil:
+		mysql.Spec.Topology = convertTopology(*mysql.Spec.Replicas)
+	}
+}
+
+// convertTopology returns group replication topology when more than one replica is required,
+// a single replica runs as standalone mysql.
+func convertTopology(replica int32) *MySQLClusterTopology {
+	if replica <= 1 {
+		return nil
+	}
+	mode := MySQLClusterModeGroup
+	groupMode := MySQLGroupModeSinglePrimary
+	return &MySQLClusterTopology{
+		Mode: &mode,
+		Group: &MySQLGroupSpec{
+			Mode: &groupMode,
+		},
+	}
+}
+
+func convertResources(res apistructs.Resources) corev1.ResourceRequirements {
+	requirements := corev1.ResourceRequirements{}
+	if res.Cpu <= 0 && res.Mem <= 0 {
+		return requirements
+	}
+	requirements.Limits = corev1.ResourceList{}
+	if res.Cpu > 0 {
+		requirements.Limits[corev1.ResourceCPU] = resource.MustParse(fmt.Sprintf("%dm", int(1000*res.Cpu)))
+	}
+	if res.Mem > 0 {
+		requirements.Limits[corev1.ResourceMemory] = resource.MustParse(fmt.Sprintf("%dMi", int(res.Mem)))
+	}
+	requirements.Requests = requirements.Limits.DeepCopy()
+	return requirements
 }
 
 func (my *MysqlOperator) waitMysqlDeleted(ctx context.Context, namespace, name string) error {
@@ -0,0 +1,75 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+func TestConvert(t *testing.T) {
+	my := New(nil, nil, nil, nil, nil)
+	sg := &apistructs.ServiceGroup{
+		Dice: apistructs.Dice{
+			ID:     "abc",
+			Type:   "addon-mysql",
+			Labels: map[string]string{"USE_OPERATOR": "mysql"},
+			Services: []apistructs.Service{{
+				Name:      "mysql",
+				Scale:     1,
+				Resources: apistructs.Resources{Cpu: 0.5, Mem: 1024},
+				Env:       map[string]string{"MYSQL_ROOT_PASSWORD": "secret"},
+			}},
+		},
+	}
+	assert.Nil(t, my.Validate(sg))
+
+	r := my.Convert(sg).(mysqlSecretBackupPVC)
+	assert.Equal(t, "addon-mysql--abc", r.mysql.Namespace)
+	assert.Equal(t, int32(1), *r.mysql.Spec.Replicas)
+	assert.Nil(t, r.mysql.Spec.Topology)
+	assert.Equal(t, "500m", r.mysql.Spec.PodTemplate.Spec.Resources.Limits.Cpu().String())
+	assert.Equal(t, "1Gi", r.mysql.Spec.PodTemplate.Spec.Resources.Requests.Memory().String())
+
+	// new mysql with more than one replica is created as before
+	sg.Services[0].Scale = 2
+	r = my.Convert(sg).(mysqlSecretBackupPVC)
+	assert.Equal(t, int32(2), *r.mysql.Spec.Replicas)
+	assert.Nil(t, r.mysql.Spec.Topology)
+}
+
+func TestMergeTopology(t *testing.T) {
+	two, one := int32(2), int32(1)
+
+	// scale standalone mysql out to group replication
+	mysql := &MySQL{Spec: MySQLSpec{Replicas: &two}}
+	mergeTopology(mysql, &MySQL{Spec: MySQLSpec{Replicas: &one}})
+	assert.Equal(t, MySQLClusterModeGroup, *mysql.Spec.Topology.Mode)
+	assert.Equal(t, MySQLGroupModeSinglePrimary, *mysql.Spec.Topology.Group.Mode)
+
+	// only change resources of standalone mysql
+	mysql = &MySQL{Spec: MySQLSpec{Replicas: &one}}
+	mergeTopology(mysql, &MySQL{Spec: MySQLSpec{Replicas: &one}})
+	assert.Nil(t, mysql.Spec.Topology)
+
+	// started group is kept
+	old := &MySQL{Spec: MySQLSpec{Replicas: &two, Topology: convertTopology(two)}}
+	old.Spec.Topology.Group.Name = "a8b1f9c2-1d3e-4f5a-9b6c-7d8e9f0a1b2c"
+	mysql = &MySQL{Spec: MySQLSpec{Replicas: &two}}
+	mergeTopology(mysql, old)
+	assert.Equal(t, old.Spec.Topology.Group.Name, mysql.Spec.Topology.Group.Name)
+}
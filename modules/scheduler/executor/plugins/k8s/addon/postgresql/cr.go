@@ -0,0 +1,84 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package postgresql
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Postgresql defines the postgresql cluster managed by zalando postgres-operator
+type Postgresql struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              PostgresSpec   `json:"spec"`
+	Status            PostgresStatus `json:"status,omitempty"`
+}
+
+// PostgresSpec defines the specification of the postgresql cluster
+type PostgresSpec struct {
+	PostgresqlParam   PostgresqlParam      `json:"postgresql"`
+	Volume            Volume               `json:"volume,omitempty"`
+	Resources         Resources            `json:"resources,omitempty"`
+	TeamID            string               `json:"teamId"`
+	DockerImage       string               `json:"dockerImage,omitempty"`
+	NumberOfInstances int32                `json:"numberOfInstances"`
+	Users             map[string]UserFlags `json:"users,omitempty"`
+	Databases         map[string]string    `json:"databases,omitempty"`
+	NodeAffinity      *corev1.NodeAffinity `json:"nodeAffinity,omitempty"`
+	PodAnnotations    map[string]string    `json:"podAnnotations,omitempty"`
+}
+
+// PostgresqlParam describes the version and parameters of postgresql
+type PostgresqlParam struct {
+	PgVersion  string            `json:"version"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// Volume describes the persistent volume of each instance
+type Volume struct {
+	Size         string `json:"size"`
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+// ResourceDescription describes cpu and memory
+type ResourceDescription struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+}
+
+// Resources describes requests and limits of each instance
+type Resources struct {
+	ResourceRequests ResourceDescription `json:"requests,omitempty"`
+	ResourceLimits   ResourceDescription `json:"limits,omitempty"`
+}
+
+// UserFlags defines the roles of the user, e.g. superuser, createdb
+type UserFlags []string
+
+// PostgresStatus contains status of the postgresql cluster
+type PostgresStatus struct {
+	PostgresClusterStatus string `json:"PostgresClusterStatus"`
+}
+
+const (
+	ClusterStatusUnknown      = ""
+	ClusterStatusCreating     = "Creating"
+	ClusterStatusUpdating     = "Updating"
+	ClusterStatusUpdateFailed = "UpdateFailed"
+	ClusterStatusSyncFailed   = "SyncFailed"
+	ClusterStatusAddFailed    = "CreateFailed"
+	ClusterStatusRunning      = "Running"
+	ClusterStatusInvalid      = "Invalid"
+)
@@ -0,0 +1,318 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package postgresql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/addon"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8sapi"
+	"github.com/erda-project/erda/modules/scheduler/schedulepolicy/constraintbuilders"
+	"github.com/erda-project/erda/pkg/httpclient"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+const (
+	svcNamePostgresql = "postgresql"
+	// teamID the name of postgresql cluster must be prefixed with teamId by postgres-operator
+	teamID         = "pg"
+	defaultVersion = "12"
+	// user the user created for the addon, which is granted superuser
+	user         = "dice"
+	passwordEnv  = "POSTGRES_PASSWORD"
+	storageClass = "dice-local-volume"
+	volumeSize   = "10Gi"
+)
+
+type PostgresqlOperator struct {
+	k8s        addon.K8SUtil
+	ns         addon.NamespaceUtil
+	secret     addon.SecretUtil
+	overcommit addon.OvercommitUtil
+	client     *httpclient.HTTPClient
+}
+
+func New(k8s addon.K8SUtil, ns addon.NamespaceUtil, secret addon.SecretUtil, overcommit addon.OvercommitUtil, client *httpclient.HTTPClient) *PostgresqlOperator {
+	return &PostgresqlOperator{
+		k8s:        k8s,
+		ns:         ns,
+		secret:     secret,
+		overcommit: overcommit,
+		client:     client,
+	}
+}
+
+// IsSupported Determine whether to support zalando postgres-operator
+func (po *PostgresqlOperator) IsSupported() bool {
+	resp, err := po.client.Get(po.k8s.GetK8SAddr()).
+		Path("/apis/acid.zalan.do/v1").
+		Do().
+		DiscardBody()
+	if err != nil {
+		logrus.Errorf("failed to query /apis/acid.zalan.do/v1, host: %v, err: %v",
+			po.k8s.GetK8SAddr(), err)
+		return false
+	}
+	if !resp.IsOK() {
+		return false
+	}
+	return true
+}
+
+// Validate Verify the legality of the ServiceGroup transformed from diceyml
+func (po *PostgresqlOperator) Validate(sg *apistructs.ServiceGroup) error {
+	operator, ok := sg.Labels["USE_OPERATOR"]
+	if !ok {
+		return fmt.Errorf("[BUG] sg need USE_OPERATOR label")
+	}
+	if strutil.ToLower(operator) != svcNamePostgresql {
+		return fmt.Errorf("[BUG] value of label USE_OPERATOR should be 'postgresql'")
+	}
+	if len(sg.Services) != 1 {
+		return fmt.Errorf("illegal services num: %d", len(sg.Services))
+	}
+	if sg.Services[0].Name != svcNamePostgresql {
+		return fmt.Errorf("illegal service: %s, should be 'postgresql'", sg.Services[0].Name)
+	}
+	if sg.Services[0].Env[passwordEnv] == "" {
+		return fmt.Errorf("illegal service: %s, need env '%s'", sg.Services[0].Name, passwordEnv)
+	}
+	return nil
+}
+
+type postgresqlAndSecret struct {
+	pg     Postgresql
+	secret corev1.Secret
+}
+
+// Convert Convert sg to cr, which is kubernetes yaml
+func (po *PostgresqlOperator) Convert(sg *apistructs.ServiceGroup) interface{} {
+	svc := sg.Services[0]
+	namespace := genK8SNamespace(sg.Type, sg.ID)
+	name := clusterName(sg.ID)
+
+	scheinfo := sg.ScheduleInfo2
+	scheinfo.Stateful = true
+	affinity := constraintbuilders.K8S(&scheinfo, nil, nil, nil).Affinity.NodeAffinity
+
+	version := sg.Labels["VERSION"]
+	if version == "" {
+		version = defaultVersion
+	}
+	replicas := int32(svc.Scale)
+	if replicas < 1 {
+		replicas = 1
+	}
+
+	pg := Postgresql{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "acid.zalan.do/v1",
+			Kind:       "postgresql",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: PostgresSpec{
+			TeamID:            teamID,
+			PostgresqlParam:   PostgresqlParam{PgVersion: version},
+			NumberOfInstances: replicas,
+			Volume:            Volume{Size: volumeSize, StorageClass: storageClass},
+			Resources: Resources{
+				ResourceRequests: ResourceDescription{
+					CPU:    fmt.Sprintf("%dm", int(1000*po.overcommit.CPUOvercommit(svc.Resources.Cpu))),
+					Memory: fmt.Sprintf("%dMi", po.overcommit.MemoryOvercommit(int(svc.Resources.Mem))),
+				},
+				ResourceLimits: ResourceDescription{
+					CPU:    fmt.Sprintf("%dm", int(1000*svc.Resources.Cpu)),
+					Memory: fmt.Sprintf("%dMi", int(svc.Resources.Mem)),
+				},
+			},
+			Users:        map[string]UserFlags{user: {"superuser", "createdb"}},
+			NodeAffinity: affinity,
+		},
+	}
+	// postgres-operator takes the password from the existing secret instead of generating a random one
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%s.credentials.postgresql.acid.zalan.do", user, name),
+			Namespace: namespace,
+			Labels:    map[string]string{"application": "spilo", "cluster-name": name, "team": teamID},
+		},
+		Data: map[string][]byte{
+			"username": []byte(user),
+			"password": []byte(svc.Env[passwordEnv]),
+		},
+	}
+	return postgresqlAndSecret{pg: pg, secret: secret}
+}
+
+func (po *PostgresqlOperator) Create(k8syml interface{}) error {
+	pgAndSecret, ok := k8syml.(postgresqlAndSecret)
+	if !ok {
+		return fmt.Errorf("[BUG] this k8syml should be postgresqlAndSecret")
+	}
+	pg := pgAndSecret.pg
+	secret := pgAndSecret.secret
+	if err := po.ns.Exists(pg.Namespace); err != nil {
+		if err := po.ns.Create(pg.Namespace, nil); err != nil {
+			return err
+		}
+	}
+	if err := po.secret.CreateIfNotExist(&secret); err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	resp, err := po.client.Post(po.k8s.GetK8SAddr()).
+		Path(fmt.Sprintf("/apis/acid.zalan.do/v1/namespaces/%s/postgresqls", pg.Namespace)).
+		JSONBody(pg).
+		Do().
+		Body(&b)
+	if err != nil {
+		return fmt.Errorf("failed to create postgresql, %s/%s, err: %v", pg.Namespace, pg.Name, err)
+	}
+	if !resp.IsOK() {
+		return fmt.Errorf("failed to create postgresql, %s/%s, statuscode: %v, body: %v",
+			pg.Namespace, pg.Name, resp.StatusCode(), b.String())
+	}
+	return nil
+}
+
+func (po *PostgresqlOperator) Inspect(sg *apistructs.ServiceGroup) (*apistructs.ServiceGroup, error) {
+	namespace := genK8SNamespace(sg.Type, sg.ID)
+	pg, err := po.Get(namespace, clusterName(sg.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	status := map[string]apistructs.StatusCode{
+		ClusterStatusRunning:      apistructs.StatusHealthy,
+		ClusterStatusCreating:     apistructs.StatusProgressing,
+		ClusterStatusUpdating:     apistructs.StatusProgressing,
+		ClusterStatusAddFailed:    apistructs.StatusFailed,
+		ClusterStatusUpdateFailed: apistructs.StatusFailed,
+		ClusterStatusSyncFailed:   apistructs.StatusFailed,
+		ClusterStatusInvalid:      apistructs.StatusFailed,
+		ClusterStatusUnknown:      apistructs.StatusUnknown,
+	}[pg.Status.PostgresClusterStatus]
+	if status == "" {
+		status = apistructs.StatusUnknown
+	}
+
+	pgsvc := &(sg.Services[0])
+	pgsvc.Status = status
+	sg.Status = status
+
+	// postgres-operator creates the service <cluster> for master and <cluster>-repl for replicas
+	pgsvc.Vip = strutil.Join([]string{pg.Name, namespace, "svc.cluster.local"}, ".")
+	if pg.Spec.NumberOfInstances > 1 {
+		sg.Labels["REPLICA_HOST"] = strutil.Join([]string{pg.Name + "-repl", namespace, "svc.cluster.local"}, ".")
+	}
+	return sg, nil
+}
+
+func (po *PostgresqlOperator) Remove(sg *apistructs.ServiceGroup) error {
+	k8snamespace := genK8SNamespace(sg.Type, sg.ID)
+	var b bytes.Buffer
+	resp, err := po.client.Delete(po.k8s.GetK8SAddr()).
+		Path(fmt.Sprintf("/apis/acid.zalan.do/v1/namespaces/%s/postgresqls/%s", k8snamespace, clusterName(sg.ID))).
+		JSONBody(k8sapi.DeleteOptions).
+		Do().
+		Body(&b)
+	if err != nil {
+		return fmt.Errorf("failed to delete postgresql: %s/%s, err: %v", sg.Type, sg.ID, err)
+	}
+	if !resp.IsOK() {
+		if resp.IsNotfound() {
+			return nil
+		}
+		return fmt.Errorf("failed to delete postgresql: %s/%s, statuscode: %v, body: %v",
+			sg.Type, sg.ID, resp.StatusCode(), b.String())
+	}
+
+	if err := po.ns.Delete(k8snamespace); err != nil {
+		logrus.Errorf("failed to delete namespace: %s: %v", k8snamespace, err)
+		return nil
+	}
+	return nil
+}
+
+func (po *PostgresqlOperator) Update(k8syml interface{}) error {
+	pgAndSecret, ok := k8syml.(postgresqlAndSecret)
+	if !ok {
+		return fmt.Errorf("[BUG] this k8syml should be postgresqlAndSecret")
+	}
+	pg := pgAndSecret.pg
+	if err := po.ns.Exists(pg.Namespace); err != nil {
+		return err
+	}
+	old, err := po.Get(pg.Namespace, pg.Name)
+	if err != nil {
+		return err
+	}
+	// fix error: "metadata.resourceVersion: Invalid value: 0x0: must be specified for an update"
+	pg.ObjectMeta.ResourceVersion = old.ObjectMeta.ResourceVersion
+
+	var b bytes.Buffer
+	resp, err := po.client.Put(po.k8s.GetK8SAddr()).
+		Path(fmt.Sprintf("/apis/acid.zalan.do/v1/namespaces/%s/postgresqls/%s", pg.Namespace, pg.Name)).
+		JSONBody(pg).
+		Do().
+		Body(&b)
+	if err != nil {
+		return fmt.Errorf("failed to update postgresql, %s/%s, err: %v", pg.Namespace, pg.Name, err)
+	}
+	if !resp.IsOK() {
+		return fmt.Errorf("failed to update postgresql, %s/%s, statuscode: %v, body: %v",
+			pg.Namespace, pg.Name, resp.StatusCode(), b.String())
+	}
+	return nil
+}
+
+// Get gets the postgresql cr
+func (po *PostgresqlOperator) Get(namespace, name string) (*Postgresql, error) {
+	var b bytes.Buffer
+	resp, err := po.client.Get(po.k8s.GetK8SAddr()).
+		Path(fmt.Sprintf("/apis/acid.zalan.do/v1/namespaces/%s/postgresqls/%s", namespace, name)).
+		Do().
+		Body(&b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get postgresql, %s/%s, err: %v", namespace, name, err)
+	}
+	if !resp.IsOK() {
+		return nil, fmt.Errorf("failed to get postgresql, %s/%s, statuscode: %v, body: %v",
+			namespace, name, resp.StatusCode(), b.String())
+	}
+	pg := &Postgresql{}
+	if err := json.NewDecoder(&b).Decode(pg); err != nil {
+		return nil, err
+	}
+	return pg, nil
+}
+
+// clusterName the name of postgresql cluster, which must be prefixed with teamId
+func clusterName(id string) string {
+	return strutil.Concat(teamID, "-", id)
+}
+
+func genK8SNamespace(namespace, name string) string {
+	return strutil.Concat(namespace, "--", name)
+}
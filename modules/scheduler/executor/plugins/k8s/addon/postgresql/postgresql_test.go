@@ -0,0 +1,58 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+type fakeOvercommit struct{}
+
+func (fakeOvercommit) CPUOvercommit(limit float64) float64 { return limit / 2 }
+func (fakeOvercommit) MemoryOvercommit(limit int) int      { return limit / 2 }
+
+func TestConvert(t *testing.T) {
+	po := New(nil, nil, nil, fakeOvercommit{}, nil)
+	sg := &apistructs.ServiceGroup{
+		Dice: apistructs.Dice{
+			ID:   "abc",
+			Type: "addon-postgresql",
+			Labels: map[string]string{
+				"USE_OPERATOR": "postgresql",
+				"VERSION":      "13",
+			},
+			Services: []apistructs.Service{{
+				Name:      "postgresql",
+				Scale:     2,
+				Resources: apistructs.Resources{Cpu: 1, Mem: 2048},
+				Env:       map[string]string{passwordEnv: "secret"},
+			}},
+		},
+	}
+	assert.Nil(t, po.Validate(sg))
+
+	r := po.Convert(sg).(postgresqlAndSecret)
+	assert.Equal(t, "pg-abc", r.pg.Name)
+	assert.Equal(t, "addon-postgresql--abc", r.pg.Namespace)
+	assert.Equal(t, "13", r.pg.Spec.PostgresqlParam.PgVersion)
+	assert.Equal(t, int32(2), r.pg.Spec.NumberOfInstances)
+	assert.Equal(t, "500m", r.pg.Spec.Resources.ResourceRequests.CPU)
+	assert.Equal(t, "2048Mi", r.pg.Spec.Resources.ResourceLimits.Memory)
+	assert.Equal(t, "dice.pg-abc.credentials.postgresql.acid.zalan.do", r.secret.Name)
+	assert.Equal(t, "secret", string(r.secret.Data["password"]))
+}
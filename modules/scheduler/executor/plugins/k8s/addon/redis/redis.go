@@ -15,6 +15,7 @@ package redis
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
@@ -289,9 +290,61 @@ func (ro *RedisOperator) Remove(sg *apistructs.ServiceGroup) error {
 	return nil
 }
 
+// Update updates the password secret and the redisfailover, the operator rolls the redis and sentinel pods
+// when the spec (e.g. requirepass env) changes, so that they read the new password from the secret
 func (ro *RedisOperator) Update(k8syml interface{}) error {
-	// TODO:
-	return fmt.Errorf("redisoperator not impl Update yet")
+	redisAndSecret, ok := k8syml.(redisFailoverAndSecret)
+	if !ok {
+		return fmt.Errorf("[BUG] this k8syml should be redisFailoverAndSecret")
+	}
+	redis := redisAndSecret.RedisFailover
+	secret := redisAndSecret.Secret
+	if err := ro.ns.Exists(redis.Namespace); err != nil {
+		return err
+	}
+	old, err := ro.Get(redis.Namespace, redis.Name)
+	if err != nil {
+		return err
+	}
+	redis.ObjectMeta.ResourceVersion = old.ObjectMeta.ResourceVersion
+	if err := ro.secret.CreateOrUpdate(&secret); err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	resp, err := ro.client.Put(ro.k8s.GetK8SAddr()).
+		Path(fmt.Sprintf("/apis/databases.spotahome.com/v1/namespaces/%s/redisfailovers/%s", redis.Namespace, redis.Name)).
+		JSONBody(redis).
+		Do().
+		Body(&b)
+	if err != nil {
+		return fmt.Errorf("failed to update redisfailover, %s/%s, err: %v", redis.Namespace, redis.Name, err)
+	}
+	if !resp.IsOK() {
+		return fmt.Errorf("failed to update redisfailover, %s/%s, statuscode: %v, body: %v",
+			redis.Namespace, redis.Name, resp.StatusCode(), b.String())
+	}
+	return nil
+}
+
+// Get gets the redisfailover cr
+func (ro *RedisOperator) Get(namespace, name string) (*RedisFailover, error) {
+	var b bytes.Buffer
+	resp, err := ro.client.Get(ro.k8s.GetK8SAddr()).
+		Path(fmt.Sprintf("/apis/databases.spotahome.com/v1/namespaces/%s/redisfailovers/%s", namespace, name)).
+		Do().
+		Body(&b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redisfailover, %s/%s, err: %v", namespace, name, err)
+	}
+	if !resp.IsOK() {
+		return nil, fmt.Errorf("failed to get redisfailover, %s/%s, statuscode: %v, body: %v",
+			namespace, name, resp.StatusCode(), b.String())
+	}
+	redis := &RedisFailover{}
+	if err := json.NewDecoder(&b).Decode(redis); err != nil {
+		return nil, err
+	}
+	return redis, nil
 }
 
 func (ro *RedisOperator) convertRedis(svc apistructs.Service, affinity *corev1.NodeAffinity) RedisSettings {
@@ -0,0 +1,90 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+// inspectOperatorRollout observes the statefulsets created by the addon operator, fills the ready replicas and
+// the resources which are rolled out, and reports the addon as progressing until the rollout is finished
+func (k *Kubernetes) inspectOperatorRollout(sg *apistructs.ServiceGroup) {
+	namespace := strutil.Concat(sg.Type, "--", sg.ID)
+	stsList, err := k.sts.List(namespace)
+	if err != nil {
+		logrus.Warnf("failed to list statefulsets of operator addon, namespace: %s, (%v)", namespace, err)
+		return
+	}
+	observeOperatorRollout(sg, stsList.Items)
+}
+
+func observeOperatorRollout(sg *apistructs.ServiceGroup, stsList []appsv1.StatefulSet) {
+	if len(sg.Services) == 0 || len(stsList) == 0 {
+		return
+	}
+	var (
+		ready     int
+		rolling   bool
+		resources *apistructs.Resources
+	)
+	for _, sts := range stsList {
+		replicas := int32(1)
+		if sts.Spec.Replicas != nil {
+			replicas = *sts.Spec.Replicas
+		}
+		if sts.Status.ObservedGeneration < sts.Generation || sts.Status.UpdatedReplicas < replicas ||
+			sts.Status.ReadyReplicas < replicas ||
+			(sts.Status.UpdateRevision != "" && sts.Status.CurrentRevision != sts.Status.UpdateRevision) {
+			rolling = true
+			continue
+		}
+		ready += int(sts.Status.ReadyReplicas)
+		if resources == nil {
+			resources = containerLimits(sts.Spec.Template.Spec.Containers)
+		}
+	}
+	// operator addon has only one service, the others are managed by the operator
+	svc := &sg.Services[0]
+	svc.CurrentReplicas = ready
+	svc.CurrentResources = resources
+	if rolling && (sg.Status == apistructs.StatusHealthy || sg.Status == apistructs.StatusReady) {
+		sg.Status = apistructs.StatusProgressing
+		svc.Status = apistructs.StatusProgressing
+	}
+}
+
+// containerLimits returns the limits of the main container, which is the one with the most memory,
+// sidecars of the operator are usually much smaller
+func containerLimits(containers []corev1.Container) *apistructs.Resources {
+	var result *apistructs.Resources
+	for _, c := range containers {
+		mem := c.Resources.Limits.Memory()
+		if mem.IsZero() {
+			continue
+		}
+		res := &apistructs.Resources{
+			Cpu: float64(c.Resources.Limits.Cpu().MilliValue()) / 1000,
+			Mem: float64(mem.Value() / 1024 / 1024),
+		}
+		if result == nil || res.Mem > result.Mem {
+			result = res
+		}
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+func TestObserveOperatorRollout(t *testing.T) {
+	newSts := func(replicas int32, cpu, mem string) appsv1.StatefulSet {
+		sts := appsv1.StatefulSet{}
+		sts.Generation = 2
+		sts.Spec.Replicas = &replicas
+		sts.Spec.Template.Spec.Containers = []corev1.Container{
+			{Name: "exporter", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("128Mi"),
+			}}},
+			{Name: "mysql", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(mem),
+			}}},
+		}
+		sts.Status = appsv1.StatefulSetStatus{ObservedGeneration: 2, Replicas: replicas, ReadyReplicas: replicas,
+			UpdatedReplicas: replicas, CurrentRevision: "r2", UpdateRevision: "r2"}
+		return sts
+	}
+	newSg := func() *apistructs.ServiceGroup {
+		sg := &apistructs.ServiceGroup{}
+		sg.Status = apistructs.StatusHealthy
+		sg.Services = []apistructs.Service{{Name: "mysql"}}
+		sg.Services[0].Status = apistructs.StatusHealthy
+		return sg
+	}
+
+	sg := newSg()
+	observeOperatorRollout(sg, []appsv1.StatefulSet{newSts(2, "500m", "1Gi")})
+	assert.Equal(t, apistructs.StatusHealthy, sg.Status)
+	assert.Equal(t, 2, sg.Services[0].CurrentReplicas)
+	assert.Equal(t, &apistructs.Resources{Cpu: 0.5, Mem: 1024}, sg.Services[0].CurrentResources)
+
+	// operator has not rolled out the new spec yet
+	sg = newSg()
+	sts := newSts(3, "1", "2Gi")
+	sts.Status.UpdatedReplicas, sts.Status.ReadyReplicas, sts.Status.UpdateRevision = 1, 2, "r3"
+	observeOperatorRollout(sg, []appsv1.StatefulSet{sts})
+	assert.Equal(t, apistructs.StatusProgressing, sg.Status)
+	assert.Equal(t, 0, sg.Services[0].CurrentReplicas)
+	assert.Nil(t, sg.Services[0].CurrentResources)
+
+	// statefulset is not observed by the controller
+	sg = newSg()
+	sts = newSts(2, "500m", "1Gi")
+	sts.Generation = 3
+	observeOperatorRollout(sg, []appsv1.StatefulSet{sts})
+	assert.Equal(t, apistructs.StatusProgressing, sg.Status)
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8serror"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+// The files declared in dice.yml are stored in the configmap '<deploy>-files',
+// secret ones (including certificates) are stored in the secret '<deploy>-secret-files'.
+// Each file is mounted by subPath, and the checksum of all files is set on the pod template,
+// so that changing the contents of files leads to a rolling restart of the service.
+// Files mounted by subPath are not refreshed by kubelet, they only change when the servicegroup is updated,
+// i.e. on redeploy, or when orchestrator finds the contents in config center or certificates changed.
+//
+// The contents of secret files are redacted before the servicegroup is persisted, so the
+// servicegroup loaded from etcd (e.g. restart, scale) keeps the secret as it is.
+const (
+	configFilesSuffix       = "-files"
+	secretFilesSuffix       = "-secret-files"
+	configFilesVolumeName   = "dice-config-files"
+	secretFilesVolumeName   = "dice-secret-files"
+	configFilesChecksumAnno = "config-files-checksum"
+)
+
+// configFilesChecksum returns the checksum of all files of the service, empty if no files
+func configFilesChecksum(service *apistructs.Service) string {
+	if len(service.ConfigFiles) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(service.ConfigFiles))
+	for _, file := range service.ConfigFiles {
+		lines = append(lines, strings.Join([]string{file.Path, boolString(file.Secret), file.ContentChecksum()}, "\x00"))
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// newConfigFiles generates the configmap and secret holding files of the service, nil if no such files
+func newConfigFiles(service *apistructs.Service) (*apiv1.ConfigMap, *apiv1.Secret) {
+	var (
+		cm     *apiv1.ConfigMap
+		secret *apiv1.Secret
+	)
+	deployName := getDeployName(service)
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:      name,
+			Namespace: service.Namespace,
+			Labels:    map[string]string{"app": service.Name},
+		}
+	}
+	for _, file := range service.ConfigFiles {
+		if file.Secret {
+			if secret == nil {
+				secret = &apiv1.Secret{
+					TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+					ObjectMeta: meta(deployName + secretFilesSuffix),
+					Type:       apiv1.SecretTypeOpaque,
+					Data:       map[string][]byte{},
+				}
+			}
+			secret.Data[diceyml.ConfigFileKey(file.Path)] = []byte(file.Content)
+			continue
+		}
+		if cm == nil {
+			cm = &apiv1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+				ObjectMeta: meta(deployName + configFilesSuffix),
+				Data:       map[string]string{},
+			}
+		}
+		cm.Data[diceyml.ConfigFileKey(file.Path)] = file.Content
+	}
+	return cm, secret
+}
+
+// setConfigFiles mounts the files of the service into the first container of the pod
+func setConfigFiles(service *apistructs.Service, podSpec *apiv1.PodSpec, podMeta *metav1.ObjectMeta) {
+	if len(service.ConfigFiles) == 0 || len(podSpec.Containers) == 0 {
+		return
+	}
+	deployName := getDeployName(service)
+	var hasConfig, hasSecret bool
+	for _, file := range service.ConfigFiles {
+		volumeName := configFilesVolumeName
+		if file.Secret {
+			volumeName = secretFilesVolumeName
+			hasSecret = true
+		} else {
+			hasConfig = true
+		}
+		podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, apiv1.VolumeMount{
+			Name:      volumeName,
+			MountPath: file.Path,
+			SubPath:   diceyml.ConfigFileKey(file.Path),
+			ReadOnly:  true,
+		})
+	}
+	if hasConfig {
+		podSpec.Volumes = append(podSpec.Volumes, apiv1.Volume{
+			Name: configFilesVolumeName,
+			VolumeSource: apiv1.VolumeSource{
+				ConfigMap: &apiv1.ConfigMapVolumeSource{
+					LocalObjectReference: apiv1.LocalObjectReference{Name: deployName + configFilesSuffix},
+				},
+			},
+		})
+	}
+	if hasSecret {
+		podSpec.Volumes = append(podSpec.Volumes, apiv1.Volume{
+			Name: secretFilesVolumeName,
+			VolumeSource: apiv1.VolumeSource{
+				Secret: &apiv1.SecretVolumeSource{SecretName: deployName + secretFilesSuffix},
+			},
+		})
+	}
+	if podMeta.Annotations == nil {
+		podMeta.Annotations = make(map[string]string)
+	}
+	podMeta.Annotations[configFilesChecksumAnno] = configFilesChecksum(service)
+}
+
+// updateConfigFiles creates or updates the configmap and secret holding files of the service,
+// and deletes the stale ones which are no longer needed
+func (k *Kubernetes) updateConfigFiles(service *apistructs.Service) error {
+	deployName := getDeployName(service)
+	cm, secret := newConfigFiles(service)
+	if cm != nil {
+		if err := k.configMap.CreateOrUpdate(cm); err != nil {
+			return err
+		}
+	} else if err := k.configMap.DeleteIfExists(service.Namespace, deployName+configFilesSuffix); err != nil {
+		return err
+	}
+	if hasRedactedConfigFiles(service) {
+		return nil
+	}
+	if secret != nil {
+		return k.secret.CreateOrUpdate(secret)
+	}
+	if err := k.secret.Delete(service.Namespace, deployName+secretFilesSuffix); err != nil && !k8serror.NotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// hasRedactedConfigFiles whether the service is loaded from etcd, with contents of secret files redacted
+func hasRedactedConfigFiles(service *apistructs.Service) bool {
+	for _, file := range service.ConfigFiles {
+		if file.Secret && file.Redacted() {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteConfigFiles deletes the configmap and secret holding files of the service
+func (k *Kubernetes) deleteConfigFiles(namespace, deployName string) error {
+	if err := k.configMap.DeleteIfExists(namespace, deployName+configFilesSuffix); err != nil {
+		return err
+	}
+	if err := k.secret.Delete(namespace, deployName+secretFilesSuffix); err != nil && !k8serror.NotFound(err) {
+		return err
+	}
+	return nil
+}
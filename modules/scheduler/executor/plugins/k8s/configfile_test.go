@@ -0,0 +1,65 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func TestConfigFiles(t *testing.T) {
+	service := &apistructs.Service{
+		Name:      "web",
+		Namespace: "default",
+		ConfigFiles: []diceyml.ConfigFile{
+			{Path: "/app/conf/application.yml", Content: "server.port: 8080"},
+			{Path: "/app/certs/tls.key", Content: "key", Secret: true},
+		},
+	}
+	cm, secret := newConfigFiles(service)
+	assert.Equal(t, "web-files", cm.Name)
+	assert.Equal(t, "server.port: 8080", cm.Data["app.conf.application.yml"])
+	assert.Equal(t, "web-secret-files", secret.Name)
+	assert.Equal(t, []byte("key"), secret.Data["app.certs.tls.key"])
+
+	podSpec := apiv1.PodSpec{Containers: []apiv1.Container{{Name: "web"}}}
+	podMeta := metav1.ObjectMeta{}
+	setConfigFiles(service, &podSpec, &podMeta)
+	assert.Equal(t, 2, len(podSpec.Volumes))
+	assert.Equal(t, "app.conf.application.yml", podSpec.Containers[0].VolumeMounts[0].SubPath)
+	assert.Equal(t, secretFilesVolumeName, podSpec.Containers[0].VolumeMounts[1].Name)
+	checksum := podMeta.Annotations[configFilesChecksumAnno]
+	assert.NotEmpty(t, checksum)
+
+	// changing the content leads to a different checksum, thus a rolling restart
+	service.ConfigFiles[0].Content = "server.port: 8081"
+	assert.NotEqual(t, checksum, configFilesChecksum(service))
+
+	// secret contents redacted in etcd keep the checksum, and the secret in cluster is kept as it is
+	checksum = configFilesChecksum(service)
+	assert.False(t, hasRedactedConfigFiles(service))
+	service.ConfigFiles[1] = diceyml.ConfigFile{Path: "/app/certs/tls.key", Secret: true, Checksum: service.ConfigFiles[1].ContentChecksum()}
+	assert.True(t, hasRedactedConfigFiles(service))
+	assert.Equal(t, checksum, configFilesChecksum(service))
+
+	cm, secret = newConfigFiles(&apistructs.Service{Name: "web"})
+	assert.Nil(t, cm)
+	assert.Nil(t, secret)
+}
@@ -216,3 +216,14 @@ func (c *ConfigMap) DeleteIfExists(namespace, name string) error {
 	}
 	return c.Delete(namespace, name)
 }
+
+// CreateOrUpdate creates the k8s configmap if not exists, otherwise updates it
+func (c *ConfigMap) CreateOrUpdate(cm *corev1.ConfigMap) error {
+	if err := c.Exists(cm.Namespace, cm.Name); err != nil {
+		if err == k8serror.ErrNotFound {
+			return c.Create(cm)
+		}
+		return err
+	}
+	return c.Update(cm)
+}
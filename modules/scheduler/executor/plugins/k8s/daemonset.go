@@ -196,6 +196,8 @@ func (k *Kubernetes) newDaemonSet(service *apistructs.Service, sg *apistructs.Se
 	}
 
 	k.AddSpotEmptyDir(&daemonset.Spec.Template.Spec)
+	setConfigFiles(service, &daemonset.Spec.Template.Spec, &daemonset.Spec.Template.ObjectMeta)
+	setLifecycle(service, &daemonset.Spec.Template.Spec)
 
 	return daemonset, nil
 }
@@ -47,6 +47,11 @@ func (k *Kubernetes) createDeployment(service *apistructs.Service, sg *apistruct
 	if err != nil {
 		return errors.Errorf("failed to generate deployment struct, name: %s, (%v)", service.Name, err)
 	}
+	if isProgressiveStrategy(service) {
+		if err := setTemplateHash(deployment); err != nil {
+			return err
+		}
+	}
 
 	return k.deploy.Create(deployment)
 }
@@ -403,10 +408,13 @@ func (k *Kubernetes) newDeployment(service *apistructs.Service, sg *apistructs.S
 			strutil.ToUpper(service.Env["DICE_WORKSPACE"]) == apistructs.TestWorkspace.String()) {
 		deployment.Spec.Strategy = appsv1.DeploymentStrategy{Type: "Recreate"}
 	}
+	// strategy declared in dice.yml takes precedence
+	setRollingUpdateStrategy(service, deployment)
 
-	affinity := constraintbuilders.K8S(&sg.ScheduleInfo2, service, []constraints.PodLabelsForAffinity{
-		{PodLabels: map[string]string{"app": service.Name}}}, k).Affinity
-	deployment.Spec.Template.Spec.Affinity = &affinity
+	cons := constraintbuilders.K8S(&sg.ScheduleInfo2, service, []constraints.PodLabelsForAffinity{
+		{PodLabels: map[string]string{"app": service.Name}}}, k)
+	deployment.Spec.Template.Spec.Affinity = &cons.Affinity
+	deployment.Spec.Template.Spec.TopologySpreadConstraints = cons.TopologySpreadConstraints
 
 	// inject hosts
 	deployment.Spec.Template.Spec.HostAliases = ConvertToHostAlias(service.Hosts)
@@ -517,6 +525,8 @@ func (k *Kubernetes) newDeployment(service *apistructs.Service, sg *apistructs.S
 
 	k.AddPodMountVolume(service, &deployment.Spec.Template.Spec, secretvolmounts, secretvolumes)
 	k.AddSpotEmptyDir(&deployment.Spec.Template.Spec)
+	setConfigFiles(service, &deployment.Spec.Template.Spec, &deployment.Spec.Template.ObjectMeta)
+	setLifecycle(service, &deployment.Spec.Template.Spec)
 
 	logrus.Debugf("show k8s deployment, name: %s, deployment: %+v", deploymentName, deployment)
 	return deployment, nil
@@ -0,0 +1,110 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8serror"
+)
+
+// newPDB generates the poddisruptionbudget of the service's deployment, pdb has the same name as the deployment
+func newPDB(service *apistructs.Service) *policyv1beta1.PodDisruptionBudget {
+	budget := service.DisruptionBudget
+	// selects the same pods as the deployment does
+	selector := deploymentSelector(service)
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: "policy/v1beta1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getDeployName(service),
+			Namespace: service.Namespace,
+			Labels:    map[string]string{"app": service.Name},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+		},
+	}
+	// the value is either a number or a percentage, which has been validated when parsing dice.yml
+	if budget.MinAvailable != "" {
+		v := intstr.Parse(budget.MinAvailable)
+		pdb.Spec.MinAvailable = &v
+	}
+	if budget.MaxUnavailable != "" {
+		v := intstr.Parse(budget.MaxUnavailable)
+		pdb.Spec.MaxUnavailable = &v
+	}
+	return pdb
+}
+
+// updatePDB reconciles the pdb of the service: create or update it if disruption budget is declared, otherwise delete it
+func (k *Kubernetes) updatePDB(service *apistructs.Service) error {
+	name := getDeployName(service)
+	if service.DisruptionBudget == nil {
+		return k.deletePDB(service.Namespace, name)
+	}
+	desired := newPDB(service)
+	old, err := k.pdb.Get(service.Namespace, name)
+	if err != nil {
+		if !k8serror.NotFound(err) {
+			return err
+		}
+		return k.pdb.Create(desired)
+	}
+	desired.ResourceVersion = old.ResourceVersion
+	if err := k.pdb.Put(desired); err != nil {
+		// the spec of pdb is immutable before k8s 1.15, recreate it instead
+		logrus.Warningf("failed to update pdb %s/%s, recreate it: %v", service.Namespace, name, err)
+		if err := k.deletePDB(service.Namespace, name); err != nil {
+			return err
+		}
+		desired.ResourceVersion = ""
+		return k.pdb.Create(desired)
+	}
+	return nil
+}
+
+// deletePDB deletes the pdb, not found is ignored
+func (k *Kubernetes) deletePDB(namespace, name string) error {
+	if err := k.pdb.Delete(namespace, name); err != nil && !k8serror.NotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// setLifecycle sets the pre stop hook of the service container and the termination grace period of the pod
+func setLifecycle(service *apistructs.Service, podSpec *apiv1.PodSpec) {
+	lc := service.Lifecycle
+	if lc == nil || len(podSpec.Containers) == 0 {
+		return
+	}
+	if lc.PreStop != "" {
+		podSpec.Containers[0].Lifecycle = &apiv1.Lifecycle{
+			PreStop: &apiv1.Handler{
+				Exec: &apiv1.ExecAction{Command: []string{"sh", "-c", lc.PreStop}},
+			},
+		}
+	}
+	if lc.TerminationGraceSeconds != nil {
+		grace := *lc.TerminationGraceSeconds
+		podSpec.TerminationGracePeriodSeconds = &grace
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func TestNewPDB(t *testing.T) {
+	service := &apistructs.Service{
+		Name:             "web",
+		Namespace:        "ns",
+		DisruptionBudget: &diceyml.DisruptionBudget{MaxUnavailable: "50%"},
+	}
+	pdb := newPDB(service)
+	assert.Equal(t, "web", pdb.Name)
+	assert.Nil(t, pdb.Spec.MinAvailable)
+	assert.Equal(t, "50%", pdb.Spec.MaxUnavailable.String())
+	assert.Equal(t, map[string]string{"app": "web"}, pdb.Spec.Selector.MatchLabels)
+
+	service.DisruptionBudget = &diceyml.DisruptionBudget{MinAvailable: "1"}
+	pdb = newPDB(service)
+	assert.Equal(t, 1, pdb.Spec.MinAvailable.IntValue())
+}
+
+func TestSetLifecycle(t *testing.T) {
+	grace := int64(60)
+	service := &apistructs.Service{
+		Name:      "web",
+		Lifecycle: &diceyml.Lifecycle{PreStop: "sleep 10", TerminationGraceSeconds: &grace},
+	}
+	podSpec := apiv1.PodSpec{Containers: []apiv1.Container{{Name: "web"}}}
+	setLifecycle(service, &podSpec)
+	assert.Equal(t, []string{"sh", "-c", "sleep 10"}, podSpec.Containers[0].Lifecycle.PreStop.Exec.Command)
+	assert.Equal(t, int64(60), *podSpec.TerminationGracePeriodSeconds)
+}
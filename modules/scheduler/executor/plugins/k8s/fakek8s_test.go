@@ -0,0 +1,239 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/deployment"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8sservice"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/namespace"
+	"github.com/erda-project/erda/pkg/httpclient"
+)
+
+var (
+	fakeK8sPath          = regexp.MustCompile(`^/apis?/(?:apps/)?v1/namespaces/([^/]+)/(deployments|services)(?:/([^/]+))?$`)
+	fakeK8sNamespacePath = regexp.MustCompile(`^/api/v1/namespaces/([^/]+)$`)
+)
+
+// fakeK8s is an in-memory k8s api server serving deployments, services and namespaces, for test
+type fakeK8s struct {
+	sync.Mutex
+	deployments map[string]*appsv1.Deployment
+	services    map[string]*apiv1.Service
+	namespaces  map[string]*apiv1.Namespace
+}
+
+// newFakeKubernetes returns Kubernetes executor talking to a fake k8s api server
+func newFakeKubernetes(t *testing.T) (*Kubernetes, *fakeK8s) {
+	fake := &fakeK8s{
+		deployments: make(map[string]*appsv1.Deployment),
+		services:    make(map[string]*apiv1.Service),
+		namespaces:  make(map[string]*apiv1.Namespace),
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := httpclient.New()
+	return &Kubernetes{
+		deploy:    deployment.New(deployment.WithCompleteParams(addr, client)),
+		service:   k8sservice.New(k8sservice.WithCompleteParams(addr, client)),
+		namespace: namespace.New(namespace.WithCompleteParams(addr, client)),
+	}, fake
+}
+
+func (f *fakeK8s) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	if matches := fakeK8sNamespacePath.FindStringSubmatch(r.URL.Path); matches != nil {
+		var body apiv1.Namespace
+		f.serve(w, r, "", matches[1], &body, func(key string) (interface{}, bool) {
+			ns, ok := f.namespaces[key]
+			return ns, ok
+		}, func(key string) { f.namespaces[key] = &body }, func(key string) { delete(f.namespaces, key) }, nil)
+		return
+	}
+	matches := fakeK8sPath.FindStringSubmatch(r.URL.Path)
+	if matches == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	namespace, kind, name := matches[1], matches[2], matches[3]
+	switch kind {
+	case "deployments":
+		var body appsv1.Deployment
+		f.serve(w, r, namespace, name, &body, func(key string) (interface{}, bool) {
+			d, ok := f.deployments[key]
+			return d, ok
+		}, func(key string) {
+			body.Generation = 1
+			if old, ok := f.deployments[key]; ok {
+				body.Generation = old.Generation + 1
+				body.Status = old.Status
+			}
+			f.deployments[key] = &body
+		}, func(key string) { delete(f.deployments, key) }, func() interface{} {
+			list := appsv1.DeploymentList{}
+			for _, d := range f.deployments {
+				if d.Namespace == namespace && matchLabels(d.Labels, r.URL.Query().Get("labelSelector")) {
+					list.Items = append(list.Items, *d)
+				}
+			}
+			return list
+		})
+	case "services":
+		var body apiv1.Service
+		f.serve(w, r, namespace, name, &body, func(key string) (interface{}, bool) {
+			s, ok := f.services[key]
+			return s, ok
+		}, func(key string) { f.services[key] = &body }, func(key string) { delete(f.services, key) }, nil)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeK8s) serve(w http.ResponseWriter, r *http.Request, namespace, name string, body interface{},
+	get func(key string) (interface{}, bool), put func(key string), del func(key string), list func() interface{}) {
+	key := namespace + "/" + name
+	switch r.Method {
+	case http.MethodGet:
+		if name == "" && list != nil {
+			json.NewEncoder(w).Encode(list())
+			return
+		}
+		obj, ok := get(key)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(obj)
+	case http.MethodPost, http.MethodPut:
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		meta := objectName(body)
+		key = namespace + "/" + meta
+		_, exists := get(key)
+		if r.Method == http.MethodPost && exists {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if r.Method == http.MethodPut && !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		put(key)
+		json.NewEncoder(w).Encode(body)
+	case http.MethodDelete:
+		if _, ok := get(key); !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		del(key)
+		w.Write([]byte("{}"))
+	}
+}
+
+func objectName(obj interface{}) string {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return o.Name
+	case *apiv1.Service:
+		return o.Name
+	case *apiv1.Namespace:
+		return o.Name
+	}
+	return ""
+}
+
+// matchLabels checks labels against selector in form of k1=v1,k2=v2
+func matchLabels(labels map[string]string, selector string) bool {
+	if selector == "" {
+		return true
+	}
+	for _, kv := range strings.Split(selector, ",") {
+		pair := strings.SplitN(kv, "=", 2)
+		if len(pair) != 2 || labels[pair[0]] != pair[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// setReady marks the deployment as rolled out with all replicas ready
+func (f *fakeK8s) setReady(namespace, name string) {
+	f.Lock()
+	defer f.Unlock()
+	d, ok := f.deployments[namespace+"/"+name]
+	if !ok {
+		return
+	}
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	d.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: d.Generation,
+		Replicas:           replicas,
+		UpdatedReplicas:    replicas,
+		ReadyReplicas:      replicas,
+		AvailableReplicas:  replicas,
+	}
+}
+
+func (f *fakeK8s) getDeployment(namespace, name string) *appsv1.Deployment {
+	f.Lock()
+	defer f.Unlock()
+	d, ok := f.deployments[namespace+"/"+name]
+	if !ok {
+		return nil
+	}
+	return d.DeepCopy()
+}
+
+func (f *fakeK8s) getService(namespace, name string) *apiv1.Service {
+	f.Lock()
+	defer f.Unlock()
+	s, ok := f.services[namespace+"/"+name]
+	if !ok {
+		return nil
+	}
+	return s.DeepCopy()
+}
+
+func (f *fakeK8s) putNamespace(ns *apiv1.Namespace) {
+	f.Lock()
+	defer f.Unlock()
+	f.namespaces["/"+ns.Name] = ns.DeepCopy()
+}
+
+func (f *fakeK8s) getNamespace(name string) *apiv1.Namespace {
+	f.Lock()
+	defer f.Unlock()
+	ns, ok := f.namespaces["/"+name]
+	if !ok {
+		return nil
+	}
+	return ns.DeepCopy()
+}
@@ -14,12 +14,18 @@
 package k8s
 
 import (
+	"strconv"
+
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
 )
 
+// grpcHealthProbeCmd the k8s version in use has no native grpc probe, check by grpc_health_probe in the image instead
+const grpcHealthProbeCmd = "grpc_health_probe"
+
 func (k *Kubernetes) NewHealthcheckProbe(service *apistructs.Service) *apiv1.Probe {
 	return FillHealthCheckProbe(service)
 }
@@ -37,6 +43,18 @@ func SetHealthCheck(container *apiv1.Container, service *apistructs.Service) {
 	}
 	container.ReadinessProbe = readinessprobe
 
+	// separately configured probes take precedence
+	if hc := service.NewHealthCheck; hc != nil {
+		if hc.LivenessProbe != nil {
+			container.LivenessProbe = ConvertProbe(hc.LivenessProbe)
+		}
+		if hc.ReadinessProbe != nil {
+			container.ReadinessProbe = ConvertProbe(hc.ReadinessProbe)
+		}
+		if hc.StartupProbe != nil {
+			container.StartupProbe = ConvertProbe(hc.StartupProbe)
+		}
+	}
 }
 
 // FillHealthCheckProbe Fill out k8s probe based on service
@@ -47,7 +65,8 @@ func FillHealthCheckProbe(service *apistructs.Service) *apiv1.Probe {
 		oldHC = service.HealthCheck
 	)
 
-	if newHC != nil && (newHC.ExecHealthCheck != nil || newHC.HttpHealthCheck != nil) {
+	if newHC != nil && (newHC.ExecHealthCheck != nil || newHC.HttpHealthCheck != nil ||
+		newHC.TCPHealthCheck != nil || newHC.GRPCHealthCheck != nil) {
 		probe = NewHealthCheck(newHC)
 	} else if oldHC != nil {
 		probe = OldHealthCheck(oldHC)
@@ -86,7 +105,8 @@ func DefaultHealthCheck(service *apistructs.Service) *apiv1.Probe {
 
 // NewHealthCheck Configure the new version of Dice health check
 func NewHealthCheck(hc *apistructs.NewHealthCheck) *apiv1.Probe {
-	if hc == nil || (hc.HttpHealthCheck == nil && hc.ExecHealthCheck == nil) {
+	if hc == nil || (hc.HttpHealthCheck == nil && hc.ExecHealthCheck == nil &&
+		hc.TCPHealthCheck == nil && hc.GRPCHealthCheck == nil) {
 		return nil
 	}
 
@@ -110,10 +130,70 @@ func NewHealthCheck(hc *apistructs.NewHealthCheck) *apiv1.Probe {
 		if times := int32(execCheck.Duration) / 15; times > probe.FailureThreshold {
 			probe.FailureThreshold = times
 		}
+	} else if hc.TCPHealthCheck != nil {
+		tcpCheck := hc.TCPHealthCheck
+		probe.TCPSocket = &apiv1.TCPSocketAction{
+			Port: intstr.FromInt(tcpCheck.Port),
+		}
+		if times := int32(tcpCheck.Duration) / 15; times > probe.FailureThreshold {
+			probe.FailureThreshold = times
+		}
+	} else if hc.GRPCHealthCheck != nil {
+		grpcCheck := hc.GRPCHealthCheck
+		probe.Exec = &apiv1.ExecAction{
+			Command: grpcHealthProbeCommand(grpcCheck.Port, grpcCheck.Service),
+		}
+		if times := int32(grpcCheck.Duration) / 15; times > probe.FailureThreshold {
+			probe.FailureThreshold = times
+		}
+	}
+	return probe
+}
+
+// ConvertProbe Convert the separately configured probe of dice.yml to k8s probe,
+// the unset timing fields are left to the default of k8s
+func ConvertProbe(p *diceyml.Probe) *apiv1.Probe {
+	if p == nil {
+		return nil
+	}
+	probe := &apiv1.Probe{
+		InitialDelaySeconds: int32(p.InitialDelaySeconds),
+		PeriodSeconds:       int32(p.PeriodSeconds),
+		TimeoutSeconds:      int32(p.TimeoutSeconds),
+		SuccessThreshold:    int32(p.SuccessThreshold),
+		FailureThreshold:    int32(p.FailureThreshold),
+	}
+	switch {
+	case p.HTTP != nil:
+		probe.HTTPGet = &apiv1.HTTPGetAction{
+			Path:   p.HTTP.Path,
+			Port:   intstr.FromInt(p.HTTP.Port),
+			Scheme: apiv1.URISchemeHTTP,
+		}
+	case p.Exec != nil:
+		probe.Exec = &apiv1.ExecAction{
+			Command: []string{"sh", "-c", p.Exec.Cmd},
+		}
+	case p.TCP != nil:
+		probe.TCPSocket = &apiv1.TCPSocketAction{
+			Port: intstr.FromInt(p.TCP.Port),
+		}
+	case p.GRPC != nil:
+		probe.Exec = &apiv1.ExecAction{
+			Command: grpcHealthProbeCommand(p.GRPC.Port, p.GRPC.Service),
+		}
 	}
 	return probe
 }
 
+func grpcHealthProbeCommand(port int, service string) []string {
+	cmd := []string{grpcHealthProbeCmd, "-addr=:" + strconv.Itoa(port)}
+	if service != "" {
+		cmd = append(cmd, "-service="+service)
+	}
+	return cmd
+}
+
 // OldHealthCheck Compatible with Dice old version health detection
 func OldHealthCheck(hc *apistructs.HealthCheck) *apiv1.Probe {
 	if hc == nil {
@@ -20,6 +20,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
 )
 
 func TestFillHealthCheckProbe(t *testing.T) {
@@ -86,3 +87,29 @@ func TestFillHealthCheckProbe(t *testing.T) {
 	assert.Equal(t, []string{"sh", "-c", service.NewHealthCheck.ExecHealthCheck.Cmd}, probe.Exec.Command)
 	assert.Equal(t, int32(service.NewHealthCheck.ExecHealthCheck.Duration/15), probe.FailureThreshold)
 }
+
+func TestSetHealthCheckProbes(t *testing.T) {
+	service := &apistructs.Service{
+		NewHealthCheck: &apistructs.NewHealthCheck{
+			TCPHealthCheck: &apistructs.TCPHealthCheck{Port: 8080},
+			StartupProbe: &diceyml.Probe{
+				HTTP:             &diceyml.HTTPCheck{Port: 8080, Path: "/health"},
+				PeriodSeconds:    10,
+				FailureThreshold: 30,
+			},
+			ReadinessProbe: &diceyml.Probe{
+				GRPC:          &diceyml.GRPCCheck{Port: 9090, Service: "app"},
+				PeriodSeconds: 5,
+			},
+		},
+	}
+	container := &corev1.Container{}
+	SetHealthCheck(container, service)
+	// liveness from the plain tcp check
+	assert.Equal(t, 8080, container.LivenessProbe.TCPSocket.Port.IntValue())
+	assert.Equal(t, "/health", container.StartupProbe.HTTPGet.Path)
+	assert.Equal(t, int32(30), container.StartupProbe.FailureThreshold)
+	assert.Equal(t, []string{"grpc_health_probe", "-addr=:9090", "-service=app"}, container.ReadinessProbe.Exec.Command)
+	assert.Equal(t, int32(5), container.ReadinessProbe.PeriodSeconds)
+	assert.Equal(t, int32(0), container.ReadinessProbe.InitialDelaySeconds)
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8serror"
+)
+
+// newHPA generates the hpa of the service's deployment, hpa has the same name as the deployment
+func newHPA(service *apistructs.Service) *autoscalingv2beta2.HorizontalPodAutoscaler {
+	as := service.Autoscaling
+	deploymentName := getDeployName(service)
+	minReplicas := int32(as.MinReplicas)
+
+	var metrics []autoscalingv2beta2.MetricSpec
+	resourceMetric := func(name apiv1.ResourceName, utilization int) autoscalingv2beta2.MetricSpec {
+		return autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.ResourceMetricSourceType,
+			Resource: &autoscalingv2beta2.ResourceMetricSource{
+				Name: name,
+				Target: autoscalingv2beta2.MetricTarget{
+					Type:               autoscalingv2beta2.UtilizationMetricType,
+					AverageUtilization: func(i int32) *int32 { return &i }(int32(utilization)),
+				},
+			},
+		}
+	}
+	if as.CPUUtilization > 0 {
+		metrics = append(metrics, resourceMetric(apiv1.ResourceCPU, as.CPUUtilization))
+	}
+	if as.MemUtilization > 0 {
+		metrics = append(metrics, resourceMetric(apiv1.ResourceMemory, as.MemUtilization))
+	}
+	for _, m := range as.Metrics {
+		// target_average_value has been validated when parsing dice.yml
+		target, err := resource.ParseQuantity(m.TargetAverageValue)
+		if err != nil {
+			logrus.Errorf("ignore autoscaling metric %s of service %s, invalid target: %s", m.Name, service.Name, m.TargetAverageValue)
+			continue
+		}
+		metrics = append(metrics, autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.PodsMetricSourceType,
+			Pods: &autoscalingv2beta2.PodsMetricSource{
+				Metric: autoscalingv2beta2.MetricIdentifier{Name: m.Name},
+				Target: autoscalingv2beta2.MetricTarget{
+					Type:         autoscalingv2beta2.AverageValueMetricType,
+					AverageValue: &target,
+				},
+			},
+		})
+	}
+
+	return &autoscalingv2beta2.HorizontalPodAutoscaler{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "HorizontalPodAutoscaler",
+			APIVersion: "autoscaling/v2beta2",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: service.Namespace,
+			Labels:    map[string]string{"app": service.Name},
+		},
+		Spec: autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{
+				Kind:       "Deployment",
+				Name:       deploymentName,
+				APIVersion: "apps/v1",
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: int32(as.MaxReplicas),
+			Metrics:     metrics,
+		},
+	}
+}
+
+// updateHPA reconciles the hpa of the service: create or update it if autoscaling is declared, otherwise delete it
+func (k *Kubernetes) updateHPA(service *apistructs.Service) error {
+	name := getDeployName(service)
+	if service.Autoscaling == nil {
+		return k.deleteHPA(service.Namespace, name)
+	}
+	desired := newHPA(service)
+	old, err := k.hpa.Get(service.Namespace, name)
+	if err != nil {
+		if !k8serror.NotFound(err) {
+			return err
+		}
+		return k.hpa.Create(desired)
+	}
+	desired.ResourceVersion = old.ResourceVersion
+	return k.hpa.Put(desired)
+}
+
+// deleteHPA deletes the hpa, not found is ignored
+func (k *Kubernetes) deleteHPA(namespace, name string) error {
+	if err := k.hpa.Delete(namespace, name); err != nil && !k8serror.NotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// keepAutoscaledReplicas sets the replicas of deployment to the current replicas in cluster,
+// so that updating deployment will not reset the replicas scaled by hpa
+func (k *Kubernetes) keepAutoscaledReplicas(service *apistructs.Service, deployment *appsv1.Deployment) error {
+	if service.Autoscaling == nil {
+		return nil
+	}
+	old, err := k.getDeployment(deployment.Namespace, deployment.Name)
+	if err != nil {
+		if k8serror.NotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if old.Spec.Replicas == nil {
+		return nil
+	}
+	replicas := *old.Spec.Replicas
+	if replicas < int32(service.Autoscaling.MinReplicas) {
+		replicas = int32(service.Autoscaling.MinReplicas)
+	}
+	if replicas > int32(service.Autoscaling.MaxReplicas) {
+		replicas = int32(service.Autoscaling.MaxReplicas)
+	}
+	deployment.Spec.Replicas = &replicas
+	return nil
+}
+
+// getCurrentReplicas returns the replicas currently decided by hpa
+func (k *Kubernetes) getCurrentReplicas(service *apistructs.Service) (int, error) {
+	hpa, err := k.hpa.Get(service.Namespace, getDeployName(service))
+	if err != nil {
+		return 0, err
+	}
+	return int(hpa.Status.CurrentReplicas), nil
+}
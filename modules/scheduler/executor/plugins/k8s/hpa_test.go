@@ -0,0 +1,48 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func TestNewHPA(t *testing.T) {
+	service := &apistructs.Service{
+		Name:      "web",
+		Namespace: "ns",
+		Autoscaling: &diceyml.Autoscaling{
+			MinReplicas:    2,
+			MaxReplicas:    10,
+			CPUUtilization: 70,
+			Metrics: []diceyml.AutoscalingMetric{
+				{Name: "http_requests_per_second", TargetAverageValue: "100"},
+			},
+		},
+	}
+	hpa := newHPA(service)
+	assert.Equal(t, "web", hpa.Name)
+	assert.Equal(t, "web", hpa.Spec.ScaleTargetRef.Name)
+	assert.Equal(t, int32(2), *hpa.Spec.MinReplicas)
+	assert.Equal(t, int32(10), hpa.Spec.MaxReplicas)
+	assert.Equal(t, 2, len(hpa.Spec.Metrics))
+	assert.Equal(t, int32(70), *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+	assert.Equal(t, autoscalingv2beta2.PodsMetricSourceType, hpa.Spec.Metrics[1].Type)
+	assert.Equal(t, int64(100), hpa.Spec.Metrics[1].Pods.Target.AverageValue.Value())
+}
@@ -144,6 +144,32 @@ func (n *Ingress) Get(namespace, name string) (*extensionsv1beta1.Ingress, error
 	return &ingress, nil
 }
 
+// List lists the k8s ingress objects in namespace
+func (n *Ingress) List(namespace string) (*extensionsv1beta1.IngressList, error) {
+	var (
+		b    bytes.Buffer
+		list extensionsv1beta1.IngressList
+	)
+
+	resp, err := n.client.Get(n.addr).
+		Path("/apis/extensions/v1beta1/namespaces/" + namespace + "/ingresses").
+		Do().
+		Body(&b)
+	if err != nil {
+		return nil, errors.Errorf("failed to list ingresses, namespace: %s, err: %v", namespace, err)
+	}
+	if !resp.IsOK() {
+		return nil, errors.Errorf("failed to list ingresses, namespace: %s, statuscode: %v, body: %v",
+			namespace, resp.StatusCode(), b.String())
+	}
+
+	if err := json.NewDecoder(&b).Decode(&list); err != nil {
+		return nil, err
+	}
+
+	return &list, nil
+}
+
 // CreateOrUpdate create or update a k8s ingress object
 func (n *Ingress) CreateOrUpdate(ing *extensionsv1beta1.Ingress) error {
 	var getErr error
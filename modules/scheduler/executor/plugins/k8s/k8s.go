@@ -36,18 +36,24 @@ import (
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/addon"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/addon/daemonset"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/addon/elasticsearch"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/addon/mongodb"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/addon/mysql"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/addon/postgresql"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/addon/redis"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/clusterinfo"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/configmap"
 	ds "github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/daemonset"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/deployment"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/event"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/hpa"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/ingress"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/instanceinfosync"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8serror"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8sservice"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/namespace"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/networkpolicy"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/nodelabel"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/pdb"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/persistentvolume"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/persistentvolumeclaim"
 	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/pod"
@@ -164,6 +170,9 @@ type Kubernetes struct {
 	evCh         chan *eventtypes.StatusEvent
 	deploy       *deployment.Deployment
 	ds           *ds.Daemonset
+	hpa          *hpa.HPA
+	pdb          *pdb.PDB
+	netPolicy    *networkpolicy.NetworkPolicy
 	ingress      *ingress.Ingress
 	namespace    *namespace.Namespace
 	service      *k8sservice.Service
@@ -172,6 +181,7 @@ type Kubernetes struct {
 	sts          *statefulset.StatefulSet
 	pod          *pod.Pod
 	secret       *secret.Secret
+	configMap    *configmap.ConfigMap
 	sa           *serviceaccount.ServiceAccount
 	nodeLabel    *nodelabel.NodeLabel
 	ClusterInfo  *clusterinfo.ClusterInfo
@@ -195,6 +205,8 @@ type Kubernetes struct {
 	redisoperator         addon.AddonOperator
 	mysqloperator         addon.AddonOperator
 	daemonsetoperator     addon.AddonOperator
+	postgresqloperator    addon.AddonOperator
+	mongodboperator       addon.AddonOperator
 
 	// instanceinfoSyncCancelFunc
 	instanceinfoSyncCancelFunc context.CancelFunc
@@ -328,6 +340,9 @@ func New(name executortypes.Name, clusterName string, options map[string]string)
 
 	deploy := deployment.New(deployment.WithCompleteParams(addr, client))
 	ds := ds.New(ds.WithCompleteParams(addr, client))
+	k8shpa := hpa.New(hpa.WithCompleteParams(addr, client))
+	k8spdb := pdb.New(pdb.WithCompleteParams(addr, client))
+	k8snetworkpolicy := networkpolicy.New(networkpolicy.WithCompleteParams(addr, client))
 	ing := ingress.New(ingress.WithCompleteParams(addr, client))
 	ns := namespace.New(namespace.WithCompleteParams(addr, client))
 	svc := k8sservice.New(k8sservice.WithCompleteParams(addr, client))
@@ -336,6 +351,7 @@ func New(name executortypes.Name, clusterName string, options map[string]string)
 	sts := statefulset.New(statefulset.WithCompleteParams(addr, client))
 	k8spod := pod.New(pod.WithCompleteParams(addr, client))
 	k8ssecret := secret.New(secret.WithCompleteParams(addr, client))
+	cm := configmap.New(configmap.WithCompleteParams(addr, client))
 	sa := serviceaccount.New(serviceaccount.WithCompleteParams(addr, client))
 	nodeLabel := nodelabel.New(addr, client)
 	event := event.New(event.WithCompleteParams(addr, client))
@@ -377,6 +393,9 @@ func New(name executortypes.Name, clusterName string, options map[string]string)
 		evCh:                     evCh,
 		deploy:                   deploy,
 		ds:                       ds,
+		hpa:                      k8shpa,
+		pdb:                      k8spdb,
+		netPolicy:                k8snetworkpolicy,
 		ingress:                  ing,
 		namespace:                ns,
 		service:                  svc,
@@ -385,6 +404,7 @@ func New(name executortypes.Name, clusterName string, options map[string]string)
 		sts:                      sts,
 		pod:                      k8spod,
 		secret:                   k8ssecret,
+		configMap:                cm,
 		sa:                       sa,
 		nodeLabel:                nodeLabel,
 		ClusterInfo:              clusterInfo,
@@ -411,6 +431,10 @@ func New(name executortypes.Name, clusterName string, options map[string]string)
 	k.mysqloperator = mysqloperator
 	daemonsetoperator := daemonset.New(k, ns, k, k, ds, k)
 	k.daemonsetoperator = daemonsetoperator
+	postgresqloperator := postgresql.New(k, ns, k8ssecret, k, client)
+	k.postgresqloperator = postgresqloperator
+	mongodboperator := mongodb.New(k, ns, k8ssecret, k, client)
+	k.mongodboperator = mongodboperator
 	return k, nil
 }
 
@@ -570,7 +594,12 @@ func (k *Kubernetes) Inspect(ctx context.Context, specObj interface{}) (interfac
 		if err != nil {
 			return nil, fmt.Errorf("not found addonoperator: %v", operator)
 		}
-		return addon.Inspect(op, runtime)
+		sg, err := addon.Inspect(op, runtime)
+		if err != nil {
+			return nil, err
+		}
+		k.inspectOperatorRollout(sg)
+		return sg, nil
 	}
 
 	if IsGroupStateful(runtime) {
@@ -613,6 +642,8 @@ func (k *Kubernetes) CapacityInfo() apistructs.CapacityInfoData {
 	r.RedisOperator = k.redisoperator.IsSupported()
 	r.MysqlOperator = k.mysqloperator.IsSupported()
 	r.DaemonsetOperator = k.daemonsetoperator.IsSupported()
+	r.PostgresqlOperator = k.postgresqloperator.IsSupported()
+	r.MongodbOperator = k.mongodboperator.IsSupported()
 	return r
 }
 
@@ -684,6 +715,12 @@ func (k *Kubernetes) createOne(service *apistructs.Service, sg *apistructs.Servi
 			return err
 		}
 	}
+	if err := k.updateConfigFiles(service); err != nil {
+		return err
+	}
+	if err := k.updateNetworkPolicy(service, sg); err != nil {
+		return err
+	}
 	var err error
 	switch service.WorkLoad {
 	case ServicePerNode:
@@ -691,6 +728,12 @@ func (k *Kubernetes) createOne(service *apistructs.Service, sg *apistructs.Servi
 	default:
 		// Step 2. Create related deployment
 		err = k.createDeployment(service, sg)
+		if err == nil && service.Autoscaling != nil {
+			err = k.updateHPA(service)
+		}
+		if err == nil && service.DisruptionBudget != nil {
+			err = k.updatePDB(service)
+		}
 	}
 	if err != nil {
 		return err
@@ -723,6 +766,18 @@ func (k *Kubernetes) tryDelete(namespace, name string) error {
 			return err
 		}
 	}
+	if err := k.deleteHPA(namespace, name); err != nil {
+		return err
+	}
+	if err := k.deletePDB(namespace, name); err != nil {
+		return err
+	}
+	if err := k.deleteNetworkPolicy(namespace, name); err != nil {
+		return err
+	}
+	if err := k.deleteRollout(namespace, name); err != nil {
+		return err
+	}
 	wg.Add(2)
 	go func() {
 		err1 = k.deleteDeployment(namespace, name)
@@ -739,7 +794,7 @@ func (k *Kubernetes) tryDelete(namespace, name string) error {
 			namespace, name, err1, err2)
 	}
 
-	return nil
+	return k.deleteConfigFiles(namespace, name)
 }
 
 func (k *Kubernetes) getClusterIP(namespace, name string) (string, error) {
@@ -761,6 +816,9 @@ func (k *Kubernetes) updateOneByOne(sg *apistructs.ServiceGroup) error {
 		labelSelector[LabelServiceGroupID] = sg.ID
 	}
 
+	if err := k.labelAddonNamespaces(sg); err != nil {
+		return err
+	}
 	visited := make([]string, 0)
 	oldSpecServices, err := k.listServiceName(ns, labelSelector)
 	if err != nil {
@@ -808,6 +866,14 @@ func (k *Kubernetes) updateOneByOne(sg *apistructs.ServiceGroup) error {
 			if err := k.updateService(&svc); err != nil {
 				return err
 			}
+			if err := k.updateConfigFiles(&svc); err != nil {
+				logrus.Errorf("failed to update config files in update interface, name: %s, (%v)", svc.Name, err)
+				return err
+			}
+			if err := k.updateNetworkPolicy(&svc, sg); err != nil {
+				logrus.Errorf("failed to update networkpolicy in update interface, name: %s, (%v)", svc.Name, err)
+				return err
+			}
 			switch svc.WorkLoad {
 			case ServicePerNode:
 				desireDaemonSet, err := k.newDaemonSet(&svc, sg)
@@ -824,10 +890,30 @@ func (k *Kubernetes) updateOneByOne(sg *apistructs.ServiceGroup) error {
 				if err != nil {
 					return err
 				}
+				if err = k.keepAutoscaledReplicas(&svc, desiredDeployment); err != nil {
+					return err
+				}
+				if err = k.updatePDB(&svc); err != nil {
+					logrus.Errorf("failed to update pdb in update interface, name: %s, (%v)", svc.Name, err)
+					return err
+				}
+				rollingOut, err := k.rolloutDeployment(&svc, desiredDeployment)
+				if err != nil {
+					logrus.Errorf("failed to rollout deployment in update interface, name: %s, (%v)", svc.Name, err)
+					return err
+				}
+				if rollingOut {
+					// the stable deployment is updated when the rollout is promoted
+					break
+				}
 				if err = k.putDeployment(desiredDeployment); err != nil {
 					logrus.Debugf("failed to update deployment in update interface, name: %s, (%v)", svc.Name, err)
 					return err
 				}
+				if err = k.updateHPA(&svc); err != nil {
+					logrus.Errorf("failed to update hpa in update interface, name: %s, (%v)", svc.Name, err)
+					return err
+				}
 			}
 			if k.istioEngine != istioctl.EmptyEngine {
 				if err := k.istioEngine.OnServiceOperator(istioctl.ServiceUpdate, &svc); err != nil {
@@ -966,6 +1052,14 @@ func (k *Kubernetes) getStatelessStatus(ctx context.Context, sg *apistructs.Serv
 
 			return status, err
 		}
+		if sg.Services[i].WorkLoad != ServicePerNode && isProgressiveStrategy(&sg.Services[i]) {
+			rollout, err := k.reconcileRollout(&sg.Services[i])
+			if err != nil {
+				logrus.Errorf("failed to reconcile rollout, namespace: %s, name: %s, (%v)",
+					sg.Services[i].Namespace, getDeployName(&sg.Services[i]), err)
+			}
+			sg.Services[i].Rollout = rollout
+		}
 		if status.Status != apistructs.StatusReady {
 			isReady = false
 			resultStatus.Status = apistructs.StatusProgressing
@@ -984,6 +1078,15 @@ func (k *Kubernetes) getStatelessStatus(ctx context.Context, sg *apistructs.Serv
 		}
 
 		sg.Services[i].Status = apistructs.StatusHealthy
+		if sg.Services[i].Autoscaling != nil && sg.Services[i].WorkLoad != ServicePerNode {
+			replicas, err := k.getCurrentReplicas(&sg.Services[i])
+			if err != nil {
+				logrus.Errorf("failed to get current replicas of hpa, namespace: %s, name: %s, (%v)",
+					sg.Services[i].Namespace, getDeployName(&sg.Services[i]), err)
+				continue
+			}
+			sg.Services[i].CurrentReplicas = replicas
+		}
 	}
 
 	if isReady {
@@ -1045,6 +1148,10 @@ func (k *Kubernetes) whichOperator(operator string) (addon.AddonOperator, error)
 		return k.mysqloperator, nil
 	case "daemonset":
 		return k.daemonsetoperator, nil
+	case "postgresql":
+		return k.postgresqloperator, nil
+	case "mongodb":
+		return k.mongodboperator, nil
 	}
 	return nil, fmt.Errorf("not found")
 }
@@ -0,0 +1,112 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"context"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/executortypes"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8serror"
+)
+
+// Live reads the deployments and daemonsets of the servicegroup from cluster,
+// orchestrator compares them with the servicegroup to detect drift
+func (k *Kubernetes) Live(ctx context.Context, sg *apistructs.ServiceGroup) ([]apistructs.ServiceLiveSpec, error) {
+	if IsGroupStateful(sg) {
+		return nil, executortypes.ErrLiveNotSupported
+	}
+	ns := MakeNamespace(sg)
+	if sg.ProjectNamespace != "" {
+		ns = sg.ProjectNamespace
+		k.setProjectNamespaceEnvs(sg)
+	}
+	result := make([]apistructs.ServiceLiveSpec, 0, len(sg.Services))
+	for i := range sg.Services {
+		service := &sg.Services[i]
+		service.Namespace = ns
+		live := apistructs.ServiceLiveSpec{Name: service.Name}
+		var podSpec *apiv1.PodSpec
+		switch service.WorkLoad {
+		case ServicePerNode:
+			ds, err := k.getDaemonSet(ns, getDeployName(service))
+			if err != nil {
+				if !k8serror.NotFound(err) {
+					return nil, err
+				}
+				live.Missing = true
+				break
+			}
+			live.Replicas = int(ds.Status.DesiredNumberScheduled)
+			podSpec = &ds.Spec.Template.Spec
+		default:
+			deploy, err := k.getDeployment(ns, getDeployName(service))
+			if err != nil {
+				if !k8serror.NotFound(err) {
+					return nil, err
+				}
+				live.Missing = true
+				break
+			}
+			if deploy.Spec.Replicas != nil {
+				live.Replicas = int(*deploy.Spec.Replicas)
+			}
+			podSpec = &deploy.Spec.Template.Spec
+		}
+		if podSpec != nil {
+			fillLiveContainer(&live, podSpec)
+		}
+		if !live.Missing && len(service.Ports) > 0 {
+			// ports are exposed by the k8s service, containers do not declare them
+			svc, err := k.GetService(ns, getServiceName(service))
+			if err != nil && !k8serror.NotFound(err) {
+				return nil, err
+			}
+			if err == nil {
+				for _, p := range svc.Spec.Ports {
+					live.Ports = append(live.Ports, int(p.Port))
+				}
+			}
+		}
+		result = append(result, live)
+	}
+	return result, nil
+}
+
+// fillLiveContainer fills the live spec with the container named after the service,
+// envs from configmap or secret are ignored
+func fillLiveContainer(live *apistructs.ServiceLiveSpec, podSpec *apiv1.PodSpec) {
+	for _, c := range podSpec.Containers {
+		if c.Name != live.Name {
+			continue
+		}
+		live.Image = c.Image
+		live.Envs = make(map[string]string, len(c.Env))
+		for _, env := range c.Env {
+			if env.ValueFrom != nil {
+				continue
+			}
+			live.Envs[env.Name] = env.Value
+		}
+		if cpu, ok := c.Resources.Limits[apiv1.ResourceCPU]; ok {
+			live.CPU = float64(cpu.MilliValue()) / 1000
+		}
+		if mem, ok := c.Resources.Limits[apiv1.ResourceMemory]; ok {
+			live.Mem = float64(mem.Value()) / (1024 * 1024)
+		}
+		return
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/executortypes"
+)
+
+func TestFillLiveContainer(t *testing.T) {
+	podSpec := &apiv1.PodSpec{
+		Containers: []apiv1.Container{
+			{Name: "sidecar", Image: "envoy"},
+			{
+				Name:  "web",
+				Image: "nginx:1.1",
+				Env: []apiv1.EnvVar{
+					{Name: "A", Value: "1"},
+					{Name: "SECRET", ValueFrom: &apiv1.EnvVarSource{}},
+				},
+				Resources: apiv1.ResourceRequirements{
+					Limits: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("500m"),
+						apiv1.ResourceMemory: resource.MustParse("512Mi"),
+					},
+				},
+			},
+		},
+	}
+	live := apistructs.ServiceLiveSpec{Name: "web"}
+	fillLiveContainer(&live, podSpec)
+	assert.Equal(t, "nginx:1.1", live.Image)
+	assert.Equal(t, map[string]string{"A": "1"}, live.Envs)
+	assert.Equal(t, 0.5, live.CPU)
+	assert.Equal(t, float64(512), live.Mem)
+}
+
+func TestLiveStatefulNotSupported(t *testing.T) {
+	k := &Kubernetes{}
+	sg := &apistructs.ServiceGroup{}
+	sg.Labels = map[string]string{ServiceType: ServiceAddon}
+	_, err := k.Live(context.Background(), sg)
+	assert.Equal(t, executortypes.ErrLiveNotSupported, err)
+}
@@ -16,6 +16,7 @@ package namespace
 
 import (
 	"bytes"
+	"encoding/json"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -28,6 +29,9 @@ import (
 	"github.com/erda-project/erda/pkg/strutil"
 )
 
+// LabelName is set to the name of namespace on each namespace, so that networkpolicy can select it
+const LabelName = "dice/namespace"
+
 // Namespace is the object to manipulate k8s api of namespace
 type Namespace struct {
 	addr   string
@@ -59,6 +63,7 @@ func WithCompleteParams(addr string, client *httpclient.HTTPClient) Option {
 // Create creates a k8s namespace
 // TODO: Need to pass in the namespace structure
 func (n *Namespace) Create(ns string, labels map[string]string) error {
+	labels = withNameLabel(ns, labels)
 	namespace := &apiv1.Namespace{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -89,6 +94,7 @@ func (n *Namespace) Create(ns string, labels map[string]string) error {
 }
 
 func (n *Namespace) Update(ns string, labels map[string]string) error {
+	labels = withNameLabel(ns, labels)
 	namespace := &apiv1.Namespace{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -196,3 +202,52 @@ func (n *Namespace) DeleteForce(ns string) error {
 	}
 	return nil
 }
+
+// EnsureNameLabel sets LabelName on the namespace if it is missing, e.g. namespaces created before the label is introduced
+func (n *Namespace) EnsureNameLabel(ns string) error {
+	var b bytes.Buffer
+	resp, err := n.client.Get(n.addr).
+		Path("/api/v1/namespaces/" + ns).
+		Do().
+		Body(&b)
+	if err != nil {
+		return errors.Errorf("failed to get namespace, ns: %s, (%v)", ns, err)
+	}
+	if !resp.IsOK() {
+		if resp.IsNotfound() {
+			return k8serror.ErrNotFound
+		}
+		return errors.Errorf("failed to get namespace, ns: %s, statuscode: %v, body: %v", ns, resp.StatusCode(), b.String())
+	}
+	namespace := &apiv1.Namespace{}
+	if err := json.NewDecoder(&b).Decode(namespace); err != nil {
+		return err
+	}
+	if namespace.Labels[LabelName] == ns {
+		return nil
+	}
+	namespace.Labels = withNameLabel(ns, namespace.Labels)
+
+	b.Reset()
+	resp, err = n.client.Put(n.addr).
+		Path("/api/v1/namespaces/" + ns).
+		JSONBody(namespace).
+		Do().
+		Body(&b)
+	if err != nil {
+		return errors.Errorf("failed to label namespace, ns: %s, (%v)", ns, err)
+	}
+	if !resp.IsOK() {
+		return errors.Errorf("failed to label namespace, ns: %s, statuscode: %v, body: %v", ns, resp.StatusCode(), b.String())
+	}
+	logrus.Infof("succeed to label namespace %s with %s", ns, LabelName)
+	return nil
+}
+
+func withNameLabel(ns string, labels map[string]string) map[string]string {
+	r := map[string]string{LabelName: ns}
+	for k, v := range labels {
+		r[k] = v
+	}
+	return r
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"context"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8serror"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/namespace"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+const (
+	// NetworkPolicyLabel turns on network isolation of the runtime, which is set in meta of dice.yml
+	NetworkPolicyLabel = "NETWORK_POLICY"
+	// NetworkPolicyAddonNamespaces is the comma separated namespaces of the addons attached to the runtime,
+	// which is set by orchestrator
+	NetworkPolicyAddonNamespaces = "NETWORK_POLICY_ADDON_NAMESPACES"
+	// anySource represents any source of traffic in the policy graph
+	anySource = "*"
+)
+
+// networkPolicyEnabled returns whether network isolation is turned on for the servicegroup
+func networkPolicyEnabled(sg *apistructs.ServiceGroup) bool {
+	v := strutil.ToLower(sg.Labels[NetworkPolicyLabel])
+	return v == "on" || v == "true"
+}
+
+// deploymentSelector selects the pods of the deployment of service
+func deploymentSelector(service *apistructs.Service) map[string]string {
+	selector := map[string]string{"app": service.Name}
+	if v, ok := service.Env[ProjectNamespace]; ok && v == "true" {
+		selector[LabelServiceGroupID] = service.Env[KeyServiceGroupID]
+	}
+	return selector
+}
+
+// serviceSelector selects the pods of the service, including the preview pods of blue-green rollout
+// which have 'app' label {name}-preview. Matching by expression keeps the pod labels of running
+// deployments unchanged, so turning on network isolation does not restart the pods.
+func serviceSelector(service *apistructs.Service) *metav1.LabelSelector {
+	apps := []string{service.Name}
+	if isProgressiveStrategy(service) && rolloutTrack(service) == rolloutTrackPreview {
+		apps = append(apps, newPreviewService(service).Name)
+	}
+	selector := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+		Key:      "app",
+		Operator: metav1.LabelSelectorOpIn,
+		Values:   apps,
+	}}}
+	if v, ok := service.Env[ProjectNamespace]; ok && v == "true" {
+		selector.MatchLabels = map[string]string{LabelServiceGroupID: service.Env[KeyServiceGroupID]}
+	}
+	return selector
+}
+
+// addonNamespaces returns the namespaces of the addons attached to the servicegroup,
+// addon split into multiple groups is deployed in the namespace with group- prefix, see MakeNamespace
+func addonNamespaces(ns string) []string {
+	return []string{ns, "group-" + ns}
+}
+
+// labelAddonNamespaces backfills the name label of the addon namespaces selected by networkpolicies,
+// namespaces created before the label is introduced do not have it
+func (k *Kubernetes) labelAddonNamespaces(sg *apistructs.ServiceGroup) error {
+	if !networkPolicyEnabled(sg) {
+		return nil
+	}
+	for _, addonNs := range strutil.Split(sg.Labels[NetworkPolicyAddonNamespaces], ",", true) {
+		for _, ns := range addonNamespaces(strutil.Trim(addonNs)) {
+			if err := k.namespace.EnsureNameLabel(ns); err != nil && !k8serror.NotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exposedPorts returns the ports of the service which can be accessed from anywhere,
+// all ports are exposed if the service has endpoint, nil if none
+func exposedPorts(service *apistructs.Service) []int {
+	ports := []int{}
+	for _, port := range service.Ports {
+		if service.Labels["IS_ENDPOINT"] == "true" || port.Expose {
+			ports = append(ports, port.Port)
+		}
+	}
+	if len(ports) == 0 {
+		return nil
+	}
+	return ports
+}
+
+// networkPolicyEdges computes the allowed traffic to the service:
+// from the services depending on it, from the addons attached to the runtime, and from anywhere to the exposed ports
+func networkPolicyEdges(service *apistructs.Service, sg *apistructs.ServiceGroup) []apistructs.NetworkPolicyEdge {
+	edges := []apistructs.NetworkPolicyEdge{}
+	for _, svc := range sg.Services {
+		for _, depend := range svc.Depends {
+			if depend == service.Name {
+				edges = append(edges, apistructs.NetworkPolicyEdge{
+					From: svc.Name,
+					To:   service.Name,
+					Kind: apistructs.NetworkPolicyEdgeDependsOn,
+				})
+				break
+			}
+		}
+	}
+	for _, ns := range strutil.Split(sg.Labels[NetworkPolicyAddonNamespaces], ",", true) {
+		edges = append(edges, apistructs.NetworkPolicyEdge{
+			From: strutil.Trim(ns),
+			To:   service.Name,
+			Kind: apistructs.NetworkPolicyEdgeAddon,
+		})
+	}
+	if ports := exposedPorts(service); ports != nil {
+		edges = append(edges, apistructs.NetworkPolicyEdge{
+			From:  anySource,
+			To:    service.Name,
+			Kind:  apistructs.NetworkPolicyEdgeExpose,
+			Ports: ports,
+		})
+	}
+	return edges
+}
+
+// newNetworkPolicy generates the ingress networkpolicy of the service, networkpolicy has the same name as the deployment.
+// Pods selected by a networkpolicy reject all the traffic which is not allowed by the policy
+func newNetworkPolicy(service *apistructs.Service, sg *apistructs.ServiceGroup) *networkingv1.NetworkPolicy {
+	depends := map[string]*apistructs.Service{}
+	for i := range sg.Services {
+		depends[sg.Services[i].Name] = &sg.Services[i]
+	}
+	rules := []networkingv1.NetworkPolicyIngressRule{}
+	for _, edge := range networkPolicyEdges(service, sg) {
+		rule := networkingv1.NetworkPolicyIngressRule{}
+		switch edge.Kind {
+		case apistructs.NetworkPolicyEdgeDependsOn:
+			rule.From = []networkingv1.NetworkPolicyPeer{{
+				PodSelector: serviceSelector(depends[edge.From]),
+			}}
+		case apistructs.NetworkPolicyEdgeAddon:
+			rule.From = []networkingv1.NetworkPolicyPeer{{
+				NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      namespace.LabelName,
+					Operator: metav1.LabelSelectorOpIn,
+					Values:   addonNamespaces(edge.From),
+				}}},
+			}}
+		case apistructs.NetworkPolicyEdgeExpose:
+			// no peer means any source, the ingress controller may run in any namespace or on host network
+			for _, port := range edge.Ports {
+				p := intstr.FromInt(port)
+				protocol := apiv1.ProtocolTCP
+				rule.Ports = append(rule.Ports, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &p})
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "NetworkPolicy",
+			APIVersion: "networking.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getDeployName(service),
+			Namespace: service.Namespace,
+			Labels:    map[string]string{"app": service.Name},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: *serviceSelector(service),
+			Ingress:     rules,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+}
+
+// updateNetworkPolicy reconciles the networkpolicy of the service: create or update it if network isolation is on,
+// otherwise delete it
+func (k *Kubernetes) updateNetworkPolicy(service *apistructs.Service, sg *apistructs.ServiceGroup) error {
+	name := getDeployName(service)
+	if !networkPolicyEnabled(sg) {
+		return k.deleteNetworkPolicy(service.Namespace, name)
+	}
+	desired := newNetworkPolicy(service, sg)
+	old, err := k.netPolicy.Get(service.Namespace, name)
+	if err != nil {
+		if !k8serror.NotFound(err) {
+			return err
+		}
+		return k.netPolicy.Create(desired)
+	}
+	desired.ResourceVersion = old.ResourceVersion
+	return k.netPolicy.Put(desired)
+}
+
+// deleteNetworkPolicy deletes the networkpolicy, not found is ignored
+func (k *Kubernetes) deleteNetworkPolicy(namespace, name string) error {
+	if err := k.netPolicy.Delete(namespace, name); err != nil && !k8serror.NotFound(err) {
+		logrus.Errorf("failed to delete networkpolicy %s/%s: %v", namespace, name, err)
+		return err
+	}
+	return nil
+}
+
+// NetworkPolicy computes the policy graph of the servicegroup without applying it
+func (k *Kubernetes) NetworkPolicy(ctx context.Context, sg *apistructs.ServiceGroup) (apistructs.NetworkPolicyGraph, error) {
+	ns := MakeNamespace(sg)
+	if !IsGroupStateful(sg) && sg.ProjectNamespace != "" {
+		ns = sg.ProjectNamespace
+		k.setProjectNamespaceEnvs(sg)
+	}
+	graph := apistructs.NetworkPolicyGraph{
+		Enabled:   networkPolicyEnabled(sg),
+		Namespace: ns,
+		Edges:     []apistructs.NetworkPolicyEdge{},
+		Policies:  []networkingv1.NetworkPolicy{},
+	}
+	services := make([]apistructs.Service, len(sg.Services))
+	copy(services, sg.Services)
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	for i := range services {
+		services[i].Namespace = ns
+		graph.Edges = append(graph.Edges, networkPolicyEdges(&services[i], sg)...)
+		graph.Policies = append(graph.Policies, *newNetworkPolicy(&services[i], sg))
+	}
+	return graph, nil
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package networkpolicy manipulates the k8s api of networkpolicy object
+package networkpolicy
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	networkingv1 "k8s.io/api/networking/v1"
+
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8sapi"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8serror"
+	"github.com/erda-project/erda/pkg/httpclient"
+)
+
+// NetworkPolicy is the object to manipulate k8s api of networkpolicy
+type NetworkPolicy struct {
+	addr   string
+	client *httpclient.HTTPClient
+}
+
+// Option configures a NetworkPolicy
+type Option func(*NetworkPolicy)
+
+// New news a NetworkPolicy
+func New(options ...Option) *NetworkPolicy {
+	n := &NetworkPolicy{}
+
+	for _, op := range options {
+		op(n)
+	}
+
+	return n
+}
+
+// WithCompleteParams provides an Option
+func WithCompleteParams(addr string, client *httpclient.HTTPClient) Option {
+	return func(n *NetworkPolicy) {
+		n.addr = addr
+		n.client = client
+	}
+}
+
+// Create creates a k8s networkpolicy object
+func (n *NetworkPolicy) Create(np *networkingv1.NetworkPolicy) error {
+	var b bytes.Buffer
+	resp, err := n.client.Post(n.addr).
+		Path("/apis/networking.k8s.io/v1/namespaces/" + np.Namespace + "/networkpolicies").
+		JSONBody(np).
+		Do().
+		Body(&b)
+
+	if err != nil {
+		return errors.Errorf("failed to create networkpolicy, name: %s, (%v)", np.Name, err)
+	}
+
+	if !resp.IsOK() {
+		return errors.Errorf("failed to create networkpolicy, name: %s, statuscode: %v, body: %v",
+			np.Name, resp.StatusCode(), b.String())
+	}
+	return nil
+}
+
+// Get gets a k8s networkpolicy object
+func (n *NetworkPolicy) Get(namespace, name string) (*networkingv1.NetworkPolicy, error) {
+	var b bytes.Buffer
+	resp, err := n.client.Get(n.addr).
+		Path("/apis/networking.k8s.io/v1/namespaces/" + namespace + "/networkpolicies/" + name).
+		Do().
+		Body(&b)
+
+	if err != nil {
+		return nil, errors.Errorf("failed to get networkpolicy, name: %s, (%v)", name, err)
+	}
+
+	if !resp.IsOK() {
+		if resp.IsNotfound() {
+			return nil, k8serror.ErrNotFound
+		}
+		return nil, errors.Errorf("failed to get networkpolicy, name: %s, statuscode: %v, body: %v",
+			name, resp.StatusCode(), b.String())
+	}
+
+	np := &networkingv1.NetworkPolicy{}
+	if err := json.NewDecoder(&b).Decode(np); err != nil {
+		return nil, err
+	}
+	return np, nil
+}
+
+// Put updates a k8s networkpolicy object
+func (n *NetworkPolicy) Put(np *networkingv1.NetworkPolicy) error {
+	var b bytes.Buffer
+	resp, err := n.client.Put(n.addr).
+		Path("/apis/networking.k8s.io/v1/namespaces/" + np.Namespace + "/networkpolicies/" + np.Name).
+		JSONBody(np).
+		Do().
+		Body(&b)
+
+	if err != nil {
+		return errors.Errorf("failed to put networkpolicy, name: %s, (%v)", np.Name, err)
+	}
+
+	if !resp.IsOK() {
+		return errors.Errorf("failed to put networkpolicy, name: %s, statuscode: %v, body: %v",
+			np.Name, resp.StatusCode(), b.String())
+	}
+	return nil
+}
+
+// Delete deletes a k8s networkpolicy object
+func (n *NetworkPolicy) Delete(namespace, name string) error {
+	var b bytes.Buffer
+	resp, err := n.client.Delete(n.addr).
+		Path("/apis/networking.k8s.io/v1/namespaces/" + namespace + "/networkpolicies/" + name).
+		JSONBody(k8sapi.DeleteOptions).
+		Do().
+		Body(&b)
+
+	if err != nil {
+		return errors.Errorf("failed to delete networkpolicy, name: %s, (%v)", name, err)
+	}
+
+	if !resp.IsOK() {
+		if resp.IsNotfound() {
+			return k8serror.ErrNotFound
+		}
+		return errors.Errorf("failed to delete networkpolicy, name: %s, statuscode: %v, body: %v",
+			name, resp.StatusCode(), b.String())
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/namespace"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func TestNetworkPolicy(t *testing.T) {
+	sg := &apistructs.ServiceGroup{
+		Dice: apistructs.Dice{
+			ID:   "abc",
+			Type: "services",
+			Labels: map[string]string{
+				NetworkPolicyLabel:           "on",
+				NetworkPolicyAddonNamespaces: "addon-mysql--123",
+			},
+			Services: []apistructs.Service{
+				{
+					Name:    "web",
+					Depends: []string{"api"},
+					Ports:   []diceyml.ServicePort{{Port: 80, Expose: true}},
+				},
+				{
+					Name:  "api",
+					Ports: []diceyml.ServicePort{{Port: 8080}},
+				},
+			},
+		},
+	}
+	k := &Kubernetes{}
+	graph, err := k.NetworkPolicy(context.Background(), sg)
+	assert.Nil(t, err)
+	assert.True(t, graph.Enabled)
+	assert.Equal(t, "services--abc", graph.Namespace)
+	assert.Equal(t, []apistructs.NetworkPolicyEdge{
+		{From: "web", To: "api", Kind: apistructs.NetworkPolicyEdgeDependsOn},
+		{From: "addon-mysql--123", To: "api", Kind: apistructs.NetworkPolicyEdgeAddon},
+		{From: "addon-mysql--123", To: "web", Kind: apistructs.NetworkPolicyEdgeAddon},
+		{From: "*", To: "web", Kind: apistructs.NetworkPolicyEdgeExpose, Ports: []int{80}},
+	}, graph.Edges)
+
+	assert.Equal(t, 2, len(graph.Policies))
+	api := graph.Policies[0]
+	assert.Equal(t, "api", api.Name)
+	assert.Equal(t, "services--abc", api.Namespace)
+	assert.Equal(t, []string{"api"}, api.Spec.PodSelector.MatchExpressions[0].Values)
+	assert.Equal(t, 2, len(api.Spec.Ingress))
+	assert.Equal(t, []string{"web"}, api.Spec.Ingress[0].From[0].PodSelector.MatchExpressions[0].Values)
+	assert.Equal(t, []string{"addon-mysql--123", "group-addon-mysql--123"},
+		api.Spec.Ingress[1].From[0].NamespaceSelector.MatchExpressions[0].Values)
+
+	web := graph.Policies[1]
+	assert.Equal(t, 2, len(web.Spec.Ingress))
+	assert.Nil(t, web.Spec.Ingress[1].From)
+	assert.Equal(t, 80, web.Spec.Ingress[1].Ports[0].Port.IntValue())
+
+	// preview pods of blue-green rollout are selected as well
+	sg.Services[0].Strategy = &diceyml.Strategy{Type: diceyml.StrategyBlueGreen}
+	graph, err = k.NetworkPolicy(context.Background(), sg)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"web", "web-preview"}, graph.Policies[0].Spec.Ingress[0].From[0].PodSelector.MatchExpressions[0].Values)
+	assert.Equal(t, []string{"web", "web-preview"}, graph.Policies[1].Spec.PodSelector.MatchExpressions[0].Values)
+
+	delete(sg.Labels, NetworkPolicyLabel)
+	graph, err = k.NetworkPolicy(context.Background(), sg)
+	assert.Nil(t, err)
+	assert.False(t, graph.Enabled)
+}
+
+func TestLabelAddonNamespaces(t *testing.T) {
+	k, fake := newFakeKubernetes(t)
+	fake.putNamespace(&apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "addon-mysql--123",
+		Labels: map[string]string{"owner": "dice"},
+	}})
+	sg := &apistructs.ServiceGroup{Dice: apistructs.Dice{Labels: map[string]string{
+		NetworkPolicyAddonNamespaces: "addon-mysql--123",
+	}}}
+
+	// nothing to do if network isolation is off
+	assert.Nil(t, k.labelAddonNamespaces(sg))
+	assert.NotContains(t, fake.getNamespace("addon-mysql--123").Labels, namespace.LabelName)
+
+	// namespace created before is labeled, the missing group- namespace is ignored
+	sg.Labels[NetworkPolicyLabel] = "on"
+	assert.Nil(t, k.labelAddonNamespaces(sg))
+	assert.Equal(t, map[string]string{"owner": "dice", namespace.LabelName: "addon-mysql--123"},
+		fake.getNamespace("addon-mysql--123").Labels)
+	assert.Nil(t, fake.getNamespace("group-addon-mysql--123"))
+}
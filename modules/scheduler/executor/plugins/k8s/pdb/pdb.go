@@ -0,0 +1,145 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package pdb manipulates the k8s api of poddisruptionbudget object
+package pdb
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8sapi"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8serror"
+	"github.com/erda-project/erda/pkg/httpclient"
+)
+
+// PDB is the object to manipulate k8s api of poddisruptionbudget
+type PDB struct {
+	addr   string
+	client *httpclient.HTTPClient
+}
+
+// Option configures a PDB
+type Option func(*PDB)
+
+// New news a PDB
+func New(options ...Option) *PDB {
+	p := &PDB{}
+
+	for _, op := range options {
+		op(p)
+	}
+
+	return p
+}
+
+// WithCompleteParams provides an Option
+func WithCompleteParams(addr string, client *httpclient.HTTPClient) Option {
+	return func(p *PDB) {
+		p.addr = addr
+		p.client = client
+	}
+}
+
+// Create creates a k8s poddisruptionbudget object
+func (p *PDB) Create(pdb *policyv1beta1.PodDisruptionBudget) error {
+	var b bytes.Buffer
+	resp, err := p.client.Post(p.addr).
+		Path("/apis/policy/v1beta1/namespaces/" + pdb.Namespace + "/poddisruptionbudgets").
+		JSONBody(pdb).
+		Do().
+		Body(&b)
+
+	if err != nil {
+		return errors.Errorf("failed to create pdb, name: %s, (%v)", pdb.Name, err)
+	}
+
+	if !resp.IsOK() {
+		return errors.Errorf("failed to create pdb, name: %s, statuscode: %v, body: %v",
+			pdb.Name, resp.StatusCode(), b.String())
+	}
+	return nil
+}
+
+// Get gets a k8s poddisruptionbudget object
+func (p *PDB) Get(namespace, name string) (*policyv1beta1.PodDisruptionBudget, error) {
+	var b bytes.Buffer
+	resp, err := p.client.Get(p.addr).
+		Path("/apis/policy/v1beta1/namespaces/" + namespace + "/poddisruptionbudgets/" + name).
+		Do().
+		Body(&b)
+
+	if err != nil {
+		return nil, errors.Errorf("failed to get pdb, name: %s, (%v)", name, err)
+	}
+
+	if !resp.IsOK() {
+		if resp.IsNotfound() {
+			return nil, k8serror.ErrNotFound
+		}
+		return nil, errors.Errorf("failed to get pdb, name: %s, statuscode: %v, body: %v",
+			name, resp.StatusCode(), b.String())
+	}
+
+	pdb := &policyv1beta1.PodDisruptionBudget{}
+	if err := json.NewDecoder(&b).Decode(pdb); err != nil {
+		return nil, err
+	}
+	return pdb, nil
+}
+
+// Put updates a k8s poddisruptionbudget object
+func (p *PDB) Put(pdb *policyv1beta1.PodDisruptionBudget) error {
+	var b bytes.Buffer
+	resp, err := p.client.Put(p.addr).
+		Path("/apis/policy/v1beta1/namespaces/" + pdb.Namespace + "/poddisruptionbudgets/" + pdb.Name).
+		JSONBody(pdb).
+		Do().
+		Body(&b)
+
+	if err != nil {
+		return errors.Errorf("failed to put pdb, name: %s, (%v)", pdb.Name, err)
+	}
+
+	if !resp.IsOK() {
+		return errors.Errorf("failed to put pdb, name: %s, statuscode: %v, body: %v",
+			pdb.Name, resp.StatusCode(), b.String())
+	}
+	return nil
+}
+
+// Delete deletes a k8s poddisruptionbudget object
+func (p *PDB) Delete(namespace, name string) error {
+	var b bytes.Buffer
+	resp, err := p.client.Delete(p.addr).
+		Path("/apis/policy/v1beta1/namespaces/" + namespace + "/poddisruptionbudgets/" + name).
+		JSONBody(k8sapi.DeleteOptions).
+		Do().
+		Body(&b)
+
+	if err != nil {
+		return errors.Errorf("failed to delete pdb, name: %s, (%v)", name, err)
+	}
+
+	if !resp.IsOK() {
+		if resp.IsNotfound() {
+			return k8serror.ErrNotFound
+		}
+		return errors.Errorf("failed to delete pdb, name: %s, statuscode: %v, body: %v",
+			name, resp.StatusCode(), b.String())
+	}
+	return nil
+}
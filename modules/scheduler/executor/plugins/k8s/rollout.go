@@ -0,0 +1,539 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/plugins/k8s/k8serror"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+// Canary and blue-green rollout are implemented by a parallel deployment holding the new version.
+// The canary deployment {name}-canary has pods with the same 'app' label as the stable version,
+// so the k8s service balances traffic between them by the ratio of replicas.
+// The blue-green deployment {name}-preview has 'app' label {name}-preview and a preview k8s service,
+// promoting switches the selector of k8s service to the preview pods, then updates the stable
+// deployment to the new version and switches back once it is ready.
+// Rollout state is stored in the annotations of the parallel deployment.
+const (
+	// LabelRolloutTrack marks the deployment and pods of the new version during rollout
+	LabelRolloutTrack = "rollout-track"
+	// LabelRolloutService the name of stable deployment which the rollout deployment belongs to
+	LabelRolloutService = "rollout-service"
+
+	rolloutTrackCanary  = "canary"
+	rolloutTrackPreview = "preview"
+
+	annotationRolloutPhase         = "rollout-phase"
+	annotationRolloutStep          = "rollout-step"
+	annotationRolloutStepStartedAt = "rollout-step-started-at"
+	annotationTemplateHash         = "rollout-template-hash"
+)
+
+func isProgressiveStrategy(service *apistructs.Service) bool {
+	if service.Strategy == nil || service.WorkLoad == ServicePerNode {
+		return false
+	}
+	switch service.Strategy.Type {
+	case diceyml.StrategyCanary:
+		// canary_steps is validated when servicegroup created, check again for servicegroups stored before
+		return len(service.Strategy.CanarySteps) > 0
+	case diceyml.StrategyBlueGreen:
+		// k8s service of project namespace is shared by servicegroups, could not switch its selector
+		return service.Env[ProjectNamespace] != "true"
+	}
+	return false
+}
+
+func rolloutTrack(service *apistructs.Service) string {
+	if service.Strategy.Type == diceyml.StrategyBlueGreen {
+		return rolloutTrackPreview
+	}
+	return rolloutTrackCanary
+}
+
+func rolloutDeployName(service *apistructs.Service) string {
+	return getDeployName(service) + "-" + rolloutTrack(service)
+}
+
+// setRollingUpdateStrategy sets maxSurge and maxUnavailable declared in dice.yml
+func setRollingUpdateStrategy(service *apistructs.Service, deployment *appsv1.Deployment) {
+	if service.Strategy == nil {
+		return
+	}
+	rollingUpdate := &appsv1.RollingUpdateDeployment{}
+	if service.Strategy.MaxSurge != "" {
+		v := intstr.Parse(service.Strategy.MaxSurge)
+		rollingUpdate.MaxSurge = &v
+	}
+	if service.Strategy.MaxUnavailable != "" {
+		v := intstr.Parse(service.Strategy.MaxUnavailable)
+		rollingUpdate.MaxUnavailable = &v
+	}
+	deployment.Spec.Strategy = appsv1.DeploymentStrategy{
+		Type:          appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: rollingUpdate,
+	}
+}
+
+// templateHash returns the hash of pod template, used to find out whether the version of service changed.
+// Containers and envs are generated from maps, so sort them before hashing.
+func templateHash(template apiv1.PodTemplateSpec) (string, error) {
+	t := template.DeepCopy()
+	for _, containers := range [][]apiv1.Container{t.Spec.Containers, t.Spec.InitContainers} {
+		sort.SliceStable(containers, func(i, j int) bool { return containers[i].Name < containers[j].Name })
+		for i := range containers {
+			env := containers[i].Env
+			sort.SliceStable(env, func(i, j int) bool { return env[i].Name < env[j].Name })
+		}
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return "", errors.Errorf("failed to marshal pod template, (%v)", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:16], nil
+}
+
+func setTemplateHash(deployment *appsv1.Deployment) error {
+	hash, err := templateHash(deployment.Spec.Template)
+	if err != nil {
+		return err
+	}
+	if deployment.Annotations == nil {
+		deployment.Annotations = make(map[string]string)
+	}
+	deployment.Annotations[annotationTemplateHash] = hash
+	return nil
+}
+
+// rolloutDeployment decides how to update the deployment of service.
+// It returns true if the new version is rolled out by a parallel deployment, then the stable deployment should not be updated.
+func (k *Kubernetes) rolloutDeployment(service *apistructs.Service, desired *appsv1.Deployment) (bool, error) {
+	if !isProgressiveStrategy(service) {
+		// strategy may be changed during rollout
+		return false, k.deleteRollout(service.Namespace, getDeployName(service))
+	}
+	if err := setTemplateHash(desired); err != nil {
+		return false, err
+	}
+	stable, err := k.getDeployment(service.Namespace, desired.Name)
+	if err != nil {
+		if k8serror.NotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	stableHash, ok := stable.Annotations[annotationTemplateHash]
+	if !ok || stableHash == desired.Annotations[annotationTemplateHash] {
+		// deployed before strategy declared, or version not changed (e.g. redeploy the stable version during rollout)
+		return false, k.deleteRollout(service.Namespace, desired.Name)
+	}
+
+	rollout := newRolloutDeployment(service, desired)
+	old, err := k.getDeployment(service.Namespace, rollout.Name)
+	if err != nil && !k8serror.NotFound(err) {
+		return false, err
+	}
+	if err == nil && old.Annotations[annotationTemplateHash] == rollout.Annotations[annotationTemplateHash] {
+		// the same version is rolling out, keep going on
+		return true, nil
+	}
+	if err == nil {
+		// a newer version arrived during rollout, restart from the first step
+		if err := k.deleteDeployment(service.Namespace, rollout.Name); err != nil && !k8serror.NotFound(err) {
+			return false, err
+		}
+	}
+	if err := k.deploy.Create(rollout); err != nil {
+		return false, err
+	}
+	if rolloutTrack(service) == rolloutTrackPreview {
+		if len(service.Ports) > 0 {
+			if err := k.updateService(newPreviewService(service)); err != nil {
+				return false, err
+			}
+		}
+	} else if err := k.scaleDeployment(service.Namespace, desired.Name, int32(stableReplicas(service.Scale, service.Strategy.CanarySteps[0].Weight))); err != nil {
+		return false, err
+	}
+	logrus.Infof("start %s rollout of service %s/%s", service.Strategy.Type, service.Namespace, desired.Name)
+	return true, nil
+}
+
+func newRolloutDeployment(service *apistructs.Service, desired *appsv1.Deployment) *appsv1.Deployment {
+	rollout := desired.DeepCopy()
+	track := rolloutTrack(service)
+	rollout.Name = rolloutDeployName(service)
+	rollout.Spec.Template.Name = rollout.Name
+	rollout.Labels[LabelRolloutTrack] = track
+	rollout.Labels[LabelRolloutService] = desired.Name
+	rollout.Spec.Template.Labels[LabelRolloutTrack] = track
+	rollout.Spec.Selector.MatchLabels[LabelRolloutTrack] = track
+	if track == rolloutTrackPreview {
+		// preview pods must not be selected by the k8s service of stable version
+		rollout.Labels["app"] = rollout.Name
+		rollout.Spec.Template.Labels["app"] = rollout.Name
+		rollout.Spec.Selector.MatchLabels["app"] = rollout.Name
+	}
+
+	phase := apistructs.RolloutPhasePaused
+	replicas := service.Scale
+	if track == rolloutTrackCanary {
+		replicas = canaryReplicas(service.Scale, service.Strategy.CanarySteps[0].Weight)
+		if service.Strategy.CanarySteps[0].Pause > 0 {
+			phase = apistructs.RolloutPhaseProgressing
+		}
+	} else if service.Strategy.AutoPromote {
+		phase = apistructs.RolloutPhaseProgressing
+	}
+	rollout.Spec.Replicas = func(i int32) *int32 { return &i }(int32(replicas))
+	rollout.Annotations[annotationRolloutPhase] = string(phase)
+	rollout.Annotations[annotationRolloutStep] = "0"
+	rollout.Annotations[annotationRolloutStepStartedAt] = strconv.FormatInt(time.Now().Unix(), 10)
+	return rollout
+}
+
+func newPreviewService(service *apistructs.Service) *apistructs.Service {
+	preview := *service
+	preview.Name = service.Name + "-" + rolloutTrackPreview
+	return &preview
+}
+
+// canaryReplicas returns replicas of canary version serving weight percent of traffic, at least 1
+func canaryReplicas(scale, weight int) int {
+	replicas := (scale*weight + 50) / 100
+	if replicas < 1 {
+		replicas = 1
+	}
+	return replicas
+}
+
+// stableReplicas returns replicas of stable version when canary serving weight percent of traffic, at least 1
+func stableReplicas(scale, weight int) int {
+	replicas := scale - canaryReplicas(scale, weight)
+	if replicas < 1 {
+		replicas = 1
+	}
+	return replicas
+}
+
+func (k *Kubernetes) scaleDeployment(namespace, name string, replicas int32) error {
+	deployment, err := k.getDeployment(namespace, name)
+	if err != nil {
+		return err
+	}
+	deployment.Spec.Replicas = &replicas
+	return k.putDeployment(deployment)
+}
+
+// deleteRollout deletes the rollout deployments and preview k8s service of the stable deployment
+func (k *Kubernetes) deleteRollout(namespace, deployName string) error {
+	deploys, err := k.deploy.List(namespace, map[string]string{LabelRolloutService: deployName})
+	if err != nil {
+		if k8serror.NotFound(err) {
+			return nil
+		}
+		return err
+	}
+	for _, deploy := range deploys.Items {
+		if deploy.Labels[LabelRolloutTrack] == rolloutTrackPreview {
+			if err := k.switchServiceSelector(namespace, deployName, deployName); err != nil {
+				return err
+			}
+			// preview service is not created if service has no ports
+			if err := k.service.Delete(namespace, deploy.Name); err != nil && !k8serror.NotFound(err) {
+				return err
+			}
+		}
+		if err := k.deleteDeployment(namespace, deploy.Name); err != nil && !k8serror.NotFound(err) {
+			return err
+		}
+		logrus.Infof("deleted rollout deployment %s/%s", namespace, deploy.Name)
+	}
+	return nil
+}
+
+// switchServiceSelector switches the k8s service to the pods with 'app' label
+func (k *Kubernetes) switchServiceSelector(namespace, name, app string) error {
+	svc, err := k.GetService(namespace, name)
+	if err != nil {
+		if k8serror.NotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if svc.Spec.Selector["app"] == app {
+		return nil
+	}
+	svc.Spec.Selector["app"] = app
+	return k.PutService(svc)
+}
+
+func isDeploymentReady(deployment *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	return status.ObservedGeneration >= deployment.Generation &&
+		status.UpdatedReplicas == replicas &&
+		status.ReadyReplicas == replicas &&
+		status.AvailableReplicas == replicas
+}
+
+// Rollout promotes, pauses or aborts the rollout of service
+func (k *Kubernetes) Rollout(ctx context.Context, sg *apistructs.ServiceGroup, serviceName string, action apistructs.RolloutAction) (
+	apistructs.RolloutStatus, error) {
+	runtime, err := ValidateRuntime(*sg, "Rollout")
+	if err != nil {
+		return apistructs.RolloutStatus{}, err
+	}
+	if runtime.ProjectNamespace != "" {
+		k.setProjectNamespaceEnvs(runtime)
+	}
+	var service *apistructs.Service
+	for i := range runtime.Services {
+		if runtime.Services[i].Name == serviceName {
+			service = &runtime.Services[i]
+			break
+		}
+	}
+	if service == nil || !isProgressiveStrategy(service) {
+		return apistructs.RolloutStatus{}, errors.Errorf("service %s is not deployed by canary or blue_green strategy", serviceName)
+	}
+	rollout, err := k.getDeployment(service.Namespace, rolloutDeployName(service))
+	if err != nil {
+		if k8serror.NotFound(err) {
+			return apistructs.RolloutStatus{}, errors.Errorf("no rollout in progress of service %s", serviceName)
+		}
+		return apistructs.RolloutStatus{}, err
+	}
+
+	switch action {
+	case apistructs.RolloutActionPromote:
+		return k.promoteRollout(service, rollout)
+	case apistructs.RolloutActionPause:
+		if rollout.Annotations[annotationRolloutPhase] == string(apistructs.RolloutPhasePromoting) {
+			return apistructs.RolloutStatus{}, errors.Errorf("rollout of service %s is promoting, could not be paused", serviceName)
+		}
+		rollout.Annotations[annotationRolloutPhase] = string(apistructs.RolloutPhasePaused)
+		if err := k.putDeployment(rollout); err != nil {
+			return apistructs.RolloutStatus{}, err
+		}
+		return k.rolloutStatus(service, rollout), nil
+	case apistructs.RolloutActionAbort:
+		if rollout.Annotations[annotationRolloutPhase] == string(apistructs.RolloutPhasePromoting) {
+			return apistructs.RolloutStatus{}, errors.Errorf("rollout of service %s is promoting, could not be aborted", serviceName)
+		}
+		if err := k.deleteRollout(service.Namespace, getDeployName(service)); err != nil {
+			return apistructs.RolloutStatus{}, err
+		}
+		if rolloutTrack(service) == rolloutTrackCanary {
+			if err := k.scaleDeployment(service.Namespace, getDeployName(service), int32(service.Scale)); err != nil {
+				return apistructs.RolloutStatus{}, err
+			}
+		}
+		logrus.Infof("aborted rollout of service %s/%s", service.Namespace, getDeployName(service))
+		return apistructs.RolloutStatus{Strategy: service.Strategy.Type, Phase: apistructs.RolloutPhaseAborted}, nil
+	}
+	return apistructs.RolloutStatus{}, errors.Errorf("invalid rollout action: %s", action)
+}
+
+func (k *Kubernetes) promoteRollout(service *apistructs.Service, rollout *appsv1.Deployment) (apistructs.RolloutStatus, error) {
+	if rollout.Annotations[annotationRolloutPhase] == string(apistructs.RolloutPhasePromoting) {
+		return k.rolloutStatus(service, rollout), nil
+	}
+	if !isDeploymentReady(rollout) {
+		return apistructs.RolloutStatus{}, errors.Errorf("new version of service %s is not ready", service.Name)
+	}
+	if rolloutTrack(service) == rolloutTrackPreview {
+		return k.promoteBlueGreen(service, rollout)
+	}
+
+	step, _ := strconv.Atoi(rollout.Annotations[annotationRolloutStep])
+	steps := service.Strategy.CanarySteps
+	if step+1 >= len(steps) {
+		// the last step, promote canary to stable
+		if err := k.promoteStable(service, rollout); err != nil {
+			return apistructs.RolloutStatus{}, err
+		}
+		if err := k.deleteDeployment(service.Namespace, rollout.Name); err != nil && !k8serror.NotFound(err) {
+			return apistructs.RolloutStatus{}, err
+		}
+		logrus.Infof("promoted canary of service %s/%s", service.Namespace, getDeployName(service))
+		return apistructs.RolloutStatus{Strategy: service.Strategy.Type, Phase: apistructs.RolloutPhaseCompleted,
+			Step: len(steps), TotalSteps: len(steps), Weight: 100}, nil
+	}
+
+	step++
+	phase := apistructs.RolloutPhasePaused
+	if steps[step].Pause > 0 {
+		phase = apistructs.RolloutPhaseProgressing
+	}
+	rollout.Spec.Replicas = func(i int32) *int32 { return &i }(int32(canaryReplicas(service.Scale, steps[step].Weight)))
+	rollout.Annotations[annotationRolloutStep] = strconv.Itoa(step)
+	rollout.Annotations[annotationRolloutPhase] = string(phase)
+	rollout.Annotations[annotationRolloutStepStartedAt] = strconv.FormatInt(time.Now().Unix(), 10)
+	if err := k.putDeployment(rollout); err != nil {
+		return apistructs.RolloutStatus{}, err
+	}
+	if err := k.scaleDeployment(service.Namespace, getDeployName(service), int32(stableReplicas(service.Scale, steps[step].Weight))); err != nil {
+		return apistructs.RolloutStatus{}, err
+	}
+	return k.rolloutStatus(service, rollout), nil
+}
+
+func (k *Kubernetes) promoteBlueGreen(service *apistructs.Service, rollout *appsv1.Deployment) (apistructs.RolloutStatus, error) {
+	deployName := getDeployName(service)
+	// switch traffic to the new version, then update the stable deployment in background
+	if err := k.switchServiceSelector(service.Namespace, service.Name, rollout.Name); err != nil {
+		return apistructs.RolloutStatus{}, err
+	}
+	if err := k.promoteStable(service, rollout); err != nil {
+		return apistructs.RolloutStatus{}, err
+	}
+	rollout.Annotations[annotationRolloutPhase] = string(apistructs.RolloutPhasePromoting)
+	if err := k.putDeployment(rollout); err != nil {
+		return apistructs.RolloutStatus{}, err
+	}
+	logrus.Infof("switched traffic of service %s/%s to preview version", service.Namespace, deployName)
+	return k.rolloutStatus(service, rollout), nil
+}
+
+// promoteStable updates the stable deployment to the pod template of the rollout deployment
+func (k *Kubernetes) promoteStable(service *apistructs.Service, rollout *appsv1.Deployment) error {
+	deployName := getDeployName(service)
+	stable, err := k.getDeployment(service.Namespace, deployName)
+	if err != nil {
+		return err
+	}
+	template := rollout.Spec.Template.DeepCopy()
+	template.Name = deployName
+	template.Labels["app"] = stable.Spec.Template.Labels["app"]
+	delete(template.Labels, LabelRolloutTrack)
+	stable.Spec.Template = *template
+	stable.Spec.Replicas = func(i int32) *int32 { return &i }(int32(service.Scale))
+	if stable.Annotations == nil {
+		stable.Annotations = make(map[string]string)
+	}
+	stable.Annotations[annotationTemplateHash] = rollout.Annotations[annotationTemplateHash]
+	return k.putDeployment(stable)
+}
+
+// reconcileRollout pushes forward the rollout of service and returns its status, nil if no rollout in progress.
+// It is driven by the status polling of servicegroup.
+func (k *Kubernetes) reconcileRollout(service *apistructs.Service) (*apistructs.RolloutStatus, error) {
+	rollout, err := k.getDeployment(service.Namespace, rolloutDeployName(service))
+	if err != nil {
+		if k8serror.NotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	phase := apistructs.RolloutPhase(rollout.Annotations[annotationRolloutPhase])
+	switch {
+	case phase == apistructs.RolloutPhasePromoting:
+		// blue_green: switch back to the stable deployment once it is updated
+		stable, err := k.getDeployment(service.Namespace, getDeployName(service))
+		if err != nil {
+			return nil, err
+		}
+		if !isDeploymentReady(stable) {
+			break
+		}
+		if err := k.deleteRollout(service.Namespace, stable.Name); err != nil {
+			return nil, err
+		}
+		logrus.Infof("blue_green rollout of service %s/%s completed", service.Namespace, stable.Name)
+		return nil, nil
+	case phase == apistructs.RolloutPhaseProgressing && isDeploymentReady(rollout):
+		if rolloutTrack(service) == rolloutTrackPreview {
+			// auto_promote
+			status, err := k.promoteRollout(service, rollout)
+			return &status, err
+		}
+		if nextStepAt := k.nextStepAt(service, rollout); nextStepAt != nil && !time.Now().Before(*nextStepAt) {
+			status, err := k.promoteRollout(service, rollout)
+			if err != nil || status.Phase == apistructs.RolloutPhaseCompleted {
+				return nil, err
+			}
+			return &status, nil
+		}
+	}
+	status := k.rolloutStatus(service, rollout)
+	return &status, nil
+}
+
+// nextStepAt returns when the canary goes to next step automatically, nil if waiting for promoting manually
+func (k *Kubernetes) nextStepAt(service *apistructs.Service, rollout *appsv1.Deployment) *time.Time {
+	if rollout.Annotations[annotationRolloutPhase] != string(apistructs.RolloutPhaseProgressing) ||
+		rolloutTrack(service) != rolloutTrackCanary {
+		return nil
+	}
+	step, _ := strconv.Atoi(rollout.Annotations[annotationRolloutStep])
+	if step >= len(service.Strategy.CanarySteps) || service.Strategy.CanarySteps[step].Pause <= 0 {
+		return nil
+	}
+	startedAt, err := strconv.ParseInt(rollout.Annotations[annotationRolloutStepStartedAt], 10, 64)
+	if err != nil {
+		return nil
+	}
+	t := time.Unix(startedAt, 0).Add(time.Duration(service.Strategy.CanarySteps[step].Pause) * time.Second)
+	return &t
+}
+
+func (k *Kubernetes) rolloutStatus(service *apistructs.Service, rollout *appsv1.Deployment) apistructs.RolloutStatus {
+	step, _ := strconv.Atoi(rollout.Annotations[annotationRolloutStep])
+	status := apistructs.RolloutStatus{
+		Strategy:        service.Strategy.Type,
+		Phase:           apistructs.RolloutPhase(rollout.Annotations[annotationRolloutPhase]),
+		Step:            step,
+		TotalSteps:      len(service.Strategy.CanarySteps),
+		RolloutReplicas: int(rollout.Status.ReadyReplicas),
+		NextStepAt:      k.nextStepAt(service, rollout),
+	}
+	stable, err := k.getDeployment(service.Namespace, getDeployName(service))
+	if err != nil {
+		logrus.Errorf("failed to get stable deployment of rollout %s/%s, (%v)", service.Namespace, rollout.Name, err)
+	} else {
+		status.StableReplicas = int(stable.Status.ReadyReplicas)
+	}
+	switch {
+	case rolloutTrack(service) == rolloutTrackCanary && status.StableReplicas+status.RolloutReplicas > 0:
+		status.Weight = status.RolloutReplicas * 100 / (status.StableReplicas + status.RolloutReplicas)
+	case status.Phase == apistructs.RolloutPhasePromoting:
+		status.Weight = 100
+	}
+	if !isDeploymentReady(rollout) {
+		status.Message = fmt.Sprintf("new version is not ready, %d/%d replicas ready",
+			rollout.Status.ReadyReplicas, *rollout.Spec.Replicas)
+	}
+	return status
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func TestCanaryReplicas(t *testing.T) {
+	assert.Equal(t, 1, canaryReplicas(4, 10))
+	assert.Equal(t, 3, stableReplicas(4, 10))
+	assert.Equal(t, 2, canaryReplicas(4, 50))
+	assert.Equal(t, 2, stableReplicas(4, 50))
+	assert.Equal(t, 1, canaryReplicas(1, 50))
+	assert.Equal(t, 1, stableReplicas(1, 90))
+}
+
+func TestTemplateHash(t *testing.T) {
+	t1 := apiv1.PodTemplateSpec{Spec: apiv1.PodSpec{Containers: []apiv1.Container{{
+		Name: "web",
+		Env:  []apiv1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}},
+	}}}}
+	t2 := *t1.DeepCopy()
+	t2.Spec.Containers[0].Env = []apiv1.EnvVar{{Name: "B", Value: "2"}, {Name: "A", Value: "1"}}
+	h1, err := templateHash(t1)
+	assert.NoError(t, err)
+	h2, err := templateHash(t2)
+	assert.NoError(t, err)
+	assert.Equal(t, h1, h2)
+	assert.Equal(t, "A", t2.Spec.Containers[0].Env[1].Name)
+
+	t2.Spec.Containers[0].Image = "web:v2"
+	h2, err = templateHash(t2)
+	assert.NoError(t, err)
+	assert.NotEqual(t, h1, h2)
+}
+
+func TestIsProgressiveStrategy(t *testing.T) {
+	service := &apistructs.Service{Strategy: &diceyml.Strategy{Type: diceyml.StrategyCanary}}
+	// canary without steps could not be rolled out, fallback to rolling update
+	assert.False(t, isProgressiveStrategy(service))
+	service.Strategy.CanarySteps = []diceyml.CanaryStep{{Weight: 50}}
+	assert.True(t, isProgressiveStrategy(service))
+	service.Strategy = &diceyml.Strategy{Type: diceyml.StrategyBlueGreen}
+	assert.True(t, isProgressiveStrategy(service))
+	service.Env = map[string]string{ProjectNamespace: "true"}
+	assert.False(t, isProgressiveStrategy(service))
+}
+
+// newTestDeployment returns deployment of service with the image
+func newTestDeployment(service *apistructs.Service, image string) *appsv1.Deployment {
+	replicas := int32(service.Scale)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: service.Name, Namespace: service.Namespace,
+			Labels: map[string]string{"app": service.Name}, Annotations: map[string]string{}},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": service.Name}},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Name: service.Name, Labels: map[string]string{"app": service.Name}},
+				Spec:       apiv1.PodSpec{Containers: []apiv1.Container{{Name: service.Name, Image: image}}},
+			},
+		},
+	}
+}
+
+// deployStable creates the stable deployment of service as createDeployment does
+func deployStable(t *testing.T, k *Kubernetes, fake *fakeK8s, service *apistructs.Service, image string) {
+	stable := newTestDeployment(service, image)
+	assert.NoError(t, setTemplateHash(stable))
+	assert.NoError(t, k.deploy.Create(stable))
+	fake.setReady(service.Namespace, service.Name)
+}
+
+func TestCanaryRollout(t *testing.T) {
+	k, fake := newFakeKubernetes(t)
+	service := &apistructs.Service{
+		Name:      "web",
+		Namespace: "ns",
+		Scale:     4,
+		Strategy: &diceyml.Strategy{
+			Type:        diceyml.StrategyCanary,
+			CanarySteps: []diceyml.CanaryStep{{Weight: 25, Pause: 60}, {Weight: 50}},
+		},
+	}
+	deployStable(t, k, fake, service, "web:v1")
+
+	// redeploy the same version is not rolled out
+	rollingOut, err := k.rolloutDeployment(service, newTestDeployment(service, "web:v1"))
+	assert.NoError(t, err)
+	assert.False(t, rollingOut)
+
+	// new version starts canary with the first step
+	rollingOut, err = k.rolloutDeployment(service, newTestDeployment(service, "web:v2"))
+	assert.NoError(t, err)
+	assert.True(t, rollingOut)
+	canary := fake.getDeployment("ns", "web-canary")
+	assert.NotNil(t, canary)
+	assert.Equal(t, int32(1), *canary.Spec.Replicas)
+	assert.Equal(t, int32(3), *fake.getDeployment("ns", "web").Spec.Replicas)
+	assert.Equal(t, "web:v1", fake.getDeployment("ns", "web").Spec.Template.Spec.Containers[0].Image)
+
+	// canary not ready, waiting
+	status, err := k.reconcileRollout(service)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.RolloutPhaseProgressing, status.Phase)
+	assert.NotEmpty(t, status.Message)
+
+	// pause of the first step elapsed, go to the next step which waits for promoting manually
+	canary = fake.getDeployment("ns", "web-canary")
+	canary.Annotations[annotationRolloutStepStartedAt] = strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	assert.NoError(t, k.putDeployment(canary))
+	fake.setReady("ns", "web-canary")
+	status, err = k.reconcileRollout(service)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.RolloutPhasePaused, status.Phase)
+	assert.Equal(t, 1, status.Step)
+	assert.Equal(t, int32(2), *fake.getDeployment("ns", "web-canary").Spec.Replicas)
+	assert.Equal(t, int32(2), *fake.getDeployment("ns", "web").Spec.Replicas)
+
+	// paused rollout is not pushed forward
+	fake.setReady("ns", "web-canary")
+	status, err = k.reconcileRollout(service)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.RolloutPhasePaused, status.Phase)
+
+	// promote the last step, canary becomes stable
+	done, err := k.promoteRollout(service, fake.getDeployment("ns", "web-canary"))
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.RolloutPhaseCompleted, done.Phase)
+	assert.Nil(t, fake.getDeployment("ns", "web-canary"))
+	stable := fake.getDeployment("ns", "web")
+	assert.Equal(t, int32(4), *stable.Spec.Replicas)
+	assert.Equal(t, "web:v2", stable.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "web", stable.Spec.Template.Labels["app"])
+	assert.Empty(t, stable.Spec.Template.Labels[LabelRolloutTrack])
+	status, err = k.reconcileRollout(service)
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+
+	// the promoted version is stable now
+	rollingOut, err = k.rolloutDeployment(service, newTestDeployment(service, "web:v2"))
+	assert.NoError(t, err)
+	assert.False(t, rollingOut)
+}
+
+func TestCanaryRolloutRestartAndStrategyChange(t *testing.T) {
+	k, fake := newFakeKubernetes(t)
+	service := &apistructs.Service{
+		Name:      "web",
+		Namespace: "ns",
+		Scale:     2,
+		Strategy: &diceyml.Strategy{
+			Type:        diceyml.StrategyCanary,
+			CanarySteps: []diceyml.CanaryStep{{Weight: 50}},
+		},
+	}
+	deployStable(t, k, fake, service, "web:v1")
+	_, err := k.rolloutDeployment(service, newTestDeployment(service, "web:v2"))
+	assert.NoError(t, err)
+
+	// a newer version arrived during rollout, restart with it
+	rollingOut, err := k.rolloutDeployment(service, newTestDeployment(service, "web:v3"))
+	assert.NoError(t, err)
+	assert.True(t, rollingOut)
+	canary := fake.getDeployment("ns", "web-canary")
+	assert.Equal(t, "web:v3", canary.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "0", canary.Annotations[annotationRolloutStep])
+
+	// strategy changed to rolling update, rollout is deleted
+	service.Strategy = &diceyml.Strategy{Type: diceyml.StrategyRolling}
+	rollingOut, err = k.rolloutDeployment(service, newTestDeployment(service, "web:v3"))
+	assert.NoError(t, err)
+	assert.False(t, rollingOut)
+	assert.Nil(t, fake.getDeployment("ns", "web-canary"))
+}
+
+func TestBlueGreenRollout(t *testing.T) {
+	k, fake := newFakeKubernetes(t)
+	service := &apistructs.Service{
+		Name:      "web",
+		Namespace: "ns",
+		Scale:     2,
+		Ports:     []diceyml.ServicePort{{Port: 8080}},
+		Strategy:  &diceyml.Strategy{Type: diceyml.StrategyBlueGreen},
+	}
+	deployStable(t, k, fake, service, "web:v1")
+	assert.NoError(t, k.CreateService(service))
+
+	rollingOut, err := k.rolloutDeployment(service, newTestDeployment(service, "web:v2"))
+	assert.NoError(t, err)
+	assert.True(t, rollingOut)
+	preview := fake.getDeployment("ns", "web-preview")
+	assert.Equal(t, int32(2), *preview.Spec.Replicas)
+	assert.Equal(t, "web-preview", fake.getService("ns", "web-preview").Spec.Selector["app"])
+	assert.Equal(t, "web", fake.getService("ns", "web").Spec.Selector["app"])
+
+	// not auto promoted, wait for promoting manually
+	fake.setReady("ns", "web-preview")
+	status, err := k.reconcileRollout(service)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.RolloutPhasePaused, status.Phase)
+
+	// promote switches traffic to preview, then updates stable
+	status2, err := k.promoteRollout(service, fake.getDeployment("ns", "web-preview"))
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.RolloutPhasePromoting, status2.Phase)
+	assert.Equal(t, "web-preview", fake.getService("ns", "web").Spec.Selector["app"])
+	assert.Equal(t, "web:v2", fake.getDeployment("ns", "web").Spec.Template.Spec.Containers[0].Image)
+
+	// stable not ready, keep serving by preview
+	status, err = k.reconcileRollout(service)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.RolloutPhasePromoting, status.Phase)
+	assert.Equal(t, "web-preview", fake.getService("ns", "web").Spec.Selector["app"])
+
+	// stable ready, switch back and clean up preview
+	fake.setReady("ns", "web")
+	status, err = k.reconcileRollout(service)
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+	assert.Equal(t, "web", fake.getService("ns", "web").Spec.Selector["app"])
+	assert.Nil(t, fake.getService("ns", "web-preview"))
+	assert.Nil(t, fake.getDeployment("ns", "web-preview"))
+}
+
+func TestBlueGreenRolloutWithoutPorts(t *testing.T) {
+	k, fake := newFakeKubernetes(t)
+	service := &apistructs.Service{
+		Name:      "worker",
+		Namespace: "ns",
+		Scale:     1,
+		Strategy:  &diceyml.Strategy{Type: diceyml.StrategyBlueGreen, AutoPromote: true},
+	}
+	deployStable(t, k, fake, service, "worker:v1")
+	_, err := k.rolloutDeployment(service, newTestDeployment(service, "worker:v2"))
+	assert.NoError(t, err)
+	assert.Nil(t, fake.getService("ns", "worker-preview"))
+
+	// auto promoted once preview is ready
+	fake.setReady("ns", "worker-preview")
+	status, err := k.reconcileRollout(service)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.RolloutPhasePromoting, status.Phase)
+	fake.setReady("ns", "worker")
+	status, err = k.reconcileRollout(service)
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+	assert.Nil(t, fake.getDeployment("ns", "worker-preview"))
+}
+
+func TestNewRolloutDeployment(t *testing.T) {
+	desired := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: map[string]string{"app": "web"},
+			Annotations: map[string]string{}},
+		Spec: appsv1.DeploymentSpec{
+			Template: apiv1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}}},
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}
+	service := &apistructs.Service{
+		Name:  "web",
+		Scale: 4,
+		Strategy: &diceyml.Strategy{
+			Type:        diceyml.StrategyCanary,
+			CanarySteps: []diceyml.CanaryStep{{Weight: 25, Pause: 60}, {Weight: 50}},
+		},
+	}
+	canary := newRolloutDeployment(service, desired)
+	assert.Equal(t, "web-canary", canary.Name)
+	assert.Equal(t, int32(1), *canary.Spec.Replicas)
+	assert.Equal(t, "web", canary.Spec.Template.Labels["app"])
+	assert.Equal(t, rolloutTrackCanary, canary.Spec.Selector.MatchLabels[LabelRolloutTrack])
+	assert.Equal(t, "web", canary.Labels[LabelRolloutService])
+	assert.Equal(t, string(apistructs.RolloutPhaseProgressing), canary.Annotations[annotationRolloutPhase])
+	// desired deployment is not modified
+	assert.Equal(t, 1, len(desired.Spec.Selector.MatchLabels))
+
+	service.Strategy = &diceyml.Strategy{Type: diceyml.StrategyBlueGreen}
+	preview := newRolloutDeployment(service, desired)
+	assert.Equal(t, "web-preview", preview.Name)
+	assert.Equal(t, int32(4), *preview.Spec.Replicas)
+	assert.Equal(t, "web-preview", preview.Spec.Template.Labels["app"])
+	assert.Equal(t, string(apistructs.RolloutPhasePaused), preview.Annotations[annotationRolloutPhase])
+}
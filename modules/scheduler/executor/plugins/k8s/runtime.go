@@ -96,11 +96,26 @@ func (k *Kubernetes) destroyRuntimeByProjectNamespace(ns string, sg *apistructs.
 		case ServicePerNode:
 			err = k.deleteDaemonSet(ns, service.Name)
 		default:
+			if err = k.deleteHPA(ns, service.Name); err != nil {
+				return fmt.Errorf("delete hpa %s error: %v", service.Name, err)
+			}
+			if err = k.deletePDB(ns, service.Name); err != nil {
+				return fmt.Errorf("delete pdb %s error: %v", service.Name, err)
+			}
+			if err = k.deleteRollout(ns, service.Name); err != nil {
+				return fmt.Errorf("delete rollout of %s error: %v", service.Name, err)
+			}
 			err = k.deleteDeployment(ns, service.Name)
 		}
 		if err != nil {
 			return fmt.Errorf("delete resource %s, %s error: %v", service.WorkLoad, service.Name, err)
 		}
+		if err = k.deleteConfigFiles(ns, service.Name); err != nil {
+			return fmt.Errorf("delete config files of %s error: %v", service.Name, err)
+		}
+		if err = k.deleteNetworkPolicy(ns, service.Name); err != nil {
+			return fmt.Errorf("delete networkpolicy of %s error: %v", service.Name, err)
+		}
 
 		labelSelector := map[string]string{
 			"app": originServiceName,
@@ -155,6 +170,9 @@ func (k *Kubernetes) createStatelessGroup(sg *apistructs.ServiceGroup, layers []
 		ns = sg.ProjectNamespace
 	}
 
+	if err := k.labelAddonNamespaces(sg); err != nil {
+		return err
+	}
 	var err error
 	for _, layer := range layers {
 		// services in one layer could be create in parallel, BUT NO NEED
@@ -163,6 +163,10 @@ func (k *Kubernetes) listServiceName(namespace string, labelSelector map[string]
 	}
 
 	for _, item := range deployList.Items {
+		// rollout deployments are managed along with their stable deployments
+		if _, ok := item.Labels[LabelRolloutTrack]; ok {
+			continue
+		}
 		strs = append(strs, item.Name)
 	}
 
@@ -71,10 +71,11 @@ func (k *Kubernetes) createStatefulSet(info StatefulsetInfo) error {
 	cpu := fmt.Sprintf("%.fm", service.Resources.Cpu*1000)
 	memory := fmt.Sprintf("%.fMi", service.Resources.Mem)
 
-	affinity := constraintbuilders.K8S(&info.sg.ScheduleInfo2, service, []constraints.PodLabelsForAffinity{
+	cons := constraintbuilders.K8S(&info.sg.ScheduleInfo2, service, []constraints.PodLabelsForAffinity{
 		{
 			PodLabels: map[string]string{"app": statefulName},
-		}}, k).Affinity
+		}}, k)
+	affinity := cons.Affinity
 
 	set.Spec.Template = apiv1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
@@ -88,6 +89,7 @@ func (k *Kubernetes) createStatefulSet(info StatefulsetInfo) error {
 		},
 	}
 	set.Spec.Template.Spec.Affinity = &affinity
+	set.Spec.Template.Spec.TopologySpreadConstraints = cons.TopologySpreadConstraints
 	// Currently only one business container is set in our Pod
 	container := &apiv1.Container{
 		Name:  statefulName,
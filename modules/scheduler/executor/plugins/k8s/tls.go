@@ -0,0 +1,112 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+// BindTLS saves the certificate of domain as a tls secret in the namespace of servicegroup,
+// and points the tls of the ingresses routing the domain to the secret
+func (k *Kubernetes) BindTLS(ctx context.Context, sg *apistructs.ServiceGroup, domain, certificate, privateKey string) error {
+	if IsGroupStateful(sg) {
+		return errors.Errorf("tls of stateful servicegroup %s/%s is not supported", sg.Type, sg.ID)
+	}
+	ns := MakeNamespace(sg)
+	if sg.ProjectNamespace != "" {
+		ns = sg.ProjectNamespace
+	}
+	secretName := tlsSecretName(domain)
+	if err := k.secret.CreateOrUpdate(&apiv1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: ns,
+		},
+		Type: apiv1.SecretTypeTLS,
+		Data: map[string][]byte{
+			apiv1.TLSCertKey:       []byte(certificate),
+			apiv1.TLSPrivateKeyKey: []byte(privateKey),
+		},
+	}); err != nil {
+		return err
+	}
+	ingresses, err := k.ingress.List(ns)
+	if err != nil {
+		return err
+	}
+	bound := false
+	for i := range ingresses.Items {
+		ing := &ingresses.Items[i]
+		if !bindIngressTLS(ing, domain, secretName) {
+			continue
+		}
+		if err := k.ingress.Update(ing); err != nil {
+			return err
+		}
+		bound = true
+	}
+	if !bound {
+		return errors.Errorf("no ingress of domain %s found in namespace %s", domain, ns)
+	}
+	return nil
+}
+
+// tlsSecretName returns the name of the secret holding the certificate of domain, a domain is a valid secret name
+func tlsSecretName(domain string) string {
+	return "tls-" + strings.ToLower(domain)
+}
+
+// bindIngressTLS makes domain use the secret if the ingress routes it, the domain is removed from other tls entries.
+// It returns false if the ingress does not route the domain
+func bindIngressTLS(ing *extensionsv1beta1.Ingress, domain, secretName string) bool {
+	routed := false
+	for _, rule := range ing.Spec.Rules {
+		if strings.EqualFold(rule.Host, domain) {
+			routed = true
+			break
+		}
+	}
+	if !routed {
+		return false
+	}
+	tls := make([]extensionsv1beta1.IngressTLS, 0, len(ing.Spec.TLS)+1)
+	for _, entry := range ing.Spec.TLS {
+		hosts := make([]string, 0, len(entry.Hosts))
+		for _, host := range entry.Hosts {
+			if !strings.EqualFold(host, domain) {
+				hosts = append(hosts, host)
+			}
+		}
+		// entry left empty only covered the domain
+		if len(hosts) == 0 && len(entry.Hosts) > 0 {
+			continue
+		}
+		entry.Hosts = hosts
+		tls = append(tls, entry)
+	}
+	ing.Spec.TLS = append(tls, extensionsv1beta1.IngressTLS{Hosts: []string{domain}, SecretName: secretName})
+	return true
+}
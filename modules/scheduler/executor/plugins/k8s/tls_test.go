@@ -0,0 +1,46 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+)
+
+func TestBindIngressTLS(t *testing.T) {
+	ing := &extensionsv1beta1.Ingress{Spec: extensionsv1beta1.IngressSpec{
+		Rules: []extensionsv1beta1.IngressRule{{Host: "a.example.com"}, {Host: "b.example.com"}},
+		TLS:   []extensionsv1beta1.IngressTLS{{Hosts: []string{"a.example.com", "b.example.com"}}},
+	}}
+	assert.False(t, bindIngressTLS(ing, "c.example.com", "tls-c.example.com"))
+	assert.Equal(t, 1, len(ing.Spec.TLS))
+
+	assert.True(t, bindIngressTLS(ing, "a.example.com", "tls-a.example.com"))
+	assert.Equal(t, []extensionsv1beta1.IngressTLS{
+		{Hosts: []string{"b.example.com"}},
+		{Hosts: []string{"a.example.com"}, SecretName: "tls-a.example.com"},
+	}, ing.Spec.TLS)
+
+	// binding again after renewal does not duplicate the entry
+	assert.True(t, bindIngressTLS(ing, "a.example.com", "tls-a.example.com"))
+	assert.Equal(t, 2, len(ing.Spec.TLS))
+
+	assert.True(t, bindIngressTLS(ing, "b.example.com", "tls-b.example.com"))
+	assert.Equal(t, []extensionsv1beta1.IngressTLS{
+		{Hosts: []string{"a.example.com"}, SecretName: "tls-a.example.com"},
+		{Hosts: []string{"b.example.com"}, SecretName: "tls-b.example.com"},
+	}, ing.Spec.TLS)
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8sjob
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/schedulepolicy/labelconfig"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+const (
+	cronJobKind       = "CronJob"
+	cronJobAPIVersion = "batch/v1beta1"
+	// annotation set by kubernetes on jobs created by `kubectl create job --from=cronjob/xxx`
+	cronJobInstantiateAnnotation = "cronjob.kubernetes.io/instantiate"
+	// label of the jobs instantiated by cron job, value is the name of cron job
+	cronJobLabel = labelconfig.K8SLabelPrefix + "cronjob"
+	// length of random suffix of manual job name, keeps it within 63 characters as cron job name is at most 52
+	manualJobSuffixLength = 5
+)
+
+// jobNamespace returns the k8s namespace the job runs in
+func jobNamespace(job *apistructs.Job) string {
+	if namespace := os.Getenv(ENABLE_SPECIFIED_K8S_NAMESPACE); namespace != "" {
+		return namespace
+	}
+	return job.Namespace
+}
+
+func newCronJob(kubeJob *batchv1.Job, schedule *diceyml.JobSchedule) *batchv1beta1.CronJob {
+	labels := map[string]string{cronJobLabel: kubeJob.Name}
+	for k, v := range kubeJob.Labels {
+		labels[k] = v
+	}
+	cronJob := &batchv1beta1.CronJob{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       cronJobKind,
+			APIVersion: cronJobAPIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kubeJob.Name,
+			Namespace: kubeJob.Namespace,
+		},
+		Spec: batchv1beta1.CronJobSpec{
+			Schedule:          schedule.Cron,
+			ConcurrencyPolicy: batchv1beta1.ConcurrencyPolicy(schedule.ConcurrencyPolicy),
+			Suspend:           &schedule.Suspend,
+			JobTemplate: batchv1beta1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: kubeJob.Spec,
+			},
+		},
+	}
+	if cronJob.Spec.ConcurrencyPolicy == "" {
+		cronJob.Spec.ConcurrencyPolicy = batchv1beta1.AllowConcurrent
+	}
+	if schedule.SuccessfulJobsHistoryLimit != nil {
+		limit := int32(*schedule.SuccessfulJobsHistoryLimit)
+		cronJob.Spec.SuccessfulJobsHistoryLimit = &limit
+	}
+	if schedule.FailedJobsHistoryLimit != nil {
+		limit := int32(*schedule.FailedJobsHistoryLimit)
+		cronJob.Spec.FailedJobsHistoryLimit = &limit
+	}
+	return cronJob
+}
+
+// createOrUpdateCronJob creates the cron job, or replaces its spec if it already exists
+func (k *k8sJob) createOrUpdateCronJob(ctx context.Context, cronJob *batchv1beta1.CronJob) error {
+	old, err := k.client.BatchV1beta1().CronJobs(cronJob.Namespace).Get(ctx, cronJob.Name, metav1.GetOptions{})
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return errors.Wrapf(err, "failed to get k8s cronjob, name: %s", cronJob.Name)
+		}
+		if _, err = k.client.BatchV1beta1().CronJobs(cronJob.Namespace).Create(ctx, cronJob, metav1.CreateOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to create k8s cronjob, name: %s", cronJob.Name)
+		}
+		return nil
+	}
+	old.Spec = cronJob.Spec
+	if _, err = k.client.BatchV1beta1().CronJobs(cronJob.Namespace).Update(ctx, old, metav1.UpdateOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to update k8s cronjob, name: %s", cronJob.Name)
+	}
+	return nil
+}
+
+func (k *k8sJob) removeCronJob(ctx context.Context, namespace, name string) error {
+	propagationPolicy := metav1.DeletePropagationBackground
+	err := k.client.BatchV1beta1().CronJobs(namespace).Delete(ctx, name, metav1.DeleteOptions{
+		PropagationPolicy: &propagationPolicy,
+	})
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return errors.Wrapf(err, "failed to remove k8s cronjob, name: %s", name)
+	}
+	return nil
+}
+
+// cronJobStatus Running while the cron job is scheduling, Stopped when it is suspended
+func (k *k8sJob) cronJobStatus(ctx context.Context, namespace, name string) (apistructs.StatusDesc, error) {
+	var statusDesc apistructs.StatusDesc
+	cronJob, err := k.client.BatchV1beta1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			statusDesc.Status = apistructs.StatusNotFoundInCluster
+			return statusDesc, nil
+		}
+		return statusDesc, errors.Wrapf(err, "failed to get k8s cronjob status, name: %s", name)
+	}
+	if cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend && len(cronJob.Status.Active) == 0 {
+		statusDesc.Status = apistructs.StatusStopped
+	} else {
+		statusDesc.Status = apistructs.StatusRunning
+	}
+	if cronJob.Status.LastScheduleTime != nil {
+		statusDesc.LastMessage = fmt.Sprintf("last scheduled at %s", cronJob.Status.LastScheduleTime.Format(time.RFC3339))
+	}
+	return statusDesc, nil
+}
+
+// CronJob query executions, trigger or suspend the cron job of job
+func (k *k8sJob) CronJob(ctx context.Context, job *apistructs.Job, action apistructs.CronJobAction) (apistructs.CronJobStatus, error) {
+	if job.Schedule == nil {
+		return apistructs.CronJobStatus{}, errors.Errorf("job %s/%s is not a scheduled job", job.Namespace, job.Name)
+	}
+	namespace := jobNamespace(job)
+	name := strutil.Concat(job.Namespace, ".", job.Name)
+
+	cronJob, err := k.client.BatchV1beta1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return apistructs.CronJobStatus{}, errors.Wrapf(err, "failed to get k8s cronjob, name: %s", name)
+	}
+
+	switch action {
+	case apistructs.CronJobHistory:
+	case apistructs.CronJobTrigger:
+		if _, err = k.client.BatchV1().Jobs(namespace).Create(ctx, newManualJob(cronJob), metav1.CreateOptions{}); err != nil {
+			return apistructs.CronJobStatus{}, errors.Wrapf(err, "failed to trigger k8s cronjob, name: %s", name)
+		}
+	case apistructs.CronJobSuspend, apistructs.CronJobResume:
+		suspend := action == apistructs.CronJobSuspend
+		cronJob.Spec.Suspend = &suspend
+		if cronJob, err = k.client.BatchV1beta1().CronJobs(namespace).Update(ctx, cronJob, metav1.UpdateOptions{}); err != nil {
+			return apistructs.CronJobStatus{}, errors.Wrapf(err, "failed to %s k8s cronjob, name: %s", action, name)
+		}
+		logrus.Infof("%s cronjob %s in namespace %s", action, name, namespace)
+	default:
+		return apistructs.CronJobStatus{}, errors.Errorf("invalid cron job action: %s", action)
+	}
+
+	jobs, err := k.client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: strutil.Concat(cronJobLabel, "=", name),
+	})
+	if err != nil {
+		return apistructs.CronJobStatus{}, errors.Wrapf(err, "failed to list executions of k8s cronjob, name: %s", name)
+	}
+	return generateCronJobStatus(cronJob, jobs.Items), nil
+}
+
+// newManualJob instantiates a job from the template of cron job, as `kubectl create job --from=cronjob/xxx` does
+func newManualJob(cronJob *batchv1beta1.CronJob) *batchv1.Job {
+	annotations := map[string]string{cronJobInstantiateAnnotation: "manual"}
+	for k, v := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[k] = v
+	}
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       jobKind,
+			APIVersion: jobAPIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        strutil.Concat(cronJob.Name, "-m", rand.String(manualJobSuffixLength)),
+			Namespace:   cronJob.Namespace,
+			Labels:      cronJob.Spec.JobTemplate.Labels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(cronJob, batchv1beta1.SchemeGroupVersion.WithKind(cronJobKind)),
+			},
+		},
+		Spec: cronJob.Spec.JobTemplate.Spec,
+	}
+}
+
+// generateCronJobStatus collects the jobs owned by cron job as its executions, latest first
+func generateCronJobStatus(cronJob *batchv1beta1.CronJob, jobs []batchv1.Job) apistructs.CronJobStatus {
+	status := apistructs.CronJobStatus{
+		Cron:       cronJob.Spec.Schedule,
+		Suspend:    cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend,
+		Executions: []apistructs.JobExecution{},
+	}
+	if cronJob.Status.LastScheduleTime != nil {
+		status.LastScheduleTime = cronJob.Status.LastScheduleTime.Unix()
+	}
+
+	owned := make([]batchv1.Job, 0, len(jobs))
+	for _, job := range jobs {
+		if ref := metav1.GetControllerOf(&job); ref != nil && ref.UID == cronJob.UID {
+			owned = append(owned, job)
+		}
+	}
+	sort.Slice(owned, func(i, j int) bool {
+		return owned[j].CreationTimestamp.Before(&owned[i].CreationTimestamp)
+	})
+
+	for i := range owned {
+		job := &owned[i]
+		execution := apistructs.JobExecution{
+			Name:   job.Name,
+			Status: generateKubeJobStatus(job, &corev1.PodList{}, "").Status,
+			Manual: job.Annotations[cronJobInstantiateAnnotation] == "manual",
+		}
+		if job.Status.StartTime != nil {
+			execution.StartTime = job.Status.StartTime.Unix()
+		}
+		if job.Status.CompletionTime != nil {
+			execution.FinishTime = job.Status.CompletionTime.Unix()
+		}
+		for _, cond := range job.Status.Conditions {
+			if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+				execution.LastMessage = cond.Message
+			}
+		}
+		status.Executions = append(status.Executions, execution)
+	}
+	return status
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package k8sjob
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/schedulepolicy/labelconfig"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func TestNewCronJob(t *testing.T) {
+	limit := 2
+	kubeJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns.cleanup", Namespace: "ns", Labels: jobLabels()},
+		Spec:       batchv1.JobSpec{Completions: &defaultCompletions},
+	}
+	cronJob := newCronJob(kubeJob, &diceyml.JobSchedule{Cron: "*/5 * * * *", FailedJobsHistoryLimit: &limit})
+	assert.Equal(t, "ns.cleanup", cronJob.Name)
+	assert.Equal(t, batchv1beta1.AllowConcurrent, cronJob.Spec.ConcurrencyPolicy)
+	assert.Equal(t, int32(2), *cronJob.Spec.FailedJobsHistoryLimit)
+	assert.Nil(t, cronJob.Spec.SuccessfulJobsHistoryLimit)
+	assert.False(t, *cronJob.Spec.Suspend)
+	// executions are listed by the label of cron job
+	assert.Equal(t, "ns.cleanup", cronJob.Spec.JobTemplate.Labels[cronJobLabel])
+	assert.Contains(t, cronJob.Spec.JobTemplate.Labels, labelconfig.K8SLabelPrefix+"job")
+	assert.NotContains(t, kubeJob.Labels, cronJobLabel)
+
+	manual := newManualJob(cronJob)
+	assert.Equal(t, "manual", manual.Annotations[cronJobInstantiateAnnotation])
+	assert.Equal(t, cronJob.Spec.JobTemplate.Spec, manual.Spec)
+	assert.Equal(t, "ns.cleanup", manual.Labels[cronJobLabel])
+	// manual jobs triggered in the same second do not collide
+	assert.NotEqual(t, manual.Name, newManualJob(cronJob).Name)
+	assert.True(t, len(manual.Name) <= 63)
+}
+
+func TestGenerateCronJobStatus(t *testing.T) {
+	cronJob := &batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns.cleanup", Namespace: "ns", UID: "cron-uid"},
+		Spec:       batchv1beta1.CronJobSpec{Schedule: "0 * * * *"},
+	}
+	now := time.Now()
+	newJob := func(name string, created time.Time, succeeded int32) batchv1.Job {
+		job := *newManualJob(cronJob)
+		job.Name = name
+		job.CreationTimestamp = metav1.NewTime(created)
+		job.Spec.Completions = &defaultCompletions
+		job.Status.StartTime = &job.CreationTimestamp
+		job.Status.Succeeded = succeeded
+		if succeeded > 0 {
+			job.Status.CompletionTime = &job.CreationTimestamp
+		}
+		return job
+	}
+	other := batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+	jobs := []batchv1.Job{newJob("old", now.Add(-time.Hour), 1), other, newJob("new", now, 0)}
+
+	status := generateCronJobStatus(cronJob, jobs)
+	assert.Equal(t, "0 * * * *", status.Cron)
+	assert.Equal(t, 2, len(status.Executions))
+	assert.Equal(t, "new", status.Executions[0].Name)
+	assert.True(t, status.Executions[0].Manual)
+	assert.Equal(t, apistructs.StatusStoppedOnOK, status.Executions[1].Status)
+}
@@ -169,6 +169,19 @@ func (k *k8sJob) Create(ctx context.Context, specObj interface{}) (interface{},
 		return nil, errors.Wrapf(err, "failed to create k8s job")
 	}
 
+	if err := k.createOrUpdateJobSecret(ctx, namespace, kubeJob.Name, job.SecretEnv); err != nil {
+		return nil, errors.Wrapf(err, "failed to create secret of k8s job")
+	}
+
+	if job.Schedule != nil {
+		kubeJob.Namespace = namespace
+		if err = k.createOrUpdateCronJob(ctx, newCronJob(kubeJob, job.Schedule)); err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		return job, nil
+	}
+
 	_, err = k.client.BatchV1().Jobs(namespace).Create(ctx, kubeJob, metav1.CreateOptions{})
 
 	name := kubeJob.Name
@@ -203,6 +216,10 @@ func (k *k8sJob) Status(ctx context.Context, specObj interface{}) (apistructs.St
 	}
 	name := strutil.Concat(kubeJob.Namespace, ".", kubeJob.Name)
 
+	if kubeJob.Schedule != nil {
+		return k.cronJobStatus(ctx, namespace, name)
+	}
+
 	job, err = k.client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
@@ -269,6 +286,22 @@ func (k *k8sJob) Remove(ctx context.Context, specObj interface{}) error {
 	name := kubeJob.Name
 	propagationPolicy := metav1.DeletePropagationBackground
 
+	if job.Schedule != nil {
+		if err = k.removeCronJob(ctx, namespace, name); err != nil {
+			return err
+		}
+		if err = k.removeJobPVCs(ctx, namespace, &job); err != nil {
+			return err
+		}
+	}
+
+	if len(job.SecretEnv) > 0 {
+		err = k.client.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return errors.Wrapf(err, "failed to remove secret of k8s job, name: %s", name)
+		}
+	}
+
 	jb, err := k.client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		if !strings.Contains(err.Error(), "not found") {
@@ -288,15 +321,8 @@ func (k *k8sJob) Remove(ctx context.Context, specObj interface{}) error {
 			logrus.Debugf("the job %s in namespace %s is not found", name, namespace)
 		}
 
-		for index := range job.Volumes {
-			pvcName := fmt.Sprintf("%s-%s-%d", namespace, job.Name, index)
-			err = k.client.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, pvcName, metav1.DeleteOptions{})
-			if err != nil {
-				if !strings.Contains(err.Error(), "not found") {
-					return errors.Wrapf(err, "failed to remove k8s pvc, name: %s", pvcName)
-				}
-				logrus.Debugf("the pvc %s in namespace %s is not found", name, namespace)
-			}
+		if err = k.removeJobPVCs(ctx, namespace, &job); err != nil {
+			return err
 		}
 	}
 	if os.Getenv(ENABLE_SPECIFIED_K8S_NAMESPACE) == "" {
@@ -338,6 +364,20 @@ func (k *k8sJob) Remove(ctx context.Context, specObj interface{}) error {
 	return nil
 }
 
+func (k *k8sJob) removeJobPVCs(ctx context.Context, namespace string, job *apistructs.Job) error {
+	for index := range job.Volumes {
+		pvcName := fmt.Sprintf("%s-%s-%d", namespace, job.Name, index)
+		err := k.client.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, pvcName, metav1.DeleteOptions{})
+		if err != nil {
+			if !strings.Contains(err.Error(), "not found") {
+				return errors.Wrapf(err, "failed to remove k8s pvc, name: %s", pvcName)
+			}
+			logrus.Debugf("the pvc %s in namespace %s is not found", pvcName, namespace)
+		}
+	}
+	return nil
+}
+
 // Update update k8s job
 func (k *k8sJob) Update(ctx context.Context, specObj interface{}) (interface{}, error) {
 	var (
@@ -350,6 +390,11 @@ func (k *k8sJob) Update(ctx context.Context, specObj interface{}) (interface{},
 		return nil, errors.Wrapf(err, "failed to update k8s job")
 	}
 
+	if job := specObj.(apistructs.Job); job.Schedule != nil {
+		kubeJob.Namespace = jobNamespace(&job)
+		return nil, k.createOrUpdateCronJob(ctx, newCronJob(kubeJob, job.Schedule))
+	}
+
 	if err = k.updateK8SJob(*kubeJob); err != nil {
 		return nil, err
 	}
@@ -376,6 +421,12 @@ func (k *k8sJob) Cancel(ctx context.Context, specObj interface{}) (interface{},
 
 	name := strutil.Concat(namespace, ".", job.Name)
 
+	// Stop the cron job by suspending subsequent executions
+	if job.Schedule != nil {
+		_, err := k.CronJob(ctx, &job, apistructs.CronJobSuspend)
+		return nil, err
+	}
+
 	// Stop the job by setting job.spec.parallelism = 0
 	return nil, k.setJobParallelism(namespace, name, 0)
 }
@@ -599,6 +650,18 @@ func (k *k8sJob) generateContainerEnvs(job *apistructs.Job, clusterInfo map[stri
 		})
 	}
 
+	// secret envs refer to the secret created along with the job, which has the same name as the job
+	for k := range job.SecretEnv {
+		env = append(env, corev1.EnvVar{
+			Name: k,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: strutil.Concat(job.Namespace, ".", job.Name)},
+					Key:                  k,
+				},
+			}})
+	}
+
 	// add K8S label
 	env = append(env, corev1.EnvVar{
 		Name:  "IS_K8S",
@@ -809,6 +872,31 @@ func whichStorageClass(tp string) string {
 	}
 }
 
+// createOrUpdateJobSecret stores the secret envs of job into a secret with the same name as the k8s job
+func (k *k8sJob) createOrUpdateJobSecret(ctx context.Context, namespace, name string, secretEnv map[string]string) error {
+	if len(secretEnv) == 0 {
+		return nil
+	}
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		StringData: secretEnv,
+		Type:       corev1.SecretTypeOpaque,
+	}
+	_, err := k.client.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err == nil || !strutil.Contains(err.Error(), "AlreadyExists") {
+		return err
+	}
+	_, err = k.client.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	return err
+}
+
 func (k *k8sJob) createImageSecretIfNotExist(namespace string) error {
 	var err error
 
@@ -0,0 +1,491 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package localprocess runs jobs and servicegroups as supervised local processes, or as containers by a
+// docker compatible oci runtime if present, so that the whole pipeline -> scheduler flow works on a single
+// machine without any cluster.
+//
+// EXECUTOR_LOCALPROCESS_LOCALFORTEST_WORK_DIR=/tmp/erda-localprocess
+// EXECUTOR_LOCALPROCESS_LOCALFORTEST_OCI_RUNTIME=auto
+//
+// The processes are kept in memory, they are not recovered after scheduler restarts.
+// Processes are supervised by process groups, so the executor is only available on unix-like systems.
+package localprocess
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/executortypes"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+const (
+	executorKind = "LOCALPROCESS"
+
+	// WORK_DIR is the base directory of process working directories and logs
+	optionWorkDir = "WORK_DIR"
+	// OCI_RUNTIME is the docker compatible cli used to run images:
+	// auto(default) uses docker or podman found in PATH, none always runs cmd as local process
+	optionOCIRuntime = "OCI_RUNTIME"
+
+	ociRuntimeAuto = "auto"
+	ociRuntimeNone = "none"
+)
+
+func init() {
+	executortypes.Register(executorKind, func(name executortypes.Name, clusterName string, options map[string]string, optionsPlus interface{}) (
+		executortypes.Executor, error) {
+		return New(name, options)
+	})
+}
+
+// LocalProcess executor
+type LocalProcess struct {
+	name       executortypes.Name
+	workDir    string
+	ociRuntime string
+
+	mu sync.Mutex
+	// jobs key: namespace/name
+	jobs map[string]*process
+	// groups key: namespace of servicegroup, value key: service name
+	groups map[string]map[string]*process
+}
+
+// New create local process executor
+func New(name executortypes.Name, options map[string]string) (*LocalProcess, error) {
+	if !supported {
+		return nil, errors.Errorf("local process executor is not supported on %s, executor: %s", runtime.GOOS, name)
+	}
+	workDir := options[optionWorkDir]
+	if workDir == "" {
+		workDir = filepath.Join(os.TempDir(), "erda-localprocess")
+	}
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return nil, errors.Errorf("failed to create work dir %s, executor: %s, (%v)", workDir, name, err)
+	}
+	ociRuntime, err := lookupOCIRuntime(options[optionOCIRuntime])
+	if err != nil {
+		return nil, errors.Errorf("failed to find oci runtime, executor: %s, (%v)", name, err)
+	}
+	logrus.Infof("local process executor %s, work dir: %s, oci runtime: %q", name, workDir, ociRuntime)
+	return &LocalProcess{
+		name:       name,
+		workDir:    workDir,
+		ociRuntime: ociRuntime,
+		jobs:       make(map[string]*process),
+		groups:     make(map[string]map[string]*process),
+	}, nil
+}
+
+func lookupOCIRuntime(option string) (string, error) {
+	switch option {
+	case ociRuntimeNone:
+		return "", nil
+	case "", ociRuntimeAuto:
+		for _, cli := range []string{"docker", "podman"} {
+			if path, err := exec.LookPath(cli); err == nil {
+				return path, nil
+			}
+		}
+		return "", nil
+	default:
+		return exec.LookPath(option)
+	}
+}
+
+func (l *LocalProcess) Kind() executortypes.Kind {
+	return executorKind
+}
+
+func (l *LocalProcess) Name() executortypes.Name {
+	return l.name
+}
+
+// ociRuntimeFor returns the oci runtime to run image, empty if run cmd as local process
+func (l *LocalProcess) ociRuntimeFor(image string) string {
+	if image == "" {
+		return ""
+	}
+	return l.ociRuntime
+}
+
+func containerName(parts ...string) string {
+	return strings.ToLower(strutil.Concat("erda-", strutil.Join(parts, "-", true)))
+}
+
+// Create starts a job or all services of servicegroup
+func (l *LocalProcess) Create(ctx context.Context, specObj interface{}) (interface{}, error) {
+	switch spec := specObj.(type) {
+	case apistructs.Job:
+		return spec, l.createJob(spec)
+	case apistructs.ServiceGroup:
+		return nil, l.createGroup(spec)
+	}
+	return nil, errors.New("invalid spec, neither job nor servicegroup")
+}
+
+func jobKey(job apistructs.Job) string {
+	return job.Namespace + "/" + job.Name
+}
+
+func (l *LocalProcess) createJob(job apistructs.Job) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if p, ok := l.jobs[jobKey(job)]; ok {
+		if state, _, _ := p.status(); state == processRunning {
+			return errors.Errorf("job %s is running", jobKey(job))
+		}
+	}
+	p := newProcess(processSpec{
+		name:  containerName(job.Namespace, job.Name),
+		dir:   filepath.Join(l.workDir, "jobs", job.Namespace, job.Name),
+		image: job.Image,
+		cmd:   job.Cmd,
+		env:   job.Env,
+		binds: job.Binds,
+		cpu:   job.CPU,
+		mem:   job.Memory,
+	}, l.ociRuntimeFor(job.Image))
+	if err := p.start(); err != nil {
+		return errors.Errorf("failed to start job %s, (%v)", jobKey(job), err)
+	}
+	l.jobs[jobKey(job)] = p
+	return nil
+}
+
+func groupKey(sg apistructs.ServiceGroup) string {
+	return sg.Type + "--" + sg.ID
+}
+
+func (l *LocalProcess) newServiceProcess(sg apistructs.ServiceGroup, service apistructs.Service) *process {
+	return newProcess(processSpec{
+		name:    containerName(sg.Type, sg.ID, service.Name),
+		dir:     filepath.Join(l.workDir, "servicegroups", groupKey(sg), service.Name),
+		image:   service.Image,
+		cmd:     service.Cmd,
+		env:     service.Env,
+		binds:   serviceBinds(service.Binds),
+		cpu:     service.Resources.Cpu,
+		mem:     service.Resources.Mem,
+		restart: true,
+	}, l.ociRuntimeFor(service.Image))
+}
+
+func serviceBinds(serviceBinds []apistructs.ServiceBind) []apistructs.Bind {
+	binds := make([]apistructs.Bind, 0, len(serviceBinds))
+	for _, bind := range serviceBinds {
+		binds = append(binds, bind.Bind)
+	}
+	return binds
+}
+
+func (l *LocalProcess) createGroup(sg apistructs.ServiceGroup) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.groups[groupKey(sg)]; ok {
+		return errors.Errorf("servicegroup %s already exists", groupKey(sg))
+	}
+	return l.startGroup(sg)
+}
+
+// startGroup starts all services of servicegroup, must be called with l.mu held
+func (l *LocalProcess) startGroup(sg apistructs.ServiceGroup) error {
+	group := make(map[string]*process, len(sg.Services))
+	for _, service := range sg.Services {
+		p := l.newServiceProcess(sg, service)
+		if err := p.start(); err != nil {
+			for _, started := range group {
+				started.kill(false)
+			}
+			return errors.Errorf("failed to start service %s of servicegroup %s, (%v)", service.Name, groupKey(sg), err)
+		}
+		group[service.Name] = p
+	}
+	l.groups[groupKey(sg)] = group
+	return nil
+}
+
+// Destroy is equivalent to Remove
+func (l *LocalProcess) Destroy(ctx context.Context, specObj interface{}) error {
+	return l.Remove(ctx, specObj)
+}
+
+// Remove kills the processes and forgets them, logs are kept in work dir
+func (l *LocalProcess) Remove(ctx context.Context, specObj interface{}) error {
+	var killing []*process
+	l.mu.Lock()
+	switch spec := specObj.(type) {
+	case apistructs.Job:
+		if p, ok := l.jobs[jobKey(spec)]; ok {
+			killing = append(killing, p)
+			delete(l.jobs, jobKey(spec))
+		}
+	case apistructs.ServiceGroup:
+		for _, p := range l.groups[groupKey(spec)] {
+			killing = append(killing, p)
+		}
+		delete(l.groups, groupKey(spec))
+	default:
+		l.mu.Unlock()
+		return errors.New("invalid spec, neither job nor servicegroup")
+	}
+	l.mu.Unlock()
+
+	for _, p := range killing {
+		p.kill(false)
+	}
+	return nil
+}
+
+// Status returns status of job or servicegroup
+func (l *LocalProcess) Status(ctx context.Context, specObj interface{}) (apistructs.StatusDesc, error) {
+	switch spec := specObj.(type) {
+	case apistructs.Job:
+		return l.jobStatus(spec), nil
+	case apistructs.ServiceGroup:
+		sg, err := l.inspectGroup(spec)
+		if err != nil {
+			return apistructs.StatusDesc{Status: apistructs.StatusNotFoundInCluster}, nil
+		}
+		return sg.StatusDesc, nil
+	}
+	return apistructs.StatusDesc{}, errors.New("invalid spec, neither job nor servicegroup")
+}
+
+func (l *LocalProcess) jobStatus(job apistructs.Job) apistructs.StatusDesc {
+	l.mu.Lock()
+	p, ok := l.jobs[jobKey(job)]
+	l.mu.Unlock()
+	if !ok {
+		return apistructs.StatusDesc{Status: apistructs.StatusNotFoundInCluster}
+	}
+	state, exitCode, _ := p.status()
+	switch state {
+	case processRunning:
+		return apistructs.StatusDesc{Status: apistructs.StatusRunning}
+	case processKilled:
+		return apistructs.StatusDesc{Status: apistructs.StatusStoppedByKilled}
+	}
+	if exitCode == 0 {
+		return apistructs.StatusDesc{Status: apistructs.StatusStoppedOnOK}
+	}
+	return apistructs.StatusDesc{
+		Status:      apistructs.StatusStoppedOnFailed,
+		LastMessage: fmt.Sprintf("exit code: %d, %s", exitCode, p.lastMessage()),
+	}
+}
+
+func (l *LocalProcess) inspectGroup(sg apistructs.ServiceGroup) (*apistructs.ServiceGroup, error) {
+	l.mu.Lock()
+	processes, ok := l.groups[groupKey(sg)]
+	// copy under lock, the group is replaced by update concurrently
+	group := make(map[string]*process, len(processes))
+	for name, p := range processes {
+		group[name] = p
+	}
+	l.mu.Unlock()
+	if !ok {
+		return nil, errors.Errorf("servicegroup %s not found", groupKey(sg))
+	}
+
+	sg.Status = apistructs.StatusHealthy
+	for i := range sg.Services {
+		service := &sg.Services[i]
+		// services are exposed on host network
+		service.Vip = "127.0.0.1"
+		p, ok := group[service.Name]
+		if !ok {
+			service.Status = apistructs.StatusUnknown
+			sg.Status = apistructs.StatusProgressing
+			continue
+		}
+		state, exitCode, restarts := p.status()
+		if state == processRunning {
+			service.Status = apistructs.StatusHealthy
+			continue
+		}
+		service.Status = apistructs.StatusFailing
+		service.LastMessage = fmt.Sprintf("exit code: %d, restarts: %d, %s", exitCode, restarts, p.lastMessage())
+		sg.Status = apistructs.StatusProgressing
+		sg.LastMessage = service.LastMessage
+	}
+	return &sg, nil
+}
+
+// Update restarts the services whose spec changed, starts new services and kills the removed ones.
+// If any service fails to start, the servicegroup is rolled back to the old processes.
+func (l *LocalProcess) Update(ctx context.Context, specObj interface{}) (interface{}, error) {
+	sg, ok := specObj.(apistructs.ServiceGroup)
+	if !ok {
+		return nil, errors.New("invalid spec, local process executor only supports updating servicegroup")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old, ok := l.groups[groupKey(sg)]
+	if !ok {
+		return nil, l.startGroup(sg)
+	}
+
+	group := make(map[string]*process, len(sg.Services))
+	// replaced are the old processes killed to restart, they are restored if update fails
+	replaced := make(map[string]*process)
+	for _, service := range sg.Services {
+		p := l.newServiceProcess(sg, service)
+		o, ok := old[service.Name]
+		if ok && reflect.DeepEqual(o.spec, p.spec) {
+			group[service.Name] = o
+			continue
+		}
+		if ok {
+			// the old one is killed first, since they share the port and container name
+			o.kill(false)
+			replaced[service.Name] = o
+		}
+		if err := p.start(); err != nil {
+			l.rollbackGroup(groupKey(sg), old, group, replaced)
+			return nil, errors.Errorf("failed to start service %s of servicegroup %s, (%v)", service.Name, groupKey(sg), err)
+		}
+		group[service.Name] = p
+	}
+	var killing []*process
+	for name, p := range old {
+		if _, ok := group[name]; !ok {
+			killing = append(killing, p)
+		}
+	}
+	l.groups[groupKey(sg)] = group
+	for _, p := range killing {
+		p.kill(false)
+	}
+	return nil, nil
+}
+
+// rollbackGroup kills the processes started by a failed update and restarts the replaced ones,
+// must be called with l.mu held
+func (l *LocalProcess) rollbackGroup(key string, old, started, replaced map[string]*process) {
+	for name, p := range started {
+		if old[name] != p {
+			p.kill(false)
+		}
+	}
+	restored := make(map[string]*process, len(old))
+	for name, p := range old {
+		restored[name] = p
+	}
+	for name, o := range replaced {
+		p := newProcess(o.spec, o.ociRuntime)
+		if err := p.start(); err != nil {
+			// keep the killed one, it is reported as failing
+			logrus.Errorf("failed to restore service %s of servicegroup %s, (%v)", name, key, err)
+			continue
+		}
+		restored[name] = p
+	}
+	l.groups[key] = restored
+}
+
+// Inspect returns servicegroup with status of services
+func (l *LocalProcess) Inspect(ctx context.Context, specObj interface{}) (interface{}, error) {
+	sg, ok := specObj.(apistructs.ServiceGroup)
+	if !ok {
+		return nil, errors.New("invalid spec, local process executor only supports inspecting servicegroup")
+	}
+	return l.inspectGroup(sg)
+}
+
+// Cancel kills the running job
+func (l *LocalProcess) Cancel(ctx context.Context, specObj interface{}) (interface{}, error) {
+	job, ok := specObj.(apistructs.Job)
+	if !ok {
+		return nil, nil
+	}
+	l.mu.Lock()
+	p, ok := l.jobs[jobKey(job)]
+	l.mu.Unlock()
+	if ok {
+		p.kill(false)
+	}
+	return nil, nil
+}
+
+func (l *LocalProcess) Precheck(ctx context.Context, specObj interface{}) (apistructs.ServiceGroupPrecheckData, error) {
+	return apistructs.ServiceGroupPrecheckData{Status: "ok"}, nil
+}
+
+func (l *LocalProcess) SetNodeLabels(setting executortypes.NodeLabelSetting, hosts []string, labels map[string]string) error {
+	return errors.New("SetNodeLabels not implemented in local process executor")
+}
+
+func (l *LocalProcess) CapacityInfo() apistructs.CapacityInfoData {
+	return apistructs.CapacityInfoData{}
+}
+
+func (l *LocalProcess) ResourceInfo(brief bool) (apistructs.ClusterResourceInfoData, error) {
+	return apistructs.ClusterResourceInfoData{}, errors.New("resourceinfo not support for local process executor")
+}
+
+// CleanUpBeforeDelete kills all processes
+func (l *LocalProcess) CleanUpBeforeDelete() {
+	l.mu.Lock()
+	var killing []*process
+	for _, p := range l.jobs {
+		killing = append(killing, p)
+	}
+	for _, group := range l.groups {
+		for _, p := range group {
+			killing = append(killing, p)
+		}
+	}
+	l.jobs = make(map[string]*process)
+	l.groups = make(map[string]map[string]*process)
+	l.mu.Unlock()
+	for _, p := range killing {
+		p.kill(false)
+	}
+}
+
+func (l *LocalProcess) JobVolumeCreate(ctx context.Context, spec interface{}) (string, error) {
+	return "", errors.New("not support for local process executor")
+}
+
+// KillPod kills the process of service, it will be restarted by the supervisor
+func (l *LocalProcess) KillPod(podname string) error {
+	l.mu.Lock()
+	var target *process
+	for _, group := range l.groups {
+		for _, p := range group {
+			if p.spec.name == podname {
+				target = p
+			}
+		}
+	}
+	l.mu.Unlock()
+	if target == nil {
+		return errors.Errorf("process %s not found", podname)
+	}
+	target.kill(true)
+	return nil
+}
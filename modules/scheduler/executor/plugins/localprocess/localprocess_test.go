@@ -0,0 +1,143 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package localprocess
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+func newTestLocalProcess(t *testing.T) *LocalProcess {
+	l, err := New("LOCALFORTEST", map[string]string{optionWorkDir: t.TempDir(), optionOCIRuntime: ociRuntimeNone})
+	assert.NoError(t, err)
+	t.Cleanup(l.CleanUpBeforeDelete)
+	return l
+}
+
+func waitJobStatus(t *testing.T, l *LocalProcess, job apistructs.Job) apistructs.StatusDesc {
+	for i := 0; i < 50; i++ {
+		status, err := l.Status(context.Background(), job)
+		assert.NoError(t, err)
+		if status.Status != apistructs.StatusRunning {
+			return status
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("job is still running")
+	return apistructs.StatusDesc{}
+}
+
+func TestJob(t *testing.T) {
+	l := newTestLocalProcess(t)
+	ctx := context.Background()
+
+	job := apistructs.Job{JobFromUser: apistructs.JobFromUser{
+		Name:      "echo",
+		Namespace: "pipeline-1",
+		Cmd:       "echo $GREETING",
+		Env:       map[string]string{"GREETING": "hello"},
+	}}
+	_, err := l.Create(ctx, job)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.StatusStoppedOnOK, waitJobStatus(t, l, job).Status)
+	stdout, err := ioutil.ReadFile(filepath.Join(l.workDir, "jobs", "pipeline-1", "echo", stdoutLogFile))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\n", string(stdout))
+
+	job.Name = "fail"
+	job.Cmd = "echo oops >&2; exit 3"
+	_, err = l.Create(ctx, job)
+	assert.NoError(t, err)
+	status := waitJobStatus(t, l, job)
+	assert.Equal(t, apistructs.StatusStoppedOnFailed, status.Status)
+	assert.Equal(t, "exit code: 3, oops", status.LastMessage)
+
+	job.Name = "sleep"
+	job.Cmd = "sleep 30"
+	_, err = l.Create(ctx, job)
+	assert.NoError(t, err)
+	_, err = l.Cancel(ctx, job)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.StatusStoppedByKilled, waitJobStatus(t, l, job).Status)
+
+	assert.NoError(t, l.Remove(ctx, job))
+	assert.Equal(t, apistructs.StatusNotFoundInCluster, waitJobStatus(t, l, job).Status)
+}
+
+func TestServiceGroup(t *testing.T) {
+	l := newTestLocalProcess(t)
+	ctx := context.Background()
+
+	sg := apistructs.ServiceGroup{
+		Dice: apistructs.Dice{
+			ID:   "app-1",
+			Type: "runtimes",
+			Services: []apistructs.Service{
+				{Name: "web", Cmd: "sleep 30"},
+				{Name: "worker", Cmd: "exit 1"},
+			},
+		},
+	}
+	_, err := l.Create(ctx, sg)
+	assert.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+	result, err := l.Inspect(ctx, sg)
+	assert.NoError(t, err)
+	inspected := result.(*apistructs.ServiceGroup)
+	assert.Equal(t, apistructs.StatusProgressing, inspected.Status)
+	assert.Equal(t, apistructs.StatusHealthy, inspected.Services[0].Status)
+	assert.Equal(t, apistructs.StatusFailing, inspected.Services[1].Status)
+
+	// fix the worker, web keeps running
+	web := l.groups[groupKey(sg)]["web"]
+	sg.Services[1].Cmd = "sleep 30"
+	_, err = l.Update(ctx, sg)
+	assert.NoError(t, err)
+	assert.True(t, web == l.groups[groupKey(sg)]["web"])
+	status, err := l.Status(ctx, sg)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.StatusHealthy, status.Status)
+
+	// failed update rolls back to the old processes
+	worker := l.groups[groupKey(sg)]["worker"]
+	sg.Services[1].Cmd = ""
+	_, err = l.Update(ctx, sg)
+	assert.Error(t, err)
+	state, _, _ := web.status()
+	assert.Equal(t, processRunning, state)
+	state, _, _ = worker.status()
+	assert.Equal(t, processKilled, state)
+	restored := l.groups[groupKey(sg)]["worker"]
+	assert.True(t, worker != restored)
+	assert.Equal(t, worker.spec, restored.spec)
+	status, err = l.Status(ctx, sg)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.StatusHealthy, status.Status)
+
+	assert.NoError(t, l.Destroy(ctx, sg))
+	state, _, _ = web.status()
+	assert.Equal(t, processKilled, state)
+	status, err = l.Status(ctx, sg)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.StatusNotFoundInCluster, status.Status)
+	_, err = l.Inspect(ctx, sg)
+	assert.Error(t, err)
+}
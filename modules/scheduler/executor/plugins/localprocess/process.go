@@ -0,0 +1,271 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package localprocess
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+const (
+	stdoutLogFile = "stdout.log"
+	stderrLogFile = "stderr.log"
+
+	// lastMessageBytes the size of stderr tail shown in status message
+	lastMessageBytes  = 512
+	maxRestartBackoff = 30 * time.Second
+)
+
+type processState string
+
+const (
+	processRunning    processState = "running"
+	processRestarting processState = "restarting"
+	processExited     processState = "exited"
+	processKilled     processState = "killed"
+)
+
+// processSpec describes how to run a job or a service instance
+type processSpec struct {
+	// name is the container name when running by oci runtime
+	name  string
+	dir   string
+	image string
+	cmd   string
+	env   map[string]string
+	binds []apistructs.Bind
+	cpu   float64
+	// mem in MiB
+	mem float64
+	// restart supervised service processes when they exit
+	restart bool
+}
+
+// process is a supervised local process, stdout and stderr are captured to files under spec.dir
+type process struct {
+	spec       processSpec
+	ociRuntime string
+
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	state     processState
+	exitCode  int
+	restarts  int
+	startedAt time.Time
+	stopped   bool
+	done      chan struct{}
+}
+
+func newProcess(spec processSpec, ociRuntime string) *process {
+	return &process{spec: spec, ociRuntime: ociRuntime, done: make(chan struct{})}
+}
+
+// start runs the process, and keeps restarting it in background if spec.restart
+func (p *process) start() error {
+	if p.ociRuntime == "" && p.spec.cmd == "" {
+		return fmt.Errorf("cmd of %s is required to run as local process without oci runtime", p.spec.name)
+	}
+	if err := os.MkdirAll(p.spec.dir, 0755); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.run(); err != nil {
+		return err
+	}
+	go p.supervise()
+	return nil
+}
+
+// run starts the command once, must be called with p.mu held
+func (p *process) run() error {
+	stdout, err := os.OpenFile(filepath.Join(p.spec.dir, stdoutLogFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	stderr, err := os.OpenFile(filepath.Join(p.spec.dir, stderrLogFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		stdout.Close()
+		return err
+	}
+	cmd := p.command()
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		stdout.Close()
+		stderr.Close()
+		return err
+	}
+	// the child holds its own descriptors after start
+	stdout.Close()
+	stderr.Close()
+	p.cmd = cmd
+	p.state = processRunning
+	p.startedAt = time.Now()
+	return nil
+}
+
+func (p *process) command() *exec.Cmd {
+	if p.ociRuntime == "" {
+		cmd := exec.Command("sh", "-c", p.spec.cmd)
+		cmd.Dir = p.spec.dir
+		cmd.Env = append(os.Environ(), envList(p.spec.env)...)
+		return cmd
+	}
+	args := []string{"run", "--rm", "--name", p.spec.name, "--network", "host"}
+	for _, env := range envList(p.spec.env) {
+		args = append(args, "-e", env)
+	}
+	for _, bind := range p.spec.binds {
+		v := bind.HostPath + ":" + bind.ContainerPath
+		if bind.ReadOnly {
+			v += ":ro"
+		}
+		args = append(args, "-v", v)
+	}
+	if p.spec.cpu > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(p.spec.cpu, 'f', -1, 64))
+	}
+	if p.spec.mem > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%.fm", p.spec.mem))
+	}
+	args = append(args, p.spec.image)
+	if p.spec.cmd != "" {
+		args = append(args, "sh", "-c", p.spec.cmd)
+	}
+	return exec.Command(p.ociRuntime, args...)
+}
+
+func (p *process) supervise() {
+	defer close(p.done)
+	backoff := time.Second
+	for {
+		p.mu.Lock()
+		cmd := p.cmd
+		p.mu.Unlock()
+
+		err := cmd.Wait()
+		exitCode := 0
+		if err != nil {
+			exitCode = -1
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				exitCode = exitErr.ExitCode()
+			}
+		}
+
+		p.mu.Lock()
+		p.exitCode = exitCode
+		if p.stopped {
+			p.state = processKilled
+			p.mu.Unlock()
+			return
+		}
+		if !p.spec.restart {
+			p.state = processExited
+			p.mu.Unlock()
+			return
+		}
+		// reset backoff if it has been running for a while
+		if time.Since(p.startedAt) > maxRestartBackoff {
+			backoff = time.Second
+		}
+		p.state = processRestarting
+		p.restarts++
+		p.mu.Unlock()
+		logrus.Warnf("local process %s exited with code %d, restart in %v", p.spec.name, exitCode, backoff)
+
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+		p.mu.Lock()
+		if p.stopped {
+			p.state = processKilled
+			p.mu.Unlock()
+			return
+		}
+		if err := p.run(); err != nil {
+			logrus.Errorf("failed to restart local process %s, (%v)", p.spec.name, err)
+			p.state = processExited
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+	}
+}
+
+// kill stops the process and its children, and waits for it to exit.
+// A supervised process is restarted if keepRunning.
+func (p *process) kill(keepRunning bool) {
+	p.mu.Lock()
+	if !keepRunning {
+		p.stopped = true
+	}
+	cmd := p.cmd
+	state := p.state
+	p.mu.Unlock()
+
+	if state == processRunning && cmd != nil && cmd.Process != nil {
+		if p.ociRuntime != "" {
+			if out, err := exec.Command(p.ociRuntime, "rm", "-f", p.spec.name).CombinedOutput(); err != nil {
+				logrus.Warnf("failed to remove container %s, %s, (%v)", p.spec.name, string(out), err)
+			}
+		}
+		if err := killProcessGroup(cmd); err != nil {
+			logrus.Warnf("failed to kill local process %s, (%v)", p.spec.name, err)
+		}
+	}
+	if !keepRunning {
+		<-p.done
+	}
+}
+
+func (p *process) status() (processState, int, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state, p.exitCode, p.restarts
+}
+
+// lastMessage returns the tail of stderr
+func (p *process) lastMessage() string {
+	b, err := ioutil.ReadFile(filepath.Join(p.spec.dir, stderrLogFile))
+	if err != nil {
+		return ""
+	}
+	if len(b) > lastMessageBytes {
+		b = b[len(b)-lastMessageBytes:]
+	}
+	return string(bytes.TrimSpace(b))
+}
+
+func envList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for k, v := range env {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
@@ -45,6 +45,11 @@ func (j *JobImpl) Create(create apistructs.JobCreateRequest) (apistructs.Job, er
 		}
 	}
 
+	// schedule runs as k8s cronjob, not supported by bigdata jobs
+	if create.Schedule != nil && create.Kind != string(apistructs.Metronome) && create.Kind != string(apistructs.Kubernetes) {
+		return apistructs.Job{}, errors.Errorf("param [schedule] is not supported by kind %s", create.Kind)
+	}
+
 	// TODO: Mandatory verification of clusterName must be added in the follow-up
 	logrus.Infof("epCreateJob job: %+v", create)
 	job := apistructs.Job{
@@ -0,0 +1,68 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package job
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor"
+	"github.com/erda-project/erda/modules/scheduler/impl/cluster/clusterutil"
+	"github.com/erda-project/erda/modules/scheduler/task"
+)
+
+// CronJob query execution history, trigger or suspend the scheduled job
+func (j *JobImpl) CronJob(namespace, name string, action apistructs.CronJobAction) (apistructs.CronJobStatus, error) {
+	job := apistructs.Job{}
+	ctx := context.Background()
+	if err := j.js.Get(ctx, makeJobKey(namespace, name), &job); err != nil {
+		return apistructs.CronJobStatus{}, err
+	}
+	if job.Schedule == nil {
+		return apistructs.CronJobStatus{}, errors.Errorf("job %s/%s is not a scheduled job", namespace, name)
+	}
+
+	if err := clusterutil.SetJobExecutorByCluster(&job); err != nil {
+		return apistructs.CronJobStatus{}, err
+	}
+	tsk, err := j.sched.Send(ctx, task.TaskRequest{
+		ExecutorKind: executor.GetJobExecutorKindByName(job.Executor),
+		ExecutorName: job.Executor,
+		Action:       task.TaskCronJob,
+		ID:           job.Name,
+		Spec:         task.CronJobSpec{Job: job, Action: action},
+	})
+	if err != nil {
+		return apistructs.CronJobStatus{}, err
+	}
+	result := tsk.Wait(ctx)
+	if result.Err() != nil {
+		return apistructs.CronJobStatus{}, result.Err()
+	}
+	status, ok := result.Extra.(apistructs.CronJobStatus)
+	if !ok {
+		return apistructs.CronJobStatus{}, errors.Errorf("invalid cron job status of job %s/%s", namespace, name)
+	}
+
+	// keep schedule in store consistent with the cron job in cluster
+	if action == apistructs.CronJobSuspend || action == apistructs.CronJobResume {
+		job.Schedule.Suspend = status.Suspend
+		if err := j.js.Put(ctx, makeJobKey(namespace, name), job); err != nil {
+			return apistructs.CronJobStatus{}, err
+		}
+	}
+	return status, nil
+}
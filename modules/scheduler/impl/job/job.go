@@ -49,6 +49,9 @@ type Job interface {
 	Concurrent(namespace string, names []string) ([]apistructs.Job, error)
 
 	CreateJobVolume(apistructs.JobVolume) (string, error)
+
+	// CronJob only for scheduled job
+	CronJob(namespace, name string, action apistructs.CronJobAction) (apistructs.CronJobStatus, error)
 }
 
 type JobImpl struct {
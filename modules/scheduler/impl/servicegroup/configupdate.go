@@ -28,7 +28,7 @@ func (s ServiceGroupImpl) ConfigUpdate(sg apistructs.ServiceGroup) error {
 	sg.LastModifiedTime = time.Now().Unix()
 
 	logrus.Debugf("config update sg: %+v", sg)
-	if err := s.js.Put(context.Background(), mkServiceGroupKey(sg.Type, sg.ID), &sg); err != nil {
+	if err := s.putServiceGroup(context.Background(), &sg); err != nil {
 		return err
 	}
 
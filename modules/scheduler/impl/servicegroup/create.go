@@ -27,7 +27,7 @@ func (s ServiceGroupImpl) Create(req apistructs.ServiceGroupCreateV2Request) (ap
 	if err != nil {
 		return apistructs.ServiceGroup{}, err
 	}
-	if err := s.js.Put(context.Background(), mkServiceGroupKey(sg.Type, sg.ID), sg); err != nil {
+	if err := s.putServiceGroup(context.Background(), &sg); err != nil {
 		return apistructs.ServiceGroup{}, err
 	}
 	sg.Labels = appendServiceTags(sg.Labels, sg.Executor)
@@ -0,0 +1,53 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package servicegroup
+
+import (
+	"context"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/executor/executortypes"
+	"github.com/erda-project/erda/modules/scheduler/task"
+)
+
+// Live returns the spec of the services actually running in cluster,
+// the result is not supported if the executor can not read the live spec of servicegroup
+func (s ServiceGroupImpl) Live(ctx context.Context, namespace string, name string) (apistructs.ServiceGroupLive, error) {
+	sg := apistructs.ServiceGroup{}
+	if err := s.js.Get(context.Background(), mkServiceGroupKey(namespace, name), &sg); err != nil {
+		return apistructs.ServiceGroupLive{}, err
+	}
+	if err := setServiceGroupExecutorByCluster(&sg, s.clusterinfo); err != nil {
+		return apistructs.ServiceGroupLive{}, err
+	}
+	t, err := s.sched.Send(ctx, task.TaskRequest{
+		ExecutorKind: getServiceExecutorKindByName(sg.Executor),
+		ExecutorName: sg.Executor,
+		Action:       task.TaskLive,
+		ID:           sg.ID,
+		Spec:         sg,
+	})
+	if err != nil {
+		return apistructs.ServiceGroupLive{}, err
+	}
+	result := t.Wait(ctx)
+	if result.Err() == executortypes.ErrLiveNotSupported {
+		return apistructs.ServiceGroupLive{}, nil
+	}
+	if result.Err() != nil {
+		return apistructs.ServiceGroupLive{}, result.Err()
+	}
+	live, _ := result.Extra.([]apistructs.ServiceLiveSpec)
+	return apistructs.ServiceGroupLive{Supported: true, Services: live}, nil
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package servicegroup
+
+import (
+	"context"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/task"
+)
+
+// NetworkPolicy computes the networkpolicy graph of the servicegroup in dice.yml, nothing is applied
+func (s ServiceGroupImpl) NetworkPolicy(ctx context.Context, req apistructs.ServiceGroupNetworkPolicyRequest) (apistructs.NetworkPolicyGraph, error) {
+	sg, err := convertServiceGroupCreateV2Request(apistructs.ServiceGroupCreateV2Request(req), s.clusterinfo)
+	if err != nil {
+		return apistructs.NetworkPolicyGraph{}, err
+	}
+	t, err := s.sched.Send(ctx, task.TaskRequest{
+		ExecutorKind: getServiceExecutorKindByName(sg.Executor),
+		ExecutorName: sg.Executor,
+		Action:       task.TaskNetworkPolicy,
+		ID:           sg.ID,
+		Spec:         sg,
+	})
+	if err != nil {
+		return apistructs.NetworkPolicyGraph{}, err
+	}
+	result := t.Wait(ctx)
+	if result.Err() != nil {
+		return apistructs.NetworkPolicyGraph{}, result.Err()
+	}
+	graph, _ := result.Extra.(apistructs.NetworkPolicyGraph)
+	return graph, nil
+}
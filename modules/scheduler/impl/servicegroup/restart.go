@@ -29,7 +29,7 @@ func (s ServiceGroupImpl) Restart(namespace string, name string) error {
 	sg.Extra[LastRestartTimeKey] = time.Now().String()
 	sg.LastModifiedTime = time.Now().Unix()
 
-	if err := s.js.Put(context.Background(), mkServiceGroupKey(sg.Type, sg.ID), &sg); err != nil {
+	if err := s.putServiceGroup(context.Background(), &sg); err != nil {
 		return err
 	}
 
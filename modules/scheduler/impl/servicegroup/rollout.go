@@ -0,0 +1,63 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package servicegroup
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/task"
+)
+
+func (s ServiceGroupImpl) Rollout(ctx context.Context, req apistructs.ServiceGroupRolloutRequest) (apistructs.RolloutStatus, error) {
+	sg := apistructs.ServiceGroup{}
+	if err := s.js.Get(context.Background(), mkServiceGroupKey(req.Namespace, req.Name), &sg); err != nil {
+		return apistructs.RolloutStatus{}, err
+	}
+	found := false
+	for _, svc := range sg.Services {
+		if svc.Name == req.Service {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return apistructs.RolloutStatus{}, errors.Errorf("not found service %s in servicegroup %s/%s", req.Service, req.Namespace, req.Name)
+	}
+	if err := setServiceGroupExecutorByCluster(&sg, s.clusterinfo); err != nil {
+		return apistructs.RolloutStatus{}, err
+	}
+	t, err := s.sched.Send(ctx, task.TaskRequest{
+		ExecutorKind: getServiceExecutorKindByName(sg.Executor),
+		ExecutorName: sg.Executor,
+		Action:       task.TaskRollout,
+		ID:           sg.ID,
+		Spec: task.RolloutSpec{
+			ServiceGroup: sg,
+			Service:      req.Service,
+			Action:       req.Action,
+		},
+	})
+	if err != nil {
+		return apistructs.RolloutStatus{}, err
+	}
+	result := t.Wait(ctx)
+	if result.Err() != nil {
+		return apistructs.RolloutStatus{}, result.Err()
+	}
+	status, _ := result.Extra.(apistructs.RolloutStatus)
+	return status, nil
+}
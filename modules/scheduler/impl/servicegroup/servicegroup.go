@@ -55,6 +55,10 @@ type ServiceGroup interface {
 	Precheck(sg apistructs.ServiceGroupPrecheckRequest) (apistructs.ServiceGroupPrecheckData, error)
 	ConfigUpdate(sg apistructs.ServiceGroup) error
 	KillPod(ctx context.Context, namespace string, name string, podname string) error
+	Rollout(ctx context.Context, req apistructs.ServiceGroupRolloutRequest) (apistructs.RolloutStatus, error)
+	NetworkPolicy(ctx context.Context, req apistructs.ServiceGroupNetworkPolicyRequest) (apistructs.NetworkPolicyGraph, error)
+	Live(ctx context.Context, namespace string, name string) (apistructs.ServiceGroupLive, error)
+	BindTLS(ctx context.Context, req apistructs.ServiceGroupTLSRequest) error
 }
 
 type ServiceGroupImpl struct {
@@ -132,6 +136,34 @@ func getServiceExecutorKindByName(name string) string {
 func mkServiceGroupKey(namespace, name string) string {
 	return filepath.Join("/dice/service", namespace, name)
 }
+
+// putServiceGroup persists servicegroup into etcd, the contents of secret files are redacted
+// and only kept in the k8s secrets created by executor
+func (s ServiceGroupImpl) putServiceGroup(ctx context.Context, sg *apistructs.ServiceGroup) error {
+	return s.js.Put(ctx, mkServiceGroupKey(sg.Type, sg.ID), redactConfigFiles(sg))
+}
+
+// redactConfigFiles returns a copy of servicegroup with the contents of secret files replaced by checksums
+func redactConfigFiles(sg *apistructs.ServiceGroup) *apistructs.ServiceGroup {
+	redacted := *sg
+	redacted.Services = make([]apistructs.Service, len(sg.Services))
+	for i, service := range sg.Services {
+		if len(service.ConfigFiles) > 0 {
+			files := make([]diceyml.ConfigFile, len(service.ConfigFiles))
+			for j, file := range service.ConfigFiles {
+				if file.Secret && !file.Redacted() {
+					file.Checksum = file.ContentChecksum()
+					file.Content = ""
+				}
+				files[j] = file
+			}
+			service.ConfigFiles = files
+		}
+		redacted.Services[i] = service
+	}
+	return &redacted
+}
+
 func validateServiceGroupName(name string) bool {
 	return len(name) > 0 && runtimeFormater.MatchString(name)
 }
@@ -216,6 +248,10 @@ func convertServiceGroup(req apistructs.ServiceGroupCreateV2Request, clusterinfo
 
 	sgServices := []apistructs.Service{}
 	for name, service := range yml.Services {
+		// dice.yml is not validated by scheduler, reject strategy which could not be rolled out
+		if st := service.Deployments.Strategy; st != nil && st.Type == diceyml.StrategyCanary && len(st.CanarySteps) == 0 {
+			return apistructs.ServiceGroup{}, fmt.Errorf("service %s: canary strategy requires canary_steps", name)
+		}
 		binds := []apistructs.ServiceBind{}
 		ymlbinds, err := diceyml.ParseBinds(service.Binds)
 		if err != nil {
@@ -275,6 +311,8 @@ func convertServiceGroup(req apistructs.ServiceGroupCreateV2Request, clusterinfo
 			Labels:           service.Labels,
 			Selectors:        service.Deployments.Selectors,
 			WorkLoad:         service.Deployments.Workload,
+			Autoscaling:      service.Deployments.Autoscaling,
+			Strategy:         service.Deployments.Strategy,
 			DeploymentLabels: service.Deployments.Labels,
 			Binds:            binds,
 			Volumes:          volumes,
@@ -284,6 +322,9 @@ func convertServiceGroup(req apistructs.ServiceGroupCreateV2Request, clusterinfo
 			InitContainer:    service.Init,
 			MeshEnable:       service.MeshEnable,
 			TrafficSecurity:  service.TrafficSecurity,
+			ConfigFiles:      service.Files,
+			DisruptionBudget: service.DisruptionBudget,
+			Lifecycle:        service.Lifecycle,
 		}
 		sgServices = append(sgServices, sgService)
 	}
@@ -308,6 +349,22 @@ func convertHealthcheck(hc diceyml.HealthCheck) *apistructs.NewHealthCheck {
 			Duration: hc.Exec.Duration,
 		}
 	}
+	if hc.TCP != nil && hc.TCP.Port != 0 {
+		nhc.TCPHealthCheck = &apistructs.TCPHealthCheck{
+			Port:     hc.TCP.Port,
+			Duration: hc.TCP.Duration,
+		}
+	}
+	if hc.GRPC != nil && hc.GRPC.Port != 0 {
+		nhc.GRPCHealthCheck = &apistructs.GRPCHealthCheck{
+			Port:     hc.GRPC.Port,
+			Service:  hc.GRPC.Service,
+			Duration: hc.GRPC.Duration,
+		}
+	}
+	nhc.LivenessProbe = hc.Liveness
+	nhc.ReadinessProbe = hc.Readiness
+	nhc.StartupProbe = hc.Startup
 	return &nhc
 }
 
@@ -0,0 +1,45 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package servicegroup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func TestRedactConfigFiles(t *testing.T) {
+	sg := &apistructs.ServiceGroup{Dice: apistructs.Dice{Services: []apistructs.Service{
+		{Name: "web", ConfigFiles: []diceyml.ConfigFile{
+			{Path: "/app/conf/application.yml", Content: "server.port: 8080"},
+			{Path: "/app/certs/tls.key", Content: "key", Secret: true},
+		}},
+		{Name: "worker"},
+	}}}
+	redacted := redactConfigFiles(sg)
+
+	files := redacted.Services[0].ConfigFiles
+	assert.Equal(t, "server.port: 8080", files[0].Content)
+	assert.Empty(t, files[1].Content)
+	assert.True(t, files[1].Redacted())
+	assert.Equal(t, sg.Services[0].ConfigFiles[1].ContentChecksum(), files[1].ContentChecksum())
+	// the servicegroup sent to executor keeps the contents
+	assert.Equal(t, "key", sg.Services[0].ConfigFiles[1].Content)
+
+	// redacting again keeps the checksum
+	assert.Equal(t, files, redactConfigFiles(redacted).Services[0].ConfigFiles)
+}
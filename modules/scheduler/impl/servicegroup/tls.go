@@ -0,0 +1,49 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package servicegroup
+
+import (
+	"context"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/task"
+)
+
+// BindTLS saves the certificate of domain and binds it to the ingresses of the services using the domain
+func (s ServiceGroupImpl) BindTLS(ctx context.Context, req apistructs.ServiceGroupTLSRequest) error {
+	sg := apistructs.ServiceGroup{}
+	if err := s.js.Get(context.Background(), mkServiceGroupKey(req.Namespace, req.Name), &sg); err != nil {
+		return err
+	}
+	if err := setServiceGroupExecutorByCluster(&sg, s.clusterinfo); err != nil {
+		return err
+	}
+	t, err := s.sched.Send(ctx, task.TaskRequest{
+		ExecutorKind: getServiceExecutorKindByName(sg.Executor),
+		ExecutorName: sg.Executor,
+		Action:       task.TaskTLS,
+		ID:           sg.ID,
+		Spec: task.TLSSpec{
+			ServiceGroup: sg,
+			Domain:       req.Domain,
+			Certificate:  req.Certificate,
+			PrivateKey:   req.PrivateKey,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	result := t.Wait(ctx)
+	return result.Err()
+}
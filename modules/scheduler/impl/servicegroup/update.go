@@ -35,7 +35,7 @@ func (s ServiceGroupImpl) Update(req apistructs.ServiceGroupUpdateV2Request) (ap
 	}
 	diffAndPatchRuntime(&sg, &oldSg)
 
-	if err := s.js.Put(context.Background(), mkServiceGroupKey(sg.Type, sg.ID), &oldSg); err != nil {
+	if err := s.putServiceGroup(context.Background(), &oldSg); err != nil {
 		return apistructs.ServiceGroup{}, err
 	}
 
@@ -17,6 +17,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 
 	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/modules/scheduler/schedulepolicy/constraintbuilders/constraints"
@@ -145,3 +146,35 @@ func TestBuildConstraints3(t *testing.T) {
 	assert.True(t, 2 == len(k8scons.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms), "%+v", k8scons)
 	assert.True(t, 1 == len(k8scons.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution), "%+v", k8scons)
 }
+
+func TestBuildSpreadConstraints(t *testing.T) {
+	scheduleinfo := apistructs.ScheduleInfo2{
+		IsUnLocked: true,
+		Spread: map[string]apistructs.SpreadPolicy{
+			"payment": {Topology: "zone", MaxSkew: 1, Required: true},
+		},
+		AntiAffinity: map[string]apistructs.AntiAffinityPolicy{
+			"payment": {Services: []string{"payment-db"}, Topology: "zone", Required: true},
+		},
+	}
+	service := apistructs.Service{Name: "payment"}
+	marathoncons := marathon.Builder{}.Build(&scheduleinfo, &service, nil, nil).(*marathon.Constraints)
+	k8scons := k8s.Builder{}.Build(&scheduleinfo, &service, []constraints.PodLabelsForAffinity{
+		{PodLabels: map[string]string{"app": "payment"}},
+	}, nil).(*k8s.Constraints)
+
+	assert.Equal(t, []string{"zone", "GROUP_BY"}, marathoncons.Cons[len(marathoncons.Cons)-1])
+	assert.Equal(t, 1, len(k8scons.TopologySpreadConstraints))
+	assert.Equal(t, "topology.kubernetes.io/zone", k8scons.TopologySpreadConstraints[0].TopologyKey)
+	assert.Equal(t, corev1.DoNotSchedule, k8scons.TopologySpreadConstraints[0].WhenUnsatisfiable)
+	// app In [payment-db] on zone
+	assert.Equal(t, 1, len(k8scons.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution))
+	assert.Equal(t, []string{"payment-db"}, k8scons.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].LabelSelector.MatchExpressions[0].Values)
+
+	// no constraint for other services
+	other := apistructs.Service{Name: "web"}
+	k8scons = k8s.Builder{}.Build(&scheduleinfo, &other, []constraints.PodLabelsForAffinity{
+		{PodLabels: map[string]string{"app": "web"}},
+	}, nil).(*k8s.Constraints)
+	assert.Equal(t, 0, len(k8scons.TopologySpreadConstraints))
+}
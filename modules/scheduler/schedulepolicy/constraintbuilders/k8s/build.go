@@ -32,6 +32,8 @@ const (
 // Constraints k8s constraints
 type Constraints struct {
 	k8s.Affinity
+	// TopologySpreadConstraints spread replicas of service across topology domains
+	TopologySpreadConstraints []k8s.TopologySpreadConstraint
 }
 
 func (*Constraints) IsConstraints() {}
@@ -87,6 +89,10 @@ func (Builder) Build(s *apistructs.ScheduleInfo2, service *apistructs.Service, p
 	// decentralized service deployments
 	buildServiceAntiAffinity(podLabels, cons)
 
+	// spread replicas across zones or racks, and keep away from the services declared in selectors
+	buildSpread(s.Spread, service, podLabels, cons)
+	buildSelectorAntiAffinity(s.AntiAffinity, service, podLabels, cons)
+
 	buildSpecificHost(s.SpecificHost, cons, hostnameUtil)
 
 	if len(cons.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms) == 0 {
@@ -497,6 +503,88 @@ func isStateless(s *apistructs.ScheduleInfo2) bool {
 	return !s.BigData && !s.Pack && !s.Job && !s.IsPlatform && !s.Stateful && !s.IsDaemonset && s.Stateless
 }
 
+// spreadTopologyKey node label key of topology in dice.yml spread selector
+// zone: topology.kubernetes.io/zone
+// host: kubernetes.io/hostname
+// others, e.g. rack: dice/topology-rack
+func spreadTopologyKey(topology string) string {
+	switch topology {
+	case "zone":
+		return "topology.kubernetes.io/zone"
+	case "host", "":
+		return "kubernetes.io/hostname"
+	default:
+		return strutil.Concat(labelPrefix, "topology-", topology)
+	}
+}
+
+// topologySpreadConstraints:
+// - maxSkew: 1
+//   topologyKey: topology.kubernetes.io/zone
+//   whenUnsatisfiable: DoNotSchedule
+//   labelSelector:
+//     matchLabels:
+//       app: <service>
+func buildSpread(spread map[string]apistructs.SpreadPolicy, service *apistructs.Service, podLabellist []constraints.PodLabelsForAffinity, cons *Constraints) {
+	if service == nil || len(podLabellist) == 0 {
+		return
+	}
+	policy, ok := spread[service.Name]
+	if !ok {
+		return
+	}
+	whenUnsatisfiable := k8s.ScheduleAnyway
+	if policy.Required {
+		whenUnsatisfiable = k8s.DoNotSchedule
+	}
+	for _, podlabels := range podLabellist {
+		cons.TopologySpreadConstraints = append(cons.TopologySpreadConstraints, k8s.TopologySpreadConstraint{
+			MaxSkew:           int32(policy.MaxSkew),
+			TopologyKey:       spreadTopologyKey(policy.Topology),
+			WhenUnsatisfiable: whenUnsatisfiable,
+			LabelSelector:     &v1.LabelSelector{MatchLabels: podlabels.PodLabels},
+		})
+	}
+}
+
+// the services declared in anti-affinity selector are matched by the same label keys as podLabels,
+// e.g. podLabels {"app": "payment"} & anti-affinity "payment-db" => app In [payment-db]
+func buildSelectorAntiAffinity(antiAffinity map[string]apistructs.AntiAffinityPolicy, service *apistructs.Service, podLabellist []constraints.PodLabelsForAffinity, cons *Constraints) {
+	if service == nil || len(podLabellist) == 0 {
+		return
+	}
+	policy, ok := antiAffinity[service.Name]
+	if !ok || len(policy.Services) == 0 {
+		return
+	}
+	preferredTerms := &(cons.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+	requiredTerms := &(cons.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+	for _, podlabels := range podLabellist {
+		for lk := range podlabels.PodLabels {
+			term := k8s.PodAffinityTerm{
+				LabelSelector: &v1.LabelSelector{
+					MatchExpressions: []v1.LabelSelectorRequirement{
+						{
+							Key:      lk,
+							Operator: v1.LabelSelectorOpIn,
+							Values:   policy.Services,
+						},
+					},
+				},
+				TopologyKey: spreadTopologyKey(policy.Topology),
+			}
+			if policy.Required {
+				*requiredTerms = append(*requiredTerms, term)
+			} else {
+				*preferredTerms = append(*preferredTerms, k8s.WeightedPodAffinityTerm{
+					Weight:          100,
+					PodAffinityTerm: term,
+				})
+			}
+		}
+	}
+}
+
 func initConstraints(cons *Constraints) {
 	if cons.Affinity.NodeAffinity != nil {
 		return
@@ -67,9 +67,28 @@ func (Builder) Build(s *apistructs.ScheduleInfo2, service *apistructs.Service, _
 	for _, t := range cons.terms {
 		cons.Cons = append(cons.Cons, t.generate())
 	}
+	buildSpread(s.Spread, service, cons)
 	return cons
 }
 
+// buildSpread spread instances evenly by the attribute of agents with GROUP_BY,
+// marathon has no preferred constraints, so only spread-mode=hard is translated.
+// Anti-affinity between services is not supported by marathon.
+func buildSpread(spread map[string]apistructs.SpreadPolicy, service *apistructs.Service, cons *Constraints) {
+	if service == nil {
+		return
+	}
+	policy, ok := spread[service.Name]
+	if !ok || !policy.Required {
+		return
+	}
+	attribute := policy.Topology
+	if attribute == "host" {
+		attribute = "hostname"
+	}
+	cons.Cons = append(cons.Cons, []string{attribute, "GROUP_BY"})
+}
+
 func buildSpecificHost(specificHosts []string, cons *Constraints) {
 	if len(specificHosts) == 0 {
 		return
@@ -43,6 +43,8 @@ const (
 	EXECUTOR_FLINK = "FLINK"
 	// EXECUTOR_K8SJOB k8sjob
 	EXECUTOR_K8SJOB = "K8SJOB"
+	// EXECUTOR_LOCALPROCESS local process
+	EXECUTOR_LOCALPROCESS = "LOCALPROCESS"
 
 	// ENABLETAG Whether to enable label scheduling
 	ENABLETAG = "ENABLETAG"
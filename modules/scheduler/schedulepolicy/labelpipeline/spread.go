@@ -0,0 +1,66 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package labelpipeline
+
+import (
+	"strconv"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/schedulepolicy/labelconfig"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+// SpreadLabelFilter LabelInfo.Selectors: spread, spread-max-skew, spread-mode, anti-affinity
+func SpreadLabelFilter(
+	r *labelconfig.RawLabelRuleResult, r2 *labelconfig.RawLabelRuleResult2, li *labelconfig.LabelInfo) {
+	for service, selectors := range li.Selectors {
+		required := false
+		if mode, ok := selectors[diceyml.SelectorSpreadMode]; ok && len(mode.Values) > 0 {
+			required = mode.Values[0] == diceyml.SpreadModeHard
+		}
+		topology := "host"
+		if spread, ok := selectors[diceyml.SelectorSpread]; ok && !spread.Not && len(spread.Values) > 0 && spread.Values[0] != "" {
+			topology = spread.Values[0]
+			maxSkew := 1
+			if skew, ok := selectors[diceyml.SelectorSpreadMaxSkew]; ok && len(skew.Values) > 0 {
+				if v, err := strconv.Atoi(skew.Values[0]); err == nil && v > 0 {
+					maxSkew = v
+				}
+			}
+			if r2.Spread == nil {
+				r2.Spread = make(map[string]apistructs.SpreadPolicy)
+			}
+			r2.Spread[service] = apistructs.SpreadPolicy{
+				Topology: topology,
+				MaxSkew:  maxSkew,
+				Required: required,
+			}
+		}
+		if antiAffinity, ok := selectors[diceyml.SelectorAntiAffinity]; ok && !antiAffinity.Not {
+			services := strutil.DedupSlice(antiAffinity.Values, true)
+			if len(services) == 0 {
+				continue
+			}
+			if r2.AntiAffinity == nil {
+				r2.AntiAffinity = make(map[string]apistructs.AntiAffinityPolicy)
+			}
+			r2.AntiAffinity[service] = apistructs.AntiAffinityPolicy{
+				Services: services,
+				Topology: topology,
+				Required: required,
+			}
+		}
+	}
+}
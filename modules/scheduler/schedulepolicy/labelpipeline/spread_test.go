@@ -0,0 +1,49 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package labelpipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/scheduler/schedulepolicy/labelconfig"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+)
+
+func TestSpreadLabelFilter(t *testing.T) {
+	r := labelconfig.RawLabelRuleResult{}
+	r2 := labelconfig.RawLabelRuleResult2{}
+	SpreadLabelFilter(&r, &r2, &labelconfig.LabelInfo{
+		Selectors: map[string]diceyml.Selectors{
+			"payment": {
+				"spread":        diceyml.Selector{Values: []string{"zone"}},
+				"spread-mode":   diceyml.Selector{Values: []string{"hard"}},
+				"anti-affinity": diceyml.Selector{Values: []string{"payment-db"}},
+			},
+			"worker": {
+				"anti-affinity": diceyml.Selector{Values: []string{"payment", "payment"}},
+			},
+			"web": {"location": diceyml.Selector{Values: []string{"xxx"}}},
+		},
+	})
+	assert.Equal(t, map[string]apistructs.SpreadPolicy{
+		"payment": {Topology: "zone", MaxSkew: 1, Required: true},
+	}, r2.Spread)
+	assert.Equal(t, map[string]apistructs.AntiAffinityPolicy{
+		"payment": {Services: []string{"payment-db"}, Topology: "zone", Required: true},
+		"worker":  {Services: []string{"payment"}, Topology: "host"},
+	}, r2.AntiAffinity)
+}
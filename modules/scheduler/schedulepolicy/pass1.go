@@ -53,6 +53,7 @@ func (p *Pass1ScheduleInfo) toNextPass() (Pass2ScheduleInfo, Pass2ScheduleInfo2)
 		labelpipeline.HostUniqueLabelFilter,
 		labelpipeline.SpecificHostLabelFilter,
 		labelpipeline.LocationLabelFilter,
+		labelpipeline.SpreadLabelFilter,
 	} {
 		labelinfo := labelconfig.LabelInfo(*p)
 		f(&result, &result2, &labelinfo)
@@ -182,6 +182,9 @@ func (s *Server) initEndpoints() {
 		{"/v1/job/{namespace}/{name}/delete", http.MethodDelete, s.httpendpoints.JobDelete},
 		{"/v1/jobs", http.MethodDelete, s.httpendpoints.DeleteJobs},
 		{"/v1/job/{namespace}/{name}", http.MethodGet, s.httpendpoints.JobInspect},
+		{"/v1/job/{namespace}/{name}/history", http.MethodGet, s.httpendpoints.JobHistory},
+		{"/v1/job/{namespace}/{name}/trigger", http.MethodPost, s.httpendpoints.JobTrigger},
+		{"/v1/job/{namespace}/{name}/suspend", http.MethodPost, s.httpendpoints.JobSuspend},
 		{"/v1/jobs/{namespace}", http.MethodGet, s.httpendpoints.JobList},
 		{"/api/jobvolume", http.MethodPost, s.httpendpoints.JobVolumeCreate},
 
@@ -227,6 +230,10 @@ func (s *Server) initEndpoints() {
 		{"/api/servicegroup/actions/precheck", http.MethodPost, s.httpendpoints.ServiceGroupPrecheck},
 		{"/api/servicegroup/actions/config", http.MethodPut, s.httpendpoints.ServiceGroupConfigUpdate},
 		{"/api/servicegroup/actions/killpod", http.MethodPost, s.httpendpoints.ServiceGroupKillPod},
+		{"/api/servicegroup/actions/rollout", http.MethodPost, s.httpendpoints.ServiceGroupRollout},
+		{"/api/servicegroup/actions/networkpolicy", http.MethodPost, s.httpendpoints.ServiceGroupNetworkPolicy},
+		{"/api/servicegroup/actions/live", http.MethodGet, s.httpendpoints.ServiceGroupLive},
+		{"/api/servicegroup/actions/tls", http.MethodPut, s.httpendpoints.ServiceGroupTLS},
 
 		// creating cluster by hooking colony-soldier's event
 		{"/clusterhook", http.MethodPost, s.httpendpoints.ClusterHook},
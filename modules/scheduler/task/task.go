@@ -38,6 +38,11 @@ const (
 	TaskPrecheck
 	TaskJobVolumeCreate
 	TaskKillPod
+	TaskRollout
+	TaskCronJob
+	TaskNetworkPolicy
+	TaskLive
+	TaskTLS
 )
 
 var (
@@ -52,6 +57,27 @@ type TaskRequest struct {
 	Action       Action
 }
 
+// RolloutSpec is the spec of TaskRollout
+type RolloutSpec struct {
+	ServiceGroup apistructs.ServiceGroup
+	Service      string
+	Action       apistructs.RolloutAction
+}
+
+// CronJobSpec is the spec of TaskCronJob
+type CronJobSpec struct {
+	Job    apistructs.Job
+	Action apistructs.CronJobAction
+}
+
+// TLSSpec is the spec of TaskTLS
+type TLSSpec struct {
+	ServiceGroup apistructs.ServiceGroup
+	Domain       string
+	Certificate  string
+	PrivateKey   string
+}
+
 type TaskResponse struct {
 	err   error
 	desc  apistructs.StatusDesc
@@ -218,6 +244,95 @@ func (t *Task) Run(ctx context.Context) TaskResponse {
 		return TaskResponse{
 			err: err,
 		}
+	case TaskRollout:
+		rolloutExecutor, ok := executor.(executortypes.RolloutExecutor)
+		if !ok {
+			return TaskResponse{
+				err: errors.Errorf("executor %s not support rollout", executor.Name()),
+			}
+		}
+		spec, ok := t.Spec.(RolloutSpec)
+		if !ok {
+			return TaskResponse{
+				err: BadSpec,
+			}
+		}
+		r, err := rolloutExecutor.Rollout(ctx, &spec.ServiceGroup, spec.Service, spec.Action)
+		return TaskResponse{
+			err:   err,
+			Extra: r,
+		}
+	case TaskCronJob:
+		cronJobExecutor, ok := executor.(executortypes.CronJobExecutor)
+		if !ok {
+			return TaskResponse{
+				err: errors.Errorf("executor %s not support cron job", executor.Name()),
+			}
+		}
+		spec, ok := t.Spec.(CronJobSpec)
+		if !ok {
+			return TaskResponse{
+				err: BadSpec,
+			}
+		}
+		r, err := cronJobExecutor.CronJob(ctx, &spec.Job, spec.Action)
+		return TaskResponse{
+			err:   err,
+			Extra: r,
+		}
+	case TaskNetworkPolicy:
+		networkPolicyExecutor, ok := executor.(executortypes.NetworkPolicyExecutor)
+		if !ok {
+			return TaskResponse{
+				err: errors.Errorf("executor %s not support networkpolicy", executor.Name()),
+			}
+		}
+		sg, ok := t.Spec.(apistructs.ServiceGroup)
+		if !ok {
+			return TaskResponse{
+				err: BadSpec,
+			}
+		}
+		r, err := networkPolicyExecutor.NetworkPolicy(ctx, &sg)
+		return TaskResponse{
+			err:   err,
+			Extra: r,
+		}
+	case TaskLive:
+		liveExecutor, ok := executor.(executortypes.LiveExecutor)
+		if !ok {
+			return TaskResponse{
+				err: executortypes.ErrLiveNotSupported,
+			}
+		}
+		sg, ok := t.Spec.(apistructs.ServiceGroup)
+		if !ok {
+			return TaskResponse{
+				err: BadSpec,
+			}
+		}
+		r, err := liveExecutor.Live(ctx, &sg)
+		return TaskResponse{
+			err:   err,
+			Extra: r,
+		}
+	case TaskTLS:
+		tlsExecutor, ok := executor.(executortypes.TLSExecutor)
+		if !ok {
+			return TaskResponse{
+				err: errors.Errorf("executor %s not support tls", executor.Name()),
+			}
+		}
+		spec, ok := t.Spec.(TLSSpec)
+		if !ok {
+			return TaskResponse{
+				err: BadSpec,
+			}
+		}
+		err := tlsExecutor.BindTLS(ctx, &spec.ServiceGroup, spec.Domain, spec.Certificate, spec.PrivateKey)
+		return TaskResponse{
+			err: err,
+		}
 	default:
 		return TaskResponse{
 			err: errors.Errorf("invlaid action: %d", t.Action),
@@ -278,6 +393,16 @@ func (a *Action) String() string {
 		return "TaskJobVolumeCreate"
 	case TaskKillPod:
 		return "TaskKillPod"
+	case TaskRollout:
+		return "TaskRollout"
+	case TaskCronJob:
+		return "TaskCronJob"
+	case TaskNetworkPolicy:
+		return "TaskNetworkPolicy"
+	case TaskLive:
+		return "TaskLive"
+	case TaskTLS:
+		return "TaskTLS"
 	}
 	panic("unreachable")
 }
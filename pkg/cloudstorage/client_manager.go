@@ -26,7 +26,10 @@ import (
 type Client interface {
 	UploadFile(bucketName, objectName, file string) (string, error)
 	DownloadFile(bucketName, objectName string) ([]byte, error)
+	DownloadToFile(bucketName, objectName, file string) error
 	GetFileUrl(bucketName, objectName string) (string, error)
+	DeleteFile(bucketName, objectName string) error
+	BucketExists(bucketName string) (bool, error)
 	HealthCheck() error
 }
 
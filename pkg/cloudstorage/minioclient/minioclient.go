@@ -86,6 +86,10 @@ func (c *MinioClient) DownloadFile(bucketName, objectName string) ([]byte, error
 	return data, nil
 }
 
+func (c *MinioClient) DownloadToFile(bucketName, objectName, file string) error {
+	return c.client.FGetObject(bucketName, objectName, file, minio.GetObjectOptions{})
+}
+
 func (c *MinioClient) GetFileUrl(bucketName, objectName string) (string, error) {
 	info, err := c.client.StatObject(bucketName, objectName, minio.StatObjectOptions{})
 	if err != nil {
@@ -97,6 +101,17 @@ func (c *MinioClient) GetFileUrl(bucketName, objectName string) (string, error)
 	return strings.Join([]string{c.endpoint, bucketName, objectName}, "/"), nil
 }
 
+func (c *MinioClient) DeleteFile(bucketName, objectName string) error {
+	if err := c.client.RemoveObject(bucketName, objectName); err != nil {
+		return errors.Wrapf(err, "delete bk=%s file=%s", bucketName, objectName)
+	}
+	return nil
+}
+
+func (c *MinioClient) BucketExists(bucketName string) (bool, error) {
+	return c.client.BucketExists(bucketName)
+}
+
 func (c *MinioClient) HealthCheck() error {
 	if _, err := c.client.BucketExists("bucket"); err != nil {
 		return err
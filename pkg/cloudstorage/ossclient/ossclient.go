@@ -85,6 +85,14 @@ func (c *OssClient) DownloadFile(bucketName, objectName string) ([]byte, error)
 	return data, nil
 }
 
+func (c *OssClient) DownloadToFile(bucketName, objectName, file string) error {
+	bucket, err := c.client.Bucket(bucketName)
+	if err != nil {
+		return errors.Wrap(err, "get bucket")
+	}
+	return bucket.GetObjectToFile(objectName, file)
+}
+
 func (c *OssClient) GetFileUrl(bucketName, objectName string) (string, error) {
 	bucket, err := c.client.Bucket(bucketName)
 	if err != nil {
@@ -100,6 +108,21 @@ func (c *OssClient) GetFileUrl(bucketName, objectName string) (string, error) {
 	return strings.Join([]string{c.endpoint, bucketName, objectName}, "/"), nil
 }
 
+func (c *OssClient) DeleteFile(bucketName, objectName string) error {
+	bucket, err := c.client.Bucket(bucketName)
+	if err != nil {
+		return errors.Wrap(err, "get bucket")
+	}
+	return bucket.DeleteObject(objectName)
+}
+
+func (c *OssClient) BucketExists(bucketName string) (bool, error) {
+	return c.client.IsBucketExist(bucketName)
+}
+
 func (c *OssClient) HealthCheck() error {
-	panic("not implement")
+	if _, err := c.client.ListBuckets(oss.MaxKeys(1)); err != nil {
+		return err
+	}
+	return nil
 }
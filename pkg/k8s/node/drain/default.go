@@ -77,12 +77,22 @@ func DrainNode(ctx context.Context, kubeClient kubernetes.Interface, req apistru
 		return errors.Errorf("unable to get node %q: %v", req.NodeName, err)
 	}
 
+	// use the termination grace period of the pods (termination_grace_seconds in dice.yml) unless specified
+	gracePeriodSeconds := -1
+	if req.GracePeriodSeconds > 0 {
+		gracePeriodSeconds = req.GracePeriodSeconds
+	}
 	drainer := &Helper{
 		Client:              kubeClient,
 		Force:               req.Force,
 		IgnoreAllDaemonSets: req.IgnoreAllDaemonSets,
 		DeleteLocalData:     req.DeleteLocalData,
-		GracePeriodSeconds:  -1,
+		GracePeriodSeconds:  gracePeriodSeconds,
+		PodSelector:         req.PodSelector,
+		// eviction honours the pod disruption budgets (disruption_budget in dice.yml),
+		// deletion is only used when eviction is disabled or not supported
+		DisableEviction:                 req.DisableEviction,
+		SkipWaitForDeleteTimeoutSeconds: req.SkipWaitForDeleteTimeoutSeconds,
 		// If a pod is not evicted in 20 seconds, retry the eviction next time the
 		// machine gets reconciled again (to allow other machines to be reconciled).
 		Timeout: req.Timeout * time.Second,
@@ -26,6 +26,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
@@ -298,12 +299,47 @@ func (d *Helper) deletePods(ctx context.Context, pods []corev1.Pod, getPodFn fun
 	} else {
 		globalTimeout = d.Timeout
 	}
+	// deleting pods bypasses the disruption budgets, so pods protected by budgets are deleted one by one,
+	// each of them is deleted only after the budgets allow one more disruption
+	var budgetedPods []corev1.Pod
 	for _, pod := range pods {
+		pdbs, err := d.matchingPDBs(ctx, pod)
+		if err != nil {
+			return err
+		}
+		if len(pdbs) > 0 {
+			budgetedPods = append(budgetedPods, pod)
+			continue
+		}
+		err = d.DeletePod(ctx, pod)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	for _, pod := range budgetedPods {
+		if err := d.waitForDisruptionAllowed(ctx, pod, globalTimeout); err != nil {
+			return fmt.Errorf("error when waiting for disruption budget of pod %q: %v", pod.Name, err)
+		}
 		err := d.DeletePod(ctx, pod)
 		if err != nil && !apierrors.IsNotFound(err) {
 			return err
 		}
+		if _, err := waitForDelete(waitForDeleteParams{
+			ctx:                             ctx,
+			pods:                            []corev1.Pod{pod},
+			interval:                        1 * time.Second,
+			timeout:                         globalTimeout,
+			usingEviction:                   false,
+			getPodFn:                        getPodFn,
+			onDoneFn:                        d.OnPodDeletedOrEvicted,
+			globalTimeout:                   globalTimeout,
+			skipWaitForDeleteTimeoutSeconds: d.SkipWaitForDeleteTimeoutSeconds,
+			out:                             d.Out,
+		}); err != nil {
+			return err
+		}
 	}
+	pods = subtractPods(pods, budgetedPods)
 	params := waitForDeleteParams{
 		ctx:                             ctx,
 		pods:                            pods,
@@ -320,6 +356,67 @@ func (d *Helper) deletePods(ctx context.Context, pods []corev1.Pod, getPodFn fun
 	return err
 }
 
+// matchingPDBs returns the pod disruption budgets selecting the pod
+func (d *Helper) matchingPDBs(ctx context.Context, pod corev1.Pod) ([]policyv1beta1.PodDisruptionBudget, error) {
+	pdbList, err := d.Client.PolicyV1beta1().PodDisruptionBudgets(pod.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var pdbs []policyv1beta1.PodDisruptionBudget
+	for _, pdb := range pdbList.Items {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+		// an empty selector matches nothing in policy/v1beta1
+		if selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		pdbs = append(pdbs, pdb)
+	}
+	return pdbs, nil
+}
+
+// waitForDisruptionAllowed waits until all the disruption budgets of the pod allow one more disruption
+func (d *Helper) waitForDisruptionAllowed(ctx context.Context, pod corev1.Pod, timeout time.Duration) error {
+	return wait.PollImmediate(5*time.Second, timeout, func() (bool, error) {
+		pdbs, err := d.matchingPDBs(ctx, pod)
+		if err != nil {
+			return false, err
+		}
+		for _, pdb := range pdbs {
+			if pdb.Status.DisruptionsAllowed <= 0 {
+				fmt.Fprintf(d.ErrOut, "cannot delete pod %q, disruption budget %q does not allow more disruptions (will retry after 5s)\n", pod.Name, pdb.Name)
+				select {
+				case <-ctx.Done():
+					return false, fmt.Errorf("global timeout reached: %v", timeout)
+				default:
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	})
+}
+
+// subtractPods returns the pods not in excluded
+func subtractPods(pods, excluded []corev1.Pod) []corev1.Pod {
+	if len(excluded) == 0 {
+		return pods
+	}
+	uids := make(map[types.UID]struct{}, len(excluded))
+	for _, pod := range excluded {
+		uids[pod.UID] = struct{}{}
+	}
+	var result []corev1.Pod
+	for _, pod := range pods {
+		if _, ok := uids[pod.UID]; !ok {
+			result = append(result, pod)
+		}
+	}
+	return result
+}
+
 func waitForDelete(params waitForDeleteParams) ([]corev1.Pod, error) {
 	pods := params.pods
 	err := wait.PollImmediate(params.interval, params.timeout, func() (bool, error) {
@@ -0,0 +1,66 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package drain
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMatchingPDBs(t *testing.T) {
+	minAvailable := intstr.FromInt(1)
+	client := fake.NewSimpleClientset(
+		&policyv1beta1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: policyv1beta1.PodDisruptionBudgetSpec{
+				MinAvailable: &minAvailable,
+				Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			},
+			Status: policyv1beta1.PodDisruptionBudgetStatus{DisruptionsAllowed: 0},
+		},
+		&policyv1beta1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "default"},
+			Spec:       policyv1beta1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{}},
+		},
+	)
+	d := &Helper{Client: client, Out: ioutil.Discard, ErrOut: ioutil.Discard}
+	ctx := context.Background()
+
+	web := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", UID: "1", Labels: map[string]string{"app": "web"}}}
+	api := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "default", UID: "2", Labels: map[string]string{"app": "api"}}}
+
+	pdbs, err := d.matchingPDBs(ctx, web)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(pdbs))
+	assert.Equal(t, "web", pdbs[0].Name)
+
+	pdbs, err = d.matchingPDBs(ctx, api)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(pdbs))
+
+	// the budget does not allow any disruption
+	assert.Error(t, d.waitForDisruptionAllowed(ctx, web, time.Second))
+	assert.NoError(t, d.waitForDisruptionAllowed(ctx, api, time.Second))
+
+	assert.Equal(t, []corev1.Pod{api}, subtractPods([]corev1.Pod{web, api}, []corev1.Pod{web}))
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package kms
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+)
+
+// auditPlugin wrap plugin, record audit event for every operation into store,
+// and forward to external audit system if forwarder is set.
+// Scheduled tasks are executed by kms itself, not audited.
+type auditPlugin struct {
+	kmstypes.Plugin
+	store     kmstypes.Store
+	forwarder kmstypes.AuditForwarder
+}
+
+func newAuditPlugin(plugin kmstypes.Plugin, store kmstypes.Store, forwarder kmstypes.AuditForwarder) kmstypes.Plugin {
+	return &auditPlugin{Plugin: plugin, store: store, forwarder: forwarder}
+}
+
+// audit record audit event, failure of recording is logged and not returned, the operation has already been done
+func (p *auditPlugin) audit(ctx context.Context, operation kmstypes.AuditOperation, keyID, keyVersionID string, err error) {
+	event := kmstypes.AuditEvent{
+		RequestID:    kmstypes.GetRequestID(ctx),
+		Caller:       kmstypes.GetCaller(ctx),
+		Operation:    operation,
+		PluginKind:   p.Kind(),
+		KeyID:        keyID,
+		KeyVersionID: keyVersionID,
+		Success:      err == nil,
+		OperatedAt:   time.Now(),
+	}
+	if err != nil {
+		event.ErrorMsg = err.Error()
+	}
+	if err := p.store.CreateAuditEvent(event); err != nil {
+		logrus.Errorf("[alert] failed to record kms audit event, event: %+v, err: %v", event, err)
+	}
+	if p.forwarder != nil {
+		if err := p.forwarder.Forward(event); err != nil {
+			logrus.Errorf("failed to forward kms audit event, event: %+v, err: %v", event, err)
+		}
+	}
+}
+
+func (p *auditPlugin) CreateKey(ctx context.Context, req *kmstypes.CreateKeyRequest) (*kmstypes.CreateKeyResponse, error) {
+	resp, err := p.Plugin.CreateKey(ctx, req)
+	var keyID, keyVersionID string
+	if resp != nil {
+		keyID, keyVersionID = resp.KeyMetadata.KeyID, resp.KeyMetadata.PrimaryKeyVersionID
+	}
+	p.audit(ctx, kmstypes.AuditOperationCreateKey, keyID, keyVersionID, err)
+	return resp, err
+}
+
+func (p *auditPlugin) DescribeKey(ctx context.Context, req *kmstypes.DescribeKeyRequest) (*kmstypes.DescribeKeyResponse, error) {
+	resp, err := p.Plugin.DescribeKey(ctx, req)
+	p.audit(ctx, kmstypes.AuditOperationDescribeKey, req.KeyID, "", err)
+	return resp, err
+}
+
+func (p *auditPlugin) ListKeys(ctx context.Context, req *kmstypes.ListKeysRequest) (*kmstypes.ListKeysResponse, error) {
+	resp, err := p.Plugin.ListKeys(ctx, req)
+	p.audit(ctx, kmstypes.AuditOperationListKeys, "", "", err)
+	return resp, err
+}
+
+func (p *auditPlugin) EnableKey(ctx context.Context, req *kmstypes.EnableKeyRequest) (*kmstypes.EnableKeyResponse, error) {
+	resp, err := p.Plugin.EnableKey(ctx, req)
+	p.audit(ctx, kmstypes.AuditOperationEnableKey, req.KeyID, "", err)
+	return resp, err
+}
+
+func (p *auditPlugin) DisableKey(ctx context.Context, req *kmstypes.DisableKeyRequest) (*kmstypes.DisableKeyResponse, error) {
+	resp, err := p.Plugin.DisableKey(ctx, req)
+	p.audit(ctx, kmstypes.AuditOperationDisableKey, req.KeyID, "", err)
+	return resp, err
+}
+
+func (p *auditPlugin) ScheduleKeyDeletion(ctx context.Context, req *kmstypes.ScheduleKeyDeletionRequest) (*kmstypes.ScheduleKeyDeletionResponse, error) {
+	resp, err := p.Plugin.ScheduleKeyDeletion(ctx, req)
+	p.audit(ctx, kmstypes.AuditOperationScheduleKeyDeletion, req.KeyID, "", err)
+	return resp, err
+}
+
+func (p *auditPlugin) CancelKeyDeletion(ctx context.Context, req *kmstypes.CancelKeyDeletionRequest) (*kmstypes.CancelKeyDeletionResponse, error) {
+	resp, err := p.Plugin.CancelKeyDeletion(ctx, req)
+	p.audit(ctx, kmstypes.AuditOperationCancelKeyDeletion, req.KeyID, "", err)
+	return resp, err
+}
+
+func (p *auditPlugin) UpdateKeyRotationPolicy(ctx context.Context, req *kmstypes.UpdateKeyRotationPolicyRequest) (*kmstypes.UpdateKeyRotationPolicyResponse, error) {
+	resp, err := p.Plugin.UpdateKeyRotationPolicy(ctx, req)
+	p.audit(ctx, kmstypes.AuditOperationUpdateKeyRotationPolicy, req.KeyID, "", err)
+	return resp, err
+}
+
+func (p *auditPlugin) Encrypt(ctx context.Context, req *kmstypes.EncryptRequest) (*kmstypes.EncryptResponse, error) {
+	resp, err := p.Plugin.Encrypt(ctx, req)
+	var keyVersionID string
+	if resp != nil {
+		keyVersionID = resp.KeyVersionID
+	}
+	p.audit(ctx, kmstypes.AuditOperationEncrypt, req.KeyID, keyVersionID, err)
+	return resp, err
+}
+
+func (p *auditPlugin) Decrypt(ctx context.Context, req *kmstypes.DecryptRequest) (*kmstypes.DecryptResponse, error) {
+	resp, err := p.Plugin.Decrypt(ctx, req)
+	var keyVersionID string
+	if resp != nil {
+		keyVersionID = resp.KeyVersionID
+	}
+	p.audit(ctx, kmstypes.AuditOperationDecrypt, req.KeyID, keyVersionID, err)
+	return resp, err
+}
+
+func (p *auditPlugin) GenerateDataKey(ctx context.Context, req *kmstypes.GenerateDataKeyRequest) (*kmstypes.GenerateDataKeyResponse, error) {
+	resp, err := p.Plugin.GenerateDataKey(ctx, req)
+	var keyVersionID string
+	if resp != nil {
+		keyVersionID = resp.KeyVersionID
+	}
+	p.audit(ctx, kmstypes.AuditOperationGenerateDataKey, req.KeyID, keyVersionID, err)
+	return resp, err
+}
+
+func (p *auditPlugin) RotateKeyVersion(ctx context.Context, req *kmstypes.RotateKeyVersionRequest) (*kmstypes.RotateKeyVersionResponse, error) {
+	resp, err := p.Plugin.RotateKeyVersion(ctx, req)
+	var keyVersionID string
+	if resp != nil {
+		keyVersionID = resp.KeyMetadata.PrimaryKeyVersionID
+	}
+	p.audit(ctx, kmstypes.AuditOperationRotateKeyVersion, req.KeyID, keyVersionID, err)
+	return resp, err
+}
+
+// ReEncrypt record audit events for both source key and destination key
+func (p *auditPlugin) ReEncrypt(ctx context.Context, req *kmstypes.ReEncryptRequest) (*kmstypes.ReEncryptResponse, error) {
+	resp, err := p.Plugin.ReEncrypt(ctx, req)
+	var sourceKeyVersionID, keyVersionID string
+	if resp != nil {
+		sourceKeyVersionID, keyVersionID = resp.SourceKeyVersionID, resp.KeyVersionID
+	}
+	p.audit(ctx, kmstypes.AuditOperationReEncrypt, req.KeyID, sourceKeyVersionID, err)
+	if req.DestinationKeyID != "" && req.DestinationKeyID != req.KeyID {
+		p.audit(ctx, kmstypes.AuditOperationReEncrypt, req.DestinationKeyID, keyVersionID, err)
+	}
+	return resp, err
+}
+
+func (p *auditPlugin) GetPublicKey(ctx context.Context, req *kmstypes.GetPublicKeyRequest) (*kmstypes.PublicKey, error) {
+	resp, err := p.Plugin.GetPublicKey(ctx, req)
+	keyVersionID := req.KeyVersionID
+	if resp != nil {
+		keyVersionID = resp.KeyVersionID
+	}
+	p.audit(ctx, kmstypes.AuditOperationGetPublicKey, req.KeyID, keyVersionID, err)
+	return resp, err
+}
+
+func (p *auditPlugin) AsymmetricDecrypt(ctx context.Context, req *kmstypes.AsymmetricDecryptRequest) (*kmstypes.AsymmetricDecryptResponse, error) {
+	resp, err := p.Plugin.AsymmetricDecrypt(ctx, req)
+	keyVersionID := req.KeyVersionID
+	if resp != nil {
+		keyVersionID = resp.KeyVersionID
+	}
+	p.audit(ctx, kmstypes.AuditOperationAsymmetricDecrypt, req.KeyID, keyVersionID, err)
+	return resp, err
+}
+
+func (p *auditPlugin) Sign(ctx context.Context, req *kmstypes.SignRequest) (*kmstypes.SignResponse, error) {
+	resp, err := p.Plugin.Sign(ctx, req)
+	keyVersionID := req.KeyVersionID
+	if resp != nil {
+		keyVersionID = resp.KeyVersionID
+	}
+	p.audit(ctx, kmstypes.AuditOperationSign, req.KeyID, keyVersionID, err)
+	return resp, err
+}
+
+func (p *auditPlugin) Verify(ctx context.Context, req *kmstypes.VerifyRequest) (*kmstypes.VerifyResponse, error) {
+	resp, err := p.Plugin.Verify(ctx, req)
+	keyVersionID := req.KeyVersionID
+	if resp != nil {
+		keyVersionID = resp.KeyVersionID
+	}
+	p.audit(ctx, kmstypes.AuditOperationVerify, req.KeyID, keyVersionID, err)
+	return resp, err
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package kms
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+)
+
+// fakePlugin only implements operations used in test
+type fakePlugin struct {
+	kmstypes.Plugin
+}
+
+func (p *fakePlugin) Kind() kmstypes.PluginKind { return kmstypes.PluginKind_DICE_KMS }
+
+func (p *fakePlugin) Encrypt(ctx context.Context, req *kmstypes.EncryptRequest) (*kmstypes.EncryptResponse, error) {
+	return &kmstypes.EncryptResponse{KeyID: req.KeyID, KeyVersionID: "v1"}, nil
+}
+
+func (p *fakePlugin) Decrypt(ctx context.Context, req *kmstypes.DecryptRequest) (*kmstypes.DecryptResponse, error) {
+	return nil, fmt.Errorf("broken ciphertext")
+}
+
+func (p *fakePlugin) ReEncrypt(ctx context.Context, req *kmstypes.ReEncryptRequest) (*kmstypes.ReEncryptResponse, error) {
+	return &kmstypes.ReEncryptResponse{
+		SourceKeyID: req.KeyID, SourceKeyVersionID: "v1", KeyID: req.DestinationKeyID, KeyVersionID: "v2",
+	}, nil
+}
+
+// fakeAuditStore only implements audit operations
+type fakeAuditStore struct {
+	kmstypes.Store
+	events []kmstypes.AuditEvent
+}
+
+func (s *fakeAuditStore) CreateAuditEvent(event kmstypes.AuditEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+type fakeForwarder struct {
+	events []kmstypes.AuditEvent
+}
+
+func (f *fakeForwarder) Forward(event kmstypes.AuditEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func TestAuditPlugin(t *testing.T) {
+	store := &fakeAuditStore{}
+	forwarder := &fakeForwarder{}
+	plugin := newAuditPlugin(&fakePlugin{}, store, forwarder)
+
+	ctx := context.WithValue(context.Background(), kmstypes.CtxKeyKmsRequestID, "req-1")
+	ctx = context.WithValue(ctx, kmstypes.CtxKeyKmsCaller, &kmstypes.Caller{InternalClient: "orchestrator", ClientIP: "10.0.0.1"})
+
+	before := time.Now()
+	_, err := plugin.Encrypt(ctx, &kmstypes.EncryptRequest{KeyID: "k1"})
+	assert.NoError(t, err)
+	_, err = plugin.Decrypt(ctx, &kmstypes.DecryptRequest{KeyID: "k1"})
+	assert.Error(t, err)
+	_, err = plugin.ReEncrypt(ctx, &kmstypes.ReEncryptRequest{KeyID: "k1", DestinationKeyID: "k2"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 4, len(store.events))
+	assert.Equal(t, store.events, forwarder.events)
+
+	encrypt := store.events[0]
+	assert.Equal(t, "req-1", encrypt.RequestID)
+	assert.Equal(t, "orchestrator", encrypt.Caller.InternalClient)
+	assert.Equal(t, "10.0.0.1", encrypt.Caller.ClientIP)
+	assert.Equal(t, kmstypes.AuditOperationEncrypt, encrypt.Operation)
+	assert.Equal(t, kmstypes.PluginKind_DICE_KMS, encrypt.PluginKind)
+	assert.Equal(t, "k1", encrypt.KeyID)
+	assert.Equal(t, "v1", encrypt.KeyVersionID)
+	assert.True(t, encrypt.Success)
+	assert.False(t, encrypt.OperatedAt.Before(before))
+
+	decrypt := store.events[1]
+	assert.Equal(t, kmstypes.AuditOperationDecrypt, decrypt.Operation)
+	assert.False(t, decrypt.Success)
+	assert.Equal(t, "broken ciphertext", decrypt.ErrorMsg)
+
+	// source and destination key
+	assert.Equal(t, "k1", store.events[2].KeyID)
+	assert.Equal(t, "v1", store.events[2].KeyVersionID)
+	assert.Equal(t, "k2", store.events[3].KeyID)
+	assert.Equal(t, "v2", store.events[3].KeyVersionID)
+}
+
+func TestListAuditEventsRequestMatch(t *testing.T) {
+	now := time.Now()
+	event := kmstypes.AuditEvent{
+		Caller:     kmstypes.Caller{UserID: "1"},
+		Operation:  kmstypes.AuditOperationDecrypt,
+		KeyID:      "k1",
+		Success:    true,
+		OperatedAt: now,
+	}
+	req := kmstypes.ListAuditEventsRequest{KeyID: "k1", Operation: kmstypes.AuditOperationDecrypt, UserID: "1"}
+	assert.NoError(t, req.ValidateRequest())
+	assert.Equal(t, 1, req.PageNo)
+	assert.Equal(t, 20, req.PageSize)
+	assert.True(t, req.Match(event))
+
+	assert.False(t, (&kmstypes.ListAuditEventsRequest{KeyID: "k2"}).Match(event))
+	assert.False(t, (&kmstypes.ListAuditEventsRequest{OnlyFailed: true}).Match(event))
+	assert.False(t, (&kmstypes.ListAuditEventsRequest{StartTime: now.Unix() + 1}).Match(event))
+	assert.False(t, (&kmstypes.ListAuditEventsRequest{EndTime: now.Unix() - 1}).Match(event))
+	assert.Error(t, (&kmstypes.ListAuditEventsRequest{StartTime: 2, EndTime: 1}).ValidateRequest())
+}
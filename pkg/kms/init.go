@@ -19,4 +19,5 @@ import (
 
 	// stores
 	_ "github.com/erda-project/erda/pkg/kms/stores/etcd"
+	_ "github.com/erda-project/erda/pkg/kms/stores/mysql"
 )
@@ -32,9 +32,12 @@ type Manager struct {
 
 	storeFactory map[kmstypes.StoreKind]kmstypes.StoreCreateFn
 	stores       map[kmstypes.StoreKind]kmstypes.Store
+	storesLock   sync.Mutex
 
 	pluginCtx context.Context
 	storeCtx  context.Context
+
+	auditForwarder kmstypes.AuditForwarder
 }
 
 func GetManager(ops ...Option) (*Manager, error) {
@@ -59,6 +62,13 @@ func WithStoreConfigs(configs map[string]string) Option {
 	}
 }
 
+// WithAuditForwarder forward audit events to external audit system
+func WithAuditForwarder(forwarder kmstypes.AuditForwarder) Option {
+	return func(mgr *Manager) {
+		mgr.auditForwarder = forwarder
+	}
+}
+
 func (m *Manager) initialize(ops ...Option) error {
 	initOnce.Do(func() {
 		m.pluginCtx = context.Background()
@@ -76,12 +86,9 @@ func (m *Manager) initialize(ops ...Option) error {
 			m.plugins[kind] = createFn(m.pluginCtx)
 		}
 
-		// store
+		// store, created when first used, so only configs of the used store are required
 		m.storeFactory = kmstypes.StoreFactory
 		m.stores = make(map[kmstypes.StoreKind]kmstypes.Store)
-		for kind, createFn := range m.storeFactory {
-			m.stores[kind] = createFn(m.storeCtx)
-		}
 	})
 	return nil
 }
@@ -100,13 +107,24 @@ func (m *Manager) GetPlugin(pluginKind kmstypes.PluginKind, storeKind kmstypes.S
 	}
 	plugin.SetStore(store)
 
-	return plugin, nil
+	// every operation of plugin is audited
+	return newAuditPlugin(plugin, store, m.auditForwarder), nil
 }
 
 func (m *Manager) GetStore(storeKind kmstypes.StoreKind) (kmstypes.Store, error) {
-	store, ok := m.stores[storeKind]
-	if !ok || store == nil {
+	m.storesLock.Lock()
+	defer m.storesLock.Unlock()
+	if store, ok := m.stores[storeKind]; ok {
+		return store, nil
+	}
+	createFn, ok := m.storeFactory[storeKind]
+	if !ok {
 		return nil, fmt.Errorf("not found store kind: %s", storeKind)
 	}
+	store := createFn(m.storeCtx)
+	if store == nil {
+		return nil, fmt.Errorf("failed to create store kind: %s", storeKind)
+	}
+	m.stores[storeKind] = store
 	return store, nil
 }
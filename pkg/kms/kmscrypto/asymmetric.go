@@ -0,0 +1,168 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package kmscrypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" // #nosec G505
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"math/big"
+)
+
+// GenerateRsaKey generate rsa private key with given bits, return PKCS#8 DER encoded private key.
+func GenerateRsaKey(bits int) ([]byte, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+	return x509.MarshalPKCS8PrivateKey(privateKey)
+}
+
+// GenerateEcP256Key generate NIST P-256 ecdsa private key, return PKCS#8 DER encoded private key.
+func GenerateEcP256Key() ([]byte, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return x509.MarshalPKCS8PrivateKey(privateKey)
+}
+
+// ParsePrivateKey parse PKCS#8 DER encoded rsa or ecdsa private key.
+func ParsePrivateKey(der []byte) (crypto.Signer, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("not supported private key type: %T", key)
+	}
+}
+
+// PublicKeyPem return PEM encoded PKIX public key of the given PKCS#8 DER encoded private key.
+func PublicKeyPem(privateKeyDER []byte) (string, error) {
+	signer, err := ParsePrivateKey(privateKeyDER)
+	if err != nil {
+		return "", err
+	}
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})), nil
+}
+
+// ParsePublicKeyPem parse PEM encoded PKIX public key.
+func ParsePublicKeyPem(publicKeyPem string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPem))
+	if block == nil {
+		return nil, fmt.Errorf("invalid public key pem")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// RsaOaepEncrypt encrypt plaintext by rsa public key using RSAES-OAEP, hash can be crypto.SHA1 or crypto.SHA256.
+func RsaOaepEncrypt(publicKey *rsa.PublicKey, hashFunc crypto.Hash, plaintext []byte) ([]byte, error) {
+	h, err := oaepHash(hashFunc)
+	if err != nil {
+		return nil, err
+	}
+	return rsa.EncryptOAEP(h, rand.Reader, publicKey, plaintext, nil)
+}
+
+// RsaOaepDecrypt decrypt ciphertext by PKCS#8 DER encoded rsa private key using RSAES-OAEP.
+func RsaOaepDecrypt(privateKeyDER []byte, hashFunc crypto.Hash, ciphertext []byte) ([]byte, error) {
+	signer, err := ParsePrivateKey(privateKeyDER)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := signer.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not rsa private key")
+	}
+	h, err := oaepHash(hashFunc)
+	if err != nil {
+		return nil, err
+	}
+	return rsa.DecryptOAEP(h, rand.Reader, privateKey, ciphertext, nil)
+}
+
+func oaepHash(hashFunc crypto.Hash) (hash.Hash, error) {
+	switch hashFunc {
+	case crypto.SHA1:
+		return sha1.New(), nil // #nosec G401
+	case crypto.SHA256:
+		return sha256.New(), nil
+	default:
+		return nil, fmt.Errorf("not supported oaep hash: %v", hashFunc)
+	}
+}
+
+// SignDigest sign SHA-256 digest by PKCS#8 DER encoded private key.
+// pss only works for rsa key, ecdsa signature is ASN.1 DER encoded.
+func SignDigest(privateKeyDER []byte, digest []byte, pss bool) ([]byte, error) {
+	if len(digest) != sha256.Size {
+		return nil, fmt.Errorf("invalid SHA-256 digest length: %d", len(digest))
+	}
+	signer, err := ParsePrivateKey(privateKeyDER)
+	if err != nil {
+		return nil, err
+	}
+	var opts crypto.SignerOpts = crypto.SHA256
+	if pss {
+		if _, ok := signer.(*rsa.PrivateKey); !ok {
+			return nil, fmt.Errorf("pss only supported by rsa key")
+		}
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+	}
+	return signer.Sign(rand.Reader, digest, opts)
+}
+
+// VerifyDigest verify signature of SHA-256 digest by public key.
+func VerifyDigest(publicKey crypto.PublicKey, digest, signature []byte, pss bool) (bool, error) {
+	switch k := publicKey.(type) {
+	case *rsa.PublicKey:
+		var err error
+		if pss {
+			err = rsa.VerifyPSS(k, crypto.SHA256, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			err = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, signature)
+		}
+		return err == nil, nil
+	case *ecdsa.PublicKey:
+		if pss {
+			return false, fmt.Errorf("pss only supported by rsa key")
+		}
+		var sig struct{ R, S *big.Int }
+		if rest, err := asn1.Unmarshal(signature, &sig); err != nil || len(rest) != 0 {
+			return false, nil
+		}
+		return ecdsa.Verify(k, digest, sig.R, sig.S), nil
+	default:
+		return false, fmt.Errorf("not supported public key type: %T", publicKey)
+	}
+}
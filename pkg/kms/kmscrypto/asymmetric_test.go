@@ -0,0 +1,80 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package kmscrypto
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRsaOaep(t *testing.T) {
+	privateKey, err := GenerateRsaKey(2048)
+	assert.NoError(t, err)
+	publicKeyPem, err := PublicKeyPem(privateKey)
+	assert.NoError(t, err)
+	publicKey, err := ParsePublicKeyPem(publicKeyPem)
+	assert.NoError(t, err)
+
+	plaintext := []byte("hello world")
+	for _, hash := range []crypto.Hash{crypto.SHA1, crypto.SHA256} {
+		ciphertext, err := RsaOaepEncrypt(publicKey.(*rsa.PublicKey), hash, plaintext)
+		assert.NoError(t, err)
+		decrypted, err := RsaOaepDecrypt(privateKey, hash, ciphertext)
+		assert.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	}
+
+	_, err = RsaOaepDecrypt(privateKey, crypto.MD5, []byte("x"))
+	assert.Error(t, err)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	rsaKey, err := GenerateRsaKey(2048)
+	assert.NoError(t, err)
+	ecKey, err := GenerateEcP256Key()
+	assert.NoError(t, err)
+	digest := sha256.Sum256([]byte("release artifact"))
+	otherDigest := sha256.Sum256([]byte("tampered artifact"))
+
+	cases := []struct {
+		key []byte
+		pss bool
+	}{
+		{rsaKey, true},
+		{rsaKey, false},
+		{ecKey, false},
+	}
+	for _, c := range cases {
+		signature, err := SignDigest(c.key, digest[:], c.pss)
+		assert.NoError(t, err)
+		signer, err := ParsePrivateKey(c.key)
+		assert.NoError(t, err)
+
+		valid, err := VerifyDigest(signer.Public(), digest[:], signature, c.pss)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+		valid, err = VerifyDigest(signer.Public(), otherDigest[:], signature, c.pss)
+		assert.NoError(t, err)
+		assert.False(t, valid)
+	}
+
+	_, err = SignDigest(ecKey, digest[:], true)
+	assert.Error(t, err)
+	_, err = SignDigest(rsaKey, []byte("not a digest"), false)
+	assert.Error(t, err)
+}
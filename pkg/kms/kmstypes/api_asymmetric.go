@@ -13,19 +13,142 @@
 
 package kmstypes
 
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
 type AsymmetricDecryptRequest struct {
-	KeyID            string `json:"keyID,omitempty"`
-	CiphertextBase64 []byte `json:"ciphertextBase64,omitempty"`
+	KeyID string `json:"keyID,omitempty"`
+	// Optional. Use primary key version if empty.
+	KeyVersionID string `json:"keyVersionID,omitempty"`
+	// Optional. Default is RSAES_OAEP_SHA_256.
+	Algorithm AsymmetricAlgorithm `json:"algorithm,omitempty"`
+	// The data encrypted by public key.
+	// A base64-encoded string.
+	CiphertextBase64 string `json:"ciphertextBase64,omitempty"`
+}
+
+func (req *AsymmetricDecryptRequest) ValidateRequest() error {
+	if req.KeyID == "" {
+		return fmt.Errorf("missing keyID")
+	}
+	if req.Algorithm == "" {
+		req.Algorithm = AsymmetricAlgorithm_RSAES_OAEP_SHA_256
+	}
+	if len(req.CiphertextBase64) == 0 {
+		return fmt.Errorf("missing ciphertextBase64")
+	}
+	if _, err := base64.StdEncoding.DecodeString(req.CiphertextBase64); err != nil {
+		return fmt.Errorf("cannot decode base64 ciphertext, err: %v", err)
+	}
+	return nil
 }
+
 type AsymmetricDecryptResponse struct {
-	PlaintextBase64 []byte `json:"plaintextBase64,omitempty"`
+	KeyID           string `json:"keyID,omitempty"`
+	KeyVersionID    string `json:"keyVersionID,omitempty"`
+	PlaintextBase64 string `json:"plaintextBase64,omitempty"`
 }
 
 type GetPublicKeyRequest struct {
 	KeyID string `json:"keyID,omitempty"`
+	// Optional. Use primary key version if empty.
+	KeyVersionID string `json:"keyVersionID,omitempty"`
+}
+
+func (req *GetPublicKeyRequest) ValidateRequest() error {
+	if req.KeyID == "" {
+		return fmt.Errorf("missing keyID")
+	}
+	return nil
 }
 
 type PublicKey struct {
-	Pem       string `json:"pem,omitempty"`
-	Algorithm string `json:"algorithm,omitempty"`
+	KeyID        string `json:"keyID,omitempty"`
+	KeyVersionID string `json:"keyVersionID,omitempty"`
+	// PEM encoded PKIX public key
+	Pem                   string                `json:"pem,omitempty"`
+	CustomerMasterKeySpec CustomerMasterKeySpec `json:"customerMasterKeySpec,omitempty"`
+	KeyUsage              KeyUsage              `json:"keyUsage,omitempty"`
+	// Algorithms supported by the key
+	Algorithms []AsymmetricAlgorithm `json:"algorithms,omitempty"`
+}
+
+type SignRequest struct {
+	KeyID string `json:"keyID,omitempty"`
+	// Optional. Use primary key version if empty.
+	KeyVersionID string `json:"keyVersionID,omitempty"`
+	// Optional. Default is RSA_PSS_SHA_256 for rsa key and ECDSA_SHA_256 for ec key.
+	Algorithm AsymmetricAlgorithm `json:"algorithm,omitempty"`
+	// Required. SHA-256 digest of the message to sign.
+	// A base64-encoded string.
+	DigestBase64 string `json:"digestBase64,omitempty"`
+}
+
+func (req *SignRequest) ValidateRequest() error {
+	if req.KeyID == "" {
+		return fmt.Errorf("missing keyID")
+	}
+	return validateDigestBase64(req.DigestBase64)
+}
+
+type SignResponse struct {
+	KeyID           string              `json:"keyID,omitempty"`
+	KeyVersionID    string              `json:"keyVersionID,omitempty"`
+	Algorithm       AsymmetricAlgorithm `json:"algorithm,omitempty"`
+	SignatureBase64 string              `json:"signatureBase64,omitempty"`
+}
+
+type VerifyRequest struct {
+	KeyID string `json:"keyID,omitempty"`
+	// Required. The key version returned by Sign.
+	KeyVersionID string `json:"keyVersionID,omitempty"`
+	// Optional. Default is RSA_PSS_SHA_256 for rsa key and ECDSA_SHA_256 for ec key.
+	Algorithm AsymmetricAlgorithm `json:"algorithm,omitempty"`
+	// Required. SHA-256 digest of the signed message.
+	// A base64-encoded string.
+	DigestBase64 string `json:"digestBase64,omitempty"`
+	// Required. A base64-encoded string.
+	SignatureBase64 string `json:"signatureBase64,omitempty"`
+}
+
+func (req *VerifyRequest) ValidateRequest() error {
+	if req.KeyID == "" {
+		return fmt.Errorf("missing keyID")
+	}
+	if req.KeyVersionID == "" {
+		return fmt.Errorf("missing keyVersionID")
+	}
+	if err := validateDigestBase64(req.DigestBase64); err != nil {
+		return err
+	}
+	if len(req.SignatureBase64) == 0 {
+		return fmt.Errorf("missing signatureBase64")
+	}
+	if _, err := base64.StdEncoding.DecodeString(req.SignatureBase64); err != nil {
+		return fmt.Errorf("cannot decode base64 signature, err: %v", err)
+	}
+	return nil
+}
+
+type VerifyResponse struct {
+	KeyID        string `json:"keyID,omitempty"`
+	KeyVersionID string `json:"keyVersionID,omitempty"`
+	Valid        bool   `json:"valid"`
+}
+
+func validateDigestBase64(digestBase64 string) error {
+	if len(digestBase64) == 0 {
+		return fmt.Errorf("missing digestBase64")
+	}
+	digest, err := base64.StdEncoding.DecodeString(digestBase64)
+	if err != nil {
+		return fmt.Errorf("cannot decode base64 digest, err: %v", err)
+	}
+	if len(digest) != sha256.Size {
+		return fmt.Errorf("invalid SHA-256 digest length: %d", len(digest))
+	}
+	return nil
 }
@@ -33,10 +33,17 @@ const (
 	CustomerMasterKeySpec_ASYMMETRIC_RSA_2048 CustomerMasterKeySpec = "RSA_2048"
 	CustomerMasterKeySpec_ASYMMETRIC_RSA_3072 CustomerMasterKeySpec = "RSA_3072"
 	CustomerMasterKeySpec_ASYMMETRIC_RSA_4096 CustomerMasterKeySpec = "RSA_4096"
+	CustomerMasterKeySpec_ASYMMETRIC_EC_P256  CustomerMasterKeySpec = "EC_P256"
 
 	KeyUsage_ENCRYPT_DECRYPT KeyUsage = "ENCRYPT_DECRYPT"
 	KeyUsage_SIGN_VERIFY     KeyUsage = "SIGN_VERIFY"
 
+	AsymmetricAlgorithm_RSAES_OAEP_SHA_1   AsymmetricAlgorithm = "RSAES_OAEP_SHA_1"
+	AsymmetricAlgorithm_RSAES_OAEP_SHA_256 AsymmetricAlgorithm = "RSAES_OAEP_SHA_256" // default for encrypt/decrypt
+	AsymmetricAlgorithm_RSA_PSS_SHA_256    AsymmetricAlgorithm = "RSA_PSS_SHA_256"    // default for rsa sign/verify
+	AsymmetricAlgorithm_RSA_PKCS1_SHA_256  AsymmetricAlgorithm = "RSA_PKCS1_SHA_256"
+	AsymmetricAlgorithm_ECDSA_SHA_256      AsymmetricAlgorithm = "ECDSA_SHA_256" // default for ec sign/verify
+
 	KeyStateEnabled         KeyState = "Enabled"
 	KeyStateDisabled        KeyState = "Disabled"
 	KeyStatePendingDeletion KeyState = "PendingDeletion"
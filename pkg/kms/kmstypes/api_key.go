@@ -13,14 +13,38 @@
 
 package kmstypes
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type (
 	CustomerMasterKeySpec string
 	KeyUsage              string
 	KeyState              string
+	AsymmetricAlgorithm   string
 )
 
+// IsSymmetric return whether key spec is symmetric
+func (spec CustomerMasterKeySpec) IsSymmetric() bool {
+	return spec == CustomerMasterKeySpec_SYMMETRIC_DEFAULT
+}
+
+// IsRSA return whether key spec is rsa
+func (spec CustomerMasterKeySpec) IsRSA() bool {
+	switch spec {
+	case CustomerMasterKeySpec_ASYMMETRIC_RSA_2048, CustomerMasterKeySpec_ASYMMETRIC_RSA_3072, CustomerMasterKeySpec_ASYMMETRIC_RSA_4096:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsEC return whether key spec is elliptic curve
+func (spec CustomerMasterKeySpec) IsEC() bool {
+	return spec == CustomerMasterKeySpec_ASYMMETRIC_EC_P256
+}
+
 type (
 	KeyMetadata struct {
 		KeyID                 string                `json:"keyID,omitempty"`
@@ -29,6 +53,9 @@ type (
 		KeyUsage              KeyUsage              `json:"keyUsage,omitempty"`
 		KeyState              KeyState              `json:"keyState,omitempty"`
 		Description           string                `json:"description,omitempty"`
+		RotationPeriodDays    int                   `json:"rotationPeriodDays,omitempty"`
+		NextRotationAt        *time.Time            `json:"nextRotationAt,omitempty"`
+		DeletionDate          *time.Time            `json:"deletionDate,omitempty"`
 	}
 
 	KeyListEntry struct {
@@ -52,6 +79,9 @@ func (req *CreateKeyRequest) ValidateRequest() error {
 	}
 	if req.KeyUsage == "" {
 		req.KeyUsage = KeyUsage_ENCRYPT_DECRYPT
+		if req.CustomerMasterKeySpec.IsEC() {
+			req.KeyUsage = KeyUsage_SIGN_VERIFY
+		}
 	}
 	return nil
 }
@@ -0,0 +1,116 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package kmstypes
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	MinPendingWindowInDays     = 7
+	MaxPendingWindowInDays     = 30
+	DefaultPendingWindowInDays = 30
+
+	MaxRotationPeriodDays = 3650
+)
+
+type EnableKeyRequest struct {
+	KeyID string `json:"keyID,omitempty"`
+}
+
+func (req *EnableKeyRequest) ValidateRequest() error {
+	if req.KeyID == "" {
+		return fmt.Errorf("missing keyID")
+	}
+	return nil
+}
+
+type EnableKeyResponse struct {
+	KeyMetadata KeyMetadata `json:"keyMetadata,omitempty"`
+}
+
+type DisableKeyRequest struct {
+	KeyID string `json:"keyID,omitempty"`
+}
+
+func (req *DisableKeyRequest) ValidateRequest() error {
+	if req.KeyID == "" {
+		return fmt.Errorf("missing keyID")
+	}
+	return nil
+}
+
+type DisableKeyResponse struct {
+	KeyMetadata KeyMetadata `json:"keyMetadata,omitempty"`
+}
+
+type ScheduleKeyDeletionRequest struct {
+	KeyID string `json:"keyID,omitempty"`
+	// PendingWindowInDays the waiting period before key is deleted, between 7 and 30, default 30
+	PendingWindowInDays int `json:"pendingWindowInDays,omitempty"`
+}
+
+func (req *ScheduleKeyDeletionRequest) ValidateRequest() error {
+	if req.KeyID == "" {
+		return fmt.Errorf("missing keyID")
+	}
+	if req.PendingWindowInDays == 0 {
+		req.PendingWindowInDays = DefaultPendingWindowInDays
+	}
+	if req.PendingWindowInDays < MinPendingWindowInDays || req.PendingWindowInDays > MaxPendingWindowInDays {
+		return fmt.Errorf("pendingWindowInDays must be between %d and %d", MinPendingWindowInDays, MaxPendingWindowInDays)
+	}
+	return nil
+}
+
+type ScheduleKeyDeletionResponse struct {
+	KeyID        string     `json:"keyID,omitempty"`
+	DeletionDate *time.Time `json:"deletionDate,omitempty"`
+}
+
+type CancelKeyDeletionRequest struct {
+	KeyID string `json:"keyID,omitempty"`
+}
+
+func (req *CancelKeyDeletionRequest) ValidateRequest() error {
+	if req.KeyID == "" {
+		return fmt.Errorf("missing keyID")
+	}
+	return nil
+}
+
+type CancelKeyDeletionResponse struct {
+	KeyMetadata KeyMetadata `json:"keyMetadata,omitempty"`
+}
+
+type UpdateKeyRotationPolicyRequest struct {
+	KeyID string `json:"keyID,omitempty"`
+	// RotationPeriodDays period of automatic key rotation, 0 means disable automatic rotation
+	RotationPeriodDays int `json:"rotationPeriodDays"`
+}
+
+func (req *UpdateKeyRotationPolicyRequest) ValidateRequest() error {
+	if req.KeyID == "" {
+		return fmt.Errorf("missing keyID")
+	}
+	if req.RotationPeriodDays < 0 || req.RotationPeriodDays > MaxRotationPeriodDays {
+		return fmt.Errorf("rotationPeriodDays must be between 0 and %d", MaxRotationPeriodDays)
+	}
+	return nil
+}
+
+type UpdateKeyRotationPolicyResponse struct {
+	KeyMetadata KeyMetadata `json:"keyMetadata,omitempty"`
+}
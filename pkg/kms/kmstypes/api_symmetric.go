@@ -39,7 +39,8 @@ func (req *EncryptRequest) ValidateRequest() error {
 }
 
 type EncryptResponse struct {
-	KeyID string `json:"keyID,omitempty"`
+	KeyID        string `json:"keyID,omitempty"`
+	KeyVersionID string `json:"keyVersionID,omitempty"`
 	// The encrypted data.
 	// A base64-encoded string.
 	CiphertextBase64 string `json:"ciphertextBase64,omitempty"`
@@ -66,5 +67,45 @@ func (req *DecryptRequest) ValidateRequest() error {
 }
 
 type DecryptResponse struct {
+	// KeyVersionID is the key version used to decrypt
+	KeyVersionID    string `json:"keyVersionID,omitempty"`
 	PlaintextBase64 string `json:"plaintextBase64,omitempty"`
 }
+
+type ReEncryptRequest struct {
+	// KeyID is the CMK used to decrypt ciphertext
+	KeyID string `json:"keyID,omitempty"`
+	// The encrypted data.
+	// A base64-encoded string.
+	CiphertextBase64 string `json:"ciphertextBase64,omitempty"`
+	// DestinationKeyID is the CMK used to encrypt again, default is KeyID
+	DestinationKeyID string `json:"destinationKeyID,omitempty"`
+}
+
+func (req *ReEncryptRequest) ValidateRequest() error {
+	if req.KeyID == "" {
+		return fmt.Errorf("missing keyID")
+	}
+	if len(req.CiphertextBase64) == 0 {
+		return fmt.Errorf("missing ciphertextBase64")
+	}
+	if _, err := base64.StdEncoding.DecodeString(req.CiphertextBase64); err != nil {
+		return fmt.Errorf("cannot decode base64 ciphertext, err: %v", err)
+	}
+	if req.DestinationKeyID == "" {
+		req.DestinationKeyID = req.KeyID
+	}
+	return nil
+}
+
+type ReEncryptResponse struct {
+	SourceKeyID string `json:"sourceKeyID,omitempty"`
+	// SourceKeyVersionID is the key version of source CMK used to decrypt
+	SourceKeyVersionID string `json:"sourceKeyVersionID,omitempty"`
+	KeyID              string `json:"keyID,omitempty"`
+	// KeyVersionID is the key version of destination CMK used to encrypt
+	KeyVersionID string `json:"keyVersionID,omitempty"`
+	// The re-encrypted data.
+	// A base64-encoded string.
+	CiphertextBase64 string `json:"ciphertextBase64,omitempty"`
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package kmstypes
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// CtxKeyKmsCaller is the context key of *Caller
+	CtxKeyKmsCaller = "KmsCaller"
+)
+
+type AuditOperation string
+
+const (
+	AuditOperationCreateKey               AuditOperation = "CreateKey"
+	AuditOperationDescribeKey             AuditOperation = "DescribeKey"
+	AuditOperationListKeys                AuditOperation = "ListKeys"
+	AuditOperationEnableKey               AuditOperation = "EnableKey"
+	AuditOperationDisableKey              AuditOperation = "DisableKey"
+	AuditOperationScheduleKeyDeletion     AuditOperation = "ScheduleKeyDeletion"
+	AuditOperationCancelKeyDeletion       AuditOperation = "CancelKeyDeletion"
+	AuditOperationUpdateKeyRotationPolicy AuditOperation = "UpdateKeyRotationPolicy"
+	AuditOperationEncrypt                 AuditOperation = "Encrypt"
+	AuditOperationDecrypt                 AuditOperation = "Decrypt"
+	AuditOperationGenerateDataKey         AuditOperation = "GenerateDataKey"
+	AuditOperationRotateKeyVersion        AuditOperation = "RotateKeyVersion"
+	AuditOperationReEncrypt               AuditOperation = "ReEncrypt"
+	AuditOperationGetPublicKey            AuditOperation = "GetPublicKey"
+	AuditOperationAsymmetricDecrypt       AuditOperation = "AsymmetricDecrypt"
+	AuditOperationSign                    AuditOperation = "Sign"
+	AuditOperationVerify                  AuditOperation = "Verify"
+)
+
+// Caller is the identity of kms api caller
+type Caller struct {
+	UserID         string `json:"userID,omitempty"`
+	InternalClient string `json:"internalClient,omitempty"`
+	ClientIP       string `json:"clientIP,omitempty"`
+}
+
+// GetCaller return caller from context, return empty caller if not exist
+func GetCaller(ctx context.Context) Caller {
+	if caller, ok := ctx.Value(CtxKeyKmsCaller).(*Caller); ok && caller != nil {
+		return *caller
+	}
+	return Caller{}
+}
+
+// GetRequestID return kms request id from context
+func GetRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(CtxKeyKmsRequestID).(string)
+	return requestID
+}
+
+// AuditEvent 记录一次 kms 操作：谁在什么时间使用哪个密钥做了什么操作，以及操作结果
+type AuditEvent struct {
+	RequestID    string         `json:"requestID,omitempty"`
+	Caller       Caller         `json:"caller"`
+	Operation    AuditOperation `json:"operation"`
+	PluginKind   PluginKind     `json:"pluginKind,omitempty"`
+	KeyID        string         `json:"keyID,omitempty"`
+	KeyVersionID string         `json:"keyVersionID,omitempty"`
+	Success      bool           `json:"success"`
+	ErrorMsg     string         `json:"errorMsg,omitempty"`
+	OperatedAt   time.Time      `json:"operatedAt"`
+}
+
+type ListAuditEventsRequest struct {
+	KeyID          string         `json:"keyID,omitempty" schema:"keyID"`
+	Operation      AuditOperation `json:"operation,omitempty" schema:"operation"`
+	UserID         string         `json:"userID,omitempty" schema:"userID"`
+	InternalClient string         `json:"internalClient,omitempty" schema:"internalClient"`
+	// OnlyFailed only list failed operations
+	OnlyFailed bool `json:"onlyFailed,omitempty" schema:"onlyFailed"`
+	// StartTime and EndTime are unix timestamps in seconds, 0 means no limit
+	StartTime int64 `json:"startTime,omitempty" schema:"startTime"`
+	EndTime   int64 `json:"endTime,omitempty" schema:"endTime"`
+	PageNo    int   `json:"pageNo,omitempty" schema:"pageNo"`
+	PageSize  int   `json:"pageSize,omitempty" schema:"pageSize"`
+}
+
+func (req *ListAuditEventsRequest) ValidateRequest() error {
+	if req.PageNo <= 0 {
+		req.PageNo = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		return fmt.Errorf("pageSize must be no more than 100")
+	}
+	if req.EndTime > 0 && req.StartTime > req.EndTime {
+		return fmt.Errorf("startTime must be before endTime")
+	}
+	return nil
+}
+
+// Match return whether event matches the filter conditions of request
+func (req *ListAuditEventsRequest) Match(event AuditEvent) bool {
+	if req.KeyID != "" && event.KeyID != req.KeyID {
+		return false
+	}
+	if req.Operation != "" && event.Operation != req.Operation {
+		return false
+	}
+	if req.UserID != "" && event.Caller.UserID != req.UserID {
+		return false
+	}
+	if req.InternalClient != "" && event.Caller.InternalClient != req.InternalClient {
+		return false
+	}
+	if req.OnlyFailed && event.Success {
+		return false
+	}
+	if req.StartTime > 0 && event.OperatedAt.Unix() < req.StartTime {
+		return false
+	}
+	if req.EndTime > 0 && event.OperatedAt.Unix() > req.EndTime {
+		return false
+	}
+	return true
+}
+
+type ListAuditEventsResponse struct {
+	Total int          `json:"total"`
+	List  []AuditEvent `json:"list"`
+}
+
+// AuditForwarder forward audit events to external audit system
+type AuditForwarder interface {
+	Forward(event AuditEvent) error
+}
@@ -40,6 +40,16 @@ type KeyInfo interface {
 	GetDescription() string
 	SetDescription(string)
 
+	// GetRotationPeriodDays return period of automatic key rotation, 0 means automatic rotation is disabled
+	GetRotationPeriodDays() int
+	SetRotationPeriodDays(int)
+	GetNextRotationAt() *time.Time
+	SetNextRotationAt(*time.Time)
+
+	// GetDeletionDate return the date key will be deleted, only set when key state is PendingDeletion
+	GetDeletionDate() *time.Time
+	SetDeletionDate(*time.Time)
+
 	GetCreatedAt() *time.Time
 	SetCreatedAt(time.Time)
 	GetUpdatedAt() *time.Time
@@ -54,9 +64,20 @@ func GetKeyMetadata(keyInfo KeyInfo) KeyMetadata {
 		KeyUsage:              keyInfo.GetKeyUsage(),
 		KeyState:              keyInfo.GetKeyState(),
 		Description:           keyInfo.GetDescription(),
+		RotationPeriodDays:    keyInfo.GetRotationPeriodDays(),
+		NextRotationAt:        keyInfo.GetNextRotationAt(),
+		DeletionDate:          keyInfo.GetDeletionDate(),
 	}
 }
 
+// CheckKeyEnabled check key state is Enabled, only enabled key can be used in cryptographic operations
+func CheckKeyEnabled(keyInfo KeyInfo) error {
+	if keyInfo.GetKeyState() != KeyStateEnabled {
+		return fmt.Errorf("key %s is %s", keyInfo.GetKeyID(), keyInfo.GetKeyState())
+	}
+	return nil
+}
+
 type KeyVersionInfo interface {
 	New() KeyVersionInfo
 
@@ -66,6 +87,10 @@ type KeyVersionInfo interface {
 	GetSymmetricKeyBase64() string
 	SetSymmetricKeyBase64(string)
 
+	// GetPrivateKeyBase64 return base64 encoded PKCS#8 private key of asymmetric key version
+	GetPrivateKeyBase64() string
+	SetPrivateKeyBase64(string)
+
 	GetCreatedAt() *time.Time
 	SetCreatedAt(time.Time)
 
@@ -103,8 +128,13 @@ type Key struct {
 	KeyUsage          KeyUsage              `json:"keyUsage,omitempty"`
 	KeyState          KeyState              `json:"keyState,omitempty"`
 	Description       string                `json:"description,omitempty"`
-	CreatedAt         *time.Time            `json:"createdAt,omitempty"`
-	UpdatedAt         *time.Time            `json:"updatedAt,omitempty"`
+	// 自动轮转周期（天），0 表示不自动轮转
+	RotationPeriodDays int        `json:"rotationPeriodDays,omitempty"`
+	NextRotationAt     *time.Time `json:"nextRotationAt,omitempty"`
+	// 计划删除时间，仅在 PendingDeletion 状态下有值
+	DeletionDate *time.Time `json:"deletionDate,omitempty"`
+	CreatedAt    *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt    *time.Time `json:"updatedAt,omitempty"`
 }
 
 func (k *Key) New() KeyInfo                          { return &Key{} }
@@ -120,6 +150,12 @@ func (k *Key) GetKeyState() KeyState                 { return k.KeyState }
 func (k *Key) SetKeyState(state KeyState)            { k.KeyState = state }
 func (k *Key) GetDescription() string                { return k.Description }
 func (k *Key) SetDescription(desc string)            { k.Description = desc }
+func (k *Key) GetRotationPeriodDays() int            { return k.RotationPeriodDays }
+func (k *Key) SetRotationPeriodDays(days int)        { k.RotationPeriodDays = days }
+func (k *Key) GetNextRotationAt() *time.Time         { return k.NextRotationAt }
+func (k *Key) SetNextRotationAt(t *time.Time)        { k.NextRotationAt = t }
+func (k *Key) GetDeletionDate() *time.Time           { return k.DeletionDate }
+func (k *Key) SetDeletionDate(t *time.Time)          { k.DeletionDate = t }
 func (k *Key) GetCreatedAt() *time.Time              { return k.CreatedAt }
 func (k *Key) SetCreatedAt(t time.Time)              { k.CreatedAt = &t }
 func (k *Key) GetUpdatedAt() *time.Time              { return k.UpdatedAt }
@@ -129,6 +165,7 @@ func (k *Key) SetPrimaryKeyVersion(version KeyVersionInfo) {
 	k.PrimaryKeyVersion = KeyVersion{
 		VersionID:          version.GetVersionID(),
 		SymmetricKeyBase64: version.GetSymmetricKeyBase64(),
+		PrivateKeyBase64:   version.GetPrivateKeyBase64(),
 		CreatedAt:          version.GetCreatedAt(),
 		UpdatedAt:          version.GetUpdatedAt(),
 	}
@@ -137,9 +174,11 @@ func (k *Key) SetPrimaryKeyVersion(version KeyVersionInfo) {
 type KeyVersion struct {
 	VersionID string `json:"versionID,omitempty"`
 	// base64 encoded
-	SymmetricKeyBase64 string     `json:"symmetricKeyBase64,omitempty"`
-	CreatedAt          *time.Time `json:"createdAt,omitempty"`
-	UpdatedAt          *time.Time `json:"updatedAt,omitempty"`
+	SymmetricKeyBase64 string `json:"symmetricKeyBase64,omitempty"`
+	// base64 encoded PKCS#8 private key, only for asymmetric key
+	PrivateKeyBase64 string     `json:"privateKeyBase64,omitempty"`
+	CreatedAt        *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt        *time.Time `json:"updatedAt,omitempty"`
 }
 
 func (k *KeyVersion) New() KeyVersionInfo            { return &KeyVersion{} }
@@ -147,7 +186,44 @@ func (k *KeyVersion) GetVersionID() string           { return k.VersionID }
 func (k *KeyVersion) SetVersionID(s string)          { k.VersionID = s }
 func (k *KeyVersion) GetSymmetricKeyBase64() string  { return k.SymmetricKeyBase64 }
 func (k *KeyVersion) SetSymmetricKeyBase64(s string) { k.SymmetricKeyBase64 = s }
+func (k *KeyVersion) GetPrivateKeyBase64() string    { return k.PrivateKeyBase64 }
+func (k *KeyVersion) SetPrivateKeyBase64(s string)   { k.PrivateKeyBase64 = s }
 func (k *KeyVersion) GetCreatedAt() *time.Time       { return k.CreatedAt }
 func (k *KeyVersion) SetCreatedAt(t time.Time)       { k.CreatedAt = &t }
 func (k *KeyVersion) GetUpdatedAt() *time.Time       { return k.UpdatedAt }
 func (k *KeyVersion) SetUpdatedAt(t time.Time)       { k.UpdatedAt = &t }
+
+// CheckKeySpecAndUsage check whether key spec supports the key usage
+func CheckKeySpecAndUsage(spec CustomerMasterKeySpec, usage KeyUsage) error {
+	switch {
+	case spec.IsSymmetric():
+		if usage != KeyUsage_ENCRYPT_DECRYPT {
+			return fmt.Errorf("key spec %s only supports key usage %s", spec, KeyUsage_ENCRYPT_DECRYPT)
+		}
+	case spec.IsRSA():
+		if usage != KeyUsage_ENCRYPT_DECRYPT && usage != KeyUsage_SIGN_VERIFY {
+			return fmt.Errorf("not supported key usage: %s", usage)
+		}
+	case spec.IsEC():
+		if usage != KeyUsage_SIGN_VERIFY {
+			return fmt.Errorf("key spec %s only supports key usage %s", spec, KeyUsage_SIGN_VERIFY)
+		}
+	default:
+		return fmt.Errorf("not supported key spec: %s", spec)
+	}
+	return nil
+}
+
+// GetAsymmetricAlgorithms return algorithms supported by asymmetric key spec and usage
+func GetAsymmetricAlgorithms(spec CustomerMasterKeySpec, usage KeyUsage) []AsymmetricAlgorithm {
+	switch {
+	case spec.IsRSA() && usage == KeyUsage_ENCRYPT_DECRYPT:
+		return []AsymmetricAlgorithm{AsymmetricAlgorithm_RSAES_OAEP_SHA_256, AsymmetricAlgorithm_RSAES_OAEP_SHA_1}
+	case spec.IsRSA() && usage == KeyUsage_SIGN_VERIFY:
+		return []AsymmetricAlgorithm{AsymmetricAlgorithm_RSA_PSS_SHA_256, AsymmetricAlgorithm_RSA_PKCS1_SHA_256}
+	case spec.IsEC() && usage == KeyUsage_SIGN_VERIFY:
+		return []AsymmetricAlgorithm{AsymmetricAlgorithm_ECDSA_SHA_256}
+	default:
+		return nil
+	}
+}
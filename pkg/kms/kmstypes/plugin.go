@@ -16,12 +16,14 @@ package kmstypes
 
 import (
 	"context"
+	"time"
 )
 
 type Plugin interface {
 	Kind() PluginKind
 	SetStore(Store)
 	BasePlugin
+	KeyLifecyclePlugin
 	SymmetricPlugin
 	AsymmetricPlugin
 }
@@ -33,6 +35,27 @@ type BasePlugin interface {
 	ListKeys(ctx context.Context, req *ListKeysRequest) (*ListKeysResponse, error)
 }
 
+// KeyLifecyclePlugin 密钥生命周期管理插件
+// 密钥状态：
+// Enabled -> Disabled: DisableKey
+// Disabled -> Enabled: EnableKey
+// Enabled/Disabled -> PendingDeletion: ScheduleKeyDeletion，等待期结束后由 ExecuteScheduledTasks 删除
+// PendingDeletion -> Disabled: CancelKeyDeletion
+// 只有 Enabled 状态的密钥可以进行加解密、签名等操作
+type KeyLifecyclePlugin interface {
+	EnableKey(ctx context.Context, req *EnableKeyRequest) (*EnableKeyResponse, error)
+	DisableKey(ctx context.Context, req *DisableKeyRequest) (*DisableKeyResponse, error)
+	// ScheduleKeyDeletion schedule key deletion after pending window, key can not be used during the window
+	ScheduleKeyDeletion(ctx context.Context, req *ScheduleKeyDeletionRequest) (*ScheduleKeyDeletionResponse, error)
+	// CancelKeyDeletion cancel scheduled key deletion, key state will be Disabled
+	CancelKeyDeletion(ctx context.Context, req *CancelKeyDeletionRequest) (*CancelKeyDeletionResponse, error)
+	// UpdateKeyRotationPolicy set period of automatic key rotation, 0 means disable automatic rotation
+	UpdateKeyRotationPolicy(ctx context.Context, req *UpdateKeyRotationPolicyRequest) (*UpdateKeyRotationPolicyResponse, error)
+	// ExecuteScheduledTasks rotate keys reached next rotation time and delete keys reached deletion date,
+	// called by background loop periodically
+	ExecuteScheduledTasks(ctx context.Context, now time.Time) error
+}
+
 // SymmetricPlugin 对称加密插件
 // 加密流程：
 // 1. 调用 Encrypt 进行加密
@@ -53,6 +76,9 @@ type SymmetricPlugin interface {
 	GenerateDataKey(ctx context.Context, req *GenerateDataKeyRequest) (*GenerateDataKeyResponse, error)
 	// RotateKeyVersion rotate key version for CMK manually, old key version still can be used to decrypt old data
 	RotateKeyVersion(ctx context.Context, req *RotateKeyVersionRequest) (*RotateKeyVersionResponse, error)
+	// ReEncrypt decrypt ciphertext and encrypt it again by the primary key version of destination CMK in kms,
+	// plaintext is never exposed outside
+	ReEncrypt(ctx context.Context, req *ReEncryptRequest) (*ReEncryptResponse, error)
 }
 
 // AsymmetricPlugin 非对称加密插件
@@ -63,8 +89,13 @@ type SymmetricPlugin interface {
 // 解密流程：
 // 1. 调用 AsymmetricDecrypt，传入密文和 解密
 type AsymmetricPlugin interface {
+	// GetPublicKey return PEM encoded public key of asymmetric CMK
 	GetPublicKey(ctx context.Context, req *GetPublicKeyRequest) (*PublicKey, error)
 	// AsymmetricDecrypt decrypts data that was encrypted with a public key retrieved from GetPublicKey
 	// corresponding to a CryptoKeyVersion with CryptoKey.purpose ASYMMETRIC_DECRYPT.
 	AsymmetricDecrypt(ctx context.Context, req *AsymmetricDecryptRequest) (*AsymmetricDecryptResponse, error)
+	// Sign sign SHA-256 digest by the private key of asymmetric CMK with usage SIGN_VERIFY
+	Sign(ctx context.Context, req *SignRequest) (*SignResponse, error)
+	// Verify verify signature generated by Sign
+	Verify(ctx context.Context, req *VerifyRequest) (*VerifyResponse, error)
 }
@@ -13,6 +13,8 @@
 
 package kmstypes
 
+import "time"
+
 // Store the key information storage interface
 type Store interface {
 	// PluginKind is key store type
@@ -24,6 +26,10 @@ type Store interface {
 	// GetKey use keyID to find CMK
 	GetKey(keyID string) (KeyInfo, error)
 
+	// UpdateKey update CMK metadata, including key state, description, rotation policy and deletion date,
+	// key versions are not changed
+	UpdateKey(info KeyInfo) error
+
 	// ListByKind use plugin type to list CMKs
 	ListKeysByKind(kind PluginKind) ([]string, error)
 
@@ -33,6 +39,18 @@ type Store interface {
 	// GetKeyVersion use keyID and keyVersionID to find keyVersion
 	GetKeyVersion(keyID, keyVersionID string) (KeyVersionInfo, error)
 
+	// ListKeyVersions use keyID to list all key versions
+	ListKeyVersions(keyID string) ([]KeyVersionInfo, error)
+
 	// RotateKeyVersion rotate key version
 	RotateKeyVersion(keyID string, newKeyVersionInfo KeyVersionInfo) (KeyVersionInfo, error)
+
+	// CreateAuditEvent persist audit event
+	CreateAuditEvent(event AuditEvent) error
+
+	// ListAuditEvents list audit events order by operated time desc, return total count and events of the page
+	ListAuditEvents(req *ListAuditEventsRequest) (int, []AuditEvent, error)
+
+	// DeleteAuditEventsBefore delete audit events operated before the time
+	DeleteAuditEventsBefore(t time.Time) error
 }
@@ -0,0 +1,191 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dicekms
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/erda-project/erda/pkg/kms/kmscrypto"
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+	"github.com/erda-project/erda/pkg/kms/log"
+)
+
+func (d *Dice) GetPublicKey(ctx context.Context, req *kmstypes.GetPublicKeyRequest) (*kmstypes.PublicKey, error) {
+	keyInfo, keyVersionInfo, privateKey, err := d.getAsymmetricKeyVersion(req.KeyID, req.KeyVersionID, "")
+	if err != nil {
+		return nil, err
+	}
+	publicKeyPem, err := kmscrypto.PublicKeyPem(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key, err: %v", err)
+	}
+	return &kmstypes.PublicKey{
+		KeyID:                 keyInfo.GetKeyID(),
+		KeyVersionID:          keyVersionInfo.GetVersionID(),
+		Pem:                   publicKeyPem,
+		CustomerMasterKeySpec: keyInfo.GetKeySpec(),
+		KeyUsage:              keyInfo.GetKeyUsage(),
+		Algorithms:            kmstypes.GetAsymmetricAlgorithms(keyInfo.GetKeySpec(), keyInfo.GetKeyUsage()),
+	}, nil
+}
+
+func (d *Dice) AsymmetricDecrypt(ctx context.Context, req *kmstypes.AsymmetricDecryptRequest) (resp *kmstypes.AsymmetricDecryptResponse, err error) {
+	keyInfo, keyVersionInfo, privateKey, err := d.getAsymmetricKeyVersion(req.KeyID, req.KeyVersionID, kmstypes.KeyUsage_ENCRYPT_DECRYPT)
+	if err != nil {
+		return nil, err
+	}
+
+	var hash crypto.Hash
+	switch req.Algorithm {
+	case kmstypes.AsymmetricAlgorithm_RSAES_OAEP_SHA_256, "":
+		hash = crypto.SHA256
+	case kmstypes.AsymmetricAlgorithm_RSAES_OAEP_SHA_1:
+		hash = crypto.SHA1
+	default:
+		return nil, fmt.Errorf("not supported algorithm: %s", req.Algorithm)
+	}
+
+	defer func() {
+		// not expose concrete error to frontend, log err and return `broken ciphertext`
+		if err != nil {
+			log.WithTraceID(ctx).Errorf("asymmetric decrypt failed, err: %v", err)
+			resp = nil
+			err = fmt.Errorf("broken ciphertext")
+		}
+	}()
+
+	ciphertext, err := base64.StdEncoding.DecodeString(req.CiphertextBase64)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := kmscrypto.RsaOaepDecrypt(privateKey, hash, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kmstypes.AsymmetricDecryptResponse{
+		KeyID:           keyInfo.GetKeyID(),
+		KeyVersionID:    keyVersionInfo.GetVersionID(),
+		PlaintextBase64: base64.StdEncoding.EncodeToString(plaintext),
+	}, nil
+}
+
+func (d *Dice) Sign(ctx context.Context, req *kmstypes.SignRequest) (*kmstypes.SignResponse, error) {
+	keyInfo, keyVersionInfo, privateKey, err := d.getAsymmetricKeyVersion(req.KeyID, req.KeyVersionID, kmstypes.KeyUsage_SIGN_VERIFY)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, pss, err := getSignAlgorithm(keyInfo.GetKeySpec(), req.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := base64.StdEncoding.DecodeString(req.DigestBase64)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := kmscrypto.SignDigest(privateKey, digest, pss)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign, err: %v", err)
+	}
+
+	return &kmstypes.SignResponse{
+		KeyID:           keyInfo.GetKeyID(),
+		KeyVersionID:    keyVersionInfo.GetVersionID(),
+		Algorithm:       algorithm,
+		SignatureBase64: base64.StdEncoding.EncodeToString(signature),
+	}, nil
+}
+
+func (d *Dice) Verify(ctx context.Context, req *kmstypes.VerifyRequest) (*kmstypes.VerifyResponse, error) {
+	keyInfo, keyVersionInfo, privateKey, err := d.getAsymmetricKeyVersion(req.KeyID, req.KeyVersionID, kmstypes.KeyUsage_SIGN_VERIFY)
+	if err != nil {
+		return nil, err
+	}
+	_, pss, err := getSignAlgorithm(keyInfo.GetKeySpec(), req.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := base64.StdEncoding.DecodeString(req.DigestBase64)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.StdEncoding.DecodeString(req.SignatureBase64)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := kmscrypto.ParsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := kmscrypto.VerifyDigest(signer.Public(), digest, signature, pss)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kmstypes.VerifyResponse{
+		KeyID:        keyInfo.GetKeyID(),
+		KeyVersionID: keyVersionInfo.GetVersionID(),
+		Valid:        valid,
+	}, nil
+}
+
+// getAsymmetricKeyVersion get enabled asymmetric key and the specified key version (primary if empty) with decoded private key,
+// check key usage if usage is not empty
+func (d *Dice) getAsymmetricKeyVersion(keyID, keyVersionID string, usage kmstypes.KeyUsage) (kmstypes.KeyInfo, kmstypes.KeyVersionInfo, []byte, error) {
+	keyInfo, err := d.store.GetKey(keyID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if keyInfo.GetKeySpec().IsSymmetric() {
+		return nil, nil, nil, fmt.Errorf("key spec %s is not asymmetric", keyInfo.GetKeySpec())
+	}
+	if err := kmstypes.CheckKeyEnabled(keyInfo); err != nil {
+		return nil, nil, nil, err
+	}
+	if usage != "" && keyInfo.GetKeyUsage() != usage {
+		return nil, nil, nil, fmt.Errorf("key usage is %s, expect: %s", keyInfo.GetKeyUsage(), usage)
+	}
+
+	keyVersionInfo := keyInfo.GetPrimaryKeyVersion()
+	if keyVersionID != "" && keyVersionID != keyVersionInfo.GetVersionID() {
+		keyVersionInfo, err = d.store.GetKeyVersion(keyInfo.GetKeyID(), keyVersionID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	privateKey, err := base64.StdEncoding.DecodeString(keyVersionInfo.GetPrivateKeyBase64())
+	if err != nil || len(privateKey) == 0 {
+		return nil, nil, nil, fmt.Errorf("invalid private key of key version: %s", keyVersionInfo.GetVersionID())
+	}
+	return keyInfo, keyVersionInfo, privateKey, nil
+}
+
+// getSignAlgorithm return the sign algorithm (default by key spec if empty) and whether it is rsa pss
+func getSignAlgorithm(spec kmstypes.CustomerMasterKeySpec, algorithm kmstypes.AsymmetricAlgorithm) (kmstypes.AsymmetricAlgorithm, bool, error) {
+	if algorithm == "" {
+		algorithm = kmstypes.AsymmetricAlgorithm_RSA_PSS_SHA_256
+		if spec.IsEC() {
+			algorithm = kmstypes.AsymmetricAlgorithm_ECDSA_SHA_256
+		}
+	}
+	for _, supported := range kmstypes.GetAsymmetricAlgorithms(spec, kmstypes.KeyUsage_SIGN_VERIFY) {
+		if supported == algorithm {
+			return algorithm, algorithm == kmstypes.AsymmetricAlgorithm_RSA_PSS_SHA_256, nil
+		}
+	}
+	return "", false, fmt.Errorf("algorithm %s not supported by key spec %s", algorithm, spec)
+}
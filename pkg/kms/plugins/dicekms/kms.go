@@ -19,6 +19,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 
@@ -62,39 +64,26 @@ func (d *Dice) CreateKey(ctx context.Context, req *kmstypes.CreateKeyRequest) (*
 		return nil, fmt.Errorf("invalid pluginKind: %s, expect: %s", req.PluginKind, kmstypes.PluginKind_DICE_KMS)
 	}
 
-	// key spec
-	if req.CustomerMasterKeySpec != kmstypes.CustomerMasterKeySpec_SYMMETRIC_DEFAULT {
-		return nil, fmt.Errorf("not supported key spec: %s", req.CustomerMasterKeySpec)
-	}
-
-	// key usage
-	if req.KeyUsage != kmstypes.KeyUsage_ENCRYPT_DECRYPT {
-		return nil, fmt.Errorf("not supported key usage: %s", req.KeyUsage)
+	// key spec & key usage
+	if err := kmstypes.CheckKeySpecAndUsage(req.CustomerMasterKeySpec, req.KeyUsage); err != nil {
+		return nil, err
 	}
 
 	// write key to store
-	primaryKeyVersion := kmstypes.KeyVersion{
-		VersionID: uuid.UUID(),
-	}
-	switch req.CustomerMasterKeySpec {
-	case kmstypes.CustomerMasterKeySpec_SYMMETRIC_DEFAULT:
-		symmetricKeyBytes, err := kmscrypto.GenerateAes256Key()
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate symmetric key, err: %v", err)
-		}
-		symmetricKeyBase64 := base64.StdEncoding.EncodeToString(symmetricKeyBytes)
-		primaryKeyVersion.SymmetricKeyBase64 = symmetricKeyBase64
+	primaryKeyVersion, err := generateKeyVersion(req.CustomerMasterKeySpec)
+	if err != nil {
+		return nil, err
 	}
 	key := kmstypes.Key{
 		PluginKind:        kmstypes.PluginKind_DICE_KMS,
 		KeyID:             uuid.UUID(),
-		PrimaryKeyVersion: primaryKeyVersion,
+		PrimaryKeyVersion: *primaryKeyVersion,
 		KeySpec:           req.CustomerMasterKeySpec,
 		KeyUsage:          req.KeyUsage,
 		KeyState:          kmstypes.KeyStateEnabled,
 		Description:       req.Description,
 	}
-	err := d.store.CreateKey(&key)
+	err = d.store.CreateKey(&key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create key in store, err: %v", err)
 	}
@@ -141,7 +130,7 @@ func (d *Dice) Encrypt(ctx context.Context, req *kmstypes.EncryptRequest) (*kmst
 	}
 
 	// key info
-	keyInfo, err := d.store.GetKey(req.KeyID)
+	keyInfo, err := d.getSymmetricKey(req.KeyID)
 	if err != nil {
 		return nil, err
 	}
@@ -172,6 +161,7 @@ func (d *Dice) Encrypt(ctx context.Context, req *kmstypes.EncryptRequest) (*kmst
 
 	return &kmstypes.EncryptResponse{
 		KeyID:            req.KeyID,
+		KeyVersionID:     keyInfo.GetPrimaryKeyVersion().GetVersionID(),
 		CiphertextBase64: wrappedCiphertextBase64,
 	}, nil
 
@@ -181,7 +171,7 @@ func (d *Dice) Decrypt(ctx context.Context, req *kmstypes.DecryptRequest) (resp
 	log.WithTraceID(ctx).Infof("decrypt request: %+v", req)
 
 	// key info
-	keyInfo, kerr := d.store.GetKey(req.KeyID)
+	keyInfo, kerr := d.getSymmetricKey(req.KeyID)
 	if kerr != nil {
 		return nil, kerr
 	}
@@ -228,15 +218,14 @@ func (d *Dice) Decrypt(ctx context.Context, req *kmstypes.DecryptRequest) (resp
 	}
 	plaintextBase64 := base64.StdEncoding.EncodeToString(plaintextBytes)
 
-	resp = &kmstypes.DecryptResponse{PlaintextBase64: plaintextBase64}
+	resp = &kmstypes.DecryptResponse{KeyVersionID: keyVersionID, PlaintextBase64: plaintextBase64}
 
 	return resp, nil
 }
 
 func (d *Dice) GenerateDataKey(ctx context.Context, req *kmstypes.GenerateDataKeyRequest) (*kmstypes.GenerateDataKeyResponse, error) {
-	// get CMK
-	keyInfo, err := d.store.GetKey(req.KeyID)
-	if err != nil {
+	// check CMK
+	if _, err := d.getSymmetricKey(req.KeyID); err != nil {
 		return nil, err
 	}
 
@@ -258,7 +247,7 @@ func (d *Dice) GenerateDataKey(ctx context.Context, req *kmstypes.GenerateDataKe
 
 	resp := kmstypes.GenerateDataKeyResponse{
 		KeyID:            req.KeyID,
-		KeyVersionID:     keyInfo.GetPrimaryKeyVersion().GetVersionID(),
+		KeyVersionID:     encryptResp.KeyVersionID,
 		CiphertextBase64: encryptResp.CiphertextBase64,
 		PlaintextBase64:  symmetricKeyBase64,
 	}
@@ -267,22 +256,26 @@ func (d *Dice) GenerateDataKey(ctx context.Context, req *kmstypes.GenerateDataKe
 }
 
 func (d *Dice) RotateKeyVersion(ctx context.Context, req *kmstypes.RotateKeyVersionRequest) (*kmstypes.RotateKeyVersionResponse, error) {
-	// generate new symmetric key
-	symmetricKey, err := kmscrypto.GenerateAes256Key()
+	keyInfo, err := d.store.GetKey(req.KeyID)
 	if err != nil {
 		return nil, err
 	}
-	newKeyVersion := kmstypes.KeyVersion{
-		VersionID:          uuid.UUID(),
-		SymmetricKeyBase64: base64.StdEncoding.EncodeToString(symmetricKey),
+	if err := kmstypes.CheckKeyEnabled(keyInfo); err != nil {
+		return nil, err
+	}
+
+	// generate new key version with the same key spec
+	newKeyVersion, err := generateKeyVersion(keyInfo.GetKeySpec())
+	if err != nil {
+		return nil, err
 	}
 
 	// rotate key version
-	_, err = d.store.RotateKeyVersion(req.KeyID, &newKeyVersion)
+	_, err = d.store.RotateKeyVersion(req.KeyID, newKeyVersion)
 	if err != nil {
 		return nil, err
 	}
-	keyInfo, err := d.store.GetKey(req.KeyID)
+	keyInfo, err = d.store.GetKey(req.KeyID)
 	if err != nil {
 		return nil, err
 	}
@@ -291,10 +284,82 @@ func (d *Dice) RotateKeyVersion(ctx context.Context, req *kmstypes.RotateKeyVers
 	return &resp, nil
 }
 
-func (d *Dice) GetPublicKey(ctx context.Context, req *kmstypes.GetPublicKeyRequest) (*kmstypes.PublicKey, error) {
-	panic("implement me")
+func (d *Dice) ReEncrypt(ctx context.Context, req *kmstypes.ReEncryptRequest) (*kmstypes.ReEncryptResponse, error) {
+	destinationKeyID := req.DestinationKeyID
+	if destinationKeyID == "" {
+		destinationKeyID = req.KeyID
+	}
+	// check destination key before decrypt, avoid decrypting in vain
+	if _, err := d.getSymmetricKey(destinationKeyID); err != nil {
+		return nil, err
+	}
+
+	// plaintext only exists in memory, never returned
+	decryptResp, err := d.Decrypt(ctx, &kmstypes.DecryptRequest{KeyID: req.KeyID, CiphertextBase64: req.CiphertextBase64})
+	if err != nil {
+		return nil, err
+	}
+	encryptResp, err := d.Encrypt(ctx, &kmstypes.EncryptRequest{KeyID: destinationKeyID, PlaintextBase64: decryptResp.PlaintextBase64})
+	if err != nil {
+		return nil, err
+	}
+
+	return &kmstypes.ReEncryptResponse{
+		SourceKeyID:        req.KeyID,
+		SourceKeyVersionID: decryptResp.KeyVersionID,
+		KeyID:              encryptResp.KeyID,
+		KeyVersionID:       encryptResp.KeyVersionID,
+		CiphertextBase64:   encryptResp.CiphertextBase64,
+	}, nil
+}
+
+// getSymmetricKey get key from store and make sure it is an enabled symmetric key
+func (d *Dice) getSymmetricKey(keyID string) (kmstypes.KeyInfo, error) {
+	keyInfo, err := d.store.GetKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if !keyInfo.GetKeySpec().IsSymmetric() {
+		return nil, fmt.Errorf("key spec %s is not symmetric", keyInfo.GetKeySpec())
+	}
+	if err := kmstypes.CheckKeyEnabled(keyInfo); err != nil {
+		return nil, err
+	}
+	return keyInfo, nil
 }
 
-func (d *Dice) AsymmetricDecrypt(ctx context.Context, req *kmstypes.AsymmetricDecryptRequest) (*kmstypes.AsymmetricDecryptResponse, error) {
-	panic("implement me")
+// generateKeyVersion generate new key version with key material of the key spec
+func generateKeyVersion(spec kmstypes.CustomerMasterKeySpec) (*kmstypes.KeyVersion, error) {
+	keyVersion := kmstypes.KeyVersion{
+		VersionID: uuid.UUID(),
+	}
+	switch spec {
+	case kmstypes.CustomerMasterKeySpec_SYMMETRIC_DEFAULT:
+		symmetricKeyBytes, err := kmscrypto.GenerateAes256Key()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate symmetric key, err: %v", err)
+		}
+		keyVersion.SymmetricKeyBase64 = base64.StdEncoding.EncodeToString(symmetricKeyBytes)
+	case kmstypes.CustomerMasterKeySpec_ASYMMETRIC_RSA_2048,
+		kmstypes.CustomerMasterKeySpec_ASYMMETRIC_RSA_3072,
+		kmstypes.CustomerMasterKeySpec_ASYMMETRIC_RSA_4096:
+		bits, err := strconv.Atoi(strings.TrimPrefix(string(spec), "RSA_"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rsa key spec: %s", spec)
+		}
+		privateKeyBytes, err := kmscrypto.GenerateRsaKey(bits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate rsa key, err: %v", err)
+		}
+		keyVersion.PrivateKeyBase64 = base64.StdEncoding.EncodeToString(privateKeyBytes)
+	case kmstypes.CustomerMasterKeySpec_ASYMMETRIC_EC_P256:
+		privateKeyBytes, err := kmscrypto.GenerateEcP256Key()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ec key, err: %v", err)
+		}
+		keyVersion.PrivateKeyBase64 = base64.StdEncoding.EncodeToString(privateKeyBytes)
+	default:
+		return nil, fmt.Errorf("not supported key spec: %s", spec)
+	}
+	return &keyVersion, nil
 }
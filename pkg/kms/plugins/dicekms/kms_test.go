@@ -0,0 +1,279 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dicekms
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/pkg/kms/kmscrypto"
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+)
+
+// memStore is an in-memory kmstypes.Store for test
+type memStore struct {
+	keys     map[string]*kmstypes.Key
+	versions map[string]map[string]*kmstypes.KeyVersion
+	events   []kmstypes.AuditEvent
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		keys:     make(map[string]*kmstypes.Key),
+		versions: make(map[string]map[string]*kmstypes.KeyVersion),
+	}
+}
+
+func (s *memStore) GetKind() kmstypes.StoreKind { return "MEMORY" }
+
+func (s *memStore) CreateKey(info kmstypes.KeyInfo) error {
+	if err := kmstypes.CheckKeyForCreate(info); err != nil {
+		return err
+	}
+	key := *info.(*kmstypes.Key)
+	version := key.PrimaryKeyVersion
+	s.keys[key.KeyID] = &key
+	s.versions[key.KeyID] = map[string]*kmstypes.KeyVersion{version.VersionID: &version}
+	return nil
+}
+
+func (s *memStore) GetKey(keyID string) (kmstypes.KeyInfo, error) {
+	key, ok := s.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key not exist")
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (s *memStore) UpdateKey(info kmstypes.KeyInfo) error {
+	key, ok := s.keys[info.GetKeyID()]
+	if !ok {
+		return fmt.Errorf("key not exist")
+	}
+	key.SetKeyState(info.GetKeyState())
+	key.SetDescription(info.GetDescription())
+	key.SetRotationPeriodDays(info.GetRotationPeriodDays())
+	key.SetNextRotationAt(info.GetNextRotationAt())
+	key.SetDeletionDate(info.GetDeletionDate())
+	return nil
+}
+
+func (s *memStore) ListKeysByKind(kind kmstypes.PluginKind) ([]string, error) {
+	var keyIDs []string
+	for id, key := range s.keys {
+		if key.PluginKind == kind {
+			keyIDs = append(keyIDs, id)
+		}
+	}
+	return keyIDs, nil
+}
+
+func (s *memStore) DeleteByKeyID(keyID string) error {
+	delete(s.keys, keyID)
+	delete(s.versions, keyID)
+	return nil
+}
+
+func (s *memStore) GetKeyVersion(keyID, keyVersionID string) (kmstypes.KeyVersionInfo, error) {
+	version, ok := s.versions[keyID][keyVersionID]
+	if !ok {
+		return nil, fmt.Errorf("key version not exist")
+	}
+	copied := *version
+	return &copied, nil
+}
+
+func (s *memStore) ListKeyVersions(keyID string) ([]kmstypes.KeyVersionInfo, error) {
+	var versions []kmstypes.KeyVersionInfo
+	for _, v := range s.versions[keyID] {
+		copied := *v
+		versions = append(versions, &copied)
+	}
+	return versions, nil
+}
+
+func (s *memStore) RotateKeyVersion(keyID string, newKeyVersionInfo kmstypes.KeyVersionInfo) (kmstypes.KeyVersionInfo, error) {
+	key, ok := s.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key not exist")
+	}
+	version := *newKeyVersionInfo.(*kmstypes.KeyVersion)
+	s.versions[keyID][version.VersionID] = &version
+	key.SetPrimaryKeyVersion(&version)
+	return newKeyVersionInfo, nil
+}
+
+func (s *memStore) CreateAuditEvent(event kmstypes.AuditEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *memStore) ListAuditEvents(req *kmstypes.ListAuditEventsRequest) (int, []kmstypes.AuditEvent, error) {
+	var events []kmstypes.AuditEvent
+	for _, event := range s.events {
+		if req.Match(event) {
+			events = append(events, event)
+		}
+	}
+	return len(events), events, nil
+}
+
+func (s *memStore) DeleteAuditEventsBefore(t time.Time) error {
+	var events []kmstypes.AuditEvent
+	for _, event := range s.events {
+		if !event.OperatedAt.Before(t) {
+			events = append(events, event)
+		}
+	}
+	s.events = events
+	return nil
+}
+
+func newTestDice() *Dice {
+	d := &Dice{}
+	d.SetStore(newMemStore())
+	return d
+}
+
+func createKey(t *testing.T, d *Dice, spec kmstypes.CustomerMasterKeySpec, usage kmstypes.KeyUsage) kmstypes.KeyMetadata {
+	req := kmstypes.CreateKeyRequest{CustomerMasterKeySpec: spec, KeyUsage: usage}
+	assert.NoError(t, req.ValidateRequest())
+	resp, err := d.CreateKey(context.Background(), &req)
+	assert.NoError(t, err)
+	return resp.KeyMetadata
+}
+
+func TestCreateKeySpecAndUsage(t *testing.T) {
+	d := newTestDice()
+	ctx := context.Background()
+
+	ec := createKey(t, d, kmstypes.CustomerMasterKeySpec_ASYMMETRIC_EC_P256, "")
+	assert.Equal(t, kmstypes.KeyUsage_SIGN_VERIFY, ec.KeyUsage)
+
+	_, err := d.CreateKey(ctx, &kmstypes.CreateKeyRequest{
+		PluginKind:            kmstypes.PluginKind_DICE_KMS,
+		CustomerMasterKeySpec: kmstypes.CustomerMasterKeySpec_ASYMMETRIC_EC_P256,
+		KeyUsage:              kmstypes.KeyUsage_ENCRYPT_DECRYPT,
+	})
+	assert.Error(t, err)
+	_, err = d.CreateKey(ctx, &kmstypes.CreateKeyRequest{
+		PluginKind:            kmstypes.PluginKind_DICE_KMS,
+		CustomerMasterKeySpec: kmstypes.CustomerMasterKeySpec_SYMMETRIC_DEFAULT,
+		KeyUsage:              kmstypes.KeyUsage_SIGN_VERIFY,
+	})
+	assert.Error(t, err)
+
+	// symmetric operation on asymmetric key
+	_, err = d.Encrypt(ctx, &kmstypes.EncryptRequest{KeyID: ec.KeyID, PlaintextBase64: "aGVsbG8="})
+	assert.Error(t, err)
+	_, err = d.GenerateDataKey(ctx, &kmstypes.GenerateDataKeyRequest{KeyID: ec.KeyID})
+	assert.Error(t, err)
+
+	// asymmetric operation on symmetric key
+	sym := createKey(t, d, "", "")
+	_, err = d.GetPublicKey(ctx, &kmstypes.GetPublicKeyRequest{KeyID: sym.KeyID})
+	assert.Error(t, err)
+}
+
+func TestAsymmetricDecrypt(t *testing.T) {
+	d := newTestDice()
+	ctx := context.Background()
+	key := createKey(t, d, kmstypes.CustomerMasterKeySpec_ASYMMETRIC_RSA_2048, kmstypes.KeyUsage_ENCRYPT_DECRYPT)
+
+	pub, err := d.GetPublicKey(ctx, &kmstypes.GetPublicKeyRequest{KeyID: key.KeyID})
+	assert.NoError(t, err)
+	assert.Equal(t, key.PrimaryKeyVersionID, pub.KeyVersionID)
+	publicKey, err := kmscrypto.ParsePublicKeyPem(pub.Pem)
+	assert.NoError(t, err)
+	ciphertext, err := kmscrypto.RsaOaepEncrypt(publicKey.(*rsa.PublicKey), crypto.SHA256, []byte("secret"))
+	assert.NoError(t, err)
+
+	// rotate, old version can still decrypt
+	rotateResp, err := d.RotateKeyVersion(ctx, &kmstypes.RotateKeyVersionRequest{KeyID: key.KeyID})
+	assert.NoError(t, err)
+	assert.NotEqual(t, key.PrimaryKeyVersionID, rotateResp.KeyMetadata.PrimaryKeyVersionID)
+
+	req := kmstypes.AsymmetricDecryptRequest{
+		KeyID:            key.KeyID,
+		KeyVersionID:     pub.KeyVersionID,
+		CiphertextBase64: base64.StdEncoding.EncodeToString(ciphertext),
+	}
+	assert.NoError(t, req.ValidateRequest())
+	decryptResp, err := d.AsymmetricDecrypt(ctx, &req)
+	assert.NoError(t, err)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("secret")), decryptResp.PlaintextBase64)
+
+	// primary version cannot decrypt data encrypted by old version
+	req.KeyVersionID = ""
+	_, err = d.AsymmetricDecrypt(ctx, &req)
+	assert.EqualError(t, err, "broken ciphertext")
+}
+
+func TestSignAndVerify(t *testing.T) {
+	d := newTestDice()
+	ctx := context.Background()
+	digest := sha256.Sum256([]byte("release-1.0.tar.gz"))
+	digestBase64 := base64.StdEncoding.EncodeToString(digest[:])
+
+	for _, spec := range []kmstypes.CustomerMasterKeySpec{
+		kmstypes.CustomerMasterKeySpec_ASYMMETRIC_RSA_2048,
+		kmstypes.CustomerMasterKeySpec_ASYMMETRIC_EC_P256,
+	} {
+		key := createKey(t, d, spec, kmstypes.KeyUsage_SIGN_VERIFY)
+		signResp, err := d.Sign(ctx, &kmstypes.SignRequest{KeyID: key.KeyID, DigestBase64: digestBase64})
+		assert.NoError(t, err)
+
+		verifyResp, err := d.Verify(ctx, &kmstypes.VerifyRequest{
+			KeyID:           key.KeyID,
+			KeyVersionID:    signResp.KeyVersionID,
+			Algorithm:       signResp.Algorithm,
+			DigestBase64:    digestBase64,
+			SignatureBase64: signResp.SignatureBase64,
+		})
+		assert.NoError(t, err)
+		assert.True(t, verifyResp.Valid)
+
+		// signature can be verified locally by exported public key
+		pub, err := d.GetPublicKey(ctx, &kmstypes.GetPublicKeyRequest{KeyID: key.KeyID})
+		assert.NoError(t, err)
+		publicKey, err := kmscrypto.ParsePublicKeyPem(pub.Pem)
+		assert.NoError(t, err)
+		signature, _ := base64.StdEncoding.DecodeString(signResp.SignatureBase64)
+		valid, err := kmscrypto.VerifyDigest(publicKey, digest[:], signature,
+			signResp.Algorithm == kmstypes.AsymmetricAlgorithm_RSA_PSS_SHA_256)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+
+		// decrypt is not allowed for SIGN_VERIFY key
+		_, err = d.AsymmetricDecrypt(ctx, &kmstypes.AsymmetricDecryptRequest{KeyID: key.KeyID, CiphertextBase64: "aGVsbG8="})
+		assert.Error(t, err)
+	}
+
+	ec := createKey(t, d, kmstypes.CustomerMasterKeySpec_ASYMMETRIC_EC_P256, "")
+	_, err := d.Sign(ctx, &kmstypes.SignRequest{
+		KeyID:        ec.KeyID,
+		Algorithm:    kmstypes.AsymmetricAlgorithm_RSA_PSS_SHA_256,
+		DigestBase64: digestBase64,
+	})
+	assert.Error(t, err)
+}
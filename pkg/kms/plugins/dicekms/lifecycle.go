@@ -0,0 +1,169 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dicekms
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+	"github.com/erda-project/erda/pkg/kms/log"
+)
+
+const day = 24 * time.Hour
+
+func (d *Dice) EnableKey(ctx context.Context, req *kmstypes.EnableKeyRequest) (*kmstypes.EnableKeyResponse, error) {
+	keyInfo, err := d.updateKeyState(req.KeyID, kmstypes.KeyStateEnabled)
+	if err != nil {
+		return nil, err
+	}
+	return &kmstypes.EnableKeyResponse{KeyMetadata: kmstypes.GetKeyMetadata(keyInfo)}, nil
+}
+
+func (d *Dice) DisableKey(ctx context.Context, req *kmstypes.DisableKeyRequest) (*kmstypes.DisableKeyResponse, error) {
+	keyInfo, err := d.updateKeyState(req.KeyID, kmstypes.KeyStateDisabled)
+	if err != nil {
+		return nil, err
+	}
+	return &kmstypes.DisableKeyResponse{KeyMetadata: kmstypes.GetKeyMetadata(keyInfo)}, nil
+}
+
+func (d *Dice) ScheduleKeyDeletion(ctx context.Context, req *kmstypes.ScheduleKeyDeletionRequest) (*kmstypes.ScheduleKeyDeletionResponse, error) {
+	keyInfo, err := d.store.GetKey(req.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if keyInfo.GetKeyState() == kmstypes.KeyStatePendingDeletion {
+		return nil, fmt.Errorf("key %s is already pending deletion", req.KeyID)
+	}
+	pendingWindowInDays := req.PendingWindowInDays
+	if pendingWindowInDays == 0 {
+		pendingWindowInDays = kmstypes.DefaultPendingWindowInDays
+	}
+	deletionDate := time.Now().Add(time.Duration(pendingWindowInDays) * day)
+	keyInfo.SetKeyState(kmstypes.KeyStatePendingDeletion)
+	keyInfo.SetDeletionDate(&deletionDate)
+	if err := d.store.UpdateKey(keyInfo); err != nil {
+		return nil, fmt.Errorf("failed to schedule key deletion, err: %v", err)
+	}
+	log.WithTraceID(ctx).Infof("key %s is scheduled to be deleted at %s", req.KeyID, deletionDate.Format(time.RFC3339))
+	return &kmstypes.ScheduleKeyDeletionResponse{KeyID: req.KeyID, DeletionDate: &deletionDate}, nil
+}
+
+func (d *Dice) CancelKeyDeletion(ctx context.Context, req *kmstypes.CancelKeyDeletionRequest) (*kmstypes.CancelKeyDeletionResponse, error) {
+	keyInfo, err := d.store.GetKey(req.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if keyInfo.GetKeyState() != kmstypes.KeyStatePendingDeletion {
+		return nil, fmt.Errorf("key %s is not pending deletion", req.KeyID)
+	}
+	// key is disabled after cancel, must be enabled explicitly before use
+	keyInfo.SetKeyState(kmstypes.KeyStateDisabled)
+	keyInfo.SetDeletionDate(nil)
+	if err := d.store.UpdateKey(keyInfo); err != nil {
+		return nil, fmt.Errorf("failed to cancel key deletion, err: %v", err)
+	}
+	return &kmstypes.CancelKeyDeletionResponse{KeyMetadata: kmstypes.GetKeyMetadata(keyInfo)}, nil
+}
+
+func (d *Dice) UpdateKeyRotationPolicy(ctx context.Context, req *kmstypes.UpdateKeyRotationPolicyRequest) (*kmstypes.UpdateKeyRotationPolicyResponse, error) {
+	keyInfo, err := d.store.GetKey(req.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if keyInfo.GetKeyState() == kmstypes.KeyStatePendingDeletion {
+		return nil, fmt.Errorf("key %s is pending deletion", req.KeyID)
+	}
+	keyInfo.SetRotationPeriodDays(req.RotationPeriodDays)
+	if req.RotationPeriodDays > 0 {
+		nextRotationAt := time.Now().Add(time.Duration(req.RotationPeriodDays) * day)
+		keyInfo.SetNextRotationAt(&nextRotationAt)
+	} else {
+		keyInfo.SetNextRotationAt(nil)
+	}
+	if err := d.store.UpdateKey(keyInfo); err != nil {
+		return nil, fmt.Errorf("failed to update key rotation policy, err: %v", err)
+	}
+	return &kmstypes.UpdateKeyRotationPolicyResponse{KeyMetadata: kmstypes.GetKeyMetadata(keyInfo)}, nil
+}
+
+func (d *Dice) ExecuteScheduledTasks(ctx context.Context, now time.Time) error {
+	keyIDs, err := d.store.ListKeysByKind(kmstypes.PluginKind_DICE_KMS)
+	if err != nil {
+		return fmt.Errorf("failed to list keys, err: %v", err)
+	}
+	for _, keyID := range keyIDs {
+		keyInfo, err := d.store.GetKey(keyID)
+		if err != nil {
+			log.WithTraceID(ctx).Errorf("failed to get key %s, err: %v", keyID, err)
+			continue
+		}
+		switch {
+		case keyInfo.GetKeyState() == kmstypes.KeyStatePendingDeletion:
+			if keyInfo.GetDeletionDate() == nil || now.Before(*keyInfo.GetDeletionDate()) {
+				continue
+			}
+			if err := d.store.DeleteByKeyID(keyID); err != nil {
+				log.WithTraceID(ctx).Errorf("failed to delete key %s, err: %v", keyID, err)
+				continue
+			}
+			log.WithTraceID(ctx).Infof("key %s is deleted, scheduled deletion date: %s", keyID, keyInfo.GetDeletionDate().Format(time.RFC3339))
+		case keyInfo.GetKeyState() == kmstypes.KeyStateEnabled && keyInfo.GetRotationPeriodDays() > 0:
+			if keyInfo.GetNextRotationAt() == nil || now.Before(*keyInfo.GetNextRotationAt()) {
+				continue
+			}
+			if err := d.rotateKeyAutomatically(keyInfo, now); err != nil {
+				log.WithTraceID(ctx).Errorf("failed to rotate key %s automatically, err: %v", keyID, err)
+				continue
+			}
+			log.WithTraceID(ctx).Infof("key %s is rotated automatically", keyID)
+		}
+	}
+	return nil
+}
+
+// rotateKeyAutomatically rotate key version and set next rotation time by rotation period
+func (d *Dice) rotateKeyAutomatically(keyInfo kmstypes.KeyInfo, now time.Time) error {
+	newKeyVersion, err := generateKeyVersion(keyInfo.GetKeySpec())
+	if err != nil {
+		return err
+	}
+	if _, err := d.store.RotateKeyVersion(keyInfo.GetKeyID(), newKeyVersion); err != nil {
+		return err
+	}
+	nextRotationAt := now.Add(time.Duration(keyInfo.GetRotationPeriodDays()) * day)
+	keyInfo.SetNextRotationAt(&nextRotationAt)
+	return d.store.UpdateKey(keyInfo)
+}
+
+// updateKeyState switch key state between Enabled and Disabled, key pending deletion must be canceled first
+func (d *Dice) updateKeyState(keyID string, state kmstypes.KeyState) (kmstypes.KeyInfo, error) {
+	keyInfo, err := d.store.GetKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if keyInfo.GetKeyState() == kmstypes.KeyStatePendingDeletion {
+		return nil, fmt.Errorf("key %s is pending deletion, cancel key deletion first", keyID)
+	}
+	if keyInfo.GetKeyState() == state {
+		return keyInfo, nil
+	}
+	keyInfo.SetKeyState(state)
+	if err := d.store.UpdateKey(keyInfo); err != nil {
+		return nil, fmt.Errorf("failed to update key state, err: %v", err)
+	}
+	return keyInfo, nil
+}
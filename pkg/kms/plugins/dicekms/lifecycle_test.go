@@ -0,0 +1,175 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dicekms
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+)
+
+func TestKeyStateEnforced(t *testing.T) {
+	d := newTestDice()
+	ctx := context.Background()
+	key := createKey(t, d, "", "")
+	plaintextBase64 := base64.StdEncoding.EncodeToString([]byte("secret"))
+
+	encryptResp, err := d.Encrypt(ctx, &kmstypes.EncryptRequest{KeyID: key.KeyID, PlaintextBase64: plaintextBase64})
+	assert.NoError(t, err)
+	assert.Equal(t, key.PrimaryKeyVersionID, encryptResp.KeyVersionID)
+
+	disableResp, err := d.DisableKey(ctx, &kmstypes.DisableKeyRequest{KeyID: key.KeyID})
+	assert.NoError(t, err)
+	assert.Equal(t, kmstypes.KeyStateDisabled, disableResp.KeyMetadata.KeyState)
+
+	_, err = d.Encrypt(ctx, &kmstypes.EncryptRequest{KeyID: key.KeyID, PlaintextBase64: plaintextBase64})
+	assert.Error(t, err)
+	_, err = d.Decrypt(ctx, &kmstypes.DecryptRequest{KeyID: key.KeyID, CiphertextBase64: encryptResp.CiphertextBase64})
+	assert.Error(t, err)
+	_, err = d.GenerateDataKey(ctx, &kmstypes.GenerateDataKeyRequest{KeyID: key.KeyID})
+	assert.Error(t, err)
+	_, err = d.RotateKeyVersion(ctx, &kmstypes.RotateKeyVersionRequest{KeyID: key.KeyID})
+	assert.Error(t, err)
+
+	_, err = d.EnableKey(ctx, &kmstypes.EnableKeyRequest{KeyID: key.KeyID})
+	assert.NoError(t, err)
+	decryptResp, err := d.Decrypt(ctx, &kmstypes.DecryptRequest{KeyID: key.KeyID, CiphertextBase64: encryptResp.CiphertextBase64})
+	assert.NoError(t, err)
+	assert.Equal(t, plaintextBase64, decryptResp.PlaintextBase64)
+
+	// asymmetric key
+	ec := createKey(t, d, kmstypes.CustomerMasterKeySpec_ASYMMETRIC_EC_P256, "")
+	_, err = d.DisableKey(ctx, &kmstypes.DisableKeyRequest{KeyID: ec.KeyID})
+	assert.NoError(t, err)
+	_, err = d.Sign(ctx, &kmstypes.SignRequest{KeyID: ec.KeyID, DigestBase64: plaintextBase64})
+	assert.Error(t, err)
+}
+
+func TestScheduleKeyDeletion(t *testing.T) {
+	d := newTestDice()
+	ctx := context.Background()
+	key := createKey(t, d, "", "")
+
+	req := kmstypes.ScheduleKeyDeletionRequest{KeyID: key.KeyID, PendingWindowInDays: 3}
+	assert.Error(t, req.ValidateRequest())
+	req.PendingWindowInDays = 0
+	assert.NoError(t, req.ValidateRequest())
+	assert.Equal(t, kmstypes.DefaultPendingWindowInDays, req.PendingWindowInDays)
+	req.PendingWindowInDays = 7
+
+	scheduleResp, err := d.ScheduleKeyDeletion(ctx, &req)
+	assert.NoError(t, err)
+	_, err = d.GenerateDataKey(ctx, &kmstypes.GenerateDataKeyRequest{KeyID: key.KeyID})
+	assert.Error(t, err)
+	_, err = d.EnableKey(ctx, &kmstypes.EnableKeyRequest{KeyID: key.KeyID})
+	assert.Error(t, err)
+
+	// cancel, key is disabled
+	cancelResp, err := d.CancelKeyDeletion(ctx, &kmstypes.CancelKeyDeletionRequest{KeyID: key.KeyID})
+	assert.NoError(t, err)
+	assert.Equal(t, kmstypes.KeyStateDisabled, cancelResp.KeyMetadata.KeyState)
+	assert.Nil(t, cancelResp.KeyMetadata.DeletionDate)
+	_, err = d.CancelKeyDeletion(ctx, &kmstypes.CancelKeyDeletionRequest{KeyID: key.KeyID})
+	assert.Error(t, err)
+
+	// schedule again, not deleted before deletion date
+	scheduleResp, err = d.ScheduleKeyDeletion(ctx, &req)
+	assert.NoError(t, err)
+	assert.NoError(t, d.ExecuteScheduledTasks(ctx, scheduleResp.DeletionDate.Add(-time.Minute)))
+	_, err = d.DescribeKey(ctx, &kmstypes.DescribeKeyRequest{KeyID: key.KeyID})
+	assert.NoError(t, err)
+
+	assert.NoError(t, d.ExecuteScheduledTasks(ctx, scheduleResp.DeletionDate.Add(time.Minute)))
+	_, err = d.DescribeKey(ctx, &kmstypes.DescribeKeyRequest{KeyID: key.KeyID})
+	assert.Error(t, err)
+}
+
+func TestAutomaticKeyRotation(t *testing.T) {
+	d := newTestDice()
+	ctx := context.Background()
+	key := createKey(t, d, "", "")
+
+	policyResp, err := d.UpdateKeyRotationPolicy(ctx, &kmstypes.UpdateKeyRotationPolicyRequest{KeyID: key.KeyID, RotationPeriodDays: 90})
+	assert.NoError(t, err)
+	assert.Equal(t, 90, policyResp.KeyMetadata.RotationPeriodDays)
+	nextRotationAt := *policyResp.KeyMetadata.NextRotationAt
+
+	// not reach next rotation time
+	assert.NoError(t, d.ExecuteScheduledTasks(ctx, nextRotationAt.Add(-time.Minute)))
+	descResp, err := d.DescribeKey(ctx, &kmstypes.DescribeKeyRequest{KeyID: key.KeyID})
+	assert.NoError(t, err)
+	assert.Equal(t, key.PrimaryKeyVersionID, descResp.KeyMetadata.PrimaryKeyVersionID)
+
+	now := nextRotationAt.Add(time.Minute)
+	assert.NoError(t, d.ExecuteScheduledTasks(ctx, now))
+	descResp, err = d.DescribeKey(ctx, &kmstypes.DescribeKeyRequest{KeyID: key.KeyID})
+	assert.NoError(t, err)
+	assert.NotEqual(t, key.PrimaryKeyVersionID, descResp.KeyMetadata.PrimaryKeyVersionID)
+	assert.Equal(t, now.Add(90*day), *descResp.KeyMetadata.NextRotationAt)
+
+	// disable automatic rotation
+	policyResp, err = d.UpdateKeyRotationPolicy(ctx, &kmstypes.UpdateKeyRotationPolicyRequest{KeyID: key.KeyID})
+	assert.NoError(t, err)
+	assert.Nil(t, policyResp.KeyMetadata.NextRotationAt)
+}
+
+func TestReEncrypt(t *testing.T) {
+	d := newTestDice()
+	ctx := context.Background()
+	key := createKey(t, d, "", "")
+	other := createKey(t, d, "", "")
+	plaintextBase64 := base64.StdEncoding.EncodeToString([]byte("secret"))
+
+	encryptResp, err := d.Encrypt(ctx, &kmstypes.EncryptRequest{KeyID: key.KeyID, PlaintextBase64: plaintextBase64})
+	assert.NoError(t, err)
+	rotateResp, err := d.RotateKeyVersion(ctx, &kmstypes.RotateKeyVersionRequest{KeyID: key.KeyID})
+	assert.NoError(t, err)
+
+	// re-encrypt by latest key version of the same key
+	req := kmstypes.ReEncryptRequest{KeyID: key.KeyID, CiphertextBase64: encryptResp.CiphertextBase64}
+	assert.NoError(t, req.ValidateRequest())
+	reEncryptResp, err := d.ReEncrypt(ctx, &req)
+	assert.NoError(t, err)
+	assert.Equal(t, rotateResp.KeyMetadata.PrimaryKeyVersionID, reEncryptResp.KeyVersionID)
+	decryptResp, err := d.Decrypt(ctx, &kmstypes.DecryptRequest{KeyID: key.KeyID, CiphertextBase64: reEncryptResp.CiphertextBase64})
+	assert.NoError(t, err)
+	assert.Equal(t, plaintextBase64, decryptResp.PlaintextBase64)
+
+	// re-encrypt by another key
+	reEncryptResp, err = d.ReEncrypt(ctx, &kmstypes.ReEncryptRequest{
+		KeyID:            key.KeyID,
+		CiphertextBase64: encryptResp.CiphertextBase64,
+		DestinationKeyID: other.KeyID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, other.KeyID, reEncryptResp.KeyID)
+	decryptResp, err = d.Decrypt(ctx, &kmstypes.DecryptRequest{KeyID: other.KeyID, CiphertextBase64: reEncryptResp.CiphertextBase64})
+	assert.NoError(t, err)
+	assert.Equal(t, plaintextBase64, decryptResp.PlaintextBase64)
+
+	// destination key disabled
+	_, err = d.DisableKey(ctx, &kmstypes.DisableKeyRequest{KeyID: other.KeyID})
+	assert.NoError(t, err)
+	_, err = d.ReEncrypt(ctx, &kmstypes.ReEncryptRequest{
+		KeyID:            key.KeyID,
+		CiphertextBase64: encryptResp.CiphertextBase64,
+		DestinationKeyID: other.KeyID,
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package etcd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeEtcdAuditEventKey(t *testing.T) {
+	t1 := time.Unix(1600000000, 0)
+	t2 := t1.Add(time.Second)
+
+	// keys are ordered by operated time, time range is a key range
+	assert.True(t, makeEtcdAuditEventKey("", t1, "b") < makeEtcdAuditEventKey("", t2, "a"))
+	assert.True(t, makeEtcdAuditEventKey("", t1, "") < makeEtcdAuditEventKey("", t1, "a"))
+	assert.True(t, makeEtcdAuditEventKey("", t1, "z") < makeEtcdAuditEventKey("", t2, ""))
+
+	// events of key are indexed under their own prefix, not mixed with global prefix
+	keyEvent := makeEtcdAuditEventKey("k1", t1, "a")
+	assert.True(t, strings.HasPrefix(keyEvent, makeEtcdAuditEventPrefix("k1")))
+	assert.False(t, strings.HasPrefix(keyEvent, makeEtcdAuditEventPrefix("")))
+	assert.False(t, strings.HasPrefix(makeEtcdAuditEventKey("k10", t1, "a"), makeEtcdAuditEventPrefix("k1")))
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/coreos/etcd/clientv3"
@@ -26,18 +27,27 @@ import (
 
 	"github.com/erda-project/erda/pkg/jsonstore/etcd"
 	"github.com/erda-project/erda/pkg/kms/kmstypes"
-)
-
-const (
-	EnvKeyEtcdEndpoints = "ETCD_ENDPOINTS"
+	"github.com/erda-project/erda/pkg/uuid"
 )
 
 func isNotFoundErr(err error) bool {
 	return err.Error() == "not found"
 }
 
+const (
+	EnvKeyEtcdEndpoints  = "ETCD_ENDPOINTS"
+	EnvKeyAuditRetention = "KMS_AUDIT_RETENTION"
+
+	auditScanBatchSize = 500
+)
+
 type Store struct {
 	etcdClient *etcd.Store
+
+	auditRetention   time.Duration
+	auditLeaseLock   sync.Mutex
+	auditLeaseBucket time.Time
+	auditLeaseID     clientv3.LeaseID
 }
 
 func init() {
@@ -60,6 +70,12 @@ func init() {
 		}
 
 		s := Store{etcdClient: etcdclient}
+		if retention := configMap[EnvKeyAuditRetention]; retention != "" {
+			s.auditRetention, err = time.ParseDuration(retention)
+			if err != nil {
+				panic(fmt.Errorf("failed to init etcd store, invalid %s: %s", EnvKeyAuditRetention, retention))
+			}
+		}
 
 		return &s
 	})
@@ -85,6 +101,7 @@ func (s *Store) CreateKey(keyInfo kmstypes.KeyInfo) error {
 	keyVersion := kmstypes.KeyVersion{
 		VersionID:          keyInfo.GetPrimaryKeyVersion().GetVersionID(),
 		SymmetricKeyBase64: keyInfo.GetPrimaryKeyVersion().GetSymmetricKeyBase64(),
+		PrivateKeyBase64:   keyInfo.GetPrimaryKeyVersion().GetPrivateKeyBase64(),
 		CreatedAt:          &now,
 		UpdatedAt:          &now,
 	}
@@ -103,6 +120,9 @@ func (s *Store) CreateKey(keyInfo kmstypes.KeyInfo) error {
 		CreatedAt:         &now,
 		UpdatedAt:         &now,
 	}
+	key.SetRotationPeriodDays(keyInfo.GetRotationPeriodDays())
+	key.SetNextRotationAt(keyInfo.GetNextRotationAt())
+	key.SetDeletionDate(keyInfo.GetDeletionDate())
 	keyJSON, err := json.Marshal(&key)
 	if err != nil {
 		return err
@@ -131,16 +151,45 @@ func (s *Store) CreateKey(keyInfo kmstypes.KeyInfo) error {
 
 func (s *Store) GetKey(keyID string) (kmstypes.KeyInfo, error) {
 	ctx := context.Background()
-	key, err := getKeyFromEtcd(ctx, keyID, s.etcdClient)
+	key, _, err := getKeyWithModRevision(ctx, keyID, s.etcdClient)
 	if err != nil {
-		if isNotFoundErr(err) {
-			return nil, fmt.Errorf("key not exist")
-		}
-		return nil, fmt.Errorf("get key from etcd failed, err: %v", err)
+		return nil, err
 	}
 	return key, nil
 }
 
+// UpdateKey update key metadata by compare-and-swap on mod revision of the key,
+// so it never overwrites a concurrent rotation of primary key version.
+func (s *Store) UpdateKey(keyInfo kmstypes.KeyInfo) error {
+	ctx := context.Background()
+	key, modRevision, err := getKeyWithModRevision(ctx, keyInfo.GetKeyID(), s.etcdClient)
+	if err != nil {
+		return err
+	}
+	key.SetKeyState(keyInfo.GetKeyState())
+	key.SetDescription(keyInfo.GetDescription())
+	key.SetRotationPeriodDays(keyInfo.GetRotationPeriodDays())
+	key.SetNextRotationAt(keyInfo.GetNextRotationAt())
+	key.SetDeletionDate(keyInfo.GetDeletionDate())
+	key.SetUpdatedAt(time.Now())
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	etcdKey := makeEtcdKeyID(keyInfo.GetKeyID())
+	resp, err := s.etcdClient.GetClient().Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(etcdKey), "=", modRevision)).
+		Then(clientv3.OpPut(etcdKey, string(keyJSON))).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return fmt.Errorf("key has been modified concurrently")
+	}
+	return nil
+}
+
 func (s *Store) ListKeysByKind(kind kmstypes.PluginKind) ([]string, error) {
 	ctx := context.Background()
 	values, err := s.etcdClient.PrefixGet(ctx, makeEtcdPluginKindPrefix(kind))
@@ -148,7 +197,8 @@ func (s *Store) ListKeysByKind(kind kmstypes.PluginKind) ([]string, error) {
 		return nil, err
 	}
 	var keys []string
-	prefix := makeEtcdPluginKindPrefix(kind)
+	// value is the etcd key of CMK, see makeEtcdKeyID
+	prefix := makeEtcdKeyID("")
 	for _, v := range values {
 		keys = append(keys, strings.TrimPrefix(string(v.Value), prefix))
 	}
@@ -157,9 +207,27 @@ func (s *Store) ListKeysByKind(kind kmstypes.PluginKind) ([]string, error) {
 
 func (s *Store) DeleteByKeyID(keyID string) error {
 	ctx := context.Background()
-	_, err := s.etcdClient.Remove(ctx, makeEtcdKeyID(keyID))
-	// TODO delete relation key
-	return err
+	key, err := s.GetKey(keyID)
+	if err != nil {
+		return err
+	}
+	resp, err := s.etcdClient.GetClient().Txn(ctx).
+		Then(
+			// CMK
+			clientv3.OpDelete(makeEtcdKeyID(keyID)),
+			// 引用：插件类型
+			clientv3.OpDelete(makeEtcdKeyIDUnderPlugin(keyID, key.GetPluginKind())),
+			// all key versions
+			clientv3.OpDelete(makeEtcdKeyVersionID(keyID, ""), clientv3.WithPrefix()),
+		).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return fmt.Errorf("failed to delete data from etcd when delete key")
+	}
+	return nil
 }
 
 func (s *Store) GetKeyVersion(keyID, keyVersionID string) (kmstypes.KeyVersionInfo, error) {
@@ -178,6 +246,26 @@ func (s *Store) GetKeyVersion(keyID, keyVersionID string) (kmstypes.KeyVersionIn
 	return &keyVersion, nil
 }
 
+func (s *Store) ListKeyVersions(keyID string) ([]kmstypes.KeyVersionInfo, error) {
+	ctx := context.Background()
+	values, err := s.etcdClient.PrefixGet(ctx, makeEtcdKeyVersionID(keyID, ""))
+	if err != nil {
+		return nil, err
+	}
+	var keyVersions []kmstypes.KeyVersionInfo
+	for _, v := range values {
+		var keyVersion kmstypes.KeyVersion
+		if err := json.Unmarshal(v.Value, &keyVersion); err != nil {
+			return nil, err
+		}
+		keyVersions = append(keyVersions, &keyVersion)
+	}
+	return keyVersions, nil
+}
+
+// RotateKeyVersion put new key version and switch primary key version in one transaction.
+// The transaction compares mod revision of the key, so concurrent rotations of the same key fail instead of
+// overwriting each other.
 func (s *Store) RotateKeyVersion(keyID string, newKeyVersionInfo kmstypes.KeyVersionInfo) (kmstypes.KeyVersionInfo, error) {
 	ctx := context.Background()
 	now := time.Now()
@@ -186,7 +274,7 @@ func (s *Store) RotateKeyVersion(keyID string, newKeyVersionInfo kmstypes.KeyVer
 	newKeyVersionInfo.SetCreatedAt(now)
 	newKeyVersionInfo.SetUpdatedAt(now)
 	// keyInfo
-	keyInfo, err := s.GetKey(keyID)
+	keyInfo, modRevision, err := getKeyWithModRevision(ctx, keyID, s.etcdClient)
 	if err != nil {
 		return nil, err
 	}
@@ -202,27 +290,156 @@ func (s *Store) RotateKeyVersion(keyID string, newKeyVersionInfo kmstypes.KeyVer
 		return nil, err
 	}
 
+	etcdKey := makeEtcdKeyID(keyID)
+	etcdKeyVersion := makeEtcdKeyVersionID(keyID, newKeyVersionInfo.GetVersionID())
 	resp, err := s.etcdClient.GetClient().Txn(ctx).
+		If(
+			clientv3.Compare(clientv3.ModRevision(etcdKey), "=", modRevision),
+			// key version id must be new
+			clientv3.Compare(clientv3.Version(etcdKeyVersion), "=", 0),
+		).
 		Then(
 			// New Key Version
-			clientv3.OpPut(makeEtcdKeyVersionID(keyID, newKeyVersionInfo.GetVersionID()), string(newKeyVersionJSON)),
+			clientv3.OpPut(etcdKeyVersion, string(newKeyVersionJSON)),
 
 			// Update CMK PrimaryKeyVersion
-			clientv3.OpPut(makeEtcdKeyID(keyID), string(keyJSON)),
+			clientv3.OpPut(etcdKey, string(keyJSON)),
 		).
 		Commit()
 	if err != nil {
 		return nil, err
 	}
 	if !resp.Succeeded {
-		return nil, fmt.Errorf("failed to put data into etcd when rotate key version")
+		return nil, fmt.Errorf("key version has been rotated concurrently")
 	}
-	err = s.etcdClient.Put(ctx, makeEtcdKeyVersionID(keyID, newKeyVersionInfo.GetVersionID()), string(newKeyVersionJSON))
+
+	return newKeyVersionInfo, nil
+}
+
+// CreateAuditEvent put audit event into etcd, etcd key is ordered by operated time.
+// Event is put both under the global prefix and under the prefix of its key, which is used as index when query by key.
+// Event keys are attached to a lease of audit retention, so they are expired by etcd and never grow without bound.
+func (s *Store) CreateAuditEvent(event kmstypes.AuditEvent) error {
+	ctx := context.Background()
+	eventJSON, err := json.Marshal(&event)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	var opts []clientv3.OpOption
+	if s.auditRetention > 0 {
+		leaseID, err := s.getAuditLease(ctx, event.OperatedAt)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, clientv3.WithLease(leaseID))
 	}
+	id := uuid.UUID()
+	ops := []clientv3.Op{clientv3.OpPut(makeEtcdAuditEventKey("", event.OperatedAt, id), string(eventJSON), opts...)}
+	if event.KeyID != "" {
+		ops = append(ops, clientv3.OpPut(makeEtcdAuditEventKey(event.KeyID, event.OperatedAt, id), string(eventJSON), opts...))
+	}
+	_, err = s.etcdClient.GetClient().Txn(ctx).Then(ops...).Commit()
+	return err
+}
 
-	return newKeyVersionInfo, nil
+// getAuditLease return lease of audit events operated in the same hour, lease is granted once per hour and cached
+func (s *Store) getAuditLease(ctx context.Context, operatedAt time.Time) (clientv3.LeaseID, error) {
+	bucket := operatedAt.Truncate(time.Hour)
+	s.auditLeaseLock.Lock()
+	defer s.auditLeaseLock.Unlock()
+	if s.auditLeaseBucket.Equal(bucket) && s.auditLeaseID != clientv3.NoLease {
+		return s.auditLeaseID, nil
+	}
+	// events live for retention at least, at most one hour more
+	ttl := int64((s.auditRetention + time.Hour).Seconds())
+	resp, err := s.etcdClient.GetClient().Grant(ctx, ttl)
+	if err != nil {
+		return clientv3.NoLease, fmt.Errorf("failed to grant lease for audit events, err: %v", err)
+	}
+	s.auditLeaseBucket, s.auditLeaseID = bucket, resp.ID
+	return resp.ID, nil
+}
+
+// ListAuditEvents list audit events in key range limited by keyID and time range, newest first.
+// Without other filters, total is counted by etcd and only events of the page are read;
+// otherwise events in range are scanned in batches and filtered.
+func (s *Store) ListAuditEvents(req *kmstypes.ListAuditEventsRequest) (int, []kmstypes.AuditEvent, error) {
+	ctx := context.Background()
+	cli := s.etcdClient.GetClient()
+	prefix := makeEtcdAuditEventPrefix(req.KeyID)
+	startKey, endKey := prefix, clientv3.GetPrefixRangeEnd(prefix)
+	if req.StartTime > 0 {
+		startKey = makeEtcdAuditEventKey(req.KeyID, time.Unix(req.StartTime, 0), "")
+	}
+	if req.EndTime > 0 {
+		endKey = makeEtcdAuditEventKey(req.KeyID, time.Unix(req.EndTime+1, 0), "")
+	}
+	offset := (req.PageNo - 1) * req.PageSize
+
+	if req.Operation == "" && req.UserID == "" && req.InternalClient == "" && !req.OnlyFailed {
+		countResp, err := cli.Get(ctx, startKey, clientv3.WithRange(endKey), clientv3.WithCountOnly())
+		if err != nil {
+			return 0, nil, err
+		}
+		total := int(countResp.Count)
+		if offset >= total {
+			return total, nil, nil
+		}
+		resp, err := cli.Get(ctx, startKey, clientv3.WithRange(endKey),
+			clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend), clientv3.WithLimit(int64(offset+req.PageSize)))
+		if err != nil {
+			return 0, nil, err
+		}
+		var events []kmstypes.AuditEvent
+		for _, kv := range resp.Kvs[offset:] {
+			var event kmstypes.AuditEvent
+			if err := json.Unmarshal(kv.Value, &event); err != nil {
+				return 0, nil, err
+			}
+			events = append(events, event)
+		}
+		return total, events, nil
+	}
+
+	var (
+		total  int
+		events []kmstypes.AuditEvent
+	)
+	for {
+		resp, err := cli.Get(ctx, startKey, clientv3.WithRange(endKey),
+			clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend), clientv3.WithLimit(auditScanBatchSize))
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, kv := range resp.Kvs {
+			var event kmstypes.AuditEvent
+			if err := json.Unmarshal(kv.Value, &event); err != nil {
+				return 0, nil, err
+			}
+			if !req.Match(event) {
+				continue
+			}
+			if total >= offset && total < offset+req.PageSize {
+				events = append(events, event)
+			}
+			total++
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			break
+		}
+		// range end is exclusive, continue with keys before the last one
+		endKey = string(resp.Kvs[len(resp.Kvs)-1].Key)
+	}
+	return total, events, nil
+}
+
+// DeleteAuditEventsBefore delete audit events under the global prefix operated before the time,
+// events under key prefixes are expired by lease.
+func (s *Store) DeleteAuditEventsBefore(t time.Time) error {
+	ctx := context.Background()
+	_, err := s.etcdClient.GetClient().Delete(ctx, makeEtcdAuditEventPrefix(""),
+		clientv3.WithRange(makeEtcdAuditEventKey("", t, "")))
+	return err
 }
 
 func makeEtcdKeyID(keyID string) string {
@@ -241,14 +458,30 @@ func makeEtcdKeyVersionID(keyID, keyVersion string) string {
 	return fmt.Sprintf("%s/version/%s", makeEtcdKeyID(keyID), keyVersion)
 }
 
-func getKeyFromEtcd(ctx context.Context, keyID string, etcdClient *etcd.Store) (*kmstypes.Key, error) {
+// getKeyWithModRevision get key and its mod revision, which is used to compare-and-swap the key
+func getKeyWithModRevision(ctx context.Context, keyID string, etcdClient *etcd.Store) (*kmstypes.Key, int64, error) {
 	value, err := etcdClient.Get(ctx, makeEtcdKeyID(keyID))
 	if err != nil {
-		return nil, err
+		if isNotFoundErr(err) {
+			return nil, 0, fmt.Errorf("key not exist")
+		}
+		return nil, 0, fmt.Errorf("get key from etcd failed, err: %v", err)
 	}
 	var model kmstypes.Key
 	if err := json.Unmarshal(value.Value, &model); err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	return &model, value.ModRevision, nil
+}
+
+// makeEtcdAuditEventPrefix return prefix of all audit events, or audit events of the key if keyID is not empty
+func makeEtcdAuditEventPrefix(keyID string) string {
+	if keyID == "" {
+		return "/dice/kms/audit/all/"
 	}
-	return &model, nil
+	return fmt.Sprintf("/dice/kms/audit/key/%s/", keyID)
+}
+
+func makeEtcdAuditEventKey(keyID string, t time.Time, id string) string {
+	return fmt.Sprintf("%s%020d-%s", makeEtcdAuditEventPrefix(keyID), t.UnixNano(), id)
 }
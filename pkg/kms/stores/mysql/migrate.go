@@ -0,0 +1,101 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+)
+
+// MigrateResult is the result of copying keys from another store.
+type MigrateResult struct {
+	Copied  []string
+	Skipped []string
+}
+
+// CopyFrom copy all keys of the given plugin kinds, with all of their key versions, from src store into mysql.
+// Each key is copied in its own transaction; keys already existing in mysql are skipped, so it is safe to rerun.
+func (s *Store) CopyFrom(src kmstypes.Store, pluginKinds []kmstypes.PluginKind, dryRun bool) (*MigrateResult, error) {
+	var result MigrateResult
+	for _, kind := range pluginKinds {
+		keyIDs, err := src.ListKeysByKind(kind)
+		if err != nil {
+			return &result, fmt.Errorf("failed to list keys of plugin kind %s, err: %v", kind, err)
+		}
+		for _, keyID := range keyIDs {
+			var count int
+			if err := s.db.Model(&KmsKey{}).Where("key_id = ?", keyID).Count(&count).Error; err != nil {
+				return &result, err
+			}
+			if count > 0 {
+				logrus.Infof("key %s already exists in mysql, skip", keyID)
+				result.Skipped = append(result.Skipped, keyID)
+				continue
+			}
+			if err := s.copyKey(src, keyID, dryRun); err != nil {
+				return &result, fmt.Errorf("failed to copy key %s, err: %v", keyID, err)
+			}
+			result.Copied = append(result.Copied, keyID)
+		}
+	}
+	return &result, nil
+}
+
+func (s *Store) copyKey(src kmstypes.Store, keyID string, dryRun bool) error {
+	keyInfo, err := src.GetKey(keyID)
+	if err != nil {
+		return err
+	}
+	keyVersions, err := src.ListKeyVersions(keyID)
+	if err != nil {
+		return err
+	}
+	primaryFound := false
+	for _, v := range keyVersions {
+		if v.GetVersionID() == keyInfo.GetPrimaryKeyVersion().GetVersionID() {
+			primaryFound = true
+		}
+	}
+	if !primaryFound {
+		return fmt.Errorf("primary key version %s not found in key versions", keyInfo.GetPrimaryKeyVersion().GetVersionID())
+	}
+	logrus.Infof("copy key %s with %d key version(s)", keyID, len(keyVersions))
+	if dryRun {
+		return nil
+	}
+
+	key := fromKeyInfo(keyInfo)
+	if t := keyInfo.GetCreatedAt(); t != nil {
+		key.CreatedAt = *t
+	}
+	if t := keyInfo.GetUpdatedAt(); t != nil {
+		key.UpdatedAt = *t
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&key).Error; err != nil {
+			return err
+		}
+		for _, v := range keyVersions {
+			keyVersion := fromKeyVersionInfo(keyID, v)
+			if err := tx.Create(&keyVersion).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
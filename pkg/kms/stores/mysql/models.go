@@ -0,0 +1,206 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mysql
+
+import (
+	"time"
+
+	"github.com/erda-project/erda/pkg/dbengine"
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+)
+
+// KmsKey CMK 主数据
+type KmsKey struct {
+	dbengine.BaseModel
+	KeyID               string `gorm:"type:varchar(64);unique_index:uk_key_id"`
+	PluginKind          string `gorm:"type:varchar(32);index:idx_plugin_kind"`
+	KeySpec             string `gorm:"type:varchar(32)"`
+	KeyUsage            string `gorm:"type:varchar(32)"`
+	KeyState            string `gorm:"type:varchar(32)"`
+	Description         string `gorm:"column:remark;type:varchar(1024)"`
+	PrimaryKeyVersionID string `gorm:"type:varchar(64)"`
+	RotationPeriodDays  int
+	// NextRotationAt and DeletionDate are not null in table, HasNextRotation and HasDeletionDate mark whether they are set
+	HasNextRotation bool
+	NextRotationAt  time.Time
+	HasDeletionDate bool
+	DeletionDate    time.Time
+}
+
+func (KmsKey) TableName() string {
+	return "kms_keys"
+}
+
+// KmsKeyVersion CMK 的密钥版本, 包含密钥材料
+type KmsKeyVersion struct {
+	dbengine.BaseModel
+	KeyID              string `gorm:"type:varchar(64);unique_index:uk_key_id_version_id"`
+	VersionID          string `gorm:"type:varchar(64);unique_index:uk_key_id_version_id"`
+	SymmetricKeyBase64 string `gorm:"type:text"`
+	PrivateKeyBase64   string `gorm:"type:text"`
+}
+
+func (KmsKeyVersion) TableName() string {
+	return "kms_key_versions"
+}
+
+// KmsKeyRotation CMK 密钥版本轮转历史
+type KmsKeyRotation struct {
+	dbengine.BaseModel
+	KeyID         string `gorm:"type:varchar(64);index:idx_key_id"`
+	FromVersionID string `gorm:"type:varchar(64)"`
+	ToVersionID   string `gorm:"type:varchar(64)"`
+	RotatedAt     time.Time
+}
+
+func (KmsKeyRotation) TableName() string {
+	return "kms_key_rotations"
+}
+
+// KmsAuditEvent kms 操作审计事件
+type KmsAuditEvent struct {
+	dbengine.BaseModel
+	RequestID      string `gorm:"type:varchar(64)"`
+	UserID         string `gorm:"type:varchar(64)"`
+	InternalClient string `gorm:"type:varchar(64)"`
+	ClientIP       string `gorm:"type:varchar(64)"`
+	Operation      string `gorm:"type:varchar(64)"`
+	PluginKind     string `gorm:"type:varchar(32)"`
+	KeyID          string `gorm:"type:varchar(64);index:idx_key_id_operated_at"`
+	KeyVersionID   string `gorm:"type:varchar(64)"`
+	IsSuccess      bool
+	ErrorMsg       string    `gorm:"type:varchar(1024)"`
+	OperatedAt     time.Time `gorm:"index:idx_key_id_operated_at;index:idx_operated_at"`
+}
+
+func (KmsAuditEvent) TableName() string {
+	return "kms_audit_events"
+}
+
+func fromKeyInfo(keyInfo kmstypes.KeyInfo) KmsKey {
+	hasNextRotation, nextRotationAt := fromNullableTime(keyInfo.GetNextRotationAt())
+	hasDeletionDate, deletionDate := fromNullableTime(keyInfo.GetDeletionDate())
+	return KmsKey{
+		KeyID:               keyInfo.GetKeyID(),
+		PluginKind:          keyInfo.GetPluginKind().String(),
+		KeySpec:             string(keyInfo.GetKeySpec()),
+		KeyUsage:            string(keyInfo.GetKeyUsage()),
+		KeyState:            string(keyInfo.GetKeyState()),
+		Description:         keyInfo.GetDescription(),
+		PrimaryKeyVersionID: keyInfo.GetPrimaryKeyVersion().GetVersionID(),
+		RotationPeriodDays:  keyInfo.GetRotationPeriodDays(),
+		HasNextRotation:     hasNextRotation,
+		NextRotationAt:      nextRotationAt,
+		HasDeletionDate:     hasDeletionDate,
+		DeletionDate:        deletionDate,
+	}
+}
+
+func fromKeyVersionInfo(keyID string, keyVersionInfo kmstypes.KeyVersionInfo) KmsKeyVersion {
+	v := KmsKeyVersion{
+		KeyID:              keyID,
+		VersionID:          keyVersionInfo.GetVersionID(),
+		SymmetricKeyBase64: keyVersionInfo.GetSymmetricKeyBase64(),
+		PrivateKeyBase64:   keyVersionInfo.GetPrivateKeyBase64(),
+	}
+	if t := keyVersionInfo.GetCreatedAt(); t != nil {
+		v.CreatedAt = *t
+	}
+	if t := keyVersionInfo.GetUpdatedAt(); t != nil {
+		v.UpdatedAt = *t
+	}
+	return v
+}
+
+func (k KmsKey) toKey(primaryKeyVersion KmsKeyVersion) *kmstypes.Key {
+	createdAt, updatedAt := k.CreatedAt, k.UpdatedAt
+	return &kmstypes.Key{
+		PluginKind:         kmstypes.PluginKind(k.PluginKind),
+		KeyID:              k.KeyID,
+		PrimaryKeyVersion:  *primaryKeyVersion.toKeyVersion(),
+		KeySpec:            kmstypes.CustomerMasterKeySpec(k.KeySpec),
+		KeyUsage:           kmstypes.KeyUsage(k.KeyUsage),
+		KeyState:           kmstypes.KeyState(k.KeyState),
+		Description:        k.Description,
+		RotationPeriodDays: k.RotationPeriodDays,
+		NextRotationAt:     toNullableTime(k.HasNextRotation, k.NextRotationAt),
+		DeletionDate:       toNullableTime(k.HasDeletionDate, k.DeletionDate),
+		CreatedAt:          &createdAt,
+		UpdatedAt:          &updatedAt,
+	}
+}
+
+func (v KmsKeyVersion) toKeyVersion() *kmstypes.KeyVersion {
+	createdAt, updatedAt := v.CreatedAt, v.UpdatedAt
+	return &kmstypes.KeyVersion{
+		VersionID:          v.VersionID,
+		SymmetricKeyBase64: v.SymmetricKeyBase64,
+		PrivateKeyBase64:   v.PrivateKeyBase64,
+		CreatedAt:          &createdAt,
+		UpdatedAt:          &updatedAt,
+	}
+}
+
+// fromNullableTime return whether time is set and the time to store, unset time is stored as unix epoch
+func fromNullableTime(t *time.Time) (bool, time.Time) {
+	if t == nil {
+		return false, time.Unix(0, 0)
+	}
+	return true, *t
+}
+
+func toNullableTime(set bool, t time.Time) *time.Time {
+	if !set {
+		return nil
+	}
+	return &t
+}
+
+func fromAuditEvent(event kmstypes.AuditEvent) KmsAuditEvent {
+	errorMsg := event.ErrorMsg
+	if runes := []rune(errorMsg); len(runes) > 1024 {
+		errorMsg = string(runes[:1024])
+	}
+	return KmsAuditEvent{
+		RequestID:      event.RequestID,
+		UserID:         event.Caller.UserID,
+		InternalClient: event.Caller.InternalClient,
+		ClientIP:       event.Caller.ClientIP,
+		Operation:      string(event.Operation),
+		PluginKind:     string(event.PluginKind),
+		KeyID:          event.KeyID,
+		KeyVersionID:   event.KeyVersionID,
+		IsSuccess:      event.Success,
+		ErrorMsg:       errorMsg,
+		OperatedAt:     event.OperatedAt,
+	}
+}
+
+func (e KmsAuditEvent) toAuditEvent() kmstypes.AuditEvent {
+	return kmstypes.AuditEvent{
+		RequestID: e.RequestID,
+		Caller: kmstypes.Caller{
+			UserID:         e.UserID,
+			InternalClient: e.InternalClient,
+			ClientIP:       e.ClientIP,
+		},
+		Operation:    kmstypes.AuditOperation(e.Operation),
+		PluginKind:   kmstypes.PluginKind(e.PluginKind),
+		KeyID:        e.KeyID,
+		KeyVersionID: e.KeyVersionID,
+		Success:      e.IsSuccess,
+		ErrorMsg:     e.ErrorMsg,
+		OperatedAt:   e.OperatedAt,
+	}
+}
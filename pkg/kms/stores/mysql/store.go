@@ -12,3 +12,272 @@
 // along with this program. If not, see <http://www.gnu.org/licenses/>.
 
 package mysql
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/pkg/dbengine"
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+)
+
+const (
+	EnvKeyMySQLURL      = "MYSQL_URL"
+	EnvKeyMySQLHost     = "MYSQL_HOST"
+	EnvKeyMySQLPort     = "MYSQL_PORT"
+	EnvKeyMySQLUsername = "MYSQL_USERNAME"
+	EnvKeyMySQLPassword = "MYSQL_PASSWORD"
+	EnvKeyMySQLDatabase = "MYSQL_DATABASE"
+)
+
+type Store struct {
+	db *gorm.DB
+}
+
+func init() {
+	logrus.Infof("begin register mysql store createFn to factory...")
+	err := kmstypes.RegisterStore(kmstypes.StoreKind_MYSQL, func(ctx context.Context) kmstypes.Store {
+		configMapValue := ctx.Value(kmstypes.CtxKeyConfigMap)
+		if configMapValue == nil {
+			panic("failed to init mysql store, no configs passed in")
+		}
+		configMap := configMapValue.(map[string]string)
+		if configMap[EnvKeyMySQLURL] == "" && configMap[EnvKeyMySQLHost] == "" {
+			panic("failed to init mysql store, missing env MYSQL_HOST or MYSQL_URL")
+		}
+		engine, err := dbengine.Open(&dbengine.Conf{
+			MySQLURL:      configMap[EnvKeyMySQLURL],
+			MySQLHost:     configMap[EnvKeyMySQLHost],
+			MySQLPort:     configMap[EnvKeyMySQLPort],
+			MySQLUsername: configMap[EnvKeyMySQLUsername],
+			MySQLPassword: configMap[EnvKeyMySQLPassword],
+			MySQLDatabase: configMap[EnvKeyMySQLDatabase],
+			MySQLCharset:  "utf8mb4",
+		})
+		if err != nil {
+			panic(fmt.Errorf("failed to init mysql client, err: %v", err))
+		}
+		return New(engine.DB)
+	})
+	if err != nil {
+		logrus.Errorf("[alert] failed to register mysql store createFn to factory, err: %v", err)
+		os.Exit(1)
+	}
+}
+
+// New create mysql store by gorm db.
+func New(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetKind() kmstypes.StoreKind {
+	return kmstypes.StoreKind_MYSQL
+}
+
+func (s *Store) CreateKey(keyInfo kmstypes.KeyInfo) error {
+	err := kmstypes.CheckKeyForCreate(keyInfo)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	key := fromKeyInfo(keyInfo)
+	key.CreatedAt, key.UpdatedAt = now, now
+	keyVersion := fromKeyVersionInfo(keyInfo.GetKeyID(), keyInfo.GetPrimaryKeyVersion())
+	keyVersion.CreatedAt, keyVersion.UpdatedAt = now, now
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&key).Error; err != nil {
+			return err
+		}
+		return tx.Create(&keyVersion).Error
+	})
+}
+
+func (s *Store) GetKey(keyID string) (kmstypes.KeyInfo, error) {
+	var key KmsKey
+	if err := s.db.Where("key_id = ?", keyID).First(&key).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, fmt.Errorf("key not exist")
+		}
+		return nil, fmt.Errorf("get key from mysql failed, err: %v", err)
+	}
+	var primaryKeyVersion KmsKeyVersion
+	if err := s.db.Where("key_id = ? AND version_id = ?", keyID, key.PrimaryKeyVersionID).First(&primaryKeyVersion).Error; err != nil {
+		return nil, fmt.Errorf("get primary key version from mysql failed, err: %v", err)
+	}
+	return key.toKey(primaryKeyVersion), nil
+}
+
+func (s *Store) UpdateKey(keyInfo kmstypes.KeyInfo) error {
+	hasNextRotation, nextRotationAt := fromNullableTime(keyInfo.GetNextRotationAt())
+	hasDeletionDate, deletionDate := fromNullableTime(keyInfo.GetDeletionDate())
+	result := s.db.Model(&KmsKey{}).Where("key_id = ?", keyInfo.GetKeyID()).
+		Updates(map[string]interface{}{
+			"key_state":            string(keyInfo.GetKeyState()),
+			"remark":               keyInfo.GetDescription(),
+			"rotation_period_days": keyInfo.GetRotationPeriodDays(),
+			"has_next_rotation":    hasNextRotation,
+			"next_rotation_at":     nextRotationAt,
+			"has_deletion_date":    hasDeletionDate,
+			"deletion_date":        deletionDate,
+			"updated_at":           time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("key not exist")
+	}
+	return nil
+}
+
+func (s *Store) ListKeysByKind(kind kmstypes.PluginKind) ([]string, error) {
+	var keyIDs []string
+	if err := s.db.Model(&KmsKey{}).Where("plugin_kind = ?", kind).Order("id").Pluck("key_id", &keyIDs).Error; err != nil {
+		return nil, err
+	}
+	return keyIDs, nil
+}
+
+func (s *Store) DeleteByKeyID(keyID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("key_id = ?", keyID).Delete(&KmsKeyRotation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("key_id = ?", keyID).Delete(&KmsKeyVersion{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("key_id = ?", keyID).Delete(&KmsKey{}).Error
+	})
+}
+
+func (s *Store) GetKeyVersion(keyID, keyVersionID string) (kmstypes.KeyVersionInfo, error) {
+	var keyVersion KmsKeyVersion
+	if err := s.db.Where("key_id = ? AND version_id = ?", keyID, keyVersionID).First(&keyVersion).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, fmt.Errorf("key version not exist")
+		}
+		return nil, err
+	}
+	return keyVersion.toKeyVersion(), nil
+}
+
+func (s *Store) ListKeyVersions(keyID string) ([]kmstypes.KeyVersionInfo, error) {
+	var keyVersions []KmsKeyVersion
+	if err := s.db.Where("key_id = ?", keyID).Order("id").Find(&keyVersions).Error; err != nil {
+		return nil, err
+	}
+	result := make([]kmstypes.KeyVersionInfo, 0, len(keyVersions))
+	for _, v := range keyVersions {
+		result = append(result, v.toKeyVersion())
+	}
+	return result, nil
+}
+
+// RotateKeyVersion insert new key version, switch primary key version and record rotation history in one transaction.
+// The primary key version is switched by compare-and-swap, so concurrent rotations of the same key fail instead of
+// overwriting each other.
+func (s *Store) RotateKeyVersion(keyID string, newKeyVersionInfo kmstypes.KeyVersionInfo) (kmstypes.KeyVersionInfo, error) {
+	now := time.Now()
+	newKeyVersionInfo.SetCreatedAt(now)
+	newKeyVersionInfo.SetUpdatedAt(now)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var key KmsKey
+		if err := tx.Where("key_id = ?", keyID).First(&key).Error; err != nil {
+			if gorm.IsRecordNotFoundError(err) {
+				return fmt.Errorf("key not exist")
+			}
+			return err
+		}
+		keyVersion := fromKeyVersionInfo(keyID, newKeyVersionInfo)
+		if err := tx.Create(&keyVersion).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&KmsKey{}).
+			Where("key_id = ? AND primary_key_version_id = ?", keyID, key.PrimaryKeyVersionID).
+			Updates(map[string]interface{}{"primary_key_version_id": keyVersion.VersionID, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return fmt.Errorf("key version has been rotated concurrently")
+		}
+		rotation := KmsKeyRotation{
+			KeyID:         keyID,
+			FromVersionID: key.PrimaryKeyVersionID,
+			ToVersionID:   keyVersion.VersionID,
+			RotatedAt:     now,
+		}
+		return tx.Create(&rotation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newKeyVersionInfo, nil
+}
+
+// ListKeyRotations list rotation history of key, order by rotated time.
+func (s *Store) ListKeyRotations(keyID string) ([]KmsKeyRotation, error) {
+	var rotations []KmsKeyRotation
+	if err := s.db.Where("key_id = ?", keyID).Order("id").Find(&rotations).Error; err != nil {
+		return nil, err
+	}
+	return rotations, nil
+}
+
+func (s *Store) CreateAuditEvent(event kmstypes.AuditEvent) error {
+	auditEvent := fromAuditEvent(event)
+	return s.db.Create(&auditEvent).Error
+}
+
+func (s *Store) ListAuditEvents(req *kmstypes.ListAuditEventsRequest) (int, []kmstypes.AuditEvent, error) {
+	sql := s.db.Model(&KmsAuditEvent{})
+	if req.KeyID != "" {
+		sql = sql.Where("key_id = ?", req.KeyID)
+	}
+	if req.Operation != "" {
+		sql = sql.Where("operation = ?", req.Operation)
+	}
+	if req.UserID != "" {
+		sql = sql.Where("user_id = ?", req.UserID)
+	}
+	if req.InternalClient != "" {
+		sql = sql.Where("internal_client = ?", req.InternalClient)
+	}
+	if req.OnlyFailed {
+		sql = sql.Where("is_success = ?", false)
+	}
+	if req.StartTime > 0 {
+		sql = sql.Where("operated_at >= ?", time.Unix(req.StartTime, 0))
+	}
+	if req.EndTime > 0 {
+		sql = sql.Where("operated_at <= ?", time.Unix(req.EndTime, 0))
+	}
+
+	var total int
+	if err := sql.Count(&total).Error; err != nil {
+		return 0, nil, err
+	}
+	var auditEvents []KmsAuditEvent
+	if err := sql.Order("operated_at DESC, id DESC").
+		Offset((req.PageNo - 1) * req.PageSize).Limit(req.PageSize).
+		Find(&auditEvents).Error; err != nil {
+		return 0, nil, err
+	}
+	events := make([]kmstypes.AuditEvent, 0, len(auditEvents))
+	for _, e := range auditEvents {
+		events = append(events, e.toAuditEvent())
+	}
+	return total, events, nil
+}
+
+func (s *Store) DeleteAuditEventsBefore(t time.Time) error {
+	return s.db.Where("operated_at < ?", t).Delete(&KmsAuditEvent{}).Error
+}
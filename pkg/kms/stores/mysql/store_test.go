@@ -0,0 +1,185 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mysql
+
+import (
+	"io/ioutil"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/pkg/kms/kmstypes"
+)
+
+// newTestStore create store backed by in-memory sqlite, tables are created by models
+func newTestStore(t *testing.T) *Store {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	assert.NoError(t, db.AutoMigrate(&KmsKey{}, &KmsKeyVersion{}, &KmsKeyRotation{}, &KmsAuditEvent{}).Error)
+	return New(db)
+}
+
+func newTestKey(keyID, versionID string) *kmstypes.Key {
+	return &kmstypes.Key{
+		PluginKind:        kmstypes.PluginKind_DICE_KMS,
+		KeyID:             keyID,
+		PrimaryKeyVersion: kmstypes.KeyVersion{VersionID: versionID, SymmetricKeyBase64: "c3ltbWV0cmlj"},
+		KeySpec:           kmstypes.CustomerMasterKeySpec_SYMMETRIC_DEFAULT,
+		KeyUsage:          kmstypes.KeyUsage_ENCRYPT_DECRYPT,
+		KeyState:          kmstypes.KeyStateEnabled,
+		Description:       "test key",
+	}
+}
+
+func TestStore_KeyCRUD(t *testing.T) {
+	s := newTestStore(t)
+
+	assert.Error(t, s.CreateKey(&kmstypes.Key{KeyID: "k1"}))
+	assert.NoError(t, s.CreateKey(newTestKey("k1", "v1")))
+	assert.Error(t, s.CreateKey(newTestKey("k1", "v1")), "duplicate key id")
+
+	keyInfo, err := s.GetKey("k1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", keyInfo.GetPrimaryKeyVersion().GetVersionID())
+	assert.Equal(t, "c3ltbWV0cmlj", keyInfo.GetPrimaryKeyVersion().GetSymmetricKeyBase64())
+	assert.Equal(t, kmstypes.KeyStateEnabled, keyInfo.GetKeyState())
+	assert.Nil(t, keyInfo.GetNextRotationAt())
+	assert.Nil(t, keyInfo.GetDeletionDate())
+
+	deletionDate := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+	keyInfo.SetKeyState(kmstypes.KeyStatePendingDeletion)
+	keyInfo.SetDeletionDate(&deletionDate)
+	assert.NoError(t, s.UpdateKey(keyInfo))
+	keyInfo, err = s.GetKey("k1")
+	assert.NoError(t, err)
+	assert.Equal(t, kmstypes.KeyStatePendingDeletion, keyInfo.GetKeyState())
+	assert.True(t, deletionDate.Equal(*keyInfo.GetDeletionDate()))
+
+	// time set explicitly is kept even if it is unix epoch
+	epoch := time.Unix(0, 0)
+	keyInfo.SetRotationPeriodDays(30)
+	keyInfo.SetNextRotationAt(&epoch)
+	keyInfo.SetDeletionDate(nil)
+	assert.NoError(t, s.UpdateKey(keyInfo))
+	keyInfo, err = s.GetKey("k1")
+	assert.NoError(t, err)
+	assert.NotNil(t, keyInfo.GetNextRotationAt())
+	assert.True(t, epoch.Equal(*keyInfo.GetNextRotationAt()))
+	assert.Nil(t, keyInfo.GetDeletionDate())
+
+	assert.Error(t, s.UpdateKey(newTestKey("not-exist", "v1")))
+	_, err = s.GetKey("not-exist")
+	assert.Error(t, err)
+
+	keyIDs, err := s.ListKeysByKind(kmstypes.PluginKind_DICE_KMS)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k1"}, keyIDs)
+
+	assert.NoError(t, s.DeleteByKeyID("k1"))
+	_, err = s.GetKey("k1")
+	assert.Error(t, err)
+	_, err = s.GetKeyVersion("k1", "v1")
+	assert.Error(t, err)
+}
+
+func TestStore_RotateKeyVersion(t *testing.T) {
+	s := newTestStore(t)
+	assert.NoError(t, s.CreateKey(newTestKey("k1", "v1")))
+
+	_, err := s.RotateKeyVersion("k1", &kmstypes.KeyVersion{VersionID: "v2", SymmetricKeyBase64: "djI="})
+	assert.NoError(t, err)
+	_, err = s.RotateKeyVersion("not-exist", &kmstypes.KeyVersion{VersionID: "v1"})
+	assert.Error(t, err)
+	// duplicate version id is rejected by unique index and rolled back
+	_, err = s.RotateKeyVersion("k1", &kmstypes.KeyVersion{VersionID: "v2"})
+	assert.Error(t, err)
+
+	keyInfo, err := s.GetKey("k1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", keyInfo.GetPrimaryKeyVersion().GetVersionID())
+
+	versions, err := s.ListKeyVersions("k1")
+	assert.NoError(t, err)
+	assert.Len(t, versions, 2)
+	v1, err := s.GetKeyVersion("k1", "v1")
+	assert.NoError(t, err)
+	assert.Equal(t, "c3ltbWV0cmlj", v1.GetSymmetricKeyBase64())
+
+	rotations, err := s.ListKeyRotations("k1")
+	assert.NoError(t, err)
+	assert.Len(t, rotations, 1)
+	assert.Equal(t, "v1", rotations[0].FromVersionID)
+	assert.Equal(t, "v2", rotations[0].ToVersionID)
+}
+
+func TestStore_CopyFrom(t *testing.T) {
+	src, dst := newTestStore(t), newTestStore(t)
+	assert.NoError(t, src.CreateKey(newTestKey("k1", "v1")))
+	_, err := src.RotateKeyVersion("k1", &kmstypes.KeyVersion{VersionID: "v2"})
+	assert.NoError(t, err)
+	assert.NoError(t, src.CreateKey(newTestKey("k2", "v1")))
+	assert.NoError(t, dst.CreateKey(newTestKey("k2", "v1")))
+
+	result, err := dst.CopyFrom(src, []kmstypes.PluginKind{kmstypes.PluginKind_DICE_KMS}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k1"}, result.Copied)
+	assert.Equal(t, []string{"k2"}, result.Skipped)
+	_, err = dst.GetKey("k1")
+	assert.Error(t, err, "dry run should not write")
+
+	result, err = dst.CopyFrom(src, []kmstypes.PluginKind{kmstypes.PluginKind_DICE_KMS}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k1"}, result.Copied)
+	keyInfo, err := dst.GetKey("k1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", keyInfo.GetPrimaryKeyVersion().GetVersionID())
+	versions, err := dst.ListKeyVersions("k1")
+	assert.NoError(t, err)
+	assert.Len(t, versions, 2)
+
+	// rerun skips copied keys
+	result, err = dst.CopyFrom(src, []kmstypes.PluginKind{kmstypes.PluginKind_DICE_KMS}, false)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Copied)
+	assert.Equal(t, []string{"k1", "k2"}, result.Skipped)
+}
+
+// TestMigrationMatchesModels check every model column is created by the sql migration
+func TestMigrationMatchesModels(t *testing.T) {
+	data, err := ioutil.ReadFile("../../../../modules/kms/sqls/4.0.sql")
+	assert.NoError(t, err)
+	sql := string(data)
+
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	for _, model := range []interface{}{&KmsKey{}, &KmsKeyVersion{}, &KmsKeyRotation{}, &KmsAuditEvent{}} {
+		scope := db.NewScope(model)
+		table := regexp.MustCompile("(?s)CREATE TABLE IF NOT EXISTS `" + scope.TableName() + "`\\s*\\((.*?)\\) ENGINE").FindStringSubmatch(sql)
+		if !assert.Len(t, table, 2, "table %s not found in migration", scope.TableName()) {
+			continue
+		}
+		for _, field := range scope.GetModelStruct().StructFields {
+			if field.IsIgnored {
+				continue
+			}
+			assert.Contains(t, table[1], "`"+field.DBName+"`", "column %s.%s not found in migration", scope.TableName(), field.DBName)
+		}
+	}
+}
@@ -17,9 +17,12 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/robfig/cron"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type BasicValidateVisitor struct {
@@ -99,6 +102,44 @@ func (o *BasicValidateVisitor) VisitService(v DiceYmlVisitor, obj *Service) {
 			break
 		}
 	}
+	// files are stored by key in configmap, e.g. /a/b.c and /a.b/c are both a.b.c, they can not be mounted together
+	keys := make(map[string]struct{}, len(obj.Files))
+	for _, file := range obj.Files {
+		sources := 0
+		for _, source := range []string{file.Config, file.Certificate, file.Content} {
+			if source != "" {
+				sources++
+			}
+		}
+		_, duplicated := keys[ConfigFileKey(file.Path)]
+		if !path.IsAbs(file.Path) || strings.HasSuffix(file.Path, "/") || duplicated || sources != 1 {
+			o.collectErrors[yamlHeaderRegexWithUpperHeader([]string{o.currentService}, "files")] = errors.Wrap(invalidConfigFile, o.currentService+":["+file.Path+"]")
+			break
+		}
+		keys[ConfigFileKey(file.Path)] = struct{}{}
+	}
+	if pdb := obj.DisruptionBudget; pdb != nil {
+		if (pdb.MinAvailable == "") == (pdb.MaxUnavailable == "") ||
+			!isValidDisruptionValue(pdb.MinAvailable) || !isValidDisruptionValue(pdb.MaxUnavailable) {
+			o.collectErrors[yamlHeaderRegexWithUpperHeader([]string{o.currentService}, "disruption_budget")] = errors.Wrap(invalidDisruptionBudget, o.currentService)
+		}
+	}
+	if lc := obj.Lifecycle; lc != nil && lc.TerminationGraceSeconds != nil && *lc.TerminationGraceSeconds < 0 {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader([]string{o.currentService, "lifecycle"}, "termination_grace_seconds")] = errors.Wrap(invalidLifecycle, o.currentService)
+	}
+}
+
+// isValidDisruptionValue empty, non-negative number or percentage between 0% and 100%
+func isValidDisruptionValue(v string) bool {
+	if v == "" {
+		return true
+	}
+	if strings.HasSuffix(v, "%") {
+		p, err := strconv.Atoi(strings.TrimSuffix(v, "%"))
+		return err == nil && p >= 0 && p <= 100
+	}
+	n, err := strconv.Atoi(v)
+	return err == nil && n >= 0
 }
 func (o *BasicValidateVisitor) VisitBinds(v DiceYmlVisitor, obj *Binds) {
 	for _, bind := range *obj {
@@ -155,6 +196,198 @@ func (o *BasicValidateVisitor) VisitDeployments(v DiceYmlVisitor, obj *Deploymen
 	if obj.Policies != "" && obj.Policies != "shuffle" && obj.Policies != "affinity" && obj.Policies != "unique" {
 		o.collectErrors[yamlHeaderRegexWithUpperHeader([]string{o.currentService, "deployments"}, "policies")] = errors.Wrap(invalidPolicy, o.currentService)
 	}
+	if obj.Autoscaling != nil {
+		o.validateAutoscaling(obj)
+	}
+	if obj.Strategy != nil {
+		o.validateStrategy(obj)
+	}
+	o.validateSpreadSelectors(obj.Selectors)
+}
+
+func (o *BasicValidateVisitor) validateSpreadSelectors(selectors Selectors) {
+	header := []string{o.currentService, "deployments", "selectors"}
+	invalid := func(key, msg string) {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, key)] = errors.Wrap(invalidSpreadSelector, o.currentService+": "+msg)
+	}
+	for key, selector := range selectors {
+		switch key {
+		case SelectorSpread:
+			if selector.Not || len(selector.Values) != 1 || selector.Values[0] == "" {
+				invalid(key, "spread must be a single topology, e.g. zone, rack or host")
+			}
+		case SelectorSpreadMaxSkew:
+			if skew, err := strconv.Atoi(strings.Join(selector.Values, "")); selector.Not || len(selector.Values) != 1 || err != nil || skew < 1 {
+				invalid(key, "spread-max-skew must be a positive integer")
+			}
+		case SelectorSpreadMode:
+			if selector.Not || len(selector.Values) != 1 || (selector.Values[0] != SpreadModeHard && selector.Values[0] != SpreadModeSoft) {
+				invalid(key, "spread-mode must be hard or soft")
+			}
+		case SelectorAntiAffinity:
+			if selector.Not {
+				invalid(key, "anti-affinity must be services joined by OR")
+			}
+		}
+	}
+	_, hasSpread := selectors[SelectorSpread]
+	_, hasSkew := selectors[SelectorSpreadMaxSkew]
+	if hasSkew && !hasSpread {
+		invalid(SelectorSpreadMaxSkew, "spread-max-skew requires spread")
+	}
+}
+
+func (o *BasicValidateVisitor) VisitHealthCheck(v DiceYmlVisitor, obj *HealthCheck) {
+	if o.currentService == "" {
+		return
+	}
+	header := []string{o.currentService, "health_check"}
+	if obj.TCP != nil && obj.TCP.Port <= 0 {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "tcp")] = errors.Wrap(invalidHealthCheck, o.currentService+": invalid tcp port")
+	}
+	if obj.GRPC != nil && obj.GRPC.Port <= 0 {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "grpc")] = errors.Wrap(invalidHealthCheck, o.currentService+": invalid grpc port")
+	}
+	o.validateProbe("liveness", obj.Liveness)
+	o.validateProbe("readiness", obj.Readiness)
+	o.validateProbe("startup", obj.Startup)
+}
+
+func (o *BasicValidateVisitor) validateProbe(name string, probe *Probe) {
+	if probe == nil {
+		return
+	}
+	header := []string{o.currentService, "health_check"}
+	checks := 0
+	valid := true
+	if probe.HTTP != nil {
+		checks++
+		valid = valid && probe.HTTP.Port > 0 && probe.HTTP.Path != ""
+	}
+	if probe.Exec != nil {
+		checks++
+		valid = valid && probe.Exec.Cmd != ""
+	}
+	if probe.TCP != nil {
+		checks++
+		valid = valid && probe.TCP.Port > 0
+	}
+	if probe.GRPC != nil {
+		checks++
+		valid = valid && probe.GRPC.Port > 0
+	}
+	if checks != 1 || !valid {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, name)] = errors.Wrap(invalidProbe, o.currentService+": "+name)
+		return
+	}
+	if probe.InitialDelaySeconds < 0 || probe.PeriodSeconds < 0 || probe.TimeoutSeconds < 0 ||
+		probe.SuccessThreshold < 0 || probe.FailureThreshold < 0 {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, name)] = errors.Wrap(invalidProbeTiming, o.currentService+": "+name)
+		return
+	}
+	// k8s requires successThreshold of liveness and startup probe to be 1
+	if name != "readiness" && probe.SuccessThreshold > 1 {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(append(header, name), "success_threshold")] = errors.Wrap(invalidProbeTiming, o.currentService+": success_threshold of "+name+" must be 1")
+	}
+}
+
+func (o *BasicValidateVisitor) validateStrategy(obj *Deployments) {
+	header := []string{o.currentService, "deployments", "strategy"}
+	st := obj.Strategy
+	switch st.Type {
+	case "", StrategyRolling:
+	case StrategyCanary, StrategyBlueGreen:
+		if obj.Workload == "per_node" || obj.Autoscaling != nil {
+			o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "type")] = errors.Wrap(invalidStrategy, o.currentService+": "+st.Type+" not support per_node workload or autoscaling")
+			return
+		}
+	default:
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "type")] = errors.Wrap(invalidStrategy, o.currentService)
+		return
+	}
+	isIntOrPercent := regexp.MustCompile(`^[0-9]+%?$`)
+	if st.MaxSurge != "" && !isIntOrPercent.MatchString(st.MaxSurge) {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "max_surge")] = errors.Wrap(invalidStrategy, o.currentService)
+	}
+	if st.MaxUnavailable != "" && !isIntOrPercent.MatchString(st.MaxUnavailable) {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "max_unavailable")] = errors.Wrap(invalidStrategy, o.currentService)
+	}
+	if st.Type != StrategyCanary {
+		if len(st.CanarySteps) > 0 {
+			o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "canary_steps")] = errors.Wrap(invalidCanaryStep, o.currentService+": canary_steps only works with canary strategy")
+		}
+		return
+	}
+	if len(st.CanarySteps) == 0 {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "canary_steps")] = errors.Wrap(invalidCanaryStep, o.currentService+": empty canary_steps")
+		return
+	}
+	lastWeight := 0
+	for _, step := range st.CanarySteps {
+		// weight of steps must be increasing, and the last step could not be 100 which is the same as promoting
+		if step.Weight <= lastWeight || step.Weight >= 100 || step.Pause < 0 {
+			o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "canary_steps")] = errors.Wrap(invalidCanaryStep, o.currentService)
+			break
+		}
+		lastWeight = step.Weight
+	}
+}
+
+func (o *BasicValidateVisitor) validateAutoscaling(obj *Deployments) {
+	deploymentsHeader := []string{o.currentService, "deployments"}
+	// daemonset can not be scaled by replicas
+	if obj.Workload == "per_node" {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(deploymentsHeader, "autoscaling")] = errors.Wrap(invalidAutoscaling, o.currentService+": per_node workload not support autoscaling")
+		return
+	}
+	header := []string{o.currentService, "deployments", "autoscaling"}
+	as := obj.Autoscaling
+	if as.MinReplicas < 1 {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "min_replicas")] = errors.Wrap(invalidAutoscalingReplicas, o.currentService)
+	}
+	if as.MaxReplicas < 1 || as.MaxReplicas < as.MinReplicas {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "max_replicas")] = errors.Wrap(invalidAutoscalingReplicas, o.currentService)
+	}
+	if as.CPUUtilization < 0 {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "cpu_utilization")] = errors.Wrap(invalidAutoscalingTarget, o.currentService)
+	}
+	if as.MemUtilization < 0 {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "mem_utilization")] = errors.Wrap(invalidAutoscalingTarget, o.currentService)
+	}
+	for _, m := range as.Metrics {
+		if m.Name == "" {
+			o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "metrics")] = errors.Wrap(invalidAutoscalingMetric, o.currentService+": empty metric name")
+			break
+		}
+		if q, err := resource.ParseQuantity(m.TargetAverageValue); err != nil || q.Sign() <= 0 {
+			o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "metrics")] = errors.Wrap(invalidAutoscalingMetric, o.currentService+": invalid target_average_value of metric "+m.Name)
+			break
+		}
+	}
+	if as.CPUUtilization == 0 && as.MemUtilization == 0 && len(as.Metrics) == 0 {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(deploymentsHeader, "autoscaling")] = errors.Wrap(invalidAutoscalingTarget, o.currentService+": at least one of cpu_utilization, mem_utilization and metrics is required")
+	}
+}
+
+func (o *BasicValidateVisitor) VisitJob(v DiceYmlVisitor, obj *Job) {
+	if obj.Schedule == nil {
+		return
+	}
+	header := []string{o.currentJob, "schedule"}
+	if _, err := cron.ParseStandard(obj.Schedule.Cron); err != nil {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "cron")] = errors.Wrap(invalidJobSchedule, o.currentJob+": "+err.Error())
+	}
+	switch obj.Schedule.ConcurrencyPolicy {
+	case "", "Allow", "Forbid", "Replace":
+	default:
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "concurrency_policy")] = errors.Wrap(invalidJobSchedule, o.currentJob+": concurrency_policy must be one of Allow, Forbid and Replace")
+	}
+	if limit := obj.Schedule.SuccessfulJobsHistoryLimit; limit != nil && *limit < 0 {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "successful_jobs_history_limit")] = errors.Wrap(invalidJobSchedule, o.currentJob+": history limit must not be negative")
+	}
+	if limit := obj.Schedule.FailedJobsHistoryLimit; limit != nil && *limit < 0 {
+		o.collectErrors[yamlHeaderRegexWithUpperHeader(header, "failed_jobs_history_limit")] = errors.Wrap(invalidJobSchedule, o.currentJob+": history limit must not be negative")
+	}
 }
 
 func (o *BasicValidateVisitor) VisitAddOns(v DiceYmlVisitor, obj *AddOns) {
@@ -84,3 +84,329 @@ func TestBasicValidate(t *testing.T) {
 	assert.Equal(t, 3, len(es), "%v", es)
 
 }
+
+var autoscaling_validate_yml = `version: 2.0
+services:
+  web:
+    resources:
+      cpu: 0.1
+      mem: 256
+    deployments:
+      replicas: 2
+      autoscaling:
+        min_replicas: 2
+        max_replicas: 10
+        cpu_utilization: 70
+        metrics:
+        - name: http_requests_per_second
+          target_average_value: 100
+  worker:
+    resources:
+      cpu: 0.1
+      mem: 256
+    deployments:
+      autoscaling:
+        min_replicas: 5
+        max_replicas: 2
+        metrics:
+        - name: queue_length
+          target_average_value: abc
+  agent:
+    resources:
+      cpu: 0.1
+      mem: 256
+    deployments:
+      workload: per_node
+      autoscaling:
+        min_replicas: 1
+        max_replicas: 2
+        cpu_utilization: 70
+`
+
+func TestBasicValidateAutoscaling(t *testing.T) {
+	d, err := New([]byte(autoscaling_validate_yml), false)
+	assert.Nil(t, err)
+	assert.Equal(t, "http_requests_per_second", d.Obj().Services["web"].Deployments.Autoscaling.Metrics[0].Name)
+	es := BasicValidate(d.Obj())
+	// worker: max_replicas < min_replicas, bad metric target; agent: per_node
+	assert.Equal(t, 3, len(es), "%v", es)
+}
+
+var strategy_validate_yml = `version: 2.0
+services:
+  web:
+    resources:
+      cpu: 0.1
+      mem: 256
+    deployments:
+      replicas: 4
+      strategy:
+        type: canary
+        max_surge: 25%
+        canary_steps:
+        - weight: 25
+        - weight: 50
+          pause: 600
+  api:
+    resources:
+      cpu: 0.1
+      mem: 256
+    deployments:
+      strategy:
+        type: blue_green
+        auto_promote: true
+  worker:
+    resources:
+      cpu: 0.1
+      mem: 256
+    deployments:
+      strategy:
+        type: canary
+        max_unavailable: abc
+        canary_steps:
+        - weight: 50
+        - weight: 20
+  agent:
+    resources:
+      cpu: 0.1
+      mem: 256
+    deployments:
+      strategy:
+        type: recreate
+`
+
+func TestBasicValidateStrategy(t *testing.T) {
+	d, err := New([]byte(strategy_validate_yml), false)
+	assert.Nil(t, err)
+	assert.Equal(t, 600, d.Obj().Services["web"].Deployments.Strategy.CanarySteps[1].Pause)
+	es := BasicValidate(d.Obj())
+	// worker: invalid max_unavailable, decreasing weight; agent: unknown type
+	assert.Equal(t, 3, len(es), "%v", es)
+}
+
+var probe_validate_yml = `version: 2.0
+services:
+  web:
+    resources:
+      cpu: 0.1
+      mem: 256
+    health_check:
+      http:
+        port: 8080
+        path: /health
+        duration: 120
+      startup:
+        http:
+          port: 8080
+          path: /health
+        period_seconds: 10
+        failure_threshold: 30
+      readiness:
+        tcp:
+          port: 8080
+        success_threshold: 2
+  grpc:
+    resources:
+      cpu: 0.1
+      mem: 256
+    health_check:
+      grpc:
+        port: 9090
+      liveness:
+        grpc:
+          port: 9090
+        success_threshold: 2
+  worker:
+    resources:
+      cpu: 0.1
+      mem: 256
+    health_check:
+      tcp:
+        port: 0
+      readiness:
+        exec:
+          cmd: ls
+        tcp:
+          port: 8080
+      startup:
+        exec:
+          cmd: ls
+        initial_delay_seconds: -1
+`
+
+func TestBasicValidateProbe(t *testing.T) {
+	d, err := New([]byte(probe_validate_yml), false)
+	assert.Nil(t, err)
+	assert.Equal(t, 30, d.Obj().Services["web"].HealthCheck.Startup.FailureThreshold)
+	es := BasicValidate(d.Obj())
+	// grpc: success_threshold of liveness; worker: tcp port, two checks in readiness, negative delay
+	assert.Equal(t, 4, len(es), "%v", es)
+}
+
+var job_schedule_validate_yml = `version: 2.0
+jobs:
+  cleanup:
+    image: busybox
+    cmd: echo cleanup
+    resources:
+      cpu: 0.1
+      mem: 128
+    schedule:
+      cron: "*/5 * * * *"
+      concurrency_policy: Forbid
+      successful_jobs_history_limit: 3
+  report:
+    image: busybox
+    cmd: echo report
+    resources:
+      cpu: 0.1
+      mem: 128
+    schedule:
+      cron: "0 0 1"
+      concurrency_policy: Never
+      failed_jobs_history_limit: -1
+`
+
+func TestBasicValidateJobSchedule(t *testing.T) {
+	d, err := New([]byte(job_schedule_validate_yml), false)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, *d.Obj().Jobs["cleanup"].Schedule.SuccessfulJobsHistoryLimit)
+	es := BasicValidate(d.Obj())
+	// report: cron, concurrency_policy, failed_jobs_history_limit
+	assert.Equal(t, 3, len(es), "%v", es)
+}
+
+var spread_validate_yml = `version: 2.0
+services:
+  payment:
+    resources:
+      cpu: 0.1
+      mem: 256
+    deployments:
+      replicas: 3
+      selectors:
+        spread: zone
+        spread-max-skew: 1
+        spread-mode: hard
+        anti-affinity: payment-db OR gateway
+  worker:
+    resources:
+      cpu: 0.1
+      mem: 256
+    deployments:
+      replicas: 2
+      selectors:
+        spread-max-skew: 0
+        spread-mode: strict
+        anti-affinity: NOT payment
+`
+
+func TestBasicValidateSpreadSelectors(t *testing.T) {
+	d, err := New([]byte(spread_validate_yml), false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"payment-db", "gateway"}, d.Obj().Services["payment"].Deployments.Selectors[SelectorAntiAffinity].Values)
+	es := BasicValidate(d.Obj())
+	// worker: spread-max-skew, spread-max-skew without spread, spread-mode, anti-affinity
+	assert.Equal(t, 4, len(es), "%v", es)
+}
+
+var config_files_validate_yml = `version: 2.0
+services:
+  web:
+    resources:
+      cpu: 0.1
+      mem: 256
+    files:
+    - path: /app/conf/application.yml
+      config: application.yml
+    - path: /app/certs/tls.key
+      certificate: web-tls
+    - path: /etc/motd
+      content: hello
+  relative:
+    resources:
+      cpu: 0.1
+      mem: 256
+    files:
+    - path: conf/application.yml
+      config: application.yml
+  ambiguous:
+    resources:
+      cpu: 0.1
+      mem: 256
+    files:
+    - path: /app/conf/application.yml
+      config: application.yml
+      content: hello
+  duplicated:
+    resources:
+      cpu: 0.1
+      mem: 256
+    files:
+    - path: /etc/motd
+      content: hello
+    - path: /etc/motd
+      content: world
+  conflicted:
+    resources:
+      cpu: 0.1
+      mem: 256
+    files:
+    - path: /a/b.c
+      content: hello
+    - path: /a.b/c
+      content: world
+`
+
+func TestBasicValidateConfigFiles(t *testing.T) {
+	d, err := New([]byte(config_files_validate_yml), false)
+	assert.Nil(t, err)
+	assert.Equal(t, "web-tls", d.Obj().Services["web"].Files[1].Certificate)
+	es := BasicValidate(d.Obj())
+	// relative, ambiguous, duplicated, conflicted
+	assert.Equal(t, 4, len(es), "%v", es)
+}
+
+var disruption_validate_yml = `version: 2.0
+services:
+  web:
+    resources:
+      cpu: 0.1
+      mem: 256
+    disruption_budget:
+      min_available: 1
+    lifecycle:
+      pre_stop: sleep 10
+      termination_grace_seconds: 30
+  api:
+    resources:
+      cpu: 0.1
+      mem: 256
+    disruption_budget:
+      max_unavailable: 50%
+  both:
+    resources:
+      cpu: 0.1
+      mem: 256
+    disruption_budget:
+      min_available: 1
+      max_unavailable: 1
+  percent:
+    resources:
+      cpu: 0.1
+      mem: 256
+    disruption_budget:
+      max_unavailable: 120%
+    lifecycle:
+      termination_grace_seconds: -1
+`
+
+func TestBasicValidateDisruptionBudget(t *testing.T) {
+	d, err := New([]byte(disruption_validate_yml), false)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", d.Obj().Services["web"].DisruptionBudget.MinAvailable)
+	assert.Equal(t, int64(30), *d.Obj().Services["web"].Lifecycle.TerminationGraceSeconds)
+	es := BasicValidate(d.Obj())
+	// both: min_available and max_unavailable; percent: max_unavailable, termination_grace_seconds
+	assert.Equal(t, 3, len(es), "%v", es)
+}
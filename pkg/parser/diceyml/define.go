@@ -14,6 +14,8 @@
 package diceyml
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -73,6 +75,20 @@ type Selector struct {
 }
 type Selectors map[string]Selector
 
+const (
+	// SelectorSpread spread replicas across topology domains, one of zone, rack and host
+	SelectorSpread = "spread"
+	// SelectorSpreadMaxSkew max difference of replicas between topology domains, default 1
+	SelectorSpreadMaxSkew = "spread-max-skew"
+	// SelectorSpreadMode hard or soft, default soft
+	SelectorSpreadMode = "spread-mode"
+	// SelectorAntiAffinity services which should not be placed in the same topology domain, e.g. "svc-a OR svc-b"
+	SelectorAntiAffinity = "anti-affinity"
+
+	SpreadModeHard = "hard"
+	SpreadModeSoft = "soft"
+)
+
 type EnvObject struct {
 	Envs     EnvMap   `yaml:"envs,omitempty" json:"-"`
 	Services Services `yaml:"services,omitempty" json:"services,omitempty"`
@@ -104,6 +120,20 @@ type Job struct {
 	Binds     Binds             `yaml:"binds,omitempty" json:"binds,omitempty"`
 	Volumes   Volumes           `yaml:"volumes,omitempty" json:"volumes,omitempty"`
 	Hosts     []string          `yaml:"hosts,omitempty" json:"hosts,omitempty"`
+	// Schedule run job periodically as cron job, job runs only once if not set
+	Schedule *JobSchedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+}
+
+type JobSchedule struct {
+	// Cron standard crontab spec with 5 fields, e.g. "*/5 * * * *"
+	Cron string `yaml:"cron,omitempty" json:"cron"`
+	// ConcurrencyPolicy Allow, Forbid or Replace, default Allow
+	ConcurrencyPolicy string `yaml:"concurrency_policy,omitempty" json:"concurrency_policy,omitempty"`
+	// SuccessfulJobsHistoryLimit and FailedJobsHistoryLimit the number of finished executions to retain
+	SuccessfulJobsHistoryLimit *int `yaml:"successful_jobs_history_limit,omitempty" json:"successful_jobs_history_limit,omitempty"`
+	FailedJobsHistoryLimit     *int `yaml:"failed_jobs_history_limit,omitempty" json:"failed_jobs_history_limit,omitempty"`
+	// Suspend stop scheduling subsequent executions
+	Suspend bool `yaml:"suspend,omitempty" json:"suspend,omitempty"`
 }
 
 type InitContainer struct {
@@ -135,6 +165,63 @@ type Service struct {
 	MeshEnable      *bool                    `yaml:"mesh_enable,omitempty" json:"mesh_enable,omitempty"`
 	TrafficSecurity TrafficSecurity          `yaml:"traffic_security,omitempty" json:"traffic_security,omitempty"`
 	Endpoints       []Endpoint               `yaml:"endpoints,omitempty" json:"endpoints,omitempty"`
+	Files           []ConfigFile             `yaml:"files,omitempty" json:"files,omitempty"`
+	// DisruptionBudget limits the number of replicas evicted at once by voluntary disruptions, e.g. draining nodes
+	DisruptionBudget *DisruptionBudget `yaml:"disruption_budget,omitempty" json:"disruption_budget,omitempty"`
+	// Lifecycle graceful shutdown of the service
+	Lifecycle *Lifecycle `yaml:"lifecycle,omitempty" json:"lifecycle,omitempty"`
+}
+
+// DisruptionBudget exactly one of MinAvailable and MaxUnavailable is required,
+// which is either a number of replicas (e.g. "1") or a percentage of replicas (e.g. "50%")
+type DisruptionBudget struct {
+	MinAvailable   string `yaml:"min_available,omitempty" json:"min_available,omitempty"`
+	MaxUnavailable string `yaml:"max_unavailable,omitempty" json:"max_unavailable,omitempty"`
+}
+
+type Lifecycle struct {
+	// PreStop command executed in the container before it is stopped, e.g. "sleep 10"
+	PreStop string `yaml:"pre_stop,omitempty" json:"pre_stop,omitempty"`
+	// TerminationGraceSeconds time given to the container to stop gracefully before it is killed
+	TerminationGraceSeconds *int64 `yaml:"termination_grace_seconds,omitempty" json:"termination_grace_seconds,omitempty"`
+}
+
+// ConfigFile file mounted into the service container, exactly one of Config, Certificate and Content is required.
+// Files are refreshed when the runtime is redeployed, and orchestrator periodically checks the files referencing
+// config center or certificates, in both cases the pods are restarted if any content changed, files are not reloaded in place.
+type ConfigFile struct {
+	// Path absolute path of the file in container
+	Path string `yaml:"path" json:"path"`
+	// Config key of the FILE type configuration in config center
+	Config string `yaml:"config,omitempty" json:"config,omitempty"`
+	// Certificate name of the certificate quoted by application
+	Certificate string `yaml:"certificate,omitempty" json:"certificate,omitempty"`
+	// Content inline content of the file
+	Content string `yaml:"content,omitempty" json:"content,omitempty"`
+	// Secret whether the file is stored as secret, certificates are always secret
+	Secret bool `yaml:"secret,omitempty" json:"secret,omitempty"`
+	// Checksum sha256 of the content of secret file, set when the content is redacted before persisted
+	Checksum string `yaml:"-" json:"checksum,omitempty"`
+}
+
+// ConfigFileKey converts the path of file to the key of configmap or secret holding it,
+// e.g. /app/conf/application.yml -> app.conf.application.yml
+func ConfigFileKey(path string) string {
+	return strings.Replace(strings.Trim(path, "/"), "/", ".", -1)
+}
+
+// Redacted whether the content of file has been replaced by its checksum
+func (f ConfigFile) Redacted() bool {
+	return f.Content == "" && f.Checksum != ""
+}
+
+// ContentChecksum returns the sha256 of content, or the checksum kept if the content is redacted
+func (f ConfigFile) ContentChecksum() string {
+	if f.Redacted() {
+		return f.Checksum
+	}
+	sum := sha256.Sum256([]byte(f.Content))
+	return hex.EncodeToString(sum[:])
 }
 
 type ServicePort struct {
@@ -413,9 +500,46 @@ type SideCar struct {
 	Resources  Resources   `yaml:"resources,omitempty" json:"resources"`
 }
 
+// HealthCheck 服务健康检查
+// 只配置 http/exec/tcp/grpc 时, 同一个检查同时作为 liveness 和 readiness 探针(兼容旧版本);
+// 配置了 liveness/readiness/startup 时, 对应的探针使用各自的配置
 type HealthCheck struct {
 	HTTP *HTTPCheck `yaml:"http,omitempty" json:"http,omitempty"`
 	Exec *ExecCheck `yaml:"exec,omitempty" json:"exec,omitempty"`
+	TCP  *TCPCheck  `yaml:"tcp,omitempty" json:"tcp,omitempty"`
+	GRPC *GRPCCheck `yaml:"grpc,omitempty" json:"grpc,omitempty"`
+
+	Liveness  *Probe `yaml:"liveness,omitempty" json:"liveness,omitempty"`
+	Readiness *Probe `yaml:"readiness,omitempty" json:"readiness,omitempty"`
+	Startup   *Probe `yaml:"startup,omitempty" json:"startup,omitempty"`
+}
+
+// TCPCheck 检查端口是否可以建立 tcp 连接
+type TCPCheck struct {
+	Port     int `yaml:"port,omitempty" json:"port,omitempty"`
+	Duration int `yaml:"duration,omitempty" json:"duration,omitempty"`
+}
+
+// GRPCCheck 按 grpc health checking protocol 检查, 镜像中需要包含 grpc_health_probe
+type GRPCCheck struct {
+	Port     int    `yaml:"port,omitempty" json:"port,omitempty"`
+	Service  string `yaml:"service,omitempty" json:"service,omitempty"`
+	Duration int    `yaml:"duration,omitempty" json:"duration,omitempty"`
+}
+
+// Probe 单独配置的探针, http/exec/tcp/grpc 有且只有一个
+// 时间单位都是秒, 未配置的字段使用 k8s 的默认值
+type Probe struct {
+	HTTP *HTTPCheck `yaml:"http,omitempty" json:"http,omitempty"`
+	Exec *ExecCheck `yaml:"exec,omitempty" json:"exec,omitempty"`
+	TCP  *TCPCheck  `yaml:"tcp,omitempty" json:"tcp,omitempty"`
+	GRPC *GRPCCheck `yaml:"grpc,omitempty" json:"grpc,omitempty"`
+
+	InitialDelaySeconds int `yaml:"initial_delay_seconds,omitempty" json:"initial_delay_seconds,omitempty"`
+	PeriodSeconds       int `yaml:"period_seconds,omitempty" json:"period_seconds,omitempty"`
+	TimeoutSeconds      int `yaml:"timeout_seconds,omitempty" json:"timeout_seconds,omitempty"`
+	SuccessThreshold    int `yaml:"success_threshold,omitempty" json:"success_threshold,omitempty"`
+	FailureThreshold    int `yaml:"failure_threshold,omitempty" json:"failure_threshold,omitempty"`
 }
 
 type HTTPCheck struct {
@@ -445,8 +569,55 @@ type Deployments struct {
 	// Type indicates the type of Deployments, per-node,stateful and stateless are supported
 	Workload string `yaml:"workload,omitempty" json:"workload,omitempty"`
 	// Selectors available selectors:
-	// [location]
+	// [location, spread, spread-max-skew, spread-mode, anti-affinity]
 	Selectors Selectors `yaml:"selectors,omitempty" json:"selectors,omitempty"`
+	// Autoscaling scale replicas between min_replicas and max_replicas according to the metrics,
+	// only supported by stateless services on k8s
+	Autoscaling *Autoscaling `yaml:"autoscaling,omitempty" json:"autoscaling,omitempty"`
+	// Strategy how to roll out new version of the service, rolling update by default
+	Strategy *Strategy `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+}
+
+const (
+	StrategyRolling   = "rolling"
+	StrategyCanary    = "canary"
+	StrategyBlueGreen = "blue_green"
+)
+
+type Strategy struct {
+	// Type rolling, canary or blue_green
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	// MaxSurge and MaxUnavailable of rolling update, number or percentage, e.g. "1", "25%"
+	MaxSurge       string `yaml:"max_surge,omitempty" json:"max_surge,omitempty"`
+	MaxUnavailable string `yaml:"max_unavailable,omitempty" json:"max_unavailable,omitempty"`
+	// CanarySteps traffic weights of canary version step by step, new version is fully promoted after the last step
+	CanarySteps []CanaryStep `yaml:"canary_steps,omitempty" json:"canary_steps,omitempty"`
+	// AutoPromote switch traffic to the new version of blue_green automatically once it is ready
+	AutoPromote bool `yaml:"auto_promote,omitempty" json:"auto_promote,omitempty"`
+}
+
+type CanaryStep struct {
+	// Weight percentage of traffic (replicas) served by canary version
+	Weight int `yaml:"weight,omitempty" json:"weight,omitempty"`
+	// Pause seconds to wait before next step, 0 means pausing until promoted manually
+	Pause int `yaml:"pause,omitempty" json:"pause,omitempty"`
+}
+
+type Autoscaling struct {
+	MinReplicas int `yaml:"min_replicas,omitempty" json:"min_replicas,omitempty"`
+	MaxReplicas int `yaml:"max_replicas,omitempty" json:"max_replicas,omitempty"`
+	// CPUUtilization target average cpu utilization, percentage of requested cpu
+	CPUUtilization int `yaml:"cpu_utilization,omitempty" json:"cpu_utilization,omitempty"`
+	// MemUtilization target average memory utilization, percentage of requested memory
+	MemUtilization int `yaml:"mem_utilization,omitempty" json:"mem_utilization,omitempty"`
+	// Metrics custom pod metrics, provided by custom metrics api (e.g. prometheus-adapter)
+	Metrics []AutoscalingMetric `yaml:"metrics,omitempty" json:"metrics,omitempty"`
+}
+
+type AutoscalingMetric struct {
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// TargetAverageValue target value of the metric averaged across pods, e.g. "100", "500m"
+	TargetAverageValue string `yaml:"target_average_value,omitempty" json:"target_average_value,omitempty"`
 }
 
 type TrafficSecurity struct {
@@ -38,6 +38,20 @@ var (
 	emptyEndpointDomain        = errortype("empty domain in endpoints")
 	invalidEndpointDomain      = errortype("invalid domain in endpoints")
 	invalidEndpointPath        = errortype("invalid path in endpoints, must start with '/'")
+	invalidAutoscaling         = errortype("invalid autoscaling defined in yaml")
+	invalidAutoscalingReplicas = errortype("invalid autoscaling replicas defined in yaml, must be 1 <= min_replicas <= max_replicas")
+	invalidAutoscalingTarget   = errortype("invalid autoscaling target defined in yaml")
+	invalidAutoscalingMetric   = errortype("invalid autoscaling metric defined in yaml")
+	invalidStrategy            = errortype("invalid strategy defined in yaml")
+	invalidCanaryStep          = errortype("invalid canary steps defined in yaml, weight must be increasing and in (0, 100)")
+	invalidHealthCheck         = errortype("invalid health_check defined in yaml")
+	invalidProbe               = errortype("invalid probe defined in yaml, exactly one of http, exec, tcp and grpc is required")
+	invalidProbeTiming         = errortype("invalid probe timing defined in yaml, must not be negative")
+	invalidJobSchedule         = errortype("invalid job schedule defined in yaml")
+	invalidSpreadSelector      = errortype("invalid spread selectors defined in yaml")
+	invalidDisruptionBudget    = errortype("invalid disruption_budget defined in yaml, exactly one of min_available and max_unavailable is required, which is a non-negative number or percentage")
+	invalidLifecycle           = errortype("invalid lifecycle defined in yaml, termination_grace_seconds must not be negative")
+	invalidConfigFile          = errortype("invalid files defined in yaml, absolute path not conflicting with others after / replaced by . and exactly one of config, certificate and content are required")
 )
 
 type errortype string
@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 
 	yaml "gopkg.in/yaml.v2"
 )
@@ -80,8 +81,8 @@ func (o *FieldnameValidateVisitor) VisitService(v DiceYmlVisitor, obj *Service)
 	for k := range o.currentService {
 		switch i := k.(type) {
 		case string:
-			if !contain(i, []string{"image", "cmd", "labels", "ports", "envs", "hosts", "resources", "volumes", "deployments", "depends_on", "expose", "health_check", "binds", "sidecars", "init", "traffic_security", "endpoints", "mesh_enable"}) {
-				o.collectErrors[yamlHeaderRegexWithUpperHeader([]string{o.currentServiceName}, i)] = fmt.Errorf("[%s] field '%s' not one of [image, cmd, ports, envs, hosts, labels, resources, volumes, deployments, depends_on, expose, health_check, binds, sidecars，init, traffic_security, endpoints, mesh_enable]", o.currentServiceName, i)
+			if !contain(i, []string{"image", "cmd", "labels", "ports", "envs", "hosts", "resources", "volumes", "deployments", "depends_on", "expose", "health_check", "binds", "sidecars", "init", "traffic_security", "endpoints", "mesh_enable", "files", "disruption_budget", "lifecycle"}) {
+				o.collectErrors[yamlHeaderRegexWithUpperHeader([]string{o.currentServiceName}, i)] = fmt.Errorf("[%s] field '%s' not one of [image, cmd, ports, envs, hosts, labels, resources, volumes, deployments, depends_on, expose, health_check, binds, sidecars，init, traffic_security, endpoints, mesh_enable, files, disruption_budget, lifecycle]", o.currentServiceName, i)
 			}
 		default:
 			o.collectErrors[yamlHeaderRegex("_"+strconv.Itoa(len(o.collectErrors)))] = fmt.Errorf("[%s] %v not string type", o.currentServiceName, k)
@@ -146,8 +147,8 @@ func (o *FieldnameValidateVisitor) VisitDeployments(v DiceYmlVisitor, obj *Deplo
 	for k := range dep {
 		switch i := k.(type) {
 		case string:
-			if !contain(i, []string{"workload", "replicas", "policies", "labels", "selectors"}) {
-				o.collectErrors[yamlHeaderRegexWithUpperHeader([]string{o.currentServiceName, "deployments"}, i)] = fmt.Errorf("[%s]/[deployments] field '%s' not one of [replicas, policies, labels, selectors]", o.currentServiceName, i)
+			if !contain(i, []string{"workload", "replicas", "policies", "labels", "selectors", "autoscaling", "strategy"}) {
+				o.collectErrors[yamlHeaderRegexWithUpperHeader([]string{o.currentServiceName, "deployments"}, i)] = fmt.Errorf("[%s]/[deployments] field '%s' not one of [replicas, policies, labels, selectors, autoscaling, strategy]", o.currentServiceName, i)
 			}
 		default:
 			o.collectErrors[yamlHeaderRegex("_"+strconv.Itoa(len(o.collectErrors)))] = fmt.Errorf("[%s]/[deployments] %v not string type", o.currentServiceName, k)
@@ -163,13 +164,58 @@ func (o *FieldnameValidateVisitor) VisitHealthCheck(v DiceYmlVisitor, obj *Healt
 	for k := range hc {
 		switch i := k.(type) {
 		case string:
-			if !contain(i, []string{"http", "exec"}) {
-				o.collectErrors[yamlHeaderRegexWithUpperHeader([]string{o.currentServiceName, "health_check"}, i)] = fmt.Errorf("[%s]/[health_check] field '%s' not one of [http, exec]", o.currentServiceName, i)
+			if !contain(i, []string{"http", "exec", "tcp", "grpc", "liveness", "readiness", "startup"}) {
+				o.collectErrors[yamlHeaderRegexWithUpperHeader([]string{o.currentServiceName, "health_check"}, i)] = fmt.Errorf("[%s]/[health_check] field '%s' not one of [http, exec, tcp, grpc, liveness, readiness, startup]", o.currentServiceName, i)
 			}
 		default:
 			o.collectErrors[yamlHeaderRegex("_"+strconv.Itoa(len(o.collectErrors)))] = fmt.Errorf("[%s]/[health_check] %v not string type", o.currentServiceName, k)
 		}
 	}
+	o.validateCheckFields(hc, []string{"health_check"})
+	for _, name := range []string{"liveness", "readiness", "startup"} {
+		probe, ok := hc[name].(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		o.validateFields(probe, []string{"health_check", name}, []string{"http", "exec", "tcp", "grpc",
+			"initial_delay_seconds", "period_seconds", "timeout_seconds", "success_threshold", "failure_threshold"})
+		o.validateCheckFields(probe, []string{"health_check", name})
+	}
+}
+
+// validateCheckFields validates fields of tcp and grpc check, and also http and exec check in probes
+func (o *FieldnameValidateVisitor) validateCheckFields(checks map[interface{}]interface{}, path []string) {
+	fields := map[string][]string{
+		"tcp":  {"port", "duration"},
+		"grpc": {"port", "service", "duration"},
+	}
+	if len(path) > 1 {
+		// http and exec under health_check are validated by VisitHTTPCheck and VisitExecCheck
+		fields["http"] = []string{"port", "path", "duration"}
+		fields["exec"] = []string{"cmd", "duration"}
+	}
+	for name, allowed := range fields {
+		check, ok := checks[name].(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		o.validateFields(check, append(append([]string{}, path...), name), allowed)
+	}
+}
+
+func (o *FieldnameValidateVisitor) validateFields(m map[interface{}]interface{}, path []string, allowed []string) {
+	header := append([]string{o.currentServiceName}, path...)
+	display := "[" + strings.Join(header, "]/[") + "]"
+	for k := range m {
+		switch i := k.(type) {
+		case string:
+			if !contain(i, allowed) {
+				o.collectErrors[yamlHeaderRegexWithUpperHeader(header, i)] = fmt.Errorf("%s field '%s' not one of [%s]", display, i, strings.Join(allowed, ", "))
+			}
+		default:
+			o.collectErrors[yamlHeaderRegex("_"+strconv.Itoa(len(o.collectErrors)))] = fmt.Errorf("%s %v not string type", display, k)
+		}
+	}
 }
 
 func (o *FieldnameValidateVisitor) VisitHTTPCheck(v DiceYmlVisitor, obj *HTTPCheck) {
@@ -73,3 +73,31 @@ func TestFieldnameValidate(t *testing.T) {
 	es := FieldnameValidate(d.Obj(), []byte(fieldname_validate_yml))
 	assert.Equal(t, 4, len(es))
 }
+
+var fieldname_validate_probe_yml = `version: 2.0
+services:
+  web:
+    resources:
+      cpu: 0.1
+      mem: 256
+    health_check:
+      tcp:
+        port: 8080
+        path: /		# err: path
+      liveness:
+        http:
+          port: 8080
+          path: /health
+        period: 10		# err: period
+      startup:
+        grpc:
+          port: 9090
+          services: app	# err: services
+`
+
+func TestFieldnameValidateProbe(t *testing.T) {
+	d, err := New([]byte(fieldname_validate_probe_yml), false)
+	assert.Nil(t, err)
+	es := FieldnameValidate(d.Obj(), []byte(fieldname_validate_probe_yml))
+	assert.Equal(t, 3, len(es), "%v", es)
+}
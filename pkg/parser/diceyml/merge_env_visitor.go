@@ -68,6 +68,13 @@ func (o *MergeEnvVisitor) VisitService(v DiceYmlVisitor, obj *Service) {
 	overrideIfNotZero(o.envObj.Services[o.currentService].Volumes, &obj.Volumes)
 	overrideIfNotZero(o.envObj.Services[o.currentService].DependsOn, &obj.DependsOn)
 	overrideIfNotZero(o.envObj.Services[o.currentService].Expose, &obj.Expose)
+	overrideIfNotZero(o.envObj.Services[o.currentService].Files, &obj.Files)
+	if o.envObj.Services[o.currentService].DisruptionBudget != nil {
+		obj.DisruptionBudget = o.envObj.Services[o.currentService].DisruptionBudget
+	}
+	if o.envObj.Services[o.currentService].Lifecycle != nil {
+		obj.Lifecycle = o.envObj.Services[o.currentService].Lifecycle
+	}
 }
 
 func (o *MergeEnvVisitor) VisitResources(v DiceYmlVisitor, obj *Resources) {
@@ -99,6 +106,37 @@ func (o *MergeEnvVisitor) VisitDeployments(v DiceYmlVisitor, obj *Deployments) {
 	}
 	overrideIfNotZero(o.envObj.Services[o.currentService].Deployments.Policies, &obj.Policies)
 	overrideIfNotZero(o.envObj.Services[o.currentService].Deployments.Labels, &obj.Labels)
+	if o.envObj.Services[o.currentService].Deployments.Autoscaling != nil {
+		obj.Autoscaling = o.envObj.Services[o.currentService].Deployments.Autoscaling
+	}
+	if o.envObj.Services[o.currentService].Deployments.Strategy != nil {
+		obj.Strategy = o.envObj.Services[o.currentService].Deployments.Strategy
+	}
+}
+
+func (o *MergeEnvVisitor) VisitHealthCheck(v DiceYmlVisitor, obj *HealthCheck) {
+	if o.currentService == "" {
+		panic("should not be empty")
+	}
+	if s, ok := o.envObj.Services[o.currentService]; !ok || s == nil {
+		return
+	}
+	hc := o.envObj.Services[o.currentService].HealthCheck
+	if hc.TCP != nil {
+		obj.TCP = hc.TCP
+	}
+	if hc.GRPC != nil {
+		obj.GRPC = hc.GRPC
+	}
+	if hc.Liveness != nil {
+		obj.Liveness = hc.Liveness
+	}
+	if hc.Readiness != nil {
+		obj.Readiness = hc.Readiness
+	}
+	if hc.Startup != nil {
+		obj.Startup = hc.Startup
+	}
 }
 
 func (o *MergeEnvVisitor) VisitHTTPCheck(v DiceYmlVisitor, obj *HTTPCheck) {
@@ -14,6 +14,7 @@
 package sqllint
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/pingcap/parser/ast"
@@ -22,13 +23,27 @@ import (
 const UTF8MB4 = "utf8mb4"
 
 type CharsetLinter struct {
-	script Script
-	err    error
-	text   string
+	script  Script
+	err     error
+	text    string
+	charset string
 }
 
 func NewCharsetLinter(script Script) Rule {
-	return &CharsetLinter{script: script}
+	return &CharsetLinter{script: script, charset: UTF8MB4}
+}
+
+func (l *CharsetLinter) Params() RuleParams {
+	return RuleParams{"charset": l.charset}
+}
+
+func (l *CharsetLinter) Configure(params RuleParams) error {
+	charset, err := params.String("charset", l.charset)
+	if err != nil {
+		return err
+	}
+	l.charset = charset
+	return nil
 }
 
 func (l *CharsetLinter) Enter(in ast.Node) (ast.Node, bool) {
@@ -42,11 +57,11 @@ func (l *CharsetLinter) Enter(in ast.Node) (ast.Node, bool) {
 	}
 
 	for _, opt := range stmt.Options {
-		if opt.Tp == ast.TableOptionCharset && strings.ToLower(opt.StrValue) == UTF8MB4 {
+		if opt.Tp == ast.TableOptionCharset && strings.EqualFold(opt.StrValue, l.charset) {
 			return in, true
 		}
 	}
-	l.err = NewLintError(l.script, l.text, "表字符集错误: 应当显示声明为 CHARSET = utf8mb4",
+	l.err = NewLintError(l.script, l.text, fmt.Sprintf("表字符集错误: 应当显示声明为 CHARSET = %s", l.charset),
 		func(line []byte) bool {
 			return false
 		})
@@ -0,0 +1,224 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sqllint
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity lint 提示的级别
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+var severityRanks = map[Severity]int{
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// Valid 是否为合法的级别
+func (s Severity) Valid() bool {
+	_, ok := severityRanks[s]
+	return ok
+}
+
+// AtLeast 级别是否不低于 t
+func (s Severity) AtLeast(t Severity) bool {
+	return severityRanks[s] >= severityRanks[t]
+}
+
+// RuleParams 规则参数, 来自配置文件
+type RuleParams map[string]interface{}
+
+// Int 读取整型参数, 不存在时返回 def
+func (p RuleParams) Int(key string, def int) (int, error) {
+	v, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+	i, ok := v.(int)
+	if !ok {
+		return 0, errors.Errorf("param %s should be an integer, got %v", key, v)
+	}
+	return i, nil
+}
+
+// String 读取字符串参数, 不存在时返回 def
+func (p RuleParams) String(key string, def string) (string, error) {
+	v, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", errors.Errorf("param %s should be a string, got %v", key, v)
+	}
+	return s, nil
+}
+
+// Configurable 可通过参数调整的规则
+type Configurable interface {
+	Rule
+
+	// Params 返回规则当前参数, 新建的规则返回默认参数
+	Params() RuleParams
+	// Configure 设置规则参数
+	Configure(params RuleParams) error
+}
+
+// RuleConfig 单个规则的配置
+type RuleConfig struct {
+	// Enabled 是否启用, 默认启用
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// Severity 规则级别, 默认 error
+	Severity Severity `yaml:"severity,omitempty"`
+	// Params 规则参数, 仅 Configurable 规则支持
+	Params RuleParams `yaml:"params,omitempty"`
+}
+
+// Config lint 配置, 未列出的规则以默认配置启用, e.g.
+//
+//	rules:
+//	  VarcharLengthLinter:
+//	    severity: warning
+//	    params:
+//	      maxLength: 1024
+//	  ForeignKeyLinter:
+//	    enabled: false
+type Config struct {
+	Rules map[string]RuleConfig `yaml:"rules"`
+}
+
+// LoadConfig 解析 YAML 配置并校验规则名称、级别及参数
+func LoadConfig(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, errors.Wrap(err, "failed to parse sqllint config")
+	}
+	if _, err := cfg.build(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// DefaultConfig 返回启用全部规则的默认配置, 包含各规则的默认参数
+func DefaultConfig() *Config {
+	cfg := &Config{Rules: make(map[string]RuleConfig, len(Rules))}
+	for name, f := range Rules {
+		enabled := true
+		rc := RuleConfig{Enabled: &enabled, Severity: SeverityError}
+		if c, ok := f(NewScript("", nil)).(Configurable); ok {
+			rc.Params = c.Params()
+		}
+		cfg.Rules[name] = rc
+	}
+	return cfg
+}
+
+// configuredRule 按配置实例化规则所需的信息
+type configuredRule struct {
+	name     string
+	new      NewRule
+	severity Severity
+	params   RuleParams
+}
+
+func (r configuredRule) instantiate(script Script) Rule {
+	rule := r.new(script)
+	if c, ok := rule.(Configurable); ok && len(r.params) > 0 {
+		// params have been validated when building the rule set
+		_ = c.Configure(r.params)
+	}
+	return rule
+}
+
+// build 按名称顺序生成启用的规则集
+func (c *Config) build() ([]configuredRule, error) {
+	for name := range c.Rules {
+		if _, ok := Rules[name]; !ok {
+			return nil, errors.Errorf("unknown sqllint rule %s", name)
+		}
+	}
+	names := make([]string, 0, len(Rules))
+	for name := range Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rules []configuredRule
+	for _, name := range names {
+		rc := c.Rules[name]
+		if rc.Enabled != nil && !*rc.Enabled {
+			continue
+		}
+		severity := rc.Severity
+		if severity == "" {
+			severity = SeverityError
+		}
+		if !severity.Valid() {
+			return nil, errors.Errorf("invalid severity %q of rule %s", severity, name)
+		}
+		if err := validateParams(name, rc.Params); err != nil {
+			return nil, err
+		}
+		rules = append(rules, configuredRule{name: name, new: Rules[name], severity: severity, params: rc.Params})
+	}
+	return rules, nil
+}
+
+func validateParams(name string, params RuleParams) error {
+	if len(params) == 0 {
+		return nil
+	}
+	c, ok := Rules[name](NewScript("", nil)).(Configurable)
+	if !ok {
+		return errors.Errorf("rule %s accepts no params", name)
+	}
+	defaults := c.Params()
+	var unknown []string
+	for key := range params {
+		if _, ok := defaults[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return errors.Errorf("unknown params %s of rule %s", strings.Join(unknown, ", "), name)
+	}
+	if err := c.Configure(params); err != nil {
+		return errors.Wrapf(err, "invalid params of rule %s", name)
+	}
+	return nil
+}
+
+// ruleName 根据构造函数查找规则名称, 未注册的规则返回 fallback
+func ruleName(f NewRule, fallback string) string {
+	p := reflect.ValueOf(f).Pointer()
+	for name, g := range Rules {
+		if reflect.ValueOf(g).Pointer() == p {
+			return name
+		}
+	}
+	return fallback
+}
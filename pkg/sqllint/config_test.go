@@ -0,0 +1,158 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sqllint_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/erda-project/erda/pkg/sqllint"
+)
+
+const configSQL = `
+create table some_table (
+	id bigint(20) not null,
+	name varchar(200) not null comment 'name'
+) charset = latin1;
+`
+
+func lintWithConfig(t *testing.T, config, sql string) []sqllint.Finding {
+	cfg, err := sqllint.LoadConfig([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	linter, err := sqllint.NewFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := linter.Input([]byte(sql), "config.sql"); err != nil {
+		t.Fatal(err)
+	}
+	return linter.Findings()
+}
+
+func findRule(findings []sqllint.Finding, rule string) *sqllint.Finding {
+	for i := range findings {
+		if findings[i].Rule == rule {
+			return &findings[i]
+		}
+	}
+	return nil
+}
+
+func TestNewFromConfig(t *testing.T) {
+	findings := lintWithConfig(t, `
+rules:
+  VarcharLengthLinter:
+    severity: warning
+    params:
+      maxLength: 100
+  CharsetLinter:
+    params:
+      charset: latin1
+  TableCommentLinter:
+    enabled: false
+`, configSQL)
+
+	varchar := findRule(findings, "VarcharLengthLinter")
+	if varchar == nil {
+		t.Fatalf("VarcharLengthLinter should report varchar(200) when maxLength is 100, findings: %+v", findings)
+	}
+	if varchar.Severity != sqllint.SeverityWarning {
+		t.Errorf("severity should be warning, got %s", varchar.Severity)
+	}
+	if !strings.Contains(varchar.Message, "100") {
+		t.Errorf("message should mention the configured max length, got %s", varchar.Message)
+	}
+	if varchar.Line != 4 || varchar.Column != 2 {
+		t.Errorf("varchar finding should be at 4:2, got %d:%d", varchar.Line, varchar.Column)
+	}
+	if varchar.Table != "some_table" {
+		t.Errorf("table should be some_table, got %s", varchar.Table)
+	}
+	if f := findRule(findings, "CharsetLinter"); f != nil {
+		t.Errorf("CharsetLinter should accept the configured charset, got %+v", f)
+	}
+	if f := findRule(findings, "TableCommentLinter"); f != nil {
+		t.Errorf("disabled TableCommentLinter should not report, got %+v", f)
+	}
+	if f := findRule(findings, "CreatedAtExistsLinter"); f == nil || f.Severity != sqllint.SeverityError {
+		t.Errorf("rules not listed in config should be enabled as error, got %+v", f)
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	configs := map[string]string{
+		"unknown rule":       "rules:\n  NoSuchLinter: {}\n",
+		"invalid severity":   "rules:\n  CharsetLinter:\n    severity: fatal\n",
+		"unknown param":      "rules:\n  VarcharLengthLinter:\n    params:\n      max: 1\n",
+		"wrong param type":   "rules:\n  VarcharLengthLinter:\n    params:\n      maxLength: long\n",
+		"not configurable":   "rules:\n  ForeignKeyLinter:\n    params:\n      any: 1\n",
+		"invalid yaml input": "rules: [",
+	}
+	for name, config := range configs {
+		if _, err := sqllint.LoadConfig([]byte(config)); err == nil {
+			t.Errorf("%s: LoadConfig should fail", name)
+		}
+	}
+}
+
+func TestDefaultConfig(t *testing.T) {
+	cfg := sqllint.DefaultConfig()
+	if len(cfg.Rules) != len(sqllint.Rules) {
+		t.Fatalf("default config should contain all %d rules, got %d", len(sqllint.Rules), len(cfg.Rules))
+	}
+	if v := cfg.Rules["VarcharLengthLinter"].Params["maxLength"]; v != 5000 {
+		t.Errorf("default maxLength should be 5000, got %v", v)
+	}
+	if _, err := sqllint.NewFromConfig(cfg); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIgnoreComments(t *testing.T) {
+	findings := lintWithConfig(t, "", "-- sqllint-disable VarcharLengthLinter, CharsetLinter\n"+
+		strings.Replace(configSQL, "varchar(200)", "varchar(6000)", 1))
+	if f := findRule(findings, "VarcharLengthLinter"); f != nil {
+		t.Errorf("VarcharLengthLinter should be ignored, got %+v", f)
+	}
+	if f := findRule(findings, "CharsetLinter"); f != nil {
+		t.Errorf("CharsetLinter should be ignored, got %+v", f)
+	}
+	if f := findRule(findings, "TableCommentLinter"); f == nil {
+		t.Error("TableCommentLinter should not be ignored")
+	}
+
+	findings = lintWithConfig(t, "", "/* sqllint-disable */\n"+configSQL)
+	if len(findings) != 0 {
+		t.Errorf("all rules should be ignored, got %+v", findings)
+	}
+}
+
+func TestSyntaxErrorFinding(t *testing.T) {
+	linter, err := sqllint.NewFromConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := linter.Input([]byte("create table t (\n  id bigint(20) not null,\n  name varchar(10) nul\n);"), "bad.sql"); err == nil {
+		t.Fatal("Input should fail on invalid sql")
+	}
+	findings := linter.Findings()
+	if len(findings) != 1 || findings[0].Rule != sqllint.SyntaxErrorRule {
+		t.Fatalf("should report one syntax error, got %+v", findings)
+	}
+	if findings[0].Line != 3 {
+		t.Errorf("syntax error should be at line 3, got %d", findings[0].Line)
+	}
+}
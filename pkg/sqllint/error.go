@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/pingcap/parser"
 	"github.com/pingcap/parser/ast"
@@ -31,6 +32,7 @@ type LintError struct {
 	Lint       string // lint 提示
 	Line       string // lint 提示所在的行内容
 	LintNo     int    // lint 提示所在行行号
+	ColNo      int    // lint 提示所在列号, 近似值, 见 getLintColumn
 }
 
 func NewLintError(script Script, stmt string, lint string, getLine func(line []byte) bool) LintError {
@@ -41,6 +43,7 @@ func NewLintError(script Script, stmt string, lint string, getLine func(line []b
 		Lint:       lint,
 		Line:       line,
 		LintNo:     num,
+		ColNo:      getLintColumn(line, num),
 	}
 }
 
@@ -84,6 +87,16 @@ func (e LintError) StmtName() string {
 	return ""
 }
 
+// 计算 lint error 列号, 行号无效时返回 0.
+// 解析器只记录语句的起始位置, 字段定义、索引等子句没有偏移, 列号近似为 lint 所在行第一个非空白字符的位置
+func getLintColumn(line string, num int) int {
+	if num <= 0 {
+		return 0
+	}
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	return utf8.RuneCountInString(line[:indent]) + 1
+}
+
 // 计算 SQL 脚本发生 lint error 行号
 func getLintLine(source, scope []byte, goal func(line []byte) bool) (line string, num int) {
 	var firstLine []byte
@@ -0,0 +1,53 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sqllint
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 文件级忽略注释, e.g.
+//
+//	-- sqllint-disable
+//	-- sqllint-disable VarcharLengthLinter, ForeignKeyLinter
+//	/* sqllint-disable CharsetLinter */
+var disableCommentRegexp = regexp.MustCompile(`(?m)(?:--|#|/\*)[ \t]*sqllint-disable\b([^\n]*)`)
+
+// ignores 脚本中通过注释忽略的规则
+type ignores struct {
+	all   bool
+	rules map[string]bool
+}
+
+func parseIgnores(data []byte) ignores {
+	ig := ignores{rules: make(map[string]bool)}
+	for _, match := range disableCommentRegexp.FindAllSubmatch(data, -1) {
+		names := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(string(match[1])), "*/"))
+		if names == "" {
+			ig.all = true
+			continue
+		}
+		for _, name := range strings.FieldsFunc(names, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		}) {
+			ig.rules[name] = true
+		}
+	}
+	return ig
+}
+
+func (ig ignores) ignored(rule string) bool {
+	return ig.all || ig.rules[rule]
+}
@@ -15,6 +15,7 @@ package sqllint
 
 import (
 	"bytes"
+	"fmt"
 	"strconv"
 
 	"github.com/pingcap/parser/ast"
@@ -26,10 +27,42 @@ type IndexLengthLinter struct {
 	script Script
 	err    error
 	text   string
+
+	// 单列索引及联合索引的最大字节数, 以及每个字符所占字节数
+	singleMaxBytes    int
+	compositeMaxBytes int
+	bytesPerChar      int
 }
 
 func NewIndexLengthLinter(script Script) Rule {
-	return &IndexLengthLinter{script: script}
+	return &IndexLengthLinter{
+		script:            script,
+		singleMaxBytes:    767,
+		compositeMaxBytes: 3072,
+		bytesPerChar:      4,
+	}
+}
+
+func (l *IndexLengthLinter) Params() RuleParams {
+	return RuleParams{
+		"singleMaxBytes":    l.singleMaxBytes,
+		"compositeMaxBytes": l.compositeMaxBytes,
+		"bytesPerChar":      l.bytesPerChar,
+	}
+}
+
+func (l *IndexLengthLinter) Configure(params RuleParams) error {
+	var err error
+	if l.singleMaxBytes, err = params.Int("singleMaxBytes", l.singleMaxBytes); err != nil {
+		return err
+	}
+	if l.compositeMaxBytes, err = params.Int("compositeMaxBytes", l.compositeMaxBytes); err != nil {
+		return err
+	}
+	if l.bytesPerChar, err = params.Int("bytesPerChar", l.bytesPerChar); err != nil {
+		return err
+	}
+	return nil
 }
 
 func (l *IndexLengthLinter) Enter(in ast.Node) (ast.Node, bool) {
@@ -69,23 +102,19 @@ func (l *IndexLengthLinter) Enter(in ast.Node) (ast.Node, bool) {
 				continue
 			}
 		}
-		if len(c.Keys) == 1 && length*4 > 767 {
-			l.err = NewLintError(l.script, l.text, "索引长度错误: 单列索引长度不得 > 767",
+		if len(c.Keys) == 1 && length*l.bytesPerChar > l.singleMaxBytes {
+			l.err = NewLintError(l.script, l.text, fmt.Sprintf("索引长度错误: 单列索引长度不得 > %d", l.singleMaxBytes),
 				func(line []byte) bool {
 					firstLenS := strconv.FormatInt(int64(firstLen), 10)
 					return bytes.Contains(bytes.ToLower(line), bytes.ToLower([]byte(firstLenS)))
 				})
 			return in, true
 		}
-		if len(c.Keys) > 1 && length*4 > 3072 {
-			_, num := getLintLine(l.script.Data(), []byte(l.text), func(_ []byte) bool {
-				return false
-			})
-			l.err = LintError{
-				Stmt:   l.text,
-				Lint:   "索引长度错误: 联合索引长度不得 > 3072",
-				LintNo: num,
-			}
+		if len(c.Keys) > 1 && length*l.bytesPerChar > l.compositeMaxBytes {
+			l.err = NewLintError(l.script, l.text, fmt.Sprintf("索引长度错误: 联合索引长度不得 > %d", l.compositeMaxBytes),
+				func(_ []byte) bool {
+					return false
+				})
 			return in, true
 		}
 	}
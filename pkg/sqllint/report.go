@@ -0,0 +1,205 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sqllint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// SyntaxErrorRule 脚本无法解析时使用的规则名称
+const SyntaxErrorRule = "SyntaxError"
+
+// Finding 一条 lint 结果, 行号、列号从 1 开始, 为 0 时表示无法定位
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"` // 语法错误为解析器给出的列号; 规则检查只能定位到行, 为该行第一个非空白字符的位置
+	Message  string   `json:"message"`
+	Table    string   `json:"table,omitempty"`
+}
+
+func newFinding(rule configuredRule, scriptName string, err error) Finding {
+	f := Finding{
+		Rule:     rule.name,
+		Severity: rule.severity,
+		File:     scriptName,
+		Message:  err.Error(),
+	}
+	if lintError, ok := err.(LintError); ok {
+		f.Message = lintError.Lint
+		f.Table = lintError.StmtName()
+		if lintError.LintNo > 0 {
+			f.Line = lintError.LintNo
+			f.Column = lintError.ColNo
+		}
+	}
+	return f
+}
+
+// pingcap parser 的错误信息, e.g. line 1 column 15 near "..."
+var syntaxErrorPosRegexp = regexp.MustCompile(`line (\d+) column (\d+)`)
+
+func newSyntaxErrorFinding(scriptName string, err error) Finding {
+	f := Finding{
+		Rule:     SyntaxErrorRule,
+		Severity: SeverityError,
+		File:     scriptName,
+		Message:  err.Error(),
+	}
+	if m := syntaxErrorPosRegexp.FindStringSubmatch(err.Error()); m != nil {
+		f.Line, _ = strconv.Atoi(m[1])
+		f.Column, _ = strconv.Atoi(m[2])
+	}
+	return f
+}
+
+// Findings 返回全部 lint 结果, 按文件、行号、列号排序
+func (r *Linter) Findings() []Finding {
+	findings := make([]Finding, len(r.findings))
+	copy(findings, r.findings)
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+	return findings
+}
+
+// WriteText 以 file:line:column: severity: message [rule] 格式输出
+func WriteText(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: %s: %s [%s]\n", f.File, f.Line, f.Column, f.Severity, f.Message, f.Rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON 以 JSON 数组格式输出
+func WriteJSON(w io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(findings)
+}
+
+// sarif 2.1.0 中用到的部分结构
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID string `json:"id"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+)
+
+var sarifLevels = map[Severity]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "note",
+}
+
+// WriteSARIF 以 SARIF 2.1.0 格式输出, 可上传至代码扫描平台
+func WriteSARIF(w io.Writer, findings []Finding) error {
+	ruleSet := make(map[string]bool)
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		ruleSet[f.Rule] = true
+		location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(f.File)},
+		}}
+		if f.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+		}
+		results = append(results, sarifResult{
+			RuleID:    f.Rule,
+			Level:     sarifLevels[f.Severity],
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{location},
+		})
+	}
+	rules := make([]sarifRule, 0, len(ruleSet))
+	for id := range ruleSet {
+		rules = append(rules, sarifRule{ID: id})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "erda-sqllint",
+				InformationURI: "https://github.com/erda-project/erda",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sqllint_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/erda-project/erda/pkg/sqllint"
+)
+
+var reportFindings = []sqllint.Finding{
+	{Rule: "VarcharLengthLinter", Severity: sqllint.SeverityWarning, File: "a.sql", Line: 4, Column: 2, Message: "too long"},
+	{Rule: "SyntaxError", Severity: sqllint.SeverityError, File: "b.sql", Message: "bad"},
+}
+
+func TestWriteText(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	if err := sqllint.WriteText(buf, reportFindings); err != nil {
+		t.Fatal(err)
+	}
+	expected := "a.sql:4:2: warning: too long [VarcharLengthLinter]\nb.sql:0:0: error: bad [SyntaxError]\n"
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	if err := sqllint.WriteJSON(buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf("empty findings should be written as [], got %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := sqllint.WriteJSON(buf, reportFindings); err != nil {
+		t.Fatal(err)
+	}
+	var findings []sqllint.Finding
+	if err := json.Unmarshal(buf.Bytes(), &findings); err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 || findings[0] != reportFindings[0] {
+		t.Errorf("unexpected findings: %+v", findings)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	if err := sqllint.WriteSARIF(buf, reportFindings); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region *struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected sarif log: %s", buf.String())
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[0].ID != "SyntaxError" {
+		t.Errorf("unexpected rules: %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("unexpected results: %+v", run.Results)
+	}
+	first := run.Results[0]
+	if first.Level != "warning" || first.Locations[0].PhysicalLocation.ArtifactLocation.URI != "a.sql" {
+		t.Errorf("unexpected result: %+v", first)
+	}
+	if region := first.Locations[0].PhysicalLocation.Region; region == nil || region.StartLine != 4 || region.StartColumn != 2 {
+		t.Errorf("unexpected region: %+v", region)
+	}
+	if run.Results[1].Locations[0].PhysicalLocation.Region != nil {
+		t.Error("finding without line should have no region")
+	}
+}
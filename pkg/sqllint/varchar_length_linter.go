@@ -15,19 +15,34 @@ package sqllint
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 
 	"github.com/pingcap/parser/ast"
 )
 
 type VarcharLengthLinter struct {
-	script Script
-	err    error
-	text   string
+	script    Script
+	err       error
+	text      string
+	maxLength int
 }
 
 func NewVarcharLengthLinter(script Script) Rule {
-	return &VarcharLengthLinter{script: script}
+	return &VarcharLengthLinter{script: script, maxLength: 5000}
+}
+
+func (l *VarcharLengthLinter) Params() RuleParams {
+	return RuleParams{"maxLength": l.maxLength}
+}
+
+func (l *VarcharLengthLinter) Configure(params RuleParams) error {
+	maxLength, err := params.Int("maxLength", l.maxLength)
+	if err != nil {
+		return err
+	}
+	l.maxLength = maxLength
+	return nil
 }
 
 func (l *VarcharLengthLinter) Enter(in ast.Node) (ast.Node, bool) {
@@ -42,8 +57,8 @@ func (l *VarcharLengthLinter) Enter(in ast.Node) (ast.Node, bool) {
 
 	if col.Tp != nil &&
 		strings.Contains(strings.ToLower(col.Tp.String()), "varchar") &&
-		col.Tp.Flen > 5000 {
-		l.err = NewLintError(l.script, l.text, "字段类型错误: varchar 类型长度不可 > 5000",
+		col.Tp.Flen > l.maxLength {
+		l.err = NewLintError(l.script, l.text, fmt.Sprintf("字段类型错误: varchar 类型长度不可 > %d", l.maxLength),
 			func(line []byte) bool {
 				return bytes.Contains(bytes.ToLower(line), []byte(col.Tp.String()))
 
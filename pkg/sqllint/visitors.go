@@ -19,44 +19,69 @@ import (
 )
 
 type Linter struct {
-	stop    bool
-	layer   int
-	errs    map[string][]error
-	reports map[string]map[string][]string
-	linters []NewRule
+	stop     bool
+	layer    int
+	errs     map[string][]error
+	reports  map[string]map[string][]string
+	linters  []configuredRule
+	findings []Finding
 }
 
 func New(rules ...NewRule) *Linter {
-	r := &Linter{
+	r := newLinter()
+	for _, l := range rules {
+		r.linters = append(r.linters, configuredRule{name: ruleName(l, "CustomLinter"), new: l, severity: SeverityError})
+	}
+	return r
+}
+
+// NewFromConfig 按配置生成 Linter, cfg 为 nil 时启用全部规则
+func NewFromConfig(cfg *Config) (*Linter, error) {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	rules, err := cfg.build()
+	if err != nil {
+		return nil, err
+	}
+	r := newLinter()
+	r.linters = rules
+	return r, nil
+}
+
+func newLinter() *Linter {
+	return &Linter{
 		stop:    false,
 		layer:   0,
 		errs:    make(map[string][]error, 0),
 		reports: make(map[string]map[string][]string, 0),
 		linters: nil,
 	}
-	for _, l := range rules {
-		r.linters = append(r.linters, l)
-	}
-	return r
 }
 
 func (r *Linter) Input(scriptData []byte, scriptName string) error {
 	p := parser.New()
 	nodes, warns, err := p.Parse(string(scriptData), "", "")
 	if err != nil {
+		r.findings = append(r.findings, newSyntaxErrorFinding(scriptName, err))
 		return err
 	}
 
 	script := NewScript(scriptName, scriptData)
 	r.reports[scriptName] = make(map[string][]string, 0)
+	ignores := parseIgnores(scriptData)
 
 	var errs []error
 	for _, node := range nodes {
-		for _, f := range r.linters {
-			linter := f(script)
+		for _, rule := range r.linters {
+			if ignores.ignored(rule.name) {
+				continue
+			}
+			linter := rule.instantiate(script)
 			_, _ = node.Accept(linter)
 			if err := linter.Error(); err != nil {
 				errs = append(errs, err)
+				r.findings = append(r.findings, newFinding(rule, scriptName, err))
 				lintError, ok := err.(LintError)
 				if !ok {
 					continue